@@ -0,0 +1,370 @@
+// Command claude-daemon runs Claude Code CLI sessions for clients that
+// connect to its Unix socket, so short-lived programs that create a client
+// per invocation skip the CLI's startup. Clients set Options.Daemon to the
+// socket path.
+//
+// Each connection starts with a request line naming the CLI's arguments,
+// environment and working directory. The daemon refuses requests with
+// flags that make the CLI run programs of the client's choosing, such as
+// --plugin-dir or stdio MCP servers, and environment variables other than
+// the CLI's, the model provider's and proxies', plus those matching the
+// -allow-env patterns. Otherwise it answers with a reply line,
+// then relays the connection to the CLI's stdin and stdout until either
+// side closes, killing the CLI when the client disconnects. After serving a
+// request it starts a spare CLI for the same request, handed to the next
+// client asking for it; spares are kept for the -warm most recently used
+// requests. The CLIs' stderr goes to the daemon's.
+//
+// A connection whose request sets multiplex instead carries many sessions,
+// so a server running hundreds of conversations holds one connection
+// rather than one per conversation. Each line is then a frame naming its
+// session: the client opens a session with a request, checked and served
+// like a connection's, writes its stdin and closes it, and the daemon
+// replies, relays the CLI's stdout and reports when it exits. Clients use
+// a Multiplexer.
+//
+// The socket is created with mode 0600, in a directory only the daemon's
+// user can enter and then moved into place, so no one else can connect in
+// between: anyone who can connect runs the CLI as the daemon's user.
+//
+//	claude-daemon -listen /run/user/1000/claude.sock -warm 4
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
+)
+
+// maxRequestLine bounds the request line of a connection.
+const maxRequestLine = 1 << 20
+
+func main() {
+	listen := flag.String("listen", "", "Unix socket path to listen on")
+	executable := flag.String("claude", "claude", "Claude Code CLI to run")
+	warm := flag.Int("warm", 4, "distinct requests to keep a spare CLI for; 0 disables spares")
+	allowEnv := flag.String("allow-env", "", "comma-separated patterns of further environment variables clients may set")
+	flag.Parse()
+
+	if *listen == "" {
+		log.Fatal("usage: claude-daemon -listen SOCKET [-claude PATH] [-warm N] [-allow-env PATTERNS]")
+	}
+	path, err := exec.LookPath(*executable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	removeStaleSocket(*listen)
+	listener, err := listenPrivate(*listen)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = os.Remove(*listen) }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	d := &daemon{
+		executable: path,
+		maxWarm:    *warm,
+		allowEnv:   defaultEnv,
+		spares:     make(map[string]*cli),
+	}
+	if *allowEnv != "" {
+		d.allowEnv = append(d.allowEnv, strings.Split(*allowEnv, ",")...)
+	}
+	defer d.close()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Print(err)
+			}
+
+			return
+		}
+		go d.serve(conn)
+	}
+}
+
+// listenPrivate listens on a Unix socket at path that only the daemon's
+// user can connect to. The socket is set up in a new directory only that
+// user can enter and linked into place, failing if path exists.
+func listenPrivate(path string) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".claude-daemon-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	private := filepath.Join(dir, "sock")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: private, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	// The socket is removed from its final path instead
+	listener.SetUnlinkOnClose(false)
+	if err = os.Chmod(private, 0o600); err == nil {
+		err = os.Link(private, path)
+	}
+	if err != nil {
+		_ = listener.Close()
+
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// removeStaleSocket removes a socket left by a daemon that didn't exit
+// cleanly; a live daemon's socket is left for Listen to report.
+func removeStaleSocket(path string) {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Type() != os.ModeSocket {
+		return
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		_ = conn.Close()
+
+		return
+	}
+	_ = os.Remove(path)
+}
+
+// cli is a running CLI process.
+type cli struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	exited chan struct{}
+}
+
+// running reports whether the process is still running.
+func (c *cli) running() bool {
+	select {
+	case <-c.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// kill stops the process.
+func (c *cli) kill() {
+	_ = c.cmd.Process.Kill()
+}
+
+// daemon starts CLIs and keeps spares.
+type daemon struct {
+	executable string
+	maxWarm    int
+	allowEnv   []string // Patterns of the variables clients may set
+
+	mu     sync.Mutex
+	spares map[string]*cli // By request key
+	order  []string        // Keys of spares, least recently used first
+	closed bool
+}
+
+// serve runs the CLI a connection asks for.
+func (d *daemon) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	reader := bufio.NewReader(io.LimitReader(conn, maxRequestLine))
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return
+	}
+	var request transport.DaemonRequest
+	if err := json.Unmarshal(line, &request); err != nil {
+		reply(conn, transport.DaemonReply{Error: "invalid request: " + err.Error()})
+
+		return
+	}
+	if request.Multiplex {
+		if reply(conn, transport.DaemonReply{}) {
+			d.serveMux(conn, io.MultiReader(readBuffered(reader), conn))
+		}
+
+		return
+	}
+	if err := checkRequest(&request, d.allowEnv); err != nil {
+		reply(conn, transport.DaemonReply{Error: "request refused: " + err.Error()})
+
+		return
+	}
+	// Re-encoded, so equal requests share a key however they were written
+	key, _ := json.Marshal(&request)
+
+	process, warm := d.take(string(key)), true
+	if process == nil {
+		warm = false
+		if process, err = d.start(&request); err != nil {
+			reply(conn, transport.DaemonReply{Error: err.Error()})
+
+			return
+		}
+	}
+	go d.replenish(string(key), &request)
+
+	if !reply(conn, transport.DaemonReply{Warm: warm}) {
+		process.kill()
+		<-process.exited
+
+		return
+	}
+
+	// Input already buffered with the request line comes first
+	input := io.MultiReader(readBuffered(reader), conn)
+	go func() {
+		_, _ = io.Copy(process.stdin, input)
+		process.kill()
+	}()
+	_, _ = io.Copy(conn, process.stdout)
+	process.kill()
+	<-process.exited
+	_ = process.stdout.Close()
+}
+
+// reply writes the reply line, reporting whether it was sent.
+func reply(conn net.Conn, r transport.DaemonReply) bool {
+	line, _ := json.Marshal(r)
+	_, err := conn.Write(append(line, '\n'))
+
+	return err == nil
+}
+
+// readBuffered returns a reader of the data reader holds.
+func readBuffered(reader *bufio.Reader) io.Reader {
+	data, _ := reader.Peek(reader.Buffered())
+
+	return bytes.NewReader(slices.Clone(data))
+}
+
+// start runs a CLI for request.
+func (d *daemon) start(request *transport.DaemonRequest) (*cli, error) {
+	cmd := exec.Command(d.executable, request.Args...)
+	cmd.Dir = request.Cwd
+	cmd.Env = append(os.Environ(), request.Env...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	// Not StdoutPipe, which Wait closes before the output is read
+	stdout, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdout = stdoutWriter
+
+	err = cmd.Start()
+	_ = stdoutWriter.Close()
+	if err != nil {
+		_ = stdout.Close()
+
+		return nil, err
+	}
+
+	process := &cli{cmd: cmd, stdin: stdin, stdout: stdout, exited: make(chan struct{})}
+	go func() {
+		_ = cmd.Wait()
+		close(process.exited)
+	}()
+
+	return process, nil
+}
+
+// take returns the spare for key if it is still running, or nil.
+func (d *daemon) take(key string) *cli {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	process := d.spares[key]
+	if process == nil {
+		return nil
+	}
+	delete(d.spares, key)
+	d.order = slices.DeleteFunc(d.order, func(k string) bool { return k == key })
+	if !process.running() {
+		_ = process.stdout.Close()
+
+		return nil
+	}
+
+	return process
+}
+
+// replenish starts a spare for key, evicting the least recently used
+// spares beyond maxWarm.
+func (d *daemon) replenish(key string, request *transport.DaemonRequest) {
+	if d.maxWarm <= 0 {
+		return
+	}
+	process, err := d.start(request)
+	if err != nil {
+		log.Printf("starting spare: %v", err)
+
+		return
+	}
+
+	d.mu.Lock()
+	var evicted []*cli
+	if d.closed || d.spares[key] != nil {
+		evicted = append(evicted, process)
+	} else {
+		d.spares[key] = process
+		d.order = append(d.order, key)
+		for len(d.order) > d.maxWarm {
+			evicted = append(evicted, d.spares[d.order[0]])
+			delete(d.spares, d.order[0])
+			d.order = d.order[1:]
+		}
+	}
+	d.mu.Unlock()
+
+	for _, spare := range evicted {
+		discard(spare)
+	}
+}
+
+// close stops the spares.
+func (d *daemon) close() {
+	d.mu.Lock()
+	d.closed = true
+	spares := d.spares
+	d.spares = nil
+	d.order = nil
+	d.mu.Unlock()
+
+	for _, spare := range spares {
+		discard(spare)
+	}
+}
+
+// discard stops an unused spare.
+func discard(process *cli) {
+	process.kill()
+	<-process.exited
+	_ = process.stdout.Close()
+}
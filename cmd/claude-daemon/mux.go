@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
+)
+
+// muxChunkSize bounds the CLI output sent in one frame.
+const muxChunkSize = 32 << 10
+
+// muxConn is a multiplexed connection and the CLIs of its sessions.
+type muxConn struct {
+	daemon *daemon
+	conn   net.Conn
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	sessions map[string]*cli // By the client's session name
+	pumps    sync.WaitGroup
+}
+
+// serveMux runs the sessions a multiplexed connection opens, reading its
+// frames from input, and stops their CLIs once the client disconnects.
+func (d *daemon) serveMux(conn net.Conn, input io.Reader) {
+	m := &muxConn{daemon: d, conn: conn, sessions: make(map[string]*cli)}
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxRequestLine)
+	for scanner.Scan() {
+		var frame transport.DaemonFrame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			log.Printf("invalid frame: %v", err)
+
+			break
+		}
+		switch {
+		case frame.Open != nil:
+			m.open(frame.Session, frame.Open)
+		case frame.Close:
+			m.stop(frame.Session)
+		case len(frame.Data) > 0:
+			m.input(frame.Session, frame.Data)
+		}
+	}
+
+	m.mu.Lock()
+	for _, process := range m.sessions {
+		process.kill()
+	}
+	m.mu.Unlock()
+	m.pumps.Wait()
+}
+
+// open starts the CLI of a session, as serve does for a connection.
+func (m *muxConn) open(session string, request *transport.DaemonRequest) {
+	m.mu.Lock()
+	_, exists := m.sessions[session]
+	m.mu.Unlock()
+	if exists {
+		m.write(&transport.DaemonFrame{Session: session, Reply: &transport.DaemonReply{Error: "session already open"}})
+
+		return
+	}
+
+	err := checkRequest(request, m.daemon.allowEnv)
+	if err == nil && request.Multiplex {
+		err = errors.New("sessions can't be multiplexed")
+	}
+	if err != nil {
+		m.write(&transport.DaemonFrame{Session: session, Reply: &transport.DaemonReply{Error: "request refused: " + err.Error()}})
+
+		return
+	}
+	key, _ := json.Marshal(request)
+
+	process, warm := m.daemon.take(string(key)), true
+	if process == nil {
+		warm = false
+		if process, err = m.daemon.start(request); err != nil {
+			m.write(&transport.DaemonFrame{Session: session, Reply: &transport.DaemonReply{Error: err.Error()}})
+
+			return
+		}
+	}
+	go m.daemon.replenish(string(key), request)
+
+	m.mu.Lock()
+	m.sessions[session] = process
+	m.mu.Unlock()
+
+	m.write(&transport.DaemonFrame{Session: session, Reply: &transport.DaemonReply{Warm: warm}})
+	m.pumps.Add(1)
+	go m.pump(session, process)
+}
+
+// pump relays a session's CLI output until it ends, then reports the end.
+func (m *muxConn) pump(session string, process *cli) {
+	defer m.pumps.Done()
+
+	buf := make([]byte, muxChunkSize)
+	for {
+		n, err := process.stdout.Read(buf)
+		if n > 0 {
+			m.write(&transport.DaemonFrame{Session: session, Data: buf[:n]})
+		}
+		if err != nil {
+			break
+		}
+	}
+	process.kill()
+	<-process.exited
+	_ = process.stdout.Close()
+
+	m.mu.Lock()
+	delete(m.sessions, session)
+	m.mu.Unlock()
+	m.write(&transport.DaemonFrame{Session: session, Close: true})
+}
+
+// input writes data to a session's CLI. A CLI that stops reading holds
+// up the connection's other sessions.
+func (m *muxConn) input(session string, data []byte) {
+	m.mu.Lock()
+	process := m.sessions[session]
+	m.mu.Unlock()
+	if process != nil {
+		_, _ = process.stdin.Write(data)
+	}
+}
+
+// stop kills a session's CLI; its pump then reports the end.
+func (m *muxConn) stop(session string) {
+	m.mu.Lock()
+	process := m.sessions[session]
+	m.mu.Unlock()
+	if process != nil {
+		process.kill()
+	}
+}
+
+// write sends a frame, dropping it if the client is gone.
+func (m *muxConn) write(frame *transport.DaemonFrame) {
+	line, _ := json.Marshal(frame)
+
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	_, _ = m.conn.Write(append(line, '\n'))
+}
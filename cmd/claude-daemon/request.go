@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
+)
+
+// cliFlags are the CLI flags clients may pass, and whether each takes a
+// value. Flags that make the CLI run programs of the client's choosing,
+// such as --plugin-dir and --settings, are left out.
+var cliFlags = map[string]bool{
+	"--print":                    false,
+	"--verbose":                  false,
+	"--continue":                 false,
+	"--fork-session":             false,
+	"--strict-mcp-config":        false,
+	"--include-partial-messages": false,
+	"--output-format":            true,
+	"--input-format":             true,
+	"--model":                    true,
+	"--max-turns":                true,
+	"--max-thinking-tokens":      true,
+	"--json-schema":              true,
+	"--resume":                   true,
+	"--permission-mode":          true,
+	"--permission-prompt-tool":   true,
+	"--add-dir":                  true,
+	"--allowed-tools":            true,
+	"--disallowed-tools":         true,
+	"--agents":                   true,
+	"--mcp-config":               true,
+}
+
+// defaultEnv are the patterns of the environment variables clients may
+// set: the CLI's own settings, model provider selection and credentials,
+// and proxies.
+var defaultEnv = []string{
+	"ANTHROPIC_*",
+	"CLAUDE_CODE_*",
+	"AWS_REGION",
+	"AWS_ACCESS_KEY_ID",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"CLOUD_ML_REGION",
+	"HTTP_PROXY",
+	"HTTPS_PROXY",
+	"NO_PROXY",
+	"http_proxy",
+	"https_proxy",
+	"no_proxy",
+}
+
+// checkRequest checks that request only sets the CLI flags of cliFlags and
+// environment variables matching allowEnv.
+func checkRequest(request *transport.DaemonRequest, allowEnv []string) error {
+	for i := 0; i < len(request.Args); i++ {
+		flag, value, inline := strings.Cut(request.Args[i], "=")
+		takesValue, ok := cliFlags[flag]
+		if !ok {
+			return fmt.Errorf("argument %s is not allowed", flag)
+		}
+		if !takesValue {
+			if inline {
+				return fmt.Errorf("flag %s takes no value", flag)
+			}
+
+			continue
+		}
+		if !inline {
+			if i++; i == len(request.Args) {
+				return fmt.Errorf("flag %s needs a value", flag)
+			}
+			value = request.Args[i]
+		}
+		if err := checkFlag(flag, value); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range request.Env {
+		name, _, _ := strings.Cut(entry, "=")
+		if !matchesAny(allowEnv, name) {
+			return fmt.Errorf("environment variable %s is not allowed", name)
+		}
+	}
+
+	if request.Cwd != "" && !filepath.IsAbs(request.Cwd) {
+		return fmt.Errorf("working directory %s is not absolute", request.Cwd)
+	}
+
+	return nil
+}
+
+// checkFlag checks the value of an allowed flag.
+func checkFlag(flag, value string) error {
+	switch flag {
+	case "--output-format", "--input-format":
+		if value != "stream-json" {
+			return fmt.Errorf("%s must be stream-json", flag)
+		}
+	case "--permission-mode":
+		if value == "bypassPermissions" {
+			return errors.New("permission mode bypassPermissions is not allowed")
+		}
+	case "--mcp-config":
+		// Servers the CLI would start itself run programs
+		var config struct {
+			McpServers map[string]struct {
+				Command string `json:"command"`
+			} `json:"mcpServers"`
+		}
+		if err := json.Unmarshal([]byte(value), &config); err != nil {
+			return fmt.Errorf("invalid --mcp-config: %w", err)
+		}
+		for name, server := range config.McpServers {
+			if server.Command != "" {
+				return fmt.Errorf("MCP server %s runs a command, which is not allowed", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchesAny reports whether name matches one of patterns.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
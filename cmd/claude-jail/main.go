@@ -0,0 +1,435 @@
+//go:build linux
+
+// Command claude-jail runs a program as an unprivileged user in private
+// mount and PID namespaces whose filesystem holds only the given paths.
+// The SDK starts the Claude Code CLI through it when Options.Jail is set.
+//
+// The new root is a tmpfs mounted on -root. /proc is a fresh procfs
+// showing only the jail's processes, /dev holds only the null, zero,
+// full, random, urandom and tty devices, /tmp is a fresh tmpfs, -ro paths
+// are bound read-only and -rw paths and -workdir read-write, all at their
+// original locations. claude-jail then pivots into the new root, detaches
+// the old one, drops every capability from the bounding set, forbids
+// gaining privileges through setuid binaries, and runs the program in
+// -workdir as -uid, -gid and -groups, which are required and may not be
+// root. It stays in the jail as PID 1, reaping orphans and forwarding
+// signals, and exits with the program's status.
+//
+// claude-jail needs CAP_SYS_ADMIN, e.g. running as root, and exits with
+// status 125 if it cannot set up the jail.
+//
+//	claude-jail -root /tmp/jail -workdir /srv/project -ro /usr -ro /etc -uid 1000 -gid 1000 -- claude --print
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	// exitSetupFailed is the exit status when the jail cannot be set up.
+	exitSetupFailed = 125
+
+	// oldRoot is where the host root is put by pivot_root before it is
+	// detached.
+	oldRoot = ".old-root"
+
+	// prctl options, from linux/prctl.h.
+	prSetPDeathSig  = 1
+	prCapBSetDrop   = 24
+	prSetNoNewPrivs = 38
+	prCapAmbient    = 47
+	prCapAmbientAll = 4 // PR_CAP_AMBIENT_CLEAR_ALL
+)
+
+// preservedFlags are the mount flags of a source kept when remounting its
+// bind read-only; dropping them is not allowed.
+const preservedFlags = syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC |
+	syscall.MS_NOATIME | syscall.MS_NODIRATIME | syscall.MS_RELATIME
+
+// devices are the /dev entries bound into the jail, if they exist.
+var devices = []string{"null", "zero", "full", "random", "urandom", "tty"}
+
+// forwardedSignals are passed on to the jailed program.
+var forwardedSignals = []os.Signal{
+	syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT, syscall.SIGUSR1, syscall.SIGUSR2,
+}
+
+// pathList collects repeated path flags.
+type pathList []string
+
+func (p *pathList) String() string { return strings.Join(*p, ":") }
+
+func (p *pathList) Set(value string) error {
+	*p = append(*p, value)
+
+	return nil
+}
+
+// bind is a path to mount into the jail.
+type bind struct {
+	path     string
+	readOnly bool
+}
+
+func main() {
+	var readOnly, writable pathList
+	flag.Var(&readOnly, "ro", "path to bind read-only (repeatable)")
+	flag.Var(&writable, "rw", "path to bind read-write (repeatable)")
+	root := flag.String("root", "", "empty directory to build the jail's root on")
+	workdir := flag.String("workdir", "", "working directory, bound read-write")
+	uid := flag.Int("uid", -1, "user ID to run as (required, not 0)")
+	gid := flag.Int("gid", -1, "group ID to run as (required)")
+	groups := flag.String("groups", "", "comma-separated supplementary group IDs")
+	initStage := flag.Bool("init", false, "internal: set up the jail as PID 1 of its namespaces")
+	flag.Parse()
+
+	if *root == "" || *workdir == "" || flag.NArg() == 0 {
+		fail(errors.New("usage: claude-jail -root DIR -workdir DIR -uid UID -gid GID " +
+			"[-ro PATH]... [-rw PATH]... -- PROGRAM [ARG]..."))
+	}
+	if *uid <= 0 || *gid < 0 {
+		fail(errors.New("-uid and -gid are required, and the user may not be root"))
+	}
+
+	if !*initStage {
+		os.Exit(spawnInit())
+	}
+
+	binds := make([]bind, 0, len(readOnly)+len(writable)+1)
+	for _, path := range readOnly {
+		binds = append(binds, bind{path: path, readOnly: true})
+	}
+	for _, path := range writable {
+		binds = append(binds, bind{path: path})
+	}
+	binds = append(binds, bind{path: *workdir})
+
+	// Mounts and credentials are set per thread before starting the
+	// program, so keep to one.
+	runtime.LockOSThread()
+	if err := buildRoot(*root, binds); err != nil {
+		fail(err)
+	}
+	if err := enter(*root, *workdir); err != nil {
+		fail(err)
+	}
+	if err := dropPrivileges(*uid, *gid, *groups); err != nil {
+		fail(err)
+	}
+
+	os.Exit(supervise(flag.Args()))
+}
+
+// fail reports a setup error and exits.
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "claude-jail: %v\n", err)
+	os.Exit(exitSetupFailed)
+}
+
+// spawnInit reruns claude-jail with -init in new mount and PID namespaces,
+// where it sets up the jail as PID 1, and returns its exit status. The
+// child is killed if claude-jail dies, and signals are forwarded to it.
+func spawnInit() int {
+	// The death signal fires when the thread starting the child exits.
+	runtime.LockOSThread()
+
+	cmd := exec.Command("/proc/self/exe", append([]string{"-init"}, os.Args[1:]...)...)
+	cmd.Args[0] = os.Args[0]
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWNS | syscall.CLONE_NEWPID,
+		Pdeathsig:  syscall.SIGKILL,
+	}
+
+	signals := make(chan os.Signal, len(forwardedSignals))
+	signal.Notify(signals, forwardedSignals...)
+	if err := cmd.Start(); err != nil {
+		fail(fmt.Errorf("cannot create namespaces: %w", err))
+	}
+	go func() {
+		for sig := range signals {
+			_ = cmd.Process.Signal(sig)
+		}
+	}()
+
+	_ = cmd.Wait()
+
+	return exitStatus(cmd.ProcessState.Sys().(syscall.WaitStatus))
+}
+
+// buildRoot mounts the jail's filesystem on root. It runs in the new
+// mount and PID namespaces, so the procfs it mounts shows only them.
+func buildRoot(root string, binds []bind) error {
+	// Keep our mounts from propagating back to the host.
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("cannot make mounts private: %w", err)
+	}
+	if err := syscall.Mount("tmpfs", root, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=0755"); err != nil {
+		return fmt.Errorf("cannot mount root: %w", err)
+	}
+
+	proc := filepath.Join(root, "proc")
+	if err := os.Mkdir(proc, 0o555); err != nil {
+		return err
+	}
+	if err := syscall.Mount("proc", proc, "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("cannot mount /proc: %w", err)
+	}
+	if err := buildDev(filepath.Join(root, "dev")); err != nil {
+		return err
+	}
+	tmp := filepath.Join(root, "tmp")
+	if err := os.Mkdir(tmp, 0o755); err != nil {
+		return err
+	}
+	if err := syscall.Mount("tmpfs", tmp, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=1777"); err != nil {
+		return fmt.Errorf("cannot mount /tmp: %w", err)
+	}
+
+	// Parents before children, so nested binds are not hidden.
+	sort.SliceStable(binds, func(i, j int) bool { return binds[i].path < binds[j].path })
+	for _, b := range binds {
+		if err := bindPath(root, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildDev mounts a tmpfs on dev holding only the devices the CLI needs,
+// bound from the host, and the standard links into /proc.
+func buildDev(dev string) error {
+	if err := os.Mkdir(dev, 0o755); err != nil {
+		return err
+	}
+	if err := syscall.Mount("tmpfs", dev, "tmpfs", syscall.MS_NOSUID|syscall.MS_NOEXEC, "mode=0755"); err != nil {
+		return fmt.Errorf("cannot mount /dev: %w", err)
+	}
+
+	for _, name := range devices {
+		source := filepath.Join("/dev", name)
+		if _, err := os.Stat(source); err != nil {
+			continue
+		}
+		target := filepath.Join(dev, name)
+		if err := createFile(target); err != nil {
+			return err
+		}
+		if err := syscall.Mount(source, target, "", syscall.MS_BIND, ""); err != nil {
+			return fmt.Errorf("cannot bind %s: %w", source, err)
+		}
+	}
+
+	for name, target := range map[string]string{
+		"fd":     "/proc/self/fd",
+		"stdin":  "/proc/self/fd/0",
+		"stdout": "/proc/self/fd/1",
+		"stderr": "/proc/self/fd/2",
+	} {
+		if err := os.Symlink(target, filepath.Join(dev, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bindPath binds b.path to the same location under root.
+func bindPath(root string, b bind) error {
+	source, err := filepath.Abs(b.path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(root, source)
+	if info.IsDir() {
+		err = os.MkdirAll(target, 0o755)
+	} else {
+		err = createFile(target)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot create mount point for %s: %w", source, err)
+	}
+
+	if err := syscall.Mount(source, target, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("cannot bind %s: %w", source, err)
+	}
+	if !b.readOnly {
+		return nil
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(source, &stat); err != nil {
+		return err
+	}
+	flags := uintptr(stat.Flags)&preservedFlags | syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY
+	if err := syscall.Mount("", target, "", flags, ""); err != nil {
+		return fmt.Errorf("cannot make %s read-only: %w", source, err)
+	}
+
+	return nil
+}
+
+// createFile creates an empty file to bind a file onto.
+func createFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// enter pivots the root to root, detaching the host's, and changes the
+// directory to workdir. Unlike chroot, nothing of the host's filesystem
+// stays reachable.
+func enter(root, workdir string) error {
+	old := filepath.Join(root, oldRoot)
+	if err := os.Mkdir(old, 0o700); err != nil {
+		return err
+	}
+	if err := syscall.PivotRoot(root, old); err != nil {
+		return fmt.Errorf("cannot pivot root: %w", err)
+	}
+	if err := os.Chdir("/"); err != nil {
+		return err
+	}
+	if err := syscall.Unmount("/"+oldRoot, syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("cannot detach the host root: %w", err)
+	}
+	if err := os.Remove("/" + oldRoot); err != nil {
+		return err
+	}
+
+	dir, err := filepath.Abs(workdir)
+	if err != nil {
+		return err
+	}
+
+	return os.Chdir(dir)
+}
+
+// dropPrivileges gives up every capability for good and switches to the
+// given group, supplementary groups and user, in that order, since
+// changing the user gives up the right to change groups. Switching from
+// root to another user clears the remaining capabilities.
+func dropPrivileges(uid, gid int, groups string) error {
+	var ids []int
+	for _, field := range strings.FieldsFunc(groups, func(r rune) bool { return r == ',' }) {
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return fmt.Errorf("invalid group %q: %w", field, err)
+		}
+		ids = append(ids, id)
+	}
+
+	// Empty the bounding set, so no program in the jail can gain a
+	// capability, and clear the ambient set, which survives exec.
+	for capability := uintptr(0); ; capability++ {
+		if err := prctl(prCapBSetDrop, capability, 0); err != nil {
+			if errors.Is(err, syscall.EINVAL) {
+				break
+			}
+
+			return fmt.Errorf("cannot drop capability %d: %w", capability, err)
+		}
+	}
+	if err := prctl(prCapAmbient, prCapAmbientAll, 0); err != nil && !errors.Is(err, syscall.EINVAL) {
+		return fmt.Errorf("cannot clear ambient capabilities: %w", err)
+	}
+	if err := prctl(prSetNoNewPrivs, 1, 0); err != nil {
+		return fmt.Errorf("cannot forbid new privileges: %w", err)
+	}
+
+	if err := syscall.Setgroups(ids); err != nil {
+		return fmt.Errorf("cannot set groups: %w", err)
+	}
+	if err := syscall.Setgid(gid); err != nil {
+		return fmt.Errorf("cannot set group %d: %w", gid, err)
+	}
+	if err := syscall.Setuid(uid); err != nil {
+		return fmt.Errorf("cannot set user %d: %w", uid, err)
+	}
+
+	// Changing credentials cleared the parent death signal.
+	if err := prctl(prSetPDeathSig, uintptr(syscall.SIGKILL), 0); err != nil {
+		return fmt.Errorf("cannot set the parent death signal: %w", err)
+	}
+
+	return nil
+}
+
+// prctl calls prctl(2) with up to two arguments.
+func prctl(option, arg2, arg3 uintptr) error {
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, option, arg2, arg3, 0, 0, 0); errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// supervise runs args as PID 1 of the jail: it forwards signals to the
+// program, reaps the orphans reparented to it, and returns the program's
+// exit status once it exits, which ends every process left in the jail.
+func supervise(args []string) int {
+	program, err := exec.LookPath(args[0])
+	if err != nil {
+		fail(err)
+	}
+
+	signals := make(chan os.Signal, len(forwardedSignals))
+	signal.Notify(signals, forwardedSignals...)
+
+	cmd := exec.Command(program, args[1:]...)
+	cmd.Args[0] = args[0]
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		fail(err)
+	}
+	go func() {
+		for sig := range signals {
+			_ = cmd.Process.Signal(sig)
+		}
+	}()
+
+	for {
+		var status syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &status, 0, nil)
+		switch {
+		case errors.Is(err, syscall.EINTR):
+		case err != nil:
+			fail(fmt.Errorf("cannot wait for the program: %w", err))
+		case pid == cmd.Process.Pid:
+			return exitStatus(status)
+		}
+	}
+}
+
+// exitStatus converts a wait status to an exit status, the shell's way
+// for processes killed by a signal.
+func exitStatus(status syscall.WaitStatus) int {
+	if status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return status.ExitStatus()
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+// Command claude-jail confines the Claude Code CLI on Linux; see main.go.
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func main() {
+	fmt.Fprintf(os.Stderr, "claude-jail: not supported on %s\n", runtime.GOOS)
+	os.Exit(125)
+}
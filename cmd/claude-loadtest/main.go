@@ -0,0 +1,125 @@
+// Command claude-loadtest drives scripted conversations through the SDK
+// at a fixed rate and prints throughput, latency, errors, and resource
+// growth. With -mock it runs against a built-in mock CLI, measuring the
+// SDK alone; otherwise it uses the real CLI and spends API credits.
+//
+//	claude-loadtest -mock -qps 50 -duration 10m -prompt hello -prompt bye
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/loadtest"
+)
+
+// envMockLatency makes the binary act as the mock CLI with the given
+// latency; -mock sets it for the processes the SDK spawns.
+const envMockLatency = "CLAUDE_LOADTEST_MOCK_LATENCY"
+
+// promptList collects repeated -prompt flags.
+type promptList []string
+
+func (p *promptList) String() string { return strings.Join(*p, ", ") }
+
+func (p *promptList) Set(value string) error {
+	*p = append(*p, value)
+
+	return nil
+}
+
+func main() {
+	if latency, ok := os.LookupEnv(envMockLatency); ok {
+		serveMock(latency)
+
+		return
+	}
+
+	var prompts promptList
+	flag.Var(&prompts, "prompt", "prompt of each conversation turn (repeatable)")
+	qps := flag.Float64("qps", 1, "conversations started per second")
+	duration := flag.Duration("duration", time.Minute, "how long to start conversations for")
+	concurrency := flag.Int("concurrency", 0, "maximum conversations in flight (default 64)")
+	turnTimeout := flag.Duration("turn-timeout", 0, "timeout of each turn (default 2m)")
+	mock := flag.Bool("mock", false, "use the built-in mock CLI instead of the real one")
+	mockLatency := flag.Duration("mock-latency", 50*time.Millisecond, "simulated model time of the mock CLI")
+	model := flag.String("model", "", "model to use with the real CLI")
+	cli := flag.String("cli", "", "path to the claude executable")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	if len(prompts) == 0 {
+		prompts = promptList{"Reply with the single word: ok"}
+	}
+
+	opts := &claude.Options{Model: *model, PathToClaudeCodeExecutable: *cli}
+	if *mock {
+		self, err := os.Executable()
+		if err != nil {
+			log.Fatalf("cannot locate own executable for -mock: %v", err)
+		}
+		opts.PathToClaudeCodeExecutable = self
+		opts.Env = map[string]string{envMockLatency: mockLatency.String()}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := loadtest.Run(ctx, loadtest.Config{
+		Options:     opts,
+		Script:      prompts,
+		QPS:         *qps,
+		Duration:    *duration,
+		Concurrency: *concurrency,
+		TurnTimeout: *turnTimeout,
+	})
+	if err != nil {
+		log.Fatalf("load test failed: %v", err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("failed to encode report: %v", err)
+		}
+
+		return
+	}
+	printReport(report)
+}
+
+// serveMock acts as the mock CLI on standard input and output.
+func serveMock(latency string) {
+	delay, err := time.ParseDuration(latency)
+	if err != nil {
+		log.Fatalf("invalid %s %q: %v", envMockLatency, latency, err)
+	}
+	if err := (loadtest.MockCLI{Latency: delay}).Serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("mock CLI failed: %v", err)
+	}
+}
+
+// printReport prints a human-readable summary.
+func printReport(r *loadtest.Report) {
+	fmt.Printf("conversations: %d started, %d completed, %d failed, %d skipped\n",
+		r.Started, r.Completed, r.Failed, r.Skipped)
+	fmt.Printf("turns:         %d in %s (%.2f conversations/s)\n", r.Turns, r.Elapsed.Round(time.Millisecond), r.Throughput)
+	fmt.Printf("latency:       p50 %s, p95 %s, p99 %s\n", r.P50, r.P95, r.P99)
+	for code, count := range r.Errors {
+		fmt.Printf("error:         %s x%d\n", code, count)
+	}
+	fmt.Printf("goroutines:    %d -> %d (leak %d)\n", r.Before.Goroutines, r.After.Goroutines, r.GoroutineLeak())
+	if r.Before.FDs >= 0 {
+		fmt.Printf("fds:           %d -> %d (leak %d)\n", r.Before.FDs, r.After.FDs, r.FDLeak())
+	}
+	fmt.Printf("heap:          %d -> %d bytes (%+d)\n", r.Before.HeapBytes, r.After.HeapBytes, r.HeapGrowth())
+}
@@ -3,7 +3,7 @@
 // This example shows how to:
 //   - Create a Claude Agent client with basic configuration
 //   - Send a simple query to the assistant
-//   - Receive and process different types of messages
+//   - Render the response stream with the render package
 //   - Handle errors and properly close the client
 package main
 
@@ -11,8 +11,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/render"
 )
 
 const (
@@ -49,71 +51,14 @@ func main() {
 		return
 	}
 
-	// Receive and process responses
+	// Render responses as they arrive
+	r := render.New(os.Stdout)
 	msgChan, errChan := client.ReceiveMessages(ctx)
+	if err := r.Stream(ctx, msgChan, errChan); err != nil {
+		log.Printf("Error: %v", err)
 
-	for {
-		select {
-		case msg := <-msgChan:
-			if msg == nil {
-				fmt.Println("Query completed")
-
-				return
-			}
-
-			handleMessage(msg)
-
-		case err := <-errChan:
-			if err != nil {
-				log.Printf("Error: %v", err)
-
-				return
-			}
-		}
-	}
-}
-
-// handleMessage processes different types of SDK messages.
-func handleMessage(msg claude.SDKMessage) {
-	switch m := msg.(type) {
-	case *claude.SDKSystemMessage:
-		handleSystemMessage(m)
-	case *claude.SDKAssistantMessage:
-		handleAssistantMessage(m)
-	case *claude.SDKResultMessage:
-		handleResultMessage(m)
-	}
-}
-
-// handleSystemMessage processes system initialization messages.
-func handleSystemMessage(m *claude.SDKSystemMessage) {
-	if m.Subtype == "init" {
-		fmt.Printf("Initialized with model: %v\n", m.Data["model"])
-	}
-}
-
-// handleAssistantMessage displays assistant response content.
-func handleAssistantMessage(m *claude.SDKAssistantMessage) {
-	fmt.Println("\nAssistant response:")
-	for _, block := range m.Message.Content {
-		displayContentBlock(block)
-	}
-}
-
-// displayContentBlock prints text content from a content block.
-func displayContentBlock(block claude.ContentBlock) {
-	switch b := block.(type) {
-	case claude.TextBlock:
-		fmt.Printf("  %s\n", b.Text)
-	case claude.TextContentBlock:
-		fmt.Printf("  %s\n", b.Text)
+		return
 	}
-}
 
-// handleResultMessage displays final result statistics.
-func handleResultMessage(m *claude.SDKResultMessage) {
-	fmt.Printf("\nResult: %s\n", m.Subtype)
-	fmt.Printf("Duration: %dms\n", m.DurationMS)
-	fmt.Printf("Cost: $%.4f\n", m.TotalCostUSD)
-	fmt.Printf("Turns: %d\n", m.NumTurns)
+	fmt.Println("Query completed")
 }
@@ -161,7 +161,14 @@ func runPlanningPhase(
 	}
 
 	fmt.Println("Claude's Plan:")
-	fmt.Println(plan)
+	if structured := client.LastPlan(); structured != nil {
+		plan = structured.Raw
+		for _, step := range structured.Steps {
+			fmt.Printf("%d. %s\n", step.Index, step.Title)
+		}
+	} else {
+		fmt.Println(plan)
+	}
 	fmt.Println()
 
 	// Simulate user approval
@@ -0,0 +1,161 @@
+// Package main demonstrates caching one-shot query results in Redis.
+//
+// This example shows how to:
+//   - Implement the claude.Cache interface on top of Redis
+//   - Answer repeated identical prompts from the cache with QueryText
+//
+// It speaks the Redis protocol directly to stay dependency-free; a real
+// application would wrap its Redis client library the same way. Set
+// REDIS_ADDR to point at a server other than localhost:6379.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+const (
+	// defaultRedisAddr is used when REDIS_ADDR is unset.
+	defaultRedisAddr = "localhost:6379"
+	// cacheTTL is how long answers stay cached.
+	cacheTTL = 24 * time.Hour
+)
+
+// redisCache is a claude.Cache backed by Redis GET and SET.
+type redisCache struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// dialRedis connects to the Redis server at addr.
+func dialRedis(addr string) (*redisCache, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redisCache{conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// Get implements claude.Cache.
+func (c *redisCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	value, err := c.do("GET", key)
+	if err != nil || value == nil {
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+// Set implements claude.Cache.
+func (c *redisCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := c.do(args...)
+
+	return err
+}
+
+// do sends a command and returns its bulk string reply, or nil for a nil
+// or status reply.
+func (c *redisCache) do(args ...string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var cmd strings.Builder
+	fmt.Fprintf(&cmd, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&cmd, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, cmd.String()); err != nil {
+		return nil, err
+	}
+
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return nil, nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+
+		return data[:size], nil
+	default:
+		return nil, fmt.Errorf("unexpected reply %q", line)
+	}
+}
+
+// Close closes the connection.
+func (c *redisCache) Close() error {
+	return c.conn.Close()
+}
+
+func main() {
+	ctx := context.Background()
+
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = defaultRedisAddr
+	}
+	cache, err := dialRedis(addr)
+	if err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err)
+	}
+	defer func() {
+		if closeErr := cache.Close(); closeErr != nil {
+			log.Printf("Failed to close Redis connection: %v", closeErr)
+		}
+	}()
+
+	opts, err := claude.NewOptions().
+		WithModel("claude-sonnet-4-5").
+		WithCache(cache, cacheTTL).
+		Build()
+	if err != nil {
+		log.Fatalf("Invalid options: %v", err)
+	}
+
+	// The second call is answered from Redis without running the CLI
+	prompt := "What is the capital of France? Answer with one word."
+	for i := 1; i <= 2; i++ {
+		start := time.Now()
+		answer, err := claude.QueryText(ctx, prompt, opts)
+		if err != nil {
+			log.Printf("Query failed: %v", err)
+
+			return
+		}
+		fmt.Printf("Call %d (%s): %s\n", i, time.Since(start).Round(time.Millisecond), answer)
+	}
+}
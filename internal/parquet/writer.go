@@ -0,0 +1,328 @@
+// Package parquet writes small Apache Parquet files: one row group of
+// required, flat columns, PLAIN-encoded and uncompressed. It covers the
+// telemetry tables the SDK exports, not the format at large.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Type is the type of a column.
+type Type int
+
+const (
+	// Int64 columns hold int64 or int values.
+	Int64 Type = iota
+	// Double columns hold float64 values.
+	Double
+	// String columns hold UTF-8 string values.
+	String
+	// Timestamp columns hold time.Time values, stored as microseconds
+	// since the Unix epoch in UTC.
+	Timestamp
+)
+
+// Field is a column of a file.
+type Field struct {
+	Name string
+	Type Type
+}
+
+// magic opens and closes every Parquet file.
+const magic = "PAR1"
+
+// createdBy names the writer in the file's metadata.
+const createdBy = "claude-agent-sdk-go"
+
+// Parquet physical types, encodings and other enumerations of the format.
+const (
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMicros = 10
+
+	repetitionRequired = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecUncompressed  = 0
+	pageTypeData       = 0
+	formatVersion      = 1
+)
+
+// ErrValueType is returned by Write for a value that doesn't match the type
+// of its column.
+var ErrValueType = errors.New("parquet: value does not match column type")
+
+// Write encodes rows, each holding one value per field in order, as a
+// Parquet file with the columns fields.
+func Write(w io.Writer, fields []Field, rows [][]any) error {
+	var file bytes.Buffer
+	file.WriteString(magic)
+
+	chunks := make([]columnChunk, len(fields))
+	for i, field := range fields {
+		data, err := encodeColumn(field, i, rows)
+		if err != nil {
+			return err
+		}
+
+		var header compactWriter
+		header.i32(1, pageTypeData)
+		header.i32(2, int32(len(data)))
+		header.i32(3, int32(len(data)))
+		header.beginStruct(5)
+		header.i32(1, int32(len(rows)))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.endStruct()
+		header.stop()
+
+		chunks[i] = columnChunk{offset: int64(file.Len()), size: int64(len(header.buf) + len(data))}
+		file.Write(header.buf)
+		file.Write(data)
+	}
+
+	footer := fileMetadata(fields, chunks, int64(len(rows)))
+	file.Write(footer)
+	_ = binary.Write(&file, binary.LittleEndian, uint32(len(footer)))
+	file.WriteString(magic)
+
+	_, err := w.Write(file.Bytes())
+
+	return err
+}
+
+// columnChunk locates the data of a column in the file.
+type columnChunk struct {
+	offset int64
+	size   int64
+}
+
+// encodeColumn PLAIN-encodes the values of column i.
+func encodeColumn(field Field, i int, rows [][]any) ([]byte, error) {
+	var data []byte
+	for row, values := range rows {
+		if i >= len(values) {
+			return nil, fmt.Errorf("%w: row %d has no value for %s", ErrValueType, row, field.Name)
+		}
+		switch v := values[i].(type) {
+		case int64:
+			if field.Type != Int64 {
+				return nil, valueError(field, row, v)
+			}
+			data = binary.LittleEndian.AppendUint64(data, uint64(v))
+		case int:
+			if field.Type != Int64 {
+				return nil, valueError(field, row, v)
+			}
+			data = binary.LittleEndian.AppendUint64(data, uint64(int64(v)))
+		case float64:
+			if field.Type != Double {
+				return nil, valueError(field, row, v)
+			}
+			data = binary.LittleEndian.AppendUint64(data, math.Float64bits(v))
+		case string:
+			if field.Type != String {
+				return nil, valueError(field, row, v)
+			}
+			data = binary.LittleEndian.AppendUint32(data, uint32(len(v)))
+			data = append(data, v...)
+		case time.Time:
+			if field.Type != Timestamp {
+				return nil, valueError(field, row, v)
+			}
+			data = binary.LittleEndian.AppendUint64(data, uint64(v.UnixMicro()))
+		default:
+			return nil, valueError(field, row, v)
+		}
+	}
+
+	return data, nil
+}
+
+// valueError reports a value of the wrong type.
+func valueError(field Field, row int, value any) error {
+	return fmt.Errorf("%w: row %d has %T for %s", ErrValueType, row, value, field.Name)
+}
+
+// fileMetadata encodes the footer of a file with one row group.
+func fileMetadata(fields []Field, chunks []columnChunk, numRows int64) []byte {
+	var w compactWriter
+	w.i32(1, formatVersion)
+
+	w.beginList(2, compactStruct, len(fields)+1)
+	w.beginElement()
+	w.binary(4, "schema")
+	w.i32(5, int32(len(fields)))
+	w.endStruct()
+	for _, field := range fields {
+		w.beginElement()
+		w.i32(1, physicalType(field.Type))
+		w.i32(3, repetitionRequired)
+		w.binary(4, field.Name)
+		switch field.Type {
+		case String:
+			w.i32(6, convertedUTF8)
+		case Timestamp:
+			w.i32(6, convertedTimestampMicros)
+		}
+		w.endStruct()
+	}
+
+	w.i64(3, numRows)
+
+	var total int64
+	for _, chunk := range chunks {
+		total += chunk.size
+	}
+	w.beginList(4, compactStruct, 1)
+	w.beginElement()
+	w.beginList(1, compactStruct, len(fields))
+	for i, field := range fields {
+		chunk := chunks[i]
+		w.beginElement()
+		w.i64(2, chunk.offset)
+		w.beginStruct(3)
+		w.i32(1, physicalType(field.Type))
+		w.beginList(2, compactI32, 1)
+		w.varint(zigzag(encodingPlain))
+		w.beginList(3, compactBinary, 1)
+		w.varint(uint64(len(field.Name)))
+		w.buf = append(w.buf, field.Name...)
+		w.i32(4, codecUncompressed)
+		w.i64(5, numRows)
+		w.i64(6, chunk.size)
+		w.i64(7, chunk.size)
+		w.i64(9, chunk.offset)
+		w.endStruct()
+		w.endStruct()
+	}
+	w.i64(2, total)
+	w.i64(3, numRows)
+	w.endStruct()
+
+	w.binary(6, createdBy)
+	w.stop()
+
+	return w.buf
+}
+
+// physicalType returns the Parquet type storing t.
+func physicalType(t Type) int32 {
+	switch t {
+	case Double:
+		return physicalDouble
+	case String:
+		return physicalByteArray
+	default:
+		return physicalInt64
+	}
+}
+
+// Thrift compact protocol type codes.
+const (
+	compactI32    = 5
+	compactI64    = 6
+	compactBinary = 8
+	compactList   = 9
+	compactStruct = 12
+
+	// maxShortListSize is the largest list size that fits in the list
+	// header byte.
+	maxShortListSize = 14
+	// maxFieldDelta is the largest field ID delta that fits in the field
+	// header byte.
+	maxFieldDelta = 15
+)
+
+// compactWriter encodes Thrift structs with the compact protocol, which
+// Parquet uses for its metadata.
+type compactWriter struct {
+	buf    []byte
+	lastID int16
+	stack  []int16 // Last field IDs of enclosing structs
+}
+
+// field writes the header of field id of type typ.
+func (w *compactWriter) field(id int16, typ byte) {
+	if delta := id - w.lastID; delta > 0 && delta <= maxFieldDelta {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.varint(zigzag(int64(id)))
+	}
+	w.lastID = id
+}
+
+func (w *compactWriter) i32(id int16, v int32) {
+	w.field(id, compactI32)
+	w.varint(zigzag(int64(v)))
+}
+
+func (w *compactWriter) i64(id int16, v int64) {
+	w.field(id, compactI64)
+	w.varint(zigzag(v))
+}
+
+func (w *compactWriter) binary(id int16, v string) {
+	w.field(id, compactBinary)
+	w.varint(uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+// beginStruct opens struct field id; close it with endStruct.
+func (w *compactWriter) beginStruct(id int16) {
+	w.field(id, compactStruct)
+	w.beginElement()
+}
+
+// beginElement opens a struct that is a list element; close it with
+// endStruct.
+func (w *compactWriter) beginElement() {
+	w.stack = append(w.stack, w.lastID)
+	w.lastID = 0
+}
+
+// endStruct closes the innermost struct.
+func (w *compactWriter) endStruct() {
+	w.stop()
+	w.lastID = w.stack[len(w.stack)-1]
+	w.stack = w.stack[:len(w.stack)-1]
+}
+
+// beginList writes the header of list field id holding n elements of type
+// elem, which the caller writes next.
+func (w *compactWriter) beginList(id int16, elem byte, n int) {
+	w.field(id, compactList)
+	if n <= maxShortListSize {
+		w.buf = append(w.buf, byte(n)<<4|elem)
+
+		return
+	}
+	w.buf = append(w.buf, 0xf0|elem)
+	w.varint(uint64(n))
+}
+
+// stop ends the fields of a struct.
+func (w *compactWriter) stop() {
+	w.buf = append(w.buf, 0)
+}
+
+func (w *compactWriter) varint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+// zigzag maps signed integers to unsigned ones with small magnitudes
+// first.
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
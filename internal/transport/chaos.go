@@ -0,0 +1,138 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrInjectedExit is returned by a ChaosTransport after it has killed the
+// process to simulate a crash.
+var ErrInjectedExit = errors.New("process exited mid-stream (injected fault)")
+
+// FaultConfig configures the faults a ChaosTransport injects into
+// messages read from the process. Rates are per-message probabilities
+// between 0 and 1.
+type FaultConfig struct {
+	// DropRate is the probability a message is silently discarded.
+	DropRate float64
+	// DelayRate is the probability a message is held back for a random
+	// duration up to MaxDelay.
+	DelayRate float64
+	MaxDelay  time.Duration
+	// MalformedRate is the probability a message is truncated into
+	// invalid JSON.
+	MalformedRate float64
+	// ExitRate is the probability the process is killed instead of the
+	// message being delivered.
+	ExitRate float64
+	// Seed makes the fault sequence reproducible. Zero picks a random
+	// seed.
+	Seed uint64
+}
+
+// ChaosTransport wraps a Transport and injects faults into the messages
+// read from it, for testing how callers handle a misbehaving process.
+// Writes pass through unchanged.
+type ChaosTransport struct {
+	inner  Transport
+	config FaultConfig
+	exit   func() error
+
+	mu     sync.Mutex
+	rand   *rand.Rand
+	exited bool
+}
+
+// NewChaosTransport wraps inner with fault injection. exit is called to
+// terminate the process when an exit fault fires.
+func NewChaosTransport(inner Transport, config FaultConfig, exit func() error) *ChaosTransport {
+	seed := config.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+
+	return &ChaosTransport{
+		inner:  inner,
+		config: config,
+		exit:   exit,
+		rand:   rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+// Read reads the next message that survives the configured faults.
+func (t *ChaosTransport) Read(ctx context.Context) ([]byte, error) {
+	for {
+		data, err := t.inner.Read(ctx)
+
+		t.mu.Lock()
+		if t.exited {
+			t.mu.Unlock()
+
+			return nil, ErrInjectedExit
+		}
+		if err != nil {
+			t.mu.Unlock()
+
+			return nil, err
+		}
+
+		exit := t.roll(t.config.ExitRate)
+		drop := !exit && t.roll(t.config.DropRate)
+		var delay time.Duration
+		if t.config.MaxDelay > 0 && t.roll(t.config.DelayRate) {
+			delay = time.Duration(t.rand.Int64N(int64(t.config.MaxDelay)) + 1)
+		}
+		malformed := t.roll(t.config.MalformedRate)
+		if exit {
+			t.exited = true
+		}
+		t.mu.Unlock()
+
+		switch {
+		case exit:
+			if t.exit != nil {
+				_ = t.exit()
+			}
+
+			return nil, ErrInjectedExit
+		case drop:
+			continue
+		}
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+
+				return nil, ctx.Err()
+			}
+		}
+
+		if malformed {
+			data = data[:len(data)/2]
+		}
+
+		return data, nil
+	}
+}
+
+// roll reports whether a fault with probability rate fires. The caller
+// holds t.mu.
+func (t *ChaosTransport) roll(rate float64) bool {
+	return rate > 0 && t.rand.Float64() < rate
+}
+
+// Write writes data to the wrapped transport unchanged.
+func (t *ChaosTransport) Write(ctx context.Context, data []byte) error {
+	return t.inner.Write(ctx, data)
+}
+
+// Close closes the wrapped transport.
+func (t *ChaosTransport) Close() error {
+	return t.inner.Close()
+}
@@ -0,0 +1,200 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Codec converts between the JSON messages the SDK exchanges with the
+// process and the frames written to its stdin and read from its stdout.
+// Encoders and decoders are created once per stream and may keep state
+// between frames.
+type Codec interface {
+	// NewEncoder returns an encoder writing frames to w.
+	NewEncoder(w io.Writer) Encoder
+	// NewDecoder returns a decoder reading frames from r.
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder writes messages as frames.
+type Encoder interface {
+	// Encode writes message, a JSON document, as a single frame.
+	Encode(message []byte) error
+}
+
+// Decoder reads frames as messages.
+type Decoder interface {
+	// Decode returns the next message as a JSON document, or io.EOF at the
+	// end of the stream.
+	Decode() ([]byte, error)
+}
+
+var (
+	// NDJSON frames each message as one line of JSON. It is the protocol
+	// the Claude Code CLI speaks and the default codec.
+	NDJSON Codec = ndjsonCodec{}
+
+	// PrettyJSON frames each message as indented JSON followed by a
+	// newline, for reading the wire in debug builds. Messages are decoded
+	// back to compact JSON.
+	PrettyJSON Codec = prettyJSONCodec{}
+)
+
+// ndjsonCodec implements NDJSON.
+type ndjsonCodec struct{}
+
+func (ndjsonCodec) NewEncoder(w io.Writer) Encoder {
+	return lineEncoder{w: w}
+}
+
+func (ndjsonCodec) NewDecoder(r io.Reader) Decoder {
+	return &lineDecoder{
+		reader:         bufio.NewReaderSize(r, readBufferSize),
+		maxMessageSize: DefaultMaxMessageSize,
+	}
+}
+
+// lineEncoder writes newline-terminated frames.
+type lineEncoder struct {
+	w io.Writer
+}
+
+// Encode writes message and its newline in a single write, so concurrent
+// frames are not interleaved.
+func (e lineEncoder) Encode(message []byte) error {
+	frame := make([]byte, 0, len(message)+1)
+	frame = append(frame, message...)
+	frame = append(frame, '\n')
+	_, err := e.w.Write(frame)
+
+	return err
+}
+
+// lineDecoder reads newline-delimited frames of arbitrary length.
+type lineDecoder struct {
+	reader         *bufio.Reader
+	maxMessageSize int
+}
+
+// Decode reads the next non-blank line.
+//
+// Lines longer than the reader buffer are reassembled from fragments so
+// huge tool results do not fail with token-too-long errors. Lines longer
+// than maxMessageSize are discarded up to the next newline and reported
+// as ErrMessageTooLarge, leaving the reader positioned on a frame boundary.
+func (d *lineDecoder) Decode() ([]byte, error) {
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			return line, nil
+		}
+	}
+}
+
+// readLine reads a single newline-terminated line of arbitrary length.
+func (d *lineDecoder) readLine() ([]byte, error) {
+	var line []byte
+
+	for {
+		fragment, err := d.reader.ReadSlice('\n')
+
+		size := len(line) + len(fragment)
+		if d.maxMessageSize > 0 && size > d.maxMessageSize {
+			if errors.Is(err, bufio.ErrBufferFull) {
+				d.discardLine()
+			}
+
+			return nil, fmt.Errorf(
+				"%w: message exceeds %d bytes",
+				ErrMessageTooLarge,
+				d.maxMessageSize,
+			)
+		}
+
+		line = append(line, fragment...)
+
+		switch {
+		case err == nil:
+			return line, nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF):
+			// Deliver a final unterminated frame before reporting EOF.
+			if len(line) > 0 {
+				return line, nil
+			}
+
+			return nil, io.EOF
+		default:
+			return nil, fmt.Errorf(errWrapFormat, ErrReadFailed, err)
+		}
+	}
+}
+
+// discardLine skips input up to and including the next newline.
+func (d *lineDecoder) discardLine() {
+	for {
+		_, err := d.reader.ReadSlice('\n')
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return
+		}
+	}
+}
+
+// prettyJSONCodec implements PrettyJSON.
+type prettyJSONCodec struct{}
+
+func (prettyJSONCodec) NewEncoder(w io.Writer) Encoder {
+	return prettyEncoder{w: w}
+}
+
+func (prettyJSONCodec) NewDecoder(r io.Reader) Decoder {
+	return prettyDecoder{dec: json.NewDecoder(r)}
+}
+
+// prettyEncoder writes indented JSON frames.
+type prettyEncoder struct {
+	w io.Writer
+}
+
+func (e prettyEncoder) Encode(message []byte) error {
+	var frame bytes.Buffer
+	if err := json.Indent(&frame, message, "", "  "); err != nil {
+		return err
+	}
+	frame.WriteByte('\n')
+	_, err := e.w.Write(frame.Bytes())
+
+	return err
+}
+
+// prettyDecoder reads consecutive JSON values regardless of layout.
+type prettyDecoder struct {
+	dec *json.Decoder
+}
+
+func (d prettyDecoder) Decode() ([]byte, error) {
+	var raw json.RawMessage
+	if err := d.dec.Decode(&raw); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+
+		return nil, fmt.Errorf(errWrapFormat, ErrReadFailed, err)
+	}
+
+	var message bytes.Buffer
+	if err := json.Compact(&message, raw); err != nil {
+		return nil, fmt.Errorf(errWrapFormat, ErrReadFailed, err)
+	}
+
+	return message.Bytes(), nil
+}
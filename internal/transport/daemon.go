@@ -0,0 +1,151 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// namedPipePrefix starts the paths of Windows named pipes.
+const namedPipePrefix = `\\.\pipe\`
+
+// ErrDaemon is returned when a claude daemon refuses or fails to start a
+// session.
+var ErrDaemon = errors.New("claude daemon failed to start the session")
+
+// DaemonRequest is the first line a client writes to a claude daemon
+// connection, describing the CLI to run. After the daemon answers with a
+// DaemonReply line, the connection carries the CLI's stdin and stdout.
+type DaemonRequest struct {
+	// Args are the CLI's arguments.
+	Args []string `json:"args"`
+	// Env holds variables added to the daemon's environment.
+	Env []string `json:"env,omitempty"`
+	// Cwd is the CLI's working directory; empty uses the daemon's.
+	Cwd string `json:"cwd,omitempty"`
+	// Multiplex makes the connection carry many sessions as DaemonFrame
+	// lines instead of one CLI; the other fields are then unused.
+	Multiplex bool `json:"multiplex,omitempty"`
+}
+
+// DaemonReply is the daemon's answer to a DaemonRequest.
+type DaemonReply struct {
+	// Error is set if the CLI could not be started; the daemon then closes
+	// the connection.
+	Error string `json:"error,omitempty"`
+	// Warm reports that the CLI was started before the request arrived.
+	Warm bool `json:"warm,omitempty"`
+}
+
+// dialDaemon connects to the claude daemon at config.Daemon and asks it to
+// run the CLI with config's arguments, environment and working directory.
+func dialDaemon(ctx context.Context, config *ProcessConfig) (*Process, error) {
+	conn, err := dialDaemonAddress(ctx, config.Daemon)
+	if err != nil {
+		return nil, fmt.Errorf(errWrapFormat, ErrProcessStart, err)
+	}
+
+	// Abort the handshake, not the session, when ctx ends
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	reader, reply, err := daemonHandshake(conn, &DaemonRequest{
+		Args: config.Args,
+		Env:  config.Env,
+		Cwd:  config.Cwd,
+	})
+	if !stop() {
+		err = ctx.Err()
+	}
+	if err == nil && reply.Error != "" {
+		err = fmt.Errorf("%w: %s", ErrDaemon, reply.Error)
+	}
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	// The CLI's stderr stays with the daemon
+	stream := &daemonStream{reader: reader, conn: conn}
+	stdio := NewStdioTransport(stream, stream, io.NopCloser(strings.NewReader("")))
+	if config.MaxMessageSize != 0 {
+		stdio.WithMaxMessageSize(config.MaxMessageSize)
+	}
+	if config.Codec != nil {
+		stdio.WithCodec(config.Codec)
+	}
+
+	proc := &Process{
+		transport: stdio,
+		done:      make(chan struct{}),
+	}
+	if config.Faults != nil {
+		proc.transport = NewChaosTransport(stdio, *config.Faults, stream.Close)
+	}
+
+	return proc, nil
+}
+
+// dialDaemonAddress opens a Windows named pipe or a Unix socket.
+func dialDaemonAddress(ctx context.Context, address string) (io.ReadWriteCloser, error) {
+	if strings.HasPrefix(address, namedPipePrefix) {
+		return os.OpenFile(address, os.O_RDWR, 0)
+	}
+
+	var dialer net.Dialer
+
+	return dialer.DialContext(ctx, "unix", address)
+}
+
+// daemonHandshake writes request and reads the reply, returning the reader
+// holding any output that followed it.
+func daemonHandshake(conn io.ReadWriter, request *DaemonRequest) (*bufio.Reader, *DaemonReply, error) {
+	line, err := json.Marshal(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := conn.Write(append(line, '\n')); err != nil {
+		return nil, nil, fmt.Errorf(errWrapFormat, ErrWriteFailed, err)
+	}
+
+	reader := bufio.NewReaderSize(conn, readBufferSize)
+	line, err = reader.ReadBytes('\n')
+	if err != nil {
+		return nil, nil, fmt.Errorf(errWrapFormat, ErrReadFailed, err)
+	}
+	var reply DaemonReply
+	if err := json.Unmarshal(line, &reply); err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid reply: %w", ErrDaemon, err)
+	}
+
+	return reader, &reply, nil
+}
+
+// daemonStream is a daemon connection serving as both the CLI's stdin and
+// stdout. It is closed once, by whichever closes first.
+type daemonStream struct {
+	reader *bufio.Reader
+	conn   io.ReadWriteCloser
+	once   sync.Once
+	err    error
+}
+
+func (s *daemonStream) Read(p []byte) (int, error) {
+	return s.reader.Read(p)
+}
+
+func (s *daemonStream) Write(p []byte) (int, error) {
+	return s.conn.Write(p)
+}
+
+func (s *daemonStream) Close() error {
+	s.once.Do(func() { s.err = s.conn.Close() })
+
+	return s.err
+}
@@ -0,0 +1,336 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// muxChunkSize bounds the data of a DaemonFrame, so frames stay well
+// below the daemon's line limit however large a message is.
+const muxChunkSize = 32 << 10
+
+// muxBacklog is the number of output chunks buffered per session.
+const muxBacklog = 256
+
+// ErrDaemonMuxClosed is returned when a multiplexed daemon connection is
+// used after it closed.
+var ErrDaemonMuxClosed = errors.New("claude daemon connection closed")
+
+// DaemonFrame is a line of a multiplexed claude daemon connection, which
+// starts with a DaemonRequest setting Multiplex instead of a CLI request.
+// Each frame belongs to the session the client named when opening it.
+type DaemonFrame struct {
+	// Session is the client's name for the session.
+	Session string `json:"session"`
+	// Open, from the client, asks the daemon to start a CLI for the
+	// session, answered by a frame with Reply.
+	Open *DaemonRequest `json:"open,omitempty"`
+	// Reply is the daemon's answer to Open.
+	Reply *DaemonReply `json:"reply,omitempty"`
+	// Data is a chunk of the CLI's stdin, from the client, or of its
+	// stdout, from the daemon.
+	Data []byte `json:"data,omitempty"`
+	// Close, from the client, stops the session's CLI; from the daemon,
+	// it reports that the CLI's output ended. No frames for the session
+	// follow the daemon's.
+	Close bool `json:"close,omitempty"`
+}
+
+// DaemonMux is one connection to a claude daemon carrying the CLI
+// sessions of many processes, set as ProcessConfig.Mux.
+//
+// Output is buffered per session; a session that stops reading holds up
+// the others once its buffer fills.
+type DaemonMux struct {
+	conn   io.ReadWriteCloser
+	reader *bufio.Reader
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	sessions map[string]*muxStream
+	next     uint64
+	err      error
+	done     chan struct{}
+}
+
+// DialDaemonMux opens a multiplexed connection to the claude daemon at
+// address, a Unix socket path or Windows named pipe.
+func DialDaemonMux(ctx context.Context, address string) (*DaemonMux, error) {
+	conn, err := dialDaemonAddress(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf(errWrapFormat, ErrProcessStart, err)
+	}
+
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	reader, reply, err := daemonHandshake(conn, &DaemonRequest{Multiplex: true})
+	if !stop() {
+		err = ctx.Err()
+	}
+	if err == nil && reply.Error != "" {
+		err = fmt.Errorf("%w: %s", ErrDaemon, reply.Error)
+	}
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	m := &DaemonMux{
+		conn:     conn,
+		reader:   reader,
+		sessions: make(map[string]*muxStream),
+		done:     make(chan struct{}),
+	}
+	go m.demux()
+
+	return m, nil
+}
+
+// Sessions returns the number of open sessions.
+func (m *DaemonMux) Sessions() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.sessions)
+}
+
+// Close closes the connection, which stops the CLIs of all its sessions.
+func (m *DaemonMux) Close() error {
+	err := m.conn.Close()
+	<-m.done
+
+	return err
+}
+
+// open starts a CLI session for config.
+func (m *DaemonMux) open(ctx context.Context, config *ProcessConfig) (*Process, error) {
+	m.mu.Lock()
+	if m.err != nil {
+		m.mu.Unlock()
+
+		return nil, fmt.Errorf(errWrapFormat, ErrProcessStart, m.err)
+	}
+	m.next++
+	s := &muxStream{
+		mux:    m,
+		id:     strconv.FormatUint(m.next, 10),
+		chunks: make(chan []byte, muxBacklog),
+		reply:  make(chan *DaemonReply, 1),
+		ended:  make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+	m.sessions[s.id] = s
+	m.mu.Unlock()
+
+	err := m.write(&DaemonFrame{
+		Session: s.id,
+		Open:    &DaemonRequest{Args: config.Args, Env: config.Env, Cwd: config.Cwd},
+	})
+	if err == nil {
+		select {
+		case reply := <-s.reply:
+			if reply.Error != "" {
+				err = fmt.Errorf("%w: %s", ErrDaemon, reply.Error)
+			}
+		case <-s.ended:
+			err = fmt.Errorf(errWrapFormat, ErrProcessStart, m.closedErr())
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	if err != nil {
+		_ = s.Close()
+
+		return nil, err
+	}
+
+	stdio := NewStdioTransport(s, s, io.NopCloser(strings.NewReader("")))
+	if config.MaxMessageSize != 0 {
+		stdio.WithMaxMessageSize(config.MaxMessageSize)
+	}
+	if config.Codec != nil {
+		stdio.WithCodec(config.Codec)
+	}
+
+	proc := &Process{
+		transport: stdio,
+		done:      make(chan struct{}),
+	}
+	if config.Faults != nil {
+		proc.transport = NewChaosTransport(stdio, *config.Faults, s.Close)
+	}
+
+	return proc, nil
+}
+
+// write sends a frame.
+func (m *DaemonMux) write(frame *DaemonFrame) error {
+	line, err := json.Marshal(frame)
+	if err != nil {
+		return err
+	}
+
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	if _, err := m.conn.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf(errWrapFormat, ErrWriteFailed, err)
+	}
+
+	return nil
+}
+
+// demux delivers the daemon's frames to their sessions until the
+// connection ends, then ends every session.
+func (m *DaemonMux) demux() {
+	var err error
+	for {
+		var line []byte
+		if line, err = m.reader.ReadBytes('\n'); err != nil {
+			break
+		}
+		var frame DaemonFrame
+		if err = json.Unmarshal(line, &frame); err != nil {
+			err = fmt.Errorf("%w: invalid frame: %w", ErrDaemon, err)
+
+			break
+		}
+
+		m.mu.Lock()
+		s := m.sessions[frame.Session]
+		if s != nil && frame.Close {
+			delete(m.sessions, frame.Session)
+		}
+		m.mu.Unlock()
+		if s == nil {
+			continue
+		}
+
+		switch {
+		case frame.Reply != nil:
+			select {
+			case s.reply <- frame.Reply:
+			default:
+			}
+		case frame.Close:
+			s.end()
+		case len(frame.Data) > 0:
+			select {
+			case s.chunks <- frame.Data:
+			case <-s.closed:
+			}
+		}
+	}
+	_ = m.conn.Close()
+
+	m.mu.Lock()
+	if errors.Is(err, io.EOF) {
+		err = ErrDaemonMuxClosed
+	}
+	m.err = err
+	sessions := m.sessions
+	m.sessions = nil
+	m.mu.Unlock()
+
+	for _, s := range sessions {
+		s.end()
+	}
+	close(m.done)
+}
+
+// closedErr returns the error that ended the connection.
+func (m *DaemonMux) closedErr() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err == nil {
+		return ErrDaemonMuxClosed
+	}
+
+	return m.err
+}
+
+// muxStream is a session of a DaemonMux serving as both the CLI's stdin
+// and stdout.
+type muxStream struct {
+	mux     *DaemonMux
+	id      string
+	chunks  chan []byte
+	pending []byte
+	reply   chan *DaemonReply
+
+	ended     chan struct{} // The daemon sent the last frame
+	endOnce   sync.Once
+	closed    chan struct{} // The client closed the session
+	closeOnce sync.Once
+}
+
+func (s *muxStream) Read(p []byte) (int, error) {
+	if len(s.pending) == 0 {
+		select {
+		case s.pending = <-s.chunks:
+		case <-s.ended:
+			// Chunks are queued before the session ends
+			select {
+			case s.pending = <-s.chunks:
+			default:
+				return 0, io.EOF
+			}
+		}
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+
+	return n, nil
+}
+
+func (s *muxStream) Write(p []byte) (int, error) {
+	select {
+	case <-s.closed:
+		return 0, ErrDaemonMuxClosed
+	case <-s.ended:
+		return 0, ErrDaemonMuxClosed
+	default:
+	}
+
+	for written := 0; written < len(p); {
+		n := min(len(p)-written, muxChunkSize)
+		if err := s.mux.write(&DaemonFrame{Session: s.id, Data: p[written : written+n]}); err != nil {
+			return written, err
+		}
+		written += n
+	}
+
+	return len(p), nil
+}
+
+// Close stops the session's CLI; the connection stays open for the others.
+func (s *muxStream) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+
+		s.mux.mu.Lock()
+		_, open := s.mux.sessions[s.id]
+		delete(s.mux.sessions, s.id)
+		s.mux.mu.Unlock()
+		if open {
+			_ = s.mux.write(&DaemonFrame{Session: s.id, Close: true})
+		}
+		s.end()
+	})
+
+	return nil
+}
+
+// end releases readers once no more output will arrive.
+func (s *muxStream) end() {
+	s.endOnce.Do(func() { close(s.ended) })
+}
@@ -38,4 +38,16 @@ var (
 
 	// ErrWriteFailed is returned when writing to stdin fails.
 	ErrWriteFailed = errors.New("failed to write to stdin")
+
+	// ErrMessageTooLarge is returned when a single message read from
+	// stdout exceeds the configured maximum message size.
+	ErrMessageTooLarge = errors.New("message too large")
+
+	// ErrLimitsUnavailable is returned when the requested resource limits
+	// cannot be enforced on this platform or host.
+	ErrLimitsUnavailable = errors.New("resource limits unavailable")
+
+	// ErrJailUnavailable is returned when the requested jail or user
+	// cannot be applied on this platform or host.
+	ErrJailUnavailable = errors.New("process jail unavailable")
 )
@@ -0,0 +1,34 @@
+package transport
+
+// JailHelper is the name of the helper binary that confines a jailed
+// process, looked up in PATH unless JailConfig.Helper is set.
+const JailHelper = "claude-jail"
+
+// JailConfig confines a spawned process's filesystem with the claude-jail
+// helper, on Linux. The process sees only its working directory and the
+// listed paths, at their original locations, plus the basic devices, its
+// own /proc and an empty /tmp. It needs ProcessConfig.User, other than
+// root.
+type JailConfig struct {
+	// Helper is the path of the claude-jail binary.
+	Helper string
+	// ReadOnlyPaths are bound read-only. Nil uses DefaultJailReadOnlyPaths
+	// that exist. The directory of the executable is always added.
+	ReadOnlyPaths []string
+	// WritablePaths are bound read-write in addition to the working
+	// directory.
+	WritablePaths []string
+}
+
+// ProcessUser is the user a spawned process runs as.
+type ProcessUser struct {
+	UID    uint32
+	GID    uint32
+	Groups []uint32
+}
+
+// DefaultJailReadOnlyPaths are the system directories bound into a jail
+// when JailConfig.ReadOnlyPaths is nil.
+var DefaultJailReadOnlyPaths = []string{
+	"/bin", "/sbin", "/usr", "/lib", "/lib32", "/lib64", "/etc", "/opt", "/nix",
+}
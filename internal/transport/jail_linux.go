@@ -0,0 +1,149 @@
+//go:build linux
+
+package transport
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// jail runs a process as another user, and inside the claude-jail helper
+// if its filesystem is confined.
+type jail struct {
+	user *ProcessUser
+	// helperArgs is the helper invocation, ending in "--", when confined.
+	helperArgs []string
+	executable string
+	root       string
+}
+
+// newJail prepares the jail for running executable under config. It
+// returns nil when config asks for neither a jail nor a user.
+func newJail(config *ProcessConfig, executable string) (*jail, error) {
+	if config.Jail == nil && config.User == nil {
+		return nil, nil
+	}
+
+	j := &jail{user: config.User}
+	if config.Jail == nil {
+		return j, nil
+	}
+	// Root could escape the jail, so it needs a user to switch to
+	if config.User == nil || config.User.UID == 0 {
+		return nil, fmt.Errorf("%w: a jail needs a user other than root to run as", ErrJailUnavailable)
+	}
+
+	helper := config.Jail.Helper
+	if helper == "" {
+		path, err := exec.LookPath(JailHelper)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrJailUnavailable, err)
+		}
+		helper = path
+	}
+
+	workdir := config.Cwd
+	if workdir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrJailUnavailable, err)
+		}
+		workdir = wd
+	}
+
+	absolute, err := filepath.Abs(executable)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJailUnavailable, err)
+	}
+	j.executable = absolute
+
+	root, err := os.MkdirTemp("", "claude-jail-")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJailUnavailable, err)
+	}
+	j.root = root
+
+	args := []string{helper, "-root", root, "-workdir", workdir}
+	for _, path := range jailReadOnlyPaths(config.Jail, absolute) {
+		args = append(args, "-ro", path)
+	}
+	for _, path := range config.Jail.WritablePaths {
+		args = append(args, "-rw", path)
+	}
+	u := config.User
+	groups := make([]string, len(u.Groups))
+	for i, group := range u.Groups {
+		groups[i] = strconv.FormatUint(uint64(group), 10)
+	}
+	args = append(args,
+		"-uid", strconv.FormatUint(uint64(u.UID), 10),
+		"-gid", strconv.FormatUint(uint64(u.GID), 10),
+		"-groups", strings.Join(groups, ","),
+	)
+	j.helperArgs = append(args, "--")
+
+	return j, nil
+}
+
+// jailReadOnlyPaths returns the read-only paths of config, adding the
+// directories holding executable and, if it is a link, its target.
+func jailReadOnlyPaths(config *JailConfig, executable string) []string {
+	paths := config.ReadOnlyPaths
+	if paths == nil {
+		for _, path := range DefaultJailReadOnlyPaths {
+			if _, err := os.Stat(path); err == nil {
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	dirs := []string{filepath.Dir(executable)}
+	if target, err := filepath.EvalSymlinks(executable); err == nil {
+		dirs = append(dirs, filepath.Dir(target))
+	}
+
+	return append(append([]string(nil), paths...), dirs...)
+}
+
+// prepare makes cmd run in the jail: through the helper if the
+// filesystem is confined, which then switches users, or directly as the
+// user otherwise.
+func (j *jail) prepare(cmd *exec.Cmd) {
+	if j == nil {
+		return
+	}
+
+	if j.helperArgs != nil {
+		args := append([]string(nil), j.helperArgs...)
+		args = append(args, j.executable)
+		cmd.Args = append(args, cmd.Args[1:]...)
+		cmd.Path = j.helperArgs[0]
+
+		return
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = &syscall.Credential{
+		Uid:    j.user.UID,
+		Gid:    j.user.GID,
+		Groups: j.user.Groups,
+	}
+}
+
+// release removes the directory the jail's root was mounted on. The mount
+// only exists in the helper's namespace, so it is empty here.
+func (j *jail) release() {
+	if j == nil || j.root == "" {
+		return
+	}
+
+	_ = os.Remove(j.root)
+	j.root = ""
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package transport
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// jail is a no-op on platforms without jail support.
+type jail struct{}
+
+// newJail rejects a requested jail or user.
+func newJail(config *ProcessConfig, _ string) (*jail, error) {
+	if config.Jail == nil && config.User == nil {
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("%w: not supported on %s", ErrJailUnavailable, runtime.GOOS)
+}
+
+func (*jail) prepare(*exec.Cmd) {}
+
+func (*jail) release() {}
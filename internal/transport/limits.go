@@ -0,0 +1,27 @@
+package transport
+
+// ResourceLimits constrains the resources of a spawned process. Zero
+// fields are not limited.
+//
+// On Linux, MaxRSS and CPUShares are enforced with a cgroup v2 group
+// created for the process, and Niceness is applied at spawn. On Windows
+// all three are enforced with a job object. Other platforms support no
+// limits.
+type ResourceLimits struct {
+	// MaxRSS is the memory limit in bytes for the process and its
+	// children.
+	MaxRSS int64
+	// CPUShares is the process's relative CPU weight, where 1024 is the
+	// default weight of other processes.
+	CPUShares int
+	// Niceness is the scheduling nice value, from -20 (highest priority)
+	// to 19 (lowest).
+	Niceness int
+	// CgroupParent is the cgroup v2 directory the process's group is
+	// created under on Linux. It defaults to the SDK's own cgroup, which
+	// must have the memory and cpu controllers delegated.
+	CgroupParent string
+}
+
+// cpuWeightDefault is the CPUShares value that gets the default weight.
+const cpuWeightDefault = 1024
@@ -0,0 +1,225 @@
+//go:build linux
+
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	// cgroupRoot is where the cgroup v2 hierarchy is mounted.
+	cgroupRoot = "/sys/fs/cgroup"
+	// cpuWeightMax is the largest cgroup v2 cpu.weight.
+	cpuWeightMax = 10000
+	// cpuSharesMax is the largest cgroup v1 cpu.shares value.
+	cpuSharesMax = 262144
+)
+
+// limiter applies ResourceLimits to one process through a cgroup v2
+// group and the nice value it is forked with.
+type limiter struct {
+	niceness int
+	cgroup   string
+	cgroupFD *os.File
+}
+
+// newLimiter creates the cgroup for limits, if they need one. It returns
+// nil when limits is nil.
+func newLimiter(limits *ResourceLimits) (*limiter, error) {
+	if limits == nil {
+		return nil, nil
+	}
+
+	l := &limiter{niceness: limits.Niceness}
+	if limits.MaxRSS <= 0 && limits.CPUShares <= 0 {
+		return l, nil
+	}
+
+	if err := l.createCgroup(limits); err != nil {
+		l.release()
+
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// createCgroup creates the process's group under the configured parent
+// and writes its limits.
+func (l *limiter) createCgroup(limits *ResourceLimits) error {
+	parent := limits.CgroupParent
+	if parent == "" {
+		own, err := ownCgroup()
+		if err != nil {
+			return err
+		}
+		parent = own
+	}
+
+	if _, err := os.Stat(filepath.Join(parent, "cgroup.controllers")); err != nil {
+		return fmt.Errorf("%w: %s is not a cgroup v2 directory", ErrLimitsUnavailable, parent)
+	}
+
+	var controllers []string
+	if limits.MaxRSS > 0 {
+		controllers = append(controllers, "+memory")
+	}
+	if limits.CPUShares > 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	// Enabling may fail if already enabled or not delegated to us; a
+	// missing controller shows up when its limit file is written.
+	_ = os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte(strings.Join(controllers, " ")), 0)
+
+	dir, err := os.MkdirTemp(parent, "claude-agent-")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLimitsUnavailable, err)
+	}
+	l.cgroup = dir
+
+	if limits.MaxRSS > 0 {
+		if err := writeCgroupFile(dir, "memory.max", strconv.FormatInt(limits.MaxRSS, 10)); err != nil {
+			return err
+		}
+		// Without these the limit could be dodged by swapping, and an OOM
+		// would kill only part of the process tree.
+		_ = writeCgroupFile(dir, "memory.swap.max", "0")
+		_ = writeCgroupFile(dir, "memory.oom.group", "1")
+	}
+	if limits.CPUShares > 0 {
+		if err := writeCgroupFile(dir, "cpu.weight", strconv.Itoa(cpuWeight(limits.CPUShares))); err != nil {
+			return err
+		}
+	}
+
+	fd, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLimitsUnavailable, err)
+	}
+	l.cgroupFD = fd
+
+	return nil
+}
+
+// ownCgroup returns the directory of the calling process's cgroup v2
+// group.
+func ownCgroup() (string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrLimitsUnavailable, err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return filepath.Join(cgroupRoot, path), nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: no cgroup v2 hierarchy", ErrLimitsUnavailable)
+}
+
+// writeCgroupFile writes value to a control file of the group in dir.
+func writeCgroupFile(dir, name, value string) error {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0); err != nil {
+		return fmt.Errorf("%w: cannot set %s: %w", ErrLimitsUnavailable, name, err)
+	}
+
+	return nil
+}
+
+// cpuWeight converts cgroup v1 style CPU shares to a cgroup v2 weight,
+// using the same mapping as systemd and the OCI runtimes.
+func cpuWeight(shares int) int {
+	shares = min(max(shares, 2), cpuSharesMax)
+
+	return 1 + ((shares-2)*(cpuWeightMax-1))/(cpuSharesMax-2)
+}
+
+// prepare makes cmd start inside the limiter's cgroup, so the process
+// never runs unconstrained.
+func (l *limiter) prepare(cmd *exec.Cmd) {
+	if l == nil || l.cgroupFD == nil {
+		return
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(l.cgroupFD.Fd())
+}
+
+// start starts cmd. With a nice value it forks from a dedicated OS thread
+// reniced first, since a child inherits its forking thread's priority.
+func (l *limiter) start(cmd *exec.Cmd) error {
+	if l == nil || l.niceness == 0 {
+		return cmd.Start()
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		// The thread is never unlocked, so it exits with this goroutine
+		// and its changed priority can't leak to other goroutines.
+		runtime.LockOSThread()
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, syscall.Gettid(), l.niceness); err != nil {
+			errs <- fmt.Errorf("%w: cannot set niceness %d: %w", ErrLimitsUnavailable, l.niceness, err)
+
+			return
+		}
+		errs <- cmd.Start()
+	}()
+
+	return <-errs
+}
+
+// exceeded reports whether the cgroup's memory limit killed a process.
+func (l *limiter) exceeded() bool {
+	if l == nil || l.cgroup == "" {
+		return false
+	}
+
+	data, err := os.ReadFile(filepath.Join(l.cgroup, "memory.events"))
+	if err != nil {
+		return false
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if count, ok := strings.CutPrefix(scanner.Text(), "oom_kill "); ok {
+			return count != "0"
+		}
+	}
+
+	return false
+}
+
+// release removes the cgroup, killing any processes left in it.
+func (l *limiter) release() {
+	if l == nil {
+		return
+	}
+
+	if l.cgroupFD != nil {
+		_ = l.cgroupFD.Close()
+		l.cgroupFD = nil
+	}
+	if l.cgroup == "" {
+		return
+	}
+
+	if err := os.Remove(l.cgroup); errors.Is(err, syscall.EBUSY) {
+		_ = writeCgroupFile(l.cgroup, "cgroup.kill", "1")
+		_ = os.Remove(l.cgroup)
+	}
+	l.cgroup = ""
+}
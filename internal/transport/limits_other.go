@@ -0,0 +1,29 @@
+//go:build !linux && !windows
+
+package transport
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// limiter is a no-op on platforms without resource limit support.
+type limiter struct{}
+
+// newLimiter rejects any requested limit.
+func newLimiter(limits *ResourceLimits) (*limiter, error) {
+	if limits == nil || *limits == (ResourceLimits{}) {
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("%w: not supported on %s", ErrLimitsUnavailable, runtime.GOOS)
+}
+
+func (*limiter) prepare(*exec.Cmd) {}
+
+func (*limiter) start(cmd *exec.Cmd) error { return cmd.Start() }
+
+func (*limiter) exceeded() bool { return false }
+
+func (*limiter) release() {}
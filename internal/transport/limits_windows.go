@@ -0,0 +1,222 @@
+//go:build windows
+
+package transport
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// Job object constants from winnt.h.
+const (
+	jobObjectExtendedLimitInformation  = 9
+	jobObjectCPURateControlInformation = 15
+	jobObjectLimitPriorityClass        = 0x00000020
+	jobObjectLimitJobMemory            = 0x00000200
+	jobObjectLimitKillOnJobClose       = 0x00002000
+	jobObjectCPURateControlEnable      = 0x1
+	jobObjectCPURateControlWeightBased = 0x2
+	processSetQuota                    = 0x0100
+	processTerminate                   = 0x0001
+	highPriorityClass                  = 0x00000080
+	aboveNormalPriorityClass           = 0x00008000
+	normalPriorityClass                = 0x00000020
+	belowNormalPriorityClass           = 0x00004000
+	idlePriorityClass                  = 0x00000040
+	cpuRateWeightDefault               = 5
+	cpuRateWeightMax                   = 9
+)
+
+var (
+	kernel32                      = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObjectW          = kernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject   = kernel32.NewProc("SetInformationJobObject")
+	procQueryInformationJobObject = kernel32.NewProc("QueryInformationJobObject")
+	procAssignProcessToJobObject  = kernel32.NewProc("AssignProcessToJobObject")
+)
+
+// jobBasicLimitInformation mirrors JOBOBJECT_BASIC_LIMIT_INFORMATION.
+type jobBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+// ioCounters mirrors IO_COUNTERS.
+type ioCounters struct {
+	ReadOperationCount  uint64
+	WriteOperationCount uint64
+	OtherOperationCount uint64
+	ReadTransferCount   uint64
+	WriteTransferCount  uint64
+	OtherTransferCount  uint64
+}
+
+// jobExtendedLimitInformation mirrors JOBOBJECT_EXTENDED_LIMIT_INFORMATION.
+type jobExtendedLimitInformation struct {
+	BasicLimitInformation jobBasicLimitInformation
+	IoInfo                ioCounters
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// jobCPURateControlInformation mirrors
+// JOBOBJECT_CPU_RATE_CONTROL_INFORMATION with the Weight member.
+type jobCPURateControlInformation struct {
+	ControlFlags uint32
+	Weight       uint32
+}
+
+// limiter applies ResourceLimits to one process through a job object.
+// Closing the job kills any processes still in it.
+type limiter struct {
+	job      syscall.Handle
+	maxBytes uintptr
+}
+
+// newLimiter creates a job object carrying limits. It returns nil when
+// limits is nil.
+func newLimiter(limits *ResourceLimits) (*limiter, error) {
+	if limits == nil {
+		return nil, nil
+	}
+
+	job, _, err := procCreateJobObjectW.Call(0, 0)
+	if job == 0 {
+		return nil, fmt.Errorf("%w: cannot create job object: %w", ErrLimitsUnavailable, err)
+	}
+	l := &limiter{job: syscall.Handle(job)}
+
+	info := jobExtendedLimitInformation{}
+	info.BasicLimitInformation.LimitFlags = jobObjectLimitKillOnJobClose
+	if limits.MaxRSS > 0 {
+		l.maxBytes = uintptr(limits.MaxRSS)
+		info.BasicLimitInformation.LimitFlags |= jobObjectLimitJobMemory
+		info.JobMemoryLimit = l.maxBytes
+	}
+	if limits.Niceness != 0 {
+		info.BasicLimitInformation.LimitFlags |= jobObjectLimitPriorityClass
+		info.BasicLimitInformation.PriorityClass = priorityClass(limits.Niceness)
+	}
+	if err := l.set(jobObjectExtendedLimitInformation, unsafe.Pointer(&info), unsafe.Sizeof(info)); err != nil {
+		l.release()
+
+		return nil, err
+	}
+
+	if limits.CPUShares > 0 {
+		rate := jobCPURateControlInformation{
+			ControlFlags: jobObjectCPURateControlEnable | jobObjectCPURateControlWeightBased,
+			Weight:       cpuRateWeight(limits.CPUShares),
+		}
+		if err := l.set(jobObjectCPURateControlInformation, unsafe.Pointer(&rate), unsafe.Sizeof(rate)); err != nil {
+			l.release()
+
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// set calls SetInformationJobObject for one information class.
+func (l *limiter) set(class uintptr, info unsafe.Pointer, size uintptr) error {
+	ok, _, err := procSetInformationJobObject.Call(uintptr(l.job), class, uintptr(info), size)
+	if ok == 0 {
+		return fmt.Errorf("%w: cannot set job object limits: %w", ErrLimitsUnavailable, err)
+	}
+
+	return nil
+}
+
+// priorityClass maps a nice value to the nearest Windows priority class.
+func priorityClass(niceness int) uint32 {
+	switch {
+	case niceness <= -15:
+		return highPriorityClass
+	case niceness < 0:
+		return aboveNormalPriorityClass
+	case niceness == 0:
+		return normalPriorityClass
+	case niceness < 10:
+		return belowNormalPriorityClass
+	default:
+		return idlePriorityClass
+	}
+}
+
+// cpuRateWeight maps CPU shares to a job CPU weight from 1 to 9, where
+// the default 1024 shares maps to the default weight of 5.
+func cpuRateWeight(shares int) uint32 {
+	weight := (shares*cpuRateWeightDefault + cpuWeightDefault/2) / cpuWeightDefault
+
+	return uint32(min(max(weight, 1), cpuRateWeightMax))
+}
+
+func (*limiter) prepare(*exec.Cmd) {}
+
+// start starts cmd and assigns it to the job. The process runs briefly
+// before it is assigned, but children it spawns afterwards inherit the
+// job.
+func (l *limiter) start(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if l == nil {
+		return nil
+	}
+
+	handle, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(cmd.Process.Pid))
+	if err == nil {
+		defer func() { _ = syscall.CloseHandle(handle) }()
+		if ok, _, assignErr := procAssignProcessToJobObject.Call(uintptr(l.job), uintptr(handle)); ok == 0 {
+			err = assignErr
+		}
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+
+		return fmt.Errorf("%w: cannot assign process to job object: %w", ErrLimitsUnavailable, err)
+	}
+
+	return nil
+}
+
+// exceeded reports whether the job's memory use reached its limit.
+func (l *limiter) exceeded() bool {
+	if l == nil || l.maxBytes == 0 {
+		return false
+	}
+
+	var info jobExtendedLimitInformation
+	ok, _, _ := procQueryInformationJobObject.Call(
+		uintptr(l.job),
+		jobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)),
+		unsafe.Sizeof(info),
+		0,
+	)
+
+	return ok != 0 && info.PeakJobMemoryUsed >= l.maxBytes
+}
+
+// release closes the job, killing any processes left in it.
+func (l *limiter) release() {
+	if l == nil || l.job == 0 {
+		return
+	}
+
+	_ = syscall.CloseHandle(l.job)
+	l.job = 0
+}
@@ -3,6 +3,7 @@ package transport
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -20,6 +21,10 @@ type Process struct {
 	err       error
 	errOnce   sync.Once
 	mu        sync.Mutex
+	limiter   *limiter
+	jail      *jail
+	// limitExceeded is set before done is closed.
+	limitExceeded bool
 }
 
 // ProcessConfig configures process spawning.
@@ -29,6 +34,27 @@ type ProcessConfig struct {
 	Env           []string
 	Cwd           string
 	StderrHandler func(string)
+	// MaxMessageSize bounds a single stdout message in bytes. Zero uses
+	// DefaultMaxMessageSize; a negative value disables the limit.
+	MaxMessageSize int
+	// Codec frames messages on stdin and stdout. Nil uses NDJSON.
+	Codec Codec
+	// Faults, if set, injects faults into messages read from the process.
+	Faults *FaultConfig
+	// Limits, if set, constrains the process's resources.
+	Limits *ResourceLimits
+	// Jail, if set, confines the process's filesystem, on Linux.
+	Jail *JailConfig
+	// User, if set, is the user the process runs as, on Linux.
+	User *ProcessUser
+	// Daemon, if set, is the Unix socket or Windows named pipe of a
+	// claude daemon to run the CLI instead of spawning it; see
+	// DaemonRequest. Executable is then chosen by the daemon, and Limits,
+	// Jail and User cannot be applied.
+	Daemon string
+	// Mux, if set, runs the CLI as a session of this multiplexed daemon
+	// connection instead, with the same restrictions as Daemon.
+	Mux *DaemonMux
 }
 
 // NewProcess spawns a new Claude Code process.
@@ -40,28 +66,75 @@ func NewProcess(
 		return nil, ErrConfigRequired
 	}
 
+	if config.Daemon != "" || config.Mux != nil {
+		if config.Limits != nil || config.Jail != nil || config.User != nil {
+			return nil, fmt.Errorf("%w: limits, jail and user need a local process", ErrDaemon)
+		}
+		if config.Mux != nil {
+			return config.Mux.open(ctx, config)
+		}
+
+		return dialDaemon(ctx, config)
+	}
+
 	executable, err := resolveExecutable(config.Executable)
 	if err != nil {
 		return nil, err
 	}
 
+	jl, err := newJail(config, executable)
+	if err != nil {
+		return nil, err
+	}
+
+	lim, err := newLimiter(config.Limits)
+	if err != nil {
+		jl.release()
+
+		return nil, err
+	}
+
 	cmd := createCommand(ctx, executable, config)
+	jl.prepare(cmd)
+	lim.prepare(cmd)
 
 	pipes, err := createPipes(cmd)
 	if err != nil {
+		lim.release()
+		jl.release()
+
 		return nil, err
 	}
 
-	transport := NewStdioTransport(pipes.stdin, pipes.stdout, pipes.stderr)
+	stdio := NewStdioTransport(pipes.stdin, pipes.stdout, pipes.stderr)
+	if config.MaxMessageSize != 0 {
+		stdio.WithMaxMessageSize(config.MaxMessageSize)
+	}
+	if config.Codec != nil {
+		stdio.WithCodec(config.Codec)
+	}
+
+	err = lim.start(cmd)
+	// The child holds its own copy of the stdout write end now
+	_ = pipes.stdoutWriter.Close()
+	if err != nil {
+		_ = pipes.stdout.Close()
+		lim.release()
+		jl.release()
 
-	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf(errWrapFormat, ErrProcessStart, err)
 	}
 
 	proc := &Process{
 		cmd:       cmd,
-		transport: transport,
+		transport: stdio,
 		done:      make(chan struct{}),
+		limiter:   lim,
+		jail:      jl,
+	}
+
+	if config.Faults != nil {
+		proc.transport = NewChaosTransport(stdio, *config.Faults, cmd.Process.Kill)
 	}
 
 	if config.StderrHandler != nil {
@@ -111,35 +184,47 @@ type pipeSet struct {
 	stdin  io.WriteCloser
 	stdout io.ReadCloser
 	stderr io.ReadCloser
+	// stdoutWriter is the child's end of stdout, closed once it started.
+	stdoutWriter io.Closer
 }
 
 // createPipes creates stdin, stdout, and stderr pipes for the command.
+//
+// Stdout is an os.Pipe rather than cmd.StdoutPipe, which cmd.Wait closes
+// as soon as the process exits; output the process wrote just before
+// exiting would be lost, since waitInternal runs alongside the reader.
 func createPipes(cmd *exec.Cmd) (pipeSet, error) {
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return pipeSet{}, fmt.Errorf(errWrapFormat, ErrStdinPipe, err)
 	}
 
-	stdout, err := cmd.StdoutPipe()
+	stdout, stdoutWriter, err := os.Pipe()
 	if err != nil {
 		return pipeSet{}, fmt.Errorf(errWrapFormat, ErrStdoutPipe, err)
 	}
+	cmd.Stdout = stdoutWriter
 
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
+		_ = stdout.Close()
+		_ = stdoutWriter.Close()
+
 		return pipeSet{}, fmt.Errorf(errWrapFormat, ErrStderrPipe, err)
 	}
 
 	return pipeSet{
-		stdin:  stdin,
-		stdout: stdout,
-		stderr: stderr,
+		stdin:        stdin,
+		stdout:       stdout,
+		stderr:       stderr,
+		stdoutWriter: stdoutWriter,
 	}, nil
 }
 
 // handleStderr reads from stderr and calls the handler for each line.
 func (*Process) handleStderr(stderr io.Reader, handler func(string)) {
 	scanner := bufio.NewScanner(stderr)
+	scanner.Buffer(make([]byte, 0, readBufferSize), DefaultMaxMessageSize)
 	for scanner.Scan() {
 		handler(scanner.Text())
 	}
@@ -148,12 +233,27 @@ func (*Process) handleStderr(stderr io.Reader, handler func(string)) {
 // waitInternal waits for the process to complete.
 func (p *Process) waitInternal() {
 	err := p.cmd.Wait()
+	exceeded := p.limiter.exceeded()
+	p.limiter.release()
+	p.jail.release()
 	p.errOnce.Do(func() {
 		p.err = err
+		p.limitExceeded = exceeded
 		close(p.done)
 	})
 }
 
+// LimitExceeded reports whether the process ran into its resource limits,
+// for example by being OOM-killed. It is false until the process exits.
+func (p *Process) LimitExceeded() bool {
+	select {
+	case <-p.done:
+		return p.limitExceeded
+	default:
+		return false
+	}
+}
+
 // Transport returns the process transport.
 func (p *Process) Transport() Transport {
 	return p.transport
@@ -179,9 +279,17 @@ func (p *Process) Close() error {
 		return fmt.Errorf(errWrapFormat, ErrTransportClose, err)
 	}
 
+	// A daemon's CLI stops when its connection closes
+	if p.cmd == nil {
+		p.errOnce.Do(func() { close(p.done) })
+
+		return nil
+	}
+
 	// Kill the process if it's still running
 	if p.cmd.Process != nil {
-		if err := p.cmd.Process.Kill(); err != nil {
+		err := p.cmd.Process.Kill()
+		if err != nil && !errors.Is(err, os.ErrProcessDone) {
 			return fmt.Errorf(errWrapFormat, ErrProcessKill, err)
 		}
 	}
@@ -1,10 +1,21 @@
 package transport
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+)
+
+const (
+	// DefaultMaxMessageSize is the default upper bound for a single
+	// line-delimited message read from stdout.
+	DefaultMaxMessageSize = 10 * 1024 * 1024
+
+	// readBufferSize is the size of the buffered stdout reader. Messages
+	// larger than this are reassembled from multiple fragments.
+	readBufferSize = 64 * 1024
 )
 
 // Transport handles communication with Claude Code process.
@@ -21,26 +32,51 @@ type Transport interface {
 
 // StdioTransport implements Transport using stdio.
 type StdioTransport struct {
-	stdin  io.WriteCloser
-	stdout io.ReadCloser
-	stderr io.ReadCloser
-	reader *bufio.Reader
+	stdin          io.WriteCloser
+	stdout         io.ReadCloser
+	stderr         io.ReadCloser
+	encoder        Encoder
+	decoder        Decoder
+	maxMessageSize int
 }
 
-// NewStdioTransport creates a new stdio transport.
+// NewStdioTransport creates a new stdio transport framing messages with
+// NDJSON.
 func NewStdioTransport(
 	stdin io.WriteCloser,
 	stdout, stderr io.ReadCloser,
 ) *StdioTransport {
 	return &StdioTransport{
-		stdin:  stdin,
-		stdout: stdout,
-		stderr: stderr,
-		reader: bufio.NewReader(stdout),
+		stdin:          stdin,
+		stdout:         stdout,
+		stderr:         stderr,
+		encoder:        NDJSON.NewEncoder(stdin),
+		decoder:        NDJSON.NewDecoder(stdout),
+		maxMessageSize: DefaultMaxMessageSize,
+	}
+}
+
+// WithMaxMessageSize sets the maximum size in bytes of a single message.
+// A value of 0 or less disables the limit.
+func (t *StdioTransport) WithMaxMessageSize(size int) *StdioTransport {
+	t.maxMessageSize = size
+	if lines, ok := t.decoder.(*lineDecoder); ok {
+		lines.maxMessageSize = size
 	}
+
+	return t
+}
+
+// WithCodec frames messages with codec instead of NDJSON. It must be
+// called before the first Read or Write.
+func (t *StdioTransport) WithCodec(codec Codec) *StdioTransport {
+	t.encoder = codec.NewEncoder(t.stdin)
+	t.decoder = codec.NewDecoder(t.stdout)
+
+	return t.WithMaxMessageSize(t.maxMessageSize)
 }
 
-// Read reads a line-delimited JSON message from stdout.
+// Read reads the next message from stdout.
 func (t *StdioTransport) Read(ctx context.Context) ([]byte, error) {
 	// Create a channel to receive the result
 	type result struct {
@@ -50,21 +86,8 @@ func (t *StdioTransport) Read(ctx context.Context) ([]byte, error) {
 	resultChan := make(chan result, 1)
 
 	go func() {
-		line, err := t.reader.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				resultChan <- result{nil, err}
-
-				return
-			}
-			resultChan <- result{
-				nil,
-				fmt.Errorf(errWrapFormat, ErrReadFailed, err),
-			}
-
-			return
-		}
-		resultChan <- result{line, nil}
+		data, err := t.readFrame()
+		resultChan <- result{data, err}
 	}()
 
 	select {
@@ -75,16 +98,32 @@ func (t *StdioTransport) Read(ctx context.Context) ([]byte, error) {
 	}
 }
 
-// Write writes a line-delimited JSON message to stdin.
+// readFrame decodes the next message. NDJSON enforces maxMessageSize
+// while reading; for other codecs it is checked once a message is decoded.
+func (t *StdioTransport) readFrame() ([]byte, error) {
+	data, err := t.decoder.Decode()
+	if err != nil {
+		return nil, err
+	}
+
+	if t.maxMessageSize > 0 && len(data) > t.maxMessageSize {
+		return nil, fmt.Errorf(
+			"%w: message exceeds %d bytes",
+			ErrMessageTooLarge,
+			t.maxMessageSize,
+		)
+	}
+
+	return data, nil
+}
+
+// Write writes a message to stdin.
 func (t *StdioTransport) Write(ctx context.Context, data []byte) error {
 	// Create a channel to signal completion
 	errChan := make(chan error, 1)
 
 	go func() {
-		// Add newline delimiter.
-		message := append([]byte(nil), data...)
-		message = append(message, '\n')
-		_, err := t.stdin.Write(message)
+		err := t.encoder.Encode(data)
 		if err != nil {
 			errChan <- fmt.Errorf(errWrapFormat, ErrWriteFailed, err)
 
@@ -103,17 +142,11 @@ func (t *StdioTransport) Write(ctx context.Context, data []byte) error {
 
 // Close closes all streams.
 func (t *StdioTransport) Close() error {
-	err := t.stdin.Close()
-	if err != nil {
-		return err
-	}
-	err = t.stdout.Close()
-	if err != nil {
-		return err
-	}
-	err = t.stderr.Close()
-	if err != nil {
-		return err
+	for _, c := range []io.Closer{t.stdin, t.stdout, t.stderr} {
+		// exec.Cmd.Wait closes the pipes once the process exits
+		if err := c.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+			return err
+		}
 	}
 
 	return nil
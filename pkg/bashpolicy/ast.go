@@ -0,0 +1,122 @@
+// Package bashpolicy evaluates Bash tool commands against allow and deny
+// policies.
+//
+// Commands are parsed into a small shell syntax tree rather than matched
+// with regular expressions, so policies see every executable in pipes,
+// lists, subshells, and command substitutions, along with its arguments and
+// redirects. Constructs the parser does not model (control flow, functions,
+// heredocs) are parse errors, which policies treat as denials.
+package bashpolicy
+
+// Script is a parsed command line: statements separated by ;, &, &&, ||, or
+// newlines.
+type Script struct {
+	Statements []*Statement
+}
+
+// Statement is a pipeline and the operator that follows it.
+type Statement struct {
+	Pipeline *Pipeline
+	// Op is the operator after the pipeline: "&&", "||", ";", "&", or empty
+	// for the last statement.
+	Op string
+}
+
+// Pipeline is one or more commands connected by pipes.
+type Pipeline struct {
+	Commands []*Command
+	// Negated reports a leading "!".
+	Negated bool
+}
+
+// Command is a simple command or a subshell.
+type Command struct {
+	// Assigns are leading NAME=value words.
+	Assigns []Word
+	// Words are the command name followed by its arguments. Empty for a
+	// subshell or a command consisting only of assignments.
+	Words []Word
+	// Redirects are the command's redirections, in order.
+	Redirects []Redirect
+	// Subshell is the body of a "( ... )" command.
+	Subshell *Script
+	// Substitutions are the scripts of command and process substitutions
+	// appearing in the command's words and redirects.
+	Substitutions []*Script
+}
+
+// Name returns the command name, or an empty word for subshells.
+func (c *Command) Name() Word {
+	if len(c.Words) == 0 {
+		return Word{}
+	}
+
+	return c.Words[0]
+}
+
+// Args returns the command's arguments.
+func (c *Command) Args() []Word {
+	if len(c.Words) < 2 {
+		return nil
+	}
+
+	return c.Words[1:]
+}
+
+// Word is a shell word after quote removal.
+type Word struct {
+	// Value is the word with quotes removed. Expansions are kept as written,
+	// e.g. "$HOME/x".
+	Value string
+	// Dynamic reports that the word contains parameter, arithmetic, or
+	// command expansion, so its runtime value is unknown.
+	Dynamic bool
+	// Glob reports unquoted pathname expansion characters (*, ?, [).
+	Glob bool
+}
+
+// Literal reports whether the word's runtime value is exactly Value.
+func (w Word) Literal() bool {
+	return !w.Dynamic && !w.Glob
+}
+
+// Redirect is a redirection such as "2>&1" or ">> log.txt".
+type Redirect struct {
+	// Fd is the explicit file descriptor, or -1 when omitted.
+	Fd int
+	// Op is the operator: "<", ">", ">>", ">|", "<>", "&>", "&>>", ">&",
+	// "<&", or "<<<".
+	Op string
+	// Target is the file, descriptor, or here-string.
+	Target Word
+}
+
+// Writes reports whether the redirect opens its target for writing. File
+// descriptor duplication such as "2>&1" does not write a file.
+func (r Redirect) Writes() bool {
+	switch r.Op {
+	case ">", ">>", ">|", "<>", "&>", "&>>":
+		return true
+	case ">&":
+		return !r.duplicatesFd()
+	default:
+		return false
+	}
+}
+
+// duplicatesFd reports whether a >& or <& target names a descriptor.
+func (r Redirect) duplicatesFd() bool {
+	if !r.Target.Literal() || r.Target.Value == "" {
+		return false
+	}
+	if r.Target.Value == "-" {
+		return true
+	}
+	for _, c := range r.Target.Value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,634 @@
+package bashpolicy
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// reservedWords start compound commands the parser does not model.
+var reservedWords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true,
+	"do": true, "done": true, "case": true, "esac": true, "while": true,
+	"until": true, "for": true, "select": true, "function": true,
+	"coproc": true, "{": true, "}": true, "[[": true, "]]": true,
+}
+
+// redirectOps are the redirection operators, longest first.
+var redirectOps = []string{
+	"<<<", "<<-", "<<", "&>>", "&>", ">>", ">|", ">&", "<&", "<>", ">", "<",
+}
+
+// assignmentPattern matches the NAME part of a NAME=value word.
+var assignmentPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parser is a recursive-descent parser over a command line.
+type parser struct {
+	src    []rune
+	pos    int
+	source string
+}
+
+// word is a parsed word with the details needed to classify it.
+type word struct {
+	Word
+	// quoted reports any quoting or escaping in the word.
+	quoted bool
+	// plain is the number of leading runes of Value that were unquoted
+	// literals.
+	plain int
+}
+
+// Parse parses a Bash command line. Syntax errors and unsupported
+// constructs are reported as a *clauderrs.ValidationError.
+func Parse(command string) (*Script, error) {
+	p := &parser{src: []rune(command), source: command}
+
+	script, err := p.parseScript(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+
+	return script, nil
+}
+
+// parseScript parses statements until EOF or the terminator rune.
+func (p *parser) parseScript(term rune) (*Script, error) {
+	script := &Script{}
+
+	for {
+		p.skipSpace(true)
+		if p.eof() || (term != 0 && p.peek() == term) {
+			return script, nil
+		}
+
+		pipeline, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		stmt := &Statement{Pipeline: pipeline}
+		script.Statements = append(script.Statements, stmt)
+
+		p.skipSpace(false)
+		switch {
+		case p.eof(), term != 0 && p.peek() == term:
+			return script, nil
+		case p.hasPrefix(";;"):
+			return nil, p.errorf("case clauses are not supported")
+		case p.hasPrefix("&&"), p.hasPrefix("||"):
+			stmt.Op = string(p.src[p.pos : p.pos+2])
+			p.pos += 2
+			p.skipSpace(true)
+			if p.eof() || (term != 0 && p.peek() == term) {
+				return nil, p.errorf("expected command after %s", stmt.Op)
+			}
+		case p.peek() == ';', p.peek() == '&', p.peek() == '\n':
+			stmt.Op = ";"
+			if p.peek() == '&' {
+				stmt.Op = "&"
+			}
+			p.pos++
+		default:
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+	}
+}
+
+// parsePipeline parses commands joined by | or |&.
+func (p *parser) parsePipeline() (*Pipeline, error) {
+	pipeline := &Pipeline{}
+
+	p.skipSpace(false)
+	if p.peek() == '!' && isBlank(p.peekAt(1)) {
+		pipeline.Negated = true
+		p.pos++
+	}
+
+	for {
+		cmd, err := p.parseCommand()
+		if err != nil {
+			return nil, err
+		}
+		pipeline.Commands = append(pipeline.Commands, cmd)
+
+		p.skipSpace(false)
+		if p.peek() != '|' || p.hasPrefix("||") {
+			return pipeline, nil
+		}
+		p.pos++
+		if p.peek() == '&' {
+			p.pos++
+		}
+		p.skipSpace(true)
+	}
+}
+
+// parseCommand parses a simple command or a subshell.
+func (p *parser) parseCommand() (*Command, error) {
+	cmd := &Command{}
+
+	p.skipSpace(false)
+	if p.peek() == '(' {
+		return p.parseSubshell(cmd)
+	}
+
+	for {
+		p.skipSpace(false)
+		if p.eof() {
+			break
+		}
+		ok, err := p.parseRedirect(cmd)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			continue
+		}
+		if p.atOperator() {
+			break
+		}
+
+		w, err := p.parseWord(cmd)
+		if err != nil {
+			return nil, err
+		}
+		if len(cmd.Words) == 0 && w.isAssignment() {
+			cmd.Assigns = append(cmd.Assigns, w.Word)
+
+			continue
+		}
+		if len(cmd.Words) == 0 && !w.quoted && !w.Dynamic && reservedWords[w.Value] {
+			return nil, p.errorf("%q compound commands are not supported", w.Value)
+		}
+		cmd.Words = append(cmd.Words, w.Word)
+	}
+
+	if len(cmd.Words) == 0 && len(cmd.Assigns) == 0 && len(cmd.Redirects) == 0 {
+		if p.eof() {
+			return nil, p.errorf("expected command")
+		}
+
+		return nil, p.errorf("expected command before %q", p.peek())
+	}
+
+	return cmd, nil
+}
+
+// parseSubshell parses "( script )" and any redirects after it.
+func (p *parser) parseSubshell(cmd *Command) (*Command, error) {
+	p.pos++
+	body, err := p.parseScript(')')
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ')' {
+		return nil, p.errorf("unterminated subshell")
+	}
+	p.pos++
+	cmd.Subshell = body
+
+	for {
+		p.skipSpace(false)
+		if p.eof() || p.atOperator() {
+			return cmd, nil
+		}
+		ok, err := p.parseRedirect(cmd)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, p.errorf("unexpected word after subshell")
+		}
+	}
+}
+
+// parseRedirect parses a redirection at the current position, reporting
+// false if there is none.
+func (p *parser) parseRedirect(cmd *Command) (bool, error) {
+	start := p.pos
+	fd := -1
+	for !p.eof() && p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	if p.pos > start {
+		if p.peek() != '<' && p.peek() != '>' {
+			p.pos = start
+
+			return false, nil
+		}
+		fd, _ = strconv.Atoi(string(p.src[start:p.pos]))
+	}
+
+	if (p.peek() == '<' || p.peek() == '>') && p.peekAt(1) == '(' {
+		p.pos = start
+
+		return false, nil
+	}
+
+	op := ""
+	for _, candidate := range redirectOps {
+		if p.hasPrefix(candidate) {
+			op = candidate
+
+			break
+		}
+	}
+	if op == "" || (fd >= 0 && strings.HasPrefix(op, "&")) {
+		p.pos = start
+
+		return false, nil
+	}
+	if op == "<<" || op == "<<-" {
+		return false, p.errorf("heredocs are not supported")
+	}
+	p.pos += len([]rune(op))
+
+	p.skipSpace(false)
+	if p.eof() || p.atOperator() || p.peek() == '<' || p.peek() == '>' {
+		return false, p.errorf("missing target for %s", op)
+	}
+	target, err := p.parseWord(cmd)
+	if err != nil {
+		return false, err
+	}
+	cmd.Redirects = append(cmd.Redirects, Redirect{Fd: fd, Op: op, Target: target.Word})
+
+	return true, nil
+}
+
+// parseWord parses one word, recording substitutions on cmd.
+func (p *parser) parseWord(cmd *Command) (word, error) {
+	var (
+		w     word
+		b     strings.Builder
+		plain = true
+		start = p.pos
+	)
+
+	for !p.eof() {
+		c := p.peek()
+		literal := false
+
+		switch {
+		case (c == '<' || c == '>') && p.peekAt(1) == '(' && p.pos == start:
+			p.pos += 2
+			if err := p.parseSubstitution(cmd); err != nil {
+				return w, err
+			}
+			b.WriteString(string(p.src[start:p.pos]))
+			w.Dynamic = true
+		case isBlank(c) || c == '\n' || isOperator(c) || c == '<' || c == '>':
+			w.Value = b.String()
+
+			return w, nil
+		case c == '\\':
+			w.quoted = true
+			switch {
+			case p.peekAt(1) == '\n':
+			case p.pos+1 < len(p.src):
+				b.WriteRune(p.src[p.pos+1])
+			default:
+				b.WriteRune(c)
+			}
+			p.pos += 2
+		case c == '\'':
+			end := p.indexFrom(p.pos+1, '\'')
+			if end < 0 {
+				return w, p.errorf("unterminated single quote")
+			}
+			w.quoted = true
+			b.WriteString(string(p.src[p.pos+1 : end]))
+			p.pos = end + 1
+		case c == '"':
+			w.quoted = true
+			p.pos++
+			if err := p.parseDoubleQuoted(cmd, &w, &b); err != nil {
+				return w, err
+			}
+		case c == '$':
+			if err := p.parseDollar(cmd, &w, &b, false); err != nil {
+				return w, err
+			}
+		case c == '`':
+			if err := p.parseBacktick(cmd, &w, &b); err != nil {
+				return w, err
+			}
+		default:
+			if c == '*' || c == '?' ||
+				(c == '[' && p.closesInWord(']')) ||
+				(c == '{' && p.closesInWord('}')) {
+				w.Glob = true
+			}
+			b.WriteRune(c)
+			p.pos++
+			literal = true
+		}
+
+		if !literal {
+			plain = false
+		}
+		if plain {
+			w.plain++
+		}
+	}
+	w.Value = b.String()
+
+	return w, nil
+}
+
+// parseDoubleQuoted parses the body of a double-quoted string after the
+// opening quote.
+func (p *parser) parseDoubleQuoted(cmd *Command, w *word, b *strings.Builder) error {
+	for !p.eof() {
+		c := p.peek()
+		switch c {
+		case '"':
+			p.pos++
+
+			return nil
+		case '\\':
+			next := p.peekAt(1)
+			switch next {
+			case '$', '`', '"', '\\':
+				b.WriteRune(next)
+			case '\n':
+			default:
+				b.WriteRune(c)
+				p.pos++
+
+				continue
+			}
+			p.pos += 2
+		case '$':
+			if err := p.parseDollar(cmd, w, b, true); err != nil {
+				return err
+			}
+		case '`':
+			if err := p.parseBacktick(cmd, w, b); err != nil {
+				return err
+			}
+		default:
+			b.WriteRune(c)
+			p.pos++
+		}
+	}
+
+	return p.errorf("unterminated double quote")
+}
+
+// parseDollar parses a $ expansion.
+func (p *parser) parseDollar(cmd *Command, w *word, b *strings.Builder, inDouble bool) error {
+	start := p.pos
+	next := p.peekAt(1)
+
+	switch {
+	case next == '(' && p.peekAt(2) == '(':
+		if err := p.skipArithmetic(); err != nil {
+			return err
+		}
+	case next == '(':
+		p.pos += 2
+		if err := p.parseSubstitution(cmd); err != nil {
+			return err
+		}
+	case next == '{':
+		end := p.indexFrom(p.pos+2, '}')
+		if end < 0 {
+			return p.errorf("unterminated parameter expansion")
+		}
+		body := string(p.src[p.pos+2 : end])
+		if strings.ContainsAny(body, "$`") {
+			return p.errorf("nested expansions in ${...} are not supported")
+		}
+		p.pos = end + 1
+	case next == '\'' && !inDouble:
+		end := p.indexFrom(p.pos+2, '\'')
+		for end > 0 && p.src[end-1] == '\\' {
+			end = p.indexFrom(end+1, '\'')
+		}
+		if end < 0 {
+			return p.errorf("unterminated $'...' string")
+		}
+		p.pos = end + 1
+	case next == '"' && !inDouble:
+		// $"..." is a translated string; the quote is parsed next.
+		p.pos++
+		w.quoted = true
+
+		return nil
+	case isNameStart(next):
+		p.pos++
+		for !p.eof() && isNameChar(p.peek()) {
+			p.pos++
+		}
+	case (next >= '0' && next <= '9') || strings.ContainsRune("@*#?-$!", next) && next != 0:
+		p.pos += 2
+	default:
+		b.WriteRune('$')
+		p.pos++
+
+		return nil
+	}
+
+	b.WriteString(string(p.src[start:p.pos]))
+	w.Dynamic = true
+
+	return nil
+}
+
+// parseSubstitution parses the script of $(...), <(...), or >(...) after
+// the opening parenthesis.
+func (p *parser) parseSubstitution(cmd *Command) error {
+	sub, err := p.parseScript(')')
+	if err != nil {
+		return err
+	}
+	if p.peek() != ')' {
+		return p.errorf("unterminated command substitution")
+	}
+	p.pos++
+	cmd.Substitutions = append(cmd.Substitutions, sub)
+
+	return nil
+}
+
+// parseBacktick parses a `...` command substitution.
+func (p *parser) parseBacktick(cmd *Command, w *word, b *strings.Builder) error {
+	start := p.pos
+	var inner strings.Builder
+
+	p.pos++
+	for {
+		if p.eof() {
+			return p.errorf("unterminated backquote")
+		}
+		c := p.peek()
+		if c == '`' {
+			p.pos++
+
+			break
+		}
+		if c == '\\' && strings.ContainsRune("`\\$", p.peekAt(1)) && p.peekAt(1) != 0 {
+			inner.WriteRune(p.peekAt(1))
+			p.pos += 2
+
+			continue
+		}
+		inner.WriteRune(c)
+		p.pos++
+	}
+
+	sub, err := Parse(inner.String())
+	if err != nil {
+		return err
+	}
+	cmd.Substitutions = append(cmd.Substitutions, sub)
+	b.WriteString(string(p.src[start:p.pos]))
+	w.Dynamic = true
+
+	return nil
+}
+
+// skipArithmetic skips a $((...)) expansion, rejecting command
+// substitutions inside it.
+func (p *parser) skipArithmetic() error {
+	depth := 0
+	for p.pos += 1; !p.eof(); p.pos++ {
+		switch p.peek() {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				p.pos++
+
+				return nil
+			}
+		case '`':
+			return p.errorf("command substitution in arithmetic expansion is not supported")
+		case '$':
+			if p.peekAt(1) == '(' && p.peekAt(2) != '(' {
+				return p.errorf("command substitution in arithmetic expansion is not supported")
+			}
+		}
+	}
+
+	return p.errorf("unterminated arithmetic expansion")
+}
+
+// skipSpace skips blanks, line continuations, and comments, and newlines
+// too when newlines is set.
+func (p *parser) skipSpace(newlines bool) {
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case isBlank(c):
+			p.pos++
+		case c == '\\' && p.peekAt(1) == '\n':
+			p.pos += 2
+		case c == '\n' && newlines:
+			p.pos++
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// closesInWord reports whether close appears later in the current word.
+func (p *parser) closesInWord(close rune) bool {
+	for i := p.pos + 1; i < len(p.src); i++ {
+		c := p.src[i]
+		if c == close {
+			return true
+		}
+		if isBlank(c) || c == '\n' || isOperator(c) || c == '<' || c == '>' {
+			return false
+		}
+	}
+
+	return false
+}
+
+// indexFrom returns the index of the first r at or after from, or -1.
+func (p *parser) indexFrom(from int, r rune) int {
+	for i := from; i < len(p.src); i++ {
+		if p.src[i] == r {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() rune {
+	return p.peekAt(0)
+}
+
+// peekAt returns the rune at offset from the current position, or 0.
+func (p *parser) peekAt(offset int) rune {
+	if p.pos+offset >= len(p.src) {
+		return 0
+	}
+
+	return p.src[p.pos+offset]
+}
+
+func (p *parser) hasPrefix(s string) bool {
+	return strings.HasPrefix(string(p.src[p.pos:]), s)
+}
+
+// atOperator reports whether the current rune ends a command.
+func (p *parser) atOperator() bool {
+	return isOperator(p.peek()) || p.peek() == '\n'
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return clauderrs.NewValidationError(
+		clauderrs.ErrCodeInvalidFormat,
+		fmt.Sprintf("bash syntax error at offset %d: %s", p.pos, fmt.Sprintf(format, args...)),
+		nil,
+		"command",
+		p.source,
+	)
+}
+
+// isAssignment reports whether the word is NAME=value with an unquoted
+// name.
+func (w word) isAssignment() bool {
+	name, _, ok := strings.Cut(w.Value, "=")
+	if !ok || len([]rune(name)) >= w.plain {
+		return false
+	}
+
+	return assignmentPattern.MatchString(name)
+}
+
+func isBlank(c rune) bool {
+	return c == ' ' || c == '\t'
+}
+
+func isOperator(c rune) bool {
+	return c == ';' || c == '&' || c == '|' || c == '(' || c == ')'
+}
+
+func isNameStart(c rune) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c rune) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
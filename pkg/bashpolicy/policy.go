@@ -0,0 +1,597 @@
+package bashpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+const (
+	// bashTool is the tool whose commands a policy checks.
+	bashTool = "Bash"
+	// maxDepth bounds nesting through substitutions, subshells, and
+	// wrapper commands.
+	maxDepth = 8
+)
+
+// shells run the script passed with -c.
+var shells = []string{"sh", "bash", "zsh", "dash", "ksh"}
+
+// wrapper describes a command that runs another command from its
+// arguments.
+type wrapper struct {
+	// valueFlags take the following argument as their value. A short flag
+	// ending a cluster, as in -Eu, takes it too; one inside a cluster takes
+	// the rest of it, as in -uroot.
+	valueFlags []string
+	// opaqueFlags make the wrapped command impossible to determine, also
+	// inside a cluster of short flags.
+	opaqueFlags []string
+	// positional is the number of non-flag arguments before the command.
+	positional int
+	// assigns skips NAME=value arguments.
+	assigns bool
+}
+
+// wrappers are checked both as commands and for the command they run.
+var wrappers = map[string]wrapper{
+	"builtin": {},
+	"chroot": {
+		valueFlags: []string{"--userspec", "--groups"},
+		positional: 1,
+	},
+	"chrt":    {valueFlags: []string{"-T", "-P", "-D"}, positional: 1},
+	"command": {},
+	"doas":    {valueFlags: []string{"-u", "-C"}},
+	"env": {
+		valueFlags:  []string{"-u", "--unset", "-C", "--chdir"},
+		opaqueFlags: []string{"-S", "--split-string", "-P"},
+		assigns:     true,
+	},
+	"exec": {valueFlags: []string{"-a"}},
+	"flock": {
+		valueFlags:  []string{"-w", "--timeout", "-E", "--conflict-exit-code"},
+		opaqueFlags: []string{"-c", "--command"},
+		positional:  1,
+	},
+	"ionice": {
+		valueFlags: []string{"-c", "--class", "-n", "--classdata", "-p", "--pid", "-P", "--pgid", "-u", "--uid"},
+	},
+	"nice":  {valueFlags: []string{"-n", "--adjustment"}},
+	"nohup": {},
+	"nsenter": {
+		valueFlags: []string{"-t", "--target", "-S", "--setuid", "-G", "--setgid"},
+	},
+	"prlimit": {valueFlags: []string{"-p", "--pid", "-o", "--output"}},
+	"setsid":  {},
+	"stdbuf":  {valueFlags: []string{"-i", "-o", "-e", "--input", "--output", "--error"}},
+	"sudo": {
+		valueFlags: []string{
+			"-u", "--user", "-g", "--group", "-C", "--close-from", "-D", "--chdir",
+			"-h", "--host", "-p", "--prompt", "-R", "--chroot", "-r", "--role",
+			"-t", "--type", "-T", "--command-timeout", "-U", "--other-user",
+		},
+	},
+	"taskset": {positional: 1},
+	"time":    {},
+	"timeout": {valueFlags: []string{"-s", "--signal", "-k", "--kill-after"}, positional: 1},
+	"unshare": {
+		valueFlags: []string{
+			"-S", "--setuid", "-G", "--setgid", "-R", "--root", "-w", "--wd",
+			"--map-user", "--map-group", "--propagation", "--setgroups",
+		},
+	},
+	"xargs": {
+		valueFlags: []string{"-I", "-n", "-d", "-P", "-L", "-E", "-s", "-a"},
+	},
+}
+
+// Rule matches simple commands by executable and arguments.
+//
+// Patterns use * for any run of characters, including "/", and ? for any
+// single character. An argument containing an expansion or unquoted glob
+// only matches an allow pattern of "*", but matches every deny pattern,
+// since it could expand to anything.
+type Rule struct {
+	// Command matches the executable. A bare name such as "rm" matches the
+	// command invoked by name; deny rules also match it invoked by path,
+	// e.g. /bin/rm. A pattern containing "/" matches the path as written.
+	// "*" matches any command.
+	Command string
+	// Args are patterns for the leading arguments, matched in order. Extra
+	// arguments are allowed.
+	Args []string
+	// AnyArg, if set, requires at least one argument to match one of the
+	// patterns, e.g. "-*r*" for recursive rm.
+	AnyArg []string
+}
+
+// Policy decides whether a Bash command may run.
+//
+// Every simple command in the line, including those in pipelines,
+// subshells, command substitutions, and commands run through wrappers such
+// as sudo, env, xargs, or "bash -c", must match an Allow rule and no Deny
+// rule. Allowing a command also allows whatever it can run itself, such as
+// find -exec; add Deny rules for those arguments. Variable assignments,
+// such as "PATH=/tmp ls" or "env LD_PRELOAD=x.so ls", are denied unless
+// the variable is in AllowAssignments.
+type Policy struct {
+	Allow []Rule
+	Deny  []Rule
+	// WriteTargets are patterns of files output redirects may write, e.g.
+	// "/dev/null" or "/tmp/*". Other output redirects are denied.
+	WriteTargets []string
+	// AllowAssignments are patterns of the variables commands may assign,
+	// before a command, alone, or through env, e.g. "LC_*". Assignments
+	// can change what an allowed command runs (PATH, LD_PRELOAD,
+	// GIT_SSH_COMMAND), so other variables are denied.
+	AllowAssignments []string
+	// AllowSubstitution permits $(...), `...`, <(...), and >(...). The
+	// substituted commands are still checked.
+	AllowSubstitution bool
+	// AllowSubshells permits "( ... )". The commands inside are still
+	// checked.
+	AllowSubshells bool
+	// AllowBackground permits commands ending in &.
+	AllowBackground bool
+	// AllowDynamicCommands permits command names containing expansions,
+	// such as "$EDITOR file". They only match "*" allow rules and match
+	// every deny rule.
+	AllowDynamicCommands bool
+}
+
+// Decision is the outcome of evaluating a command.
+type Decision struct {
+	Allowed bool
+	// Reason explains a denial in terms the model can act on.
+	Reason string
+}
+
+// Evaluate parses command and checks it against the policy. Commands that
+// cannot be parsed are denied.
+func (p *Policy) Evaluate(command string) Decision {
+	script, err := Parse(command)
+	if err != nil {
+		return deny("command could not be parsed: %v", err)
+	}
+
+	return p.evalScript(script, 0)
+}
+
+// CanUseTool returns a permission callback that denies Bash commands the
+// policy rejects and delegates everything else to next, allowing it when
+// next is nil.
+func (p *Policy) CanUseTool(next claude.CanUseToolFunc) claude.CanUseToolFunc {
+	return func(
+		ctx context.Context,
+		toolName string,
+		input map[string]claude.JSONValue,
+		suggestions []claude.PermissionUpdate,
+		toolUseID string,
+		agentID *string,
+		blockedPath *string,
+		decisionReason *string,
+	) (claude.PermissionResult, error) {
+		if toolName == bashTool {
+			var command string
+			if err := json.Unmarshal(input["command"], &command); err != nil {
+				return &claude.PermissionDeny{
+					Behavior: claude.PermissionBehaviorDeny,
+					Message:  "Bash input has no command",
+				}, nil
+			}
+
+			if decision := p.Evaluate(command); !decision.Allowed {
+				return &claude.PermissionDeny{
+					Behavior: claude.PermissionBehaviorDeny,
+					Message:  decision.Reason,
+				}, nil
+			}
+		}
+
+		if next == nil {
+			return &claude.PermissionAllow{Behavior: claude.PermissionBehaviorAllow}, nil
+		}
+
+		return next(
+			ctx,
+			toolName,
+			input,
+			suggestions,
+			toolUseID,
+			agentID,
+			blockedPath,
+			decisionReason,
+		)
+	}
+}
+
+// evalScript checks every statement of a script.
+func (p *Policy) evalScript(script *Script, depth int) Decision {
+	if depth > maxDepth {
+		return deny("command nesting is too deep")
+	}
+
+	for _, stmt := range script.Statements {
+		if stmt.Op == "&" && !p.AllowBackground {
+			return deny("background commands are not allowed")
+		}
+		for _, cmd := range stmt.Pipeline.Commands {
+			if decision := p.evalCommand(cmd, depth); !decision.Allowed {
+				return decision
+			}
+		}
+	}
+
+	return allow()
+}
+
+// evalCommand checks a command, its redirects, and nested scripts.
+func (p *Policy) evalCommand(cmd *Command, depth int) Decision {
+	if len(cmd.Substitutions) > 0 && !p.AllowSubstitution {
+		return deny("command substitution is not allowed")
+	}
+	for _, sub := range cmd.Substitutions {
+		if decision := p.evalScript(sub, depth+1); !decision.Allowed {
+			return decision
+		}
+	}
+
+	if cmd.Subshell != nil {
+		if !p.AllowSubshells {
+			return deny("subshells are not allowed")
+		}
+		if decision := p.evalScript(cmd.Subshell, depth+1); !decision.Allowed {
+			return decision
+		}
+	}
+
+	for _, assign := range cmd.Assigns {
+		if decision := p.evalAssign(assign); !decision.Allowed {
+			return decision
+		}
+	}
+
+	for _, redirect := range cmd.Redirects {
+		if !redirect.Writes() {
+			continue
+		}
+		if !redirect.Target.Literal() || !matchesAny(p.WriteTargets, redirect.Target.Value) {
+			return deny("writing to %q is not allowed%s", redirect.Target.Value, listing("writable paths", p.WriteTargets))
+		}
+	}
+
+	if len(cmd.Words) == 0 {
+		return allow()
+	}
+
+	return p.evalWords(cmd.Words, depth)
+}
+
+// evalWords checks a simple command and any command it runs.
+func (p *Policy) evalWords(words []Word, depth int) Decision {
+	if depth > maxDepth {
+		return deny("command nesting is too deep")
+	}
+
+	name := words[0]
+	if !name.Literal() && !p.AllowDynamicCommands {
+		return deny("command name %q must be a literal", name.Value)
+	}
+
+	for _, rule := range p.Deny {
+		if rule.matches(words, true) {
+			return deny("command %q is denied by policy", strings.Join(wordValues(words), " "))
+		}
+	}
+
+	allowed := false
+	for _, rule := range p.Allow {
+		if rule.matches(words, false) {
+			allowed = true
+
+			break
+		}
+	}
+	if !allowed {
+		return deny("command %q is not allowed%s", name.Value, listing("allowed commands", p.allowedCommands()))
+	}
+
+	if !name.Literal() {
+		return allow()
+	}
+
+	return p.evalInner(path.Base(name.Value), words[1:], depth)
+}
+
+// evalInner checks the command run by a shell, eval, or wrapper command.
+func (p *Policy) evalInner(name string, args []Word, depth int) Decision {
+	switch {
+	case slices.Contains(shells, name):
+		for i, arg := range args {
+			if !isShellScriptFlag(arg) {
+				continue
+			}
+			if i+1 >= len(args) {
+				return deny("%s -c has no command", name)
+			}
+
+			return p.evalNested(name, args[i+1:i+2], depth)
+		}
+	case name == "eval":
+		return p.evalNested(name, args, depth)
+	}
+
+	w, ok := wrappers[name]
+	if !ok {
+		return allow()
+	}
+
+	assigns, inner, ok := w.inner(args)
+	if !ok {
+		return deny("cannot determine the command run by %s", name)
+	}
+	for _, assign := range assigns {
+		if decision := p.evalAssign(assign); !decision.Allowed {
+			return decision
+		}
+	}
+	if len(inner) == 0 {
+		return allow()
+	}
+
+	return p.evalWords(inner, depth+1)
+}
+
+// evalNested parses and checks script text passed to a shell or eval.
+func (p *Policy) evalNested(name string, args []Word, depth int) Decision {
+	values := make([]string, len(args))
+	for i, arg := range args {
+		if !arg.Literal() {
+			return deny("commands passed to %s must be literals", name)
+		}
+		values[i] = arg.Value
+	}
+
+	script, err := Parse(strings.Join(values, " "))
+	if err != nil {
+		return deny("command passed to %s could not be parsed: %v", name, err)
+	}
+
+	return p.evalScript(script, depth+1)
+}
+
+// evalAssign checks a NAME=value assignment against AllowAssignments.
+func (p *Policy) evalAssign(assign Word) Decision {
+	name, _, _ := strings.Cut(assign.Value, "=")
+	if !matchesAny(p.AllowAssignments, name) {
+		return deny("setting %s is not allowed%s", name, listing("assignable variables", p.AllowAssignments))
+	}
+
+	return allow()
+}
+
+// allowedCommands lists the allow rule commands for deny messages.
+func (p *Policy) allowedCommands() []string {
+	var commands []string
+	for _, rule := range p.Allow {
+		if !slices.Contains(commands, rule.Command) {
+			commands = append(commands, rule.Command)
+		}
+	}
+
+	return commands
+}
+
+// inner returns the assignments a wrapper makes and the command words it
+// runs, or false if they cannot be determined.
+func (w wrapper) inner(args []Word) ([]Word, []Word, bool) {
+	positional := w.positional
+
+	var assigns []Word
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !arg.Literal() {
+			return nil, nil, false
+		}
+
+		switch {
+		case arg.Value == "--":
+			return assigns, skipPositional(args[i+1:], positional), true
+		case strings.HasPrefix(arg.Value, "--"):
+			flag, _, hasValue := strings.Cut(arg.Value, "=")
+			if slices.Contains(w.opaqueFlags, flag) {
+				return nil, nil, false
+			}
+			if !hasValue && slices.Contains(w.valueFlags, flag) {
+				i++
+			}
+		case strings.HasPrefix(arg.Value, "-") && len(arg.Value) > 1:
+			opaque, takesNext := w.shortFlags(arg.Value[1:])
+			if opaque {
+				return nil, nil, false
+			}
+			if takesNext {
+				i++
+			}
+		case w.assigns && strings.Contains(arg.Value, "="):
+			assigns = append(assigns, arg)
+		case positional > 0:
+			positional--
+		default:
+			return assigns, args[i:], true
+		}
+	}
+
+	return assigns, nil, true
+}
+
+// shortFlags reads a cluster of short flags such as -Eu, reporting whether
+// one makes the command opaque and whether the last takes the next
+// argument as its value.
+func (w wrapper) shortFlags(cluster string) (opaque, takesNext bool) {
+	for i, letter := range cluster {
+		flag := "-" + string(letter)
+		if slices.Contains(w.opaqueFlags, flag) {
+			return true, false
+		}
+		if slices.Contains(w.valueFlags, flag) {
+			// The rest of the cluster is the value
+			return false, i+utf8.RuneLen(letter) == len(cluster)
+		}
+	}
+
+	return false, false
+}
+
+// skipPositional drops n leading positional arguments.
+func skipPositional(args []Word, n int) []Word {
+	if n > len(args) {
+		return nil
+	}
+
+	return args[n:]
+}
+
+// matches reports whether the rule matches a simple command.
+func (r Rule) matches(words []Word, isDeny bool) bool {
+	if !commandMatches(r.Command, words[0], isDeny) {
+		return false
+	}
+
+	args := words[1:]
+	if len(args) < len(r.Args) {
+		return false
+	}
+	for i, pattern := range r.Args {
+		if !argMatches(pattern, args[i], isDeny) {
+			return false
+		}
+	}
+
+	if len(r.AnyArg) == 0 {
+		return true
+	}
+	for _, arg := range args {
+		for _, pattern := range r.AnyArg {
+			if argMatches(pattern, arg, isDeny) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// commandMatches matches a rule's Command against a command name.
+func commandMatches(pattern string, name Word, isDeny bool) bool {
+	if pattern == "*" {
+		return true
+	}
+	if !name.Literal() {
+		return isDeny
+	}
+
+	value := name.Value
+	switch {
+	case strings.Contains(pattern, "/"):
+	case isDeny:
+		value = path.Base(value)
+	case strings.Contains(value, "/"):
+		return false
+	}
+
+	return wildcardMatch(pattern, value)
+}
+
+// argMatches matches a pattern against an argument.
+func argMatches(pattern string, arg Word, isDeny bool) bool {
+	if !arg.Literal() {
+		return isDeny || pattern == "*"
+	}
+
+	return wildcardMatch(pattern, arg.Value)
+}
+
+// matchesAny reports whether value matches any of the patterns.
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if wildcardMatch(pattern, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wildcardMatch matches value against a pattern where * matches any run of
+// characters and ? matches one character.
+func wildcardMatch(pattern, value string) bool {
+	p, v := []rune(pattern), []rune(value)
+	pi, vi := 0, 0
+	star, mark := -1, 0
+
+	for vi < len(v) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == v[vi]):
+			pi++
+			vi++
+		case pi < len(p) && p[pi] == '*':
+			star, mark = pi, vi
+			pi++
+		case star >= 0:
+			pi = star + 1
+			mark++
+			vi = mark
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+
+	return pi == len(p)
+}
+
+// isShellScriptFlag reports whether arg is a shell option cluster
+// containing -c, e.g. "-c" or "-lc".
+func isShellScriptFlag(arg Word) bool {
+	return arg.Literal() &&
+		strings.HasPrefix(arg.Value, "-") &&
+		!strings.HasPrefix(arg.Value, "--") &&
+		strings.Contains(arg.Value, "c")
+}
+
+// wordValues returns the values of words.
+func wordValues(words []Word) []string {
+	values := make([]string, len(words))
+	for i, w := range words {
+		values[i] = w.Value
+	}
+
+	return values
+}
+
+// listing formats a "; <label>: a, b" suffix for deny messages.
+func listing(label string, items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("; %s: %s", label, strings.Join(items, ", "))
+}
+
+func allow() Decision {
+	return Decision{Allowed: true}
+}
+
+func deny(format string, args ...any) Decision {
+	return Decision{Reason: fmt.Sprintf(format, args...)}
+}
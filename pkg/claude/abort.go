@@ -0,0 +1,137 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// PartialResponse captures the progress of a turn that was aborted.
+type PartialResponse struct {
+	// LastAssistantMessage is the most recent assistant message received
+	// before the abort, or nil if none arrived.
+	LastAssistantMessage *SDKAssistantMessage
+	// Usage is the token usage accumulated from assistant messages in the
+	// aborted turn.
+	Usage Usage
+	// Result is the result message that ended the turn, when the CLI sent
+	// one (interrupt and budget aborts).
+	Result *SDKResultMessage
+}
+
+// AbortPartial extracts the partial response carried by an abort error
+// returned from ClaudeSDKClient.
+func AbortPartial(err error) (*PartialResponse, bool) {
+	abortErr, ok := clauderrs.AsAbortedError(err)
+	if !ok {
+		return nil, false
+	}
+
+	partial, ok := abortErr.Partial().(*PartialResponse)
+
+	return partial, ok
+}
+
+// turnTracker records per-turn progress so aborted turns can report what
+// was received before they stopped.
+type turnTracker struct {
+	mu            sync.Mutex
+	lastAssistant *SDKAssistantMessage
+	usage         Usage
+	interrupted   bool
+	err           error
+}
+
+// observe records assistant messages and ends the turn on a result.
+func (t *turnTracker) observe(msg SDKMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch m := msg.(type) {
+	case *SDKAssistantMessage:
+		t.lastAssistant = m
+		t.usage.InputTokens += m.Message.Usage.InputTokens
+		t.usage.OutputTokens += m.Message.Usage.OutputTokens
+		t.usage.CacheReadInputTokens += m.Message.Usage.CacheReadInputTokens
+		t.usage.CacheCreationInputTokens += m.Message.Usage.CacheCreationInputTokens
+	case *SDKResultMessage:
+		t.err = t.resultAbort(m)
+		t.lastAssistant = nil
+		t.usage = Usage{}
+		t.interrupted = false
+	}
+}
+
+// resultAbort returns an abort error if the result ended the turn early.
+// Callers must hold t.mu.
+func (t *turnTracker) resultAbort(result *SDKResultMessage) error {
+	var abortErr *clauderrs.AbortedError
+
+	switch {
+	case result.Subtype == ResultSubtypeErrorMaxBudgetUsd:
+		abortErr = clauderrs.NewAbortedError("turn exceeded max budget", nil).
+			WithReason(clauderrs.AbortReasonBudget)
+	case t.interrupted:
+		abortErr = clauderrs.NewAbortedError("turn was interrupted", nil).
+			WithReason(clauderrs.AbortReasonInterrupt)
+	default:
+		return nil
+	}
+
+	partial := t.partialLocked()
+	partial.Result = result
+
+	return abortErr.WithPartial(partial).WithSessionID(result.SessionID())
+}
+
+// setInterrupted flags whether the current turn was interrupted.
+func (t *turnTracker) setInterrupted(interrupted bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.interrupted = interrupted
+}
+
+// abortContext records and returns an abort error for a cancelled context.
+func (t *turnTracker) abortContext(cause error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.err = clauderrs.NewAbortedError("operation cancelled", cause).
+		WithReason(clauderrs.AbortReasonContext).
+		WithPartial(t.partialLocked())
+
+	return t.err
+}
+
+// partialLocked snapshots the turn. Callers must hold t.mu.
+func (t *turnTracker) partialLocked() *PartialResponse {
+	return &PartialResponse{
+		LastAssistantMessage: t.lastAssistant,
+		Usage:                t.usage,
+	}
+}
+
+// clearErr resets the recorded abort before a new response is received.
+func (t *turnTracker) clearErr() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.err = nil
+}
+
+// lastErr returns the abort recorded for the most recent response.
+func (t *turnTracker) lastErr() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.err
+}
+
+// isContextErr reports whether err is a context cancellation or deadline.
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,230 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// MemoryServerName is the SDK MCP server serving the tools of
+	// Options.AgentMemory.
+	MemoryServerName = "sdk-memory"
+
+	// defaultNotesRead is how many notes the notes_read tool returns when
+	// the model doesn't say.
+	defaultNotesRead = 50
+
+	// memoryKeyPrefix starts the SessionStore keys of the memory's values.
+	memoryKeyPrefix = "memory/"
+	// memoryNotesLog is the SessionStore log of the memory's notes.
+	memoryNotesLog = "memory"
+)
+
+// memoryTools are the tools of the memory server.
+var memoryTools = []string{"memory_set", "memory_get", "memory_delete", "memory_list", "note_append", "notes_read"}
+
+// AgentNote is a note the agent appended to its memory.
+type AgentNote struct {
+	Text string `json:"text"`
+	// SessionID is the session that wrote the note.
+	SessionID string    `json:"sessionId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// checkAgentMemory reports AgentMemory set without a SessionStore.
+func (o *Options) checkAgentMemory() error {
+	if o.AgentMemory && o.SessionStore == nil {
+		return conflictError(
+			"SessionStore",
+			"AgentMemory requires a SessionStore to keep the memory in",
+			o.SessionStore,
+		)
+	}
+
+	return nil
+}
+
+// ReadAgentNotes returns the notes agents appended to their memory in
+// store, oldest first.
+func ReadAgentNotes(ctx context.Context, store SessionStore) ([]AgentNote, error) {
+	records, err := store.Records(ctx, memoryNotesLog)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := make([]AgentNote, 0, len(records))
+	for _, record := range records {
+		var note AgentNote
+		if json.Unmarshal(record, &note) == nil {
+			notes = append(notes, note)
+		}
+	}
+
+	return notes, nil
+}
+
+// memoryToolNames returns the full names of the memory tools, as allowed
+// on the command line.
+func memoryToolNames() []string {
+	names := make([]string, len(memoryTools))
+	for i, tool := range memoryTools {
+		names[i] = mcpToolPrefix + MemoryServerName + "__" + tool
+	}
+
+	return names
+}
+
+// newMemoryServer returns the SDK MCP server giving the model its memory
+// in store.
+func newMemoryServer(store SessionStore) McpSdkServerConfig {
+	key := map[string]any{"type": "string", "description": "Key of the value"}
+	object := func(properties map[string]any, required ...string) map[string]any {
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+
+		return schema
+	}
+	server := memoryServer{store: store}
+
+	config, _ := CreateSdkMcpServer(MemoryServerName, "1.0.0", []McpTool{
+		Tool("memory_set",
+			"Stores a value under a key in your long-term memory, which persists across sessions. "+
+				"Replaces any value already stored under the key.",
+			object(map[string]any{"key": key, "value": map[string]any{"type": "string", "description": "Value to remember"}}, "key", "value"),
+			server.set),
+		Tool("memory_get", "Reads the value stored under a key in your long-term memory.",
+			object(map[string]any{"key": key}, "key"), server.get),
+		Tool("memory_delete", "Removes a key from your long-term memory.",
+			object(map[string]any{"key": key}, "key"), server.delete),
+		Tool("memory_list", "Lists the keys in your long-term memory.",
+			object(map[string]any{}), server.list),
+		Tool("note_append",
+			"Appends a note to your long-term notes, which persist across sessions. "+
+				"Notes can't be changed or removed.",
+			object(map[string]any{"text": map[string]any{"type": "string", "description": "The note"}}, "text"),
+			server.appendNote),
+		Tool("notes_read", "Reads your most recent long-term notes, oldest first.",
+			object(map[string]any{"last": map[string]any{
+				"type": "integer", "minimum": 1,
+				"description": fmt.Sprintf("How many of the latest notes to read; defaults to %d", defaultNotesRead),
+			}}), server.readNotes),
+	}).(McpSdkServerConfig)
+
+	return config
+}
+
+// memoryServer serves the memory tools from a store.
+type memoryServer struct {
+	store SessionStore
+}
+
+func (s memoryServer) set(ctx context.Context, args map[string]any) (*McpToolResult, error) {
+	key, value := stringArg(args, "key"), stringArg(args, "value")
+	if key == "" {
+		return memoryError("key is required"), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, memoryKeyPrefix+key, data); err != nil {
+		return nil, err
+	}
+
+	return TextResult(fmt.Sprintf("stored %q", key)), nil
+}
+
+func (s memoryServer) get(ctx context.Context, args map[string]any) (*McpToolResult, error) {
+	key := stringArg(args, "key")
+	data, ok, err := s.store.Get(ctx, memoryKeyPrefix+key)
+	if err != nil {
+		return nil, err
+	}
+	var value string
+	if !ok || json.Unmarshal(data, &value) != nil {
+		return memoryError(fmt.Sprintf("nothing is stored under %q", key)), nil
+	}
+
+	return TextResult(value), nil
+}
+
+func (s memoryServer) delete(ctx context.Context, args map[string]any) (*McpToolResult, error) {
+	key := stringArg(args, "key")
+	if err := s.store.Delete(ctx, memoryKeyPrefix+key); err != nil {
+		return nil, err
+	}
+
+	return TextResult(fmt.Sprintf("deleted %q", key)), nil
+}
+
+func (s memoryServer) list(ctx context.Context, _ map[string]any) (*McpToolResult, error) {
+	keys, err := s.store.Keys(ctx, memoryKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return TextResult("memory is empty"), nil
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, memoryKeyPrefix)
+	}
+
+	return TextResult(strings.Join(keys, "\n")), nil
+}
+
+func (s memoryServer) appendNote(ctx context.Context, args map[string]any) (*McpToolResult, error) {
+	text := stringArg(args, "text")
+	if strings.TrimSpace(text) == "" {
+		return memoryError("text is required"), nil
+	}
+	note, err := json.Marshal(AgentNote{Text: text, SessionID: McpSessionID(ctx), CreatedAt: time.Now().UTC()})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Append(ctx, memoryNotesLog, note); err != nil {
+		return nil, err
+	}
+
+	return TextResult("note appended"), nil
+}
+
+func (s memoryServer) readNotes(ctx context.Context, args map[string]any) (*McpToolResult, error) {
+	last := defaultNotesRead
+	if n, ok := args["last"].(float64); ok && n >= 1 {
+		last = int(n)
+	}
+	notes, err := ReadAgentNotes(ctx, s.store)
+	if err != nil {
+		return nil, err
+	}
+	if len(notes) == 0 {
+		return TextResult("there are no notes"), nil
+	}
+
+	var text strings.Builder
+	for i, note := range notes[max(len(notes)-last, 0):] {
+		if i > 0 {
+			text.WriteString("\n")
+		}
+		fmt.Fprintf(&text, "[%s] %s", note.CreatedAt.Format(time.RFC3339), note.Text)
+	}
+
+	return TextResult(text.String()), nil
+}
+
+// stringArg returns the string argument name, or "".
+func stringArg(args map[string]any, name string) string {
+	value, _ := args[name].(string)
+
+	return value
+}
+
+// memoryError returns an error result telling the model what went wrong.
+func memoryError(text string) *McpToolResult {
+	return &McpToolResult{Content: []ContentBlock{TextContentBlock{Type: "text", Text: text}}, IsError: true}
+}
@@ -0,0 +1,163 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// agentToolNames are the tools that spawn subagents. Older CLIs call the
+// tool Task.
+var agentToolNames = []string{"Agent", "Task"}
+
+// planModeTools are the tools a subagent in plan mode may use.
+var planModeTools = []string{
+	"ExitPlanMode",
+	"Glob",
+	"Grep",
+	"LS",
+	"NotebookRead",
+	"Read",
+	"TodoWrite",
+	"WebFetch",
+	"WebSearch",
+}
+
+// permissionModeRank orders modes from most to least restrictive.
+var permissionModeRank = map[PermissionMode]int{
+	PermissionModePlan:              0,
+	PermissionModeDefault:           1,
+	PermissionModeAcceptEdits:       2,
+	PermissionModeBypassPermissions: 3,
+}
+
+// agentTracker attributes tool uses to the subagents that made them.
+//
+// Subagent messages carry the ID of the Agent tool_use that spawned them
+// as parent_tool_use_id, so each tool_use can be walked up to the chain of
+// subagent types it runs under. The tracker is fed by the read loop before
+// control requests are dispatched, so a tool_use is always recorded before
+// the permission request for it arrives.
+type agentTracker struct {
+	mu sync.Mutex
+	// parents maps a tool_use ID to the tool_use ID of its subagent.
+	parents map[string]string
+	// agents maps an Agent tool_use ID to the subagent type it spawned.
+	agents map[string]string
+}
+
+func newAgentTracker() *agentTracker {
+	return &agentTracker{
+		parents: make(map[string]string),
+		agents:  make(map[string]string),
+	}
+}
+
+// observe records tool uses and forgets completed ones.
+func (t *agentTracker) observe(msg SDKMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch m := msg.(type) {
+	case *SDKAssistantMessage:
+		for _, block := range m.Message.Content {
+			toolUse, ok := block.(ToolUseContentBlock)
+			if !ok {
+				continue
+			}
+			if m.ParentToolUseID != nil {
+				t.parents[toolUse.ID] = *m.ParentToolUseID
+			}
+			if slices.Contains(agentToolNames, toolUse.Name) {
+				var input AgentInput
+				if err := json.Unmarshal(toolUse.Input, &input); err == nil && input.SubagentType != "" {
+					t.agents[toolUse.ID] = input.SubagentType
+				}
+			}
+		}
+	case *SDKUserMessage:
+		for _, block := range m.Message.Content {
+			if result, ok := block.(ToolResultContentBlock); ok {
+				delete(t.parents, result.ToolUseID)
+				delete(t.agents, result.ToolUseID)
+			}
+		}
+	}
+}
+
+// chain returns the subagent types a tool use runs under, innermost first.
+func (t *agentTracker) chain(toolUseID string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var chain []string
+	parent := t.parents[toolUseID]
+	for parent != "" && len(chain) <= len(t.parents) {
+		if agent, ok := t.agents[parent]; ok {
+			chain = append(chain, agent)
+		}
+		parent = t.parents[parent]
+	}
+
+	return chain
+}
+
+// effectiveAgentID resolves the agent a permission request belongs to: the
+// innermost subagent type of the tool use, or the CLI-supplied agent ID.
+func (q *queryImpl) effectiveAgentID(req *SDKControlPermissionRequest) (*string, []string) {
+	chain := q.agents.chain(req.ToolUseID)
+	if len(chain) > 0 {
+		return &chain[0], chain
+	}
+
+	if req.AgentID != nil {
+		if _, ok := q.opts.Agents[*req.AgentID]; ok {
+			return req.AgentID, []string{*req.AgentID}
+		}
+	}
+
+	return req.AgentID, nil
+}
+
+// checkAgentPolicy returns a deny reason if any subagent in chain forbids
+// the tool. A nested subagent is bound by every agent above it.
+func (q *queryImpl) checkAgentPolicy(toolName string, chain []string) (string, bool) {
+	for _, name := range chain {
+		agent, ok := q.opts.Agents[name]
+		if !ok {
+			continue
+		}
+
+		if len(agent.Tools) > 0 && !slices.Contains(agent.Tools, toolName) {
+			return fmt.Sprintf("tool '%s' is not available to agent '%s'", toolName, name), false
+		}
+		if slices.Contains(agent.DisallowedTools, toolName) {
+			return fmt.Sprintf("tool '%s' is disallowed for agent '%s'", toolName, name), false
+		}
+		if q.agentMode(agent) == PermissionModePlan && !slices.Contains(planModeTools, toolName) {
+			return fmt.Sprintf("agent '%s' runs in plan mode and cannot use tool '%s'", name, toolName), false
+		}
+	}
+
+	return "", true
+}
+
+// agentMode returns the narrower of the agent's and the session's
+// permission modes, so a subagent can restrict but never widen access.
+func (q *queryImpl) agentMode(agent AgentDefinition) PermissionMode {
+	session := q.opts.PermissionMode
+	if session == "" {
+		session = PermissionModeDefault
+	}
+	if agent.PermissionMode == "" {
+		return session
+	}
+
+	agentRank, ok := permissionModeRank[agent.PermissionMode]
+	if !ok || agentRank >= permissionModeRank[session] {
+		return session
+	}
+
+	return agent.PermissionMode
+}
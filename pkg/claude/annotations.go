@@ -0,0 +1,164 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/parquet"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// annotationLogPrefix starts the SessionStore logs of annotations, one per
+// session.
+const annotationLogPrefix = "annotations/"
+
+// Annotation labels a message of a session, such as a rating for
+// fine-tuning data or a reviewer's verdict.
+type Annotation struct {
+	SessionID string `json:"sessionId"`
+	// MessageID is the UUID of the message annotated.
+	MessageID string `json:"messageUuid"`
+	Key       string `json:"key"`
+	// Value is the annotation's value as JSON.
+	Value     json.RawMessage `json:"value"`
+	CreatedAt time.Time       `json:"timestamp"`
+}
+
+// annotationLog records the annotations made through a client.
+type annotationLog struct {
+	mu          sync.Mutex
+	annotations []Annotation
+}
+
+func (l *annotationLog) add(annotation Annotation) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.annotations = append(l.annotations, annotation)
+}
+
+func (l *annotationLog) list() []Annotation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]Annotation(nil), l.annotations...)
+}
+
+// Annotate labels the message with UUID messageID, such as an assistant
+// message's SDKAssistantMessage.UUID, with value under key. The annotation
+// is appended to the session's annotations in Options.SessionStore, where
+// ReadAnnotations finds it, and is included in Options.TelemetryExport.
+// value must marshal to JSON. Annotating a message again with the same key
+// adds another annotation rather than replacing the first. The session
+// must have started, i.e. its first message been received.
+func (c *ClaudeSDKClient) Annotate(ctx context.Context, messageID, key string, value any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if messageID == "" {
+		return clauderrs.NewValidationError(
+			clauderrs.ErrCodeMissingField,
+			"Annotate requires the UUID of the message annotated",
+			nil,
+			"messageID",
+			messageID,
+		)
+	}
+	if key == "" {
+		return clauderrs.NewValidationError(
+			clauderrs.ErrCodeMissingField,
+			"Annotate requires a key",
+			nil,
+			"key",
+			key,
+		)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidType,
+			"annotation value must marshal to JSON",
+			err,
+			"value",
+			value,
+		)
+	}
+
+	sessionID := c.sessionID.Load()
+	if sessionID == nil {
+		return clauderrs.NewClientError(
+			clauderrs.ErrCodeNoActiveQuery,
+			"session has not started yet",
+			nil,
+		)
+	}
+
+	annotation := Annotation{
+		SessionID: *sessionID,
+		MessageID: messageID,
+		Key:       key,
+		Value:     data,
+		CreatedAt: c.opts.now().UTC(),
+	}
+	record, err := json.Marshal(annotation)
+	if err != nil {
+		return err
+	}
+	if err := c.store.Append(ctx, annotationLogPrefix+*sessionID, record); err != nil {
+		return err
+	}
+	c.annotations.add(annotation)
+
+	return nil
+}
+
+// Annotations returns the annotations made through the client, oldest
+// first.
+func (c *ClaudeSDKClient) Annotations() []Annotation {
+	return c.annotations.list()
+}
+
+// ReadAnnotations returns the annotations of session sessionID in store,
+// oldest first.
+func ReadAnnotations(ctx context.Context, store SessionStore, sessionID string) ([]Annotation, error) {
+	records, err := store.Records(ctx, annotationLogPrefix+sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := make([]Annotation, 0, len(records))
+	for _, record := range records {
+		var annotation Annotation
+		if json.Unmarshal(record, &annotation) == nil {
+			annotations = append(annotations, annotation)
+		}
+	}
+
+	return annotations, nil
+}
+
+// WriteAnnotations writes annotations to w in format, one row each, with
+// their values as JSON text.
+func WriteAnnotations(w io.Writer, format ExportFormat, annotations ...Annotation) error {
+	table := telemetryTable{fields: []parquet.Field{
+		{Name: "session_id", Type: parquet.String},
+		{Name: "message_uuid", Type: parquet.String},
+		{Name: "key", Type: parquet.String},
+		{Name: "value", Type: parquet.String},
+		{Name: "created_at", Type: parquet.Timestamp},
+	}}
+	for _, a := range annotations {
+		table.rows = append(table.rows, []any{
+			a.SessionID,
+			a.MessageID,
+			a.Key,
+			string(a.Value),
+			a.CreatedAt,
+		})
+	}
+
+	return table.write(w, format)
+}
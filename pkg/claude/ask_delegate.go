@@ -0,0 +1,291 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// defaultAskTimeout bounds the wait for an AskDelegate decision when
+	// AskPolicy.Timeout is unset.
+	defaultAskTimeout = 10 * time.Minute
+
+	// maxWebhookResponseBytes caps the decision body read from a webhook.
+	maxWebhookResponseBytes = 1 << 20
+)
+
+// AskRequest describes a tool call waiting for an approval decision from
+// an AskDelegate. It is JSON-encoded as the body of webhook requests.
+type AskRequest struct {
+	SessionID string               `json:"session_id"`
+	ToolName  string               `json:"tool_name"`
+	ToolUseID string               `json:"tool_use_id"`
+	Input     map[string]JSONValue `json:"input"`
+	// Reason is the message of the PermissionAsk returned by CanUseTool,
+	// if any.
+	Reason         string             `json:"reason,omitempty"`
+	AgentID        *string            `json:"agent_id,omitempty"`
+	BlockedPath    *string            `json:"blocked_path,omitempty"`
+	DecisionReason *string            `json:"decision_reason,omitempty"`
+	Suggestions    []PermissionUpdate `json:"suggestions,omitempty"`
+	// Deadline is when the default policy applies if no decision arrives.
+	Deadline time.Time `json:"deadline"`
+}
+
+// AskDelegate decides tool calls on behalf of a human, for example by
+// posting to a webhook, a Slack channel or a paging service and waiting
+// for someone to respond. Ask may block until a decision is made; ctx is
+// cancelled when AskPolicy.Timeout elapses or the session ends.
+type AskDelegate interface {
+	Ask(ctx context.Context, req *AskRequest) (PermissionResult, error)
+}
+
+// AskDelegateFunc adapts a function to the AskDelegate interface.
+type AskDelegateFunc func(ctx context.Context, req *AskRequest) (PermissionResult, error)
+
+// Ask calls f.
+func (f AskDelegateFunc) Ask(ctx context.Context, req *AskRequest) (PermissionResult, error) {
+	return f(ctx, req)
+}
+
+// AskPolicy escalates permission prompts to an AskDelegate so unattended
+// agents can wait for a human decision. The delegate answers every prompt
+// the CLI would have asked the user about, unless CanUseTool is also set;
+// then only calls for which CanUseTool returns a PermissionAsk are
+// escalated.
+type AskPolicy struct {
+	// Delegate makes the decision.
+	Delegate AskDelegate
+	// Timeout bounds the wait for a decision. Defaults to 10 minutes.
+	Timeout time.Duration
+	// Default is the behavior applied when the delegate fails or times
+	// out: PermissionBehaviorAllow or PermissionBehaviorDeny (the
+	// default).
+	Default PermissionBehavior
+}
+
+// PermissionAsk is returned by CanUseTool to escalate a tool call to the
+// AskPolicy delegate instead of deciding it.
+type PermissionAsk struct {
+	Behavior PermissionBehavior `json:"behavior"` // "ask"
+	// Message is passed to the delegate as AskRequest.Reason.
+	Message string `json:"message,omitempty"`
+}
+
+func (PermissionAsk) permissionResult() {}
+
+// validate checks the policy's fields.
+func (p *AskPolicy) validate() error {
+	var errs []error
+	if p.Delegate == nil {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeMissingField,
+			"AskPolicy requires a Delegate",
+			nil,
+			"AskPolicy.Delegate",
+			nil,
+		))
+	}
+	if p.Timeout < 0 {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"AskPolicy.Timeout must not be negative",
+			nil,
+			"AskPolicy.Timeout",
+			p.Timeout,
+		))
+	}
+	switch p.Default {
+	case "", PermissionBehaviorAllow, PermissionBehaviorDeny:
+	default:
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidType,
+			fmt.Sprintf("AskPolicy.Default must be %q or %q", PermissionBehaviorAllow, PermissionBehaviorDeny),
+			nil,
+			"AskPolicy.Default",
+			p.Default,
+		))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ask escalates a permission request to the policy's delegate. Errors,
+// timeouts and invalid decisions fall back to the default behavior and
+// are reported through opts.Stderr.
+func (q *queryImpl) ask(
+	ctx context.Context,
+	req *SDKControlPermissionRequest,
+	agentID *string,
+	suggestions []PermissionUpdate,
+	reason string,
+) PermissionResult {
+	policy := q.opts.AskPolicy
+	timeout := policy.Timeout
+	if timeout == 0 {
+		timeout = defaultAskTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	deadline, _ := ctx.Deadline()
+	result, err := policy.Delegate.Ask(ctx, &AskRequest{
+		SessionID:      q.sessionID,
+		ToolName:       req.ToolName,
+		ToolUseID:      req.ToolUseID,
+		Input:          req.Input,
+		Reason:         reason,
+		AgentID:        agentID,
+		BlockedPath:    req.BlockedPath,
+		DecisionReason: req.DecisionReason,
+		Suggestions:    suggestions,
+		Deadline:       deadline,
+	})
+
+	switch result.(type) {
+	case *PermissionAllow, PermissionAllow, *PermissionDeny, PermissionDeny:
+		if err == nil {
+			return result
+		}
+	default:
+		if err == nil {
+			err = fmt.Errorf("invalid decision type %T", result)
+		}
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("no decision within %s", timeout)
+	}
+	if q.opts.Stderr != nil {
+		q.opts.Stderr(fmt.Sprintf("Approval for tool '%s' failed, applying default policy: %v", req.ToolName, err))
+	}
+
+	if policy.Default == PermissionBehaviorAllow {
+		return &PermissionAllow{Behavior: PermissionBehaviorAllow}
+	}
+
+	return &PermissionDeny{
+		Behavior: PermissionBehaviorDeny,
+		Message:  fmt.Sprintf("tool '%s' was not approved: %v", req.ToolName, err),
+	}
+}
+
+// WebhookAskDelegate posts each AskRequest as JSON to URL and waits for
+// the response, which decides the call:
+//
+//	{"behavior": "allow", "updatedInput": {...}}
+//	{"behavior": "deny", "message": "not during a freeze"}
+//
+// The endpoint may hold the request open until a human responds; the
+// AskPolicy timeout bounds the wait.
+type WebhookAskDelegate struct {
+	// URL receives the POST requests.
+	URL string
+	// Header is added to each request, e.g. for authentication.
+	Header http.Header
+	// Client sends the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// webhookDecision is the response body of a webhook approval.
+type webhookDecision struct {
+	Behavior     PermissionBehavior   `json:"behavior"`
+	Message      string               `json:"message"`
+	UpdatedInput map[string]JSONValue `json:"updatedInput"`
+	Interrupt    bool                 `json:"interrupt"`
+}
+
+// Ask posts req to the webhook and decodes its decision.
+func (w *WebhookAskDelegate) Ask(ctx context.Context, req *AskRequest) (PermissionResult, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, clauderrs.NewClientError(
+			clauderrs.ErrCodeInvalidConfig,
+			"failed to encode approval request",
+			err,
+		)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, clauderrs.NewClientError(
+			clauderrs.ErrCodeInvalidConfig,
+			"invalid approval webhook URL",
+			err,
+		)
+	}
+	for key, values := range w.Header {
+		httpReq.Header[key] = values
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, clauderrs.NewNetworkError(
+			clauderrs.ErrCodeConnectionFailed,
+			"approval webhook request failed",
+			err,
+		).
+			WithHost(httpReq.URL.Host)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBytes))
+	if err != nil {
+		return nil, clauderrs.NewNetworkError(
+			clauderrs.ErrCodeConnectionClosed,
+			"failed to read approval webhook response",
+			err,
+		).
+			WithHost(httpReq.URL.Host)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, clauderrs.NewNetworkError(
+			clauderrs.ErrCodeConnectionFailed,
+			fmt.Sprintf("approval webhook returned %s", resp.Status),
+			nil,
+		).
+			WithHost(httpReq.URL.Host)
+	}
+
+	var decision webhookDecision
+	if err := json.Unmarshal(data, &decision); err != nil {
+		return nil, clauderrs.NewProtocolError(
+			clauderrs.ErrCodeMessageParseFailed,
+			"failed to parse approval webhook response",
+			err,
+		)
+	}
+
+	switch decision.Behavior {
+	case PermissionBehaviorAllow:
+		return &PermissionAllow{
+			Behavior:     PermissionBehaviorAllow,
+			UpdatedInput: decision.UpdatedInput,
+		}, nil
+	case PermissionBehaviorDeny:
+		return &PermissionDeny{
+			Behavior:  PermissionBehaviorDeny,
+			Message:   decision.Message,
+			Interrupt: decision.Interrupt,
+		}, nil
+	default:
+		return nil, clauderrs.NewProtocolError(
+			clauderrs.ErrCodeInvalidMessage,
+			fmt.Sprintf("approval webhook returned unknown behavior %q", decision.Behavior),
+			nil,
+		)
+	}
+}
@@ -0,0 +1,209 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+	"github.com/google/uuid"
+)
+
+// defaultAsyncHookTimeout bounds the wait for an async hook's output when
+// AsyncHookOutput.AsyncTimeout is not set. It matches the CLI's default
+// hook timeout, after which the CLI stops waiting anyway.
+const defaultAsyncHookTimeout = 60 * time.Second
+
+// asyncHookKey is the context key under which hook invocations carry their
+// async hook ID.
+type asyncHookKey struct{}
+
+// AsyncHookID returns the ID of the hook invocation running with ctx, or
+// "" outside a hook callback. A hook that returns AsyncHookOutput hands
+// this ID to whatever will decide, which then delivers the hook's real
+// output with ClaudeSDKClient.CompleteAsyncHook.
+func AsyncHookID(ctx context.Context) string {
+	id, _ := ctx.Value(asyncHookKey{}).(string)
+
+	return id
+}
+
+// asyncHookRegistry holds the hook invocations that may complete
+// asynchronously, keyed by async hook ID.
+type asyncHookRegistry struct {
+	mu      sync.Mutex
+	pending map[string]chan HookJSONOutput
+}
+
+// register adds a pending invocation and returns its ID and a context
+// carrying it. Invocations are registered before the hook runs so a
+// completion delivered before the hook returns is not lost.
+func (r *asyncHookRegistry) register(ctx context.Context) (context.Context, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pending == nil {
+		r.pending = make(map[string]chan HookJSONOutput)
+	}
+	id := uuid.NewString()
+	r.pending[id] = make(chan HookJSONOutput, 1)
+
+	return context.WithValue(ctx, asyncHookKey{}, id), id
+}
+
+// remove forgets a pending invocation.
+func (r *asyncHookRegistry) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.pending, id)
+}
+
+// complete delivers output to a pending invocation. Each invocation can be
+// completed once.
+func (r *asyncHookRegistry) complete(id string, output HookJSONOutput) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ch, ok := r.pending[id]
+	if !ok {
+		return false
+	}
+	delete(r.pending, id)
+	ch <- output
+
+	return true
+}
+
+// wait blocks until the invocation is completed, timeout passes, closed
+// is closed, or ctx is done. An invocation that was not completed is
+// removed, so later completions of it fail.
+func (r *asyncHookRegistry) wait(
+	ctx context.Context,
+	id string,
+	timeout time.Duration,
+	closed <-chan struct{},
+) (HookJSONOutput, error) {
+	r.mu.Lock()
+	ch, ok := r.pending[id]
+	r.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("async hook %s is not pending", id)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case output := <-ch:
+		return output, nil
+	case <-timer.C:
+		err = fmt.Errorf("async hook %s was not completed within %s", id, timeout)
+	case <-closed:
+		err = fmt.Errorf("async hook %s was not completed before the query closed", id)
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	r.remove(id)
+	// A completion may have raced the removal
+	select {
+	case output := <-ch:
+		return output, nil
+	default:
+		return nil, err
+	}
+}
+
+// asyncHookTimeout returns how long to wait for the output of a hook that
+// returned output, and whether it asked to complete asynchronously.
+func asyncHookTimeout(output HookJSONOutput) (time.Duration, bool) {
+	var async AsyncHookOutput
+	switch o := output.(type) {
+	case AsyncHookOutput:
+		async = o
+	case *AsyncHookOutput:
+		if o == nil {
+			return 0, false
+		}
+		async = *o
+	default:
+		return 0, false
+	}
+	if !async.Async {
+		return 0, false
+	}
+	if async.AsyncTimeout != nil && *async.AsyncTimeout > 0 {
+		return time.Duration(*async.AsyncTimeout) * time.Millisecond, true
+	}
+
+	return defaultAsyncHookTimeout, true
+}
+
+// validateHookOutput rejects outputs the CLI can't act on consistently.
+func validateHookOutput(output HookJSONOutput) error {
+	switch o := output.(type) {
+	case SyncHookOutput:
+		return o.Validate()
+	case *SyncHookOutput:
+		return o.Validate()
+	}
+
+	return nil
+}
+
+// CompleteAsyncHook delivers the output of a hook invocation that returned
+// AsyncHookOutput, letting hooks defer their decision to a background
+// worker or a user. callbackID is the invocation's AsyncHookID. The CLI
+// receives output as if the hook had returned it directly.
+//
+// It returns a ClientError with ErrCodeInvalidState if the invocation is
+// not pending, because it already completed or its AsyncTimeout passed,
+// and a ValidationError if output is itself async or invalid.
+func (c *ClaudeSDKClient) CompleteAsyncHook(
+	ctx context.Context,
+	callbackID string,
+	output HookJSONOutput,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, async := asyncHookTimeout(output); async || output == nil {
+		return clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidType,
+			"an async hook must be completed with its final output",
+			nil,
+			"output",
+			output,
+		)
+	}
+	if err := validateHookOutput(output); err != nil {
+		return clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidFormat,
+			"invalid hook output",
+			err,
+			"output",
+			output,
+		)
+	}
+
+	c.mu.Lock()
+	q, _ := c.query.(*queryImpl)
+	c.mu.Unlock()
+	if q == nil {
+		return clauderrs.NewClientError(clauderrs.ErrCodeNoActiveQuery, errNoActiveQuery, nil)
+	}
+
+	if !q.asyncHooks.complete(callbackID, output) {
+		return clauderrs.NewClientError(
+			clauderrs.ErrCodeInvalidState,
+			fmt.Sprintf("no pending async hook %q", callbackID),
+			nil,
+		)
+	}
+
+	return nil
+}
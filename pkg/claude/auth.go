@@ -0,0 +1,149 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// authEventBuffer is how many auth status messages AuthEvents holds
+	// before dropping the oldest.
+	authEventBuffer = 16
+	// redactedToken replaces authentication tokens in tee output.
+	redactedToken = "[redacted]"
+)
+
+// SDKControlAuthenticateRequest completes an interactive authentication
+// flow of the CLI with a token obtained out of band.
+type SDKControlAuthenticateRequest struct {
+	SubtypeField string `json:"subtype"` // "authenticate"
+	Token        string `json:"token"`
+}
+
+// Subtype returns the authenticate request subtype field.
+func (SDKControlAuthenticateRequest) Subtype() string {
+	return ControlRequestSubtypeAuthenticate
+}
+func (SDKControlAuthenticateRequest) controlRequestVariant() {}
+
+// MarshalJSON ensures the subtype field is always set to "authenticate".
+func (r SDKControlAuthenticateRequest) MarshalJSON() ([]byte, error) {
+	type Alias SDKControlAuthenticateRequest
+
+	return json.Marshal(&struct {
+		SubtypeField string `json:"subtype"`
+		*Alias
+	}{
+		SubtypeField: ControlRequestSubtypeAuthenticate,
+		Alias:        (*Alias)(&r),
+	})
+}
+
+// authEvents is the client's channel of auth status messages. It outlives
+// the client's queries and is closed when the client is.
+type authEvents struct {
+	mu     sync.Mutex
+	ch     chan *SDKAuthStatusMessage
+	closed bool
+}
+
+func newAuthEvents() *authEvents {
+	return &authEvents{ch: make(chan *SDKAuthStatusMessage, authEventBuffer)}
+}
+
+// deliver queues msg without blocking the read loop, dropping the oldest
+// queued message if nobody is reading.
+func (a *authEvents) deliver(msg *SDKAuthStatusMessage) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return
+	}
+	for {
+		select {
+		case a.ch <- msg:
+			return
+		default:
+		}
+		select {
+		case <-a.ch:
+		default:
+		}
+	}
+}
+
+// close closes the channel; later messages are dropped.
+func (a *authEvents) close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.closed {
+		a.closed = true
+		close(a.ch)
+	}
+}
+
+// AuthEvents returns the auth status messages the CLI sends while it is
+// in an interactive authentication flow, such as logging in to an MCP
+// server, so a headless application can show the login instructions in
+// Output to an operator or complete the flow with Authenticate instead of
+// the session hanging.
+//
+// Auth status messages are delivered here rather than by ReceiveMessages
+// and ReceiveResponse. The channel is shared by the client's sessions and
+// closed by Close; if it is not read, the oldest messages are dropped.
+func (c *ClaudeSDKClient) AuthEvents() <-chan *SDKAuthStatusMessage {
+	return c.auth.ch
+}
+
+// Authenticate completes the CLI's pending authentication flow with token,
+// such as an OAuth authorization code or an API key obtained out of band.
+// The token is redacted from TeeJSONL output and from errors.
+func (c *ClaudeSDKClient) Authenticate(ctx context.Context, token string) error {
+	c.mu.Lock()
+	q := c.query
+	c.mu.Unlock()
+	if q == nil {
+		return clauderrs.NewClientError(clauderrs.ErrCodeNoActiveQuery, errNoActiveQuery, nil)
+	}
+
+	return q.Authenticate(ctx, token)
+}
+
+// Authenticate sends the token completing an authentication flow.
+func (q *queryImpl) Authenticate(ctx context.Context, token string) error {
+	q.redactor.addValue(token)
+	_, err := q.sendControlRequest(ctx, SDKControlAuthenticateRequest{Token: token})
+
+	return err
+}
+
+// redactAuthToken returns frame with the token of an authenticate control
+// request replaced, and other frames unchanged.
+func redactAuthToken(frame []byte) []byte {
+	if !bytes.Contains(frame, []byte(`"`+ControlRequestSubtypeAuthenticate+`"`)) {
+		return frame
+	}
+
+	var full map[string]any
+	if err := json.Unmarshal(frame, &full); err != nil {
+		return frame
+	}
+	request, ok := full[fieldRequest].(map[string]any)
+	if !ok || request["subtype"] != ControlRequestSubtypeAuthenticate {
+		return frame
+	}
+
+	request["token"] = redactedToken
+	redacted, err := json.Marshal(full)
+	if err != nil {
+		return frame
+	}
+
+	return redacted
+}
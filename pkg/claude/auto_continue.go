@@ -0,0 +1,153 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// defaultSummaryPrompt asks Claude to summarize before the session is
+	// restarted.
+	defaultSummaryPrompt = "You are about to run out of turns. Summarize " +
+		"the task, what has been done so far, the current state, and the " +
+		"remaining steps, so the work can be continued in a new session."
+	// continuationPromptFormat seeds the new session with the summary.
+	continuationPromptFormat = "Continue the task from where the previous " +
+		"session stopped. Summary of progress so far:\n\n%s"
+
+	defaultTurnMargin       = 1
+	defaultMaxContinuations = 3
+)
+
+// shouldAutoContinue reports whether a result ended close enough to
+// MaxTurns that the response should be continued in a new session.
+func (c *ClaudeSDKClient) shouldAutoContinue(result *SDKResultMessage, continuations int) bool {
+	ac := c.opts.AutoContinue
+	if !ac.Enabled || c.opts.MaxTurns <= 0 {
+		return false
+	}
+
+	limit := ac.MaxContinuations
+	if limit <= 0 {
+		limit = defaultMaxContinuations
+	}
+	if continuations >= limit {
+		return false
+	}
+
+	margin := ac.TurnMargin
+	if margin <= 0 {
+		margin = defaultTurnMargin
+	}
+
+	return result.Subtype == ResultSubtypeErrorMaxTurns ||
+		result.NumTurns >= c.opts.MaxTurns-margin
+}
+
+// autoContinue asks the current session for a summary, then replaces it
+// with a new session seeded with that summary.
+func (c *ClaudeSDKClient) autoContinue(ctx context.Context) error {
+	c.mu.Lock()
+	old := c.query
+	c.mu.Unlock()
+
+	prompt := c.opts.AutoContinue.SummaryPrompt
+	if prompt == "" {
+		prompt = defaultSummaryPrompt
+	}
+	if err := old.SendUserMessage(ctx, prompt); err != nil {
+		return err
+	}
+
+	summary, err := c.receiveSummary(ctx, old)
+	if err != nil {
+		return err
+	}
+	if onContinue := c.opts.AutoContinue.OnContinue; onContinue != nil {
+		onContinue(summary)
+	}
+
+	// The new session starts fresh; resuming the old one would inherit
+	// its exhausted turn count.
+	opts := *c.opts
+	opts.Continue = false
+	opts.Resume = ""
+	opts.ResumeSessionAt = ""
+	opts.ForkSession = false
+
+	q, err := c.newQuery(fmt.Sprintf(continuationPromptFormat, summary), &opts)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		_ = q.Close()
+
+		return clauderrs.NewClientError(
+			clauderrs.ErrCodeClientClosed,
+			"client is closed",
+			nil,
+		)
+	}
+	c.query = q
+	c.mu.Unlock()
+
+	// The new session is live; failing to stop the old process does not
+	// undo the continuation.
+	_ = old.Close()
+
+	return nil
+}
+
+// receiveSummary consumes the summarization turn and returns its text: the
+// result text, or the last assistant text if the result carries none.
+func (c *ClaudeSDKClient) receiveSummary(ctx context.Context, q Query) (string, error) {
+	var lastText string
+
+	for {
+		msg, err := q.Next(ctx)
+		if err != nil {
+			return "", err
+		}
+		c.toolStats.Observe(msg)
+
+		switch m := msg.(type) {
+		case *SDKAssistantMessage:
+			if text := assistantText(m); text != "" {
+				lastText = text
+			}
+		case *SDKResultMessage:
+			if m.Result != nil && *m.Result != "" {
+				return *m.Result, nil
+			}
+
+			return lastText, nil
+		}
+	}
+}
+
+// assistantText joins the text blocks of an assistant message.
+func assistantText(msg *SDKAssistantMessage) string {
+	var texts textCollector
+	_ = WalkBlocks(msg, &texts)
+
+	return strings.Join(texts.parts, "\n")
+}
+
+// textCollector collects the text of visited blocks.
+type textCollector struct {
+	NopBlockVisitor
+	parts []string
+}
+
+// VisitText records block's text.
+func (c *textCollector) VisitText(block TextContentBlock) error {
+	c.parts = append(c.parts, block.Text)
+
+	return nil
+}
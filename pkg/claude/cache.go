@@ -0,0 +1,324 @@
+package claude
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// cacheKeyPrefix versions cache keys so a change to how keys are derived
+// never returns entries written by an older version.
+const cacheKeyPrefix = "claude-agent-sdk:v1:"
+
+// Cache stores results of the one-shot helpers QueryResult and QueryText,
+// so repeated identical prompts are answered without calling the model.
+// It suits deterministic and evaluation workloads; don't use it where a
+// fresh answer is expected each time.
+//
+// Implementations must be safe for concurrent use. Get reports a miss with
+// ok false; expired entries must be reported as misses.
+type Cache interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores value for ttl; zero means no expiry.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// MemoryCache is an in-memory LRU Cache.
+type MemoryCache struct {
+	capacity int
+
+	mu      sync.Mutex
+	order   *list.List // Front is the most recently used
+	entries map[string]*list.Element
+}
+
+// memoryCacheEntry is an element of MemoryCache.order.
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache creates an LRU cache holding up to capacity entries.
+// A capacity of 0 or less means unbounded.
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value stored for key.
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := elem.Value.(*memoryCacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+
+		return nil, false, nil
+	}
+	c.order.MoveToFront(elem)
+
+	return entry.value, true, nil
+}
+
+// Set stores value for key, evicting the least recently used entry when
+// the cache is full.
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	entry := &memoryCacheEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet
+// evicted.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// cacheKeyOptions are the options that change what a prompt returns.
+type cacheKeyOptions struct {
+	Model                 string                  `json:"model,omitempty"`
+	FallbackModel         string                  `json:"fallbackModel,omitempty"`
+	SystemPrompt          SystemPromptConfig      `json:"systemPrompt,omitempty"`
+	MaxThinkingTokens     int                     `json:"maxThinkingTokens,omitempty"`
+	MaxOutputTokens       int                     `json:"maxOutputTokens,omitempty"`
+	MaxTurns              int                     `json:"maxTurns,omitempty"`
+	AllowedTools          []string                `json:"allowedTools,omitempty"`
+	DisallowedTools       []string                `json:"disallowedTools,omitempty"`
+	PermissionMode        PermissionMode          `json:"permissionMode,omitempty"`
+	OutputFormat          *JsonSchemaOutputFormat `json:"outputFormat,omitempty"`
+	Cwd                   string                  `json:"cwd,omitempty"`
+	AdditionalDirectories []string                `json:"additionalDirectories,omitempty"`
+	SettingSources        []ConfigScope           `json:"settingSources,omitempty"`
+	ToolOverrides         []string                `json:"toolOverrides,omitempty"`
+}
+
+// CacheKey returns the key QueryResult uses to cache prompt under opts: a
+// hash of the prompt and the options that affect the answer (model,
+// system prompt, tools, permission mode, output format and working
+// directories). Tools served by ToolOverrides are part of the key, so mocked
+// and real runs are cached apart.
+func CacheKey(prompt string, opts *Options) (string, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	data, err := json.Marshal(struct {
+		Prompt  string          `json:"prompt"`
+		Options cacheKeyOptions `json:"options"`
+	}{
+		Prompt: prompt,
+		Options: cacheKeyOptions{
+			Model:                 opts.Model,
+			FallbackModel:         opts.FallbackModel,
+			SystemPrompt:          opts.SystemPrompt,
+			MaxThinkingTokens:     opts.MaxThinkingTokens,
+			MaxOutputTokens:       opts.MaxOutputTokens,
+			MaxTurns:              opts.MaxTurns,
+			AllowedTools:          opts.AllowedTools,
+			DisallowedTools:       opts.DisallowedTools,
+			PermissionMode:        opts.PermissionMode,
+			OutputFormat:          opts.OutputFormat,
+			Cwd:                   opts.Cwd,
+			AdditionalDirectories: opts.AdditionalDirectories,
+			SettingSources:        opts.SettingSources,
+			ToolOverrides:         overriddenTools(opts),
+		},
+	})
+	if err != nil {
+		return "", clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidConfig,
+			"failed to derive cache key from options",
+			err,
+			"Cache",
+			nil,
+		)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return cacheKeyPrefix + hex.EncodeToString(sum[:]), nil
+}
+
+// QueryResult runs prompt as a one-shot query and returns its result.
+// With opts.Cache set, a cached result for the same prompt and options is
+// returned without starting the CLI, and successful results are cached
+// for opts.CacheTTL. Error results are returned as a ClientError and never
+// cached.
+func QueryResult(ctx context.Context, prompt string, opts *Options) (*SDKResultMessage, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	var key string
+	if opts.Cache != nil {
+		var err error
+		if key, err = CacheKey(prompt, opts); err != nil {
+			return nil, err
+		}
+		if result := loadCachedResult(ctx, opts, key); result != nil {
+			return result, nil
+		}
+	}
+
+	result, err := runOneShot(ctx, prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Cache != nil {
+		if data, err := json.Marshal(result); err == nil {
+			if err := opts.Cache.Set(ctx, key, data, opts.CacheTTL); err != nil && opts.Stderr != nil {
+				opts.Stderr("Failed to cache query result: " + err.Error())
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// QueryText runs prompt as a one-shot query and returns the result text.
+// It is cached like QueryResult.
+func QueryText(ctx context.Context, prompt string, opts *Options) (string, error) {
+	result, err := QueryResult(ctx, prompt, opts)
+	if err != nil {
+		return "", err
+	}
+	if result.Result == nil {
+		return "", nil
+	}
+
+	return *result.Result, nil
+}
+
+// loadCachedResult returns the cached result for key, or nil on a miss.
+// Cache failures are reported through opts.Stderr and treated as misses.
+func loadCachedResult(ctx context.Context, opts *Options, key string) *SDKResultMessage {
+	data, ok, err := opts.Cache.Get(ctx, key)
+	if err != nil && opts.Stderr != nil {
+		opts.Stderr("Failed to read query result cache: " + err.Error())
+	}
+	if err != nil || !ok {
+		return nil
+	}
+
+	var result SDKResultMessage
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil
+	}
+
+	return &result
+}
+
+// runOneShot sends prompt in a new session and waits for its result.
+func runOneShot(ctx context.Context, prompt string, opts *Options) (*SDKResultMessage, error) {
+	var result *SDKResultMessage
+	var err error
+	if opts.Checkpointer != nil {
+		result, err = runCheckpointed(ctx, prompt, opts)
+	} else {
+		result, err = runSession(ctx, prompt, opts, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// runSession sends prompt in a new session, passing each message to
+// observe if it is set, and waits for the result. Error results are
+// returned along with a ClientError.
+func runSession(
+	ctx context.Context,
+	prompt string,
+	opts *Options,
+	observe func(SDKMessage),
+) (*SDKResultMessage, error) {
+	q, err := QueryFunc(prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = q.Close() }()
+
+	for {
+		msg, err := q.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil, clauderrs.NewProcessError(
+				clauderrs.ErrCodeProcessExited,
+				"Claude Code exited without a result",
+				nil,
+				-1,
+				"",
+			)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if observe != nil {
+			observe(msg)
+		}
+
+		result, ok := msg.(*SDKResultMessage)
+		if !ok {
+			continue
+		}
+		if result.IsError {
+			message := "query failed: " + result.Subtype
+			if len(result.Errors) > 0 {
+				message += ": " + strings.Join(result.Errors, "; ")
+			}
+
+			return result, clauderrs.NewClientError(
+				clauderrs.ErrCodeInvalidState,
+				message,
+				nil,
+			).
+				WithSessionID(result.SessionID())
+		}
+
+		return result, nil
+	}
+}
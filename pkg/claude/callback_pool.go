@@ -0,0 +1,93 @@
+package claude
+
+import (
+	"sync"
+)
+
+// callbackPool runs the control requests of Options.CallbackWorkers: at
+// most workers at once, and those of one event in the order they arrived.
+// Submitting never blocks, so the reader keeps delivering messages and
+// control responses, which interrupts and other control requests wait on,
+// while callbacks are slow.
+type callbackPool struct {
+	slots chan struct{} // Holds a token per running callback
+
+	mu     sync.Mutex
+	queues map[string][]func() // Waiting callbacks by event; a queue exists while its runner does
+	closed bool
+}
+
+func newCallbackPool(workers int) *callbackPool {
+	return &callbackPool{
+		slots:  make(chan struct{}, workers),
+		queues: make(map[string][]func()),
+	}
+}
+
+// submit queues fn behind the earlier callbacks of event.
+func (p *callbackPool) submit(event string, fn func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	queue, running := p.queues[event]
+	p.queues[event] = append(queue, fn)
+	if !running {
+		go p.run(event)
+	}
+}
+
+// run runs the callbacks of event one at a time until its queue is empty.
+func (p *callbackPool) run(event string) {
+	for {
+		p.mu.Lock()
+		queue := p.queues[event]
+		if len(queue) == 0 || p.closed {
+			delete(p.queues, event)
+			p.mu.Unlock()
+
+			return
+		}
+		fn := queue[0]
+		p.queues[event] = queue[1:]
+		p.mu.Unlock()
+
+		p.slots <- struct{}{}
+		fn()
+		<-p.slots
+	}
+}
+
+// queued returns how many callbacks wait for a worker.
+func (p *callbackPool) queued() int {
+	if p == nil {
+		return 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := 0
+	for _, queue := range p.queues {
+		n += len(queue)
+	}
+
+	return n
+}
+
+// close drops the callbacks not yet started. Running ones finish.
+func (p *callbackPool) close() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for event := range p.queues {
+		p.queues[event] = nil
+	}
+}
@@ -0,0 +1,227 @@
+package claude
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// checkpointResumePrompt continues a checkpointed session on a rerun.
+const checkpointResumePrompt = "The previous run was interrupted. Continue " +
+	"the task from where you left off."
+
+// Checkpoint is the progress of a one-shot query, saved after each
+// assistant message.
+type Checkpoint struct {
+	// SessionID is the session a rerun resumes.
+	SessionID string `json:"sessionId"`
+	// Text is the assistant text so far.
+	Text string `json:"text"`
+	// ToolUses are the tool calls made so far, in order.
+	ToolUses []ToolUseContentBlock `json:"toolUses,omitempty"`
+	// Usage sums the token usage of the assistant messages so far.
+	Usage Usage `json:"usage"`
+	// Turns counts the assistant messages so far.
+	Turns     int       `json:"turns"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Checkpointer stores the progress of the one-shot helpers QueryResult and
+// QueryText, so that when the process running a batch job dies, a rerun
+// of the same prompt and options resumes the checkpointed session from its
+// last completed turn instead of starting over. Checkpoints are removed
+// once the query returns a result.
+//
+// Implementations must be safe for concurrent use. Load reports a missing
+// checkpoint with a nil Checkpoint and nil error.
+type Checkpointer interface {
+	Load(ctx context.Context, key string) (*Checkpoint, error)
+	Save(ctx context.Context, key string, checkpoint *Checkpoint) error
+	Delete(ctx context.Context, key string) error
+}
+
+// FileCheckpointer is a Checkpointer storing each checkpoint as a JSON
+// file in a directory.
+type FileCheckpointer struct {
+	dir string
+}
+
+// NewFileCheckpointer creates a Checkpointer storing checkpoints in dir,
+// which is created if needed.
+func NewFileCheckpointer(dir string) (*FileCheckpointer, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidFormat,
+			fmt.Sprintf("failed to create checkpoint directory %s", dir),
+			err,
+			"dir",
+			dir,
+		)
+	}
+
+	return &FileCheckpointer{dir: dir}, nil
+}
+
+// path returns the file holding the checkpoint for key.
+func (f *FileCheckpointer) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Load reads the checkpoint for key.
+func (f *FileCheckpointer) Load(_ context.Context, key string) (*Checkpoint, error) {
+	data, err := os.ReadFile(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, err
+	}
+
+	return &checkpoint, nil
+}
+
+// Save writes the checkpoint for key. The file is replaced atomically, so
+// a crash mid-write leaves the previous checkpoint.
+func (f *FileCheckpointer) Save(_ context.Context, key string, checkpoint *Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(f.dir, ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path(key))
+}
+
+// Delete removes the checkpoint for key.
+func (f *FileCheckpointer) Delete(_ context.Context, key string) error {
+	err := os.Remove(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// runCheckpointed runs a one-shot query, saving its progress to
+// opts.Checkpointer and resuming a checkpointed session if there is one.
+func runCheckpointed(ctx context.Context, prompt string, opts *Options) (*SDKResultMessage, error) {
+	key, err := CacheKey(prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint, err := opts.Checkpointer.Load(ctx, key)
+	if err != nil {
+		reportCheckpointError(opts, "read", err)
+	}
+
+	if checkpoint != nil && checkpoint.SessionID != "" {
+		resumed := *opts
+		resumed.Continue = false
+		resumed.Resume = checkpoint.SessionID
+		resumed.ResumeSessionAt = ""
+		resumed.ForkSession = false
+
+		recorder := &checkpointRecorder{ctx: ctx, opts: opts, key: key, checkpoint: checkpoint}
+		result, err := runSession(ctx, checkpointResumePrompt, &resumed, recorder.observe)
+		// A session that can't be resumed, such as one whose transcript
+		// is gone, is started over
+		if result != nil || recorder.progressed || ctx.Err() != nil {
+			return recorder.finish(result, err)
+		}
+	}
+
+	recorder := &checkpointRecorder{ctx: ctx, opts: opts, key: key, checkpoint: &Checkpoint{}}
+	result, err := runSession(ctx, prompt, opts, recorder.observe)
+
+	return recorder.finish(result, err)
+}
+
+// checkpointRecorder saves a checkpoint after each assistant message.
+type checkpointRecorder struct {
+	ctx        context.Context
+	opts       *Options
+	key        string
+	checkpoint *Checkpoint
+	progressed bool // An assistant message was received
+}
+
+// observe adds msg to the checkpoint.
+func (r *checkpointRecorder) observe(msg SDKMessage) {
+	assistant, ok := msg.(*SDKAssistantMessage)
+	if !ok {
+		return
+	}
+	r.progressed = true
+
+	c := r.checkpoint
+	if id := assistant.SessionID(); id != "" {
+		c.SessionID = id
+	}
+	c.Text += assistantText(assistant)
+	for _, block := range assistant.Message.Content {
+		if use, ok := block.(ToolUseContentBlock); ok {
+			c.ToolUses = append(c.ToolUses, use)
+		}
+	}
+	usage := assistant.Message.Usage
+	c.Usage.InputTokens += usage.InputTokens
+	c.Usage.OutputTokens += usage.OutputTokens
+	c.Usage.CacheReadInputTokens += usage.CacheReadInputTokens
+	c.Usage.CacheCreationInputTokens += usage.CacheCreationInputTokens
+	c.Turns++
+	c.UpdatedAt = time.Now()
+
+	if err := r.opts.Checkpointer.Save(r.ctx, r.key, c); err != nil {
+		reportCheckpointError(r.opts, "save", err)
+	}
+}
+
+// finish removes the checkpoint once the query returned a result, and
+// keeps it otherwise for a rerun.
+func (r *checkpointRecorder) finish(result *SDKResultMessage, err error) (*SDKResultMessage, error) {
+	if result != nil {
+		if err := r.opts.Checkpointer.Delete(r.ctx, r.key); err != nil {
+			reportCheckpointError(r.opts, "delete", err)
+		}
+	}
+
+	return result, err
+}
+
+// reportCheckpointError reports a Checkpointer failure through
+// opts.Stderr. Checkpoint failures never fail the query.
+func reportCheckpointError(opts *Options, action string, err error) {
+	if opts.Stderr != nil {
+		opts.Stderr(fmt.Sprintf("Failed to %s query checkpoint: %v", action, err))
+	}
+}
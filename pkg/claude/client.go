@@ -2,8 +2,10 @@ package claude
 
 import (
 	"context"
+	"errors"
 	"io"
 	"sync"
+	"sync/atomic"
 
 	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
 )
@@ -16,28 +18,157 @@ const (
 	errNoActiveQuery = "no active query"
 )
 
+// Client is the conversation API of ClaudeSDKClient, for code that takes
+// a client it doesn't create and for decorators such as WithLogging,
+// WithMetrics and WithRetry that layer behavior over one.
+type Client interface {
+	// Query sends a prompt, starting the session on the first call.
+	Query(ctx context.Context, prompt string) error
+	// SendMessage sends a message with structured content blocks.
+	SendMessage(ctx context.Context, content []ContentBlock, sessionID string) error
+	// ReceiveMessages receives every message until the session ends.
+	ReceiveMessages(ctx context.Context) (<-chan SDKMessage, <-chan error)
+	// ReceiveResponse receives messages until the turn's result.
+	ReceiveResponse(ctx context.Context) <-chan SDKMessage
+	// Err reports why the last ReceiveResponse stream was aborted.
+	Err() error
+	// Interrupt stops the current turn.
+	Interrupt(ctx context.Context) error
+	// Close ends the session.
+	Close() error
+}
+
+var _ Client = (*ClaudeSDKClient)(nil)
+
 // ClaudeSDKClient provides a high-level interface to Claude Agent.
 type ClaudeSDKClient struct {
-	opts   *Options
-	query  Query
-	mu     sync.Mutex
-	closed bool
+	opts      *Options
+	query     Query
+	mu        sync.Mutex
+	closed    bool
+	toolStats *ToolStatsCollector
+	report    *SessionReportCollector
+	export    *telemetryExporter // Set with Options.TelemetryExport
+	toolUses  toolUseTracker
+	turn      turnTracker
+	dedup     streamDedup // Used with Options.SuppressFinalDuplicate
+	readOnly  bool
+	lastPlan  atomic.Pointer[Plan]
+	tee       atomic.Pointer[frameTee]
+	sessionID atomic.Pointer[string] // The CLI's session ID, once known
+	auth      *authEvents
+	startup   *startupTimer  // Set for clients from a WarmPool
+	commands  []SlashCommand // Cached by CompleteCommand
+
+	annotations annotationLog // Made with Annotate
+
+	idempotency idempotency
+	store       SessionStore // Options.SessionStore, or one per client
 }
 
 // NewClient creates a new Claude SDK client.
 func NewClient(opts *Options) (*ClaudeSDKClient, error) {
+	return newClient(opts, NewToolStatsCollector()), nil
+}
+
+// newClient creates a client keeping its tool statistics in toolStats.
+func newClient(opts *Options, toolStats *ToolStatsCollector) *ClaudeSDKClient {
 	options := opts
 	if options == nil {
 		options = &Options{}
 	}
 
-	return &ClaudeSDKClient{
-		opts: options,
-	}, nil
+	c := &ClaudeSDKClient{
+		opts:      options,
+		toolStats: toolStats,
+		report:    NewSessionReportCollector(),
+		auth:      newAuthEvents(),
+		store:     options.SessionStore,
+	}
+	if c.store == nil {
+		c.store = NewMemorySessionStore()
+	}
+	if options.TelemetryExport != nil {
+		c.export = newTelemetryExporter(options.TelemetryExport, c.report, c.toolStats, &c.annotations)
+	}
+
+	return c
+}
+
+// observe feeds a received message to the client's bookkeeping.
+func (c *ClaudeSDKClient) observe(msg SDKMessage) {
+	c.toolStats.Observe(msg)
+	c.report.Observe(msg)
+	c.toolUses.observe(msg)
+	c.turn.observe(msg)
+	c.startup.observe(msg)
+	if plan := planFromMessage(msg); plan != nil {
+		c.lastPlan.Store(plan)
+	}
+	if id := msg.SessionID(); id != "" {
+		c.sessionID.Store(&id)
+	}
+}
+
+// newQuery starts a query session with the client's tee attached and, with
+// Options.ToolTiming, its tool calls timed.
+func (c *ClaudeSDKClient) newQuery(prompt string, opts *Options) (Query, error) {
+	return newQueryImpl(prompt, c.toolStats.timedOptions(opts), c.tee.Load(), c.auth)
+}
+
+// ToolStats returns per-tool invocation counts, latency percentiles, and
+// failure rates observed so far in this client's session. Latencies are
+// only measured with Options.ToolTiming.
+func (c *ClaudeSDKClient) ToolStats() map[string]ToolStats {
+	return c.toolStats.Stats()
+}
+
+// SessionReport returns the turns, duration, cost and token usage of the
+// session so far.
+func (c *ClaudeSDKClient) SessionReport() SessionReport {
+	return c.report.Report()
+}
+
+// ExportTelemetry writes the files of Options.TelemetryExport now, instead
+// of waiting for its interval or Close.
+func (c *ClaudeSDKClient) ExportTelemetry() error {
+	if c.export == nil {
+		return clauderrs.NewClientError(
+			clauderrs.ErrCodeInvalidConfig,
+			"TelemetryExport is not configured",
+			nil,
+		)
+	}
+
+	return c.export.export()
+}
+
+// LastPlan returns the most recent plan Claude presented with the
+// ExitPlanMode tool, or nil if none has been received. Plans are normally
+// produced in plan mode.
+func (c *ClaudeSDKClient) LastPlan() *Plan {
+	return c.lastPlan.Load()
+}
+
+// Err returns the abort error that ended the most recent ReceiveResponse
+// stream, or nil if it completed normally.
+//
+// A turn is aborted when the context is cancelled, when Interrupt was
+// called, or when the CLI stops it for exceeding MaxBudgetUsd. The returned
+// *clauderrs.AbortedError carries the partial usage and last assistant
+// message; use AbortPartial to retrieve them.
+func (c *ClaudeSDKClient) Err() error {
+	return c.turn.lastErr()
 }
 
 // Query sends a query to Claude.
 func (c *ClaudeSDKClient) Query(ctx context.Context, prompt string) error {
+	return c.send(ctx, prompt, "")
+}
+
+// send sends a query whose result is saved under key, unless the turn of a
+// non-empty key is already in flight.
+func (c *ClaudeSDKClient) send(ctx context.Context, prompt, key string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -49,9 +180,20 @@ func (c *ClaudeSDKClient) Query(ctx context.Context, prompt string) error {
 		)
 	}
 
+	if err := checkPromptSize(c.opts, []ContentBlock{TextContentBlock{Type: "text", Text: prompt}}); err != nil {
+		return err
+	}
+	if key != "" && c.idempotency.inFlight(key) {
+		return nil
+	}
+	c.startup.query()
+
+	// Queued first, so a quick result finds its key
+	c.idempotency.sent(key)
 	if c.query == nil {
-		q, err := QueryFunc(prompt, c.opts)
+		q, err := c.newQuery(prompt, c.opts)
 		if err != nil {
+			c.idempotency.unsent()
 			// Preserve and wrap underlying errors from query
 			// creation
 			if sdkErr, ok := clauderrs.AsSDKError(err); ok {
@@ -71,7 +213,13 @@ func (c *ClaudeSDKClient) Query(ctx context.Context, prompt string) error {
 
 	// If query already exists, send a user message for multi-turn
 	// conversation
-	return c.query.SendUserMessage(ctx, prompt)
+	if err := c.query.SendUserMessage(ctx, prompt); err != nil {
+		c.idempotency.unsent()
+
+		return err
+	}
+
+	return nil
 }
 
 // SendMessage sends a message with structured content blocks to Claude.
@@ -102,7 +250,17 @@ func (c *ClaudeSDKClient) SendMessage(
 		)
 	}
 
-	return c.query.SendUserMessageWithContent(ctx, content)
+	if err := checkPromptSize(c.opts, content); err != nil {
+		return err
+	}
+	c.idempotency.sent("")
+	if err := c.query.SendUserMessageWithContent(ctx, content); err != nil {
+		c.idempotency.unsent()
+
+		return err
+	}
+
+	return nil
 }
 
 // ReceiveMessages receives all messages from the current query until EOF.
@@ -118,7 +276,10 @@ func (c *ClaudeSDKClient) ReceiveMessages(
 		defer close(msgChan)
 		defer close(errChan)
 
-		if c.query == nil {
+		c.mu.Lock()
+		q := c.query
+		c.mu.Unlock()
+		if q == nil {
 			errChan <- clauderrs.NewClientError(
 				clauderrs.ErrCodeNoActiveQuery,
 				errNoActiveQuery,
@@ -129,22 +290,32 @@ func (c *ClaudeSDKClient) ReceiveMessages(
 		}
 
 		for {
-			msg, err := c.query.Next(ctx)
+			msg, err := q.Next(ctx)
 			if err != nil {
+				if isContextErr(err) {
+					err = c.turn.abortContext(err)
+				}
 				if err != io.EOF {
 					errChan <- err
 				}
 
 				return
 			}
+			c.observe(msg)
+			if c.opts.SuppressFinalDuplicate {
+				msg = c.dedup.filter(msg)
+			}
 
 			select {
 			case msgChan <- msg:
 			case <-ctx.Done():
-				errChan <- ctx.Err()
+				errChan <- c.turn.abortContext(ctx.Err())
 
 				return
 			}
+			if result, ok := msg.(*SDKResultMessage); ok {
+				_ = c.idempotency.complete(ctx, c.store, result)
+			}
 		}
 	}()
 
@@ -156,33 +327,113 @@ func (c *ClaudeSDKClient) ReceiveMessages(
 //
 // This is a convenience method for single-response workflows.
 //
-// The channel automatically closes after receiving a result message.
+// The channel automatically closes after receiving a result message. If the
+// turn was aborted, Err reports why once the channel is closed. With
+// Options.AutoContinue enabled, a result near MaxTurns is replaced by the
+// messages of a continuation session; see AutoContinue. With
+// Options.StreamResume, a stream cut off mid-turn continues with the
+// messages of the resumed session; see StreamResume.
 func (c *ClaudeSDKClient) ReceiveResponse(
 	ctx context.Context,
 ) <-chan SDKMessage {
 	msgChan := make(chan SDKMessage, defaultMessageChannelBuffer)
+	c.turn.clearErr()
 
 	go func() {
 		defer close(msgChan)
 
-		if c.query == nil {
+		// A deduplicated query is answered with its cached result
+		if result := c.idempotency.takeReplay(); result != nil {
+			select {
+			case msgChan <- result:
+			case <-ctx.Done():
+			}
+
 			return
 		}
 
+		c.mu.Lock()
+		started := c.query != nil
+		c.mu.Unlock()
+		if !started {
+			return
+		}
+
+		continuations := 0
+		resumes := 0
+		resumed := false
+		open := openStream{}
 		for {
-			msg, err := c.query.Next(ctx)
+			c.mu.Lock()
+			q := c.query
+			c.mu.Unlock()
+
+			msg, err := q.Next(ctx)
 			if err != nil {
+				if isContextErr(err) {
+					_ = c.turn.abortContext(err)
+
+					return
+				}
+
+				// A stream cut off mid-turn continues in a resumed
+				// session
+				if c.shouldResumeStream(err, resumes) {
+					resumes++
+					if c.resumeStream(resumes, err) == nil {
+						resumed = true
+						// Close what the dropped stream left open
+						for _, stop := range open.close(c.opts, *c.sessionID.Load()) {
+							select {
+							case msgChan <- stop:
+							case <-ctx.Done():
+								_ = c.turn.abortContext(ctx.Err())
+
+								return
+							}
+						}
+
+						continue
+					}
+				}
+
 				return
 			}
+			// The resumed session announces itself again
+			if system, ok := msg.(*SDKSystemMessage); ok && resumed && system.Subtype == "init" {
+				continue
+			}
+			c.observe(msg)
+
+			// Near MaxTurns, continue in a new session instead of
+			// ending the response. If continuing fails, the original
+			// result is delivered.
+			if result, ok := msg.(*SDKResultMessage); ok &&
+				c.shouldAutoContinue(result, continuations) {
+				if err := c.autoContinue(ctx); err == nil {
+					continuations++
+
+					continue
+				}
+			}
+
+			if c.opts.SuppressFinalDuplicate {
+				msg = c.dedup.filter(msg)
+			}
 
 			select {
 			case msgChan <- msg:
 			case <-ctx.Done():
+				_ = c.turn.abortContext(ctx.Err())
+
 				return
 			}
+			open.observe(msg)
 
 			// Check if this is a result message (end of query)
-			if _, ok := msg.(*SDKResultMessage); ok {
+			if result, ok := msg.(*SDKResultMessage); ok {
+				_ = c.idempotency.complete(ctx, c.store, result)
+
 				return
 			}
 		}
@@ -191,6 +442,44 @@ func (c *ClaudeSDKClient) ReceiveResponse(
 	return msgChan
 }
 
+// Response is a turn read by CollectResponse: everything received, even
+// when the turn was cut short.
+type Response struct {
+	// Messages are the messages received, in order, including the result.
+	Messages []SDKMessage
+	// Result is the result that ended the turn, or nil if it didn't end.
+	Result *SDKResultMessage
+	// Interrupted reports that the turn was cut short by the context or
+	// Interrupt.
+	Interrupted bool
+	// Err is the abort error that ended the turn early, as reported by
+	// Err, or nil.
+	Err error
+}
+
+// CollectResponse reads the current turn like ReceiveResponse and returns
+// it whole. When ctx is cancelled mid-turn, for instance on Ctrl-C, it
+// returns the messages received so far instead of discarding them.
+func (c *ClaudeSDKClient) CollectResponse(ctx context.Context) *Response {
+	response := &Response{}
+	for msg := range c.ReceiveResponse(ctx) {
+		response.Messages = append(response.Messages, msg)
+		if result, ok := msg.(*SDKResultMessage); ok {
+			response.Result = result
+		}
+	}
+
+	response.Err = c.Err()
+	if abortErr, ok := clauderrs.AsAbortedError(response.Err); ok {
+		switch abortErr.Reason() {
+		case clauderrs.AbortReasonContext, clauderrs.AbortReasonInterrupt:
+			response.Interrupted = true
+		}
+	}
+
+	return response
+}
+
 // Interrupt interrupts the current query.
 func (c *ClaudeSDKClient) Interrupt(ctx context.Context) error {
 	c.mu.Lock()
@@ -204,7 +493,16 @@ func (c *ClaudeSDKClient) Interrupt(ctx context.Context) error {
 		)
 	}
 
-	return c.query.Interrupt(ctx)
+	// Mark before sending so a result racing the response is attributed
+	// to the interrupt.
+	c.turn.setInterrupted(true)
+	if err := c.query.Interrupt(ctx); err != nil {
+		c.turn.setInterrupted(false)
+
+		return err
+	}
+
+	return nil
 }
 
 // SetPermissionMode changes the permission mode.
@@ -223,9 +521,41 @@ func (c *ClaudeSDKClient) SetPermissionMode(
 		)
 	}
 
+	if c.readOnly {
+		if err := checkReadOnlyMode(mode); err != nil {
+			return err
+		}
+	}
+
 	return c.query.SetPermissionMode(ctx, mode)
 }
 
+// UpdatePermissions applies permission updates for the rest of the session.
+// See Query.UpdatePermissions for which updates are supported.
+func (c *ClaudeSDKClient) UpdatePermissions(
+	ctx context.Context,
+	updates []PermissionUpdate,
+) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.query == nil {
+		return clauderrs.NewClientError(
+			clauderrs.ErrCodeNoActiveQuery,
+			errNoActiveQuery,
+			nil,
+		)
+	}
+
+	if c.readOnly {
+		if err := checkReadOnlyUpdates(updates); err != nil {
+			return err
+		}
+	}
+
+	return c.query.UpdatePermissions(ctx, updates)
+}
+
 // SetModel changes the model.
 func (c *ClaudeSDKClient) SetModel(ctx context.Context, model *string) error {
 	c.mu.Lock()
@@ -242,6 +572,39 @@ func (c *ClaudeSDKClient) SetModel(ctx context.Context, model *string) error {
 	return c.query.SetModel(ctx, model)
 }
 
+// GetModel returns the model the session uses: Options.Model until the CLI
+// reports the model it started with, as changed by SetModel since. It is
+// empty while the CLI's default model is in use and not yet reported.
+func (c *ClaudeSDKClient) GetModel() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.query == nil {
+		return c.opts.Model
+	}
+
+	return c.query.Model()
+}
+
+// GetPermissionMode returns the permission mode the session uses:
+// Options.PermissionMode until the CLI reports the mode it started with, as
+// changed by SetPermissionMode, UpdatePermissions and setMode updates
+// accepted by CanUseTool since.
+func (c *ClaudeSDKClient) GetPermissionMode() PermissionMode {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.query == nil {
+		if c.opts.PermissionMode == "" {
+			return PermissionModeDefault
+		}
+
+		return c.opts.PermissionMode
+	}
+
+	return c.query.PermissionMode()
+}
+
 // SupportedCommands returns available slash commands.
 func (c *ClaudeSDKClient) SupportedCommands(
 	ctx context.Context,
@@ -278,6 +641,24 @@ func (c *ClaudeSDKClient) SupportedModels(
 	return c.query.SupportedModels(ctx)
 }
 
+// AccountInfo returns the account the session's credentials belong to
+// (email, organization, subscription, and where the token or API key came
+// from), for showing which credentials a session consumes.
+func (c *ClaudeSDKClient) AccountInfo(ctx context.Context) (*AccountInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.query == nil {
+		return nil, clauderrs.NewClientError(
+			clauderrs.ErrCodeNoActiveQuery,
+			errNoActiveQuery,
+			nil,
+		)
+	}
+
+	return c.query.AccountInfo(ctx)
+}
+
 // McpServerStatus returns MCP server status.
 func (c *ClaudeSDKClient) McpServerStatus(
 	ctx context.Context,
@@ -322,10 +703,15 @@ func (c *ClaudeSDKClient) Close() error {
 	}
 
 	c.closed = true
+	defer c.auth.close()
 
+	var err error
 	if c.query != nil {
-		return c.query.Close()
+		err = c.query.Close()
+	}
+	if c.export != nil {
+		err = errors.Join(err, c.export.close())
 	}
 
-	return nil
+	return err
 }
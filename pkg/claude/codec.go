@@ -0,0 +1,34 @@
+package claude
+
+import "github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
+
+// Codec converts between the JSON messages exchanged with the CLI and the
+// frames on its stdin and stdout. Set Options.Codec to replace the default
+// NDJSON framing, e.g. with PrettyJSONCodec in debug builds or a binary
+// encoding for an embedded transport. Codecs other than NDJSONCodec only
+// work with a CLI, proxy or fake speaking the same encoding.
+//
+// Implementations create an encoder and decoder per stream:
+//
+//	type codec struct{}
+//
+//	func (codec) NewEncoder(w io.Writer) claude.FrameEncoder { ... }
+//	func (codec) NewDecoder(r io.Reader) claude.FrameDecoder { ... }
+type Codec = transport.Codec
+
+// FrameEncoder writes a message, a JSON document, as a single frame.
+type FrameEncoder = transport.Encoder
+
+// FrameDecoder reads the next frame as a JSON document, returning io.EOF
+// at the end of the stream.
+type FrameDecoder = transport.Decoder
+
+var (
+	// NDJSONCodec frames each message as one line of JSON. It is the
+	// protocol the CLI speaks and the default.
+	NDJSONCodec Codec = transport.NDJSON
+
+	// PrettyJSONCodec frames each message as indented JSON, for reading
+	// the wire while debugging.
+	PrettyJSONCodec Codec = transport.PrettyJSON
+)
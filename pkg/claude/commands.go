@@ -0,0 +1,199 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// CommandArgument is an argument of a slash command, as described by its
+// ArgumentHint.
+type CommandArgument struct {
+	// Name is the argument's name, such as "file" for "<file>".
+	Name string
+	// Required is set for arguments in angle brackets and bare words;
+	// those in square brackets are optional.
+	Required bool
+	// Enum lists the values the argument takes, from hints such as
+	// "[mode:fast|slow]" or "<fast|slow>". It is nil for free-form
+	// arguments.
+	Enum []string
+	// Variadic is set for arguments taking several words, written with a
+	// trailing "...", such as "[files...]".
+	Variadic bool
+}
+
+// CommandCompletion is a completion of slash command input.
+type CommandCompletion struct {
+	// Text is the input completed, replacing it, such as "/review " for
+	// "/rev".
+	Text string
+	// Label is the command name or argument value completed.
+	Label string
+	// Description is the command's description, or the name of the
+	// argument completed.
+	Description string
+}
+
+// UnmarshalJSON decodes a command and parses its ArgumentHint.
+func (c *SlashCommand) UnmarshalJSON(data []byte) error {
+	type plain SlashCommand
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+	c.Arguments = ParseArgumentHint(c.ArgumentHint)
+
+	return nil
+}
+
+// ParseArgumentHint parses the argument hint of a slash command, such as
+// "<file> [mode:fast|slow] [notes...]". Each argument is a bare word or is
+// bracketed, "<>" for required arguments and "[]" for optional ones. A
+// bracketed argument may list its values after a colon, or be a list of
+// values alone, separated by "|".
+func ParseArgumentHint(hint string) []CommandArgument {
+	var args []CommandArgument
+	for rest := strings.TrimSpace(hint); rest != ""; rest = strings.TrimSpace(rest) {
+		var token string
+		required := true
+		switch rest[0] {
+		case '<', '[':
+			closer := ">"
+			if rest[0] == '[' {
+				closer, required = "]", false
+			}
+			end := strings.Index(rest, closer)
+			if end < 0 {
+				end = len(rest)
+			}
+			token = rest[1:end]
+			rest = rest[min(end+1, len(rest)):]
+		default:
+			end := strings.IndexAny(rest, " \t<[")
+			if end < 0 {
+				end = len(rest)
+			}
+			token, rest = rest[:end], rest[end:]
+		}
+
+		if arg, ok := parseArgumentToken(token, required); ok {
+			args = append(args, arg)
+		}
+	}
+
+	return args
+}
+
+// parseArgumentToken parses the text of one argument of a hint.
+func parseArgumentToken(token string, required bool) (CommandArgument, bool) {
+	token = strings.TrimSpace(token)
+	arg := CommandArgument{Required: required}
+	if name, found := strings.CutSuffix(token, "..."); found {
+		token, arg.Variadic = strings.TrimSpace(name), true
+	}
+	if token == "" || token == "|" {
+		return arg, false
+	}
+
+	arg.Name = token
+	values := token
+	if name, list, found := strings.Cut(token, ":"); found {
+		arg.Name, values = strings.TrimSpace(name), list
+	}
+	if strings.Contains(values, "|") {
+		for _, value := range strings.Split(values, "|") {
+			if value = strings.TrimSpace(value); value != "" {
+				arg.Enum = append(arg.Enum, value)
+			}
+		}
+	}
+
+	return arg, true
+}
+
+// CompleteCommand completes slash command input, such as a TUI's prompt,
+// against commands: a partial command name such as "/rev" to the names
+// starting with it, and a partial argument such as "/review fa" to the
+// values of the argument's Enum starting with it. Input that isn't a
+// command, or is at an argument without values, has no completions.
+func CompleteCommand(commands []SlashCommand, input string) []CommandCompletion {
+	rest, ok := strings.CutPrefix(input, "/")
+	if !ok {
+		return nil
+	}
+
+	name, argsText, hasArgs := strings.Cut(rest, " ")
+	if !hasArgs {
+		var completions []CommandCompletion
+		for _, cmd := range commands {
+			if !strings.HasPrefix(strings.ToLower(cmd.Name), strings.ToLower(name)) {
+				continue
+			}
+			text := "/" + cmd.Name
+			if len(cmd.Arguments) > 0 {
+				text += " "
+			}
+			completions = append(completions, CommandCompletion{Text: text, Label: cmd.Name, Description: cmd.Description})
+		}
+		slices.SortFunc(completions, func(a, b CommandCompletion) int { return strings.Compare(a.Label, b.Label) })
+
+		return completions
+	}
+
+	i := slices.IndexFunc(commands, func(cmd SlashCommand) bool { return cmd.Name == name })
+	if i < 0 {
+		return nil
+	}
+	args := commands[i].Arguments
+
+	// The argument completed is the last word, or a new one after a space
+	words := strings.Fields(argsText)
+	partial := ""
+	if len(words) > 0 && !strings.HasSuffix(argsText, " ") {
+		partial = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+	var arg CommandArgument
+	switch {
+	case len(words) < len(args):
+		arg = args[len(words)]
+	case len(args) > 0 && args[len(args)-1].Variadic:
+		arg = args[len(args)-1]
+	default:
+		return nil
+	}
+
+	var completions []CommandCompletion
+	base := strings.TrimSuffix(input, partial)
+	for _, value := range arg.Enum {
+		if strings.HasPrefix(value, partial) {
+			completions = append(completions, CommandCompletion{Text: base + value + " ", Label: value, Description: arg.Name})
+		}
+	}
+
+	return completions
+}
+
+// CompleteCommand completes slash command input against the commands of
+// the session, fetched with SupportedCommands on the first call and kept
+// for the client's life. See the CompleteCommand function.
+func (c *ClaudeSDKClient) CompleteCommand(ctx context.Context, input string) ([]CommandCompletion, error) {
+	c.mu.Lock()
+	commands := c.commands
+	c.mu.Unlock()
+
+	if commands == nil {
+		fetched, err := c.SupportedCommands(ctx)
+		if err != nil {
+			return nil, err
+		}
+		commands = fetched
+
+		c.mu.Lock()
+		c.commands = fetched
+		c.mu.Unlock()
+	}
+
+	return CompleteCommand(commands, input), nil
+}
@@ -0,0 +1,90 @@
+package claude
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// ProtocolVersion is the version of the stream-json control protocol the
+// SDK speaks. It is sent to the CLI in the initialize request and raised
+// whenever the SDK starts relying on messages or control requests older
+// CLIs lack.
+const ProtocolVersion = 2
+
+// cli1MissingHookEvents are the hook events Claude Code 1.x CLIs reject.
+var cli1MissingHookEvents = []HookEvent{
+	HookEventSubagentStart,
+	HookEventPermissionRequest,
+}
+
+// unknownRequestPattern matches a CLI's refusal of a control request it
+// doesn't implement.
+var unknownRequestPattern = regexp.MustCompile(
+	`(?i)\b(?:unknown|unsupported|unrecognized|invalid)\b[^.]*\b(?:request|subtype)\b`,
+)
+
+// explainControlFailure returns failure, the error for a control request
+// the CLI refused with text, wrapped in an ErrCodeIncompatibleCLI error
+// saying what to do if the refusal is a known CLI/SDK mismatch.
+func explainControlFailure(request ControlRequestVariant, text string, failure *clauderrs.ProtocolError) error {
+	var advice string
+	if request.Subtype() == ControlRequestSubtypeInitialize {
+		for _, event := range cli1MissingHookEvents {
+			if strings.Contains(text, string(event)) {
+				advice = fmt.Sprintf(
+					"Claude Code CLI 1.x lacks %s hooks; upgrade the CLI or remove the hook",
+					event,
+				)
+
+				break
+			}
+		}
+	}
+	if advice == "" && unknownRequestPattern.MatchString(text) {
+		advice = fmt.Sprintf(
+			"the Claude Code CLI doesn't support the %s control request; upgrade the CLI",
+			request.Subtype(),
+		)
+	}
+	if advice == "" {
+		return failure
+	}
+
+	return incompatibleCLIError(advice, failure)
+}
+
+// explainUnknownType returns err, a message decoding failure, wrapped in an
+// ErrCodeIncompatibleCLI error if it failed on a message, event or block
+// type the SDK doesn't know, which a CLI newer than the SDK sends.
+func explainUnknownType(err *clauderrs.ProtocolError) error {
+	for cause := error(err); cause != nil; cause = errors.Unwrap(cause) {
+		unknown, ok := cause.(*clauderrs.ProtocolError)
+		if !ok || unknown.Code() != clauderrs.ErrCodeUnknownMessageType {
+			continue
+		}
+
+		return incompatibleCLIError(fmt.Sprintf(
+			"Claude Code sent %q, which this SDK (protocol version %d) doesn't know; "+
+				"upgrade the SDK or use an older CLI",
+			unknown.MessageType(),
+			ProtocolVersion,
+		), err)
+	}
+
+	return err
+}
+
+// incompatibleCLIError wraps cause, keeping its session and message type.
+func incompatibleCLIError(advice string, cause *clauderrs.ProtocolError) *clauderrs.ProtocolError {
+	err := clauderrs.NewProtocolError(clauderrs.ErrCodeIncompatibleCLI, advice, cause).
+		WithMessageType(cause.MessageType())
+	if sessionID, ok := cause.Metadata()[clauderrs.MetadataKeySessionID].(string); ok {
+		_ = err.WithSessionID(sessionID)
+	}
+
+	return err
+}
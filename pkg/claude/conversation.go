@@ -0,0 +1,322 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// systemNoteFormat wraps a note sent ahead of a prompt.
+const systemNoteFormat = "<system-note>\n%s\n</system-note>\n\n"
+
+// TypedTurn is one prompt of a Conversation and the response to it.
+type TypedTurn struct {
+	// Index is the turn's zero-based position in the conversation.
+	Index int
+	// Prompt is the prompt as given to Send, without system notes.
+	Prompt string
+	// Notes are the system notes sent along with the prompt.
+	Notes []string
+	// Messages are all messages received for the turn, in order.
+	Messages []SDKMessage
+	// Assistant are the turn's assistant messages.
+	Assistant []*SDKAssistantMessage
+	// Result ends the turn; it is nil if the turn failed.
+	Result *SDKResultMessage
+	// Err is why the turn failed, if it did.
+	Err error
+}
+
+// Text joins the text of the turn's assistant messages.
+func (t TypedTurn) Text() string {
+	var parts []string
+	for _, msg := range t.Assistant {
+		if text := assistantText(msg); text != "" {
+			parts = append(parts, text)
+		}
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// ToolUses returns the tool calls Claude made during the turn.
+func (t TypedTurn) ToolUses() []ToolUseContentBlock {
+	var uses []ToolUseContentBlock
+	for _, msg := range t.Assistant {
+		for _, block := range msg.Message.Content {
+			if use, ok := block.(ToolUseContentBlock); ok {
+				uses = append(uses, use)
+			}
+		}
+	}
+
+	return uses
+}
+
+// Conversation wraps a client and records each prompt and its response as
+// a TypedTurn, so application logic can look back at earlier turns.
+// Messages received through the client directly are not recorded. Every
+// turn is kept in memory unless LimitMemory caps it.
+//
+// Its methods are safe for concurrent use, but Send calls are serialized.
+type Conversation struct {
+	client *ClaudeSDKClient
+
+	sendMu sync.Mutex
+	mu     sync.Mutex
+	turns  []TypedTurn // The turns in memory, after those spilled
+	notes  []string
+
+	maxTurns int
+	spill    *turnSpill
+	spillErr error // The first failure to spill, returned by Close
+}
+
+// NewConversation wraps client. The client should not have been used yet.
+func NewConversation(client *ClaudeSDKClient) *Conversation {
+	return &Conversation{client: client}
+}
+
+// Client returns the wrapped client.
+func (c *Conversation) Client() *ClaudeSDKClient {
+	return c.client
+}
+
+// Send sends prompt, along with any pending system notes, waits for the
+// response, and records the turn. It returns the turn and, if the turn
+// failed, the error also recorded in it.
+func (c *Conversation) Send(ctx context.Context, prompt string) (*TypedTurn, error) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	c.mu.Lock()
+	notes := c.notes
+	c.notes = nil
+	turn := TypedTurn{Index: c.spill.len() + len(c.turns), Prompt: prompt, Notes: notes}
+	c.mu.Unlock()
+
+	var text strings.Builder
+	for _, note := range notes {
+		fmt.Fprintf(&text, systemNoteFormat, note)
+	}
+	text.WriteString(prompt)
+
+	if err := c.client.Query(ctx, text.String()); err != nil {
+		turn.Err = err
+	} else {
+		c.receive(ctx, &turn)
+	}
+
+	c.mu.Lock()
+	c.turns = append(c.turns, turn)
+	c.spillOldTurns()
+	c.mu.Unlock()
+
+	return &turn, turn.Err
+}
+
+// LimitMemory caps the turns kept in memory, spilling older ones to disk
+// as the conversation grows; see ConversationMemory. Call Close to remove
+// the spill file.
+func (c *Conversation) LimitMemory(limit ConversationMemory) error {
+	if limit.MaxTurns < 0 {
+		return clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"MaxTurns must not be negative",
+			nil,
+			"ConversationMemory.MaxTurns",
+			limit.MaxTurns,
+		)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if limit.MaxTurns > 0 && c.spill == nil {
+		spill, err := newTurnSpill(limit.Dir)
+		if err != nil {
+			return err
+		}
+		c.spill = spill
+	}
+	c.maxTurns = limit.MaxTurns
+	c.spillOldTurns()
+
+	return c.spillErr
+}
+
+// spillOldTurns moves the turns beyond the memory limit to the spill
+// file. If spilling fails, the turns stay in memory. c.mu must be held.
+func (c *Conversation) spillOldTurns() {
+	if c.maxTurns == 0 || c.spillErr != nil {
+		return
+	}
+
+	spilled := 0
+	for len(c.turns) > c.maxTurns {
+		if err := c.spill.write(&c.turns[0]); err != nil {
+			c.spillErr = err
+
+			break
+		}
+		c.turns[0] = TypedTurn{}
+		c.turns = c.turns[1:]
+		spilled++
+	}
+	if spilled > 0 {
+		// Copy so the array holding the spilled turns can be collected
+		c.turns = append([]TypedTurn(nil), c.turns...)
+	}
+}
+
+// Close removes the spill file created by LimitMemory; the conversation
+// should not be used afterwards. It doesn't close the client. It returns
+// the first error spilling turns, if any.
+func (c *Conversation) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.spill.close()
+	c.spill = nil
+	c.maxTurns = 0
+	if c.spillErr != nil {
+		return c.spillErr
+	}
+
+	return err
+}
+
+// receive collects the response to the turn's prompt.
+func (c *Conversation) receive(ctx context.Context, turn *TypedTurn) {
+	for msg := range c.client.ReceiveResponse(ctx) {
+		turn.Messages = append(turn.Messages, msg)
+		switch m := msg.(type) {
+		case *SDKAssistantMessage:
+			turn.Assistant = append(turn.Assistant, m)
+		case *SDKResultMessage:
+			turn.Result = m
+		}
+	}
+
+	switch {
+	case c.client.Err() != nil:
+		turn.Err = c.client.Err()
+	case ctx.Err() != nil:
+		turn.Err = ctx.Err()
+	case turn.Result == nil:
+		turn.Err = clauderrs.NewProcessError(
+			clauderrs.ErrCodeProcessExited,
+			"Claude Code ended the turn without a result",
+			nil,
+			-1,
+			"",
+		)
+	}
+}
+
+// AppendSystemNote queues note to be sent ahead of the next prompt, marked
+// as a system note rather than part of the user's request. Use it to tell
+// Claude about changes it can't observe, such as an updated ticket.
+func (c *Conversation) AppendSystemNote(note string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notes = append(c.notes, note)
+}
+
+// History returns the recorded turns, oldest first, reading spilled turns
+// back into memory. Spilled turns that can't be read are left out; use
+// TurnsPage to page through a long conversation and see read errors.
+func (c *Conversation) History() []TypedTurn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var history []TypedTurn
+	for i := range c.spill.len() {
+		if turn, err := c.spill.read(i); err == nil {
+			history = append(history, turn)
+		}
+	}
+
+	return append(history, c.turns...)
+}
+
+// Turn returns the turn at index, reading it back if it was spilled.
+func (c *Conversation) Turn(index int) (TypedTurn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.turn(index)
+}
+
+// TurnsPage returns up to limit turns starting at offset, oldest first,
+// reading spilled turns back as needed.
+func (c *Conversation) TurnsPage(offset, limit int) ([]TypedTurn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	end := min(offset+limit, c.spill.len()+len(c.turns))
+	var page []TypedTurn
+	for i := max(offset, 0); i < end; i++ {
+		turn, err := c.turn(i)
+		if err != nil {
+			return page, err
+		}
+		page = append(page, turn)
+	}
+
+	return page, nil
+}
+
+// turn returns the turn at index. c.mu must be held.
+func (c *Conversation) turn(index int) (TypedTurn, error) {
+	spilled := c.spill.len()
+	if index < 0 || index >= spilled+len(c.turns) {
+		return TypedTurn{}, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			fmt.Sprintf("turn %d is out of range", index),
+			nil,
+			"index",
+			index,
+		)
+	}
+	if index < spilled {
+		return c.spill.read(index)
+	}
+
+	return c.turns[index-spilled], nil
+}
+
+// Turns returns the number of recorded turns, including spilled ones.
+func (c *Conversation) Turns() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.spill.len() + len(c.turns)
+}
+
+// LastAssistantText returns the text of the most recent assistant message
+// with text, searching back through earlier turns if needed, or "" if
+// there is none.
+func (c *Conversation) LastAssistantText() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := c.spill.len() + len(c.turns) - 1; i >= 0; i-- {
+		turn, err := c.turn(i)
+		if err != nil {
+			continue
+		}
+		assistant := turn.Assistant
+		for j := len(assistant) - 1; j >= 0; j-- {
+			if text := assistantText(assistant[j]); text != "" {
+				return text
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,160 @@
+package claude
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// ConversationMemory caps how much of a Conversation stays in memory, for
+// long-lived sessions such as always-on chat services. Turns beyond the
+// most recent MaxTurns are spilled to a temporary file and read back on
+// demand by History, Turn, TurnsPage and LastAssistantText.
+type ConversationMemory struct {
+	// MaxTurns is how many recent turns stay in memory. Zero keeps every
+	// turn in memory.
+	MaxTurns int
+	// Dir is where the spill file is created. Empty uses the default
+	// directory for temporary files.
+	Dir string
+}
+
+// spilledTurn is a TypedTurn as stored in a spill file. Assistant and
+// Result are rebuilt from Messages when it is read back.
+type spilledTurn struct {
+	Prompt   string            `json:"prompt"`
+	Notes    []string          `json:"notes,omitempty"`
+	Messages []json.RawMessage `json:"messages"`
+	Err      string            `json:"error,omitempty"`
+}
+
+// turnSpill stores spilled turns in a file, one JSON record per turn.
+type turnSpill struct {
+	file    *os.File
+	offsets []int64 // Where each turn's record starts
+	size    int64
+}
+
+func newTurnSpill(dir string) (*turnSpill, error) {
+	file, err := os.CreateTemp(dir, "claude-conversation-*.jsonl")
+	if err != nil {
+		return nil, clauderrs.NewClientError(
+			clauderrs.ErrCodeIOError,
+			"failed to create conversation spill file",
+			err,
+		)
+	}
+
+	return &turnSpill{file: file}, nil
+}
+
+// len returns the number of spilled turns.
+func (s *turnSpill) len() int {
+	if s == nil {
+		return 0
+	}
+
+	return len(s.offsets)
+}
+
+// write appends turn, which must be the turn after the last one spilled.
+func (s *turnSpill) write(turn *TypedTurn) error {
+	record := spilledTurn{Prompt: turn.Prompt, Notes: turn.Notes}
+	for _, msg := range turn.Messages {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return clauderrs.NewClientError(
+				clauderrs.ErrCodeIOError,
+				"failed to encode spilled conversation message",
+				err,
+			)
+		}
+		record.Messages = append(record.Messages, data)
+	}
+	if turn.Err != nil {
+		record.Err = turn.Err.Error()
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return clauderrs.NewClientError(
+			clauderrs.ErrCodeIOError,
+			"failed to encode spilled conversation turn",
+			err,
+		)
+	}
+	if _, err := s.file.WriteAt(append(data, '\n'), s.size); err != nil {
+		return clauderrs.NewClientError(
+			clauderrs.ErrCodeWriteFailed,
+			"failed to write conversation spill file",
+			err,
+		)
+	}
+
+	s.offsets = append(s.offsets, s.size)
+	s.size += int64(len(data)) + 1
+
+	return nil
+}
+
+// read returns the spilled turn at index. A failed turn's Err keeps its
+// message but not its type.
+func (s *turnSpill) read(index int) (TypedTurn, error) {
+	end := s.size
+	if index+1 < len(s.offsets) {
+		end = s.offsets[index+1]
+	}
+	data := make([]byte, end-s.offsets[index])
+	if _, err := s.file.ReadAt(data, s.offsets[index]); err != nil {
+		return TypedTurn{}, clauderrs.NewClientError(
+			clauderrs.ErrCodeReadFailed,
+			"failed to read conversation spill file",
+			err,
+		)
+	}
+
+	var record spilledTurn
+	if err := json.Unmarshal(data, &record); err != nil {
+		return TypedTurn{}, clauderrs.NewClientError(
+			clauderrs.ErrCodeReadFailed,
+			"failed to decode spilled conversation turn",
+			err,
+		)
+	}
+
+	turn := TypedTurn{Index: index, Prompt: record.Prompt, Notes: record.Notes}
+	for _, raw := range record.Messages {
+		msg, err := DecodeMessage(raw)
+		if err != nil {
+			return TypedTurn{}, err
+		}
+		turn.Messages = append(turn.Messages, msg)
+		switch m := msg.(type) {
+		case *SDKAssistantMessage:
+			turn.Assistant = append(turn.Assistant, m)
+		case *SDKResultMessage:
+			turn.Result = m
+		}
+	}
+	if record.Err != "" {
+		turn.Err = errors.New(record.Err)
+	}
+
+	return turn, nil
+}
+
+// close removes the spill file.
+func (s *turnSpill) close() error {
+	if s == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	if removeErr := os.Remove(s.file.Name()); err == nil {
+		err = removeErr
+	}
+
+	return err
+}
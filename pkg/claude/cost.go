@@ -0,0 +1,188 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// usdScale is the number of USD units in a dollar.
+const usdScale = 1_000_000_000
+
+// USD is an exact amount of US dollars, counted in billionths of a dollar.
+// Unlike float64 costs, USD amounts add up without drift, so totals over
+// thousands of results stay exact. It encodes to and decodes from JSON
+// numbers; use it in place of float64 for costs read from the CLI.
+type USD int64
+
+// ParseUSD parses a decimal dollar amount such as "0.0123" or "1e-7",
+// rounding digits beyond a billionth of a dollar half away from zero. A
+// malformed or out of range amount is reported as a ValidationError.
+func ParseUSD(s string) (USD, error) {
+	// big.Rat also accepts fractions and hexadecimal, which are not amounts
+	decimal := s != "" && strings.Trim(s, "0123456789.+-eE") == ""
+	amount, ok := new(big.Rat).SetString(s)
+	if ok && decimal {
+		amount.Mul(amount, big.NewRat(usdScale, 1))
+		// Round half away from zero
+		num, den := amount.Num(), amount.Denom()
+		quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+		if rem.Abs(rem).Lsh(rem, 1).Cmp(den) >= 0 {
+			quo.Add(quo, big.NewInt(int64(num.Sign())))
+		}
+		if quo.IsInt64() {
+			return USD(quo.Int64()), nil
+		}
+	}
+
+	return 0, clauderrs.NewValidationError(
+		clauderrs.ErrCodeInvalidFormat,
+		fmt.Sprintf("invalid dollar amount %q", s),
+		nil,
+		"amount",
+		s,
+	)
+}
+
+// USDFromFloat converts a float64 cost to USD through the shortest decimal
+// that parses to f. That is the literal the CLI wrote, so a cost decoded
+// into a float64 field converts back to exactly the amount the CLI sent.
+// Amounts beyond the range of USD are clamped.
+func USDFromFloat(f float64) USD {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	amount, err := ParseUSD(strconv.FormatFloat(f, 'g', -1, 64))
+	if err != nil {
+		if f < 0 {
+			return math.MinInt64
+		}
+
+		return math.MaxInt64
+	}
+
+	return amount
+}
+
+// Float64 returns the amount in dollars as a float64.
+func (u USD) Float64() float64 {
+	return float64(u) / usdScale
+}
+
+// String formats the amount in dollars without trailing zeros, such as
+// "0.0123".
+func (u USD) String() string {
+	sign := ""
+	abs := uint64(u)
+	if u < 0 {
+		sign = "-"
+		abs = -abs
+	}
+
+	whole, frac := abs/usdScale, abs%usdScale
+	if frac == 0 {
+		return sign + strconv.FormatUint(whole, 10)
+	}
+	digits := strings.TrimRight(fmt.Sprintf("%09d", frac), "0")
+
+	return sign + strconv.FormatUint(whole, 10) + "." + digits
+}
+
+// MarshalJSON encodes the amount as a JSON number.
+func (u USD) MarshalJSON() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number, or a string holding one, exactly.
+// null leaves the amount unchanged.
+func (u *USD) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	amount, err := ParseUSD(s)
+	if err != nil {
+		return err
+	}
+	*u = amount
+
+	return nil
+}
+
+// TotalCost returns the result's cost as an exact USD amount, for totals
+// over many results. Results decoded from JSON keep the amount the CLI
+// wrote, read without passing through float64; for others it is converted
+// from TotalCostUSD.
+func (m SDKResultMessage) TotalCost() USD {
+	if m.totalCost != nil {
+		return *m.totalCost
+	}
+
+	return USDFromFloat(m.TotalCostUSD)
+}
+
+// UnmarshalJSON decodes a result, reading total_cost_usd exactly for
+// TotalCost as well as into TotalCostUSD.
+func (m *SDKResultMessage) UnmarshalJSON(data []byte) error {
+	type Alias SDKResultMessage
+	var cost struct {
+		TotalCostUSD *USD `json:"total_cost_usd"`
+	}
+	if err := json.Unmarshal(data, (*Alias)(m)); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &cost); err != nil {
+		return err
+	}
+	m.totalCost = exactCost(cost.TotalCostUSD, m.TotalCostUSD)
+
+	return nil
+}
+
+// Cost returns the model's cost as an exact USD amount, read like
+// SDKResultMessage.TotalCost.
+func (u ModelUsage) Cost() USD {
+	if u.cost != nil {
+		return *u.cost
+	}
+
+	return USDFromFloat(u.CostUSD)
+}
+
+// UnmarshalJSON decodes model usage, reading costUSD exactly for Cost as
+// well as into CostUSD.
+func (u *ModelUsage) UnmarshalJSON(data []byte) error {
+	type Alias ModelUsage
+	var cost struct {
+		CostUSD *USD `json:"costUSD"`
+	}
+	if err := json.Unmarshal(data, (*Alias)(u)); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &cost); err != nil {
+		return err
+	}
+	u.cost = exactCost(cost.CostUSD, u.CostUSD)
+
+	return nil
+}
+
+// exactCost returns the decoded amount if converting the float64 decoded
+// with it loses digits, or nil, so that values decoded from JSON compare
+// equal to those built from the same amounts.
+func exactCost(decoded *USD, f float64) *USD {
+	if decoded == nil || *decoded == USDFromFloat(f) {
+		return nil
+	}
+
+	return decoded
+}
@@ -0,0 +1,136 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// credentialsTimeout bounds a CredentialsProvider call when starting
+	// the CLI.
+	credentialsTimeout = 30 * time.Second
+
+	// defaultRefreshBefore is how long before expiry RefreshingCredentials
+	// fetches new credentials.
+	defaultRefreshBefore = time.Minute
+
+	// CLI environment variables carrying credentials.
+	envAPIKey     = "ANTHROPIC_API_KEY"
+	envOAuthToken = "CLAUDE_CODE_OAUTH_TOKEN"
+)
+
+// Credentials authenticate the CLI with the Anthropic API. Set one of
+// APIKey and OAuthToken.
+type Credentials struct {
+	APIKey     string
+	OAuthToken string
+	// ExpiresAt, if set, is when the credentials stop working.
+	ExpiresAt time.Time
+}
+
+// CredentialsProvider supplies credentials each time the SDK starts a CLI
+// process, so short-lived tokens and secrets kept in a vault (AWS Secrets
+// Manager, HashiCorp Vault) never need to sit in the environment. The CLI
+// reads its credentials once at startup, so they must stay valid for the
+// whole session; wrap slow or rate-limited sources in
+// RefreshingCredentials.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (*Credentials, error)
+}
+
+// CredentialsProviderFunc adapts a function to the CredentialsProvider
+// interface.
+type CredentialsProviderFunc func(ctx context.Context) (*Credentials, error)
+
+// Credentials calls f.
+func (f CredentialsProviderFunc) Credentials(ctx context.Context) (*Credentials, error) {
+	return f(ctx)
+}
+
+// RefreshingCredentials caches the credentials of Provider and fetches
+// new ones when they are within RefreshBefore (default one minute) of
+// expiring. Credentials without ExpiresAt are cached indefinitely. It is
+// safe for concurrent use.
+type RefreshingCredentials struct {
+	Provider      CredentialsProvider
+	RefreshBefore time.Duration
+
+	mu     sync.Mutex
+	cached *Credentials
+}
+
+// Credentials returns the cached credentials, refreshing them if needed.
+func (r *RefreshingCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	refreshBefore := r.RefreshBefore
+	if refreshBefore == 0 {
+		refreshBefore = defaultRefreshBefore
+	}
+	if r.cached != nil &&
+		(r.cached.ExpiresAt.IsZero() || time.Until(r.cached.ExpiresAt) > refreshBefore) {
+		return r.cached, nil
+	}
+
+	creds, err := r.Provider.Credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.cached = creds
+
+	return creds, nil
+}
+
+// credentialsEnv fetches credentials from opts.CredentialsProvider and
+// returns the environment variables passing them to the CLI. Inherited
+// credentials of the other kind are cleared so they can't take
+// precedence.
+func credentialsEnv(opts *Options) ([]string, error) {
+	if opts.CredentialsProvider == nil {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), credentialsTimeout)
+	defer cancel()
+
+	creds, err := opts.CredentialsProvider.Credentials(ctx)
+	if err != nil {
+		return nil, clauderrs.NewClientError(
+			clauderrs.ErrCodeMissingAPIKey,
+			"failed to obtain credentials from CredentialsProvider",
+			err,
+		)
+	}
+
+	switch {
+	case creds == nil || creds.APIKey == "" && creds.OAuthToken == "":
+		return nil, clauderrs.NewClientError(
+			clauderrs.ErrCodeMissingAPIKey,
+			"CredentialsProvider returned no API key or OAuth token",
+			nil,
+		)
+	case creds.APIKey != "" && creds.OAuthToken != "":
+		return nil, clauderrs.NewClientError(
+			clauderrs.ErrCodeInvalidConfig,
+			"CredentialsProvider returned both an API key and an OAuth token",
+			nil,
+		)
+	case !creds.ExpiresAt.IsZero() && !time.Now().Before(creds.ExpiresAt):
+		return nil, clauderrs.NewClientError(
+			clauderrs.ErrCodeMissingAPIKey,
+			fmt.Sprintf("CredentialsProvider returned credentials that expired at %s", creds.ExpiresAt.Format(time.RFC3339)),
+			nil,
+		)
+	}
+
+	if creds.APIKey != "" {
+		return []string{envAPIKey + "=" + creds.APIKey, envOAuthToken + "="}, nil
+	}
+
+	return []string{envOAuthToken + "=" + creds.OAuthToken, envAPIKey + "="}, nil
+}
@@ -0,0 +1,349 @@
+package claude
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// defaultRetryAttempts is RetryPolicy's MaxAttempts when unset.
+	defaultRetryAttempts = 3
+	// defaultRetryDelay is RetryPolicy's Delay when unset.
+	defaultRetryDelay = time.Second
+)
+
+// Metrics recorded by WithMetrics.
+const (
+	// MetricRequests counts prompts sent, labeled op: "query" or
+	// "send_message".
+	MetricRequests = "claude_requests_total"
+	// MetricErrors counts failed calls, labeled op and code, the
+	// clauderrs code or "unknown".
+	MetricErrors = "claude_errors_total"
+	// MetricTurns counts results, labeled subtype.
+	MetricTurns = "claude_turns_total"
+	// MetricTurnDuration is the distribution of turn durations reported
+	// by results, in seconds.
+	MetricTurnDuration = "claude_turn_duration_seconds"
+	// MetricTurnCost is the distribution of turn costs, in USD.
+	MetricTurnCost = "claude_turn_cost_usd"
+	// MetricTokens counts tokens, labeled kind: "input", "output",
+	// "cache_read" or "cache_creation".
+	MetricTokens = "claude_tokens_total"
+	// MetricToolCalls counts completed tool calls, labeled tool and
+	// status: "success" or "error".
+	MetricToolCalls = "claude_tool_calls_total"
+	// MetricToolDuration is the distribution of tool call durations, in
+	// seconds, labeled tool. Only calls timed with Options.ToolTiming are
+	// recorded.
+	MetricToolDuration = "claude_tool_duration_seconds"
+)
+
+// MetricsRecorder receives the measurements of WithMetrics. Adapt it to a
+// metrics library such as Prometheus or OpenTelemetry.
+type MetricsRecorder interface {
+	// Count adds delta to the counter name.
+	Count(name string, delta float64, labels map[string]string)
+	// Observe records value in the distribution name.
+	Observe(name string, value float64, labels map[string]string)
+}
+
+// RetryPolicy configures WithRetry.
+type RetryPolicy struct {
+	// MaxAttempts bounds the attempts per call. Zero means 3.
+	MaxAttempts int
+	// Delay is the wait before the first retry; it doubles for each later
+	// retry. Zero means one second.
+	Delay time.Duration
+	// MaxDelay caps the wait between retries. Zero means no cap.
+	MaxDelay time.Duration
+	// Retryable reports whether a failed call may succeed if repeated.
+	// Nil uses clauderrs.IsRetryable.
+	Retryable func(error) bool
+}
+
+// WithLogging returns c logging its calls to logger: prompts sent (by
+// size, not content), results, aborted turns and failed calls. A nil
+// logger uses slog.Default.
+func WithLogging(c Client, logger *slog.Logger) Client {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &loggingClient{Client: c, logger: logger}
+}
+
+// WithMetrics returns c recording requests, errors, turns, their duration
+// and cost, and token usage in recorder; see the Metric constants. Tool
+// calls are recorded too when c is a ClaudeSDKClient, directly or under
+// these decorators.
+func WithMetrics(c Client, recorder MetricsRecorder) Client {
+	if tools := toolStatsOf(c); tools != nil {
+		tools.addRecorder(recorder)
+	}
+
+	return &metricsClient{Client: c, recorder: recorder}
+}
+
+// toolStatsOf returns the ToolStatsCollector of the ClaudeSDKClient under
+// c's decorators, or nil if there isn't one.
+func toolStatsOf(c Client) *ToolStatsCollector {
+	switch c := c.(type) {
+	case *ClaudeSDKClient:
+		return c.toolStats
+	case *loggingClient:
+		return toolStatsOf(c.Client)
+	case *metricsClient:
+		return toolStatsOf(c.Client)
+	case *retryClient:
+		return toolStatsOf(c.Client)
+	default:
+		return nil
+	}
+}
+
+// recordToolCall records call in recorders.
+func recordToolCall(recorders []MetricsRecorder, call toolCall) {
+	status := "success"
+	if call.failed {
+		status = "error"
+	}
+	for _, recorder := range recorders {
+		recorder.Count(MetricToolCalls, 1, map[string]string{"tool": call.name, "status": status})
+		if call.timed {
+			recorder.Observe(MetricToolDuration, call.latency.Seconds(), map[string]string{"tool": call.name})
+		}
+	}
+}
+
+// WithRetry returns c repeating Query, SendMessage and Interrupt calls that
+// fail with a retryable error, such as a CLI that exited before reading the
+// prompt. A turn that fails after its prompt was sent is not repeated, as
+// the model may have acted on it.
+func WithRetry(c Client, policy RetryPolicy) Client {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultRetryAttempts
+	}
+	if policy.Delay <= 0 {
+		policy.Delay = defaultRetryDelay
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = clauderrs.IsRetryable
+	}
+
+	return &retryClient{Client: c, policy: policy}
+}
+
+// observeStream forwards in, calling observe with each message first and
+// closed, if set, once in is closed.
+func observeStream(
+	ctx context.Context,
+	in <-chan SDKMessage,
+	observe func(SDKMessage),
+	closed func(),
+) <-chan SDKMessage {
+	out := make(chan SDKMessage, defaultMessageChannelBuffer)
+	go func() {
+		defer close(out)
+		for msg := range in {
+			observe(msg)
+			select {
+			case out <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if closed != nil {
+			closed()
+		}
+	}()
+
+	return out
+}
+
+// loggingClient is the Client returned by WithLogging.
+type loggingClient struct {
+	Client
+	logger *slog.Logger
+}
+
+func (c *loggingClient) Query(ctx context.Context, prompt string) error {
+	c.logger.DebugContext(ctx, "claude query", "prompt_bytes", len(prompt))
+
+	return c.logError(ctx, "query", c.Client.Query(ctx, prompt))
+}
+
+func (c *loggingClient) SendMessage(ctx context.Context, content []ContentBlock, sessionID string) error {
+	c.logger.DebugContext(ctx, "claude send message", "blocks", len(content))
+
+	return c.logError(ctx, "send_message", c.Client.SendMessage(ctx, content, sessionID))
+}
+
+func (c *loggingClient) ReceiveMessages(ctx context.Context) (<-chan SDKMessage, <-chan error) {
+	msgs, errs := c.Client.ReceiveMessages(ctx)
+
+	return observeStream(ctx, msgs, func(msg SDKMessage) { c.logResult(ctx, msg) }, nil), errs
+}
+
+func (c *loggingClient) ReceiveResponse(ctx context.Context) <-chan SDKMessage {
+	return observeStream(ctx, c.Client.ReceiveResponse(ctx), func(msg SDKMessage) { c.logResult(ctx, msg) }, func() {
+		if err := c.Client.Err(); err != nil {
+			c.logger.WarnContext(ctx, "claude turn aborted", "error", err)
+		}
+	})
+}
+
+func (c *loggingClient) Interrupt(ctx context.Context) error {
+	c.logger.InfoContext(ctx, "claude interrupt")
+
+	return c.logError(ctx, "interrupt", c.Client.Interrupt(ctx))
+}
+
+func (c *loggingClient) Close() error {
+	return c.logError(context.Background(), "close", c.Client.Close())
+}
+
+// logResult logs msg if it is a result.
+func (c *loggingClient) logResult(ctx context.Context, msg SDKMessage) {
+	result, ok := msg.(*SDKResultMessage)
+	if !ok {
+		return
+	}
+
+	level := slog.LevelInfo
+	if result.IsError {
+		level = slog.LevelWarn
+	}
+	c.logger.Log(ctx, level, "claude turn completed",
+		"session_id", result.SessionID(),
+		"subtype", result.Subtype,
+		"duration_ms", result.DurationMS,
+		"num_turns", result.NumTurns,
+		"cost_usd", result.TotalCostUSD,
+		"input_tokens", result.Usage.InputTokens,
+		"output_tokens", result.Usage.OutputTokens,
+	)
+}
+
+// logError logs err, if any, and returns it.
+func (c *loggingClient) logError(ctx context.Context, op string, err error) error {
+	if err != nil {
+		c.logger.ErrorContext(ctx, "claude "+op+" failed", "error", err)
+	}
+
+	return err
+}
+
+// metricsClient is the Client returned by WithMetrics.
+type metricsClient struct {
+	Client
+	recorder MetricsRecorder
+}
+
+func (c *metricsClient) Query(ctx context.Context, prompt string) error {
+	c.recorder.Count(MetricRequests, 1, map[string]string{"op": "query"})
+
+	return c.countError("query", c.Client.Query(ctx, prompt))
+}
+
+func (c *metricsClient) SendMessage(ctx context.Context, content []ContentBlock, sessionID string) error {
+	c.recorder.Count(MetricRequests, 1, map[string]string{"op": "send_message"})
+
+	return c.countError("send_message", c.Client.SendMessage(ctx, content, sessionID))
+}
+
+func (c *metricsClient) ReceiveMessages(ctx context.Context) (<-chan SDKMessage, <-chan error) {
+	msgs, errs := c.Client.ReceiveMessages(ctx)
+
+	return observeStream(ctx, msgs, c.observe, nil), errs
+}
+
+func (c *metricsClient) ReceiveResponse(ctx context.Context) <-chan SDKMessage {
+	return observeStream(ctx, c.Client.ReceiveResponse(ctx), c.observe, nil)
+}
+
+func (c *metricsClient) Interrupt(ctx context.Context) error {
+	return c.countError("interrupt", c.Client.Interrupt(ctx))
+}
+
+// observe records the turn msg completes, if it is a result.
+func (c *metricsClient) observe(msg SDKMessage) {
+	result, ok := msg.(*SDKResultMessage)
+	if !ok {
+		return
+	}
+
+	c.recorder.Count(MetricTurns, 1, map[string]string{"subtype": result.Subtype})
+	c.recorder.Observe(MetricTurnDuration, (time.Duration(result.DurationMS) * time.Millisecond).Seconds(), nil)
+	c.recorder.Observe(MetricTurnCost, result.TotalCostUSD, nil)
+	for kind, tokens := range map[string]int{
+		"input":          result.Usage.InputTokens,
+		"output":         result.Usage.OutputTokens,
+		"cache_read":     result.Usage.CacheReadInputTokens,
+		"cache_creation": result.Usage.CacheCreationInputTokens,
+	} {
+		if tokens > 0 {
+			c.recorder.Count(MetricTokens, float64(tokens), map[string]string{"kind": kind})
+		}
+	}
+}
+
+// countError counts err, if any, and returns it.
+func (c *metricsClient) countError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	code := "unknown"
+	if sdkErr, ok := clauderrs.AsSDKError(err); ok {
+		code = string(sdkErr.Code())
+	}
+	c.recorder.Count(MetricErrors, 1, map[string]string{"op": op, "code": code})
+
+	return err
+}
+
+// retryClient is the Client returned by WithRetry.
+type retryClient struct {
+	Client
+	policy RetryPolicy
+}
+
+func (c *retryClient) Query(ctx context.Context, prompt string) error {
+	return c.retry(ctx, func() error { return c.Client.Query(ctx, prompt) })
+}
+
+func (c *retryClient) SendMessage(ctx context.Context, content []ContentBlock, sessionID string) error {
+	return c.retry(ctx, func() error { return c.Client.SendMessage(ctx, content, sessionID) })
+}
+
+func (c *retryClient) Interrupt(ctx context.Context) error {
+	return c.retry(ctx, func() error { return c.Client.Interrupt(ctx) })
+}
+
+// retry calls fn until it succeeds, fails with an error that isn't
+// retryable, or runs out of attempts.
+func (c *retryClient) retry(ctx context.Context, fn func() error) error {
+	delay := c.policy.Delay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= c.policy.MaxAttempts || !c.policy.Retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return err
+		}
+		delay *= 2
+		if c.policy.MaxDelay > 0 && delay > c.policy.MaxDelay {
+			delay = c.policy.MaxDelay
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// doctorCommandTimeout bounds each version command Doctor runs.
+	doctorCommandTimeout = 10 * time.Second
+	// doctorPingTimeout bounds the API key check.
+	doctorPingTimeout = 10 * time.Second
+	// anthropicVersion is the API version header sent by the key check.
+	anthropicVersion = "2023-06-01"
+)
+
+// versionPattern finds a version number in command output.
+var versionPattern = regexp.MustCompile(`\d+\.\d+\.\d+\S*`)
+
+// DoctorStatus is the outcome of a DoctorCheck.
+type DoctorStatus string
+
+const (
+	// DoctorPass means the check found no problem.
+	DoctorPass DoctorStatus = "pass"
+	// DoctorWarn means the setup may work but something looks wrong.
+	DoctorWarn DoctorStatus = "warn"
+	// DoctorFail means queries are expected to fail until it is fixed.
+	DoctorFail DoctorStatus = "fail"
+	// DoctorSkip means the check does not apply to this setup.
+	DoctorSkip DoctorStatus = "skip"
+)
+
+// DoctorCheck is one item of a DoctorReport.
+type DoctorCheck struct {
+	// Name identifies the check: "cli", "node", "credentials",
+	// "workspace", or "mcp:<server>".
+	Name   string
+	Status DoctorStatus
+	// Detail explains the outcome for display, such as the CLI version
+	// found or what to fix.
+	Detail string
+}
+
+// DoctorReport is the result of Doctor.
+type DoctorReport struct {
+	Checks []DoctorCheck
+}
+
+// OK reports whether no check failed.
+func (r DoctorReport) OK() bool {
+	return len(r.Failed()) == 0
+}
+
+// Failed returns the checks that failed.
+func (r DoctorReport) Failed() []DoctorCheck {
+	var failed []DoctorCheck
+	for _, check := range r.Checks {
+		if check.Status == DoctorFail {
+			failed = append(failed, check)
+		}
+	}
+
+	return failed
+}
+
+// Doctor checks that queries with opts can run, for display in setup
+// flows: the CLI is installed and runs, Node.js is available, the API
+// credentials work, MCP server configs are usable, and the workspace is
+// writable. The credentials check makes one cheap API request (listing a
+// single model); no tokens are spent. A nil opts checks the defaults.
+func Doctor(ctx context.Context, opts *Options) DoctorReport {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	report := DoctorReport{Checks: []DoctorCheck{
+		checkCLI(ctx, opts),
+		checkNode(ctx),
+		checkCredentials(ctx, opts),
+		checkWorkspace(opts),
+	}}
+	report.Checks = append(report.Checks, checkMcpServers(opts)...)
+
+	return report
+}
+
+// checkCLI finds the CLI and asks it for its version.
+func checkCLI(ctx context.Context, opts *Options) DoctorCheck {
+	check := DoctorCheck{Name: "cli"}
+
+	path := opts.PathToClaudeCodeExecutable
+	if path == "" {
+		found, err := exec.LookPath("claude")
+		if err != nil {
+			check.Status = DoctorFail
+			check.Detail = "claude not found in PATH; install Claude Code or set PathToClaudeCodeExecutable"
+
+			return check
+		}
+		path = found
+	}
+
+	version, err := commandVersion(ctx, path)
+	if err != nil {
+		check.Status = DoctorFail
+		check.Detail = fmt.Sprintf("%s --version failed: %v", path, err)
+
+		return check
+	}
+	check.Status = DoctorPass
+	check.Detail = fmt.Sprintf("%s at %s", version, path)
+
+	return check
+}
+
+// checkNode looks for Node.js, which npm installs of the CLI run on.
+func checkNode(ctx context.Context) DoctorCheck {
+	check := DoctorCheck{Name: "node"}
+
+	path, err := exec.LookPath("node")
+	if err != nil {
+		check.Status = DoctorWarn
+		check.Detail = "node not found in PATH; only native builds of the CLI will run"
+
+		return check
+	}
+
+	version, err := commandVersion(ctx, path)
+	if err != nil {
+		check.Status = DoctorWarn
+		check.Detail = fmt.Sprintf("%s --version failed: %v", path, err)
+
+		return check
+	}
+	check.Status = DoctorPass
+	check.Detail = fmt.Sprintf("%s at %s", version, path)
+
+	return check
+}
+
+// commandVersion runs path --version and returns the version it prints.
+func commandVersion(ctx context.Context, path string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, doctorCommandTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return "", err
+	}
+	if version := versionPattern.FindString(string(output)); version != "" {
+		return version, nil
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// checkCredentials checks the provider settings and, for the Anthropic
+// API, that the API key is accepted.
+func checkCredentials(ctx context.Context, opts *Options) DoctorCheck {
+	check := DoctorCheck{Name: "credentials"}
+
+	if _, err := providerEnv(opts); err != nil {
+		check.Status = DoctorFail
+		check.Detail = err.Error()
+
+		return check
+	}
+	if opts.Provider == ProviderBedrock || opts.Provider == ProviderVertex {
+		check.Status = DoctorSkip
+		check.Detail = fmt.Sprintf("%s credentials come from the cloud SDK chain and are not verified", opts.Provider)
+
+		return check
+	}
+
+	lookup := func(name string) string {
+		if value, ok := opts.Env[name]; ok {
+			return value
+		}
+
+		return os.Getenv(name)
+	}
+	apiKey, oauthToken := lookup(envAPIKey), lookup(envOAuthToken)
+	if opts.CredentialsProvider != nil {
+		env, err := credentialsEnv(opts)
+		if err != nil {
+			check.Status = DoctorFail
+			check.Detail = err.Error()
+
+			return check
+		}
+		for _, entry := range env {
+			name, value, _ := strings.Cut(entry, "=")
+			switch name {
+			case envAPIKey:
+				apiKey = value
+			case envOAuthToken:
+				oauthToken = value
+			}
+		}
+	}
+
+	switch {
+	case apiKey != "":
+		return pingAPIKey(ctx, opts, apiKey)
+	case oauthToken != "":
+		check.Status = DoctorSkip
+		check.Detail = "an OAuth token is set; it is not verified"
+	default:
+		check.Status = DoctorWarn
+		check.Detail = "no " + envAPIKey + " or " + envOAuthToken + "; the CLI will need a stored login"
+	}
+
+	return check
+}
+
+// pingAPIKey checks apiKey with a one-model list request.
+func pingAPIKey(ctx context.Context, opts *Options, apiKey string) DoctorCheck {
+	check := DoctorCheck{Name: "credentials"}
+
+	baseURL, ok := opts.Env[envAnthropicBaseURL]
+	if !ok {
+		baseURL = os.Getenv(envAnthropicBaseURL)
+	}
+	if baseURL == "" {
+		baseURL = "https://" + defaultAPIHost
+	}
+	endpoint, err := url.JoinPath(baseURL, "v1", "models")
+	if err != nil {
+		check.Status = DoctorFail
+		check.Detail = fmt.Sprintf("invalid %s %q: %v", envAnthropicBaseURL, baseURL, err)
+
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, doctorPingTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?limit=1", nil)
+	if err != nil {
+		check.Status = DoctorFail
+		check.Detail = err.Error()
+
+		return check
+	}
+	req.Header.Set("x-api-key", apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		check.Status = DoctorFail
+		check.Detail = fmt.Sprintf("cannot reach the API at %s: %v", baseURL, err)
+
+		return check
+	}
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		check.Status = DoctorPass
+		check.Detail = "API key accepted by " + baseURL
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		check.Status = DoctorFail
+		check.Detail = fmt.Sprintf("API key rejected by %s (%s)", baseURL, resp.Status)
+	default:
+		check.Status = DoctorWarn
+		check.Detail = fmt.Sprintf("unexpected response from %s (%s)", baseURL, resp.Status)
+	}
+
+	return check
+}
+
+// checkWorkspace checks that the working directory is writable.
+func checkWorkspace(opts *Options) DoctorCheck {
+	check := DoctorCheck{Name: "workspace"}
+
+	dir := opts.Cwd
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	file, err := os.CreateTemp(dir, ".claude-doctor-*")
+	if err != nil {
+		check.Status = DoctorFail
+		check.Detail = fmt.Sprintf("%s is not writable: %v", dir, err)
+
+		return check
+	}
+	_ = file.Close()
+	_ = os.Remove(file.Name())
+
+	check.Status = DoctorPass
+	check.Detail = dir + " is writable"
+
+	return check
+}
+
+// checkMcpServers checks each configured MCP server, in name order.
+func checkMcpServers(opts *Options) []DoctorCheck {
+	names := make([]string, 0, len(opts.McpServers))
+	for name := range opts.McpServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	checks := make([]DoctorCheck, 0, len(names))
+	for _, name := range names {
+		check := DoctorCheck{Name: "mcp:" + name, Status: DoctorPass}
+
+		switch config := opts.McpServers[name].(type) {
+		case McpStdioServerConfig:
+			if path, err := exec.LookPath(config.Command); err != nil {
+				check.Status = DoctorFail
+				check.Detail = fmt.Sprintf("command %q not found", config.Command)
+			} else {
+				check.Detail = "command " + path
+			}
+		case McpSSEServerConfig:
+			check.Status, check.Detail = checkMcpURL(config.URL)
+		case McpHTTPServerConfig:
+			check.Status, check.Detail = checkMcpURL(config.URL)
+		case McpSdkServerConfig:
+			if config.Instance == nil {
+				check.Status = DoctorFail
+				check.Detail = "SDK server has no instance"
+			} else {
+				check.Detail = "in-process SDK server"
+			}
+		default:
+			check.Status = DoctorWarn
+			check.Detail = fmt.Sprintf("unknown server config %T", config)
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// checkMcpURL checks that an MCP server URL is an absolute http(s) URL.
+func checkMcpURL(rawURL string) (DoctorStatus, string) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return DoctorFail, fmt.Sprintf("invalid server URL %q", rawURL)
+	}
+
+	return DoctorPass, "server at " + parsed.Host
+}
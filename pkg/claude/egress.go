@@ -0,0 +1,414 @@
+package claude
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// defaultAPIHost is the Anthropic API host the CLI talks to.
+	defaultAPIHost = "api.anthropic.com"
+	// envAnthropicBaseURL overrides the API endpoint.
+	envAnthropicBaseURL = "ANTHROPIC_BASE_URL"
+	// egressDialTimeout bounds connecting to a destination.
+	egressDialTimeout = 30 * time.Second
+	// egressHeaderTimeout bounds reading a request's headers.
+	egressHeaderTimeout = 30 * time.Second
+	// egressNoProxy keeps local connections, such as to local MCP
+	// servers, off the proxy.
+	egressNoProxy = "localhost,127.0.0.1,::1"
+)
+
+// egressProxyEnv are the variables pointing HTTP clients at a proxy.
+var egressProxyEnv = []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "ALL_PROXY", "all_proxy"}
+
+// hopHeaders are the headers that apply to a single connection and are
+// not forwarded.
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// EgressPolicy restricts the hosts the CLI process, and the commands it
+// runs, may connect to.
+//
+// The SDK runs a filtering HTTP proxy on the loopback interface for the
+// session and points the CLI at it through HTTPS_PROXY and the related
+// variables, which child processes such as Bash commands inherit. Hosts
+// match as in WebPolicy: themselves and their subdomains, or subdomains
+// only with a "*." prefix, with blocked hosts taking precedence. The
+// Anthropic API host (api.anthropic.com, or the host of
+// ANTHROPIC_BASE_URL) is always permitted; add Bedrock or Vertex endpoints
+// to AllowedHosts when using those providers.
+//
+// Programs that ignore the proxy variables or open raw sockets bypass the
+// proxy. For a hard guarantee, also run the SDK where the only route out
+// is through the proxy, such as a network namespace or a firewall that
+// permits only UpstreamProxy.
+type EgressPolicy struct {
+	// AllowedHosts, if set, are the only hosts that may be reached.
+	AllowedHosts []string
+	// BlockedHosts may never be reached.
+	BlockedHosts []string
+	// UpstreamProxy, if set, is the http:// URL of a proxy that permitted
+	// connections are forwarded through, e.g. a corporate proxy.
+	UpstreamProxy string
+	// OnDenied, if set, is called with a *clauderrs.PermissionError with
+	// code ErrCodeEgressDenied for each connection the policy refuses.
+	// It may be called concurrently.
+	OnDenied func(err error)
+}
+
+// validate checks the upstream proxy URL and host patterns.
+func (p *EgressPolicy) validate() []error {
+	var errs []error
+
+	if p.UpstreamProxy != "" {
+		upstream, err := url.Parse(p.UpstreamProxy)
+		if err != nil || upstream.Scheme != "http" || upstream.Host == "" {
+			errs = append(errs, clauderrs.NewValidationError(
+				clauderrs.ErrCodeInvalidFormat,
+				"EgressPolicy.UpstreamProxy must be an http:// proxy URL",
+				err,
+				"EgressPolicy.UpstreamProxy",
+				p.UpstreamProxy,
+			))
+		}
+	}
+
+	for field, hosts := range map[string][]string{
+		"EgressPolicy.AllowedHosts": p.AllowedHosts,
+		"EgressPolicy.BlockedHosts": p.BlockedHosts,
+	} {
+		for _, host := range hosts {
+			if normalizeDomain(strings.TrimPrefix(host, "*.")) == "" {
+				errs = append(errs, clauderrs.NewValidationError(
+					clauderrs.ErrCodeInvalidFormat,
+					field+" must not contain empty hosts",
+					nil,
+					field,
+					host,
+				))
+			}
+		}
+	}
+
+	return errs
+}
+
+// egressProxy is the filtering proxy enforcing an EgressPolicy.
+type egressProxy struct {
+	policy    *EgressPolicy
+	apiHost   string
+	upstream  *url.URL
+	listener  net.Listener
+	server    *http.Server
+	transport *http.Transport
+
+	mu      sync.Mutex
+	tunnels map[net.Conn]struct{}
+	closed  bool
+}
+
+// startEgressProxy starts the proxy for opts.EgressPolicy. It returns nil
+// when no policy is set.
+func startEgressProxy(opts *Options) (*egressProxy, error) {
+	policy := opts.EgressPolicy
+	if policy == nil {
+		return nil, nil
+	}
+	if errs := policy.validate(); len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	p := &egressProxy{
+		policy:  policy,
+		apiHost: apiHost(opts),
+		tunnels: make(map[net.Conn]struct{}),
+	}
+	if policy.UpstreamProxy != "" {
+		p.upstream, _ = url.Parse(policy.UpstreamProxy)
+	}
+	p.transport = &http.Transport{
+		Proxy:       http.ProxyURL(p.upstream),
+		DialContext: (&net.Dialer{Timeout: egressDialTimeout}).DialContext,
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, clauderrs.NewNetworkError(
+			clauderrs.ErrCodeConnectionFailed,
+			"failed to start egress proxy",
+			err,
+		)
+	}
+	p.listener = listener
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: egressHeaderTimeout}
+	go func() { _ = p.server.Serve(listener) }()
+
+	return p, nil
+}
+
+// apiHost returns the host of the API endpoint the CLI will use.
+func apiHost(opts *Options) string {
+	baseURL, ok := opts.Env[envAnthropicBaseURL]
+	if !ok {
+		baseURL = os.Getenv(envAnthropicBaseURL)
+	}
+	if parsed, err := url.Parse(baseURL); err == nil && parsed.Hostname() != "" {
+		return normalizeDomain(parsed.Hostname())
+	}
+
+	return defaultAPIHost
+}
+
+// env returns the variables pointing the CLI at the proxy.
+func (p *egressProxy) env() []string {
+	if p == nil {
+		return nil
+	}
+
+	proxyURL := "http://" + p.listener.Addr().String()
+	env := make([]string, 0, len(egressProxyEnv)+2)
+	for _, name := range egressProxyEnv {
+		env = append(env, name+"="+proxyURL)
+	}
+
+	return append(env, "NO_PROXY="+egressNoProxy, "no_proxy="+egressNoProxy)
+}
+
+// close stops the proxy and tears down open tunnels.
+func (p *egressProxy) close() {
+	if p == nil {
+		return
+	}
+
+	_ = p.server.Close()
+	p.transport.CloseIdleConnections()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for conn := range p.tunnels {
+		_ = conn.Close()
+	}
+}
+
+// permits reports whether the policy allows connecting to host.
+func (p *egressProxy) permits(host string) bool {
+	host = normalizeDomain(host)
+
+	for _, blocked := range p.policy.BlockedHosts {
+		if domainMatches(blocked, host) {
+			return false
+		}
+	}
+	if host == p.apiHost || len(p.policy.AllowedHosts) == 0 {
+		return true
+	}
+	for _, allowed := range p.policy.AllowedHosts {
+		if domainMatches(allowed, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ServeHTTP handles CONNECT tunnels and plain HTTP proxy requests.
+func (p *egressProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Host
+	if r.Method == http.MethodConnect {
+		target = r.Host
+	}
+	host, _, err := net.SplitHostPort(target)
+	if err != nil {
+		host = target
+	}
+	if host == "" {
+		http.Error(w, "not a proxy request", http.StatusBadRequest)
+
+		return
+	}
+
+	if !p.permits(host) {
+		deniedErr := clauderrs.NewPermissionError(
+			clauderrs.ErrCodeEgressDenied,
+			fmt.Sprintf("egress to %s is denied by policy", host),
+			nil,
+			host,
+			"connect",
+		)
+		if p.policy.OnDenied != nil {
+			p.policy.OnDenied(deniedErr)
+		}
+		http.Error(w, deniedErr.Error(), http.StatusForbidden)
+
+		return
+	}
+
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+
+		return
+	}
+	p.forward(w, r)
+}
+
+// tunnel connects a CONNECT request to its destination and relays bytes
+// both ways until either side closes.
+func (p *egressProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	dst, err := p.dial(r.Context(), r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		_ = dst.Close()
+		http.Error(w, "tunneling not supported", http.StatusInternalServerError)
+
+		return
+	}
+	src, buffered, err := hijacker.Hijack()
+	if err != nil {
+		_ = dst.Close()
+
+		return
+	}
+	if !p.track(src, dst) {
+		return
+	}
+	defer p.untrack(src, dst)
+
+	if _, err := io.WriteString(src, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(dst, buffered)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(src, dst)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// track registers the connections of a tunnel so close can tear it down.
+// It closes them and returns false if the proxy is already closed.
+func (p *egressProxy) track(conns ...net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, conn := range conns {
+		if p.closed {
+			_ = conn.Close()
+
+			continue
+		}
+		p.tunnels[conn] = struct{}{}
+	}
+
+	return !p.closed
+}
+
+// untrack closes and forgets the connections of a finished tunnel.
+func (p *egressProxy) untrack(conns ...net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, conn := range conns {
+		_ = conn.Close()
+		delete(p.tunnels, conn)
+	}
+}
+
+// dial connects to target directly or through the upstream proxy.
+func (p *egressProxy) dial(ctx context.Context, target string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: egressDialTimeout}
+	if p.upstream == nil {
+		return dialer.DialContext(ctx, "tcp", target)
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", p.upstream.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	connect := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: target},
+		Host:   target,
+		Header: make(http.Header),
+	}
+	if user := p.upstream.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		connect.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := connect.Write(conn); err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), connect)
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_ = conn.Close()
+
+		return nil, fmt.Errorf("upstream proxy refused %s: %s", target, resp.Status)
+	}
+
+	return conn, nil
+}
+
+// forward relays a plain HTTP proxy request to its destination.
+func (p *egressProxy) forward(w http.ResponseWriter, r *http.Request) {
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	for _, header := range hopHeaders {
+		out.Header.Del(header)
+	}
+
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	for _, header := range hopHeaders {
+		resp.Header.Del(header)
+	}
+	for name, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
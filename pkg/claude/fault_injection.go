@@ -0,0 +1,86 @@
+package claude
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// FaultInjection makes the SDK misbehave on purpose so applications can
+// test their error handling. Faults are applied to messages read from the
+// CLI; rates are per-message probabilities between 0 and 1.
+//
+// Malformed messages fail with ErrCodeMessageParseFailed and injected
+// exits kill the CLI and fail with ErrCodeProcessCrashed, as real
+// failures would. Never enable it in production.
+type FaultInjection struct {
+	// DropRate is the probability a message is silently lost.
+	DropRate float64
+	// DelayRate is the probability a message is delayed by up to
+	// MaxDelay.
+	DelayRate float64
+	MaxDelay  time.Duration
+	// MalformedRate is the probability a message is truncated into
+	// invalid JSON.
+	MalformedRate float64
+	// ExitRate is the probability the CLI process is killed instead of a
+	// message being delivered.
+	ExitRate float64
+	// Seed makes the faults reproducible across runs. Zero picks a random
+	// seed.
+	Seed uint64
+}
+
+// validate checks that the rates are probabilities.
+func (f *FaultInjection) validate() error {
+	rates := []struct {
+		field string
+		rate  float64
+	}{
+		{"FaultInjection.DropRate", f.DropRate},
+		{"FaultInjection.DelayRate", f.DelayRate},
+		{"FaultInjection.MalformedRate", f.MalformedRate},
+		{"FaultInjection.ExitRate", f.ExitRate},
+	}
+	for _, r := range rates {
+		if r.rate < 0 || r.rate > 1 {
+			return clauderrs.NewValidationError(
+				clauderrs.ErrCodeRangeViolation,
+				fmt.Sprintf("%s must be between 0 and 1", r.field),
+				nil,
+				r.field,
+				r.rate,
+			)
+		}
+	}
+
+	if f.MaxDelay < 0 {
+		return clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"FaultInjection.MaxDelay must not be negative",
+			nil,
+			"FaultInjection.MaxDelay",
+			f.MaxDelay,
+		)
+	}
+
+	return nil
+}
+
+// faultConfig converts the options to the transport's fault config.
+func (f *FaultInjection) faultConfig() *transport.FaultConfig {
+	if f == nil {
+		return nil
+	}
+
+	return &transport.FaultConfig{
+		DropRate:      f.DropRate,
+		DelayRate:     f.DelayRate,
+		MaxDelay:      f.MaxDelay,
+		MalformedRate: f.MalformedRate,
+		ExitRate:      f.ExitRate,
+		Seed:          f.Seed,
+	}
+}
@@ -0,0 +1,344 @@
+package claude
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// diffContext is the number of unchanged lines around each hunk.
+	diffContext = 3
+	// maxDiffCells bounds the line comparison table; larger changes are
+	// shown as the old lines removed and the new lines added.
+	maxDiffCells = 4_000_000
+)
+
+// FileChange is a change the Edit, MultiEdit or Write tool intends to
+// make to a file, for showing in permission prompts and review UIs.
+type FileChange struct {
+	Path string
+	// Old is the text being replaced: an edit's old_string, or empty for
+	// a Write.
+	Old string
+	// New is the replacement text, or the whole new content for a Write.
+	New string
+	// ReplaceAll reports an edit replacing every occurrence of Old.
+	ReplaceAll bool
+	// WholeFile reports that Old and New are entire file contents, as
+	// for a Write or a change returned by ResolveFileChanges.
+	WholeFile bool
+}
+
+// fileEdit is an edit of the Edit and MultiEdit tools.
+type fileEdit struct {
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// ParseFileChanges returns the changes an Edit, MultiEdit or Write tool
+// call intends to make, in the order they apply, from the input passed to
+// CanUseTool. Other tools return no changes. A malformed input is
+// reported as a ValidationError.
+func ParseFileChanges(toolName string, input map[string]JSONValue) ([]FileChange, error) {
+	var target any
+	var edit struct {
+		FilePath string `json:"file_path"`
+		fileEdit
+	}
+	var multiEdit struct {
+		FilePath string     `json:"file_path"`
+		Edits    []fileEdit `json:"edits"`
+	}
+	var write FileWriteInput
+
+	switch toolName {
+	case "Edit":
+		target = &edit
+	case "MultiEdit":
+		target = &multiEdit
+	case "Write":
+		target = &write
+	default:
+		return nil, nil
+	}
+
+	data, err := json.Marshal(input)
+	if err == nil {
+		err = json.Unmarshal(data, target)
+	}
+	if err != nil {
+		return nil, clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidFormat,
+			fmt.Sprintf("failed to parse %s tool input", toolName),
+			err,
+			"input",
+			nil,
+		)
+	}
+
+	var changes []FileChange
+	var path string
+	switch toolName {
+	case "Edit":
+		path = edit.FilePath
+		changes = append(changes, edit.change(path))
+	case "MultiEdit":
+		path = multiEdit.FilePath
+		for _, e := range multiEdit.Edits {
+			changes = append(changes, e.change(path))
+		}
+	case "Write":
+		path = write.FilePath
+		changes = append(changes, FileChange{Path: path, New: write.Content, WholeFile: true})
+	}
+
+	if path == "" {
+		return nil, clauderrs.NewValidationError(
+			clauderrs.ErrCodeMissingField,
+			fmt.Sprintf("%s tool input has no file_path", toolName),
+			nil,
+			"file_path",
+			nil,
+		)
+	}
+
+	return changes, nil
+}
+
+// change returns the FileChange for e on path.
+func (e fileEdit) change(path string) FileChange {
+	return FileChange{Path: path, Old: e.OldString, New: e.NewString, ReplaceAll: e.ReplaceAll}
+}
+
+// ResolveFileChanges applies changes to the current contents of their
+// files, without writing them, and returns one WholeFile change per file
+// in the order the files first appear. Missing files are treated as
+// empty. An edit whose Old text isn't found, or is found more than once
+// without ReplaceAll, fails with a ValidationError as the tool itself
+// would.
+func ResolveFileChanges(changes []FileChange) ([]FileChange, error) {
+	var resolved []FileChange
+	index := make(map[string]int)
+
+	for _, change := range changes {
+		i, ok := index[change.Path]
+		if !ok {
+			data, err := os.ReadFile(change.Path)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			i = len(resolved)
+			index[change.Path] = i
+			resolved = append(resolved, FileChange{
+				Path:      change.Path,
+				Old:       string(data),
+				New:       string(data),
+				WholeFile: true,
+			})
+		}
+
+		content, err := change.apply(resolved[i].New)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i].New = content
+	}
+
+	return resolved, nil
+}
+
+// apply returns content with c applied.
+func (c FileChange) apply(content string) (string, error) {
+	if c.WholeFile || c.Old == "" && content == "" {
+		return c.New, nil
+	}
+
+	switch n := strings.Count(content, c.Old); {
+	case n == 0 || c.Old == "":
+		return "", clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidFormat,
+			fmt.Sprintf("text to replace was not found in %s", c.Path),
+			nil,
+			"old_string",
+			c.Old,
+		)
+	case n > 1 && !c.ReplaceAll:
+		return "", clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidFormat,
+			fmt.Sprintf("text to replace matches %d places in %s", n, c.Path),
+			nil,
+			"old_string",
+			c.Old,
+		)
+	case c.ReplaceAll:
+		return strings.ReplaceAll(content, c.Old, c.New), nil
+	default:
+		return strings.Replace(content, c.Old, c.New, 1), nil
+	}
+}
+
+// UnifiedDiff renders the change as a unified diff with three lines of
+// context, or returns "" if nothing changes. Line numbers are relative to
+// Old, so resolve edits with ResolveFileChanges first to number them by
+// their place in the file.
+func (c FileChange) UnifiedDiff() string {
+	if c.Old == c.New {
+		return ""
+	}
+
+	ops := diffLines(splitLines(c.Old), splitLines(c.New))
+
+	// Line numbers before each op
+	oldLine := make([]int, len(ops)+1)
+	newLine := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if op.kind != '+' {
+			oldLine[i+1]++
+		}
+		if op.kind != '-' {
+			newLine[i+1]++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", c.Path, c.Path)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+
+			continue
+		}
+
+		// Extend the hunk over changes separated by little context
+		start, end := max(i-diffContext, 0), i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end = min(end+diffContext, len(ops))
+
+				break
+			}
+			end = run
+		}
+
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			hunkRange(oldLine[start], oldLine[end]-oldLine[start]),
+			hunkRange(newLine[start], newLine[end]-newLine[start]),
+		)
+		for _, op := range ops[start:end] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+
+	return b.String()
+}
+
+// hunkRange formats the range of a hunk starting after line before.
+func hunkRange(before, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprint(before + 1)
+	default:
+		return fmt.Sprintf("%d,%d", before+1, count)
+	}
+}
+
+// diffOp is a line of a diff: kept (' '), removed ('-') or added ('+').
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// splitLines splits s into lines, keeping their line breaks.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// diffLines returns the edit from a to b keeping their longest common
+// subsequence of lines.
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		ops = append(ops, diffOp{' ', a[prefix]})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	if len(midA)*len(midB) > maxDiffCells {
+		for _, line := range midA {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range midB {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		// lcs[i*(m+1)+j] is the LCS length of midA[i:] and midB[j:]
+		n, m := len(midA), len(midB)
+		lcs := make([]int32, (n+1)*(m+1))
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if midA[i] == midB[j] {
+					lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+				} else {
+					lcs[i*(m+1)+j] = max(lcs[(i+1)*(m+1)+j], lcs[i*(m+1)+j+1])
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && midA[i] == midB[j]:
+				ops = append(ops, diffOp{' ', midA[i]})
+				i++
+				j++
+			case j == m || i < n && lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]:
+				ops = append(ops, diffOp{'-', midA[i]})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', midB[j]})
+				j++
+			}
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	return ops
+}
@@ -0,0 +1,69 @@
+package claude
+
+import (
+	"context"
+	"maps"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// Fork returns a new client continuing a copy of this client's
+// conversation, for exploring an alternative continuation without
+// disturbing the original. The fork's first Query resumes the current
+// session with the CLI's fork option, so it starts from the conversation
+// so far under a new session ID, while this client carries on unchanged.
+//
+// The fork uses this client's options, changed by modify, e.g. to pick a
+// different model or system prompt. Changes that conflict with the
+// conversation, such as removing a tool it called, are handled as for
+// Options.Resume (see CheckResume). The session must have started, i.e.
+// its first message been received; fork between turns, as the transcript
+// of a turn in progress is incomplete.
+func (c *ClaudeSDKClient) Fork(ctx context.Context, modify ...func(*Options)) (*ClaudeSDKClient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return nil, clauderrs.NewClientError(
+			clauderrs.ErrCodeClientClosed,
+			"client is closed",
+			nil,
+		)
+	}
+
+	sessionID := c.sessionID.Load()
+	if sessionID == nil {
+		return nil, clauderrs.NewClientError(
+			clauderrs.ErrCodeNoActiveQuery,
+			"session has not started yet",
+			nil,
+		)
+	}
+
+	// Maps are copied so modify can't change the original's options
+	opts := *c.opts
+	opts.Env = maps.Clone(opts.Env)
+	opts.ExtraArgs = maps.Clone(opts.ExtraArgs)
+	opts.McpServers = maps.Clone(opts.McpServers)
+	opts.Hooks = maps.Clone(opts.Hooks)
+	opts.Agents = maps.Clone(opts.Agents)
+	for _, fn := range modify {
+		fn(&opts)
+	}
+	opts.Continue = false
+	opts.Resume = *sessionID
+	opts.ResumeSessionAt = ""
+	opts.ForkSession = true
+
+	fork, err := NewClient(&opts)
+	if err != nil {
+		return nil, err
+	}
+	fork.readOnly = c.readOnly
+
+	return fork, nil
+}
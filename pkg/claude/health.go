@@ -0,0 +1,332 @@
+package claude
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// defaultStallTimeout is the Watchdog's StallTimeout when unset.
+	defaultStallTimeout = time.Minute
+	// stackDumpSize bounds the goroutine dump passed to Watchdog.OnStall.
+	stackDumpSize = 1 << 20
+)
+
+// GoroutineState is what one of a session's internal goroutines is doing.
+type GoroutineState string
+
+const (
+	// GoroutineWaiting means the goroutine is waiting for work: the reader
+	// for output from the CLI, the writer for a message to send, the
+	// dispatcher for a control request.
+	GoroutineWaiting GoroutineState = "waiting"
+	// GoroutineWorking means the goroutine is busy: the reader handing a
+	// message to a consumer, the writer writing to the CLI, the dispatcher
+	// running permission or hook callbacks.
+	GoroutineWorking GoroutineState = "working"
+	// GoroutineStopped means the session has ended.
+	GoroutineStopped GoroutineState = "stopped"
+)
+
+// GoroutineHealth describes one of a session's internal goroutines.
+type GoroutineHealth struct {
+	State GoroutineState
+	// Since is when the goroutine entered State; while working, when its
+	// oldest unfinished unit of work started.
+	Since time.Time
+	// LastActivity is when it last finished a unit of work.
+	LastActivity time.Time
+	// InFlight is how many units of work are unfinished: concurrent writes
+	// for the writer, running callbacks for the dispatcher.
+	InFlight int
+}
+
+// Health is a snapshot of a session's internal goroutines and queues, for
+// spotting a session that has deadlocked or leaks work.
+type Health struct {
+	// Active reports whether a session is running.
+	Active bool
+	// Reader reads messages from the CLI.
+	Reader GoroutineHealth
+	// Writer sends messages and control responses to the CLI.
+	Writer GoroutineHealth
+	// Dispatcher runs control requests from the CLI, such as hook and
+	// permission callbacks.
+	Dispatcher GoroutineHealth
+	// MessageBacklog is how many messages were read but not yet received.
+	MessageBacklog int
+	// ControlRequestBacklog is how many control requests from the CLI
+	// wait to be dispatched, including those waiting for one of
+	// Options.CallbackWorkers.
+	ControlRequestBacklog int
+	// PendingControlRequests is how many control requests sent to the
+	// CLI await a response.
+	PendingControlRequests int
+}
+
+// String formats h for logs and stall errors.
+func (h Health) String() string {
+	if !h.Active {
+		return "no active session"
+	}
+
+	now := time.Now()
+	format := func(g GoroutineHealth) string {
+		return fmt.Sprintf("%s for %s (%d in flight)", g.State, now.Sub(g.Since).Round(time.Millisecond), g.InFlight)
+	}
+
+	return fmt.Sprintf(
+		"reader %s, writer %s, dispatcher %s, %d messages and %d control requests queued, %d control requests pending",
+		format(h.Reader), format(h.Writer), format(h.Dispatcher),
+		h.MessageBacklog, h.ControlRequestBacklog, h.PendingControlRequests,
+	)
+}
+
+// Watchdog fails a session whose internal goroutines are stuck, instead of
+// letting it hang. A goroutine is stuck when it stays working on one unit
+// of work for StallTimeout: a write the CLI does not read, a permission or
+// hook callback that does not return, or a message nobody receives while
+// the buffer in front of ReceiveMessages is full.
+//
+// A stalled session is closed; Next and ReceiveMessages report a
+// ClientError with ErrCodeSessionStalled describing its Health.
+type Watchdog struct {
+	// StallTimeout is how long a goroutine may work on one unit of work.
+	// Zero means one minute. It must exceed the longest permission prompt
+	// a CanUseTool callback waits on.
+	StallTimeout time.Duration
+	// OnStall, if set, is called with the session's health and a dump of
+	// all goroutine stacks before the session is failed.
+	OnStall func(health Health, stacks []byte)
+}
+
+// validate checks the watchdog's timeout.
+func (w *Watchdog) validate() []error {
+	if w.StallTimeout < 0 {
+		return []error{clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"Watchdog.StallTimeout must not be negative",
+			nil,
+			"Watchdog.StallTimeout",
+			w.StallTimeout,
+		)}
+	}
+
+	return nil
+}
+
+// stallTimeout returns StallTimeout or its default.
+func (w *Watchdog) stallTimeout() time.Duration {
+	if w.StallTimeout > 0 {
+		return w.StallTimeout
+	}
+
+	return defaultStallTimeout
+}
+
+// activityTracker records the units of work of one goroutine, or of a
+// group of goroutines doing the same job.
+type activityTracker struct {
+	mu        sync.Mutex
+	stopped   bool
+	nextID    int
+	active    map[int]time.Time
+	idleSince time.Time
+	last      time.Time
+}
+
+func newActivityTracker() *activityTracker {
+	now := time.Now()
+
+	return &activityTracker{active: make(map[int]time.Time), idleSince: now, last: now}
+}
+
+// begin starts a unit of work; the returned func finishes it.
+func (t *activityTracker) begin() func() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := t.nextID
+	t.nextID++
+	t.active[id] = time.Now()
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		delete(t.active, id)
+		t.last = time.Now()
+		if len(t.active) == 0 {
+			t.idleSince = t.last
+		}
+	}
+}
+
+// touch records activity that completed without blocking.
+func (t *activityTracker) touch() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.last = time.Now()
+	if len(t.active) == 0 {
+		t.idleSince = t.last
+	}
+}
+
+// stop marks the goroutine as finished.
+func (t *activityTracker) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.stopped {
+		t.stopped = true
+		t.idleSince = time.Now()
+	}
+}
+
+// snapshot returns the tracker's state.
+func (t *activityTracker) snapshot() GoroutineHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	health := GoroutineHealth{
+		State:        GoroutineWaiting,
+		Since:        t.idleSince,
+		LastActivity: t.last,
+		InFlight:     len(t.active),
+	}
+	for _, started := range t.active {
+		if health.State != GoroutineWorking || started.Before(health.Since) {
+			health.State = GoroutineWorking
+			health.Since = started
+		}
+	}
+	if t.stopped {
+		health.State = GoroutineStopped
+		health.Since = t.idleSince
+	}
+
+	return health
+}
+
+// sessionHealth tracks a query's internal goroutines.
+type sessionHealth struct {
+	reader     *activityTracker
+	writer     *activityTracker
+	dispatcher *activityTracker
+}
+
+func newSessionHealth() sessionHealth {
+	return sessionHealth{
+		reader:     newActivityTracker(),
+		writer:     newActivityTracker(),
+		dispatcher: newActivityTracker(),
+	}
+}
+
+// health returns a snapshot of the query's goroutines and queues.
+func (q *queryImpl) health() Health {
+	q.mu.Lock()
+	active := !q.closed
+	pending := len(q.pendingControlResponses)
+	q.mu.Unlock()
+
+	return Health{
+		Active:                 active,
+		Reader:                 q.activity.reader.snapshot(),
+		Writer:                 q.activity.writer.snapshot(),
+		Dispatcher:             q.activity.dispatcher.snapshot(),
+		MessageBacklog:         len(q.msgChan),
+		ControlRequestBacklog:  len(q.controlRequestChan) + q.callbacks.queued(),
+		PendingControlRequests: pending,
+	}
+}
+
+// watch runs the watchdog until the query closes, failing it when a
+// goroutine stalls.
+func (q *queryImpl) watch(w *Watchdog) {
+	timeout := w.stallTimeout()
+	ticker := time.NewTicker(timeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.closeChan:
+			return
+		case now := <-ticker.C:
+			health := q.health()
+			stalled := stalledGoroutine(health, now, timeout)
+			if stalled == "" {
+				continue
+			}
+
+			if w.OnStall != nil {
+				stacks := make([]byte, stackDumpSize)
+				w.OnStall(health, stacks[:runtime.Stack(stacks, true)])
+			}
+			stallErr := clauderrs.NewClientError(
+				clauderrs.ErrCodeSessionStalled,
+				fmt.Sprintf("session stalled: %s working for over %s: %s", stalled, timeout, health),
+				nil,
+			).
+				WithSessionID(q.sessionID)
+			_ = stallErr.WithMetadata("health", health)
+			q.fail(stallErr)
+
+			return
+		}
+	}
+}
+
+// stalledGoroutine names the first goroutine working on one unit of work
+// for longer than timeout, or returns "" if none is.
+func stalledGoroutine(health Health, now time.Time, timeout time.Duration) string {
+	for _, g := range []struct {
+		name   string
+		health GoroutineHealth
+	}{
+		{"reader", health.Reader},
+		{"writer", health.Writer},
+		{"dispatcher", health.Dispatcher},
+	} {
+		if g.health.State == GoroutineWorking && now.Sub(g.health.Since) > timeout {
+			return g.name
+		}
+	}
+
+	return ""
+}
+
+// fail closes the query, reporting err from Next instead of io.EOF.
+func (q *queryImpl) fail(err error) {
+	err = q.redactor.error(err)
+	q.failure.CompareAndSwap(nil, &err)
+	_ = q.Close()
+}
+
+// failed returns the error the query was failed with, if any.
+func (q *queryImpl) failed() error {
+	if err := q.failure.Load(); err != nil {
+		return *err
+	}
+
+	return nil
+}
+
+// Health reports the state of the active session's internal goroutines
+// and queues. Health.Active is false when no session is running or it
+// has been closed.
+func (c *ClaudeSDKClient) Health() Health {
+	c.mu.Lock()
+	q, _ := c.query.(*queryImpl)
+	c.mu.Unlock()
+	if q == nil {
+		return Health{}
+	}
+
+	return q.health()
+}
@@ -0,0 +1,118 @@
+package claude
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// Block returns a hook output that blocks the action the hook was called
+// for, such as a tool call, prompt, or stop, and tells Claude reason.
+func Block(reason string) SyncHookOutput {
+	decision := HookDecisionBlock
+
+	return SyncHookOutput{Decision: &decision, Reason: &reason}
+}
+
+// Approve returns a hook output approving the action; for PreToolUse
+// hooks the tool runs without a permission prompt.
+func Approve() SyncHookOutput {
+	decision := HookDecisionApprove
+
+	return SyncHookOutput{Decision: &decision}
+}
+
+// Continue returns a hook output letting the session go on, showing
+// systemMessage to the user if it is not empty.
+func Continue(systemMessage string) SyncHookOutput {
+	proceed := true
+	output := SyncHookOutput{Continue: &proceed}
+	if systemMessage != "" {
+		output.SystemMessage = &systemMessage
+	}
+
+	return output
+}
+
+// Stop returns a hook output ending the session, showing stopReason to
+// the user.
+func Stop(stopReason string) SyncHookOutput {
+	proceed := false
+
+	return SyncHookOutput{Continue: &proceed, StopReason: &stopReason}
+}
+
+// Validate reports combinations of fields the CLI can't act on
+// consistently, such as a block without a reason or a stop reason on an
+// output that continues. Hook outputs are validated before they are sent,
+// and an invalid one fails the hook callback.
+func (o SyncHookOutput) Validate() error {
+	var errs []error
+
+	if o.Decision != nil {
+		switch *o.Decision {
+		case HookDecisionApprove, HookDecisionBlock:
+		default:
+			errs = append(errs, clauderrs.NewValidationError(
+				clauderrs.ErrCodeInvalidType,
+				fmt.Sprintf("unknown hook decision %q", *o.Decision),
+				nil,
+				"Decision",
+				*o.Decision,
+			))
+		}
+	}
+	blocks := o.Decision != nil && *o.Decision == HookDecisionBlock
+	approves := o.Decision != nil && *o.Decision == HookDecisionApprove
+	stops := o.Continue != nil && !*o.Continue
+
+	if blocks && (o.Reason == nil || *o.Reason == "") {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeMissingField,
+			"a block decision needs a reason to give Claude",
+			nil,
+			"Reason",
+			nil,
+		))
+	}
+	if o.Reason != nil && o.Decision == nil {
+		errs = append(errs, conflictError("Reason", "Reason is only used with a Decision", *o.Reason))
+	}
+	if o.StopReason != nil && !stops {
+		errs = append(errs, conflictError("StopReason", "StopReason requires Continue to be false", *o.StopReason))
+	}
+	if approves && stops {
+		errs = append(errs, conflictError("Continue", "an approved action cannot also stop the session", false))
+	}
+
+	var pre *PreToolUseHookOutput
+	switch specific := o.HookSpecificOutput.(type) {
+	case PreToolUseHookOutput:
+		pre = &specific
+	case *PreToolUseHookOutput:
+		pre = specific
+	}
+	if pre != nil && pre.PermissionDecision != nil {
+		switch PermissionDecision(*pre.PermissionDecision) {
+		case PermissionDecisionAllow:
+			if blocks {
+				errs = append(errs, conflictError("PermissionDecision", "a blocked tool call cannot have an allow permission decision", *pre.PermissionDecision))
+			}
+		case PermissionDecisionDeny, PermissionDecisionAsk:
+			if approves {
+				errs = append(errs, conflictError("PermissionDecision", "an approved tool call cannot have a deny or ask permission decision", *pre.PermissionDecision))
+			}
+		default:
+			errs = append(errs, clauderrs.NewValidationError(
+				clauderrs.ErrCodeInvalidType,
+				fmt.Sprintf("unknown permission decision %q", *pre.PermissionDecision),
+				nil,
+				"PermissionDecision",
+				*pre.PermissionDecision,
+			))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -213,7 +213,11 @@ type HookJSONOutput interface {
 	hookOutput()
 }
 
-// AsyncHookOutput indicates async hook processing.
+// AsyncHookOutput defers a hook's decision. The SDK holds the CLI's
+// request until the real output is delivered with
+// ClaudeSDKClient.CompleteAsyncHook, using the AsyncHookID of the hook's
+// context, or until AsyncTimeout (in milliseconds, default 60s) passes and
+// the hook fails.
 type AsyncHookOutput struct {
 	Async        bool `json:"async"`
 	AsyncTimeout *int `json:"asyncTimeout,omitempty"`
@@ -362,7 +366,8 @@ type HookCallbackMatcher struct {
 	Hooks   []HookCallback `json:"-"`
 	// Timeout specifies the maximum duration in milliseconds to wait for hook execution.
 	// If not specified, a default timeout applies. A timeout of 0 or negative value
-	// will use the default timeout behavior.
+	// will use the default timeout behavior. The CLI enforces it in whole seconds,
+	// rounded up.
 	Timeout *int `json:"timeout,omitempty"`
 }
 
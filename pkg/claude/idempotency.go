@@ -0,0 +1,139 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+)
+
+// resultKeyPrefix starts the SessionStore keys of idempotent query results.
+const resultKeyPrefix = "results/"
+
+// QueryOptions holds per-request options for ClaudeSDKClient.QueryWithOptions.
+type QueryOptions struct {
+	// IdempotencyKey identifies the request across retries. A key whose
+	// turn already completed successfully is answered from
+	// Options.SessionStore without running the turn again, and a key whose
+	// turn is still in progress is not sent a second time. Empty keys are
+	// never deduplicated.
+	IdempotencyKey string
+}
+
+// idempotency tracks a client's keyed requests: the keys of the turns
+// awaiting their results, oldest first, and a cached result waiting to be
+// replayed. Unkeyed turns are queued with an empty key, so each result
+// pairs with the turn it answers.
+type idempotency struct {
+	mu      sync.Mutex
+	pending []string
+	replay  *SDKResultMessage
+}
+
+// loadResult returns the result saved under key in store, or nil.
+func loadResult(ctx context.Context, store SessionStore, key string) (*SDKResultMessage, error) {
+	data, ok, err := store.Get(ctx, resultKeyPrefix+key)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	var result SDKResultMessage
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// takeReplay returns and clears the cached result to replay, if any.
+func (i *idempotency) takeReplay() *SDKResultMessage {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	result := i.replay
+	i.replay = nil
+
+	return result
+}
+
+// sent queues the key of a turn being sent.
+func (i *idempotency) sent(key string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.pending = append(i.pending, key)
+}
+
+// unsent drops the key queued last, for a turn that failed to send. Sends
+// are serialized, so it is the failed turn's.
+func (i *idempotency) unsent() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if len(i.pending) > 0 {
+		i.pending = i.pending[:len(i.pending)-1]
+	}
+}
+
+// inFlight reports whether the turn of key awaits its result.
+func (i *idempotency) inFlight(key string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return slices.Contains(i.pending, key)
+}
+
+// complete saves a delivered result under the key of the oldest pending
+// turn in store. Error results are not saved, so a retry runs the turn
+// again.
+func (i *idempotency) complete(ctx context.Context, store SessionStore, result *SDKResultMessage) error {
+	i.mu.Lock()
+	var key string
+	if len(i.pending) > 0 {
+		key = i.pending[0]
+		i.pending = i.pending[1:]
+	}
+	i.mu.Unlock()
+
+	if key == "" || result.IsError {
+		return nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	return store.Put(ctx, resultKeyPrefix+key, data)
+}
+
+// QueryWithOptions sends a query like Query, applying per-request options.
+//
+// With an IdempotencyKey whose result is already in the store, nothing is
+// sent and the next ReceiveResponse delivers only the cached result. If
+// the key's turn is still in progress on this client, nothing is sent and
+// ReceiveResponse continues that turn.
+func (c *ClaudeSDKClient) QueryWithOptions(
+	ctx context.Context,
+	prompt string,
+	qopts QueryOptions,
+) error {
+	key := qopts.IdempotencyKey
+	if key == "" {
+		return c.Query(ctx, prompt)
+	}
+
+	result, err := loadResult(ctx, c.store, key)
+	if err != nil {
+		return err
+	}
+
+	if result != nil {
+		c.idempotency.mu.Lock()
+		c.idempotency.replay = result
+		c.idempotency.mu.Unlock()
+
+		return nil
+	}
+
+	return c.send(ctx, prompt, key)
+}
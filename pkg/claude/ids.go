@@ -0,0 +1,43 @@
+package claude
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SequentialUUIDs returns a UUID generator for Options.NewUUID yielding
+// 00000000-0000-4000-8000-000000000001, then ...002 and so on: valid
+// version 4 UUIDs that are the same on every run.
+func SequentialUUIDs() func() UUID {
+	var counter atomic.Uint64
+
+	return func() UUID {
+		var id UUID
+		binary.BigEndian.PutUint64(id[8:], counter.Add(1))
+		id[6] = 0x40 // Version 4
+		id[8] = 0x80 // RFC 4122 variant
+
+		return id
+	}
+}
+
+// newUUID returns a UUID from NewUUID, or a random one.
+func (o *Options) newUUID() UUID {
+	if o.NewUUID != nil {
+		return o.NewUUID()
+	}
+
+	return uuid.New()
+}
+
+// now returns the time from Now, or the current time.
+func (o *Options) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+
+	return time.Now()
+}
@@ -0,0 +1,95 @@
+package claude
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// ProcessUser is a user the CLI runs as on Linux. Spawning as another user
+// needs the privilege to switch to it, typically running as root.
+type ProcessUser struct {
+	UID uint32
+	GID uint32
+	// Groups are the supplementary group IDs. Nil runs with none.
+	Groups []uint32
+}
+
+// ProcessJail confines the CLI's view of the filesystem on Linux, as a
+// defense-in-depth boundary around the agent's file operations. The CLI is
+// started through the claude-jail helper (see cmd/claude-jail), which
+// needs CAP_SYS_ADMIN, in new mount and PID namespaces, as the
+// unprivileged Options.RunAs user, which a jail requires, and sees only:
+//
+//   - the working directory (Options.Cwd, or the SDK's own), read-write
+//   - ReadOnlyPaths and the directory of the CLI executable, read-only
+//   - WritablePaths, read-write
+//   - the null, zero, full, random, urandom and tty devices, a /proc of
+//     the jail's own processes, and an empty /tmp
+//
+// all at their original locations. The CLI keeps its state under
+// ~/.claude, which usually belongs in WritablePaths. Subdirectories that
+// are separate mounts stay writable inside read-only paths.
+//
+// A jail that can't be set up fails the query with
+// ErrCodeProcessSpawnFailed, or, if the helper fails, ends the session
+// with its error on Options.Stderr.
+type ProcessJail struct {
+	// Helper is the path of the claude-jail binary. Empty looks it up in
+	// PATH.
+	Helper string
+	// ReadOnlyPaths are absolute paths bound read-only. Nil uses the
+	// system directories that exist among /bin, /sbin, /usr, /lib, /lib32,
+	// /lib64, /etc, /opt and /nix.
+	ReadOnlyPaths []string
+	// WritablePaths are absolute paths bound read-write.
+	WritablePaths []string
+}
+
+// validate checks the jail's paths are absolute.
+func (j *ProcessJail) validate() []error {
+	var errs []error
+
+	for field, paths := range map[string][]string{
+		"Jail.ReadOnlyPaths": j.ReadOnlyPaths,
+		"Jail.WritablePaths": j.WritablePaths,
+	} {
+		for _, path := range paths {
+			if !filepath.IsAbs(path) {
+				errs = append(errs, clauderrs.NewValidationError(
+					clauderrs.ErrCodeInvalidFormat,
+					fmt.Sprintf("%s must hold absolute paths, got %q", field, path),
+					nil,
+					field,
+					path,
+				))
+			}
+		}
+	}
+
+	return errs
+}
+
+// jailConfig converts the options to the transport's jail.
+func (j *ProcessJail) jailConfig() *transport.JailConfig {
+	if j == nil {
+		return nil
+	}
+
+	return &transport.JailConfig{
+		Helper:        j.Helper,
+		ReadOnlyPaths: j.ReadOnlyPaths,
+		WritablePaths: j.WritablePaths,
+	}
+}
+
+// processUser converts the options to the transport's user.
+func (u *ProcessUser) processUser() *transport.ProcessUser {
+	if u == nil {
+		return nil
+	}
+
+	return &transport.ProcessUser{UID: u.UID, GID: u.GID, Groups: u.Groups}
+}
@@ -0,0 +1,228 @@
+package claude
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// repairFrame tracks an open JSON container while repairing.
+type repairFrame struct {
+	closer    byte // '}' or ']'
+	expectKey bool // next string in this object is a key
+}
+
+// repairState is the scanner state for RepairJSON.
+type repairState struct {
+	out   []byte
+	stack []repairFrame
+	// safeLen is the length of out after the last complete value and
+	// safeClosers holds the closers needed to finish the document there.
+	safeLen     int
+	safeClosers []byte
+}
+
+// RepairJSON performs a best-effort repair of truncated or sloppy JSON such
+// as structured output cut off by max tokens.
+//
+// It strips surrounding markdown code fences, removes trailing commas,
+// closes unterminated strings, drops dangling object keys, and balances
+// open braces and brackets. The boolean result reports whether any repair
+// was applied. Valid input is returned unchanged.
+func RepairJSON(data []byte) (json.RawMessage, bool, error) {
+	trimmed := bytes.TrimSpace(data)
+	if json.Valid(trimmed) {
+		return json.RawMessage(trimmed), false, nil
+	}
+
+	trimmed = stripCodeFence(trimmed)
+	if json.Valid(trimmed) {
+		return json.RawMessage(trimmed), true, nil
+	}
+
+	state := &repairState{out: make([]byte, 0, len(trimmed)+8)}
+	inValueString := state.scan(trimmed)
+
+	// First try closing everything at the point of truncation.
+	candidate := append([]byte(nil), state.out...)
+	if inValueString {
+		candidate = append(candidate, '"')
+	}
+	candidate = append(bytes.TrimRight(candidate, " \t\r\n,"), closers(state.stack)...)
+	if json.Valid(candidate) {
+		return json.RawMessage(candidate), true, nil
+	}
+
+	// Otherwise fall back to the last complete value.
+	if state.safeLen > 0 {
+		candidate = append([]byte(nil), state.out[:state.safeLen]...)
+		candidate = append(candidate, state.safeClosers...)
+		if json.Valid(candidate) {
+			return json.RawMessage(candidate), true, nil
+		}
+	}
+
+	return nil, false, clauderrs.NewProtocolError(
+		clauderrs.ErrCodeMessageParseFailed,
+		"unable to repair truncated JSON",
+		nil,
+	)
+}
+
+// scan copies data into out while tracking containers and safe cut points.
+// It reports whether the input ended inside a string value.
+func (s *repairState) scan(data []byte) bool {
+	for i := 0; i < len(data); i++ {
+		ch := data[i]
+
+		switch ch {
+		case '{', '[':
+			closer := byte('}')
+			if ch == '[' {
+				closer = ']'
+			}
+			s.stack = append(s.stack, repairFrame{
+				closer:    closer,
+				expectKey: ch == '{',
+			})
+			s.out = append(s.out, ch)
+		case '}', ']':
+			s.out = bytes.TrimRight(s.out, " \t\r\n,")
+			if len(s.stack) > 0 {
+				s.stack = s.stack[:len(s.stack)-1]
+			}
+			s.out = append(s.out, ch)
+			s.markSafe()
+		case ',':
+			if top := s.top(); top != nil && top.closer == '}' {
+				top.expectKey = true
+			}
+			s.out = append(s.out, ch)
+		case ':':
+			if top := s.top(); top != nil {
+				top.expectKey = false
+			}
+			s.out = append(s.out, ch)
+		case '"':
+			isKey := s.top() != nil && s.top().closer == '}' && s.top().expectKey
+			end, closed := scanString(data, i)
+			s.out = append(s.out, data[i:end]...)
+			i = end - 1
+			if !closed {
+				// Drop a dangling escape so the closing quote is literal.
+				if bytes.HasSuffix(s.out, []byte(`\`)) {
+					s.out = s.out[:len(s.out)-1]
+				}
+
+				return !isKey
+			}
+			if !isKey {
+				s.markSafe()
+			}
+		case ' ', '\t', '\r', '\n':
+			s.out = append(s.out, ch)
+		default:
+			end := i
+			for end < len(data) && bytes.IndexByte([]byte(",:]} \t\r\n"), data[end]) < 0 {
+				end++
+			}
+			s.out = append(s.out, data[i:end]...)
+			if end < len(data) || json.Valid(data[i:end]) {
+				s.markSafe()
+			}
+			i = end - 1
+		}
+	}
+
+	return false
+}
+
+// top returns the innermost open container, if any.
+func (s *repairState) top() *repairFrame {
+	if len(s.stack) == 0 {
+		return nil
+	}
+
+	return &s.stack[len(s.stack)-1]
+}
+
+// markSafe records the current position as a complete-value boundary.
+func (s *repairState) markSafe() {
+	s.safeLen = len(s.out)
+	s.safeClosers = closers(s.stack)
+}
+
+// closers returns the closing characters for the open containers.
+func closers(stack []repairFrame) []byte {
+	out := make([]byte, 0, len(stack))
+	for i := len(stack) - 1; i >= 0; i-- {
+		out = append(out, stack[i].closer)
+	}
+
+	return out
+}
+
+// scanString returns the index just past the string starting at start and
+// whether the closing quote was found.
+func scanString(data []byte, start int) (int, bool) {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, true
+		}
+	}
+
+	return len(data), false
+}
+
+// stripCodeFence removes a surrounding markdown code fence, if present.
+func stripCodeFence(data []byte) []byte {
+	if !bytes.HasPrefix(data, []byte("```")) {
+		return data
+	}
+
+	if idx := bytes.IndexByte(data, '\n'); idx >= 0 {
+		data = data[idx+1:]
+	} else {
+		return data
+	}
+
+	data = bytes.TrimSpace(data)
+
+	return bytes.TrimSpace(bytes.TrimSuffix(data, []byte("```")))
+}
+
+// StructuredOutputLenient returns the structured output of the result,
+// repairing it if the model's JSON was truncated (for example by the max
+// output token limit).
+//
+// The parsed StructuredOutput field is preferred when present; otherwise the
+// Result text is decoded. The boolean reports whether a repair was applied,
+// so callers can decide whether to trust partially recovered data.
+func (m SDKResultMessage) StructuredOutputLenient() (json.RawMessage, bool, error) {
+	if m.StructuredOutput != nil {
+		data, err := json.Marshal(m.StructuredOutput)
+		if err != nil {
+			return nil, false, clauderrs.NewProtocolError(
+				clauderrs.ErrCodeMessageParseFailed,
+				"failed to marshal structured output",
+				err,
+			).WithMessageType("result")
+		}
+
+		return data, false, nil
+	}
+
+	if m.Result == nil {
+		return nil, false, clauderrs.NewProtocolError(
+			clauderrs.ErrCodeInvalidMessage,
+			"result message has no structured output",
+			nil,
+		).WithMessageType("result")
+	}
+
+	return RepairJSON([]byte(*m.Result))
+}
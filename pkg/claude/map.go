@@ -0,0 +1,116 @@
+package claude
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// defaultMapConcurrency is how many sessions Map runs at once by default.
+const defaultMapConcurrency = 4
+
+// Prompt is the query Map runs for one item.
+type Prompt struct {
+	Text string
+	// Options, if set, replaces MapOptions.Options for this item, e.g. to
+	// set Cwd to the repository being processed.
+	Options *Options
+}
+
+// MapOptions configures Map.
+type MapOptions struct {
+	// Options are the session options for each prompt.
+	Options *Options
+	// Concurrency is the most sessions run at once. It defaults to 4.
+	Concurrency int
+	// MinInterval, if set, is the least time between starting sessions,
+	// to stay under API rate limits.
+	MinInterval time.Duration
+}
+
+// MapResult is the outcome of one item of Map.
+type MapResult[T any] struct {
+	Item   T
+	Result *SDKResultMessage
+	// Err is set if the item's session failed or returned an error
+	// result, as for QueryResult.
+	Err error
+}
+
+// Map runs a one-shot session for each item, with the prompt fn builds
+// for it, and returns the results in the order of items. It suits sweeps
+// such as codemods and audits across many files or repositories. Sessions
+// run with bounded concurrency and go through QueryResult, so a
+// configured Cache applies. fn is called from one goroutine at a time.
+//
+// A failing item doesn't stop the others. Items not started when ctx is
+// done fail with an AbortedError.
+func Map[T any](ctx context.Context, items []T, fn func(item T) Prompt, opts *MapOptions) []MapResult[T] {
+	if opts == nil {
+		opts = &MapOptions{}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultMapConcurrency
+	}
+
+	results := make([]MapResult[T], len(items))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var lastStart time.Time
+
+	for i, item := range items {
+		results[i].Item = item
+
+		if err := acquireMapSlot(ctx, slots, lastStart, opts.MinInterval); err != nil {
+			results[i].Err = err
+
+			continue
+		}
+		lastStart = time.Now()
+
+		prompt := fn(item)
+		sessionOpts := prompt.Options
+		if sessionOpts == nil {
+			sessionOpts = opts.Options
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			results[i].Result, results[i].Err = QueryResult(ctx, prompt.Text, sessionOpts)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// acquireMapSlot waits for a free slot and for minInterval to pass since
+// lastStart.
+func acquireMapSlot(ctx context.Context, slots chan struct{}, lastStart time.Time, minInterval time.Duration) error {
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return clauderrs.NewAbortedError("map cancelled before the item started", ctx.Err())
+	}
+
+	if wait := time.Until(lastStart.Add(minInterval)); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			<-slots
+
+			return clauderrs.NewAbortedError("map cancelled before the item started", ctx.Err())
+		}
+	}
+
+	return nil
+}
@@ -5,7 +5,13 @@ package claude
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
 	"sync"
 )
 
@@ -36,12 +42,118 @@ type McpTool interface {
 }
 
 // McpToolResult represents the result of tool execution.
+//
+// Content may hold TextContentBlock, ImageContentBlock and
+// ResourceContentBlock values. It is sent to the CLI in MCP's content
+// format; other block types fail to marshal, and a tool returning them is
+// reported to the model as an error.
 type McpToolResult struct {
 	Content []ContentBlock `json:"content"`
 	IsError bool           `json:"isError,omitempty"`
 }
 
-// ToolFunc is the handler function for SDK MCP tools.
+// MarshalJSON encodes the result as an MCP CallToolResult.
+func (r McpToolResult) MarshalJSON() ([]byte, error) {
+	content := make([]map[string]any, 0, len(r.Content))
+	for i, block := range r.Content {
+		item, err := mcpContent(block)
+		if err != nil {
+			return nil, fmt.Errorf("content block %d: %w", i, err)
+		}
+		content = append(content, item)
+	}
+
+	result := map[string]any{"content": content}
+	if r.IsError {
+		result["isError"] = true
+	}
+
+	return json.Marshal(result)
+}
+
+// mcpContent converts a content block to an MCP content item.
+func mcpContent(block ContentBlock) (map[string]any, error) {
+	switch b := block.(type) {
+	case TextContentBlock:
+		return map[string]any{"type": "text", "text": b.Text}, nil
+	case TextBlock:
+		return map[string]any{"type": "text", "text": b.Text}, nil
+	case ImageContentBlock:
+		if b.Source.Data == "" || !strings.HasPrefix(b.Source.MediaType, "image/") {
+			return nil, errors.New("image block needs base64 data and an image/* media type")
+		}
+
+		return map[string]any{"type": "image", "data": b.Source.Data, "mimeType": b.Source.MediaType}, nil
+	case ResourceContentBlock:
+		if b.Resource.URI == "" {
+			return nil, errors.New("resource block needs a URI")
+		}
+		if b.Resource.Text != "" && b.Resource.Blob != "" {
+			return nil, errors.New("resource block needs text or a blob, not both")
+		}
+
+		return map[string]any{"type": "resource", "resource": b.Resource}, nil
+	default:
+		return nil, fmt.Errorf("unsupported content block %T in MCP tool result", block)
+	}
+}
+
+// ResourceContentBlock embeds a resource, such as a file, in an MCP tool
+// result.
+type ResourceContentBlock struct {
+	Type     string           `json:"type"` // "resource"
+	Resource EmbeddedResource `json:"resource"`
+}
+
+func (ResourceContentBlock) contentBlock() {}
+
+// EmbeddedResource is the content of a ResourceContentBlock. At most one
+// of Text and Blob is set.
+type EmbeddedResource struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	// Text is the content of a text resource.
+	Text string `json:"text,omitempty"`
+	// Blob is the base64-encoded content of a binary resource.
+	Blob string `json:"blob,omitempty"`
+}
+
+// TextResult returns a tool result holding text.
+func TextResult(text string) *McpToolResult {
+	return &McpToolResult{Content: []ContentBlock{TextContentBlock{Type: "text", Text: text}}}
+}
+
+// ImageResult returns a tool result holding an encoded image, such as a
+// PNG screenshot or chart. The media type is detected from the data.
+func ImageResult(data []byte) *McpToolResult {
+	return &McpToolResult{Content: []ContentBlock{ImageContentBlock{
+		Type: "image",
+		Source: ImageSource{
+			Type:      "base64",
+			MediaType: http.DetectContentType(data),
+			Data:      base64.StdEncoding.EncodeToString(data),
+		},
+	}}}
+}
+
+// ResourceResult returns a tool result embedding a resource. Data is sent
+// as text for text/* and JSON media types and base64-encoded otherwise.
+func ResourceResult(uri, mimeType string, data []byte) *McpToolResult {
+	resource := EmbeddedResource{URI: uri, MimeType: mimeType}
+	if strings.HasPrefix(mimeType, "text/") || mimeType == "application/json" {
+		resource.Text = string(data)
+	} else {
+		resource.Blob = base64.StdEncoding.EncodeToString(data)
+	}
+
+	return &McpToolResult{Content: []ContentBlock{ResourceContentBlock{Type: "resource", Resource: resource}}}
+}
+
+// ToolFunc is the handler function for SDK MCP tools. Handlers may be
+// called concurrently, from several sessions when their server is shared;
+// McpSessionID(ctx) reports which session a call belongs to. A handler
+// that panics fails only its call: the model gets an error result and the
+// panic and its stack are reported to Options.Stderr.
 type ToolFunc func(
 	ctx context.Context,
 	args map[string]any,
@@ -80,51 +192,56 @@ func Tool(
 	}
 }
 
-// sdkMcpServer implements McpServer.
+// sdkMcpServer implements McpServer. Its tools are fixed at creation, so
+// it can serve any number of sessions at once; sessions counts the
+// clients that have started it.
 type sdkMcpServer struct {
-	name    string
-	version string
-	tools   []McpTool
-	running bool
-	mu      sync.Mutex
+	name     string
+	version  string
+	tools    []McpTool
+	sessions int
+	mu       sync.Mutex
 }
 
 func (s *sdkMcpServer) Name() string     { return s.name }
 func (s *sdkMcpServer) Version() string  { return s.version }
 func (s *sdkMcpServer) Tools() []McpTool { return s.tools }
 
-func (s *sdkMcpServer) Start(_ctx context.Context) error {
+// Start registers a session using the server.
+func (s *sdkMcpServer) Start(_ context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.running {
-		return errors.New("server already running")
-	}
-
-	s.running = true
+	s.sessions++
 
 	return nil
 }
 
-func (s *sdkMcpServer) Stop(_ctx context.Context) error {
+// Stop releases a session started with Start.
+func (s *sdkMcpServer) Stop(_ context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.running {
-		return nil
+	if s.sessions > 0 {
+		s.sessions--
 	}
 
-	s.running = false
-
 	return nil
 }
 
 // CreateSdkMcpServer creates an SDK MCP server.
+//
+// The returned config can be shared by any number of clients, including
+// concurrent ones: each client answers its own CLI's MCP requests, so tool
+// results always go back to the session that called the tool, and the
+// tools list is copied so later changes to tools don't race with calls.
+// Create one server per process rather than per conversation, and keep
+// per-conversation state in the handlers keyed by McpSessionID.
 func CreateSdkMcpServer(name, version string, tools []McpTool) McpServerConfig {
 	server := &sdkMcpServer{
 		name:    name,
 		version: version,
-		tools:   tools,
+		tools:   append([]McpTool(nil), tools...),
 	}
 
 	return McpSdkServerConfig{
@@ -133,3 +250,27 @@ func CreateSdkMcpServer(name, version string, tools []McpTool) McpServerConfig {
 		Instance: server,
 	}
 }
+
+// toolPanicError reports a tool handler that panicked. Its text, returned
+// to the model, leaves out the stack.
+type toolPanicError struct {
+	tool  string
+	value any
+	stack []byte
+}
+
+func (e *toolPanicError) Error() string {
+	return fmt.Sprintf("tool %s panicked: %v", e.tool, e.value)
+}
+
+// executeTool runs tool, converting a panic in its handler into a
+// toolPanicError so the session survives it.
+func executeTool(ctx context.Context, tool McpTool, input map[string]any) (result *McpToolResult, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			result, err = nil, &toolPanicError{tool: tool.Name(), value: value, stack: debug.Stack()}
+		}
+	}()
+
+	return tool.Execute(ctx, input)
+}
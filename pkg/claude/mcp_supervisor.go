@@ -0,0 +1,605 @@
+package claude
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// Defaults of McpSupervision.
+	defaultMcpMaxRestarts    = 5
+	defaultMcpInitialBackoff = 200 * time.Millisecond
+	defaultMcpMaxBackoff     = 30 * time.Second
+
+	// mcpStableUptime is how long a restarted server must stay up before
+	// its restart count and backoff are reset.
+	mcpStableUptime = 30 * time.Second
+	// mcpHandshakeTimeout bounds the replayed handshake after a restart.
+	mcpHandshakeTimeout = 30 * time.Second
+	// mcpMaxLine bounds a single JSON-RPC message from a server.
+	mcpMaxLine = 10 << 20
+
+	// jsonrpcInternalError answers requests a supervised server could not
+	// serve.
+	jsonrpcInternalError = -32603
+
+	// McpServerStatus values reported for supervised servers.
+	mcpStatusConnected = "connected"
+	mcpStatusPending   = "pending"
+	mcpStatusFailed    = "failed"
+)
+
+var (
+	// errMcpNotSent means a message could not be written to the server.
+	errMcpNotSent = errors.New("MCP server is not running")
+	// errMcpExited means the server exited before answering a request.
+	errMcpExited = errors.New("MCP server exited before responding")
+)
+
+// McpSupervision makes the SDK run the stdio servers in Options.McpServers
+// itself, instead of the CLI, and restart any that exit mid-session. After
+// a restart the SDK replays the CLI's initialize handshake and lists the
+// server's tools again, so the CLI keeps using the server as if nothing
+// happened. Requests sent while a server restarts wait for it, and
+// requests lost when it exited are sent again, except tool calls the
+// server may already have acted on, which fail.
+//
+// Supervised servers are declared to the CLI as SDK servers and reached
+// through mcp_message control requests.
+type McpSupervision struct {
+	// MaxRestarts bounds consecutive restarts of a server that keeps
+	// exiting; the server is then reported as failed. A server that stays
+	// up for 30 seconds has its count reset. It defaults to 5.
+	MaxRestarts int
+	// InitialBackoff is the wait before the first restart, doubled for
+	// each consecutive restart up to MaxBackoff. They default to 200ms and
+	// 30s.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// OnStatus, if set, is called with each status transition of a
+	// supervised server: "connected", "pending" while restarting, and
+	// "failed" once it gave up.
+	OnStatus func(McpServerStatus)
+}
+
+// validate checks the supervision settings.
+func (s *McpSupervision) validate() error {
+	switch {
+	case s.MaxRestarts < 0:
+		return clauderrs.NewValidationError(clauderrs.ErrCodeRangeViolation,
+			"MaxRestarts must not be negative", nil, "McpSupervision.MaxRestarts", s.MaxRestarts)
+	case s.InitialBackoff < 0:
+		return clauderrs.NewValidationError(clauderrs.ErrCodeRangeViolation,
+			"InitialBackoff must not be negative", nil, "McpSupervision.InitialBackoff", s.InitialBackoff)
+	case s.MaxBackoff < 0:
+		return clauderrs.NewValidationError(clauderrs.ErrCodeRangeViolation,
+			"MaxBackoff must not be negative", nil, "McpSupervision.MaxBackoff", s.MaxBackoff)
+	}
+
+	return nil
+}
+
+// isStdioServer reports whether config is a stdio MCP server.
+func isStdioServer(config McpServerConfig) (McpStdioServerConfig, bool) {
+	stdio, ok := config.(McpStdioServerConfig)
+	if !ok || (stdio.Type != nil && *stdio.Type != "stdio") {
+		return McpStdioServerConfig{}, false
+	}
+
+	return stdio, true
+}
+
+// startMcpSupervisors starts supervisors for the stdio servers in
+// Options.McpServers when McpSupervision is set.
+func (q *queryImpl) startMcpSupervisors() {
+	if q.opts.McpSupervision == nil {
+		return
+	}
+
+	for name, config := range q.opts.McpServers {
+		stdio, ok := isStdioServer(config)
+		if !ok {
+			continue
+		}
+		if q.mcpSupervisors == nil {
+			q.mcpSupervisors = make(map[string]*mcpSupervisor)
+		}
+		supervisor := newMcpSupervisor(name, stdio, *q.opts.McpSupervision, q.opts.Env)
+		q.mcpSupervisors[name] = supervisor
+		go supervisor.run()
+	}
+}
+
+// stopMcpSupervisors stops the supervised servers.
+func (q *queryImpl) stopMcpSupervisors() {
+	for _, supervisor := range q.mcpSupervisors {
+		supervisor.close()
+	}
+}
+
+// cliMcpServers returns Options.McpServers as declared to the CLI, with
+// supervised servers replaced by SDK server declarations and the paging
+// and memory servers added.
+func (q *queryImpl) cliMcpServers() map[string]McpServerConfig {
+	if len(q.mcpSupervisors) == 0 && q.pager == nil && q.memory == nil {
+		return q.opts.McpServers
+	}
+
+	servers := make(map[string]McpServerConfig, len(q.opts.McpServers)+2)
+	for name, config := range q.opts.McpServers {
+		if _, ok := q.mcpSupervisors[name]; ok {
+			config = McpSdkServerConfig{Type: "sdk", Name: name}
+		}
+		servers[name] = config
+	}
+	if q.pager != nil {
+		servers[PagingServerName] = q.pager.server
+	}
+	if q.memory != nil {
+		servers[MemoryServerName] = *q.memory
+	}
+
+	return servers
+}
+
+// mcpSupervisor runs one stdio MCP server and restarts it when it exits.
+type mcpSupervisor struct {
+	name   string
+	config McpStdioServerConfig
+	policy McpSupervision
+	env    []string
+	done   chan struct{}
+
+	mu         sync.Mutex
+	status     string
+	generation int           // Incremented on each connect
+	changed    chan struct{} // Closed and replaced on each state change
+	stdin      io.WriteCloser
+	cmd        *exec.Cmd
+	pending    map[string]chan json.RawMessage
+	initParams json.RawMessage // The CLI's initialize params, for replay
+	serverInfo *McpServerInfo
+	nextID     int
+	closed     bool
+}
+
+// newMcpSupervisor creates a supervisor for config. sessionEnv is
+// Options.Env, which the server inherits like the CLI would pass it.
+func newMcpSupervisor(
+	name string,
+	config McpStdioServerConfig,
+	policy McpSupervision,
+	sessionEnv map[string]string,
+) *mcpSupervisor {
+	if policy.MaxRestarts == 0 {
+		policy.MaxRestarts = defaultMcpMaxRestarts
+	}
+	if policy.InitialBackoff == 0 {
+		policy.InitialBackoff = defaultMcpInitialBackoff
+	}
+	if policy.MaxBackoff == 0 {
+		policy.MaxBackoff = defaultMcpMaxBackoff
+	}
+
+	env := os.Environ()
+	for key, value := range sessionEnv {
+		env = append(env, key+"="+value)
+	}
+	for key, value := range config.Env {
+		env = append(env, key+"="+value)
+	}
+
+	return &mcpSupervisor{
+		name:    name,
+		config:  config,
+		policy:  policy,
+		env:     env,
+		done:    make(chan struct{}),
+		status:  mcpStatusPending,
+		changed: make(chan struct{}),
+		pending: make(map[string]chan json.RawMessage),
+	}
+}
+
+// run starts the server and restarts it with exponential backoff each time
+// it exits, until MaxRestarts consecutive restarts fail or the supervisor
+// is closed.
+func (s *mcpSupervisor) run() {
+	restarts := 0
+	backoff := s.policy.InitialBackoff
+	for {
+		started := time.Now()
+		exited, err := s.launch()
+		if err == nil {
+			select {
+			case <-exited:
+			case <-s.done:
+				return
+			}
+		}
+		if s.isClosed() {
+			return
+		}
+
+		if time.Since(started) >= mcpStableUptime {
+			restarts = 0
+			backoff = s.policy.InitialBackoff
+		}
+		if restarts >= s.policy.MaxRestarts {
+			s.setStatus(mcpStatusFailed)
+
+			return
+		}
+		restarts++
+		s.setStatus(mcpStatusPending)
+
+		select {
+		case <-time.After(backoff):
+		case <-s.done:
+			return
+		}
+		backoff = min(backoff*2, s.policy.MaxBackoff)
+	}
+}
+
+// launch starts the server process and, if the CLI already initialized
+// it, replays the handshake. The returned channel is closed when the
+// process exits.
+func (s *mcpSupervisor) launch() (<-chan struct{}, error) {
+	cmd := exec.Command(s.config.Command, s.config.Args...)
+	cmd.Env = s.env
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cmd = cmd
+	s.stdin = stdin
+	initParams := s.initParams
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		_ = cmd.Process.Kill()
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		s.readLoop(stdout)
+		_ = cmd.Wait()
+		s.failPending()
+		close(exited)
+	}()
+
+	if initParams != nil {
+		if err := s.handshake(initParams); err != nil {
+			_ = cmd.Process.Kill()
+			<-exited
+
+			return nil, err
+		}
+	}
+	s.setStatus(mcpStatusConnected)
+
+	return exited, nil
+}
+
+// handshake replays the CLI's initialize request on a restarted server,
+// then lists its tools again.
+func (s *mcpSupervisor) handshake(initParams json.RawMessage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mcpHandshakeTimeout)
+	defer cancel()
+
+	if _, err := s.call(ctx, "initialize", initParams); err != nil {
+		return err
+	}
+	if err := s.write(map[string]any{"jsonrpc": "2.0", "method": "notifications/initialized"}); err != nil {
+		return err
+	}
+	_, err := s.call(ctx, "tools/list", nil)
+
+	return err
+}
+
+// call sends a request of the supervisor's own and waits for its result.
+func (s *mcpSupervisor) call(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	s.mu.Lock()
+	s.nextID++
+	id, _ := json.Marshal(fmt.Sprintf("supervisor-%d", s.nextID))
+	s.mu.Unlock()
+
+	request := map[string]any{"jsonrpc": "2.0", "id": json.RawMessage(id), "method": method}
+	if params != nil {
+		request["params"] = params
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.roundTrip(ctx, id, data)
+	if err != nil {
+		return nil, err
+	}
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(response, &reply); err != nil {
+		return nil, err
+	}
+	if reply.Error != nil {
+		return nil, fmt.Errorf("%s failed: %s", method, reply.Error.Message)
+	}
+
+	return reply.Result, nil
+}
+
+// relay forwards a JSON-RPC message from the CLI to the server and returns
+// the mcp_message response payload.
+func (s *mcpSupervisor) relay(ctx context.Context, msg jsonrpcRequest, raw json.RawMessage) map[string]any {
+	generation, err := s.await(ctx, 0)
+	for attempt := 0; ; attempt++ {
+		if err != nil {
+			return mcpResponse(msg.ID, nil, jsonrpcInternalError, err.Error())
+		}
+
+		var response json.RawMessage
+		if len(msg.ID) == 0 {
+			// Notifications get no response
+			err = s.writeRaw(raw)
+		} else {
+			response, err = s.roundTrip(ctx, msg.ID, raw)
+		}
+		if err == nil {
+			if len(msg.ID) == 0 {
+				return mcpResponse(nil, map[string]any{}, 0, "")
+			}
+			if msg.Method == "initialize" {
+				s.recordInitialize(msg.Params, response)
+			}
+
+			return map[string]any{"mcp_response": response}
+		}
+
+		// Send lost messages again once the server is back, unless the
+		// server may have acted on them
+		lost := errors.Is(err, errMcpNotSent) || (errors.Is(err, errMcpExited) && msg.Method != "tools/call")
+		if !lost || attempt > 0 {
+			return mcpResponse(msg.ID, nil, jsonrpcInternalError, err.Error())
+		}
+		generation, err = s.await(ctx, generation)
+	}
+}
+
+// await waits until the server is connected in a generation after the
+// given one, and returns that generation.
+func (s *mcpSupervisor) await(ctx context.Context, after int) (int, error) {
+	for {
+		s.mu.Lock()
+		status, generation, changed := s.status, s.generation, s.changed
+		s.mu.Unlock()
+
+		switch {
+		case status == mcpStatusFailed:
+			return 0, fmt.Errorf("MCP server %q failed and was not restarted", s.name)
+		case status == mcpStatusConnected && generation > after:
+			return generation, nil
+		}
+
+		select {
+		case <-changed:
+		case <-s.done:
+			return 0, errors.New("session closed")
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// recordInitialize keeps the CLI's initialize params for replay after a
+// restart, and the server's info for status reports.
+func (s *mcpSupervisor) recordInitialize(params, response json.RawMessage) {
+	var reply struct {
+		Result struct {
+			ServerInfo *McpServerInfo `json:"serverInfo"`
+		} `json:"result"`
+	}
+	_ = json.Unmarshal(response, &reply)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initParams = params
+	if s.initParams == nil {
+		s.initParams = json.RawMessage(`{}`)
+	}
+	s.serverInfo = reply.Result.ServerInfo
+}
+
+// roundTrip writes data and waits for the response with id.
+func (s *mcpSupervisor) roundTrip(ctx context.Context, id, data json.RawMessage) (json.RawMessage, error) {
+	key := string(id)
+	ch := make(chan json.RawMessage, 1)
+	s.mu.Lock()
+	s.pending[key] = ch
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, key)
+		s.mu.Unlock()
+	}()
+
+	if err := s.writeRaw(data); err != nil {
+		return nil, err
+	}
+
+	select {
+	case response, ok := <-ch:
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is being restarted", errMcpExited, s.name)
+		}
+
+		return response, nil
+	case <-s.done:
+		return nil, fmt.Errorf("session closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// write encodes message and sends it to the server.
+func (s *mcpSupervisor) write(message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	return s.writeRaw(data)
+}
+
+// writeRaw sends one line to the server.
+func (s *mcpSupervisor) writeRaw(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stdin == nil {
+		return fmt.Errorf("%w: %q", errMcpNotSent, s.name)
+	}
+	if _, err := s.stdin.Write(append(append([]byte(nil), data...), '\n')); err != nil {
+		return fmt.Errorf("%w: %q: %v", errMcpNotSent, s.name, err)
+	}
+
+	return nil
+}
+
+// readLoop routes responses from the server to their waiting requests.
+// Requests and notifications from the server are not relayed.
+func (s *mcpSupervisor) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64<<10), mcpMaxLine)
+	for scanner.Scan() {
+		var envelope struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &envelope); err != nil || envelope.Method != "" || len(envelope.ID) == 0 {
+			continue
+		}
+
+		s.mu.Lock()
+		ch, ok := s.pending[string(envelope.ID)]
+		delete(s.pending, string(envelope.ID))
+		s.mu.Unlock()
+		if ok {
+			ch <- append(json.RawMessage(nil), scanner.Bytes()...)
+		}
+	}
+}
+
+// failPending fails the requests in flight when the server exits.
+func (s *mcpSupervisor) failPending() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, ch := range s.pending {
+		close(ch)
+		delete(s.pending, key)
+	}
+	s.stdin = nil
+	s.notifyLocked()
+}
+
+// notifyLocked wakes goroutines waiting in await. Callers must hold s.mu.
+func (s *mcpSupervisor) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// setStatus records a status transition and reports it to OnStatus.
+func (s *mcpSupervisor) setStatus(status string) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+
+		return
+	}
+	s.status = status
+	if status == mcpStatusConnected {
+		s.generation++
+	}
+	s.notifyLocked()
+	report := s.statusLocked()
+	s.mu.Unlock()
+
+	if s.policy.OnStatus != nil {
+		s.policy.OnStatus(report)
+	}
+}
+
+// currentStatus returns the server's status.
+func (s *mcpSupervisor) currentStatus() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.status
+}
+
+// statusLocked returns the server's status report. Callers must hold
+// s.mu.
+func (s *mcpSupervisor) statusLocked() McpServerStatus {
+	return McpServerStatus{Name: s.name, Status: s.status, ServerInfo: s.serverInfo}
+}
+
+// report returns the server's status report.
+func (s *mcpSupervisor) report() McpServerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.statusLocked()
+}
+
+// isClosed reports whether the supervisor was closed.
+func (s *mcpSupervisor) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+// close stops supervising and kills the server.
+func (s *mcpSupervisor) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.done)
+	if s.stdin != nil {
+		_ = s.stdin.Close()
+	}
+	if s.cmd != nil && s.cmd.Process != nil {
+		_ = s.cmd.Process.Kill()
+	}
+}
@@ -194,10 +194,24 @@ func (ToolResultContentBlock) contentBlock() {}
 type ThinkingBlock struct {
 	Type     string `json:"type"` // "thinking"
 	Thinking string `json:"thinking"`
+	// Signature verifies the thinking to the API. It must be kept when the
+	// block is sent back, or continued extended-thinking conversations are
+	// rejected.
+	Signature string `json:"signature,omitempty"`
 }
 
 func (ThinkingBlock) contentBlock() {}
 
+// RedactedThinkingBlock is extended thinking flagged by safety systems and
+// returned encrypted. It is opaque but, like a ThinkingBlock, must be sent
+// back unchanged.
+type RedactedThinkingBlock struct {
+	Type string `json:"type"` // "redacted_thinking"
+	Data string `json:"data"`
+}
+
+func (RedactedThinkingBlock) contentBlock() {}
+
 // TextBlock represents text content.
 type TextBlock struct {
 	Type string `json:"type"` // "text"
@@ -267,6 +281,17 @@ func decodeContentBlock(data []byte) (ContentBlock, error) {
 			).WithMessageType("thinking")
 		}
 
+		return block, nil
+	case "redacted_thinking":
+		var block RedactedThinkingBlock
+		if err := json.Unmarshal(data, &block); err != nil {
+			return nil, clauderrs.NewProtocolError(
+				clauderrs.ErrCodeMessageParseFailed,
+				"failed to parse redacted thinking block",
+				err,
+			).WithMessageType("redacted_thinking")
+		}
+
 		return block, nil
 	case "image":
 		var block ImageContentBlock
@@ -319,6 +344,20 @@ type SDKAssistantMessage struct {
 
 func (SDKAssistantMessage) Type() string { return "assistant" }
 
+// MarshalJSON includes the type field so stored messages, including their
+// thinking signatures, decode again with DecodeMessage.
+func (m SDKAssistantMessage) MarshalJSON() ([]byte, error) {
+	type Alias SDKAssistantMessage
+
+	return json.Marshal(&struct {
+		TypeField string `json:"type"`
+		*Alias
+	}{
+		TypeField: m.Type(),
+		Alias:     (*Alias)(&m),
+	})
+}
+
 // APIAssistantMessage represents the actual assistant message.
 type APIAssistantMessage struct {
 	ID           string         `json:"id"`
@@ -384,6 +423,22 @@ type SDKStreamEvent struct {
 
 func (SDKStreamEvent) Type() string { return "stream_event" }
 
+// MarshalJSON includes the type field and the event, so stored stream
+// events decode again with DecodeMessage.
+func (e SDKStreamEvent) MarshalJSON() ([]byte, error) {
+	type Alias SDKStreamEvent
+
+	return json.Marshal(&struct {
+		TypeField string                `json:"type"`
+		Event     RawMessageStreamEvent `json:"event"`
+		*Alias
+	}{
+		TypeField: e.Type(),
+		Event:     e.Event,
+		Alias:     (*Alias)(&e),
+	})
+}
+
 // UnmarshalJSON decodes the event union into a typed value.
 func (e *SDKStreamEvent) UnmarshalJSON(data []byte) error {
 	type Alias struct {
@@ -472,17 +527,52 @@ type MessageStopEvent struct {
 
 func (MessageStopEvent) EventType() string { return "message_stop" }
 
-// ContentDelta represents partial updates to a text or tool block.
+// ContentDelta represents partial updates to a text, thinking, or tool block.
+// At most one field is set.
 type ContentDelta struct {
 	TextDelta *string `json:"text_delta,omitempty"`
-	// Additional delta fields handled as needed (e.g., tool call streaming)
+	// ThinkingDelta is a fragment of an extended thinking block.
+	ThinkingDelta *string `json:"thinking_delta,omitempty"`
+	// SignatureDelta is the signature of the thinking block, sent at its
+	// end.
+	SignatureDelta *string `json:"signature_delta,omitempty"`
+	// PartialJSON is a fragment of a tool_use block's input JSON.
+	PartialJSON *string `json:"partial_json,omitempty"`
+}
+
+// MarshalJSON writes the delta as the CLI sends it, so stream events
+// decode again.
+func (d ContentDelta) MarshalJSON() ([]byte, error) {
+	type wire struct {
+		Type        string  `json:"type"`
+		Text        *string `json:"text,omitempty"`
+		Thinking    *string `json:"thinking,omitempty"`
+		Signature   *string `json:"signature,omitempty"`
+		PartialJSON *string `json:"partial_json,omitempty"`
+	}
+
+	switch {
+	case d.TextDelta != nil:
+		return json.Marshal(wire{Type: "text_delta", Text: d.TextDelta})
+	case d.ThinkingDelta != nil:
+		return json.Marshal(wire{Type: "thinking_delta", Thinking: d.ThinkingDelta})
+	case d.SignatureDelta != nil:
+		return json.Marshal(wire{Type: "signature_delta", Signature: d.SignatureDelta})
+	case d.PartialJSON != nil:
+		return json.Marshal(wire{Type: "input_json_delta", PartialJSON: d.PartialJSON})
+	}
+
+	return json.Marshal(wire{})
 }
 
 // decodeContentDelta converts raw JSON into a typed delta representation.
 func decodeContentDelta(data []byte) (ContentDelta, error) {
 	var envelope struct {
-		Type string `json:"type"`
-		Text string `json:"text,omitempty"`
+		Type        string  `json:"type"`
+		Text        string  `json:"text,omitempty"`
+		Thinking    string  `json:"thinking,omitempty"`
+		Signature   string  `json:"signature,omitempty"`
+		PartialJSON *string `json:"partial_json,omitempty"`
 	}
 	if err := json.Unmarshal(data, &envelope); err != nil {
 		return ContentDelta{}, clauderrs.NewProtocolError(
@@ -493,8 +583,14 @@ func decodeContentDelta(data []byte) (ContentDelta, error) {
 	}
 
 	switch envelope.Type {
-	case "text_delta", "input_json_delta":
-		// "input_json_delta" also carries text payloads for current protocol.
+	case "input_json_delta":
+		if envelope.PartialJSON != nil {
+			return ContentDelta{PartialJSON: envelope.PartialJSON}, nil
+		}
+
+		// Older CLIs sent tool input fragments in a text field
+		fallthrough
+	case "text_delta":
 		if envelope.Text == "" {
 			return ContentDelta{}, clauderrs.NewProtocolError(
 				clauderrs.ErrCodeInvalidMessage,
@@ -505,6 +601,14 @@ func decodeContentDelta(data []byte) (ContentDelta, error) {
 		text := envelope.Text
 
 		return ContentDelta{TextDelta: &text}, nil
+	case "thinking_delta":
+		thinking := envelope.Thinking
+
+		return ContentDelta{ThinkingDelta: &thinking}, nil
+	case "signature_delta":
+		signature := envelope.Signature
+
+		return ContentDelta{SignatureDelta: &signature}, nil
 	default:
 		return ContentDelta{}, clauderrs.NewProtocolError(
 			clauderrs.ErrCodeUnknownMessageType,
@@ -664,6 +768,20 @@ type SDKSystemMessage struct {
 
 func (SDKSystemMessage) Type() string { return "system" }
 
+// MarshalJSON includes the type field so stored system messages decode
+// again with DecodeMessage.
+func (m SDKSystemMessage) MarshalJSON() ([]byte, error) {
+	type Alias SDKSystemMessage
+
+	return json.Marshal(&struct {
+		TypeField string `json:"type"`
+		*Alias
+	}{
+		TypeField: m.Type(),
+		Alias:     (*Alias)(&m),
+	})
+}
+
 // SystemInitMessage represents initialization message.
 type SystemInitMessage struct {
 	SDKSystemMessage
@@ -724,10 +842,26 @@ type SDKResultMessage struct {
 	// (subtype error_during_execution or error_max_turns), this field holds
 	// an array of error message strings describing what went wrong during execution.
 	Errors []string `json:"errors,omitempty"`
+
+	totalCost *USD // total_cost_usd as decoded, for TotalCost
 }
 
 func (SDKResultMessage) Type() string { return "result" }
 
+// MarshalJSON includes the type field so stored results decode again with
+// DecodeMessage.
+func (m SDKResultMessage) MarshalJSON() ([]byte, error) {
+	type Alias SDKResultMessage
+
+	return json.Marshal(&struct {
+		TypeField string `json:"type"`
+		*Alias
+	}{
+		TypeField: m.Type(),
+		Alias:     (*Alias)(&m),
+	})
+}
+
 // Result subtype constants define the possible values for SDKResultMessage.Subtype.
 const (
 	// ResultSubtypeSuccess indicates the query completed successfully.
@@ -848,6 +982,8 @@ const (
 	ControlRequestSubtypeMcpMessage        = "mcp_message"
 	ControlRequestSubtypeCanUseTool        = "can_use_tool"
 	ControlRequestSubtypeHookCallback      = "hook_callback"
+	ControlRequestSubtypeAuthenticate      = "authenticate"
+	ControlRequestSubtypeRewindCode        = "rewind_code"
 
 	// Control response subtypes.
 	ControlResponseSubtypeSuccess = "success"
@@ -864,6 +1000,20 @@ type SDKControlRequest struct {
 
 func (SDKControlRequest) Type() string { return ControlRequest }
 
+// MarshalJSON ensures the type field is always set to "control_request" so
+// the CLI routes the frame to its control handler.
+func (r SDKControlRequest) MarshalJSON() ([]byte, error) {
+	type Alias SDKControlRequest
+
+	return json.Marshal(&struct {
+		TypeField string `json:"type"`
+		*Alias
+	}{
+		TypeField: r.Type(),
+		Alias:     (*Alias)(&r),
+	})
+}
+
 // ControlRequestVariant is the interface for all control request variants.
 type ControlRequestVariant interface {
 	// Subtype returns the control request subtype string.
@@ -898,6 +1048,8 @@ func (r SDKControlInterruptRequest) MarshalJSON() ([]byte, error) {
 type SDKControlInitializeRequest struct {
 	SubtypeField string               `json:"subtype"` // "initialize"
 	Hooks        map[string]JSONValue `json:"hooks,omitempty"`
+	// ProtocolVersion is the SDK's ProtocolVersion.
+	ProtocolVersion int `json:"protocolVersion,omitempty"`
 }
 
 func (r SDKControlInitializeRequest) Subtype() string {
@@ -1066,6 +1218,18 @@ func decodeControlRequestVariant(data []byte) (ControlRequestVariant, error) {
 			).WithMessageType(ControlRequestSubtypeMcpMessage)
 		}
 
+		return req, nil
+	case ControlRequestSubtypeAuthenticate:
+		var req SDKControlAuthenticateRequest
+		err := json.Unmarshal(data, &req)
+		if err != nil {
+			return nil, clauderrs.NewProtocolError(
+				clauderrs.ErrCodeMessageParseFailed,
+				"failed to parse authenticate control request",
+				err,
+			).WithMessageType(ControlRequestSubtypeAuthenticate)
+		}
+
 		return req, nil
 	default:
 		return nil, clauderrs.NewProtocolError(
@@ -1161,6 +1325,20 @@ func (r *SDKControlResponse) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON ensures the type field is always set to "control_response" so
+// the CLI can route responses to its pending requests.
+func (r SDKControlResponse) MarshalJSON() ([]byte, error) {
+	type Alias SDKControlResponse
+
+	return json.Marshal(&struct {
+		TypeField string `json:"type"`
+		*Alias
+	}{
+		TypeField: messageTypeControlResponse,
+		Alias:     (*Alias)(&r),
+	})
+}
+
 // decodeControlResponseVariant converts raw JSON into a typed control
 // response variant.
 func decodeControlResponseVariant(data []byte) (ControlResponseVariant, error) {
@@ -1250,3 +1428,110 @@ type SDKHookCallbackRequest struct {
 func (SDKHookCallbackRequest) Type() string        { return ControlRequest }
 func (r SDKHookCallbackRequest) Subtype() string   { return r.SubtypeField }
 func (r SDKHookCallbackRequest) RequestID() string { return r.RequestIDField }
+
+// DecodeMessage decodes a single stream-json message from the CLI by its
+// "type" field. Control protocol frames are not SDK messages and return an
+// ErrCodeUnknownMessageType error.
+func DecodeMessage(data []byte) (SDKMessage, error) {
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, clauderrs.NewProtocolError(
+			clauderrs.ErrCodeMessageParseFailed,
+			"failed to parse message envelope",
+			err,
+		)
+	}
+
+	msg, err := decodeMessage(envelope.Type, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// decodeMessage decodes data as the SDK message of the given type.
+func decodeMessage(msgType string, data []byte) (SDKMessage, *clauderrs.ProtocolError) {
+	switch msgType {
+	case "user":
+		var msg SDKUserMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, clauderrs.NewProtocolError(
+				clauderrs.ErrCodeMessageParseFailed,
+				"failed to parse user message",
+				err,
+			).WithMessageType("user")
+		}
+
+		return &msg, nil
+
+	case "assistant":
+		var msg SDKAssistantMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, clauderrs.NewProtocolError(
+				clauderrs.ErrCodeMessageParseFailed,
+				"failed to parse assistant message",
+				err,
+			).WithMessageType("assistant")
+		}
+
+		return &msg, nil
+
+	case "stream_event":
+		var msg SDKStreamEvent
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, clauderrs.NewProtocolError(
+				clauderrs.ErrCodeMessageParseFailed,
+				"failed to parse stream event",
+				err,
+			).WithMessageType("stream_event")
+		}
+
+		return &msg, nil
+
+	case "system":
+		var msg SDKSystemMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, clauderrs.NewProtocolError(
+				clauderrs.ErrCodeMessageParseFailed,
+				"failed to parse system message",
+				err,
+			).WithMessageType("system")
+		}
+
+		return &msg, nil
+
+	case "auth_status":
+		var msg SDKAuthStatusMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, clauderrs.NewProtocolError(
+				clauderrs.ErrCodeMessageParseFailed,
+				"failed to parse auth status message",
+				err,
+			).WithMessageType("auth_status")
+		}
+
+		return &msg, nil
+
+	case "result":
+		var msg SDKResultMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, clauderrs.NewProtocolError(
+				clauderrs.ErrCodeMessageParseFailed,
+				"failed to parse result message",
+				err,
+			).WithMessageType("result")
+		}
+
+		return &msg, nil
+
+	default:
+		return nil, clauderrs.NewProtocolError(
+			clauderrs.ErrCodeUnknownMessageType,
+			fmt.Sprintf("unknown message type: %s", msgType),
+			nil,
+		).WithMessageType(msgType)
+	}
+}
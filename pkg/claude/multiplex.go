@@ -0,0 +1,56 @@
+package claude
+
+import (
+	"context"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// Multiplexer is one connection to a claude-daemon carrying the CLI
+// sessions of many clients, so a server running hundreds of lightweight
+// conversations holds one connection instead of one per conversation.
+// Clients share it by setting Options.Multiplexer; the daemon runs a CLI
+// per session and the Multiplexer routes each session's messages to its
+// client.
+//
+// Output is buffered per session; a client that stops reading holds up
+// the others once its buffer fills. Closing the Multiplexer ends every
+// session on it.
+type Multiplexer struct {
+	mux *transport.DaemonMux
+}
+
+// NewMultiplexer connects to the claude-daemon listening on address, a
+// Unix socket path or Windows named pipe (\\.\pipe\name).
+func NewMultiplexer(ctx context.Context, address string) (*Multiplexer, error) {
+	mux, err := transport.DialDaemonMux(ctx, address)
+	if err != nil {
+		return nil, clauderrs.NewTransportError(
+			clauderrs.ErrCodeConnectionFailed,
+			"failed to connect to claude-daemon",
+			err,
+		)
+	}
+
+	return &Multiplexer{mux: mux}, nil
+}
+
+// Sessions returns the number of sessions open on the connection.
+func (m *Multiplexer) Sessions() int {
+	return m.mux.Sessions()
+}
+
+// Close closes the connection, stopping the CLIs of all its sessions.
+func (m *Multiplexer) Close() error {
+	return m.mux.Close()
+}
+
+// daemonMux returns the connection, or nil for a nil Multiplexer.
+func (m *Multiplexer) daemonMux() *transport.DaemonMux {
+	if m == nil {
+		return nil
+	}
+
+	return m.mux
+}
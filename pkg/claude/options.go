@@ -1,6 +1,9 @@
 package claude
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Options configures the Claude SDK client.
 type Options struct {
@@ -22,6 +25,8 @@ type Options struct {
 	PermissionMode PermissionMode
 	// Customize which tool is used for permission prompts
 	PermissionPromptToolName string
+	// AskPolicy escalates permission prompts to an external approver
+	AskPolicy *AskPolicy
 
 	// Session management
 	Continue        bool
@@ -35,17 +40,50 @@ type Options struct {
 	ExecutableArgs []string
 	ExtraArgs      map[string]*string
 
+	// CredentialsProvider supplies the API key or OAuth token each time a
+	// CLI process starts, instead of ANTHROPIC_API_KEY in the environment.
+	CredentialsProvider CredentialsProvider
+
+	// Provider selects the Anthropic API, Amazon Bedrock or Google Vertex
+	// AI. ProviderRegion sets the Bedrock or Vertex region and
+	// VertexProjectID the Vertex project; both default to the
+	// environment.
+	Provider        Provider
+	ProviderRegion  string
+	VertexProjectID string
+
 	// Model configuration
-	Model             string
-	FallbackModel     string
+	Model         string
+	FallbackModel string
+	// MaxThinkingTokens caps the extended thinking tokens of each model
+	// response, and MaxOutputTokens all of its output tokens, thinking
+	// included, so batch jobs get a fixed upper bound per call. Zero keeps
+	// the CLI's defaults. When the model is known, Validate checks them
+	// against its output limit (see OutputTokenLimit).
 	MaxThinkingTokens int
+	MaxOutputTokens   int
 	MaxTurns          int
+	// Temperature and TopP set the model's sampling, and Seed its random
+	// seed, for evaluation runs that want less variance. The CLI has no
+	// setting for any of them, so the model keeps its defaults: Validate
+	// checks their ranges and SamplingWarnings, which the client reports
+	// to Stderr when it starts, says they are ignored.
+	Temperature *float64
+	TopP        *float64
+	Seed        *int64
+	// AutoContinue summarizes and restarts the session when a turn gets
+	// close to MaxTurns, so long agentic tasks are not cut off.
+	AutoContinue AutoContinue
 
 	// Budget and output constraints
 	// MaxBudgetUsd enforces a maximum spending limit in USD for API calls during the query session.
 	// Precision is maintained to two decimal places (penny precision). A value of 0 or omission
 	// means no budget enforcement.
 	MaxBudgetUsd float64 `json:"maxBudgetUsd,omitempty"`
+	// MaxToolUses, if set, bounds how many times tools may be called in
+	// the session, overall and per tool, denying calls over the limits
+	// with a PreToolUse hook. Nil means no limit.
+	MaxToolUses *ToolUseLimits
 
 	// OutputFormat specifies the desired output format for structured outputs.
 	// When set, the model's responses will conform to the specified JSON schema format.
@@ -61,30 +99,236 @@ type Options struct {
 
 	// Plugins configures SDK plugins for extending functionality.
 	// Plugins provide custom commands, agents, skills, and hooks that extend Claude Code's capabilities.
-	// Currently only local plugins are supported via the 'local' type.
+	// Local plugins are used in place; git and registry plugins are fetched
+	// into PluginCacheDir before the CLI starts.
 	Plugins []SdkPluginConfig `json:"plugins,omitempty"`
 
+	// PluginCacheDir is where git and registry plugins are checked out.
+	// Defaults to claude-agent-sdk/plugins in the user cache directory.
+	PluginCacheDir string `json:"pluginCacheDir,omitempty"`
+
+	// Skills are SKILL.md-based skill directories to make available to
+	// Claude, in addition to those the CLI discovers through
+	// SettingSources. The SDK passes them to the CLI as a generated plugin,
+	// so the CLI names them "sdk-skills:<name>". Claude invokes skills with
+	// the Skill tool, which must be allowed.
+	Skills []SkillConfig `json:"skills,omitempty"`
+
 	// MCP servers
 	McpServers      map[string]McpServerConfig
 	StrictMcpConfig bool
+	// McpSupervision, if set, makes the SDK run the stdio servers in
+	// McpServers and restart them when they exit.
+	McpSupervision *McpSupervision
 
 	// Hooks and callbacks
 	Hooks  map[HookEvent][]HookCallbackMatcher
 	Stderr func(string)
+	// CallbackWorkers, if positive, bounds how many hook, permission and
+	// SDK MCP server callbacks run at once, and runs those of one hook
+	// event, of permission checks, or of one MCP server in the order the
+	// CLI requested them. Callbacks waiting for a worker are queued
+	// without holding up messages. An async hook awaiting
+	// CompleteAsyncHook keeps its worker and its event's queue. Zero runs
+	// every callback as soon as it is requested, concurrently and in no
+	// particular order.
+	CallbackWorkers int
+	// WebPolicy restricts the domains WebFetch and WebSearch may reach. It
+	// is enforced with SDK hooks registered alongside Hooks.
+	WebPolicy *WebPolicy
+	// ToolOverrides serves calls to the named tools with SDK-side handlers
+	// instead of running them, for developing offline against expensive or
+	// external tools. Calls are intercepted with a PreToolUse hook that
+	// denies them, giving the handler's result as the reason: the model
+	// sees it as the result of a denied call, while the messages delivered
+	// to the application carry it as a regular tool result.
+	ToolOverrides map[string]ToolHandler
+	// OnToolStart and OnToolEnd, if set, are called as each tool call
+	// starts and when its result arrives, including for calls that fail
+	// or are denied. They observe calls without affecting them and are
+	// registered as SDK hooks alongside Hooks. OnToolStart may be called
+	// from several goroutines at once.
+	OnToolStart func(ToolStart)
+	OnToolEnd   func(ToolEnd)
+	// EgressPolicy restricts the hosts the CLI process may connect to,
+	// through a filtering proxy the SDK runs for the session.
+	EgressPolicy *EgressPolicy
+
+	// SessionStore holds what the SDK keeps beside the CLI's transcripts:
+	// the results of queries sent with an idempotency key (see
+	// QueryWithOptions), annotations (see ClaudeSDKClient.Annotate) and the
+	// memory of AgentMemory. Nil uses an in-process store per client.
+	SessionStore SessionStore
+
+	// PromptLimit rejects or warns about prompts whose estimated size is
+	// too large a share of the model's context window.
+	PromptLimit *PromptLimit
 
 	// Message handling
 	IncludePartialMessages bool
+	// SuppressFinalDuplicate removes from the assistant messages
+	// ClaudeSDKClient delivers the text and thinking blocks it already
+	// delivered as partial message deltas, so consumers rendering both
+	// show each once. Tool use and other blocks are kept. Only the
+	// client's receive channels are affected; its statistics and the Query
+	// still see the full messages.
+	SuppressFinalDuplicate bool
+	// StreamEventFilter, if set, delivers only the partial message events
+	// it selects. Setting it enables partial messages.
+	StreamEventFilter *StreamEventFilter
+	// StreamResume, if set, resumes the session when the stream drops
+	// mid-turn, continuing ClaudeSDKClient.ReceiveResponse with a new
+	// response instead of ending it.
+	StreamResume *StreamResume
+	// MessageOrdering, if set, checks that messages arrive in protocol
+	// order and handles those that don't.
+	MessageOrdering *MessageOrdering
+	// MaxToolResultBytes, if positive, truncates tool results larger than
+	// this many bytes, appending a marker with the original size. Results
+	// delivered to the application are truncated for every tool; MCP tool
+	// output is also truncated before the model sees it, via a PostToolUse
+	// hook. Built-in tool output cannot be replaced by hooks, so it reaches
+	// the model in full.
+	MaxToolResultBytes int
+	// SpillToolResults saves the full text of truncated tool results to a
+	// temporary file and names its path in the truncation marker, so the
+	// model can read the rest on demand. The files are not removed.
+	SpillToolResults bool
+	// ToolResultPaging stores MCP tool results too large for the context
+	// and gives the model their first page, with a tool to read the rest.
+	ToolResultPaging *ToolResultPaging
+	// DecompressToolResults expands gzip-compressed MCP tool output, so
+	// servers can return multi-megabyte results compactly: resources with
+	// a base64 blob and MIME type application/gzip are decompressed, via a
+	// PostToolUse hook, and the model sees the output's text instead, paged
+	// or truncated as configured. Decompressed results are bounded by
+	// MaxMessageSize.
+	DecompressToolResults bool
+	// AgentMemory gives the model durable memory kept in SessionStore,
+	// which it requires: tools to store values by key and to append and
+	// read notes, served by the built-in MemoryServerName SDK MCP server.
+	// Sessions sharing a store share their memory.
+	AgentMemory bool
+	// MaxMessageSize bounds the size in bytes of a single message read from
+	// the CLI. Zero uses the transport default (10 MiB); a negative value
+	// disables the limit. Oversized messages fail with ErrCodeMessageTooLarge.
+	MaxMessageSize int
+	// MaxOutboundMessageSize bounds the encoded size in bytes of a single
+	// user message or control request sent to the CLI. Zero uses 10 MiB; a
+	// negative value disables the limit. Oversized messages are rejected
+	// before they are written, with a ValidationError using
+	// ErrCodeMessageTooLarge.
+	MaxOutboundMessageSize int
+	// Codec frames messages exchanged with the CLI. Nil uses NDJSONCodec.
+	// MaxMessageSize applies to decoded messages.
+	Codec Codec
+	// Cache stores results of QueryResult and QueryText, keyed by the
+	// prompt and options, for CacheTTL (zero means no expiry).
+	Cache    Cache
+	CacheTTL time.Duration
+	// Checkpointer saves the progress of QueryResult and QueryText after
+	// each assistant message, so a rerun after a crash resumes the session
+	// instead of starting over.
+	Checkpointer Checkpointer
+	// EnableFileCheckpointing makes the CLI checkpoint the files it
+	// changes before each user message, so ClaudeSDKClient.RewindFiles
+	// can undo the agent's edits since one. See FileCheckpoints.
+	EnableFileCheckpointing bool
+	// FaultInjection, for resilience tests, drops, delays and corrupts
+	// messages from the CLI or kills it mid-stream.
+	FaultInjection *FaultInjection
+	// NewUUID and Now, if set, replace uuid.New and time.Now for the
+	// session ID, message UUIDs, request IDs and timestamps the SDK
+	// generates, so recorded fixtures and TeeJSONL output of outgoing
+	// messages are stable across runs. See SequentialUUIDs. Both must be
+	// safe for concurrent use.
+	NewUUID func() UUID
+	Now     func() time.Time
+	// ProcessLimits constrains the memory, CPU weight and priority of the
+	// CLI process.
+	ProcessLimits *ProcessLimits
+	// RunAs runs the CLI as another user, on Linux.
+	RunAs *ProcessUser
+	// Jail confines the CLI's filesystem to its working directory and the
+	// paths it needs, on Linux. It requires RunAs.
+	Jail *ProcessJail
+	// ProfileTransport traces the size and handling time of every frame
+	// exchanged with the CLI, through a callback or pprof labels.
+	ProfileTransport *TransportProfile
+	// Watchdog fails a session whose internal goroutines are stuck, with
+	// diagnostics, instead of letting it hang. See ClaudeSDKClient.Health.
+	Watchdog *Watchdog
+	// ToolTiming times ClaudeSDKClient's tool calls with PreToolUse and
+	// PostToolUse hooks, for the latencies of ToolStats and WithMetrics.
+	// Each tool call then waits for the SDK to answer both hooks.
+	ToolTiming bool
+	// TelemetryExport writes the client's SessionReport and ToolStats to
+	// CSV or Parquet files on Close and, optionally, on an interval.
+	TelemetryExport *TelemetryExport
+	// Redaction configures the scrubbing of secrets from errors and CLI
+	// stderr lines, which is on by default. Nil redacts the built-in
+	// secrets only.
+	Redaction *Redaction
 
 	// SDK-specific
 	PathToClaudeCodeExecutable string
+	// Daemon, if set, runs the CLI through the claude-daemon listening on
+	// this Unix socket path or Windows named pipe (\\.\pipe\name)
+	// instead of spawning it, so short-lived programs skip the CLI's
+	// startup. The daemon chooses the executable and keeps its stderr, and
+	// refuses sessions needing plugins, stdio MCP servers or Env variables
+	// it doesn't allow; ProcessLimits, RunAs and Jail can't be combined
+	// with it.
+	Daemon string
+	// Multiplexer, if set, runs the CLI as a session of this shared
+	// claude-daemon connection instead, with the same restrictions as
+	// Daemon, which it replaces.
+	Multiplexer *Multiplexer
 
 	// Settings sources
 	SettingSources []ConfigScope // validated scopes: local, user, project
 
+	// PreserveThinking makes Resume check that the session's transcript
+	// still carries the signatures of its thinking blocks, failing with a
+	// ValidationError before the CLI starts if one was stripped, e.g. by a
+	// tool that rewrote the transcript. Without them the API rejects the
+	// continued extended-thinking conversation partway through a turn.
+	PreserveThinking bool
+
+	// OnResumeConflict decides whether Resume continues a session whose
+	// transcript conflicts with these options, e.g. after removing a tool
+	// it used (see CheckResume). Nil fails blocking conflicts with a
+	// ClientError using ErrCodeResumeConflict, from which ResumeConflicts
+	// recovers them, and reports the others to Stderr.
+	OnResumeConflict ResumeConflictFunc
+
 	// Agents
 	Agents map[string]AgentDefinition
 }
 
+// AutoContinue configures automatic continuation near MaxTurns.
+//
+// When a result reports NumTurns within TurnMargin of MaxTurns (or the turn
+// ended with error_max_turns), the client sends SummaryPrompt, starts a new
+// session seeded with the summary, and keeps streaming from it within the
+// same ReceiveResponse call. Messages of the summarization turn and the
+// intermediate result are not forwarded.
+type AutoContinue struct {
+	Enabled bool
+	// SummaryPrompt asks Claude to summarize progress. Empty uses a
+	// default prompt.
+	SummaryPrompt string
+	// TurnMargin is how many turns before MaxTurns to continue. Zero means
+	// one turn.
+	TurnMargin int
+	// MaxContinuations bounds how many times a response is continued. Zero
+	// means three.
+	MaxContinuations int
+	// OnContinue, if set, is called with each summary before the new
+	// session starts.
+	OnContinue func(summary string)
+}
+
 // AgentDefinition defines a custom agent.
 //
 // Tools and DisallowedTools control which tools the agent can use:
@@ -100,6 +344,11 @@ type AgentDefinition struct {
 	Tools           []string `json:"tools,omitempty"`
 	DisallowedTools []string `json:"disallowedTools,omitempty"`
 	Model           string   `json:"model,omitempty"`
+	// PermissionMode narrows the session's permission mode for this agent.
+	// It is enforced by the SDK for permission requests made by the agent
+	// and its nested subagents; modes broader than the session's are
+	// ignored.
+	PermissionMode PermissionMode `json:"permissionMode,omitempty"`
 }
 
 // ModelInfo represents model information.
@@ -114,4 +363,7 @@ type SlashCommand struct {
 	Name         string `json:"name"`
 	Description  string `json:"description"`
 	ArgumentHint string `json:"argumentHint"`
+	// Arguments are parsed from ArgumentHint when the command is decoded;
+	// see ParseArgumentHint.
+	Arguments []CommandArgument `json:"-"`
 }
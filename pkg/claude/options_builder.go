@@ -0,0 +1,906 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// OptionsBuilder assembles Options fluently and validates them on Build.
+//
+// Example:
+//
+//	opts, err := claude.NewOptions().
+//		WithModel("claude-sonnet-4-5").
+//		WithTools("Read", "Grep").
+//		WithPermissionMode(claude.PermissionModePlan).
+//		Build()
+type OptionsBuilder struct {
+	opts  Options
+	modes []PermissionMode
+}
+
+// NewOptions creates an empty OptionsBuilder.
+func NewOptions() *OptionsBuilder {
+	return &OptionsBuilder{}
+}
+
+// WithContext sets the context used to cancel the session.
+func (b *OptionsBuilder) WithContext(ctx context.Context) *OptionsBuilder {
+	b.opts.Context = ctx
+
+	return b
+}
+
+// WithModel sets the model.
+func (b *OptionsBuilder) WithModel(model string) *OptionsBuilder {
+	b.opts.Model = model
+
+	return b
+}
+
+// WithFallbackModel sets the model used when the primary is unavailable.
+func (b *OptionsBuilder) WithFallbackModel(model string) *OptionsBuilder {
+	b.opts.FallbackModel = model
+
+	return b
+}
+
+// WithTools appends to the allowed tool list.
+func (b *OptionsBuilder) WithTools(tools ...string) *OptionsBuilder {
+	b.opts.AllowedTools = append(b.opts.AllowedTools, tools...)
+
+	return b
+}
+
+// WithDisallowedTools appends to the disallowed tool list.
+func (b *OptionsBuilder) WithDisallowedTools(tools ...string) *OptionsBuilder {
+	b.opts.DisallowedTools = append(b.opts.DisallowedTools, tools...)
+
+	return b
+}
+
+// WithCwd sets the working directory.
+func (b *OptionsBuilder) WithCwd(cwd string) *OptionsBuilder {
+	b.opts.Cwd = cwd
+
+	return b
+}
+
+// WithAdditionalDirectories appends directories the CLI may access.
+func (b *OptionsBuilder) WithAdditionalDirectories(dirs ...string) *OptionsBuilder {
+	b.opts.AdditionalDirectories = append(b.opts.AdditionalDirectories, dirs...)
+
+	return b
+}
+
+// WithSystemPrompt sets the system prompt configuration.
+func (b *OptionsBuilder) WithSystemPrompt(prompt SystemPromptConfig) *OptionsBuilder {
+	b.opts.SystemPrompt = prompt
+
+	return b
+}
+
+// WithPermissionMode sets the permission mode. Requesting two different
+// modes is reported as a conflict by Build.
+func (b *OptionsBuilder) WithPermissionMode(mode PermissionMode) *OptionsBuilder {
+	b.opts.PermissionMode = mode
+	b.modes = append(b.modes, mode)
+
+	return b
+}
+
+// WithAskDelegate escalates permission prompts to delegate, waiting up to
+// timeout for a decision before applying fallback.
+func (b *OptionsBuilder) WithAskDelegate(
+	delegate AskDelegate,
+	timeout time.Duration,
+	fallback PermissionBehavior,
+) *OptionsBuilder {
+	b.opts.AskPolicy = &AskPolicy{Delegate: delegate, Timeout: timeout, Default: fallback}
+
+	return b
+}
+
+// WithCanUseTool sets the permission callback.
+func (b *OptionsBuilder) WithCanUseTool(fn CanUseToolFunc) *OptionsBuilder {
+	b.opts.CanUseTool = fn
+
+	return b
+}
+
+// WithPermissionPromptToolName sets the MCP tool used for permission prompts.
+func (b *OptionsBuilder) WithPermissionPromptToolName(name string) *OptionsBuilder {
+	b.opts.PermissionPromptToolName = name
+
+	return b
+}
+
+// WithAllowDangerouslySkipPermissions permits PermissionModeBypassPermissions.
+func (b *OptionsBuilder) WithAllowDangerouslySkipPermissions() *OptionsBuilder {
+	b.opts.AllowDangerouslySkipPermissions = true
+
+	return b
+}
+
+// WithContinue continues the most recent conversation.
+func (b *OptionsBuilder) WithContinue() *OptionsBuilder {
+	b.opts.Continue = true
+
+	return b
+}
+
+// WithResume resumes the given session.
+func (b *OptionsBuilder) WithResume(sessionID string) *OptionsBuilder {
+	b.opts.Resume = sessionID
+
+	return b
+}
+
+// WithResumeSessionAt resumes the session at the given message.
+func (b *OptionsBuilder) WithResumeSessionAt(messageID string) *OptionsBuilder {
+	b.opts.ResumeSessionAt = messageID
+
+	return b
+}
+
+// WithPreserveThinking checks a resumed session's thinking signatures
+// before starting the CLI.
+func (b *OptionsBuilder) WithPreserveThinking() *OptionsBuilder {
+	b.opts.PreserveThinking = true
+
+	return b
+}
+
+// WithOnResumeConflict decides whether to resume a session whose
+// transcript conflicts with the options.
+func (b *OptionsBuilder) WithOnResumeConflict(fn ResumeConflictFunc) *OptionsBuilder {
+	b.opts.OnResumeConflict = fn
+
+	return b
+}
+
+// WithForkSession forks the resumed session instead of appending to it.
+func (b *OptionsBuilder) WithForkSession() *OptionsBuilder {
+	b.opts.ForkSession = true
+
+	return b
+}
+
+// WithEnv sets an environment variable for the CLI process.
+func (b *OptionsBuilder) WithEnv(key, value string) *OptionsBuilder {
+	if b.opts.Env == nil {
+		b.opts.Env = make(map[string]string)
+	}
+	b.opts.Env[key] = value
+
+	return b
+}
+
+// WithMaxTurns limits the number of agent turns.
+func (b *OptionsBuilder) WithMaxTurns(turns int) *OptionsBuilder {
+	b.opts.MaxTurns = turns
+
+	return b
+}
+
+// WithAutoContinue enables automatic continuation near MaxTurns.
+func (b *OptionsBuilder) WithAutoContinue(ac AutoContinue) *OptionsBuilder {
+	ac.Enabled = true
+	b.opts.AutoContinue = ac
+
+	return b
+}
+
+// WithMaxThinkingTokens limits extended thinking tokens.
+func (b *OptionsBuilder) WithMaxThinkingTokens(tokens int) *OptionsBuilder {
+	b.opts.MaxThinkingTokens = tokens
+
+	return b
+}
+
+// WithMaxOutputTokens limits the output tokens of each model response.
+func (b *OptionsBuilder) WithMaxOutputTokens(tokens int) *OptionsBuilder {
+	b.opts.MaxOutputTokens = tokens
+
+	return b
+}
+
+// WithTemperature sets the sampling temperature, from 0 to 1. The CLI
+// ignores it; see Options.Temperature.
+func (b *OptionsBuilder) WithTemperature(temperature float64) *OptionsBuilder {
+	b.opts.Temperature = &temperature
+
+	return b
+}
+
+// WithTopP sets the nucleus sampling threshold, from 0 to 1. The CLI
+// ignores it; see Options.TopP.
+func (b *OptionsBuilder) WithTopP(topP float64) *OptionsBuilder {
+	b.opts.TopP = &topP
+
+	return b
+}
+
+// WithSeed sets the sampling seed. The CLI ignores it; see Options.Seed.
+func (b *OptionsBuilder) WithSeed(seed int64) *OptionsBuilder {
+	b.opts.Seed = &seed
+
+	return b
+}
+
+// WithMaxBudgetUsd limits spend for the session.
+func (b *OptionsBuilder) WithMaxBudgetUsd(usd float64) *OptionsBuilder {
+	b.opts.MaxBudgetUsd = usd
+
+	return b
+}
+
+// WithMaxToolUses limits how many times tools may be called in the
+// session.
+func (b *OptionsBuilder) WithMaxToolUses(limits ToolUseLimits) *OptionsBuilder {
+	b.opts.MaxToolUses = &limits
+
+	return b
+}
+
+// WithOutputFormat requests structured output.
+func (b *OptionsBuilder) WithOutputFormat(format *JsonSchemaOutputFormat) *OptionsBuilder {
+	b.opts.OutputFormat = format
+
+	return b
+}
+
+// WithMcpServer registers an MCP server under name.
+func (b *OptionsBuilder) WithMcpServer(name string, config McpServerConfig) *OptionsBuilder {
+	if b.opts.McpServers == nil {
+		b.opts.McpServers = make(map[string]McpServerConfig)
+	}
+	b.opts.McpServers[name] = config
+
+	return b
+}
+
+// WithMcpSupervision makes the SDK run stdio MCP servers and restart them
+// when they exit.
+func (b *OptionsBuilder) WithMcpSupervision(supervision McpSupervision) *OptionsBuilder {
+	b.opts.McpSupervision = &supervision
+
+	return b
+}
+
+// WithHook appends a hook matcher for event.
+func (b *OptionsBuilder) WithHook(event HookEvent, matcher HookCallbackMatcher) *OptionsBuilder {
+	if b.opts.Hooks == nil {
+		b.opts.Hooks = make(map[HookEvent][]HookCallbackMatcher)
+	}
+	b.opts.Hooks[event] = append(b.opts.Hooks[event], matcher)
+
+	return b
+}
+
+// WithAgent registers a custom agent under name.
+func (b *OptionsBuilder) WithAgent(name string, agent AgentDefinition) *OptionsBuilder {
+	if b.opts.Agents == nil {
+		b.opts.Agents = make(map[string]AgentDefinition)
+	}
+	b.opts.Agents[name] = agent
+
+	return b
+}
+
+// WithSettingSources sets which settings scopes the CLI loads.
+func (b *OptionsBuilder) WithSettingSources(scopes ...ConfigScope) *OptionsBuilder {
+	b.opts.SettingSources = append(b.opts.SettingSources, scopes...)
+
+	return b
+}
+
+// WithSkills adds skill directories to make available to Claude.
+func (b *OptionsBuilder) WithSkills(paths ...string) *OptionsBuilder {
+	for _, path := range paths {
+		b.opts.Skills = append(b.opts.Skills, SkillConfig{Path: path})
+	}
+
+	return b
+}
+
+// WithPlugins adds plugins to load, fetching git and registry plugins
+// into cacheDir (the default cache if empty).
+func (b *OptionsBuilder) WithPlugins(cacheDir string, plugins ...SdkPluginConfig) *OptionsBuilder {
+	b.opts.Plugins = append(b.opts.Plugins, plugins...)
+	if cacheDir != "" {
+		b.opts.PluginCacheDir = cacheDir
+	}
+
+	return b
+}
+
+// WithWebPolicy restricts the domains WebFetch and WebSearch may reach.
+func (b *OptionsBuilder) WithWebPolicy(policy WebPolicy) *OptionsBuilder {
+	b.opts.WebPolicy = &policy
+
+	return b
+}
+
+// WithEgressPolicy restricts the hosts the CLI process may connect to.
+func (b *OptionsBuilder) WithEgressPolicy(policy EgressPolicy) *OptionsBuilder {
+	b.opts.EgressPolicy = &policy
+
+	return b
+}
+
+// WithToolOverride serves calls to the named tool with handler instead of
+// running it.
+func (b *OptionsBuilder) WithToolOverride(name string, handler ToolHandler) *OptionsBuilder {
+	if b.opts.ToolOverrides == nil {
+		b.opts.ToolOverrides = make(map[string]ToolHandler)
+	}
+	b.opts.ToolOverrides[name] = handler
+
+	return b
+}
+
+// WithToolCallbacks sets the functions called as tool calls start and end;
+// either may be nil.
+func (b *OptionsBuilder) WithToolCallbacks(onStart func(ToolStart), onEnd func(ToolEnd)) *OptionsBuilder {
+	b.opts.OnToolStart = onStart
+	b.opts.OnToolEnd = onEnd
+
+	return b
+}
+
+// WithMaxToolResultBytes truncates tool results over limit bytes; with
+// spill set, full results are saved to temporary files.
+func (b *OptionsBuilder) WithMaxToolResultBytes(limit int, spill bool) *OptionsBuilder {
+	b.opts.MaxToolResultBytes = limit
+	b.opts.SpillToolResults = spill
+
+	return b
+}
+
+// WithToolResultDecompression expands gzip-compressed MCP tool output
+// before the model sees it.
+func (b *OptionsBuilder) WithToolResultDecompression() *OptionsBuilder {
+	b.opts.DecompressToolResults = true
+
+	return b
+}
+
+// WithCallbackWorkers runs hook and permission callbacks on a pool of
+// workers, in order per event.
+func (b *OptionsBuilder) WithCallbackWorkers(workers int) *OptionsBuilder {
+	b.opts.CallbackWorkers = workers
+
+	return b
+}
+
+// WithStderr sets the stderr line callback.
+func (b *OptionsBuilder) WithStderr(fn func(string)) *OptionsBuilder {
+	b.opts.Stderr = fn
+
+	return b
+}
+
+// WithIncludePartialMessages enables partial stream events.
+func (b *OptionsBuilder) WithIncludePartialMessages() *OptionsBuilder {
+	b.opts.IncludePartialMessages = true
+
+	return b
+}
+
+// WithSuppressFinalDuplicate delivers streamed text and thinking once,
+// removing it from the assistant message that follows the deltas.
+func (b *OptionsBuilder) WithSuppressFinalDuplicate() *OptionsBuilder {
+	b.opts.SuppressFinalDuplicate = true
+
+	return b
+}
+
+// WithStreamEventFilter enables partial stream events, delivering only
+// those the filter selects.
+func (b *OptionsBuilder) WithStreamEventFilter(filter StreamEventFilter) *OptionsBuilder {
+	b.opts.StreamEventFilter = &filter
+
+	return b
+}
+
+// WithPromptLimit rejects prompts estimated to use more than fraction of
+// the model's context window, or reports them to warn if it is non-nil.
+func (b *OptionsBuilder) WithPromptLimit(fraction float64, warn func(estimate, limit int)) *OptionsBuilder {
+	b.opts.PromptLimit = &PromptLimit{Fraction: fraction, Warn: warn}
+
+	return b
+}
+
+// WithMaxMessageSize bounds the size of a single message from the CLI.
+func (b *OptionsBuilder) WithMaxMessageSize(size int) *OptionsBuilder {
+	b.opts.MaxMessageSize = size
+
+	return b
+}
+
+// WithMaxOutboundMessageSize bounds the size of a single message sent to
+// the CLI.
+func (b *OptionsBuilder) WithMaxOutboundMessageSize(size int) *OptionsBuilder {
+	b.opts.MaxOutboundMessageSize = size
+
+	return b
+}
+
+// WithCredentialsProvider supplies credentials when each CLI process
+// starts.
+func (b *OptionsBuilder) WithCredentialsProvider(provider CredentialsProvider) *OptionsBuilder {
+	b.opts.CredentialsProvider = provider
+
+	return b
+}
+
+// WithProvider selects the API backend and its region ("" to use the
+// environment).
+func (b *OptionsBuilder) WithProvider(provider Provider, region string) *OptionsBuilder {
+	b.opts.Provider = provider
+	b.opts.ProviderRegion = region
+
+	return b
+}
+
+// WithCache caches one-shot query results in cache for ttl.
+func (b *OptionsBuilder) WithCache(cache Cache, ttl time.Duration) *OptionsBuilder {
+	b.opts.Cache = cache
+	b.opts.CacheTTL = ttl
+
+	return b
+}
+
+// WithCheckpointer saves the progress of one-shot queries to checkpointer.
+func (b *OptionsBuilder) WithCheckpointer(checkpointer Checkpointer) *OptionsBuilder {
+	b.opts.Checkpointer = checkpointer
+
+	return b
+}
+
+// WithFaultInjection injects faults into messages from the CLI, for
+// testing error handling.
+func (b *OptionsBuilder) WithFaultInjection(faults FaultInjection) *OptionsBuilder {
+	b.opts.FaultInjection = &faults
+
+	return b
+}
+
+// WithProcessLimits constrains the CLI process's resources.
+func (b *OptionsBuilder) WithProcessLimits(limits ProcessLimits) *OptionsBuilder {
+	b.opts.ProcessLimits = &limits
+
+	return b
+}
+
+// WithRunAs runs the CLI as another user.
+func (b *OptionsBuilder) WithRunAs(user ProcessUser) *OptionsBuilder {
+	b.opts.RunAs = &user
+
+	return b
+}
+
+// WithJail confines the CLI's filesystem.
+func (b *OptionsBuilder) WithJail(jail ProcessJail) *OptionsBuilder {
+	b.opts.Jail = &jail
+
+	return b
+}
+
+// WithProfileTransport traces every frame exchanged with the CLI.
+func (b *OptionsBuilder) WithProfileTransport(profile TransportProfile) *OptionsBuilder {
+	b.opts.ProfileTransport = &profile
+
+	return b
+}
+
+// WithMessageOrdering checks that messages arrive in protocol order.
+func (b *OptionsBuilder) WithMessageOrdering(ordering MessageOrdering) *OptionsBuilder {
+	b.opts.MessageOrdering = &ordering
+
+	return b
+}
+
+// WithToolResultPaging pages oversized MCP tool results, letting the
+// model read them a page at a time.
+func (b *OptionsBuilder) WithToolResultPaging(paging ToolResultPaging) *OptionsBuilder {
+	b.opts.ToolResultPaging = &paging
+
+	return b
+}
+
+// WithSessionStore keeps idempotent query results, annotations and agent
+// memory in store.
+func (b *OptionsBuilder) WithSessionStore(store SessionStore) *OptionsBuilder {
+	b.opts.SessionStore = store
+
+	return b
+}
+
+// WithAgentMemory gives the model durable memory kept in the session
+// store, shared with every session using the same store.
+func (b *OptionsBuilder) WithAgentMemory() *OptionsBuilder {
+	b.opts.AgentMemory = true
+
+	return b
+}
+
+// WithWatchdog fails sessions whose internal goroutines get stuck.
+func (b *OptionsBuilder) WithWatchdog(watchdog Watchdog) *OptionsBuilder {
+	b.opts.Watchdog = &watchdog
+
+	return b
+}
+
+// WithToolTiming times the client's tool calls with PreToolUse and
+// PostToolUse hooks.
+func (b *OptionsBuilder) WithToolTiming() *OptionsBuilder {
+	b.opts.ToolTiming = true
+
+	return b
+}
+
+// WithTelemetryExport writes the client's usage and tool statistics to
+// files for data warehouses.
+func (b *OptionsBuilder) WithTelemetryExport(export TelemetryExport) *OptionsBuilder {
+	b.opts.TelemetryExport = &export
+
+	return b
+}
+
+// WithStreamResume resumes the session when the stream drops mid-turn.
+func (b *OptionsBuilder) WithStreamResume(resume StreamResume) *OptionsBuilder {
+	b.opts.StreamResume = &resume
+
+	return b
+}
+
+// WithRedactionPatterns redacts the matches of the regular expressions
+// patterns from errors and CLI stderr, in addition to the built-in secrets.
+func (b *OptionsBuilder) WithRedactionPatterns(patterns ...string) *OptionsBuilder {
+	if b.opts.Redaction == nil {
+		b.opts.Redaction = &Redaction{}
+	}
+	b.opts.Redaction.Patterns = append(b.opts.Redaction.Patterns, patterns...)
+
+	return b
+}
+
+// WithCodec sets the codec framing messages exchanged with the CLI.
+func (b *OptionsBuilder) WithCodec(codec Codec) *OptionsBuilder {
+	b.opts.Codec = codec
+
+	return b
+}
+
+// WithExecutable sets the path to the Claude CLI.
+func (b *OptionsBuilder) WithExecutable(path string) *OptionsBuilder {
+	b.opts.PathToClaudeCodeExecutable = path
+
+	return b
+}
+
+// WithDaemon runs the CLI through the claude-daemon at address.
+func (b *OptionsBuilder) WithDaemon(address string) *OptionsBuilder {
+	b.opts.Daemon = address
+
+	return b
+}
+
+// WithMultiplexer runs the CLI as a session of mux.
+func (b *OptionsBuilder) WithMultiplexer(mux *Multiplexer) *OptionsBuilder {
+	b.opts.Multiplexer = mux
+
+	return b
+}
+
+// Build validates the accumulated options and returns them.
+//
+// All problems are reported together; each is a *clauderrs.ValidationError
+// and can be extracted with errors.As.
+func (b *OptionsBuilder) Build() (*Options, error) {
+	var errs []error
+
+	for _, mode := range b.modes {
+		if mode != b.modes[0] {
+			errs = append(errs, conflictError(
+				"PermissionMode",
+				fmt.Sprintf(
+					"conflicting permission modes %q and %q requested",
+					b.modes[0],
+					mode,
+				),
+				mode,
+			))
+
+			break
+		}
+	}
+
+	if err := b.opts.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	opts := b.opts
+
+	return &opts, nil
+}
+
+// Validate checks Options for invalid values and conflicting fields without
+// starting a process. It returns nil or an error joining one
+// *clauderrs.ValidationError per problem.
+func (o *Options) Validate() error {
+	var errs []error
+
+	if o.PermissionMode == PermissionModeBypassPermissions {
+		if o.CanUseTool != nil {
+			errs = append(errs, conflictError(
+				"CanUseTool",
+				"CanUseTool is never invoked in bypassPermissions mode",
+				o.PermissionMode,
+			))
+		}
+		if !o.AllowDangerouslySkipPermissions {
+			errs = append(errs, clauderrs.NewValidationError(
+				clauderrs.ErrCodeMissingField,
+				"bypassPermissions mode requires AllowDangerouslySkipPermissions",
+				nil,
+				"AllowDangerouslySkipPermissions",
+				false,
+			))
+		}
+	}
+
+	if o.AskPolicy != nil {
+		if err := o.AskPolicy.validate(); err != nil {
+			errs = append(errs, err)
+		}
+		if o.PermissionMode == PermissionModeBypassPermissions {
+			errs = append(errs, conflictError(
+				"AskPolicy",
+				"AskPolicy is never consulted in bypassPermissions mode",
+				o.PermissionMode,
+			))
+		}
+		if o.PermissionPromptToolName != "" {
+			errs = append(errs, conflictError(
+				"PermissionPromptToolName",
+				"AskPolicy cannot be combined with PermissionPromptToolName",
+				o.PermissionPromptToolName,
+			))
+		}
+	}
+
+	if o.FaultInjection != nil {
+		if err := o.FaultInjection.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if o.EgressPolicy != nil {
+		errs = append(errs, o.EgressPolicy.validate()...)
+	}
+
+	if o.ProcessLimits != nil {
+		errs = append(errs, o.ProcessLimits.validate()...)
+	}
+	if o.Jail != nil {
+		errs = append(errs, o.Jail.validate()...)
+		if o.RunAs == nil || o.RunAs.UID == 0 {
+			errs = append(errs, conflictError(
+				"RunAs",
+				"Jail requires RunAs with a user other than root, which could escape the jail",
+				o.RunAs,
+			))
+		}
+	}
+	if err := o.checkAgentMemory(); err != nil {
+		errs = append(errs, err)
+	}
+	if o.Daemon != "" && (o.ProcessLimits != nil || o.RunAs != nil || o.Jail != nil) {
+		errs = append(errs, conflictError(
+			"Daemon",
+			"ProcessLimits, RunAs and Jail cannot be combined with Daemon",
+			o.Daemon,
+		))
+	}
+	if o.Multiplexer != nil {
+		if o.Daemon != "" {
+			errs = append(errs, conflictError("Multiplexer", "Multiplexer cannot be combined with Daemon", o.Daemon))
+		}
+		if o.ProcessLimits != nil || o.RunAs != nil || o.Jail != nil {
+			errs = append(errs, conflictError(
+				"Multiplexer",
+				"ProcessLimits, RunAs and Jail cannot be combined with Multiplexer",
+				nil,
+			))
+		}
+	}
+	if o.Watchdog != nil {
+		errs = append(errs, o.Watchdog.validate()...)
+	}
+	if o.ToolResultPaging != nil {
+		errs = append(errs, o.ToolResultPaging.validate()...)
+	}
+	if o.TelemetryExport != nil {
+		errs = append(errs, o.TelemetryExport.validate()...)
+	}
+	if o.Redaction != nil {
+		errs = append(errs, o.Redaction.validate()...)
+	}
+	if o.MaxToolUses != nil {
+		errs = append(errs, o.MaxToolUses.validate()...)
+	}
+	if o.CallbackWorkers < 0 {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"CallbackWorkers must not be negative",
+			nil,
+			"CallbackWorkers",
+			o.CallbackWorkers,
+		))
+	}
+
+	if o.McpSupervision != nil {
+		if err := o.McpSupervision.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if o.CanUseTool != nil && o.PermissionPromptToolName != "" {
+		errs = append(errs, conflictError(
+			"PermissionPromptToolName",
+			"CanUseTool cannot be combined with PermissionPromptToolName",
+			o.PermissionPromptToolName,
+		))
+	}
+
+	errs = append(errs, o.validateProvider()...)
+
+	if o.Continue && o.Resume != "" {
+		errs = append(errs, conflictError(
+			"Resume",
+			"Continue and Resume are mutually exclusive",
+			o.Resume,
+		))
+	}
+
+	if o.Resume == "" {
+		if o.ForkSession {
+			errs = append(errs, missingResumeError("ForkSession", o.ForkSession))
+		}
+		if o.ResumeSessionAt != "" {
+			errs = append(errs, missingResumeError("ResumeSessionAt", o.ResumeSessionAt))
+		}
+	}
+
+	if o.FallbackModel != "" && o.FallbackModel == o.Model {
+		errs = append(errs, conflictError(
+			"FallbackModel",
+			"FallbackModel must differ from Model",
+			o.FallbackModel,
+		))
+	}
+
+	errs = append(errs, o.validateTools()...)
+	errs = append(errs, o.validateToolOverrides()...)
+	errs = append(errs, o.validateLimits()...)
+	errs = append(errs, o.validateTokenCaps()...)
+	errs = append(errs, o.validateSampling()...)
+
+	return errors.Join(errs...)
+}
+
+// validateTools reports tools that are both allowed and disallowed.
+func (o *Options) validateTools() []error {
+	disallowed := make(map[string]bool, len(o.DisallowedTools))
+	for _, tool := range o.DisallowedTools {
+		disallowed[tool] = true
+	}
+
+	var errs []error
+	for _, tool := range o.AllowedTools {
+		if disallowed[tool] {
+			errs = append(errs, conflictError(
+				"AllowedTools",
+				fmt.Sprintf("tool %q is both allowed and disallowed", tool),
+				tool,
+			))
+		}
+	}
+
+	return errs
+}
+
+// validateLimits reports negative numeric limits.
+func (o *Options) validateLimits() []error {
+	var errs []error
+
+	limits := []struct {
+		field string
+		value float64
+	}{
+		{"MaxTurns", float64(o.MaxTurns)},
+		{"MaxThinkingTokens", float64(o.MaxThinkingTokens)},
+		{"MaxOutputTokens", float64(o.MaxOutputTokens)},
+		{"MaxBudgetUsd", o.MaxBudgetUsd},
+	}
+	for _, limit := range limits {
+		if limit.value < 0 {
+			errs = append(errs, clauderrs.NewValidationError(
+				clauderrs.ErrCodeRangeViolation,
+				limit.field+" must not be negative",
+				nil,
+				limit.field,
+				limit.value,
+			))
+		}
+	}
+
+	return errs
+}
+
+// validateTokenCaps checks MaxOutputTokens and MaxThinkingTokens against
+// each other and the model's output limit. Thinking counts toward output,
+// so its budget must be below the output cap.
+func (o *Options) validateTokenCaps() []error {
+	var errs []error
+
+	limit := OutputTokenLimit(o.Model)
+	if limit > 0 && o.MaxOutputTokens > limit {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			fmt.Sprintf("MaxOutputTokens exceeds the %d-token output limit of %s", limit, o.Model),
+			nil,
+			"MaxOutputTokens",
+			o.MaxOutputTokens,
+		))
+	}
+
+	outputCap := o.MaxOutputTokens
+	if outputCap <= 0 || (limit > 0 && outputCap > limit) {
+		outputCap = limit
+	}
+	if outputCap > 0 && o.MaxThinkingTokens >= outputCap {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			fmt.Sprintf("MaxThinkingTokens must be below the %d-token output cap, which includes thinking", outputCap),
+			nil,
+			"MaxThinkingTokens",
+			o.MaxThinkingTokens,
+		))
+	}
+
+	return errs
+}
+
+// conflictError builds a ValidationError for mutually exclusive options.
+func conflictError(field, message string, value any) error {
+	return clauderrs.NewValidationError(
+		clauderrs.ErrCodeConflictingOptions,
+		message,
+		nil,
+		field,
+		value,
+	)
+}
+
+// missingResumeError reports a resume-only option set without Resume.
+func missingResumeError(field string, value any) error {
+	return clauderrs.NewValidationError(
+		clauderrs.ErrCodeMissingField,
+		field+" requires Resume",
+		nil,
+		"Resume",
+		value,
+	)
+}
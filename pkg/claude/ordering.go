@@ -0,0 +1,278 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// orderViolationKey is the error metadata key holding the OrderViolation
+// that failed a session.
+const orderViolationKey = "order_violation"
+
+// OrderViolationKind classifies an OrderViolation.
+type OrderViolationKind string
+
+const (
+	// OrderViolationMissing means a message the protocol requires before
+	// this one never arrived, e.g. a content block index was skipped or a
+	// delta came for a block that was never started.
+	OrderViolationMissing OrderViolationKind = "missing"
+	// OrderViolationOutOfOrder means the message arrived after one that
+	// should have followed it, e.g. a content block index went backwards
+	// or a delta came for a block already stopped.
+	OrderViolationOutOfOrder OrderViolationKind = "out_of_order"
+)
+
+// OrderViolation describes a message that broke the protocol's ordering.
+type OrderViolation struct {
+	Kind OrderViolationKind
+	// MessageType is the type of the offending message; for partial
+	// messages it is the stream event type, e.g. "content_block_delta".
+	MessageType string
+	// Expected and Got are the sequence positions involved, such as the
+	// next content block index and the one received, or a tool_use ID.
+	Expected string
+	Got      string
+	// Message describes the violation.
+	Message string
+}
+
+// String returns the violation's message.
+func (v OrderViolation) String() string {
+	return v.Message
+}
+
+// OrderingAction is what to do with a message that broke the ordering.
+type OrderingAction int
+
+const (
+	// OrderingFail fails the session with ErrCodeProtocolOutOfOrder.
+	OrderingFail OrderingAction = iota
+	// OrderingDrop discards the message and carries on.
+	OrderingDrop
+	// OrderingDeliver delivers the message anyway and carries on as if it
+	// were in order.
+	OrderingDeliver
+)
+
+// MessageOrdering validates the order of messages read from the CLI. Partial
+// message events must follow message_start, content_block_start,
+// content_block_delta, content_block_stop, message_stop, with content block
+// indexes counting up from zero, separately for the main conversation and
+// each subagent. A tool result must follow the tool use it answers.
+// Violations would otherwise show as text attached to the wrong block or
+// results without a call.
+type MessageOrdering struct {
+	// OnViolation picks the action for each violation. Nil fails the
+	// session on the first one.
+	OnViolation func(OrderViolation) OrderingAction
+}
+
+// OrderViolationOf returns the violation that failed a session with
+// ErrCodeProtocolOutOfOrder.
+func OrderViolationOf(err error) (OrderViolation, bool) {
+	sdkErr, ok := clauderrs.AsSDKError(err)
+	if !ok || sdkErr.Code() != clauderrs.ErrCodeProtocolOutOfOrder {
+		return OrderViolation{}, false
+	}
+	violation, ok := sdkErr.Metadata()[orderViolationKey].(OrderViolation)
+
+	return violation, ok
+}
+
+// messageSequence is the position of one stream of partial messages.
+type messageSequence struct {
+	started   bool
+	nextIndex int
+	open      map[int]bool
+}
+
+// orderingChecker tracks the sequence of messages read from the CLI. It is
+// used only by the reader goroutine.
+type orderingChecker struct {
+	opts *MessageOrdering
+	// checkResults is false for resumed sessions, whose tool results may
+	// answer tool uses sent before this process started.
+	checkResults bool
+	streams      map[string]*messageSequence // By parent_tool_use_id
+	toolUses     map[string]bool
+}
+
+// newOrderingChecker returns nil if opts.MessageOrdering is nil.
+func newOrderingChecker(opts *Options) *orderingChecker {
+	if opts.MessageOrdering == nil {
+		return nil
+	}
+
+	return &orderingChecker{
+		opts:         opts.MessageOrdering,
+		checkResults: opts.Resume == "" && !opts.Continue,
+		streams:      make(map[string]*messageSequence),
+		toolUses:     make(map[string]bool),
+	}
+}
+
+// orderingFrame is the part of a frame the checker reads.
+type orderingFrame struct {
+	ParentToolUseID string `json:"parent_tool_use_id"`
+	Event           struct {
+		Type         string `json:"type"`
+		Index        int    `json:"index"`
+		ContentBlock struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		} `json:"content_block"`
+	} `json:"event"`
+	Message struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// check validates the frame data of type typ, reporting whether to deliver
+// it, or the error failing the session.
+func (c *orderingChecker) check(typ string, data []byte) (bool, *clauderrs.ProtocolError) {
+	var frame orderingFrame
+	if json.Unmarshal(data, &frame) != nil {
+		// Let the full decode report the malformed frame
+		return true, nil
+	}
+
+	var violation *OrderViolation
+	switch typ {
+	case "stream_event":
+		violation = c.checkEvent(&frame)
+	case "assistant":
+		for _, block := range orderingBlocks(frame.Message.Content) {
+			if block.Type == "tool_use" {
+				c.toolUses[block.ID] = true
+			}
+		}
+	case "user":
+		violation = c.checkResult(&frame)
+	}
+	if violation == nil {
+		return true, nil
+	}
+
+	action := OrderingFail
+	if c.opts.OnViolation != nil {
+		action = c.opts.OnViolation(*violation)
+	}
+	switch action {
+	case OrderingDrop:
+		return false, nil
+	case OrderingDeliver:
+		return true, nil
+	}
+
+	err := clauderrs.NewProtocolError(
+		clauderrs.ErrCodeProtocolOutOfOrder,
+		violation.Message,
+		nil,
+	).
+		WithMessageType(violation.MessageType)
+	_ = err.WithMetadata(orderViolationKey, *violation)
+
+	return false, err
+}
+
+// checkEvent advances the event's stream, returning the violation it
+// causes, if any. The stream moves on even then, so a delivered event
+// doesn't cause further violations.
+func (c *orderingChecker) checkEvent(frame *orderingFrame) *OrderViolation {
+	seq := c.streams[frame.ParentToolUseID]
+	if seq == nil {
+		seq = &messageSequence{}
+		c.streams[frame.ParentToolUseID] = seq
+	}
+	event := frame.Event
+	index := event.Index
+
+	var violation *OrderViolation
+	switch event.Type {
+	case "message_start":
+		if seq.started {
+			violation = orderViolation(OrderViolationMissing, event.Type, "message_stop", event.Type,
+				"message_start before the previous message's message_stop")
+		}
+		*seq = messageSequence{started: true, open: make(map[int]bool)}
+
+		return violation
+	case ContentBlockStart:
+		if event.ContentBlock.Type == "tool_use" {
+			c.toolUses[event.ContentBlock.ID] = true
+		}
+	}
+
+	if !seq.started {
+		violation = orderViolation(OrderViolationMissing, event.Type, "message_start", event.Type,
+			event.Type+" before message_start")
+		*seq = messageSequence{started: true, open: make(map[int]bool)}
+	}
+
+	switch event.Type {
+	case ContentBlockStart:
+		if violation == nil && index != seq.nextIndex {
+			kind := OrderViolationMissing
+			if index < seq.nextIndex {
+				kind = OrderViolationOutOfOrder
+			}
+			violation = orderViolation(kind, event.Type, fmt.Sprint(seq.nextIndex), fmt.Sprint(index),
+				fmt.Sprintf("content block %d started when %d was expected", index, seq.nextIndex))
+		}
+		seq.open[index] = true
+		seq.nextIndex = max(seq.nextIndex, index+1)
+	case ContentBlockDelta, "content_block_stop":
+		if violation == nil && !seq.open[index] {
+			kind := OrderViolationOutOfOrder
+			message := fmt.Sprintf("%s for content block %d after it stopped", event.Type, index)
+			if index >= seq.nextIndex {
+				kind = OrderViolationMissing
+				message = fmt.Sprintf("%s for content block %d before it started", event.Type, index)
+			}
+			violation = orderViolation(kind, event.Type, "", fmt.Sprint(index), message)
+		}
+		if event.Type == "content_block_stop" {
+			delete(seq.open, index)
+		}
+	case "message_stop":
+		if violation == nil && len(seq.open) > 0 {
+			violation = orderViolation(OrderViolationMissing, event.Type, "content_block_stop", event.Type,
+				fmt.Sprintf("message_stop with %d content blocks not stopped", len(seq.open)))
+		}
+		seq.started = false
+	}
+
+	return violation
+}
+
+// checkResult returns the violation of a user message answering a tool use
+// not yet seen, if any.
+func (c *orderingChecker) checkResult(frame *orderingFrame) *OrderViolation {
+	if !c.checkResults {
+		return nil
+	}
+	for _, block := range orderingBlocks(frame.Message.Content) {
+		if block.Type == MessageTypeToolResult && !c.toolUses[block.ToolUseID] {
+			return orderViolation(OrderViolationMissing, MessageTypeToolResult, "tool_use", block.ToolUseID,
+				fmt.Sprintf("tool_result for %s before its tool_use", block.ToolUseID))
+		}
+	}
+
+	return nil
+}
+
+// orderingBlocks decodes the content blocks of a message; string content
+// has none.
+func orderingBlocks(content json.RawMessage) []transcriptResumeBlock {
+	var blocks []transcriptResumeBlock
+	_ = json.Unmarshal(content, &blocks)
+
+	return blocks
+}
+
+func orderViolation(kind OrderViolationKind, typ, expected, got, message string) *OrderViolation {
+	return &OrderViolation{Kind: kind, MessageType: typ, Expected: expected, Got: got, Message: message}
+}
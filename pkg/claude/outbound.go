@@ -0,0 +1,131 @@
+package claude
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// permissionModes are the modes the CLI accepts in set_permission_mode.
+var permissionModes = []PermissionMode{
+	PermissionModeDefault,
+	PermissionModeAcceptEdits,
+	PermissionModeBypassPermissions,
+	PermissionModePlan,
+}
+
+// missingField returns a ValidationError for a required field left empty.
+func missingField(field string) error {
+	return clauderrs.NewValidationError(
+		clauderrs.ErrCodeMissingField,
+		field+" is required",
+		nil,
+		field,
+		nil,
+	)
+}
+
+// validateUserContent checks the content of an outgoing user message for
+// the fields the CLI requires. Errors name the offending field by its path
+// in the message, such as "message.content[1].source.data".
+func validateUserContent(content []ContentBlock) error {
+	if len(content) == 0 {
+		return missingField("message.content")
+	}
+
+	for i, block := range content {
+		path := fmt.Sprintf("message.content[%d]", i)
+		switch b := block.(type) {
+		case TextContentBlock:
+			if b.Text == "" {
+				return missingField(path + ".text")
+			}
+		case ImageContentBlock:
+			switch {
+			case b.Source.Type == "":
+				return missingField(path + ".source.type")
+			case b.Source.MediaType == "":
+				return missingField(path + ".source.media_type")
+			case b.Source.Data == "":
+				return missingField(path + ".source.data")
+			}
+		case ToolResultContentBlock:
+			if b.ToolUseID == "" {
+				return missingField(path + ".tool_use_id")
+			}
+		case nil:
+			return missingField(path)
+		default:
+			return clauderrs.NewValidationError(
+				clauderrs.ErrCodeInvalidType,
+				fmt.Sprintf("%T cannot be sent in a user message", block),
+				nil,
+				path,
+				block,
+			)
+		}
+	}
+
+	return nil
+}
+
+// validateControlRequest checks an outgoing control request for the fields
+// the CLI requires.
+func validateControlRequest(request ControlRequestVariant) error {
+	switch r := request.(type) {
+	case SDKControlSetPermissionModeRequest:
+		if r.Mode == "" {
+			return missingField("request.mode")
+		}
+		if !slices.Contains(permissionModes, PermissionMode(r.Mode)) {
+			return clauderrs.NewValidationError(
+				clauderrs.ErrCodeInvalidFormat,
+				fmt.Sprintf("unknown permission mode %q", r.Mode),
+				nil,
+				"request.mode",
+				r.Mode,
+			)
+		}
+	case SDKControlMcpMessageRequest:
+		if r.ServerName == "" {
+			return missingField("request.server_name")
+		}
+		if r.Message == nil {
+			return missingField("request.message")
+		}
+	case SDKControlAuthenticateRequest:
+		if r.Token == "" {
+			return missingField("request.token")
+		}
+	case SDKControlRewindCodeRequest:
+		if r.UserMessageID == "" {
+			return missingField("request.user_message_id")
+		}
+	case nil:
+		return missingField("request")
+	}
+
+	return nil
+}
+
+// checkOutboundSize rejects a frame larger than opts.MaxOutboundMessageSize.
+// field names the part of the frame that carries the payload.
+func checkOutboundSize(opts *Options, data []byte, field string) error {
+	limit := opts.MaxOutboundMessageSize
+	if limit == 0 {
+		limit = transport.DefaultMaxMessageSize
+	}
+	if limit < 0 || len(data) <= limit {
+		return nil
+	}
+
+	return clauderrs.NewValidationError(
+		clauderrs.ErrCodeMessageTooLarge,
+		fmt.Sprintf("%s is %d bytes encoded, over the limit of %d", field, len(data), limit),
+		nil,
+		field,
+		len(data),
+	)
+}
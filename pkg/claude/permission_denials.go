@@ -0,0 +1,57 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// denialRuleInputs are the inputs, in order of preference, whose value
+// scopes the rule SuggestedRule returns: the command, file or URL the
+// denied call acted on.
+var denialRuleInputs = []string{"command", "file_path", "notebook_path", "url"}
+
+// SuggestedRule returns a rule allowing the denied call: the tool scoped to
+// the call's command, file or URL, or the whole tool when the call had
+// none of these inputs.
+func (d SDKPermissionDenial) SuggestedRule() PermissionRuleValue {
+	rule := PermissionRuleValue{ToolName: d.ToolName}
+	if _, value, ok := ruleSubject(d.ToolInput); ok {
+		rule.RuleContent = &value
+	}
+
+	return rule
+}
+
+// AllowAndRetry allows the call denied in denial for the rest of the
+// session, adding its SuggestedRule as a session rule, and asks the model
+// to retry it in a new turn. Read the turn with ReceiveResponse.
+//
+// Session rules answer the CLI's permission prompts, so the client needs
+// CanUseTool or AskPolicy set for the CLI to route them to the SDK.
+func (c *ClaudeSDKClient) AllowAndRetry(ctx context.Context, denial SDKPermissionDenial) error {
+	if c.opts.CanUseTool == nil && c.opts.AskPolicy == nil {
+		return clauderrs.NewClientError(
+			clauderrs.ErrCodeInvalidState,
+			"AllowAndRetry needs CanUseTool or AskPolicy to receive permission prompts",
+			nil,
+		)
+	}
+
+	err := c.UpdatePermissions(ctx, []PermissionUpdate{AddRulesUpdate{
+		Type:        permissionUpdateAddRules,
+		Rules:       []PermissionRuleValue{denial.SuggestedRule()},
+		Behavior:    PermissionBehaviorAllow,
+		Destination: PermissionDestinationSession,
+	}})
+	if err != nil {
+		return err
+	}
+
+	return c.Query(ctx, fmt.Sprintf(
+		"The %s call that was denied (tool use %s) is now allowed. Please retry it and continue.",
+		denial.ToolName,
+		denial.ToolUseID,
+	))
+}
@@ -0,0 +1,306 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// Permission update type discriminators.
+const (
+	permissionUpdateAddRules          = "addRules"
+	permissionUpdateReplaceRules      = "replaceRules"
+	permissionUpdateRemoveRules       = "removeRules"
+	permissionUpdateAddDirectories    = "addDirectories"
+	permissionUpdateRemoveDirectories = "removeDirectories"
+	permissionUpdateSetMode           = "setMode"
+
+	// ruleWildcardSuffix marks a rule content as a prefix match, as in
+	// "npm test:*".
+	ruleWildcardSuffix = ":*"
+)
+
+// DecodePermissionUpdate decodes a single permission update by its "type"
+// discriminator.
+func DecodePermissionUpdate(data json.RawMessage) (PermissionUpdate, error) {
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, clauderrs.NewProtocolError(
+			clauderrs.ErrCodeMessageParseFailed,
+			"failed to parse permission update",
+			err,
+		)
+	}
+
+	var update PermissionUpdate
+	switch envelope.Type {
+	case permissionUpdateAddRules:
+		update = &AddRulesUpdate{}
+	case permissionUpdateReplaceRules:
+		update = &ReplaceRulesUpdate{}
+	case permissionUpdateRemoveRules:
+		update = &RemoveRulesUpdate{}
+	case permissionUpdateAddDirectories:
+		update = &AddDirectoriesUpdate{}
+	case permissionUpdateRemoveDirectories:
+		update = &RemoveDirectoriesUpdate{}
+	case permissionUpdateSetMode:
+		update = &SetModeUpdate{}
+	default:
+		return nil, clauderrs.NewProtocolError(
+			clauderrs.ErrCodeInvalidMessage,
+			fmt.Sprintf("unknown permission update type: %s", envelope.Type),
+			nil,
+		)
+	}
+
+	if err := json.Unmarshal(data, update); err != nil {
+		return nil, clauderrs.NewProtocolError(
+			clauderrs.ErrCodeMessageParseFailed,
+			fmt.Sprintf("failed to parse %s permission update", envelope.Type),
+			err,
+		)
+	}
+
+	return update, nil
+}
+
+// decodePermissionSuggestions decodes the suggestions of a can_use_tool
+// request, skipping entries the SDK does not understand.
+func decodePermissionSuggestions(raw []JSONValue) []PermissionUpdate {
+	updates := make([]PermissionUpdate, 0, len(raw))
+	for _, data := range raw {
+		update, err := DecodePermissionUpdate(data)
+		if err != nil {
+			continue
+		}
+		updates = append(updates, update)
+	}
+
+	return updates
+}
+
+// normalizePermissionUpdate fills in the type discriminator so updates
+// built without it marshal correctly.
+func normalizePermissionUpdate(update PermissionUpdate) PermissionUpdate {
+	switch u := update.(type) {
+	case *AddRulesUpdate:
+		c := *u
+		c.Type = permissionUpdateAddRules
+
+		return c
+	case AddRulesUpdate:
+		u.Type = permissionUpdateAddRules
+
+		return u
+	case *ReplaceRulesUpdate:
+		c := *u
+		c.Type = permissionUpdateReplaceRules
+
+		return c
+	case ReplaceRulesUpdate:
+		u.Type = permissionUpdateReplaceRules
+
+		return u
+	case *RemoveRulesUpdate:
+		c := *u
+		c.Type = permissionUpdateRemoveRules
+
+		return c
+	case RemoveRulesUpdate:
+		u.Type = permissionUpdateRemoveRules
+
+		return u
+	case *AddDirectoriesUpdate:
+		c := *u
+		c.Type = permissionUpdateAddDirectories
+
+		return c
+	case AddDirectoriesUpdate:
+		u.Type = permissionUpdateAddDirectories
+
+		return u
+	case *RemoveDirectoriesUpdate:
+		c := *u
+		c.Type = permissionUpdateRemoveDirectories
+
+		return c
+	case RemoveDirectoriesUpdate:
+		u.Type = permissionUpdateRemoveDirectories
+
+		return u
+	case *SetModeUpdate:
+		c := *u
+		c.Type = permissionUpdateSetMode
+
+		return c
+	case SetModeUpdate:
+		u.Type = permissionUpdateSetMode
+
+		return u
+	default:
+		return update
+	}
+}
+
+// sessionPermissions holds session-scoped permission rules applied by the
+// SDK before the CanUseTool callback is consulted.
+type sessionPermissions struct {
+	mu    sync.RWMutex
+	rules map[PermissionBehavior][]PermissionRuleValue
+}
+
+// newSessionPermissions creates an empty rule set.
+func newSessionPermissions() *sessionPermissions {
+	return &sessionPermissions{
+		rules: make(map[PermissionBehavior][]PermissionRuleValue),
+	}
+}
+
+// apply records rule updates targeting the session. Directory and mode
+// updates, and updates for other destinations, are left to the CLI.
+func (s *sessionPermissions) apply(update PermissionUpdate) {
+	update = normalizePermissionUpdate(update)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch u := update.(type) {
+	case AddRulesUpdate:
+		if u.Destination == PermissionDestinationSession {
+			s.rules[u.Behavior] = append(s.rules[u.Behavior], u.Rules...)
+		}
+	case ReplaceRulesUpdate:
+		if u.Destination == PermissionDestinationSession {
+			s.rules[u.Behavior] = append([]PermissionRuleValue(nil), u.Rules...)
+		}
+	case RemoveRulesUpdate:
+		if u.Destination == PermissionDestinationSession {
+			s.rules[u.Behavior] = removeRules(s.rules[u.Behavior], u.Rules)
+		}
+	}
+}
+
+// decide returns the behavior of the first matching session rule. Deny
+// rules take precedence over allow rules.
+func (s *sessionPermissions) decide(
+	toolName string,
+	input map[string]JSONValue,
+) (PermissionBehavior, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, behavior := range []PermissionBehavior{
+		PermissionBehaviorDeny,
+		PermissionBehaviorAllow,
+	} {
+		for _, rule := range s.rules[behavior] {
+			if ruleMatches(rule, behavior, toolName, input) {
+				return behavior, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// removeRules returns rules without any entry equal to one in remove.
+func removeRules(rules, remove []PermissionRuleValue) []PermissionRuleValue {
+	kept := rules[:0]
+	for _, rule := range rules {
+		if !containsRule(remove, rule) {
+			kept = append(kept, rule)
+		}
+	}
+
+	return kept
+}
+
+// containsRule reports whether rules contains rule.
+func containsRule(rules []PermissionRuleValue, rule PermissionRuleValue) bool {
+	for _, r := range rules {
+		if r.ToolName == rule.ToolName && ruleContent(r) == ruleContent(rule) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ruleContent returns the rule content or "" when unset.
+func ruleContent(rule PermissionRuleValue) string {
+	if rule.RuleContent == nil {
+		return ""
+	}
+
+	return *rule.RuleContent
+}
+
+// ruleMatches reports whether rule, of behavior, covers a call to toolName
+// with input.
+//
+// A rule without content matches every call to the tool. Otherwise it
+// matches when the call's command, file or URL input, the one
+// SuggestedRule scopes rules to, equals the content, or starts with it
+// when the content ends in ":*". An allow prefix never matches a command
+// chaining, substituting or redirecting others, such as
+// "npm test && curl x | sh", or a path leaving the prefix through "..".
+func ruleMatches(
+	rule PermissionRuleValue,
+	behavior PermissionBehavior,
+	toolName string,
+	input map[string]JSONValue,
+) bool {
+	if rule.ToolName != toolName {
+		return false
+	}
+
+	content := ruleContent(rule)
+	if content == "" {
+		return true
+	}
+
+	name, value, ok := ruleSubject(input)
+	if !ok {
+		return false
+	}
+	if value == content {
+		return true
+	}
+	prefix, wildcard := strings.CutSuffix(content, ruleWildcardSuffix)
+	if !wildcard || !strings.HasPrefix(value, prefix) {
+		return false
+	}
+	if behavior != PermissionBehaviorAllow {
+		return true
+	}
+	if name == "command" {
+		return !strings.ContainsAny(value, shellControl)
+	}
+
+	return !slices.Contains(strings.Split(value, "/"), "..")
+}
+
+// shellControl are the characters that chain, substitute, expand or
+// redirect commands, so a command containing one may run more than its
+// prefix says.
+const shellControl = ";&|<>`$\n\r"
+
+// ruleSubject returns the name and value of the input rule contents are
+// matched against: the first of denialRuleInputs the call has.
+func ruleSubject(input map[string]JSONValue) (string, string, bool) {
+	for _, name := range denialRuleInputs {
+		var value string
+		if err := json.Unmarshal(input[name], &value); err == nil && value != "" {
+			return name, value, true
+		}
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,157 @@
+package claude
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// exitPlanModeTool is the tool Claude calls to present a plan in plan mode.
+const exitPlanModeTool = "ExitPlanMode"
+
+// planItemPattern matches a numbered ("1." or "1)") or bulleted list item
+// and captures its indentation and text.
+var planItemPattern = regexp.MustCompile(`^(\s*)(?:\d+[.)]|[-*+])\s+(.*)$`)
+
+// Plan is a plan Claude presented in plan mode.
+type Plan struct {
+	// ToolUseID is the ID of the ExitPlanMode tool_use block.
+	ToolUseID string
+	// Raw is the plan text as written by Claude.
+	Raw string
+	// Steps are the top-level list items of the plan, in order. A plan
+	// without a list has no steps.
+	Steps []PlanStep
+}
+
+// PlanStep is one top-level item of a plan.
+type PlanStep struct {
+	// Index is the 1-based position of the step.
+	Index int
+	// Title is the text of the list item's first line.
+	Title string
+	// Details holds the indented lines and nested items under the step,
+	// with common indentation removed.
+	Details string
+}
+
+// ParsePlan parses plan text into steps. Steps are the list items at the
+// shallowest indentation; deeper lines belong to the preceding step's
+// details, and unindented non-list text ends it.
+func ParsePlan(text string) *Plan {
+	plan := &Plan{Raw: text}
+	lines := strings.Split(text, "\n")
+
+	indent := -1
+	for _, line := range lines {
+		if m := planItemPattern.FindStringSubmatch(line); m != nil {
+			if indent < 0 || len(m[1]) < indent {
+				indent = len(m[1])
+			}
+		}
+	}
+	if indent < 0 {
+		return plan
+	}
+
+	var (
+		current *PlanStep
+		details []string
+	)
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Details = dedent(details)
+		plan.Steps = append(plan.Steps, *current)
+		current, details = nil, nil
+	}
+
+	for _, line := range lines {
+		m := planItemPattern.FindStringSubmatch(line)
+		switch {
+		case m != nil && len(m[1]) == indent:
+			flush()
+			current = &PlanStep{
+				Index: len(plan.Steps) + 1,
+				Title: strings.TrimSpace(m[2]),
+			}
+		case current == nil:
+		case strings.TrimSpace(line) == "":
+			details = append(details, "")
+		case leadingSpace(line) > indent:
+			details = append(details, line)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return plan
+}
+
+// planFromMessage returns the plan carried by an ExitPlanMode tool_use in
+// msg, or nil.
+func planFromMessage(msg SDKMessage) *Plan {
+	assistant, ok := msg.(*SDKAssistantMessage)
+	if !ok {
+		return nil
+	}
+
+	for _, block := range assistant.Message.Content {
+		toolUse, ok := block.(ToolUseContentBlock)
+		if !ok || toolUse.Name != exitPlanModeTool {
+			continue
+		}
+
+		var input struct {
+			Plan string `json:"plan"`
+		}
+		if err := json.Unmarshal(toolUse.Input, &input); err != nil || input.Plan == "" {
+			continue
+		}
+
+		plan := ParsePlan(input.Plan)
+		plan.ToolUseID = toolUse.ID
+
+		return plan
+	}
+
+	return nil
+}
+
+// dedent trims blank edge lines and removes the indentation common to all
+// non-blank lines.
+func dedent(lines []string) string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := leadingSpace(line); common < 0 || n < common {
+			common = n
+		}
+	}
+
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= common && common > 0 {
+			line = line[common:]
+		}
+		out[i] = strings.TrimRight(line, " \t")
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// leadingSpace counts the leading spaces and tabs of line.
+func leadingSpace(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
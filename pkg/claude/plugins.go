@@ -0,0 +1,375 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// Plugin source types for SdkPluginConfig.Type.
+const (
+	PluginTypeLocal    = "local"
+	PluginTypeGit      = "git"
+	PluginTypeRegistry = "registry"
+)
+
+const (
+	// pluginFetchTimeout bounds fetching all plugins before launch.
+	pluginFetchTimeout = 5 * time.Minute
+	// marketplaceManifest is the registry index within a marketplace
+	// repository.
+	marketplaceManifest = ".claude-plugin/marketplace.json"
+)
+
+// commitSHAPattern matches full SHA-1 and SHA-256 commit hashes.
+var commitSHAPattern = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// marketplaceEntry is a plugin listed in a marketplace manifest. Source is
+// a path relative to the marketplace root, or an object naming a GitHub
+// repository or git URL.
+type marketplaceEntry struct {
+	Name   string          `json:"name"`
+	Source json.RawMessage `json:"source"`
+}
+
+// marketplaceSource is the object form of a marketplace entry's source.
+type marketplaceSource struct {
+	Source string `json:"source"` // "github" or "url"
+	Repo   string `json:"repo"`
+	URL    string `json:"url"`
+	Ref    string `json:"ref"`
+	SHA    string `json:"sha"`
+}
+
+// resolvePlugins returns the plugin directories to pass to the CLI,
+// fetching git and registry plugins into the cache first.
+func resolvePlugins(opts *Options) ([]string, error) {
+	if len(opts.Plugins) == 0 {
+		return nil, nil
+	}
+
+	cacheDir, err := pluginCacheDir(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pluginFetchTimeout)
+	defer cancel()
+
+	dirs := make([]string, 0, len(opts.Plugins))
+	for _, plugin := range opts.Plugins {
+		dir, err := resolvePlugin(ctx, plugin, cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, dir)
+	}
+
+	return dirs, nil
+}
+
+// pluginCacheDir returns the directory fetched plugins are cached in.
+func pluginCacheDir(opts *Options) (string, error) {
+	if opts.PluginCacheDir != "" {
+		return opts.PluginCacheDir, nil
+	}
+
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidConfig,
+			"no user cache directory for plugins; set PluginCacheDir",
+			err,
+			"PluginCacheDir",
+			"",
+		)
+	}
+
+	return filepath.Join(cache, "claude-agent-sdk", "plugins"), nil
+}
+
+// resolvePlugin returns the directory of a single plugin.
+func resolvePlugin(ctx context.Context, plugin SdkPluginConfig, cacheDir string) (string, error) {
+	if plugin.SHA != "" && !commitSHAPattern.MatchString(plugin.SHA) {
+		return "", clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidFormat,
+			"plugin sha must be a full lowercase commit hash",
+			nil,
+			"sha",
+			plugin.SHA,
+		)
+	}
+
+	switch plugin.Type {
+	case PluginTypeLocal, "":
+		if plugin.Path == "" {
+			return "", clauderrs.NewValidationError(clauderrs.ErrCodeMissingField, "local plugin requires a path", nil, "path", "")
+		}
+
+		return plugin.Path, nil
+	case PluginTypeGit:
+		if plugin.URL == "" {
+			return "", clauderrs.NewValidationError(clauderrs.ErrCodeMissingField, "git plugin requires a url", nil, "url", "")
+		}
+
+		repo, err := fetchGitRepo(ctx, cacheDir, plugin.URL, plugin.Ref, plugin.SHA)
+		if err != nil {
+			return "", err
+		}
+
+		return pluginSubdir(repo, plugin.Subdir)
+	case PluginTypeRegistry:
+		if plugin.URL == "" || plugin.Name == "" {
+			return "", clauderrs.NewValidationError(
+				clauderrs.ErrCodeMissingField,
+				"registry plugin requires a marketplace url and a name",
+				nil,
+				"name",
+				plugin.Name,
+			)
+		}
+
+		return resolveRegistryPlugin(ctx, plugin, cacheDir)
+	default:
+		return "", clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidConfig,
+			fmt.Sprintf("unknown plugin type %q", plugin.Type),
+			nil,
+			"type",
+			plugin.Type,
+		)
+	}
+}
+
+// resolveRegistryPlugin fetches a marketplace, looks the plugin up in its
+// manifest, and fetches the plugin's source if it lives elsewhere.
+func resolveRegistryPlugin(ctx context.Context, plugin SdkPluginConfig, cacheDir string) (string, error) {
+	marketplace, err := fetchGitRepo(ctx, cacheDir, plugin.URL, plugin.Ref, plugin.SHA)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(filepath.Join(marketplace, marketplaceManifest))
+	if err != nil {
+		return "", clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidConfig,
+			fmt.Sprintf("marketplace %s has no %s", plugin.URL, marketplaceManifest),
+			err,
+			"url",
+			plugin.URL,
+		)
+	}
+
+	var manifest struct {
+		Plugins []marketplaceEntry `json:"plugins"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidFormat,
+			fmt.Sprintf("failed to parse %s in %s", marketplaceManifest, plugin.URL),
+			err,
+			"url",
+			plugin.URL,
+		)
+	}
+
+	for _, entry := range manifest.Plugins {
+		if entry.Name != plugin.Name {
+			continue
+		}
+
+		var path string
+		if err := json.Unmarshal(entry.Source, &path); err == nil {
+			dir, err := pluginSubdir(marketplace, path)
+			if err != nil {
+				return "", err
+			}
+
+			return pluginSubdir(dir, plugin.Subdir)
+		}
+
+		var source marketplaceSource
+		if err := json.Unmarshal(entry.Source, &source); err != nil {
+			return "", clauderrs.NewValidationError(
+				clauderrs.ErrCodeInvalidFormat,
+				fmt.Sprintf("plugin %s has an invalid source", plugin.Name),
+				err,
+				"source",
+				string(entry.Source),
+			)
+		}
+
+		url := source.URL
+		if source.Source == "github" {
+			url = "https://github.com/" + source.Repo + ".git"
+		}
+		if url == "" {
+			return "", clauderrs.NewValidationError(
+				clauderrs.ErrCodeInvalidConfig,
+				fmt.Sprintf("plugin %s source %q is not supported", plugin.Name, source.Source),
+				nil,
+				"source",
+				string(entry.Source),
+			)
+		}
+
+		repo, err := fetchGitRepo(ctx, cacheDir, url, source.Ref, source.SHA)
+		if err != nil {
+			return "", err
+		}
+
+		return pluginSubdir(repo, plugin.Subdir)
+	}
+
+	return "", clauderrs.NewValidationError(
+		clauderrs.ErrCodeInvalidConfig,
+		fmt.Sprintf("plugin %s not found in marketplace %s", plugin.Name, plugin.URL),
+		nil,
+		"name",
+		plugin.Name,
+	)
+}
+
+// pluginSubdir joins a relative path to a repository root, refusing paths
+// that leave it.
+func pluginSubdir(root, subdir string) (string, error) {
+	if subdir == "" {
+		return root, nil
+	}
+
+	clean := filepath.Clean(filepath.FromSlash(subdir))
+	if !filepath.IsLocal(clean) {
+		return "", clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidFormat,
+			"plugin subdirectory must be relative to the repository",
+			nil,
+			"subdir",
+			subdir,
+		)
+	}
+
+	return filepath.Join(root, clean), nil
+}
+
+// fetchGitRepo returns a checkout of url at sha, or at ref (the default
+// branch if empty) when sha is unset. Checkouts pinned to a commit are
+// reused from the cache as long as they are still at that commit;
+// unpinned checkouts are updated on every call.
+func fetchGitRepo(ctx context.Context, cacheDir, url, ref, sha string) (string, error) {
+	key := sha256.Sum256([]byte(url + "\x00" + ref + "\x00" + sha))
+	dir := filepath.Join(cacheDir, hex.EncodeToString(key[:8]))
+
+	if _, err := os.Stat(dir); err == nil {
+		if sha != "" {
+			if head, err := gitOutput(ctx, dir, "rev-parse", "HEAD"); err == nil && head == sha {
+				return dir, nil
+			}
+		} else if err := checkoutRef(ctx, dir, ref); err == nil {
+			return dir, nil
+		}
+		// The cached checkout is stale or damaged; fetch a fresh one
+		_ = os.RemoveAll(dir)
+	}
+
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		return "", pluginFetchError(url, err)
+	}
+	tmp, err := os.MkdirTemp(cacheDir, ".fetch-*")
+	if err != nil {
+		return "", pluginFetchError(url, err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if _, err := gitOutput(ctx, "", "clone", "--quiet", "--no-checkout", "--", url, tmp); err != nil {
+		return "", pluginFetchError(url, err)
+	}
+
+	target := sha
+	if target == "" {
+		if err := checkoutRef(ctx, tmp, ref); err != nil {
+			return "", pluginFetchError(url, err)
+		}
+	} else if _, err := gitOutput(ctx, tmp, "checkout", "--quiet", "--detach", target); err != nil {
+		return "", pluginFetchError(url, err)
+	}
+
+	if sha != "" {
+		head, err := gitOutput(ctx, tmp, "rev-parse", "HEAD")
+		if err != nil {
+			return "", pluginFetchError(url, err)
+		}
+		if head != sha {
+			return "", clauderrs.NewValidationError(
+				clauderrs.ErrCodeInvalidConfig,
+				fmt.Sprintf("plugin %s is at commit %s, not the pinned %s", url, head, sha),
+				nil,
+				"sha",
+				sha,
+			)
+		}
+	}
+
+	if err := os.Rename(tmp, dir); err != nil {
+		// Another process may have populated the cache concurrently
+		if _, statErr := os.Stat(dir); statErr != nil {
+			return "", pluginFetchError(url, err)
+		}
+	}
+
+	return dir, nil
+}
+
+// checkoutRef updates a checkout to the latest commit of ref, or of the
+// remote's default branch when ref is empty.
+func checkoutRef(ctx context.Context, dir, ref string) error {
+	target := "HEAD"
+	if ref != "" {
+		target = ref
+	}
+	if _, err := gitOutput(ctx, dir, "fetch", "--quiet", "origin", target); err != nil {
+		return err
+	}
+	_, err := gitOutput(ctx, dir, "checkout", "--quiet", "--detach", "FETCH_HEAD")
+
+	return err
+}
+
+// gitOutput runs git in dir and returns its trimmed stdout.
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// pluginFetchError wraps a failure to fetch a plugin repository.
+func pluginFetchError(url string, err error) error {
+	return clauderrs.NewClientError(
+		clauderrs.ErrCodeIOError,
+		fmt.Sprintf("failed to fetch plugin repository %s", url),
+		err,
+	)
+}
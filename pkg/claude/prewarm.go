@@ -0,0 +1,328 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// maxStartupSamples bounds the latency samples a WarmPool keeps per kind of
+// start. Older samples are overwritten once the limit is reached.
+const maxStartupSamples = 1024
+
+// WarmPool keeps CLI processes started, with the initialize handshake
+// done, so clients taken from it skip the CLI's startup. Create one with
+// Prewarm. Each process serves one client; the pool starts a replacement
+// in the background whenever one is taken, and starts processes on demand,
+// cold, when none is idle.
+type WarmPool struct {
+	opts *Options
+	size int
+
+	mu      sync.Mutex
+	idle    []*warmQuery
+	closed  bool
+	warm    startupSamples
+	cold    startupSamples
+	pending sync.WaitGroup // Replacements being started
+}
+
+// warmQuery is a started, initialized session awaiting its first prompt.
+type warmQuery struct {
+	query *queryImpl
+	auth  *authEvents
+	tools *ToolStatsCollector // Times the query's tool calls
+}
+
+// StartupStats compares the startup latency of clients from a WarmPool
+// that got an idle process (warm) with those that had to start one (cold).
+type StartupStats struct {
+	Warm StartupTimings
+	Cold StartupTimings
+}
+
+// StartupTimings summarizes the startup latency of one kind of start.
+type StartupTimings struct {
+	// Count is the number of clients started this way.
+	Count int
+	// ReadyP50 and ReadyP95 are percentiles of the time NewClient took to
+	// return a client ready for its first prompt.
+	ReadyP50 time.Duration
+	ReadyP95 time.Duration
+	// FirstTokenP50 and FirstTokenP95 are percentiles of the time to the
+	// first output of the model, a partial message or an assistant
+	// message: the ready time plus the time from the client's first Query
+	// to that output, over the clients that received one.
+	FirstTokenP50 time.Duration
+	FirstTokenP95 time.Duration
+}
+
+// startupSamples is a bounded record of startup latencies.
+type startupSamples struct {
+	count      int
+	ready      []time.Duration
+	firstToken []time.Duration
+	nextReady  int
+	nextFirst  int
+}
+
+// Prewarm starts n CLI processes with opts and completes their initialize
+// handshake, returning a pool that hands them to clients. It fails if any
+// can't be started before ctx ends. Close the pool to stop the processes
+// no client took.
+func Prewarm(ctx context.Context, n int, opts *Options) (*WarmPool, error) {
+	if n <= 0 {
+		return nil, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"Prewarm needs at least one process",
+			nil,
+			"n",
+			n,
+		)
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	pool := &WarmPool{opts: opts, size: n}
+	results := make(chan error, n)
+	for range n {
+		go func() {
+			started, err := pool.start(ctx)
+			if err == nil {
+				pool.mu.Lock()
+				pool.idle = append(pool.idle, started)
+				pool.mu.Unlock()
+			}
+			results <- err
+		}()
+	}
+
+	var errs []error
+	for range n {
+		if err := <-results; err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		_ = pool.Close()
+
+		return nil, errors.Join(errs...)
+	}
+
+	return pool, nil
+}
+
+// start starts a session and completes its initialize handshake.
+func (p *WarmPool) start(ctx context.Context) (*warmQuery, error) {
+	auth := newAuthEvents()
+	tools := NewToolStatsCollector()
+	q, err := newQueryImpl("", tools.timedOptions(p.opts), nil, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sessions with SDK hooks were initialized when they started
+	q.mu.Lock()
+	initialized := q.initializationResult != nil
+	q.mu.Unlock()
+	if !initialized {
+		if _, err := q.Initialize(ctx); err != nil {
+			_ = q.Close()
+
+			return nil, err
+		}
+	}
+
+	return &warmQuery{query: q, auth: auth, tools: tools}, nil
+}
+
+// NewClient returns a client whose session is started, taking an idle
+// process if there is one and starting one otherwise. The client's first
+// Query only sends the prompt.
+func (p *WarmPool) NewClient(ctx context.Context) (*ClaudeSDKClient, error) {
+	begin := time.Now()
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+
+		return nil, clauderrs.NewClientError(
+			clauderrs.ErrCodeClientClosed,
+			"warm pool is closed",
+			nil,
+		)
+	}
+	var taken *warmQuery
+	for taken == nil && len(p.idle) > 0 {
+		taken, p.idle = p.idle[0], p.idle[1:]
+		// Skip processes that exited while idle
+		if taken.query.activity.reader.snapshot().State == GoroutineStopped {
+			_ = taken.query.Close()
+			taken.auth.close()
+			taken = nil
+		}
+	}
+	p.pending.Add(1)
+	p.mu.Unlock()
+	go p.replenish()
+
+	samples := &p.warm
+	if taken == nil {
+		samples = &p.cold
+		var err error
+		if taken, err = p.start(ctx); err != nil {
+			return nil, err
+		}
+	}
+	ready := time.Since(begin)
+
+	p.mu.Lock()
+	samples.count++
+	samples.ready, samples.nextReady = addStartupSample(samples.ready, samples.nextReady, ready)
+	p.mu.Unlock()
+
+	client := newClient(p.opts, taken.tools)
+	client.query = taken.query
+	client.auth = taken.auth
+	client.startup = &startupTimer{ready: ready, record: func(latency time.Duration) {
+		p.mu.Lock()
+		samples.firstToken, samples.nextFirst = addStartupSample(samples.firstToken, samples.nextFirst, latency)
+		p.mu.Unlock()
+	}}
+
+	return client, nil
+}
+
+// replenish starts a process to replace one taken from the pool.
+func (p *WarmPool) replenish() {
+	defer p.pending.Done()
+
+	p.mu.Lock()
+	full := p.closed || len(p.idle) >= p.size
+	p.mu.Unlock()
+	if full {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), initializeTimeout)
+	defer cancel()
+	started, err := p.start(ctx)
+	if err != nil {
+		return
+	}
+
+	p.mu.Lock()
+	if p.closed || len(p.idle) >= p.size {
+		p.mu.Unlock()
+		_ = started.query.Close()
+
+		return
+	}
+	p.idle = append(p.idle, started)
+	p.mu.Unlock()
+}
+
+// Stats returns the startup latency of the clients the pool returned.
+func (p *WarmPool) Stats() StartupStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return StartupStats{Warm: p.warm.timings(), Cold: p.cold.timings()}
+}
+
+// Close stops the idle processes. Clients taken from the pool are closed
+// by their owners.
+func (p *WarmPool) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	// Replacements still starting are stopped when they finish
+	p.pending.Wait()
+
+	var errs []error
+	for _, started := range idle {
+		if err := started.query.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		started.auth.close()
+	}
+
+	return errors.Join(errs...)
+}
+
+// timings summarizes the samples.
+func (s *startupSamples) timings() StartupTimings {
+	ready := slices.Sorted(slices.Values(s.ready))
+	firstToken := slices.Sorted(slices.Values(s.firstToken))
+
+	return StartupTimings{
+		Count:         s.count,
+		ReadyP50:      percentile(ready, percentile50),
+		ReadyP95:      percentile(ready, percentile95),
+		FirstTokenP50: percentile(firstToken, percentile50),
+		FirstTokenP95: percentile(firstToken, percentile95),
+	}
+}
+
+// addStartupSample records latency in the ring samples, whose next slot
+// to overwrite is next.
+func addStartupSample(samples []time.Duration, next int, latency time.Duration) ([]time.Duration, int) {
+	if len(samples) < maxStartupSamples {
+		return append(samples, latency), next
+	}
+	samples[next] = latency
+
+	return samples, (next + 1) % maxStartupSamples
+}
+
+// startupTimer measures the time to the first token of a client from a
+// WarmPool: the time NewClient took plus the time from the first Query to
+// the first model output, leaving out how long the client sat unused.
+type startupTimer struct {
+	ready  time.Duration
+	record func(time.Duration)
+
+	mu      sync.Mutex
+	queried time.Time
+	done    bool
+}
+
+// query notes the client's first Query.
+func (t *startupTimer) query() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if t.queried.IsZero() {
+		t.queried = time.Now()
+	}
+	t.mu.Unlock()
+}
+
+// observe records the first token when msg carries model output.
+func (t *startupTimer) observe(msg SDKMessage) {
+	if t == nil {
+		return
+	}
+	switch msg.(type) {
+	case *SDKStreamEvent, *SDKAssistantMessage:
+	default:
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done || t.queried.IsZero() {
+		return
+	}
+	t.done = true
+	t.record(t.ready + time.Since(t.queried))
+}
@@ -0,0 +1,83 @@
+package claude
+
+import (
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// ProcessLimits constrains the CLI subprocess and everything it spawns, so
+// hosts running many agents can contain a runaway one. Zero fields are not
+// limited.
+//
+// On Linux, MaxRSS and CPUShares need cgroup v2 with the memory and cpu
+// controllers delegated to CgroupParent (by default the SDK's own cgroup,
+// e.g. under systemd's Delegate=yes). On Windows the limits are enforced
+// with a job object. Other platforms support no limits. Limits that can't
+// be enforced fail the query with ErrCodeProcessSpawnFailed rather than
+// being ignored.
+//
+// A CLI killed for exceeding MaxRSS fails with
+// ErrCodeResourceLimitExceeded.
+type ProcessLimits struct {
+	// MaxRSS is the memory limit in bytes. Swap is not allowed to extend
+	// it.
+	MaxRSS int64
+	// CPUShares is the relative CPU weight under contention; 1024 is the
+	// weight of an unconstrained process.
+	CPUShares int
+	// Niceness is the scheduling nice value, from -20 (highest priority)
+	// to 19 (lowest). Negative values usually need privileges.
+	Niceness int
+	// CgroupParent is the cgroup v2 directory the CLI's group is created
+	// in, on Linux.
+	CgroupParent string
+}
+
+// validate checks the limits' ranges.
+func (l *ProcessLimits) validate() []error {
+	var errs []error
+
+	if l.MaxRSS < 0 {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"ProcessLimits.MaxRSS must not be negative",
+			nil,
+			"ProcessLimits.MaxRSS",
+			l.MaxRSS,
+		))
+	}
+	if l.CPUShares < 0 {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"ProcessLimits.CPUShares must not be negative",
+			nil,
+			"ProcessLimits.CPUShares",
+			l.CPUShares,
+		))
+	}
+	if l.Niceness < -20 || l.Niceness > 19 {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"ProcessLimits.Niceness must be between -20 and 19",
+			nil,
+			"ProcessLimits.Niceness",
+			l.Niceness,
+		))
+	}
+
+	return errs
+}
+
+// resourceLimits converts the options to the transport's limits.
+func (l *ProcessLimits) resourceLimits() *transport.ResourceLimits {
+	if l == nil {
+		return nil
+	}
+
+	return &transport.ResourceLimits{
+		MaxRSS:       l.MaxRSS,
+		CPUShares:    l.CPUShares,
+		Niceness:     l.Niceness,
+		CgroupParent: l.CgroupParent,
+	}
+}
@@ -0,0 +1,179 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// pprof label keys set when TransportProfile.PprofLabels is enabled.
+const (
+	// PprofLabelGoroutine names the SDK goroutine: "reader", "writer" or
+	// "dispatcher".
+	PprofLabelGoroutine = "claude_sdk_goroutine"
+	// PprofLabelSessionID is the SDK's session ID of the query.
+	PprofLabelSessionID = "claude_sdk_session_id"
+)
+
+// TransportProfile enables per-message tracing of the SDK's transport, to
+// quantify its overhead in high-throughput streaming.
+type TransportProfile struct {
+	// OnTrace, if set, is called with a MessageTrace for every frame read
+	// from or written to the CLI. Inbound messages are traced when Next
+	// returns them, on the caller's goroutine, other frames where they are
+	// read or written. It should return quickly.
+	OnTrace func(MessageTrace)
+	// PprofLabels runs the SDK's reader, writer and dispatcher under pprof
+	// labels (PprofLabelGoroutine and PprofLabelSessionID), so CPU and
+	// goroutine profiles attribute their time to the SDK.
+	PprofLabels bool
+}
+
+// MessageTrace records the cost of handling one frame.
+type MessageTrace struct {
+	// Direction is TeeInbound or TeeOutbound.
+	Direction string
+	// Type is the frame's message type, e.g. "assistant" or
+	// "control_request".
+	Type string
+	// Bytes is the size of the frame as JSON.
+	Bytes int
+	// Timestamp is when the frame was read or written.
+	Timestamp time.Time
+	// DecodeTime is how long an inbound frame took to parse and route.
+	DecodeTime time.Duration
+	// WriteTime is how long an outbound frame took to write to the CLI.
+	WriteTime time.Duration
+	// QueueWait is how long an inbound message waited between being
+	// decoded and being returned by Next.
+	QueueWait time.Duration
+}
+
+// transportProfiler traces a query's frames. Traces of delivered messages
+// wait in queued, in msgChan order, until Next returns the message. A nil
+// profiler does nothing.
+type transportProfiler struct {
+	profile *TransportProfile
+	labels  pprof.LabelSet
+
+	mu     sync.Mutex
+	queued []MessageTrace
+}
+
+// newTransportProfiler returns nil if profile is nil.
+func newTransportProfiler(profile *TransportProfile, sessionID string) *transportProfiler {
+	if profile == nil {
+		return nil
+	}
+
+	return &transportProfiler{
+		profile: profile,
+		labels:  pprof.Labels(PprofLabelSessionID, sessionID),
+	}
+}
+
+// do runs f, under pprof labels naming goroutine if enabled.
+func (p *transportProfiler) do(goroutine string, f func()) {
+	if p == nil || !p.profile.PprofLabels {
+		f()
+
+		return
+	}
+
+	ctx := pprof.WithLabels(context.Background(), p.labels)
+	pprof.Do(ctx, pprof.Labels(PprofLabelGoroutine, goroutine), func(context.Context) { f() })
+}
+
+// decode runs decode on an inbound frame and returns its trace.
+func (p *transportProfiler) decode(
+	data []byte,
+	decode func([]byte) (SDKMessage, error),
+) (SDKMessage, MessageTrace, error) {
+	started := time.Now()
+	msg, err := decode(data)
+	trace := MessageTrace{
+		Direction:  TeeInbound,
+		Bytes:      len(data),
+		Timestamp:  started,
+		DecodeTime: time.Since(started),
+	}
+	if msg != nil {
+		trace.Type = msg.Type()
+	} else {
+		trace.Type = frameType(data)
+	}
+
+	return msg, trace, err
+}
+
+// write runs write on an outbound frame and emits its trace.
+func (p *transportProfiler) write(data []byte, write func() error) error {
+	if p == nil {
+		return write()
+	}
+
+	started := time.Now()
+	err := write()
+	p.emit(MessageTrace{
+		Direction: TeeOutbound,
+		Type:      frameType(data),
+		Bytes:     len(data),
+		Timestamp: started,
+		WriteTime: time.Since(started),
+	})
+
+	return err
+}
+
+// queue holds trace until its message is returned by Next.
+func (p *transportProfiler) queue(trace MessageTrace) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.queued = append(p.queued, trace)
+}
+
+// dequeue emits the trace of the message Next is returning.
+func (p *transportProfiler) dequeue() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	if len(p.queued) == 0 {
+		p.mu.Unlock()
+
+		return
+	}
+	trace := p.queued[0]
+	p.queued = p.queued[1:]
+	p.mu.Unlock()
+
+	trace.QueueWait = time.Since(trace.Timestamp) - trace.DecodeTime
+	p.emit(trace)
+}
+
+// emit passes trace to OnTrace.
+func (p *transportProfiler) emit(trace MessageTrace) {
+	if p == nil || p.profile.OnTrace == nil {
+		return
+	}
+
+	p.profile.OnTrace(trace)
+}
+
+// frameType returns the message type of a frame.
+func frameType(data []byte) string {
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	_ = json.Unmarshal(data, &envelope)
+
+	return envelope.Type
+}
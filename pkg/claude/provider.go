@@ -0,0 +1,139 @@
+package claude
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// Provider selects the API backend the CLI sends model requests to.
+type Provider string
+
+const (
+	// ProviderAnthropic uses the Anthropic API, ignoring Bedrock or
+	// Vertex settings inherited from the environment.
+	ProviderAnthropic Provider = "anthropic"
+	// ProviderBedrock uses Amazon Bedrock. It needs AWS_REGION;
+	// credentials come from the standard AWS chain (environment, profile,
+	// or instance role).
+	ProviderBedrock Provider = "bedrock"
+	// ProviderVertex uses Google Vertex AI. It needs CLOUD_ML_REGION and
+	// ANTHROPIC_VERTEX_PROJECT_ID; credentials come from Application
+	// Default Credentials.
+	ProviderVertex Provider = "vertex"
+)
+
+// CLI environment variables selecting and configuring providers.
+const (
+	envUseBedrock      = "CLAUDE_CODE_USE_BEDROCK"
+	envUseVertex       = "CLAUDE_CODE_USE_VERTEX"
+	envAWSRegion       = "AWS_REGION"
+	envAWSAccessKeyID  = "AWS_ACCESS_KEY_ID"
+	envAWSSecretKey    = "AWS_SECRET_ACCESS_KEY"
+	envVertexRegion    = "CLOUD_ML_REGION"
+	envVertexProjectID = "ANTHROPIC_VERTEX_PROJECT_ID"
+	envGoogleCreds     = "GOOGLE_APPLICATION_CREDENTIALS"
+)
+
+// validateProvider checks the provider fields without reading the
+// environment.
+func (o *Options) validateProvider() []error {
+	var errs []error
+
+	switch o.Provider {
+	case "", ProviderAnthropic, ProviderBedrock, ProviderVertex:
+	default:
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidType,
+			fmt.Sprintf("unknown provider %q", o.Provider),
+			nil,
+			"Provider",
+			o.Provider,
+		))
+	}
+
+	if o.ProviderRegion != "" && o.Provider != ProviderBedrock && o.Provider != ProviderVertex {
+		errs = append(errs, conflictError(
+			"ProviderRegion",
+			"ProviderRegion requires the bedrock or vertex provider",
+			o.ProviderRegion,
+		))
+	}
+	if o.CredentialsProvider != nil && (o.Provider == ProviderBedrock || o.Provider == ProviderVertex) {
+		errs = append(errs, conflictError(
+			"CredentialsProvider",
+			fmt.Sprintf("CredentialsProvider supplies Anthropic API credentials, which the %s provider doesn't use", o.Provider),
+			o.Provider,
+		))
+	}
+	if o.VertexProjectID != "" && o.Provider != ProviderVertex {
+		errs = append(errs, conflictError(
+			"VertexProjectID",
+			"VertexProjectID requires the vertex provider",
+			o.VertexProjectID,
+		))
+	}
+
+	return errs
+}
+
+// providerEnv returns the environment variables selecting opts.Provider
+// and checks that the variables it requires are set, in Options.Env or
+// the SDK's own environment. Credentials are inherited from the
+// environment as usual. All problems are reported in one ValidationError
+// naming the variables involved.
+func providerEnv(opts *Options) ([]string, error) {
+	lookup := func(name string) string {
+		if value, ok := opts.Env[name]; ok {
+			return value
+		}
+
+		return os.Getenv(name)
+	}
+
+	var env, fields, problems []string
+	require := func(name, option, value string) {
+		if value != "" {
+			env = append(env, name+"="+value)
+		} else if lookup(name) == "" {
+			fields = append(fields, name)
+			problems = append(problems, fmt.Sprintf("%s is not set (set %s or the environment variable)", name, option))
+		}
+	}
+
+	switch opts.Provider {
+	case ProviderAnthropic:
+		env = append(env, envUseBedrock+"=0", envUseVertex+"=0")
+	case ProviderBedrock:
+		env = append(env, envUseBedrock+"=1", envUseVertex+"=0")
+		require(envAWSRegion, "ProviderRegion", opts.ProviderRegion)
+		if lookup(envAWSAccessKeyID) != "" && lookup(envAWSSecretKey) == "" {
+			fields = append(fields, envAWSSecretKey)
+			problems = append(problems, fmt.Sprintf("%s is set without %s", envAWSAccessKeyID, envAWSSecretKey))
+		}
+	case ProviderVertex:
+		env = append(env, envUseVertex+"=1", envUseBedrock+"=0")
+		require(envVertexRegion, "ProviderRegion", opts.ProviderRegion)
+		require(envVertexProjectID, "VertexProjectID", opts.VertexProjectID)
+		if path := lookup(envGoogleCreds); path != "" {
+			if _, err := os.Stat(path); err != nil {
+				fields = append(fields, envGoogleCreds)
+				problems = append(problems, fmt.Sprintf("%s file %s is not readable", envGoogleCreds, path))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, clauderrs.NewValidationError(
+			clauderrs.ErrCodeMissingField,
+			fmt.Sprintf("%s provider configuration is incomplete: %s", opts.Provider, strings.Join(problems, "; ")),
+			nil,
+			strings.Join(fields, ","),
+			nil,
+		)
+	}
+
+	return env, nil
+}
@@ -3,13 +3,16 @@ package claude
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
 	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
-	"github.com/google/uuid"
 )
 
 const (
@@ -26,6 +29,14 @@ const (
 	// Request ID format.
 	requestIDFormat = "req_%d_%s"
 
+	// initializeTimeout bounds the wait for the CLI to acknowledge hook
+	// registration.
+	initializeTimeout = 60 * time.Second
+
+	// limitExitTimeout bounds the wait, after the CLI closes its output,
+	// to learn whether it was killed for exceeding its ProcessLimits.
+	limitExitTimeout = 2 * time.Second
+
 	// JSON field names.
 	fieldType      = "type"
 	fieldUUID      = "uuid"
@@ -67,8 +78,15 @@ type Query interface {
 	Interrupt(ctx context.Context) error
 	// SetPermissionMode changes the permission mode.
 	SetPermissionMode(ctx context.Context, mode PermissionMode) error
+	// UpdatePermissions applies permission updates for the rest of the session.
+	UpdatePermissions(ctx context.Context, updates []PermissionUpdate) error
 	// SetModel changes the model.
 	SetModel(ctx context.Context, model *string) error
+	// Model returns the session's current model, or "" while the CLI's
+	// default model is in use and not yet reported.
+	Model() string
+	// PermissionMode returns the session's current permission mode.
+	PermissionMode() PermissionMode
 	// SupportedCommands returns available slash commands.
 	SupportedCommands(ctx context.Context) ([]SlashCommand, error)
 	// SupportedModels returns available models.
@@ -77,6 +95,8 @@ type Query interface {
 	McpServerStatus(ctx context.Context) ([]McpServerStatus, error)
 	// GetServerInfo returns the initialization result stored during Initialize.
 	GetServerInfo() (map[string]any, error)
+	// Authenticate completes a pending authentication flow with a token.
+	Authenticate(ctx context.Context, token string) error
 
 	// SetMaxThinkingTokens allows dynamic adjustment of the maximum thinking token budget.
 	// Pass nil to clear the limit. Returns an error if the query is closed.
@@ -102,13 +122,40 @@ type queryImpl struct {
 	requestCounter          int
 	pendingControlResponses map[string]chan *SDKControlResponse
 	initializationResult    map[string]any
-	hookCallbacks           map[string]HookCallback // Maps callback IDs to hook functions
-	nextCallbackID          int                     // Counter for generating callback IDs
-	controlRequestChan      chan json.RawMessage    // Channel for incoming control requests
+	hookCallbacks           map[string]HookCallback  // Maps callback IDs to hook functions
+	asyncHooks              asyncHookRegistry        // Hook invocations awaiting CompleteAsyncHook
+	nextCallbackID          int                      // Counter for generating callback IDs
+	controlRequestChan      chan json.RawMessage     // Channel for incoming control requests
+	permissions             *sessionPermissions      // Session-scoped permission rules
+	agents                  *agentTracker            // Attributes tool uses to subagents
+	overrides               *toolOverrides           // Results served by Options.ToolOverrides
+	tools                   *toolCallbacks           // Calls reported to Options.OnToolStart
+	tee                     atomic.Pointer[frameTee] // Mirrors frames, see ClaudeSDKClient.TeeJSONL
+	skillsDir               string                   // Generated plugin exposing Options.Skills
+	pluginDirs              []string                 // Resolved Options.Plugins directories
+	apiKeySource            atomic.Pointer[string]   // From the CLI's init message
+	model                   atomic.Pointer[string]   // Effective model; see Model
+	permissionMode          atomic.Pointer[PermissionMode]
+	providerEnv             []string                  // Provider and credentials variables
+	egress                  *egressProxy              // Enforces Options.EgressPolicy
+	mcpSupervisors          map[string]*mcpSupervisor // Stdio MCP servers run by the SDK
+	pager                   *toolResultPager          // Pages oversized MCP tool output
+	memory                  *McpSdkServerConfig       // Serves Options.AgentMemory, if set
+	ordering                *orderingChecker          // Validates message order
+	auth                    *authEvents               // Receives auth status messages, if set
+	activity                sessionHealth             // Goroutine states, see ClaudeSDKClient.Health
+	failure                 atomic.Pointer[error]     // Why the query was failed, see fail
+	profiler                *transportProfiler        // Traces frames for Options.ProfileTransport
+	redactor                *redactor                 // Scrubs secrets from errors and stderr
+	callbacks               *callbackPool             // Runs control requests, with Options.CallbackWorkers
+	fileCheckpoints         fileCheckpointLog         // User messages sent, with Options.EnableFileCheckpointing
+	toolBudget              *toolBudget               // Counts tool calls for Options.MaxToolUses, if set
 }
 
-// newQueryImpl creates a new query implementation.
-func newQueryImpl(prompt string, opts *Options) (*queryImpl, error) {
+// newQueryImpl creates a new query implementation. Frames are mirrored to
+// tee, if non-nil, from the first one written. Auth status messages are
+// delivered to auth if non-nil, and with the other messages otherwise.
+func newQueryImpl(prompt string, opts *Options, tee *frameTee, auth *authEvents) (*queryImpl, error) {
 	if opts == nil {
 		opts = &Options{}
 	}
@@ -118,16 +165,33 @@ func newQueryImpl(prompt string, opts *Options) (*queryImpl, error) {
 		errChan:                 make(chan error, 1),
 		closeChan:               make(chan struct{}),
 		opts:                    opts,
-		sessionID:               uuid.New().String(),
+		sessionID:               opts.newUUID().String(),
 		pendingControlResponses: make(map[string]chan *SDKControlResponse),
 		hookCallbacks:           make(map[string]HookCallback),
 		nextCallbackID:          0,
 		controlRequestChan:      make(chan json.RawMessage, controlRequestChanBuffer),
+		permissions:             newSessionPermissions(),
+		agents:                  newAgentTracker(),
+		overrides:               newToolOverrides(),
+		tools:                   newToolCallbacks(),
+		auth:                    auth,
+		activity:                newSessionHealth(),
+	}
+	q.tee.Store(tee)
+	q.profiler = newTransportProfiler(opts.ProfileTransport, q.sessionID)
+	q.setModel(opts.Model)
+	q.setPermissionMode(opts.PermissionMode)
+	q.redactor = newRedactor(opts)
+	if opts.CallbackWorkers > 0 {
+		q.callbacks = newCallbackPool(opts.CallbackWorkers)
+	}
+	if opts.MaxToolUses != nil {
+		q.toolBudget = newToolBudget(opts.MaxToolUses)
 	}
 
 	// Start the process
 	if err := q.start(prompt); err != nil {
-		return nil, err
+		return nil, q.redactor.error(err)
 	}
 
 	return q, nil
@@ -135,6 +199,67 @@ func newQueryImpl(prompt string, opts *Options) (*queryImpl, error) {
 
 // start initializes the process and message handling.
 func (q *queryImpl) start(prompt string) error {
+	// Check the provider and fetch credentials before anything needs
+	// cleaning up
+	providerEnv, err := providerEnv(q.opts)
+	if err != nil {
+		return err
+	}
+	credentialsEnv, err := credentialsEnv(q.opts)
+	if err != nil {
+		return err
+	}
+	q.providerEnv = append(providerEnv, credentialsEnv...)
+	q.redactor.addEnv(q.providerEnv)
+
+	// Make sure a resumed transcript can continue with extended thinking
+	if err := checkResumeThinking(q.opts); err != nil {
+		return err
+	}
+
+	// Reconcile changed options with the resumed session
+	if err := checkResumeOptions(q.opts); err != nil {
+		return err
+	}
+	reportSamplingWarnings(q.opts)
+
+	// Agent memory needs somewhere to live
+	if err := q.opts.checkAgentMemory(); err != nil {
+		return err
+	}
+
+	// Fetch remote plugins
+	pluginDirs, err := resolvePlugins(q.opts)
+	if err != nil {
+		return err
+	}
+	q.pluginDirs = pluginDirs
+
+	// Hand configured skills to the CLI as a generated plugin
+	skillsDir, err := prepareSkills(q.opts)
+	if err != nil {
+		return err
+	}
+	q.skillsDir = skillsDir
+
+	// Route the CLI's traffic through the egress policy's proxy
+	egress, err := startEgressProxy(q.opts)
+	if err != nil {
+		q.removeSkillsDir()
+
+		return err
+	}
+	q.egress = egress
+
+	// Run stdio MCP servers under supervision
+	q.startMcpSupervisors()
+	q.pager = newToolResultPager(q.opts.ToolResultPaging)
+	if q.opts.AgentMemory {
+		memory := newMemoryServer(q.opts.SessionStore)
+		q.memory = &memory
+	}
+	q.ordering = newOrderingChecker(q.opts)
+
 	// Build process args
 	args := q.buildArgs()
 
@@ -143,16 +268,28 @@ func (q *queryImpl) start(prompt string) error {
 
 	// Create process config
 	config := &transport.ProcessConfig{
-		Executable:    q.opts.PathToClaudeCodeExecutable,
-		Args:          args,
-		Env:           env,
-		Cwd:           q.opts.Cwd,
-		StderrHandler: q.opts.Stderr,
+		Executable:     q.opts.PathToClaudeCodeExecutable,
+		Args:           args,
+		Env:            env,
+		Cwd:            q.opts.Cwd,
+		StderrHandler:  q.redactor.lines(q.opts.Stderr),
+		MaxMessageSize: q.opts.MaxMessageSize,
+		Codec:          q.opts.Codec,
+		Faults:         q.opts.FaultInjection.faultConfig(),
+		Limits:         q.opts.ProcessLimits.resourceLimits(),
+		Jail:           q.opts.Jail.jailConfig(),
+		User:           q.opts.RunAs.processUser(),
+		Daemon:         q.opts.Daemon,
+		Mux:            q.opts.Multiplexer.daemonMux(),
 	}
 
 	// Start process
 	proc, err := transport.NewProcess(context.Background(), config)
 	if err != nil {
+		q.removeSkillsDir()
+		q.egress.close()
+		q.stopMcpSupervisors()
+
 		return clauderrs.CreateProcessError(
 			clauderrs.ErrCodeProcessSpawnFailed,
 			"failed to start Claude Code process",
@@ -166,15 +303,48 @@ func (q *queryImpl) start(prompt string) error {
 	q.proc = proc
 
 	// Start message reading goroutine
-	go q.readMessages()
+	go q.profiler.do("reader", q.readMessages)
 
 	// Start control request handler goroutine
-	go q.handleControlRequests()
+	go q.profiler.do("dispatcher", q.handleControlRequests)
+
+	// Fail the session if one of them gets stuck
+	if q.opts.Watchdog != nil {
+		go q.watch(q.opts.Watchdog)
+	}
+
+	// Register SDK hooks before the first prompt so they apply to it
+	if len(q.hookMatchers()) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), initializeTimeout)
+		_, err := q.Initialize(ctx)
+		cancel()
+		if err != nil {
+			_ = q.Close()
+			if sdkErr, ok := clauderrs.AsSDKError(err); ok && sdkErr.Code() == clauderrs.ErrCodeIncompatibleCLI {
+				return err
+			}
+
+			return clauderrs.NewProtocolError(clauderrs.ErrCodeProtocolError, "failed to register hooks", err).
+				WithSessionID(q.sessionID).
+				WithMessageType("initialize")
+		}
+	}
 
 	// Send initial prompt
 	if prompt != "" {
 		if err := q.SendUserMessage(context.Background(), prompt); err != nil {
 			_ = q.Close()
+			if clauderrs.IsValidationError(err) {
+				return err
+			}
+			// A CLI that exited before reading its input is a transport
+			// failure, which callers may retry, not a protocol violation
+			if errors.Is(err, transport.ErrWriteFailed) {
+				transportErr := clauderrs.NewTransportError(clauderrs.ErrCodeWriteFailed, "failed to send initial prompt", err)
+				_ = transportErr.WithMetadata(clauderrs.MetadataKeySessionID, q.sessionID)
+
+				return transportErr
+			}
 
 			return clauderrs.NewProtocolError(clauderrs.ErrCodeProtocolError, "failed to send initial prompt", err).
 				WithSessionID(q.sessionID).
@@ -199,6 +369,21 @@ func (q *queryImpl) buildArgs() []string {
 		args = append(args, "--model", q.opts.Model)
 	}
 
+	if q.opts.MaxTurns > 0 {
+		args = append(args, "--max-turns", strconv.Itoa(q.opts.MaxTurns))
+	}
+
+	if q.opts.MaxThinkingTokens > 0 {
+		args = append(args, "--max-thinking-tokens", strconv.Itoa(q.opts.MaxThinkingTokens))
+	}
+
+	// Request structured output matching the schema
+	if q.opts.OutputFormat != nil && len(q.opts.OutputFormat.Schema) > 0 {
+		if data, err := json.Marshal(q.opts.OutputFormat.Schema); err == nil {
+			args = append(args, "--json-schema", string(data))
+		}
+	}
+
 	if q.opts.Continue {
 		args = append(args, "--continue")
 	}
@@ -207,10 +392,21 @@ func (q *queryImpl) buildArgs() []string {
 		args = append(args, "--resume", q.opts.Resume)
 	}
 
+	if q.opts.ForkSession {
+		args = append(args, "--fork-session")
+	}
+
 	if q.opts.PermissionMode != "" {
 		args = append(args, "--permission-mode", string(q.opts.PermissionMode))
 	}
 
+	// Route permission prompts to CanUseTool over the control protocol
+	if q.opts.CanUseTool != nil || q.opts.AskPolicy != nil {
+		args = append(args, "--permission-prompt-tool", "stdio")
+	} else if q.opts.PermissionPromptToolName != "" {
+		args = append(args, "--permission-prompt-tool", q.opts.PermissionPromptToolName)
+	}
+
 	// Add additional directories
 	for _, dir := range q.opts.AdditionalDirectories {
 		args = append(args, "--add-dir", dir)
@@ -220,14 +416,45 @@ func (q *queryImpl) buildArgs() []string {
 	for _, tool := range q.opts.AllowedTools {
 		args = append(args, "--allowed-tools", tool)
 	}
+	if q.pager != nil {
+		args = append(args, "--allowed-tools", ReadMoreToolName)
+	}
+	if q.memory != nil {
+		for _, tool := range memoryToolNames() {
+			args = append(args, "--allowed-tools", tool)
+		}
+	}
 
 	// Add disallowed tools
 	for _, tool := range q.opts.DisallowedTools {
 		args = append(args, "--disallowed-tools", tool)
 	}
 
+	// Add custom subagent definitions
+	if len(q.opts.Agents) > 0 {
+		if data, err := json.Marshal(q.opts.Agents); err == nil {
+			args = append(args, "--agents", string(data))
+		}
+	}
+
+	if servers := q.cliMcpServers(); len(servers) > 0 {
+		if config, err := mcpConfigArg(servers); err == nil {
+			args = append(args, "--mcp-config", config)
+		}
+	}
+	if q.opts.StrictMcpConfig {
+		args = append(args, "--strict-mcp-config")
+	}
+
+	for _, dir := range q.pluginDirs {
+		args = append(args, "--plugin-dir", dir)
+	}
+	if q.skillsDir != "" {
+		args = append(args, "--plugin-dir", q.skillsDir)
+	}
+
 	// Add include partial messages flag for streaming
-	if q.opts.IncludePartialMessages {
+	if q.opts.IncludePartialMessages || q.opts.StreamEventFilter != nil {
 		args = append(args, "--include-partial-messages")
 	}
 
@@ -242,48 +469,135 @@ func (q *queryImpl) buildEnv() []string {
 		env = append(env, fmt.Sprintf("%s=%s", key, value))
 	}
 
-	return env
+	// The CLI has no flag for the output cap
+	if q.opts.MaxOutputTokens > 0 {
+		env = append(env, maxOutputTokensEnv+"="+strconv.Itoa(q.opts.MaxOutputTokens))
+	}
+	if q.opts.EnableFileCheckpointing {
+		env = append(env, fileCheckpointingEnv+"=true")
+	}
+
+	env = append(env, q.providerEnv...)
+
+	return append(env, q.egress.env()...)
 }
 
 // readMessages reads messages from the process.
 func (q *queryImpl) readMessages() {
 	defer close(q.msgChan)
+	defer q.activity.reader.stop()
 
 	for {
 		select {
 		case <-q.closeChan:
 			return
 		default:
-			msg, err := q.readMessage()
+			msg, trace, err := q.readMessage()
 			if err != nil {
 				q.handleReadError(err)
 
 				return
 			}
+			q.activity.reader.touch()
+
+			if status, ok := msg.(*SDKAuthStatusMessage); ok && q.auth != nil {
+				q.profiler.emit(trace)
+				q.auth.deliver(status)
 
-			if msg != nil {
-				q.msgChan <- msg
+				continue
+			}
+			if msg == nil {
+				q.profiler.emit(trace)
+
+				continue
+			}
+			q.agents.observe(msg)
+			q.applyToolOverrides(msg)
+			q.limitToolResults(msg)
+			q.reportToolEnds(msg)
+			q.profiler.queue(trace)
+			if !q.deliver(msg) {
+				return
 			}
 		}
 	}
 }
 
+// deliver hands msg to Next, reporting false if the query closed first.
+func (q *queryImpl) deliver(msg SDKMessage) bool {
+	done := q.activity.reader.begin()
+	defer done()
+
+	select {
+	case q.msgChan <- msg:
+		return true
+	case <-q.closeChan:
+		return false
+	}
+}
+
 // handleReadError handles errors during message reading.
 func (q *queryImpl) handleReadError(err error) {
+	// A failed query reports its own error
+	if q.failed() != nil {
+		return
+	}
 	if err == io.EOF {
+		if limitErr := q.limitExceededError(); limitErr != nil {
+			q.errChan <- limitErr
+		}
+
 		return
 	}
 
-	q.errChan <- err
+	q.errChan <- q.redactor.error(err)
 }
 
-// readMessage reads a single message from the process.
-func (q *queryImpl) readMessage() (SDKMessage, error) {
+// limitExceededError returns a ProcessError if the process, which closed
+// its output, was killed for exceeding its ProcessLimits.
+func (q *queryImpl) limitExceededError() error {
+	if q.opts.ProcessLimits == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), limitExitTimeout)
+	defer cancel()
+	_ = q.proc.Wait(ctx)
+	if !q.proc.LimitExceeded() {
+		return nil
+	}
+
+	return clauderrs.NewProcessError(
+		clauderrs.ErrCodeResourceLimitExceeded,
+		"Claude Code process was killed for exceeding its resource limits",
+		nil,
+		-1,
+		"",
+	).
+		WithSessionID(q.sessionID)
+}
+
+// readMessage reads a single message from the process, with its trace if
+// the transport is profiled.
+func (q *queryImpl) readMessage() (SDKMessage, MessageTrace, error) {
 	data, err := q.proc.Transport().Read(context.Background())
 	if err != nil {
-		return nil, err
+		return nil, MessageTrace{}, q.wrapReadError(err)
 	}
+	q.tee.Load().record(TeeInbound, data)
+
+	if q.profiler == nil {
+		msg, err := q.decodeFrame(data)
 
+		return msg, MessageTrace{}, err
+	}
+
+	return q.profiler.decode(data, q.decodeFrame)
+}
+
+// decodeFrame parses a frame read from the process, routing control
+// messages. It returns a nil message for frames not delivered to Next.
+func (q *queryImpl) decodeFrame(data []byte) (SDKMessage, error) {
 	// Parse the message type first
 	var envelope struct {
 		Type string `json:"type"`
@@ -333,87 +647,114 @@ func (q *queryImpl) readMessage() (SDKMessage, error) {
 		return nil, nil // Control requests don't go to the message stream
 	}
 
-	// Decode based on type
-	switch envelope.Type {
-	case "user":
-		var msg SDKUserMessage
-		if err := json.Unmarshal(data, &msg); err != nil {
-			return nil, clauderrs.NewProtocolError(
-				clauderrs.ErrCodeMessageParseFailed,
-				"failed to parse user message",
-				err,
-			).
-				WithSessionID(q.sessionID).
-				WithMessageType("user")
-		}
-
-		return &msg, nil
+	// Remember where the CLI got its API key, for AccountInfo, and the
+	// model and permission mode it settled on
+	if envelope.Type == "system" {
+		q.recordInit(data)
+	}
 
-	case "assistant":
-		var msg SDKAssistantMessage
-		if err := json.Unmarshal(data, &msg); err != nil {
-			return nil, clauderrs.NewProtocolError(
-				clauderrs.ErrCodeMessageParseFailed,
-				"failed to parse assistant message",
-				err,
-			).
-				WithSessionID(q.sessionID).
-				WithMessageType("assistant")
+	// Check the order of every message, including filtered events
+	if q.ordering != nil {
+		deliver, orderErr := q.ordering.check(envelope.Type, data)
+		if orderErr != nil {
+			return nil, orderErr.WithSessionID(q.sessionID)
+		}
+		if !deliver {
+			return nil, nil
 		}
+	}
 
-		return &msg, nil
+	// Drop filtered partial events before decoding them
+	if envelope.Type == "stream_event" && !q.opts.StreamEventFilter.allows(data) {
+		return nil, nil
+	}
 
-	case "stream_event":
-		var msg SDKStreamEvent
-		if err := json.Unmarshal(data, &msg); err != nil {
-			return nil, clauderrs.NewProtocolError(
-				clauderrs.ErrCodeMessageParseFailed,
-				"failed to parse stream event",
-				err,
-			).
-				WithSessionID(q.sessionID).
-				WithMessageType("stream_event")
-		}
+	msg, decodeErr := decodeMessage(envelope.Type, data)
+	if decodeErr != nil {
+		return nil, explainUnknownType(decodeErr.WithSessionID(q.sessionID))
+	}
 
-		return &msg, nil
+	return msg, nil
+}
 
-	case "system":
-		var msg SDKSystemMessage
-		if err := json.Unmarshal(data, &msg); err != nil {
-			return nil, clauderrs.NewProtocolError(
-				clauderrs.ErrCodeMessageParseFailed,
-				"failed to parse system message",
-				err,
-			).
-				WithSessionID(q.sessionID).
-				WithMessageType("system")
-		}
+// recordInit stores the apiKeySource, model and permission mode of an init
+// system message.
+func (q *queryImpl) recordInit(data []byte) {
+	var init struct {
+		Subtype        string         `json:"subtype"`
+		APIKeySource   string         `json:"apiKeySource"`
+		Model          string         `json:"model"`
+		PermissionMode PermissionMode `json:"permissionMode"`
+	}
+	if json.Unmarshal(data, &init) != nil || init.Subtype != "init" {
+		return
+	}
+	if init.APIKeySource != "" {
+		q.apiKeySource.Store(&init.APIKeySource)
+	}
+	if init.Model != "" {
+		q.setModel(init.Model)
+	}
+	if init.PermissionMode != "" {
+		q.setPermissionMode(init.PermissionMode)
+	}
+}
 
-		return &msg, nil
+// Model returns the session's model: the one the CLI reported starting
+// with, or Options.Model before that, as changed by SetModel since. It is
+// empty while the CLI's default model is in use and not yet reported.
+func (q *queryImpl) Model() string {
+	return *q.model.Load()
+}
 
-	case "result":
-		var msg SDKResultMessage
-		if err := json.Unmarshal(data, &msg); err != nil {
-			return nil, clauderrs.NewProtocolError(
-				clauderrs.ErrCodeMessageParseFailed,
-				"failed to parse result message",
-				err,
-			).
-				WithSessionID(q.sessionID).
-				WithMessageType("result")
-		}
+// setModel records the session's model.
+func (q *queryImpl) setModel(model string) {
+	q.model.Store(&model)
+}
 
-		return &msg, nil
+// PermissionMode returns the session's permission mode: the one the CLI
+// reported starting with, or Options.PermissionMode before that, as changed
+// by SetPermissionMode and accepted setMode permission updates since.
+func (q *queryImpl) PermissionMode() PermissionMode {
+	return *q.permissionMode.Load()
+}
 
-	default:
-		return nil, clauderrs.NewProtocolError(
-			clauderrs.ErrCodeUnknownMessageType,
-			fmt.Sprintf("unknown message type: %s", envelope.Type),
-			nil,
+// setPermissionMode records the session's permission mode, with empty
+// meaning the default mode.
+func (q *queryImpl) setPermissionMode(mode PermissionMode) {
+	if mode == "" {
+		mode = PermissionModeDefault
+	}
+	q.permissionMode.Store(&mode)
+}
+
+// wrapReadError maps framing failures from the transport to typed SDK
+// errors. Other errors, including io.EOF, are returned unchanged.
+func (q *queryImpl) wrapReadError(err error) error {
+	var code clauderrs.ErrorCode
+	var message string
+
+	switch {
+	case errors.Is(err, transport.ErrMessageTooLarge):
+		code = clauderrs.ErrCodeMessageTooLarge
+		message = "message from Claude Code exceeds MaxMessageSize"
+	case errors.Is(err, transport.ErrInjectedExit):
+		return clauderrs.NewProcessError(
+			clauderrs.ErrCodeProcessCrashed,
+			"Claude Code process exited mid-stream",
+			err,
+			-1,
+			"",
 		).
-			WithSessionID(q.sessionID).
-			WithMessageType(envelope.Type)
+			WithSessionID(q.sessionID)
+	default:
+		return err
 	}
+
+	transportErr := clauderrs.NewTransportError(code, message, err)
+	_ = transportErr.WithMetadata(clauderrs.MetadataKeySessionID, q.sessionID)
+
+	return transportErr
 }
 
 // SendUserMessage sends a text user message to the process.
@@ -428,9 +769,14 @@ func (q *queryImpl) SendUserMessage(ctx context.Context, text string) error {
 
 // SendUserMessageWithContent sends a user message with structured content blocks.
 func (q *queryImpl) SendUserMessageWithContent(ctx context.Context, content []ContentBlock) error {
+	if err := validateUserContent(content); err != nil {
+		return err
+	}
+
+	id := q.opts.newUUID()
 	msg := SDKUserMessage{
 		BaseMessage: BaseMessage{
-			UUIDField:      uuid.New(),
+			UUIDField:      id,
 			SessionIDField: q.sessionID,
 		},
 		TypeField: "user",
@@ -446,8 +792,37 @@ func (q *queryImpl) SendUserMessageWithContent(ctx context.Context, content []Co
 			WithSessionID(q.sessionID).
 			WithMessageType("user")
 	}
+	if err := checkOutboundSize(q.opts, data, "message"); err != nil {
+		return err
+	}
+
+	if err := q.write(ctx, data); err != nil {
+		return err
+	}
+	// The CLI checkpoints files under the message's UUID
+	if q.opts.EnableFileCheckpointing {
+		q.fileCheckpoints.add(id.String(), content, q.opts.now())
+	}
 
-	return q.proc.Transport().Write(ctx, data)
+	return nil
+}
+
+// write sends a frame to the CLI and mirrors it to the tee. Frames are
+// mirrored before they are written so a reply is never recorded ahead of
+// the request it answers.
+func (q *queryImpl) write(ctx context.Context, data []byte) error {
+	done := q.activity.writer.begin()
+	defer done()
+	q.tee.Load().record(TeeOutbound, data)
+
+	var err error
+	q.profiler.do("writer", func() {
+		err = q.profiler.write(data, func() error {
+			return q.proc.Transport().Write(ctx, data)
+		})
+	})
+
+	return err
 }
 
 // Next returns the next message from the query.
@@ -455,15 +830,43 @@ func (q *queryImpl) Next(ctx context.Context) (SDKMessage, error) {
 	select {
 	case msg, ok := <-q.msgChan:
 		if !ok {
+			if err := q.failed(); err != nil {
+				return nil, err
+			}
+			// readMessages reports its error before closing msgChan
+			select {
+			case err := <-q.errChan:
+				return nil, err
+			default:
+			}
+
 			return nil, io.EOF
 		}
+		q.profiler.dequeue()
 
 		return msg, nil
 	case err := <-q.errChan:
+		// Messages read before the error are delivered first; readMessages
+		// reports a single error, so it can be put back for a later call
+		select {
+		case msg, ok := <-q.msgChan:
+			if ok {
+				q.errChan <- err
+				q.profiler.dequeue()
+
+				return msg, nil
+			}
+		default:
+		}
+
 		return nil, err
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	case <-q.closeChan:
+		if err := q.failed(); err != nil {
+			return nil, err
+		}
+
 		return nil, io.EOF
 	}
 }
@@ -480,6 +883,11 @@ func (q *queryImpl) Close() error {
 	q.closed = true
 	close(q.closeChan)
 	close(q.controlRequestChan)
+	q.callbacks.close()
+	q.activity.writer.stop()
+	q.removeSkillsDir()
+	q.egress.close()
+	q.stopMcpSupervisors()
 
 	if q.proc != nil {
 		return q.proc.Close()
@@ -491,13 +899,36 @@ func (q *queryImpl) Close() error {
 // controlRequestEnvelope represents the envelope for control request messages.
 type controlRequestEnvelope struct {
 	Request struct {
-		Subtype string `json:"subtype"`
+		Subtype    string          `json:"subtype"`
+		ServerName string          `json:"server_name,omitempty"`
+		Input      json.RawMessage `json:"input,omitempty"`
 	} `json:"request"`
 	RequestID string `json:"request_id"`
 }
 
+// event names the callbacks a control request runs in order with, for
+// Options.CallbackWorkers: those of one hook event, permission checks, or
+// the messages of one MCP server.
+func (e *controlRequestEnvelope) event() string {
+	switch e.Request.Subtype {
+	case "hook_callback":
+		var input struct {
+			HookEventName string `json:"hook_event_name"`
+		}
+		_ = json.Unmarshal(e.Request.Input, &input)
+
+		return "hook:" + input.HookEventName
+	case ControlRequestSubtypeMcpMessage:
+		return "mcp:" + e.Request.ServerName
+	default:
+		return e.Request.Subtype
+	}
+}
+
 // handleControlRequests processes incoming control requests from the CLI.
 func (q *queryImpl) handleControlRequests() {
+	defer q.activity.dispatcher.stop()
+
 	for {
 		select {
 		case <-q.closeChan:
@@ -511,12 +942,19 @@ func (q *queryImpl) handleControlRequests() {
 			}
 
 			// Handle the request in the background to avoid blocking
-			go q.handleControlRequest(
-				context.Background(),
-				data,
-				envelope.RequestID,
-				envelope.Request.Subtype,
-			)
+			handle := func() {
+				q.handleControlRequest(
+					context.Background(),
+					data,
+					envelope.RequestID,
+					envelope.Request.Subtype,
+				)
+			}
+			if q.callbacks != nil {
+				q.callbacks.submit(envelope.event(), handle)
+			} else {
+				go handle()
+			}
 		}
 	}
 }
@@ -527,6 +965,9 @@ func (q *queryImpl) handleControlRequest(
 	data json.RawMessage,
 	requestID, subtype string,
 ) {
+	done := q.activity.dispatcher.begin()
+	defer done()
+
 	var responseData map[string]any
 	var err error
 
@@ -535,15 +976,8 @@ func (q *queryImpl) handleControlRequest(
 		responseData, err = q.handleCanUseTool(ctx, data)
 	case "hook_callback":
 		responseData, err = q.handleHookCallback(ctx, data)
-	case "mcp_message":
-		// TODO: Handle SDK MCP requests when MCP servers are implemented
-		err = clauderrs.NewProtocolError(
-			clauderrs.ErrCodeProtocolError,
-			"mcp_message handling not yet implemented",
-			nil,
-		).
-			WithSessionID(q.sessionID).
-			WithMessageType("control_request")
+	case ControlRequestSubtypeMcpMessage:
+		responseData, err = q.handleMcpMessage(ctx, data)
 	default:
 		err = clauderrs.NewProtocolError(
 			clauderrs.ErrCodeProtocolError,
@@ -569,7 +1003,7 @@ func (q *queryImpl) handleCanUseTool(
 	data json.RawMessage,
 ) (map[string]any, error) {
 	var req SDKControlPermissionRequest
-	if err := json.Unmarshal(data, &req); err != nil {
+	if err := json.Unmarshal(controlRequestBody(data), &req); err != nil {
 		return nil, clauderrs.NewProtocolError(
 			clauderrs.ErrCodeMessageParseFailed,
 			"failed to parse permission request",
@@ -579,8 +1013,26 @@ func (q *queryImpl) handleCanUseTool(
 			WithMessageType("control_request")
 	}
 
+	// Subagent restrictions apply before session rules and the callback
+	agentID, chain := q.effectiveAgentID(&req)
+	if reason, ok := q.checkAgentPolicy(req.ToolName, chain); !ok {
+		return map[string]any{"allow": false, "reason": reason}, nil
+	}
+
+	// Session rules answer before the callback is consulted
+	if behavior, ok := q.permissions.decide(req.ToolName, req.Input); ok {
+		if behavior == PermissionBehaviorDeny {
+			return map[string]any{
+				"allow":  false,
+				"reason": fmt.Sprintf("tool '%s' denied by session permission rule", req.ToolName),
+			}, nil
+		}
+
+		return map[string]any{"allow": true}, nil
+	}
+
 	// Check if canUseTool callback is provided
-	if q.opts.CanUseTool == nil {
+	if q.opts.CanUseTool == nil && q.opts.AskPolicy == nil {
 		return nil, clauderrs.NewCallbackError(
 			clauderrs.ErrCodeCallbackFailed,
 			"canUseTool callback is not provided",
@@ -598,45 +1050,63 @@ func (q *queryImpl) handleCanUseTool(
 	}
 
 	// Parse permission suggestions
-	var suggestions []PermissionUpdate
-	// TODO: Parse permission suggestions when needed
+	suggestions := decodePermissionSuggestions(req.PermissionSuggestions)
 
 	// Call the user's callback with the new parameters
-	result, err := q.opts.CanUseTool(
-		ctx,
-		req.ToolName,
-		inputMap,
-		suggestions,
-		req.ToolUseID,
-		req.AgentID,
-		req.BlockedPath,
-		req.DecisionReason,
-	)
-	if err != nil {
-		return nil, clauderrs.NewCallbackError(
-			clauderrs.ErrCodeCallbackFailed,
-			fmt.Sprintf("canUseTool failed for tool '%s'", req.ToolName),
-			err,
-			"canUseTool",
-			false,
-		).
-			WithSessionID(q.sessionID)
+	var result PermissionResult = &PermissionAsk{Behavior: PermissionBehaviorAsk}
+	if q.opts.CanUseTool != nil {
+		var err error
+		result, err = q.opts.CanUseTool(
+			ctx,
+			req.ToolName,
+			inputMap,
+			suggestions,
+			req.ToolUseID,
+			agentID,
+			req.BlockedPath,
+			req.DecisionReason,
+		)
+		if err != nil {
+			return nil, clauderrs.NewCallbackError(
+				clauderrs.ErrCodeCallbackFailed,
+				fmt.Sprintf("canUseTool failed for tool '%s'", req.ToolName),
+				err,
+				"canUseTool",
+				false,
+			).
+				WithSessionID(q.sessionID)
+		}
+	}
+
+	// Escalate "ask" decisions to the AskPolicy delegate
+	var ask *PermissionAsk
+	switch r := result.(type) {
+	case *PermissionAsk:
+		ask = r
+	case PermissionAsk:
+		ask = &r
+	}
+	if ask != nil {
+		if q.opts.AskPolicy == nil {
+			return nil, clauderrs.NewCallbackError(
+				clauderrs.ErrCodeCallbackFailed,
+				fmt.Sprintf("canUseTool asked about tool '%s' but no AskPolicy is configured", req.ToolName),
+				nil,
+				"canUseTool",
+				false,
+			).
+				WithSessionID(q.sessionID)
+		}
+		result = q.ask(ctx, &req, agentID, suggestions, ask.Message)
 	}
 
 	// Convert PermissionResult to response format
 	responseData := make(map[string]any)
 	switch r := result.(type) {
 	case *PermissionAllow:
-		responseData["allow"] = true
-		if r.UpdatedInput != nil {
-			responseData["input"] = r.UpdatedInput
-		}
-		// TODO: Handle updatedPermissions when control protocol supports it
+		q.allowResponse(responseData, r, suggestions)
 	case PermissionAllow:
-		responseData["allow"] = true
-		if r.UpdatedInput != nil {
-			responseData["input"] = r.UpdatedInput
-		}
+		q.allowResponse(responseData, &r, suggestions)
 	case *PermissionDeny:
 		responseData["allow"] = false
 		responseData["reason"] = r.Message
@@ -652,13 +1122,59 @@ func (q *queryImpl) handleCanUseTool(
 	return responseData, nil
 }
 
+// allowResponse fills an allow response, applying any accepted permission
+// updates to the session and forwarding them to the CLI.
+func (q *queryImpl) allowResponse(
+	responseData map[string]any,
+	allow *PermissionAllow,
+	suggestions []PermissionUpdate,
+) {
+	responseData["allow"] = true
+	if allow.UpdatedInput != nil {
+		responseData["input"] = allow.UpdatedInput
+	}
+
+	updates := allow.UpdatedPermissions
+	if allow.ApplySuggestions {
+		updates = append(append([]PermissionUpdate(nil), updates...), suggestions...)
+	}
+	if len(updates) == 0 {
+		return
+	}
+
+	normalized := make([]PermissionUpdate, 0, len(updates))
+	for _, update := range updates {
+		q.permissions.apply(update)
+		update = normalizePermissionUpdate(update)
+		normalized = append(normalized, update)
+		// The CLI switches modes on setMode updates
+		if mode, ok := update.(SetModeUpdate); ok {
+			q.setPermissionMode(mode.Mode)
+		}
+	}
+	responseData["updatedPermissions"] = normalized
+}
+
+// controlRequestBody returns the request nested in a control_request
+// envelope, or data itself if it is not wrapped.
+func controlRequestBody(data json.RawMessage) json.RawMessage {
+	var envelope struct {
+		Request json.RawMessage `json:"request"`
+	}
+	if err := json.Unmarshal(data, &envelope); err == nil && len(envelope.Request) > 0 {
+		return envelope.Request
+	}
+
+	return data
+}
+
 // handleHookCallback processes hook_callback control requests.
 func (q *queryImpl) handleHookCallback(
 	ctx context.Context,
 	data json.RawMessage,
 ) (map[string]any, error) {
 	var req SDKHookCallbackRequest
-	if err := json.Unmarshal(data, &req); err != nil {
+	if err := json.Unmarshal(controlRequestBody(data), &req); err != nil {
 		return nil, clauderrs.NewProtocolError(
 			clauderrs.ErrCodeMessageParseFailed,
 			"failed to parse hook callback request",
@@ -693,6 +1209,8 @@ func (q *queryImpl) handleHookCallback(
 	}
 
 	// Call the hook callback
+	ctx, asyncID := q.asyncHooks.register(ctx)
+	defer q.asyncHooks.remove(asyncID)
 	output, err := callback(ctx, hookInput, req.ToolUseID)
 	if err != nil {
 		toolUseID := ""
@@ -710,6 +1228,33 @@ func (q *queryImpl) handleHookCallback(
 			WithSessionID(q.sessionID)
 	}
 
+	// Wait for the real output of hooks that deferred their decision
+	if timeout, async := asyncHookTimeout(output); async {
+		output, err = q.asyncHooks.wait(ctx, asyncID, timeout, q.closeChan)
+		if err != nil {
+			return nil, clauderrs.NewCallbackError(
+				clauderrs.ErrCodeHookTimeout,
+				"async hook was not completed",
+				err,
+				req.CallbackID,
+				true,
+			).
+				WithSessionID(q.sessionID)
+		}
+	}
+
+	// Reject outputs the CLI can't act on consistently
+	if validationErr := validateHookOutput(output); validationErr != nil {
+		return nil, clauderrs.NewCallbackError(
+			clauderrs.ErrCodeHookFailed,
+			"hook returned an invalid output",
+			validationErr,
+			req.CallbackID,
+			false,
+		).
+			WithSessionID(q.sessionID)
+	}
+
 	// Convert hook output to response format
 	// The hook output should already be in the correct format (JSON-serializable)
 	// Marshal and unmarshal to convert to map[string]any
@@ -747,7 +1292,7 @@ func (q *queryImpl) sendControlResponse(
 ) error {
 	var response SDKControlResponse
 	response.BaseMessage = BaseMessage{
-		UUIDField:      uuid.New(),
+		UUIDField:      q.opts.newUUID(),
 		SessionIDField: q.sessionID,
 	}
 
@@ -791,7 +1336,7 @@ func (q *queryImpl) sendControlResponse(
 			WithMessageType("control_response")
 	}
 
-	return q.proc.Transport().Write(ctx, data)
+	return q.write(ctx, data)
 }
 
 // sendControlRequest sends a control request and waits for response.
@@ -799,13 +1344,17 @@ func (q *queryImpl) sendControlRequest(
 	ctx context.Context,
 	request ControlRequestVariant,
 ) (map[string]any, error) {
+	if err := validateControlRequest(request); err != nil {
+		return nil, err
+	}
+
 	// Generate unique request ID
 	q.mu.Lock()
 	q.requestCounter++
 	counter := q.requestCounter
 	q.mu.Unlock()
 
-	requestID := fmt.Sprintf(requestIDFormat, counter, uuid.New().String()[:8])
+	requestID := fmt.Sprintf(requestIDFormat, counter, q.opts.newUUID().String()[:8])
 
 	// Create channel for response
 	respChan := make(chan *SDKControlResponse, 1)
@@ -816,7 +1365,7 @@ func (q *queryImpl) sendControlRequest(
 	// Build and send request
 	controlReq := SDKControlRequest{
 		BaseMessage: BaseMessage{
-			UUIDField:      uuid.New(),
+			UUIDField:      q.opts.newUUID(),
 			SessionIDField: q.sessionID,
 		},
 		RequestID: requestID,
@@ -838,8 +1387,15 @@ func (q *queryImpl) sendControlRequest(
 			WithRequestID(requestID).
 			WithMessageType("control_request")
 	}
+	if err := checkOutboundSize(q.opts, data, "request"); err != nil {
+		q.mu.Lock()
+		delete(q.pendingControlResponses, requestID)
+		q.mu.Unlock()
 
-	if err := q.proc.Transport().Write(ctx, data); err != nil {
+		return nil, err
+	}
+
+	if err := q.write(ctx, data); err != nil {
 		q.mu.Lock()
 		delete(q.pendingControlResponses, requestID)
 		q.mu.Unlock()
@@ -864,10 +1420,12 @@ func (q *queryImpl) sendControlRequest(
 
 			return result, nil
 		case ControlErrorResponse:
-			return nil, clauderrs.NewProtocolError(clauderrs.ErrCodeProtocolError, fmt.Sprintf("control request failed: %s", r.Error), nil).
+			failure := clauderrs.NewProtocolError(clauderrs.ErrCodeProtocolError, fmt.Sprintf("control request failed: %s", q.redactor.text(r.Error)), nil).
 				WithSessionID(q.sessionID).
 				WithRequestID(requestID).
 				WithMessageType("control_response")
+
+			return nil, explainControlFailure(request, r.Error, failure)
 		default:
 			return nil, clauderrs.NewProtocolError(clauderrs.ErrCodeProtocolError, fmt.Sprintf("unexpected control response type: %T", r), nil).
 				WithSessionID(q.sessionID).
@@ -895,10 +1453,76 @@ func (q *queryImpl) SetPermissionMode(ctx context.Context, mode PermissionMode)
 	_, err := q.sendControlRequest(ctx, SDKControlSetPermissionModeRequest{
 		Mode: string(mode),
 	})
+	if err == nil {
+		q.setPermissionMode(mode)
+	}
 
 	return err
 }
 
+// UpdatePermissions applies permission updates for the rest of the session.
+//
+// Rule updates targeting PermissionDestinationSession are enforced by the
+// SDK: matching can_use_tool requests are answered without invoking
+// CanUseTool. SetModeUpdate changes the permission mode through the control
+// protocol. Other updates cannot be applied at runtime and are rejected
+// with a ValidationError before any update is applied.
+func (q *queryImpl) UpdatePermissions(
+	ctx context.Context,
+	updates []PermissionUpdate,
+) error {
+	for i, update := range updates {
+		switch u := normalizePermissionUpdate(update).(type) {
+		case SetModeUpdate:
+		case AddRulesUpdate, ReplaceRulesUpdate, RemoveRulesUpdate:
+			if destination := updateDestination(u); destination != PermissionDestinationSession {
+				return clauderrs.NewValidationError(
+					clauderrs.ErrCodeInvalidFormat,
+					fmt.Sprintf("permission update %d: only session rules can be applied at runtime", i),
+					nil,
+					"Destination",
+					destination,
+				)
+			}
+		default:
+			return clauderrs.NewValidationError(
+				clauderrs.ErrCodeInvalidType,
+				fmt.Sprintf("permission update %d: %T cannot be applied at runtime", i, update),
+				nil,
+				"updates",
+				update,
+			)
+		}
+	}
+
+	for _, update := range updates {
+		if mode, ok := normalizePermissionUpdate(update).(SetModeUpdate); ok {
+			if err := q.SetPermissionMode(ctx, mode.Mode); err != nil {
+				return err
+			}
+
+			continue
+		}
+		q.permissions.apply(update)
+	}
+
+	return nil
+}
+
+// updateDestination returns the destination of a normalized rule update.
+func updateDestination(update PermissionUpdate) PermissionUpdateDestination {
+	switch u := update.(type) {
+	case AddRulesUpdate:
+		return u.Destination
+	case ReplaceRulesUpdate:
+		return u.Destination
+	case RemoveRulesUpdate:
+		return u.Destination
+	default:
+		return ""
+	}
+}
+
 // SetModel changes the model.
 func (q *queryImpl) SetModel(ctx context.Context, model *string) error {
 	// Create a request with the model field
@@ -914,7 +1538,7 @@ func (q *queryImpl) SetModel(ctx context.Context, model *string) error {
 	counter := q.requestCounter
 	q.mu.Unlock()
 
-	requestID := fmt.Sprintf(requestIDFormat, counter, uuid.New().String()[:8])
+	requestID := fmt.Sprintf(requestIDFormat, counter, q.opts.newUUID().String()[:8])
 
 	respChan := make(chan *SDKControlResponse, 1)
 	q.mu.Lock()
@@ -923,7 +1547,7 @@ func (q *queryImpl) SetModel(ctx context.Context, model *string) error {
 
 	controlReq := map[string]any{
 		fieldType:      messageTypeControlRequest,
-		fieldUUID:      uuid.New().String(),
+		fieldUUID:      q.opts.newUUID().String(),
 		fieldSessionID: q.sessionID,
 		fieldRequestID: requestID,
 		fieldRequest:   request,
@@ -945,7 +1569,7 @@ func (q *queryImpl) SetModel(ctx context.Context, model *string) error {
 			WithMessageType("control_request")
 	}
 
-	if err := q.proc.Transport().Write(ctx, data); err != nil {
+	if err := q.write(ctx, data); err != nil {
 		q.mu.Lock()
 		delete(q.pendingControlResponses, requestID)
 		q.mu.Unlock()
@@ -960,6 +1584,12 @@ func (q *queryImpl) SetModel(ctx context.Context, model *string) error {
 	case resp := <-respChan:
 		switch r := resp.Response.(type) {
 		case ControlSuccessResponse:
+			if model != nil {
+				q.setModel(*model)
+			} else {
+				q.setModel("")
+			}
+
 			return nil
 		case ControlErrorResponse:
 			return clauderrs.NewProtocolError(clauderrs.ErrCodeProtocolError, fmt.Sprintf("SetModel request failed: %s", r.Error), nil).
@@ -995,7 +1625,7 @@ func (q *queryImpl) SetMaxThinkingTokens(maxThinkingTokens *int) error {
 	counter := q.requestCounter
 	q.mu.Unlock()
 
-	requestID := fmt.Sprintf(requestIDFormat, counter, uuid.New().String()[:8])
+	requestID := fmt.Sprintf(requestIDFormat, counter, q.opts.newUUID().String()[:8])
 
 	respChan := make(chan *SDKControlResponse, 1)
 	q.mu.Lock()
@@ -1004,7 +1634,7 @@ func (q *queryImpl) SetMaxThinkingTokens(maxThinkingTokens *int) error {
 
 	controlReq := map[string]any{
 		fieldType:      messageTypeControlRequest,
-		fieldUUID:      uuid.New().String(),
+		fieldUUID:      q.opts.newUUID().String(),
 		fieldSessionID: q.sessionID,
 		fieldRequestID: requestID,
 		fieldRequest:   request,
@@ -1027,7 +1657,7 @@ func (q *queryImpl) SetMaxThinkingTokens(maxThinkingTokens *int) error {
 	}
 
 	ctx := context.Background()
-	if err := q.proc.Transport().Write(ctx, data); err != nil {
+	if err := q.write(ctx, data); err != nil {
 		q.mu.Lock()
 		delete(q.pendingControlResponses, requestID)
 		q.mu.Unlock()
@@ -1067,13 +1697,65 @@ func (q *queryImpl) SetMaxThinkingTokens(maxThinkingTokens *int) error {
 // Returns *AccountInfo struct with optional fields for account details.
 // The context can be used to cancel the operation.
 // Returns an error if the query is closed or if the request fails.
+//
+// The account is read from the initialize response, sending initialize
+// first if no hooks did, and requested separately from CLIs that don't
+// include it there. A missing ApiKeySource is taken from the init message.
 func (q *queryImpl) AccountInfo(ctx context.Context) (*AccountInfo, error) {
+	q.mu.Lock()
+	initialized := q.initializationResult != nil
+	q.mu.Unlock()
+	if !initialized {
+		if _, err := q.Initialize(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	q.mu.Lock()
+	account, ok := q.initializationResult["account"]
+	q.mu.Unlock()
+
+	var info *AccountInfo
+	if ok {
+		data, err := json.Marshal(account)
+		if err == nil {
+			err = json.Unmarshal(data, &info)
+		}
+		if err != nil {
+			return nil, clauderrs.NewProtocolError(
+				clauderrs.ErrCodeMessageParseFailed,
+				"failed to parse account info from initialize response",
+				err,
+			).
+				WithSessionID(q.sessionID).
+				WithMessageType("control_response")
+		}
+	} else {
+		var err error
+		if info, err = q.requestAccountInfo(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if info == nil {
+		info = &AccountInfo{}
+	}
+	if source := q.apiKeySource.Load(); info.ApiKeySource == nil && source != nil {
+		info.ApiKeySource = source
+	}
+
+	return info, nil
+}
+
+// requestAccountInfo asks the CLI for account information with an
+// accountInfo control request.
+func (q *queryImpl) requestAccountInfo(ctx context.Context) (*AccountInfo, error) {
 	q.mu.Lock()
 	q.requestCounter++
 	counter := q.requestCounter
 	q.mu.Unlock()
 
-	requestID := fmt.Sprintf(requestIDFormat, counter, uuid.New().String()[:8])
+	requestID := fmt.Sprintf(requestIDFormat, counter, q.opts.newUUID().String()[:8])
 
 	respChan := make(chan *SDKControlResponse, 1)
 	q.mu.Lock()
@@ -1082,7 +1764,7 @@ func (q *queryImpl) AccountInfo(ctx context.Context) (*AccountInfo, error) {
 
 	controlReq := map[string]any{
 		fieldType:      messageTypeControlRequest,
-		fieldUUID:      uuid.New().String(),
+		fieldUUID:      q.opts.newUUID().String(),
 		fieldSessionID: q.sessionID,
 		fieldRequestID: requestID,
 		"request": map[string]any{
@@ -1106,7 +1788,7 @@ func (q *queryImpl) AccountInfo(ctx context.Context) (*AccountInfo, error) {
 			WithMessageType("control_request")
 	}
 
-	if err := q.proc.Transport().Write(ctx, data); err != nil {
+	if err := q.write(ctx, data); err != nil {
 		q.mu.Lock()
 		delete(q.pendingControlResponses, requestID)
 		q.mu.Unlock()
@@ -1176,7 +1858,7 @@ func (q *queryImpl) SupportedCommands(ctx context.Context) ([]SlashCommand, erro
 	counter := q.requestCounter
 	q.mu.Unlock()
 
-	requestID := fmt.Sprintf(requestIDFormat, counter, uuid.New().String()[:8])
+	requestID := fmt.Sprintf(requestIDFormat, counter, q.opts.newUUID().String()[:8])
 
 	respChan := make(chan *SDKControlResponse, 1)
 	q.mu.Lock()
@@ -1185,7 +1867,7 @@ func (q *queryImpl) SupportedCommands(ctx context.Context) ([]SlashCommand, erro
 
 	controlReq := map[string]any{
 		fieldType:      messageTypeControlRequest,
-		fieldUUID:      uuid.New().String(),
+		fieldUUID:      q.opts.newUUID().String(),
 		fieldSessionID: q.sessionID,
 		fieldRequestID: requestID,
 		"request": map[string]any{
@@ -1209,7 +1891,7 @@ func (q *queryImpl) SupportedCommands(ctx context.Context) ([]SlashCommand, erro
 			WithMessageType("control_request")
 	}
 
-	if err := q.proc.Transport().Write(ctx, data); err != nil {
+	if err := q.write(ctx, data); err != nil {
 		q.mu.Lock()
 		delete(q.pendingControlResponses, requestID)
 		q.mu.Unlock()
@@ -1275,7 +1957,7 @@ func (q *queryImpl) SupportedModels(ctx context.Context) ([]ModelInfo, error) {
 	counter := q.requestCounter
 	q.mu.Unlock()
 
-	requestID := fmt.Sprintf(requestIDFormat, counter, uuid.New().String()[:8])
+	requestID := fmt.Sprintf(requestIDFormat, counter, q.opts.newUUID().String()[:8])
 
 	respChan := make(chan *SDKControlResponse, 1)
 	q.mu.Lock()
@@ -1284,7 +1966,7 @@ func (q *queryImpl) SupportedModels(ctx context.Context) ([]ModelInfo, error) {
 
 	controlReq := map[string]any{
 		fieldType:      messageTypeControlRequest,
-		fieldUUID:      uuid.New().String(),
+		fieldUUID:      q.opts.newUUID().String(),
 		fieldSessionID: q.sessionID,
 		fieldRequestID: requestID,
 		"request": map[string]any{
@@ -1308,7 +1990,7 @@ func (q *queryImpl) SupportedModels(ctx context.Context) ([]ModelInfo, error) {
 			WithMessageType("control_request")
 	}
 
-	if err := q.proc.Transport().Write(ctx, data); err != nil {
+	if err := q.write(ctx, data); err != nil {
 		q.mu.Lock()
 		delete(q.pendingControlResponses, requestID)
 		q.mu.Unlock()
@@ -1374,7 +2056,7 @@ func (q *queryImpl) McpServerStatus(ctx context.Context) ([]McpServerStatus, err
 	counter := q.requestCounter
 	q.mu.Unlock()
 
-	requestID := fmt.Sprintf(requestIDFormat, counter, uuid.New().String()[:8])
+	requestID := fmt.Sprintf(requestIDFormat, counter, q.opts.newUUID().String()[:8])
 
 	respChan := make(chan *SDKControlResponse, 1)
 	q.mu.Lock()
@@ -1383,7 +2065,7 @@ func (q *queryImpl) McpServerStatus(ctx context.Context) ([]McpServerStatus, err
 
 	controlReq := map[string]any{
 		fieldType:      messageTypeControlRequest,
-		fieldUUID:      uuid.New().String(),
+		fieldUUID:      q.opts.newUUID().String(),
 		fieldSessionID: q.sessionID,
 		fieldRequestID: requestID,
 		"request": map[string]any{
@@ -1407,7 +2089,7 @@ func (q *queryImpl) McpServerStatus(ctx context.Context) ([]McpServerStatus, err
 			WithMessageType("control_request")
 	}
 
-	if err := q.proc.Transport().Write(ctx, data); err != nil {
+	if err := q.write(ctx, data); err != nil {
 		q.mu.Lock()
 		delete(q.pendingControlResponses, requestID)
 		q.mu.Unlock()
@@ -1444,6 +2126,12 @@ func (q *queryImpl) McpServerStatus(ctx context.Context) ([]McpServerStatus, err
 					WithRequestID(requestID).
 					WithMessageType("control_response")
 			}
+			// The CLI only sees the SDK side of supervised servers
+			for i, server := range servers {
+				if supervisor, ok := q.mcpSupervisors[server.Name]; ok {
+					servers[i] = supervisor.report()
+				}
+			}
 
 			return servers, nil
 		case ControlErrorResponse:
@@ -1479,15 +2167,62 @@ func (q *queryImpl) GetServerInfo() (map[string]any, error) {
 	return q.initializationResult, nil
 }
 
+// hookMatchers returns opts.Hooks plus the hooks that enforce SDK-side
+// policies such as Options.WebPolicy.
+func (q *queryImpl) hookMatchers() map[HookEvent][]HookCallbackMatcher {
+	var policies []map[HookEvent][]HookCallbackMatcher
+	if q.opts.WebPolicy != nil {
+		policies = append(policies, q.opts.WebPolicy.hooks())
+	}
+	if q.toolBudget != nil {
+		policies = append(policies, q.toolBudgetHooks())
+	}
+	if q.opts.DecompressToolResults {
+		policies = append(policies, q.decompressionHooks())
+	} else if q.pager != nil {
+		policies = append(policies, q.pagingHooks())
+	} else if q.opts.MaxToolResultBytes > 0 {
+		policies = append(policies, q.toolResultLimitHooks())
+	}
+	if len(q.opts.ToolOverrides) > 0 {
+		policies = append(policies, q.toolOverrideHooks())
+	}
+	if skillHooks := q.skillHooks(); skillHooks != nil {
+		policies = append(policies, skillHooks)
+	}
+	if toolHooks := q.toolCallbackHooks(); toolHooks != nil {
+		policies = append(policies, toolHooks)
+	}
+	if len(policies) == 0 {
+		return q.opts.Hooks
+	}
+
+	hooks := make(map[HookEvent][]HookCallbackMatcher, len(q.opts.Hooks)+2)
+	for event, matchers := range q.opts.Hooks {
+		// SDK-side events are dispatched by the SDK's own hooks
+		if event != HookEventSkillInvoked {
+			hooks[event] = matchers
+		}
+	}
+	for i := len(policies) - 1; i >= 0; i-- {
+		for event, matchers := range policies[i] {
+			// Policy hooks run first so user hooks cannot pre-empt a denial
+			hooks[event] = append(matchers, hooks[event]...)
+		}
+	}
+
+	return hooks
+}
+
 // Initialize sends initialize control request and stores the response.
-// This should be called if bidirectional control protocol is needed.
+// It is sent automatically on start when hooks are configured.
 func (q *queryImpl) Initialize(ctx context.Context) (map[string]any, error) {
-	// Build hooks configuration from opts.Hooks
+	// Build hooks configuration from opts.Hooks and SDK policies
 	var hooksConfig map[string]JSONValue
-	if len(q.opts.Hooks) > 0 {
+	if hooks := q.hookMatchers(); len(hooks) > 0 {
 		hooksConfig = make(map[string]JSONValue)
 
-		for event, matchers := range q.opts.Hooks {
+		for event, matchers := range hooks {
 			if len(matchers) == 0 {
 				continue
 			}
@@ -1511,6 +2246,10 @@ func (q *queryImpl) Initialize(ctx context.Context) (map[string]any, error) {
 				if matcher.Matcher != nil {
 					matcherConfig["matcher"] = *matcher.Matcher
 				}
+				// The CLI takes the timeout in whole seconds
+				if matcher.Timeout != nil && *matcher.Timeout > 0 {
+					matcherConfig["timeout"] = (*matcher.Timeout + 999) / 1000
+				}
 				matcherConfigs = append(matcherConfigs, matcherConfig)
 			}
 
@@ -1530,7 +2269,8 @@ func (q *queryImpl) Initialize(ctx context.Context) (map[string]any, error) {
 	}
 
 	resp, err := q.sendControlRequest(ctx, SDKControlInitializeRequest{
-		Hooks: hooksConfig,
+		Hooks:           hooksConfig,
+		ProtocolVersion: ProtocolVersion,
 	})
 	if err != nil {
 		return nil, err
@@ -1545,5 +2285,5 @@ func (q *queryImpl) Initialize(ctx context.Context) (map[string]any, error) {
 
 // QueryFunc creates a new query session.
 func QueryFunc(prompt string, opts *Options) (Query, error) {
-	return newQueryImpl(prompt, opts)
+	return newQueryImpl(prompt, opts, nil, nil)
 }
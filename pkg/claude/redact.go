@@ -0,0 +1,176 @@
+package claude
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// Redaction configures how secrets are scrubbed from the errors a query
+// returns and the CLI stderr lines passed to Options.Stderr. The values of
+// environment variables with secret-looking names, such as
+// ANTHROPIC_API_KEY, credentials the SDK fetched or was given with
+// Authenticate, Authorization and API key headers, and Anthropic keys are
+// always replaced with "[redacted]".
+type Redaction struct {
+	// Patterns are additional regular expressions whose matches are
+	// redacted.
+	Patterns []string
+	// Disabled turns redaction off, for debugging in a trusted
+	// environment.
+	Disabled bool
+}
+
+// validate checks the patterns compile.
+func (r *Redaction) validate() []error {
+	var errs []error
+	for _, pattern := range r.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, clauderrs.NewValidationError(
+				clauderrs.ErrCodeInvalidFormat,
+				fmt.Sprintf("invalid redaction pattern %q", pattern),
+				err,
+				"Redaction.Patterns",
+				pattern,
+			))
+		}
+	}
+
+	return errs
+}
+
+// minSecretLength is the shortest environment value redacted, so flags
+// such as FOO_TOKEN_ENABLED=1 don't scrub every digit.
+const minSecretLength = 8
+
+// secretEnvNameParts mark the environment variables whose values are
+// secrets.
+var secretEnvNameParts = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "CREDENTIAL"}
+
+// secretHeaderPattern matches the values of authentication headers, in
+// header, JSON and key=value form.
+var secretHeaderPattern = regexp.MustCompile(
+	`(?i)((?:proxy-)?authorization|x-api-key)(["']?\s*[:=]\s*["']?)((?:bearer|basic)\s+)?[^\s"',;]+`,
+)
+
+// anthropicKeyPattern matches Anthropic API keys and OAuth tokens.
+var anthropicKeyPattern = regexp.MustCompile(`sk-ant-[A-Za-z0-9_\-]{8,}`)
+
+// redactor scrubs secrets from text. A nil redactor leaves text unchanged.
+type redactor struct {
+	mu       sync.RWMutex
+	values   []string // Secret values, longest first
+	patterns []*regexp.Regexp
+}
+
+// newRedactor creates the redactor for opts, knowing the secrets in the
+// SDK's environment and opts.Env. It returns nil if redaction is disabled.
+func newRedactor(opts *Options) *redactor {
+	config := opts.Redaction
+	if config == nil {
+		config = &Redaction{}
+	}
+	if config.Disabled {
+		return nil
+	}
+
+	r := &redactor{}
+	for _, pattern := range config.Patterns {
+		// Options.Validate reports invalid patterns
+		if re, err := regexp.Compile(pattern); err == nil {
+			r.patterns = append(r.patterns, re)
+		}
+	}
+	r.addEnv(os.Environ())
+	for key, value := range opts.Env {
+		r.addEnv([]string{key + "=" + value})
+	}
+
+	return r
+}
+
+// addEnv records the secret values among env, a list of KEY=VALUE.
+func (r *redactor) addEnv(env []string) {
+	for _, entry := range env {
+		key, value, ok := strings.Cut(entry, "=")
+		if ok && isSecretEnvName(key) {
+			r.addValue(value)
+		}
+	}
+}
+
+// addValue records a secret value.
+func (r *redactor) addValue(value string) {
+	if r == nil || len(value) < minSecretLength {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if slices.Contains(r.values, value) {
+		return
+	}
+	r.values = append(r.values, value)
+	// Longer values first, so a secret containing another is replaced whole
+	slices.SortFunc(r.values, func(a, b string) int { return len(b) - len(a) })
+}
+
+// isSecretEnvName reports whether the variable name suggests a secret.
+func isSecretEnvName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, part := range secretEnvNameParts {
+		if strings.Contains(upper, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// text returns s with its secrets replaced.
+func (r *redactor) text(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+
+	r.mu.RLock()
+	for _, value := range r.values {
+		s = strings.ReplaceAll(s, value, redactedToken)
+	}
+	r.mu.RUnlock()
+
+	s = secretHeaderPattern.ReplaceAllString(s, "${1}${2}${3}"+redactedToken)
+	s = anthropicKeyPattern.ReplaceAllString(s, redactedToken)
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, redactedToken)
+	}
+
+	return s
+}
+
+// error returns err with its secrets replaced; see clauderrs.Redact.
+func (r *redactor) error(err error) error {
+	if r == nil || err == nil {
+		return err
+	}
+
+	return clauderrs.Redact(err, r.text)
+}
+
+// lines wraps a line handler to receive redacted lines. It returns nil if
+// handler is nil.
+func (r *redactor) lines(handler func(string)) func(string) {
+	if r == nil || handler == nil {
+		return handler
+	}
+
+	return func(line string) {
+		handler(r.text(line))
+	}
+}
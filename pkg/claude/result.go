@@ -0,0 +1,157 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// StructuredOutputJSON returns the structured output of a query run with
+// OutputFormat, and whether there was any. It is the StructuredOutput
+// field re-encoded as JSON, ready to decode into a caller's type.
+func (m SDKResultMessage) StructuredOutputJSON() (json.RawMessage, bool) {
+	if m.StructuredOutput == nil {
+		return nil, false
+	}
+
+	data, err := json.Marshal(m.StructuredOutput)
+	if err != nil {
+		return nil, false
+	}
+
+	return data, true
+}
+
+// DecodeStructured decodes the structured output of a query run with
+// OutputFormat into v. It returns a ProtocolError if the result has no
+// structured output or it does not decode into v.
+func (m SDKResultMessage) DecodeStructured(v any) error {
+	data, ok := m.StructuredOutputJSON()
+	if !ok {
+		return clauderrs.NewProtocolError(
+			clauderrs.ErrCodeInvalidMessage,
+			"result message has no structured output",
+			nil,
+		).WithMessageType("result").WithSessionID(m.SessionID())
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return clauderrs.NewProtocolError(
+			clauderrs.ErrCodeMessageParseFailed,
+			fmt.Sprintf("structured output does not decode into %T", v),
+			err,
+		).WithMessageType("result").WithSessionID(m.SessionID())
+	}
+
+	return nil
+}
+
+// ResultErrorDetails describes why a result ended in error. It is one of
+// MaxTurnsExceeded, BudgetExceeded, StructuredOutputRetriesExceeded,
+// ExecutionFailed, or UnknownResultError; switch on the type rather than
+// comparing Subtype strings.
+type ResultErrorDetails interface {
+	error
+	resultErrorDetails()
+}
+
+// MaxTurnsExceeded is a result ended by the MaxTurns limit.
+type MaxTurnsExceeded struct {
+	// Turns is how many turns ran.
+	Turns int
+}
+
+func (e MaxTurnsExceeded) Error() string {
+	return fmt.Sprintf("query stopped after reaching the turn limit (%d turns)", e.Turns)
+}
+
+func (MaxTurnsExceeded) resultErrorDetails() {}
+
+// BudgetExceeded is a result ended by the MaxBudgetUsd limit.
+type BudgetExceeded struct {
+	// CostUSD is what the query had spent.
+	CostUSD float64
+}
+
+func (e BudgetExceeded) Error() string {
+	return fmt.Sprintf("query stopped after exceeding its budget ($%.2f spent)", e.CostUSD)
+}
+
+func (BudgetExceeded) resultErrorDetails() {}
+
+// StructuredOutputRetriesExceeded is a result whose output never matched
+// the OutputFormat schema within the allowed retries.
+type StructuredOutputRetriesExceeded struct {
+	Errors []string
+}
+
+func (e StructuredOutputRetriesExceeded) Error() string {
+	return joinResultErrors("structured output did not match the schema", e.Errors)
+}
+
+func (StructuredOutputRetriesExceeded) resultErrorDetails() {}
+
+// ExecutionFailed is a result ended by an error while the query ran, such
+// as an API error.
+type ExecutionFailed struct {
+	Errors []string
+}
+
+func (e ExecutionFailed) Error() string {
+	return joinResultErrors("query failed during execution", e.Errors)
+}
+
+func (ExecutionFailed) resultErrorDetails() {}
+
+// UnknownResultError is an error result with a subtype this SDK does not
+// know, from a newer CLI.
+type UnknownResultError struct {
+	Subtype string
+	Errors  []string
+}
+
+func (e UnknownResultError) Error() string {
+	return joinResultErrors("query ended with "+e.Subtype, e.Errors)
+}
+
+func (UnknownResultError) resultErrorDetails() {}
+
+// joinResultErrors appends the CLI's error messages to summary.
+func joinResultErrors(summary string, errors []string) string {
+	if len(errors) == 0 {
+		return summary
+	}
+
+	return summary + ": " + strings.Join(errors, "; ")
+}
+
+// ErrorDetails returns why the result ended in error, or nil for a
+// successful result.
+func (m SDKResultMessage) ErrorDetails() ResultErrorDetails {
+	switch m.Subtype {
+	case ResultSubtypeSuccess, "":
+		if !m.IsError {
+			return nil
+		}
+		// The CLI reports API errors as an error-flagged success with the
+		// message in Result.
+		errors := m.Errors
+		if len(errors) == 0 && m.Result != nil && *m.Result != "" {
+			errors = []string{*m.Result}
+		}
+
+		return ExecutionFailed{Errors: errors}
+	case ResultSubtypeErrorMaxTurns:
+		return MaxTurnsExceeded{Turns: m.NumTurns}
+	case ResultSubtypeErrorMaxBudgetUsd:
+		return BudgetExceeded{CostUSD: m.TotalCostUSD}
+	case ResultSubtypeErrorMaxStructuredOutputRetries:
+		return StructuredOutputRetriesExceeded{Errors: m.Errors}
+	case ResultSubtypeErrorDuringExecution:
+		return ExecutionFailed{Errors: m.Errors}
+	default:
+		return UnknownResultError{Subtype: m.Subtype, Errors: m.Errors}
+	}
+}
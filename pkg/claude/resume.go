@@ -0,0 +1,320 @@
+package claude
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// resumeConflictsKey is the error metadata key holding the conflicts
+	// that failed a resume.
+	resumeConflictsKey = "resume_conflicts"
+	// syntheticModel is the model the CLI records for messages it
+	// generated itself.
+	syntheticModel = "<synthetic>"
+	// mcpToolPrefix starts the names of MCP tools: mcp__<server>__<tool>.
+	mcpToolPrefix = "mcp__"
+)
+
+// ResumeConflictKind classifies a ResumeConflict.
+type ResumeConflictKind string
+
+const (
+	// ResumeConflictModel means Options.Model differs from the model that
+	// wrote the session's last response. The conversation continues with
+	// the new model.
+	ResumeConflictModel ResumeConflictKind = "model"
+	// ResumeConflictToolRemoved means the session used a tool that Options
+	// no longer makes available. The model sees its earlier calls but can't
+	// call it again.
+	ResumeConflictToolRemoved ResumeConflictKind = "tool_removed"
+	// ResumeConflictPendingToolUse means the session ended waiting for the
+	// result of a call to a tool that Options no longer makes available,
+	// so it can't continue from where it stopped.
+	ResumeConflictPendingToolUse ResumeConflictKind = "pending_tool_use"
+)
+
+// ResumeConflict is a difference between the Options a session is resumed
+// with and what its transcript records, found by CheckResume.
+type ResumeConflict struct {
+	Kind ResumeConflictKind
+	// Blocking reports whether the session can't continue correctly with
+	// the new options. Other conflicts are warnings.
+	Blocking bool
+	// Previous is what the transcript records: the model or the tool name.
+	Previous string
+	// Current is what Options sets: the new model, or empty for a tool.
+	Current string
+	// Message describes the conflict.
+	Message string
+}
+
+// String returns the conflict's message.
+func (c ResumeConflict) String() string {
+	return c.Message
+}
+
+// ResumeConflictFunc decides whether to resume a session despite
+// conflicts. It returns nil to resume anyway or an error to fail the query
+// with it. To keep the original session intact, call CheckResume before
+// connecting and set Options.ForkSession instead.
+type ResumeConflictFunc func(conflicts []ResumeConflict) error
+
+// CheckResume compares opts with the transcript of the session named by
+// opts.Resume, up to opts.ResumeSessionAt if set, and returns the
+// conflicts between them. It returns nothing when no session is resumed
+// or its transcript can't be found, which the CLI reports.
+//
+// The transcript records the models and tools the session used, not the
+// options it ran with: a model change is found when Options.Model is set,
+// and a tool is considered removed when DisallowedTools names it or, with
+// StrictMcpConfig, its MCP server is not in McpServers.
+func CheckResume(opts *Options) ([]ResumeConflict, error) {
+	if opts == nil || opts.Resume == "" {
+		return nil, nil
+	}
+
+	path := resumeTranscriptPath(opts)
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	history, err := readResumeHistory(file, opts.ResumeSessionAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return history.conflicts(opts), nil
+}
+
+// ResumeConflicts returns the conflicts that failed a resume with
+// ErrCodeResumeConflict, or nil for other errors.
+func ResumeConflicts(err error) []ResumeConflict {
+	sdkErr, ok := clauderrs.AsSDKError(err)
+	if !ok || sdkErr.Code() != clauderrs.ErrCodeResumeConflict {
+		return nil
+	}
+	conflicts, _ := sdkErr.Metadata()[resumeConflictsKey].([]ResumeConflict)
+
+	return conflicts
+}
+
+// checkResumeOptions reconciles opts with the session it resumes. Without
+// Options.OnResumeConflict, blocking conflicts fail with
+// ErrCodeResumeConflict and warnings are reported to Options.Stderr.
+func checkResumeOptions(opts *Options) error {
+	conflicts, err := CheckResume(opts)
+	if err != nil || len(conflicts) == 0 {
+		return err
+	}
+	if opts.OnResumeConflict != nil {
+		return opts.OnResumeConflict(conflicts)
+	}
+
+	var blocking []string
+	for _, conflict := range conflicts {
+		if conflict.Blocking {
+			blocking = append(blocking, conflict.Message)
+		} else if opts.Stderr != nil {
+			opts.Stderr("resume: " + conflict.Message)
+		}
+	}
+	if len(blocking) == 0 {
+		return nil
+	}
+
+	resumeErr := clauderrs.NewClientError(
+		clauderrs.ErrCodeResumeConflict,
+		fmt.Sprintf("cannot resume session %s with these options: %s", opts.Resume, strings.Join(blocking, "; ")),
+		nil,
+	).
+		WithSessionID(opts.Resume)
+	_ = resumeErr.WithMetadata(resumeConflictsKey, conflicts)
+
+	return resumeErr
+}
+
+// resumeTranscriptPath returns the transcript of the resumed session, or
+// "" if there is none.
+func resumeTranscriptPath(opts *Options) string {
+	cwd := opts.Cwd
+	if cwd == "" {
+		cwd, _ = os.Getwd()
+	}
+	projects := filepath.Join(configDir("", opts.Env), "projects")
+
+	for _, dir := range projectDirs(projects, cwd) {
+		path := filepath.Join(dir, opts.Resume+".jsonl")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// transcriptResumeEntry is the part of a transcript line CheckResume reads.
+type transcriptResumeEntry struct {
+	Type    string `json:"type"`
+	UUID    string `json:"uuid"`
+	Message struct {
+		Model   string          `json:"model"`
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// transcriptResumeBlock is the part of a content block CheckResume reads.
+type transcriptResumeBlock struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	ToolUseID string `json:"tool_use_id"`
+}
+
+// resumeHistory is what a transcript records about the models and tools
+// its session used.
+type resumeHistory struct {
+	// model wrote the last response.
+	model string
+	// tools were called, in order of first use.
+	tools []string
+	// pending maps calls awaiting their result to the tool called.
+	pending map[string]string
+}
+
+// readResumeHistory reads a transcript up to and including the entry with
+// UUID until, if set.
+func readResumeHistory(file *os.File, until string) (*resumeHistory, error) {
+	history := &resumeHistory{pending: make(map[string]string)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxTranscriptLine)
+
+	for scanner.Scan() {
+		var entry transcriptResumeEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+
+		var blocks []transcriptResumeBlock
+		_ = json.Unmarshal(entry.Message.Content, &blocks)
+		switch entry.Type {
+		case "assistant":
+			if entry.Message.Model != "" && entry.Message.Model != syntheticModel {
+				history.model = entry.Message.Model
+			}
+			for _, block := range blocks {
+				if block.Type != "tool_use" {
+					continue
+				}
+				if !slices.Contains(history.tools, block.Name) {
+					history.tools = append(history.tools, block.Name)
+				}
+				history.pending[block.ID] = block.Name
+			}
+		case "user":
+			for _, block := range blocks {
+				if block.Type == "tool_result" {
+					delete(history.pending, block.ToolUseID)
+				}
+			}
+		}
+
+		if until != "" && entry.UUID == until {
+			break
+		}
+	}
+
+	return history, scanner.Err()
+}
+
+// conflicts compares the history with opts.
+func (h *resumeHistory) conflicts(opts *Options) []ResumeConflict {
+	var conflicts []ResumeConflict
+	if opts.Model != "" && h.model != "" && !strings.Contains(h.model, strings.TrimSuffix(opts.Model, extendedContextSuffix)) {
+		conflicts = append(conflicts, ResumeConflict{
+			Kind:     ResumeConflictModel,
+			Previous: h.model,
+			Current:  opts.Model,
+			Message:  fmt.Sprintf("session was written by %s and continues with %s", h.model, opts.Model),
+		})
+	}
+
+	for _, tool := range h.tools {
+		if toolAvailable(opts, tool) {
+			continue
+		}
+		conflicts = append(conflicts, ResumeConflict{
+			Kind:     ResumeConflictToolRemoved,
+			Previous: tool,
+			Message:  fmt.Sprintf("session used %s, which is no longer available", tool),
+		})
+	}
+
+	// Sorted, so the conflicts don't depend on map order
+	pending := make([]string, 0, len(h.pending))
+	for id := range h.pending {
+		pending = append(pending, id)
+	}
+	slices.Sort(pending)
+	for _, id := range pending {
+		tool := h.pending[id]
+		if toolAvailable(opts, tool) {
+			continue
+		}
+		conflicts = append(conflicts, ResumeConflict{
+			Kind:     ResumeConflictPendingToolUse,
+			Blocking: true,
+			Previous: tool,
+			Message:  fmt.Sprintf("session awaits the result of %s call %s, which is no longer available", tool, id),
+		})
+	}
+
+	return conflicts
+}
+
+// toolAvailable reports whether opts leaves tool callable. Only whole-tool
+// rules count: patterns such as "Bash(rm:*)" restrict some calls.
+func toolAvailable(opts *Options, tool string) bool {
+	server, isMcp := mcpToolServer(tool)
+	for _, rule := range opts.DisallowedTools {
+		if rule == tool || (isMcp && rule == mcpToolPrefix+server) {
+			return false
+		}
+	}
+	if isMcp && server == PagingServerName {
+		return opts.ToolResultPaging != nil
+	}
+	if isMcp && server == MemoryServerName {
+		return opts.AgentMemory
+	}
+	if isMcp && opts.StrictMcpConfig {
+		if _, ok := opts.McpServers[server]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mcpToolServer returns the server of an MCP tool name.
+func mcpToolServer(tool string) (string, bool) {
+	rest, ok := strings.CutPrefix(tool, mcpToolPrefix)
+	if !ok {
+		return "", false
+	}
+	server, _, ok := strings.Cut(rest, "__")
+
+	return server, ok
+}
@@ -0,0 +1,284 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// reviewTools are the only tools a review client may use.
+var reviewTools = []string{"Read", "Grep", "Glob"}
+
+// reviewDeniedTools are passed to the CLI as disallowed so writes and
+// network access are blocked even before the SDK permission check runs,
+// along with the client's MCP servers.
+var reviewDeniedTools = []string{
+	"Bash",
+	"Edit",
+	"KillShell",
+	"MultiEdit",
+	"NotebookEdit",
+	"Task",
+	"WebFetch",
+	"WebSearch",
+	"Write",
+}
+
+// ReviewClient creates a read-only client for code-review agents.
+//
+// The returned client:
+//   - allows only Read, Grep, and Glob and disallows write-capable tools,
+//     web tools, Task and its MCP servers;
+//   - runs the CLI in default mode unless plan mode is asked for, so
+//     settings can't choose a more permissive one;
+//   - denies every other tool in a PreToolUse hook, which runs even for
+//     tools CLI settings allow, and in the SDK permission check before
+//     consulting opts.CanUseTool;
+//   - drops permission updates and suggestions accepted by opts.CanUseTool
+//     that would widen its permissions;
+//   - refuses SetPermissionMode escalation beyond plan or default mode and
+//     UpdatePermissions that would widen its permissions.
+//
+// opts is copied and may be nil. Options that would weaken these guarantees
+// (bypass or acceptEdits modes, a permission prompt tool) are rejected with
+// a ValidationError.
+func ReviewClient(opts *Options) (*ClaudeSDKClient, error) {
+	var reviewOpts Options
+	if opts != nil {
+		reviewOpts = *opts
+	}
+
+	if !isReviewMode(reviewOpts.PermissionMode) {
+		return nil, clauderrs.NewValidationError(
+			clauderrs.ErrCodeConflictingOptions,
+			fmt.Sprintf("review clients cannot use %q permission mode", reviewOpts.PermissionMode),
+			nil,
+			"PermissionMode",
+			reviewOpts.PermissionMode,
+		)
+	}
+	if reviewOpts.PermissionPromptToolName != "" {
+		return nil, clauderrs.NewValidationError(
+			clauderrs.ErrCodeConflictingOptions,
+			"review clients answer permission prompts in the SDK",
+			nil,
+			"PermissionPromptToolName",
+			reviewOpts.PermissionPromptToolName,
+		)
+	}
+
+	if reviewOpts.PermissionMode == "" {
+		reviewOpts.PermissionMode = PermissionModeDefault
+	}
+	reviewOpts.AllowedTools = slices.Clone(reviewTools)
+	reviewOpts.DisallowedTools = slices.Clone(reviewOpts.DisallowedTools)
+	denied := slices.Clone(reviewDeniedTools)
+	for name := range reviewOpts.McpServers {
+		denied = append(denied, "mcp__"+name)
+	}
+	for _, tool := range denied {
+		if !slices.Contains(reviewOpts.DisallowedTools, tool) {
+			reviewOpts.DisallowedTools = append(reviewOpts.DisallowedTools, tool)
+		}
+	}
+	reviewOpts.AllowDangerouslySkipPermissions = false
+	reviewOpts.CanUseTool = reviewCanUseTool(reviewOpts.CanUseTool)
+
+	// Policy hooks run first, so the user's hooks can't pre-empt a denial
+	reviewOpts.Hooks = maps.Clone(reviewOpts.Hooks)
+	if reviewOpts.Hooks == nil {
+		reviewOpts.Hooks = make(map[HookEvent][]HookCallbackMatcher)
+	}
+	reviewOpts.Hooks[HookEventPreToolUse] = append(
+		[]HookCallbackMatcher{{Hooks: []HookCallback{reviewPreToolUse}}},
+		reviewOpts.Hooks[HookEventPreToolUse]...,
+	)
+
+	client, err := NewClient(&reviewOpts)
+	if err != nil {
+		return nil, err
+	}
+	client.readOnly = true
+
+	return client, nil
+}
+
+// reviewPreToolUse denies non-review tools, including those CLI settings
+// allow without asking.
+func reviewPreToolUse(_ context.Context, input HookInput, _ *string) (HookJSONOutput, error) {
+	pre, ok := input.(PreToolUseHookInput)
+	if !ok || slices.Contains(reviewTools, pre.ToolName) {
+		return SyncHookOutput{}, nil
+	}
+
+	decision := string(PermissionDecisionDeny)
+	reason := fmt.Sprintf("tool '%s' is not available to review clients", pre.ToolName)
+
+	return SyncHookOutput{
+		HookSpecificOutput: PreToolUseHookOutput{
+			HookEventName:            HookEventPreToolUse,
+			PermissionDecision:       &decision,
+			PermissionDecisionReason: &reason,
+		},
+	}, nil
+}
+
+// reviewCanUseTool denies non-review tools and delegates the rest to next,
+// allowing them when next is nil. Permission updates next accepts are
+// kept only if they don't widen the client's permissions.
+func reviewCanUseTool(next CanUseToolFunc) CanUseToolFunc {
+	return func(
+		ctx context.Context,
+		toolName string,
+		input map[string]JSONValue,
+		suggestions []PermissionUpdate,
+		toolUseID string,
+		agentID *string,
+		blockedPath *string,
+		decisionReason *string,
+	) (PermissionResult, error) {
+		if !slices.Contains(reviewTools, toolName) {
+			return &PermissionDeny{
+				Behavior: PermissionBehaviorDeny,
+				Message:  fmt.Sprintf("tool '%s' is not available to review clients", toolName),
+			}, nil
+		}
+
+		if next == nil {
+			return &PermissionAllow{Behavior: PermissionBehaviorAllow}, nil
+		}
+
+		result, err := next(
+			ctx,
+			toolName,
+			input,
+			suggestions,
+			toolUseID,
+			agentID,
+			blockedPath,
+			decisionReason,
+		)
+		switch allow := result.(type) {
+		case *PermissionAllow:
+			result = readOnlyAllow(*allow, suggestions)
+		case PermissionAllow:
+			result = readOnlyAllow(allow, suggestions)
+		}
+
+		return result, err
+	}
+}
+
+// readOnlyAllow returns allow with its permission updates, including the
+// suggestions it accepts, reduced to those a read-only client may apply.
+func readOnlyAllow(allow PermissionAllow, suggestions []PermissionUpdate) *PermissionAllow {
+	updates := allow.UpdatedPermissions
+	if allow.ApplySuggestions {
+		updates = append(slices.Clone(updates), suggestions...)
+	}
+
+	allow.UpdatedPermissions = nil
+	allow.ApplySuggestions = false
+	for _, update := range updates {
+		if checkReadOnlyUpdates([]PermissionUpdate{update}) == nil {
+			allow.UpdatedPermissions = append(allow.UpdatedPermissions, update)
+		}
+	}
+
+	return &allow
+}
+
+// isReviewMode reports whether mode keeps a review client read-only.
+func isReviewMode(mode PermissionMode) bool {
+	return mode == "" || mode == PermissionModeDefault || mode == PermissionModePlan
+}
+
+// checkReadOnlyMode rejects permission modes that would let a read-only
+// client write.
+func checkReadOnlyMode(mode PermissionMode) error {
+	if isReviewMode(mode) {
+		return nil
+	}
+
+	return clauderrs.NewPermissionError(
+		clauderrs.ErrCodeResourceDenied,
+		fmt.Sprintf("review clients cannot switch to %q permission mode", mode),
+		nil,
+		"permission_mode",
+		string(mode),
+	)
+}
+
+// checkReadOnlyUpdates rejects permission updates that would widen a
+// read-only client's permissions: modes beyond plan or default, allow rules
+// for other tools, removing or replacing deny and ask rules, and added
+// directories.
+func checkReadOnlyUpdates(updates []PermissionUpdate) error {
+	for _, update := range updates {
+		var err error
+		switch u := normalizePermissionUpdate(update).(type) {
+		case SetModeUpdate:
+			err = checkReadOnlyMode(u.Mode)
+		case AddRulesUpdate:
+			err = checkReadOnlyRules(u.Behavior, u.Rules)
+		case ReplaceRulesUpdate:
+			if u.Behavior != PermissionBehaviorAllow {
+				err = readOnlyUpdateError("replaceRules", string(u.Behavior))
+			} else {
+				err = checkReadOnlyRules(u.Behavior, u.Rules)
+			}
+		case RemoveRulesUpdate:
+			if u.Behavior != PermissionBehaviorAllow {
+				err = readOnlyUpdateError("removeRules", string(u.Behavior))
+			}
+		case RemoveDirectoriesUpdate:
+			// Narrowing the directories is always allowed
+		case AddDirectoriesUpdate:
+			err = readOnlyUpdateError("addDirectories", strings.Join(u.Directories, ", "))
+		default:
+			err = readOnlyUpdateError(fmt.Sprintf("%T", update), "")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readOnlyUpdateError rejects a permission update a read-only client may
+// not apply.
+func readOnlyUpdateError(kind, value string) error {
+	return clauderrs.NewPermissionError(
+		clauderrs.ErrCodeResourceDenied,
+		fmt.Sprintf("review clients cannot apply %s permission updates", kind),
+		nil,
+		kind,
+		value,
+	)
+}
+
+// checkReadOnlyRules rejects allow rules for non-review tools.
+func checkReadOnlyRules(behavior PermissionBehavior, rules []PermissionRuleValue) error {
+	if behavior != PermissionBehaviorAllow {
+		return nil
+	}
+
+	for _, rule := range rules {
+		if !slices.Contains(reviewTools, rule.ToolName) {
+			return clauderrs.NewPermissionError(
+				clauderrs.ErrCodeToolDenied,
+				fmt.Sprintf("review clients cannot allow tool '%s'", rule.ToolName),
+				nil,
+				rule.ToolName,
+				string(behavior),
+			)
+		}
+	}
+
+	return nil
+}
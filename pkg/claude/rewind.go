@@ -0,0 +1,118 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// fileCheckpointingEnv makes the CLI checkpoint files before each user
+// message, for rewind_code requests.
+const fileCheckpointingEnv = "CLAUDE_CODE_ENABLE_SDK_FILE_CHECKPOINTING"
+
+// FileCheckpoint is the state of the files before a user message of the
+// session, which RewindFiles restores.
+type FileCheckpoint struct {
+	// ID is the UUID of the user message.
+	ID string
+	// Prompt is the text of the user message, for showing in undo menus.
+	Prompt    string
+	CreatedAt time.Time
+}
+
+// SDKControlRewindCodeRequest restores the files the CLI changed since a
+// user message to their state before it.
+type SDKControlRewindCodeRequest struct {
+	SubtypeField  string `json:"subtype"` // "rewind_code"
+	UserMessageID string `json:"user_message_id"`
+}
+
+// Subtype returns the rewind_code request subtype field.
+func (SDKControlRewindCodeRequest) Subtype() string {
+	return ControlRequestSubtypeRewindCode
+}
+func (SDKControlRewindCodeRequest) controlRequestVariant() {}
+
+// MarshalJSON ensures the subtype field is always set to "rewind_code".
+func (r SDKControlRewindCodeRequest) MarshalJSON() ([]byte, error) {
+	type Alias SDKControlRewindCodeRequest
+
+	return json.Marshal(&struct {
+		SubtypeField string `json:"subtype"`
+		*Alias
+	}{
+		SubtypeField: ControlRequestSubtypeRewindCode,
+		Alias:        (*Alias)(&r),
+	})
+}
+
+// fileCheckpointLog records the checkpoints of a session's user messages.
+type fileCheckpointLog struct {
+	mu          sync.Mutex
+	checkpoints []FileCheckpoint
+}
+
+// add records the checkpoint of a user message sent.
+func (l *fileCheckpointLog) add(id string, content []ContentBlock, at time.Time) {
+	var texts textCollector
+	_ = WalkContent(content, &texts)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.checkpoints = append(l.checkpoints, FileCheckpoint{
+		ID:        id,
+		Prompt:    strings.Join(texts.parts, "\n"),
+		CreatedAt: at,
+	})
+}
+
+func (l *fileCheckpointLog) list() []FileCheckpoint {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]FileCheckpoint(nil), l.checkpoints...)
+}
+
+// FileCheckpoints returns the file checkpoints of the session, one per
+// user message sent through the client, oldest first. It is empty unless
+// Options.EnableFileCheckpointing is set.
+func (c *ClaudeSDKClient) FileCheckpoints() []FileCheckpoint {
+	c.mu.Lock()
+	q, _ := c.query.(*queryImpl)
+	c.mu.Unlock()
+	if q == nil {
+		return nil
+	}
+
+	return q.fileCheckpoints.list()
+}
+
+// RewindFiles restores the files Claude changed since the user message of
+// checkpointID, one of FileCheckpoints, to their state before it, undoing
+// the agent's edits. The conversation itself is not rewound. It requires
+// Options.EnableFileCheckpointing.
+func (c *ClaudeSDKClient) RewindFiles(ctx context.Context, checkpointID string) error {
+	if !c.opts.EnableFileCheckpointing {
+		return clauderrs.NewClientError(
+			clauderrs.ErrCodeInvalidConfig,
+			"RewindFiles requires EnableFileCheckpointing",
+			nil,
+		)
+	}
+
+	c.mu.Lock()
+	q, _ := c.query.(*queryImpl)
+	c.mu.Unlock()
+	if q == nil {
+		return clauderrs.NewClientError(clauderrs.ErrCodeNoActiveQuery, errNoActiveQuery, nil)
+	}
+
+	_, err := q.sendControlRequest(ctx, SDKControlRewindCodeRequest{UserMessageID: checkpointID})
+
+	return err
+}
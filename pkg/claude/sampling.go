@@ -0,0 +1,117 @@
+package claude
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// thinkingMinTopP is the lowest TopP extended thinking accepts.
+const thinkingMinTopP = 0.95
+
+// exclusiveSamplingModels accept Temperature or TopP in a request, but not
+// both.
+var exclusiveSamplingModels = []string{
+	"claude-opus-4-1",
+	"claude-opus-4-5",
+	"claude-sonnet-4-5",
+	"claude-haiku-4-5",
+}
+
+// SamplingWarnings returns why the sampling parameters of o won't have the
+// effect asked for. The CLI has no setting for Temperature, TopP or Seed,
+// so any of them set is ignored; the rest say what the model would do if
+// they reached it. The client reports them to Stderr when it starts.
+func (o *Options) SamplingWarnings() []string {
+	var set []string
+	if o.Temperature != nil {
+		set = append(set, "Temperature")
+	}
+	if o.TopP != nil {
+		set = append(set, "TopP")
+	}
+	if o.Seed != nil {
+		set = append(set, "Seed")
+	}
+	if len(set) == 0 {
+		return nil
+	}
+
+	warnings := []string{fmt.Sprintf(
+		"the CLI doesn't support %s, so the model keeps its default sampling",
+		strings.Join(set, ", "),
+	)}
+	if o.Seed != nil {
+		warnings = append(warnings, "the Anthropic API doesn't take a seed, so runs vary even with Seed set")
+	}
+	if o.Temperature != nil && o.TopP != nil {
+		for _, model := range exclusiveSamplingModels {
+			if strings.Contains(o.Model, model) {
+				warnings = append(warnings, fmt.Sprintf(
+					"%s accepts Temperature or TopP but not both",
+					o.Model,
+				))
+
+				break
+			}
+		}
+	}
+
+	return warnings
+}
+
+// validateSampling checks the sampling parameters' ranges and that they
+// are compatible with extended thinking.
+func (o *Options) validateSampling() []error {
+	var errs []error
+
+	if o.Temperature != nil && (*o.Temperature < 0 || *o.Temperature > 1) {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"Temperature must be between 0 and 1",
+			nil,
+			"Temperature",
+			*o.Temperature,
+		))
+	}
+	if o.TopP != nil && (*o.TopP <= 0 || *o.TopP > 1) {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"TopP must be above 0 and at most 1",
+			nil,
+			"TopP",
+			*o.TopP,
+		))
+	}
+
+	if o.MaxThinkingTokens > 0 {
+		if o.Temperature != nil && *o.Temperature != 1 {
+			errs = append(errs, conflictError(
+				"Temperature",
+				"extended thinking requires Temperature 1; unset MaxThinkingTokens to lower it",
+				*o.Temperature,
+			))
+		}
+		if o.TopP != nil && *o.TopP < thinkingMinTopP {
+			errs = append(errs, conflictError(
+				"TopP",
+				fmt.Sprintf("extended thinking requires TopP of at least %g", thinkingMinTopP),
+				*o.TopP,
+			))
+		}
+	}
+
+	return errs
+}
+
+// reportSamplingWarnings reports the sampling warnings of opts to its
+// Stderr.
+func reportSamplingWarnings(opts *Options) {
+	if opts.Stderr == nil {
+		return
+	}
+	for _, warning := range opts.SamplingWarnings() {
+		opts.Stderr("sampling: " + warning)
+	}
+}
@@ -0,0 +1,192 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// mcpProtocolVersion is the MCP revision SDK servers speak.
+	mcpProtocolVersion = "2024-11-05"
+
+	// JSON-RPC error codes used in MCP responses.
+	jsonrpcMethodNotFound = -32601
+	jsonrpcInvalidParams  = -32602
+)
+
+// mcpSessionKey is the context key holding the session ID of an SDK MCP
+// tool call.
+type mcpSessionKey struct{}
+
+// McpSessionID returns the ID of the session that made the SDK MCP tool
+// call running with ctx, or "" outside a tool call. Handlers of servers
+// shared between clients use it to keep per-session state apart.
+func McpSessionID(ctx context.Context) string {
+	id, _ := ctx.Value(mcpSessionKey{}).(string)
+
+	return id
+}
+
+// jsonrpcRequest is a JSON-RPC request or notification relayed by the CLI
+// to an SDK MCP server.
+type jsonrpcRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// mcpConfigArg returns the --mcp-config value for Options.McpServers. SDK
+// servers are declared by name only; the CLI reaches them through
+// mcp_message control requests.
+func mcpConfigArg(servers map[string]McpServerConfig) (string, error) {
+	data, err := json.Marshal(map[string]any{"mcpServers": servers})
+	if err != nil {
+		return "", clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidConfig,
+			"failed to encode MCP server configuration",
+			err,
+			"McpServers",
+			nil,
+		)
+	}
+
+	return string(data), nil
+}
+
+// handleMcpMessage answers an mcp_message control request by dispatching
+// the JSON-RPC message to the named SDK MCP server, or relaying it to a
+// supervised stdio server.
+func (q *queryImpl) handleMcpMessage(
+	ctx context.Context,
+	data json.RawMessage,
+) (map[string]any, error) {
+	var req SDKControlMcpMessageRequest
+	var msg jsonrpcRequest
+	err := json.Unmarshal(controlRequestBody(data), &req)
+	if err == nil {
+		err = json.Unmarshal(req.Message, &msg)
+	}
+	if err != nil || msg.Method == "" {
+		return nil, clauderrs.NewProtocolError(
+			clauderrs.ErrCodeMessageParseFailed,
+			"failed to parse mcp_message request",
+			err,
+		).
+			WithSessionID(q.sessionID).
+			WithMessageType("control_request")
+	}
+
+	if supervisor, ok := q.mcpSupervisors[req.ServerName]; ok {
+		return supervisor.relay(ctx, msg, req.Message), nil
+	}
+
+	config, ok := q.opts.McpServers[req.ServerName].(McpSdkServerConfig)
+	if q.pager != nil && req.ServerName == PagingServerName {
+		config, ok = q.pager.server, true
+	}
+	if q.memory != nil && req.ServerName == MemoryServerName {
+		config, ok = *q.memory, true
+	}
+	if !ok || config.Instance == nil {
+		return mcpResponse(msg.ID, nil, jsonrpcMethodNotFound,
+			fmt.Sprintf("SDK MCP server %q not found", req.ServerName)), nil
+	}
+
+	ctx = context.WithValue(ctx, mcpSessionKey{}, q.sessionID)
+
+	return dispatchMcp(ctx, config.Instance, msg, q.opts.Stderr), nil
+}
+
+// dispatchMcp handles one JSON-RPC message for server. Tool handler panics
+// are reported to logf, if set, with their stack.
+func dispatchMcp(ctx context.Context, server McpServer, msg jsonrpcRequest, logf func(string)) map[string]any {
+	switch msg.Method {
+	case "initialize":
+		return mcpResponse(msg.ID, map[string]any{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": server.Name(), "version": server.Version()},
+		}, 0, "")
+	case "notifications/initialized":
+		return mcpResponse(msg.ID, map[string]any{}, 0, "")
+	case "tools/list":
+		tools := make([]map[string]any, 0, len(server.Tools()))
+		for _, tool := range server.Tools() {
+			tools = append(tools, map[string]any{
+				"name":        tool.Name(),
+				"description": tool.Description(),
+				"inputSchema": tool.InputSchema(),
+			})
+		}
+
+		return mcpResponse(msg.ID, map[string]any{"tools": tools}, 0, "")
+	case "tools/call":
+		var params struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return mcpResponse(msg.ID, nil, jsonrpcInvalidParams, "invalid tools/call params: "+err.Error())
+		}
+		for _, tool := range server.Tools() {
+			if tool.Name() == params.Name {
+				return mcpResponse(msg.ID, callMcpTool(ctx, tool, params.Arguments, logf), 0, "")
+			}
+		}
+
+		return mcpResponse(msg.ID, nil, jsonrpcInvalidParams, fmt.Sprintf("tool %q not found", params.Name))
+	default:
+		return mcpResponse(msg.ID, nil, jsonrpcMethodNotFound, fmt.Sprintf("method %q not supported", msg.Method))
+	}
+}
+
+// callMcpTool runs a tool and encodes its result. Handler errors, panics
+// and results that can't be encoded are returned to the model as error
+// results rather than failing the request.
+func callMcpTool(ctx context.Context, tool McpTool, args map[string]any, logf func(string)) json.RawMessage {
+	if args == nil {
+		args = map[string]any{}
+	}
+
+	result, err := executeTool(ctx, tool, args)
+	var panicErr *toolPanicError
+	if errors.As(err, &panicErr) && logf != nil {
+		logf(fmt.Sprintf("SDK MCP tool '%s' panicked: %v\n%s", panicErr.tool, panicErr.value, panicErr.stack))
+	}
+	if err == nil && result == nil {
+		result = &McpToolResult{}
+	}
+
+	var data []byte
+	if err == nil {
+		data, err = json.Marshal(result)
+	}
+	if err != nil {
+		data, _ = json.Marshal(McpToolResult{
+			Content: []ContentBlock{TextContentBlock{Type: "text", Text: err.Error()}},
+			IsError: true,
+		})
+	}
+
+	return data
+}
+
+// mcpResponse builds the control response payload wrapping a JSON-RPC
+// result, or an error when code is non-zero.
+func mcpResponse(id json.RawMessage, result any, code int, message string) map[string]any {
+	response := map[string]any{"jsonrpc": "2.0"}
+	if len(id) > 0 {
+		response["id"] = id
+	}
+	if code != 0 {
+		response["error"] = map[string]any{"code": code, "message": message}
+	} else {
+		response["result"] = result
+	}
+
+	return map[string]any{"mcp_response": response}
+}
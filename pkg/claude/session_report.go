@@ -0,0 +1,87 @@
+package claude
+
+import (
+	"sync"
+	"time"
+)
+
+// SessionReport summarizes the usage of one session: its turns, their
+// duration, cost and tokens, as reported by the session's results.
+type SessionReport struct {
+	SessionID string
+	// Model is the model of the most recent assistant message.
+	Model string
+	// StartedAt is when the first message of the session was observed.
+	StartedAt time.Time
+	// Turns is the number of results, one per prompt answered, and
+	// ErrorTurns the number of those that ended in error.
+	Turns      int
+	ErrorTurns int
+	// Duration and APIDuration total the results' durations.
+	Duration    time.Duration
+	APIDuration time.Duration
+	// Cost totals the results' costs.
+	Cost USD
+	// Token totals of the results' usage.
+	InputTokens              int
+	OutputTokens             int
+	CacheReadInputTokens     int
+	CacheCreationInputTokens int
+	// PermissionDenials is the number of tool calls denied.
+	PermissionDenials int
+}
+
+// SessionReportCollector builds a SessionReport from the message stream.
+// ClaudeSDKClient maintains one automatically; use this type directly when
+// consuming a Query without the client.
+type SessionReportCollector struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	report SessionReport
+}
+
+// NewSessionReportCollector creates an empty collector.
+func NewSessionReportCollector() *SessionReportCollector {
+	return &SessionReportCollector{now: time.Now}
+}
+
+// Observe records the session, model and usage found in msg.
+func (c *SessionReportCollector) Observe(msg SDKMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.report.StartedAt.IsZero() {
+		c.report.StartedAt = c.now()
+	}
+	if id := msg.SessionID(); id != "" {
+		c.report.SessionID = id
+	}
+
+	switch m := msg.(type) {
+	case *SDKAssistantMessage:
+		if m.Message.Model != "" {
+			c.report.Model = m.Message.Model
+		}
+	case *SDKResultMessage:
+		c.report.Turns++
+		if m.IsError {
+			c.report.ErrorTurns++
+		}
+		c.report.Duration += time.Duration(m.DurationMS) * time.Millisecond
+		c.report.APIDuration += time.Duration(m.DurationAPIMS) * time.Millisecond
+		c.report.Cost += m.TotalCost()
+		c.report.InputTokens += m.Usage.InputTokens
+		c.report.OutputTokens += m.Usage.OutputTokens
+		c.report.CacheReadInputTokens += m.Usage.CacheReadInputTokens
+		c.report.CacheCreationInputTokens += m.Usage.CacheCreationInputTokens
+		c.report.PermissionDenials += len(m.PermissionDenials)
+	}
+}
+
+// Report returns the report so far.
+func (c *SessionReportCollector) Report() SessionReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.report
+}
@@ -0,0 +1,272 @@
+package claude
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// maxSessionStoreRecord bounds a record read back from a FileSessionStore
+// log.
+const maxSessionStoreRecord = 16 << 20
+
+// storeFilePrefix starts the names of a FileSessionStore's files.
+const storeFilePrefix = "_"
+
+// SessionStore persists what the SDK keeps beside the CLI's sessions,
+// which the CLI's transcripts can't hold: the results of idempotent
+// queries, the memory of Options.AgentMemory and annotations. It holds
+// JSON values by key and append-only logs of JSON records. Implementations
+// must be safe for concurrent use; share a store between clients, or point
+// file stores at the same directory, to share what it holds.
+type SessionStore interface {
+	// Get returns the value stored under key, and whether there is one.
+	Get(ctx context.Context, key string) (json.RawMessage, bool, error)
+	// Put stores value under key, replacing any previous value.
+	Put(ctx context.Context, key string, value json.RawMessage) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// Keys returns the stored keys starting with prefix, sorted.
+	Keys(ctx context.Context, prefix string) ([]string, error)
+	// Append adds record to the end of the log named log.
+	Append(ctx context.Context, log string, record json.RawMessage) error
+	// Records returns the records of log, oldest first.
+	Records(ctx context.Context, log string) ([]json.RawMessage, error)
+}
+
+// MemorySessionStore is a SessionStore kept in memory, lasting as long as
+// the process.
+type MemorySessionStore struct {
+	mu     sync.Mutex
+	values map[string]json.RawMessage
+	logs   map[string][]json.RawMessage
+}
+
+// NewMemorySessionStore creates an empty in-process SessionStore.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		values: make(map[string]json.RawMessage),
+		logs:   make(map[string][]json.RawMessage),
+	}
+}
+
+// Get returns the value stored under key.
+func (s *MemorySessionStore) Get(_ context.Context, key string) (json.RawMessage, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.values[key]
+
+	return value, ok, nil
+}
+
+// Put stores a copy of value under key.
+func (s *MemorySessionStore) Put(_ context.Context, key string, value json.RawMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[key] = slices.Clone(value)
+
+	return nil
+}
+
+// Delete removes key.
+func (s *MemorySessionStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.values, key)
+
+	return nil
+}
+
+// Keys returns the stored keys starting with prefix, sorted.
+func (s *MemorySessionStore) Keys(_ context.Context, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for key := range s.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	return keys, nil
+}
+
+// Append adds a copy of record to the end of log.
+func (s *MemorySessionStore) Append(_ context.Context, log string, record json.RawMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.logs[log] = append(s.logs[log], slices.Clone(record))
+
+	return nil
+}
+
+// Records returns the records of log, oldest first.
+func (s *MemorySessionStore) Records(_ context.Context, log string) ([]json.RawMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.logs[log]), nil
+}
+
+// FileSessionStore is a SessionStore kept in a directory, so what it holds
+// survives restarts: each value in its own file under values/, replaced
+// atomically, and each log in a file under logs/, one record per line.
+// Processes sharing the directory see each other's changes, but
+// concurrent changes to one key from several processes may overwrite each
+// other.
+type FileSessionStore struct {
+	dir string
+	mu  sync.Mutex // Serializes appends within the process
+}
+
+// NewFileSessionStore creates a SessionStore in dir, which is created if
+// needed.
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	for _, sub := range []string{"values", "logs"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, clauderrs.NewValidationError(
+				clauderrs.ErrCodeInvalidFormat,
+				fmt.Sprintf("failed to create session store directory %s", dir),
+				err,
+				"dir",
+				dir,
+			)
+		}
+	}
+
+	return &FileSessionStore{dir: dir}, nil
+}
+
+// Get returns the value stored under key.
+func (f *FileSessionStore) Get(_ context.Context, key string) (json.RawMessage, bool, error) {
+	data, err := os.ReadFile(f.valuePath(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return data, true, nil
+}
+
+// Put stores value under key.
+func (f *FileSessionStore) Put(_ context.Context, key string, value json.RawMessage) error {
+	return writeFileAtomic(filepath.Join(f.dir, "values"), storeFileName(key), value)
+}
+
+// Delete removes key.
+func (f *FileSessionStore) Delete(_ context.Context, key string) error {
+	if err := os.Remove(f.valuePath(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// Keys returns the stored keys starting with prefix, sorted.
+func (f *FileSessionStore) Keys(_ context.Context, prefix string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(f.dir, "values"))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, entry := range entries {
+		// Skips other files, such as those of writes in progress
+		name, ok := strings.CutPrefix(entry.Name(), storeFilePrefix)
+		if !ok {
+			continue
+		}
+		key, err := url.PathUnescape(name)
+		if err == nil && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	return keys, nil
+}
+
+// Append adds record to the end of log.
+func (f *FileSessionStore) Append(_ context.Context, log string, record json.RawMessage) error {
+	// One record per line
+	var line bytes.Buffer
+	if err := json.Compact(&line, record); err != nil {
+		return err
+	}
+	line.WriteByte('\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.logPath(log), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(line.Bytes()); err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	return file.Close()
+}
+
+// Records returns the records of log, oldest first.
+func (f *FileSessionStore) Records(_ context.Context, log string) ([]json.RawMessage, error) {
+	file, err := os.Open(f.logPath(log))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var records []json.RawMessage
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxSessionStoreRecord)
+	for scanner.Scan() {
+		// A line cut short by a crash is skipped
+		if json.Valid(scanner.Bytes()) {
+			records = append(records, slices.Clone(scanner.Bytes()))
+		}
+	}
+
+	return records, scanner.Err()
+}
+
+// valuePath returns the file holding the value of key.
+func (f *FileSessionStore) valuePath(key string) string {
+	return filepath.Join(f.dir, "values", storeFileName(key))
+}
+
+// logPath returns the file holding log.
+func (f *FileSessionStore) logPath(log string) string {
+	return filepath.Join(f.dir, "logs", storeFileName(log)+".jsonl")
+}
+
+// storeFileName returns the file name of key: escaped, so no key names a
+// file outside the store or one of another key, and prefixed, so empty and
+// dot keys are names too.
+func storeFileName(key string) string {
+	return storeFilePrefix + url.PathEscape(key)
+}
@@ -0,0 +1,293 @@
+package claude
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// envConfigDir overrides where the CLI keeps its configuration and
+	// sessions.
+	envConfigDir = "CLAUDE_CONFIG_DIR"
+	// sessionEntryTitle is the transcript entry holding a session's
+	// label, as written by the CLI's /rename command.
+	sessionEntryTitle = "custom-title"
+	// maxTranscriptLine bounds a transcript line ListSessions reads.
+	maxTranscriptLine = 16 << 20
+)
+
+// projectDirChars are the characters the CLI replaces with "-" when
+// naming a project's session directory after its working directory.
+var projectDirChars = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// SessionInfo describes a session stored by the CLI, which can be resumed
+// with Options.Resume.
+type SessionInfo struct {
+	ID string
+	// Label is the name set with SetSessionLabel or the CLI's /rename.
+	Label string
+	// Summary is the CLI's summary of the conversation, if it wrote one.
+	Summary   string
+	Cwd       string
+	GitBranch string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Messages counts the user and assistant messages.
+	Messages     int
+	InputTokens  int
+	OutputTokens int
+	// CostUSD is the cost recorded in the transcript. Recent CLI versions
+	// don't record it, leaving it zero; price the token counts instead.
+	CostUSD float64
+	// Cost is CostUSD summed exactly.
+	Cost USD
+	// Path is the transcript file.
+	Path string
+}
+
+// SessionFilter selects the sessions ListSessions returns.
+type SessionFilter struct {
+	// Cwd, if set, limits sessions to those started in this directory.
+	Cwd string
+	// Label, if set, limits sessions to those whose label contains it.
+	Label string
+	// Since, if set, limits sessions to those updated after it.
+	Since time.Time
+	// Limit, if positive, is the most sessions returned.
+	Limit int
+	// ConfigDir is the CLI configuration directory. It defaults to
+	// $CLAUDE_CONFIG_DIR or ~/.claude.
+	ConfigDir string
+}
+
+// transcriptEntry holds the transcript fields ListSessions reads.
+type transcriptEntry struct {
+	Type        string    `json:"type"`
+	SessionID   string    `json:"sessionId"`
+	Timestamp   time.Time `json:"timestamp"`
+	Cwd         string    `json:"cwd"`
+	GitBranch   string    `json:"gitBranch"`
+	Summary     string    `json:"summary"`
+	CustomTitle *string   `json:"customTitle"`
+	CostUSD     USD       `json:"costUSD"`
+	Message     struct {
+		Usage *Usage `json:"usage"`
+	} `json:"message"`
+}
+
+// ListSessions returns the sessions stored by the CLI that match filter,
+// most recently updated first. Unreadable transcripts are skipped.
+func ListSessions(ctx context.Context, filter SessionFilter) ([]SessionInfo, error) {
+	projects := filepath.Join(configDir(filter.ConfigDir, nil), "projects")
+
+	var dirs []string
+	if filter.Cwd != "" {
+		dirs = projectDirs(projects, filter.Cwd)
+	} else {
+		entries, err := os.ReadDir(projects)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				dirs = append(dirs, filepath.Join(projects, entry.Name()))
+			}
+		}
+	}
+
+	var sessions []SessionInfo
+	for _, dir := range dirs {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+		for _, file := range files {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			// Subagent transcripts are part of their parent session
+			if strings.HasPrefix(filepath.Base(file), "agent-") {
+				continue
+			}
+
+			info, err := readSessionInfo(file)
+			if err != nil || !filter.matches(info) {
+				continue
+			}
+			sessions = append(sessions, *info)
+		}
+	}
+
+	slices.SortFunc(sessions, func(a, b SessionInfo) int {
+		return b.UpdatedAt.Compare(a.UpdatedAt)
+	})
+	if filter.Limit > 0 && len(sessions) > filter.Limit {
+		sessions = sessions[:filter.Limit]
+	}
+
+	return sessions, nil
+}
+
+// matches reports whether info passes the filter.
+func (f SessionFilter) matches(info *SessionInfo) bool {
+	return (f.Label == "" || strings.Contains(info.Label, f.Label)) &&
+		(f.Since.IsZero() || info.UpdatedAt.After(f.Since))
+}
+
+// readSessionInfo summarizes a transcript file.
+func readSessionInfo(path string) (*SessionInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	info := &SessionInfo{ID: strings.TrimSuffix(filepath.Base(path), ".jsonl"), Path: path}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxTranscriptLine)
+	for scanner.Scan() {
+		var entry transcriptEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+
+		switch entry.Type {
+		case "user", "assistant":
+			info.Messages++
+		case "summary":
+			info.Summary = entry.Summary
+		case sessionEntryTitle:
+			if entry.CustomTitle != nil {
+				info.Label = *entry.CustomTitle
+			}
+		}
+		if !entry.Timestamp.IsZero() {
+			if info.CreatedAt.IsZero() {
+				info.CreatedAt = entry.Timestamp
+			}
+			info.UpdatedAt = entry.Timestamp
+		}
+		if entry.Cwd != "" {
+			info.Cwd = entry.Cwd
+		}
+		if entry.GitBranch != "" {
+			info.GitBranch = entry.GitBranch
+		}
+		if usage := entry.Message.Usage; entry.Type == "assistant" && usage != nil {
+			info.InputTokens += usage.InputTokens
+			info.OutputTokens += usage.OutputTokens
+		}
+		info.Cost += entry.CostUSD
+	}
+	info.CostUSD = info.Cost.Float64()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// SetSessionLabel names the client's current session, so it can be found
+// with ListSessions and in the CLI's resume picker. The label is stored in
+// the session's transcript; an empty label clears it. The session must
+// have started, i.e. its first message been received.
+func (c *ClaudeSDKClient) SetSessionLabel(ctx context.Context, label string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sessionID := c.sessionID.Load()
+	if sessionID == nil {
+		return clauderrs.NewClientError(
+			clauderrs.ErrCodeNoActiveQuery,
+			"session has not started yet",
+			nil,
+		)
+	}
+
+	cwd := c.opts.Cwd
+	if cwd == "" {
+		cwd, _ = os.Getwd()
+	}
+	projects := filepath.Join(configDir("", c.opts.Env), "projects")
+
+	var path string
+	for _, dir := range projectDirs(projects, cwd) {
+		candidate := filepath.Join(dir, *sessionID+".jsonl")
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+
+			break
+		}
+	}
+	if path == "" {
+		return clauderrs.NewClientError(
+			clauderrs.ErrCodeSessionNotFound,
+			fmt.Sprintf("no transcript found for session %s under %s", *sessionID, projects),
+			nil,
+		).
+			WithSessionID(*sessionID)
+	}
+
+	entry, err := json.Marshal(map[string]string{
+		"type":        sessionEntryTitle,
+		"customTitle": label,
+		"sessionId":   *sessionID,
+	})
+	if err != nil {
+		return err
+	}
+
+	// A single appended line, so it can't interleave with the CLI's own
+	// writes to the transcript
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(entry, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// configDir returns the CLI configuration directory: dir if set, then
+// CLAUDE_CONFIG_DIR from env or the environment, then ~/.claude.
+func configDir(dir string, env map[string]string) string {
+	if dir != "" {
+		return dir
+	}
+	if value, ok := env[envConfigDir]; ok && value != "" {
+		return value
+	}
+	if value := os.Getenv(envConfigDir); value != "" {
+		return value
+	}
+	home, _ := os.UserHomeDir()
+
+	return filepath.Join(home, ".claude")
+}
+
+// projectDirs returns the session directories the CLI may have used for
+// cwd, as given and with symlinks resolved.
+func projectDirs(projects, cwd string) []string {
+	abs, err := filepath.Abs(cwd)
+	if err != nil {
+		abs = cwd
+	}
+	dirs := []string{filepath.Join(projects, projectDirChars.ReplaceAllString(abs, "-"))}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil && resolved != abs {
+		dirs = append(dirs, filepath.Join(projects, projectDirChars.ReplaceAllString(resolved, "-")))
+	}
+
+	return dirs
+}
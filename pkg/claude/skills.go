@@ -0,0 +1,435 @@
+package claude
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// skillFileName is the file that defines a skill.
+	skillFileName = "SKILL.md"
+	// skillsPluginName names the plugin the SDK generates to hand
+	// configured skills to the CLI. Its skills are namespaced by the CLI
+	// as "sdk-skills:<name>".
+	skillsPluginName = "sdk-skills"
+	// skillToolName is the tool Claude uses to invoke a skill.
+	skillToolName = "Skill"
+
+	maxSkillNameLength        = 64
+	maxSkillDescriptionLength = 1024
+)
+
+// HookEventSkillInvoked is an SDK-side hook event fired when Claude invokes
+// a skill. The CLI has no such event; the SDK derives it from PreToolUse
+// calls of the Skill tool, so hooks registered for it may deny the
+// invocation like a PreToolUse hook. Matchers match the skill name. It is
+// not part of HookEvents, which lists the CLI's events.
+const HookEventSkillInvoked HookEvent = "SkillInvoked"
+
+// skillNamePattern is the format required of skill names.
+var skillNamePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// SkillConfig points the SDK at skills to make available to Claude.
+type SkillConfig struct {
+	// Path is a skill directory containing a SKILL.md file, or a directory
+	// whose subdirectories are skill directories.
+	Path string `json:"path"`
+}
+
+// Skill is a skill defined by a SKILL.md file.
+type Skill struct {
+	// Name and Description come from the SKILL.md frontmatter.
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// AllowedTools restricts the tools Claude may use while the skill is
+	// active, from the allowed-tools frontmatter field.
+	AllowedTools []string `json:"allowedTools,omitempty"`
+	// Path is the skill directory.
+	Path string `json:"path"`
+	// Source is where the skill was found: "sdk" for Options.Skills, or
+	// the settings scope ("project" or "user") it was discovered in.
+	Source string `json:"source"`
+	// Available is set by ClaudeSDKClient.Skills when the CLI reports the
+	// skill as invocable.
+	Available bool `json:"available"`
+}
+
+// SkillInvokedHookInput is the input to HookEventSkillInvoked hooks.
+type SkillInvokedHookInput struct {
+	BaseHookInput
+	HookEventName HookEvent `json:"hook_event_name"`
+	// Skill is the invoked skill's name as Claude called it.
+	Skill string `json:"skill"`
+	// Args are the arguments Claude passed to the skill, if any.
+	Args      string `json:"args,omitempty"`
+	ToolUseID string `json:"tool_use_id"`
+}
+
+func (SkillInvokedHookInput) hookInput()           {}
+func (SkillInvokedHookInput) EventName() HookEvent { return HookEventSkillInvoked }
+
+// LoadSkills loads the skill at path, or every skill in the immediate
+// subdirectories of path if path has no SKILL.md of its own.
+func LoadSkills(path string) ([]Skill, error) {
+	skill, err := LoadSkill(path)
+	if err == nil {
+		return []Skill{*skill}, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidConfig,
+			fmt.Sprintf("failed to read skills directory %s", path),
+			err,
+			"path",
+			path,
+		)
+	}
+
+	var skills []Skill
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		skill, err := LoadSkill(filepath.Join(path, entry.Name()))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		skills = append(skills, *skill)
+	}
+
+	return skills, nil
+}
+
+// LoadSkill loads the skill defined by dir/SKILL.md. The error wraps
+// fs.ErrNotExist if dir has no SKILL.md.
+func LoadSkill(dir string) (*Skill, error) {
+	path := filepath.Join(dir, skillFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := parseFrontmatter(string(data))
+	if err != nil {
+		return nil, clauderrs.NewValidationError(clauderrs.ErrCodeInvalidFormat, err.Error(), nil, "path", path)
+	}
+
+	skill := &Skill{
+		Name:         fields["name"],
+		Description:  fields["description"],
+		AllowedTools: splitList(fields["allowed-tools"]),
+		Path:         dir,
+	}
+	if err := skill.validate(); err != nil {
+		return nil, clauderrs.NewValidationError(clauderrs.ErrCodeInvalidFormat, err.Error(), nil, "path", path)
+	}
+
+	return skill, nil
+}
+
+// validate checks the skill name and description.
+func (s *Skill) validate() error {
+	switch {
+	case s.Name == "":
+		return errors.New("skill frontmatter is missing name")
+	case len(s.Name) > maxSkillNameLength || !skillNamePattern.MatchString(s.Name):
+		return fmt.Errorf("skill name %q must be at most %d lowercase letters, digits and hyphens",
+			s.Name, maxSkillNameLength)
+	case s.Description == "":
+		return fmt.Errorf("skill %q frontmatter is missing description", s.Name)
+	case len(s.Description) > maxSkillDescriptionLength:
+		return fmt.Errorf("skill %q description exceeds %d characters", s.Name, maxSkillDescriptionLength)
+	}
+
+	return nil
+}
+
+// parseFrontmatter returns the top-level scalar and list fields of a
+// SKILL.md YAML frontmatter block. Lists are returned comma-separated.
+func parseFrontmatter(content string) (map[string]string, error) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "---" {
+		return nil, errors.New("SKILL.md must start with a --- frontmatter block")
+	}
+
+	fields := make(map[string]string)
+	var key string
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "---":
+			return fields, nil
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+		case strings.HasPrefix(trimmed, "- ") && key != "":
+			item := unquote(strings.TrimSpace(strings.TrimPrefix(trimmed, "- ")))
+			if fields[key] != "" {
+				item = fields[key] + ", " + item
+			}
+			fields[key] = item
+		case line[0] == ' ' || line[0] == '\t':
+			// Nested or continued values are not used by the SDK
+		default:
+			name, value, ok := strings.Cut(line, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid frontmatter line %q", line)
+			}
+			key = strings.TrimSpace(name)
+			value = strings.TrimSpace(value)
+			if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+				value = strings.Trim(value, "[]")
+			}
+			fields[key] = unquote(value)
+		}
+	}
+
+	return nil, errors.New("SKILL.md frontmatter is not closed with ---")
+}
+
+// unquote strips matching YAML quotes.
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+// splitList splits a comma-separated list, trimming quotes and spaces.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = unquote(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// configuredSkills loads the skills named by Options.Skills.
+func configuredSkills(opts *Options) ([]Skill, error) {
+	var skills []Skill
+	for _, config := range opts.Skills {
+		loaded, err := LoadSkills(config.Path)
+		if err != nil {
+			return nil, err
+		}
+		for i := range loaded {
+			loaded[i].Source = "sdk"
+		}
+		skills = append(skills, loaded...)
+	}
+
+	return skills, nil
+}
+
+// discoveredSkills loads the skills the CLI finds on its own in the
+// project and user skill directories enabled by SettingSources.
+func discoveredSkills(opts *Options) []Skill {
+	type skillDir struct {
+		scope ConfigScope
+		path  string
+	}
+
+	var dirs []skillDir
+	if slices.Contains(opts.SettingSources, ConfigScopeProject) {
+		cwd := opts.Cwd
+		if cwd == "" {
+			cwd, _ = os.Getwd()
+		}
+		dirs = append(dirs, skillDir{ConfigScopeProject, filepath.Join(cwd, ".claude", "skills")})
+	}
+	if slices.Contains(opts.SettingSources, ConfigScopeUser) {
+		if home, err := os.UserHomeDir(); err == nil {
+			dirs = append(dirs, skillDir{ConfigScopeUser, filepath.Join(home, ".claude", "skills")})
+		}
+	}
+
+	var skills []Skill
+	for _, dir := range dirs {
+		loaded, err := LoadSkills(dir.path)
+		if err != nil {
+			continue
+		}
+		for i := range loaded {
+			loaded[i].Source = string(dir.scope)
+		}
+		skills = append(skills, loaded...)
+	}
+
+	return skills
+}
+
+// prepareSkills writes a plugin directory exposing the configured skills
+// to the CLI and returns its path, or "" if no skills are configured.
+func prepareSkills(opts *Options) (string, error) {
+	skills, err := configuredSkills(opts)
+	if err != nil || len(skills) == 0 {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "claude-sdk-skills-*")
+	if err != nil {
+		return "", clauderrs.NewClientError(clauderrs.ErrCodeIOError, "failed to create skills plugin directory", err)
+	}
+
+	manifest, _ := json.Marshal(map[string]string{
+		"name":        skillsPluginName,
+		"description": "Skills configured through the Claude Agent SDK",
+	})
+	if err := os.MkdirAll(filepath.Join(dir, ".claude-plugin"), 0o700); err == nil {
+		err = os.WriteFile(filepath.Join(dir, ".claude-plugin", "plugin.json"), manifest, 0o600)
+	}
+	if err == nil {
+		err = os.Mkdir(filepath.Join(dir, "skills"), 0o700)
+	}
+	for _, skill := range skills {
+		if err != nil {
+			break
+		}
+
+		var target string
+		if target, err = filepath.Abs(skill.Path); err == nil {
+			err = os.Symlink(target, filepath.Join(dir, "skills", skill.Name))
+		}
+	}
+	if err != nil {
+		_ = os.RemoveAll(dir)
+
+		return "", clauderrs.NewClientError(clauderrs.ErrCodeIOError, "failed to prepare skills plugin directory", err)
+	}
+
+	return dir, nil
+}
+
+// removeSkillsDir deletes the generated skills plugin directory.
+func (q *queryImpl) removeSkillsDir() {
+	if q.skillsDir != "" {
+		_ = os.RemoveAll(q.skillsDir)
+	}
+}
+
+// skillHooks returns the PreToolUse hook that fires HookEventSkillInvoked
+// hooks, or nil if none are registered.
+func (q *queryImpl) skillHooks() map[HookEvent][]HookCallbackMatcher {
+	if len(q.opts.Hooks[HookEventSkillInvoked]) == 0 {
+		return nil
+	}
+
+	matcher := skillToolName
+
+	return map[HookEvent][]HookCallbackMatcher{
+		HookEventPreToolUse: {{Matcher: &matcher, Hooks: []HookCallback{q.dispatchSkillInvoked}}},
+	}
+}
+
+// dispatchSkillInvoked runs HookEventSkillInvoked hooks for a Skill tool
+// call. The first hook returning a non-empty output decides.
+func (q *queryImpl) dispatchSkillInvoked(
+	ctx context.Context,
+	input HookInput,
+	toolUseID *string,
+) (HookJSONOutput, error) {
+	pre, ok := input.(PreToolUseHookInput)
+	if !ok {
+		return SyncHookOutput{}, nil
+	}
+
+	var call struct {
+		Skill   string `json:"skill"`
+		Command string `json:"command"`
+		Args    string `json:"args"`
+	}
+	_ = json.Unmarshal(pre.ToolInput, &call)
+	if call.Skill == "" {
+		call.Skill = call.Command
+	}
+
+	skillInput := SkillInvokedHookInput{
+		BaseHookInput: pre.BaseHookInput,
+		HookEventName: HookEventSkillInvoked,
+		Skill:         call.Skill,
+		Args:          call.Args,
+		ToolUseID:     pre.ToolUseID,
+	}
+
+	for _, matcher := range q.opts.Hooks[HookEventSkillInvoked] {
+		if matcher.Matcher != nil && *matcher.Matcher != "" {
+			re, err := regexp.Compile("^(?:" + *matcher.Matcher + ")$")
+			if err != nil || !re.MatchString(call.Skill) {
+				continue
+			}
+		}
+
+		for _, hook := range matcher.Hooks {
+			output, err := hook(ctx, skillInput, toolUseID)
+			if err != nil {
+				return nil, err
+			}
+			if data, _ := json.Marshal(output); output != nil && string(data) != "{}" {
+				return output, nil
+			}
+		}
+	}
+
+	return SyncHookOutput{}, nil
+}
+
+// Skills lists the skills configured in Options.Skills and those the CLI
+// discovers in the project and user skill directories enabled by
+// SettingSources. With an active session, each skill's Available field
+// reports whether the CLI lists it among its supported commands.
+func (c *ClaudeSDKClient) Skills(ctx context.Context) ([]Skill, error) {
+	skills, err := configuredSkills(c.opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, skill := range discoveredSkills(c.opts) {
+		if !slices.ContainsFunc(skills, func(s Skill) bool { return s.Name == skill.Name }) {
+			skills = append(skills, skill)
+		}
+	}
+
+	c.mu.Lock()
+	q := c.query
+	c.mu.Unlock()
+	if q == nil {
+		return skills, nil
+	}
+
+	commands, err := q.SupportedCommands(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range skills {
+		skills[i].Available = slices.ContainsFunc(commands, func(cmd SlashCommand) bool {
+			name := strings.TrimPrefix(cmd.Name, "/")
+
+			return name == skills[i].Name || name == skillsPluginName+":"+skills[i].Name
+		})
+	}
+
+	return skills, nil
+}
@@ -0,0 +1,169 @@
+package claude
+
+import "context"
+
+// ToolEvent is a tool call or a tool result from SplitStream.
+type ToolEvent struct {
+	// Use is set for a tool call and Result for a tool result.
+	Use    *ToolUseContentBlock
+	Result *ToolResultContentBlock
+	// ParentToolUseID is the Task tool use of the subagent that made the
+	// call, or "" for the main agent.
+	ParentToolUseID string
+	// Message is the assistant or user message carrying the block.
+	Message SDKMessage
+}
+
+// StreamDelta is a content_block_delta stream event from SplitStream.
+type StreamDelta struct {
+	// Index is the content block the delta extends.
+	Index int
+	Delta ContentDelta
+	// ParentToolUseID is the Task tool use of the subagent streaming, or
+	// "" for the main agent.
+	ParentToolUseID string
+}
+
+// SplitStreams are the typed channels returned by SplitStream.
+type SplitStreams struct {
+	Assistant <-chan *SDKAssistantMessage
+	// ToolEvents receives the tool calls of assistant messages and the
+	// tool results of user messages.
+	ToolEvents <-chan ToolEvent
+	Results    <-chan *SDKResultMessage
+	System     <-chan *SDKSystemMessage
+	// Deltas receives the content deltas of partial messages, sent with
+	// IncludePartialMessages.
+	Deltas <-chan StreamDelta
+	// Other receives the remaining messages: user messages without tool
+	// results and stream events other than deltas.
+	Other <-chan SDKMessage
+}
+
+// SplitStream routes the messages of msgs, such as those returned by
+// ClaudeSDKClient.ReceiveMessages, to a typed channel per category, so
+// each category can be handled by its own goroutine. Assistant messages
+// carrying tool calls go to both Assistant and ToolEvents.
+//
+// All channels are closed once msgs is closed or ctx is done. Every
+// channel must be drained, as a full channel holds up the others; range
+// over the channels of categories you don't need.
+func SplitStream(ctx context.Context, msgs <-chan SDKMessage) SplitStreams {
+	assistant := make(chan *SDKAssistantMessage, defaultMessageChannelBuffer)
+	toolEvents := make(chan ToolEvent, defaultMessageChannelBuffer)
+	results := make(chan *SDKResultMessage, defaultMessageChannelBuffer)
+	system := make(chan *SDKSystemMessage, defaultMessageChannelBuffer)
+	deltas := make(chan StreamDelta, defaultMessageChannelBuffer)
+	other := make(chan SDKMessage, defaultMessageChannelBuffer)
+
+	go func() {
+		defer close(assistant)
+		defer close(toolEvents)
+		defer close(results)
+		defer close(system)
+		defer close(deltas)
+		defer close(other)
+
+		for {
+			var msg SDKMessage
+			var ok bool
+			select {
+			case msg, ok = <-msgs:
+			case <-ctx.Done():
+				return
+			}
+			if !ok || !routeMessage(ctx, msg, assistant, toolEvents, results, system, deltas, other) {
+				return
+			}
+		}
+	}()
+
+	return SplitStreams{
+		Assistant:  assistant,
+		ToolEvents: toolEvents,
+		Results:    results,
+		System:     system,
+		Deltas:     deltas,
+		Other:      other,
+	}
+}
+
+// routeMessage sends msg to its channels. It returns false if ctx is done.
+func routeMessage(
+	ctx context.Context,
+	msg SDKMessage,
+	assistant chan<- *SDKAssistantMessage,
+	toolEvents chan<- ToolEvent,
+	results chan<- *SDKResultMessage,
+	system chan<- *SDKSystemMessage,
+	deltas chan<- StreamDelta,
+	other chan<- SDKMessage,
+) bool {
+	switch m := msg.(type) {
+	case *SDKAssistantMessage:
+		if !send(ctx, assistant, m) {
+			return false
+		}
+		parent := parentToolUseID(m.ParentToolUseID)
+		for _, block := range m.Message.Content {
+			if use, ok := block.(ToolUseContentBlock); ok {
+				event := ToolEvent{Use: &use, ParentToolUseID: parent, Message: m}
+				if !send(ctx, toolEvents, event) {
+					return false
+				}
+			}
+		}
+
+		return true
+	case *SDKUserMessage:
+		parent := parentToolUseID(m.ParentToolUseID)
+		found := false
+		for _, block := range m.Message.Content {
+			if result, ok := block.(ToolResultContentBlock); ok {
+				found = true
+				event := ToolEvent{Result: &result, ParentToolUseID: parent, Message: m}
+				if !send(ctx, toolEvents, event) {
+					return false
+				}
+			}
+		}
+		if found {
+			return true
+		}
+	case *SDKResultMessage:
+		return send(ctx, results, m)
+	case *SDKSystemMessage:
+		return send(ctx, system, m)
+	case *SDKStreamEvent:
+		if event, ok := m.Event.(ContentBlockDeltaEvent); ok {
+			delta := StreamDelta{
+				Index:           event.Index,
+				Delta:           event.Delta,
+				ParentToolUseID: parentToolUseID(m.ParentToolUseID),
+			}
+
+			return send(ctx, deltas, delta)
+		}
+	}
+
+	return send(ctx, other, msg)
+}
+
+// send sends v on ch unless ctx is done first.
+func send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// parentToolUseID dereferences a message's parent tool use ID.
+func parentToolUseID(id *string) string {
+	if id == nil {
+		return ""
+	}
+
+	return *id
+}
@@ -0,0 +1,146 @@
+package claude
+
+import (
+	"strings"
+	"sync"
+)
+
+// streamDedup removes from assistant messages the text and thinking
+// already delivered as partial message deltas, for
+// Options.SuppressFinalDuplicate. Blocks are matched by content, so output
+// that wasn't streamed, such as deltas dropped by a StreamEventFilter, is
+// kept.
+type streamDedup struct {
+	mu     sync.Mutex
+	scopes map[string]*streamedBlocks // By parent tool use ID, "" for the main conversation
+}
+
+// streamedBlocks are the blocks of one conversation streamed since its
+// last message_start and not yet matched to an assistant message.
+type streamedBlocks struct {
+	open map[int]*streamedBlock // Blocks still streaming, by index
+	done []*streamedBlock       // Blocks streamed in full
+}
+
+// streamedBlock is the text or thinking streamed for one content block.
+type streamedBlock struct {
+	thinking bool
+	text     strings.Builder
+}
+
+// filter records the deltas of stream events and returns msg, or a copy of
+// an assistant message without its streamed blocks.
+func (d *streamDedup) filter(msg SDKMessage) SDKMessage {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch m := msg.(type) {
+	case *SDKStreamEvent:
+		d.record(d.scope(m.ParentToolUseID), m.Event)
+	case *SDKAssistantMessage:
+		scope := d.scope(m.ParentToolUseID)
+		if len(scope.open) == 0 && len(scope.done) == 0 {
+			return msg
+		}
+
+		kept := make([]ContentBlock, 0, len(m.Message.Content))
+		for _, block := range m.Message.Content {
+			if !scope.take(block) {
+				kept = append(kept, block)
+			}
+		}
+		if len(kept) == len(m.Message.Content) {
+			return msg
+		}
+
+		deduped := *m
+		deduped.Message.Content = kept
+
+		return &deduped
+	}
+
+	return msg
+}
+
+// scope returns the blocks streamed in the conversation of parent.
+func (d *streamDedup) scope(parent *string) *streamedBlocks {
+	key := ""
+	if parent != nil {
+		key = *parent
+	}
+	if d.scopes == nil {
+		d.scopes = make(map[string]*streamedBlocks)
+	}
+	scope, ok := d.scopes[key]
+	if !ok {
+		scope = &streamedBlocks{open: make(map[int]*streamedBlock)}
+		d.scopes[key] = scope
+	}
+
+	return scope
+}
+
+// record adds the text and thinking of a stream event.
+func (d *streamDedup) record(scope *streamedBlocks, event RawMessageStreamEvent) {
+	switch e := event.(type) {
+	case MessageStartEvent:
+		scope.open = make(map[int]*streamedBlock)
+		scope.done = nil
+	case ContentBlockDeltaEvent:
+		var fragment string
+		thinking := false
+		switch {
+		case e.Delta.TextDelta != nil:
+			fragment = *e.Delta.TextDelta
+		case e.Delta.ThinkingDelta != nil:
+			fragment, thinking = *e.Delta.ThinkingDelta, true
+		default:
+			return
+		}
+		block, ok := scope.open[e.Index]
+		if !ok {
+			block = &streamedBlock{thinking: thinking}
+			scope.open[e.Index] = block
+		}
+		block.text.WriteString(fragment)
+	case ContentBlockStopEvent:
+		if block, ok := scope.open[e.Index]; ok {
+			delete(scope.open, e.Index)
+			scope.done = append(scope.done, block)
+		}
+	}
+}
+
+// take reports whether block was streamed, forgetting the streamed block
+// it matches.
+func (s *streamedBlocks) take(block ContentBlock) bool {
+	var text string
+	thinking := false
+	switch b := block.(type) {
+	case TextContentBlock:
+		text = b.Text
+	case TextBlock:
+		text = b.Text
+	case ThinkingBlock:
+		text, thinking = b.Thinking, true
+	default:
+		return false
+	}
+
+	for i, streamed := range s.done {
+		if streamed.thinking == thinking && streamed.text.String() == text {
+			s.done = append(s.done[:i], s.done[i+1:]...)
+
+			return true
+		}
+	}
+	for index, streamed := range s.open {
+		if streamed.thinking == thinking && streamed.text.String() == text {
+			delete(s.open, index)
+
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,56 @@
+package claude
+
+import "encoding/json"
+
+// StreamEventFilter selects which partial message events are delivered.
+// Events it excludes are dropped after reading only their type, so servers
+// that need one kind of delta don't pay to decode the rest.
+type StreamEventFilter struct {
+	// TextDeltas delivers text_delta content_block_delta events.
+	TextDeltas bool
+	// ThinkingDeltas delivers thinking_delta and signature_delta
+	// content_block_delta events.
+	ThinkingDeltas bool
+	// ToolInputDeltas delivers input_json_delta content_block_delta
+	// events, which stream tool_use input as it is generated.
+	ToolInputDeltas bool
+	// Lifecycle delivers message_start, message_delta, message_stop,
+	// content_block_start and content_block_stop events.
+	Lifecycle bool
+}
+
+// allows reports whether the stream_event frame data passes the filter. A
+// nil filter allows every event.
+func (f *StreamEventFilter) allows(data []byte) bool {
+	if f == nil {
+		return true
+	}
+
+	var frame struct {
+		Event struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Type string `json:"type"`
+			} `json:"delta"`
+		} `json:"event"`
+	}
+	if err := json.Unmarshal(data, &frame); err != nil {
+		// Let the full decode report the malformed frame
+		return true
+	}
+
+	if frame.Event.Type != ContentBlockDelta {
+		return f.Lifecycle
+	}
+
+	switch frame.Event.Delta.Type {
+	case "text_delta":
+		return f.TextDeltas
+	case "thinking_delta", "signature_delta":
+		return f.ThinkingDeltas
+	case "input_json_delta":
+		return f.ToolInputDeltas
+	default:
+		return false
+	}
+}
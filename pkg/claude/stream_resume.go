@@ -0,0 +1,177 @@
+package claude
+
+import (
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// defaultResumePrompt asks the resumed session to pick up the turn.
+	defaultResumePrompt = "Your previous response was cut off by a connection " +
+		"failure. Continue exactly where it stopped, without repeating what " +
+		"was already said."
+
+	defaultResumeAttempts = 2
+)
+
+// StreamResume makes ClaudeSDKClient.ReceiveResponse survive a CLI stream
+// that drops mid-turn, because the process crashed or its transport
+// failed. The session is resumed in a new process and asked to continue,
+// and its messages follow those already delivered; only its repeated init
+// message is dropped.
+//
+// With IncludePartialMessages, the interrupted response is closed before
+// the resumed one starts: each content block it left open is stopped with
+// a content_block_stop event and the message with a message_stop event,
+// both made up by the SDK, so the stream stays well-formed for consumers
+// assembling it. The resumed session answers with a new response, under a
+// new message ID, so what was delivered of the interrupted one is not
+// deduplicated: the model may repeat or rephrase part of it. Use OnResume
+// to mark where the interrupted response ends.
+type StreamResume struct {
+	// MaxAttempts bounds how many times a turn is resumed. Zero means two.
+	MaxAttempts int
+	// Prompt is sent to the resumed session. Empty uses a default asking
+	// Claude to continue where it stopped.
+	Prompt string
+	// OnResume, if set, is called with the attempt number, from one, and
+	// the error that dropped the stream, before the session is resumed.
+	OnResume func(attempt int, cause error)
+}
+
+// shouldResumeStream reports whether a turn whose stream ended with err
+// can be resumed. Streams end with io.EOF when the CLI exits, which
+// before the result means the turn was cut off.
+func (c *ClaudeSDKClient) shouldResumeStream(err error, attempts int) bool {
+	config := c.opts.StreamResume
+	if config == nil || c.sessionID.Load() == nil {
+		return false
+	}
+
+	limit := config.MaxAttempts
+	if limit <= 0 {
+		limit = defaultResumeAttempts
+	}
+	if attempts >= limit {
+		return false
+	}
+
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+
+	return !closed && (err == io.EOF || clauderrs.IsRetryable(err))
+}
+
+// resumeStream replaces the dropped session with a resumed one asked to
+// continue the turn.
+func (c *ClaudeSDKClient) resumeStream(attempt int, cause error) error {
+	config := c.opts.StreamResume
+	if config.OnResume != nil {
+		config.OnResume(attempt, cause)
+	}
+
+	opts := *c.opts
+	opts.Continue = false
+	opts.Resume = *c.sessionID.Load()
+	opts.ResumeSessionAt = ""
+	opts.ForkSession = false
+
+	prompt := config.Prompt
+	if prompt == "" {
+		prompt = defaultResumePrompt
+	}
+	q, err := c.newQuery(prompt, &opts)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		_ = q.Close()
+
+		return clauderrs.NewClientError(
+			clauderrs.ErrCodeClientClosed,
+			"client is closed",
+			nil,
+		)
+	}
+	old := c.query
+	c.query = q
+	c.mu.Unlock()
+
+	// The dropped process is gone or unusable; closing it only cleans up
+	_ = old.Close()
+
+	return nil
+}
+
+// openStream tracks the streamed messages delivered but not yet stopped,
+// by parent tool use ID, so a resumed turn can close them.
+type openStream map[string]*openMessage
+
+// openMessage is a streamed message and its content blocks not yet
+// stopped, in the order they started.
+type openMessage struct {
+	parentToolUseID *string
+	blocks          []int
+}
+
+// observe records a delivered message.
+func (o openStream) observe(msg SDKMessage) {
+	event, ok := msg.(*SDKStreamEvent)
+	if !ok {
+		return
+	}
+	var key string
+	if event.ParentToolUseID != nil {
+		key = *event.ParentToolUseID
+	}
+
+	switch e := event.Event.(type) {
+	case MessageStartEvent:
+		o[key] = &openMessage{parentToolUseID: event.ParentToolUseID}
+	case ContentBlockStartEvent:
+		if message := o[key]; message != nil {
+			message.blocks = append(message.blocks, e.Index)
+		}
+	case ContentBlockStopEvent:
+		if message := o[key]; message != nil {
+			message.blocks = slices.DeleteFunc(message.blocks, func(i int) bool { return i == e.Index })
+		}
+	case MessageStopEvent:
+		delete(o, key)
+	}
+}
+
+// close returns the events stopping the open blocks, then their messages,
+// and forgets them.
+func (o openStream) close(opts *Options, sessionID string) []SDKMessage {
+	keys := slices.Sorted(maps.Keys(o))
+
+	var events []SDKMessage
+	event := func(message *openMessage, e RawMessageStreamEvent) {
+		events = append(events, &SDKStreamEvent{
+			BaseMessage: BaseMessage{
+				UUIDField:      opts.newUUID(),
+				SessionIDField: sessionID,
+			},
+			Event:           e,
+			ParentToolUseID: message.parentToolUseID,
+		})
+	}
+	for _, key := range keys {
+		message := o[key]
+		for _, index := range message.blocks {
+			event(message, ContentBlockStopEvent{Type: "content_block_stop", Index: index})
+		}
+		event(message, MessageStopEvent{Type: "message_stop"})
+		delete(o, key)
+	}
+
+	return events
+}
@@ -0,0 +1,89 @@
+package claude
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Directions of TeeRecord frames.
+const (
+	// TeeInbound marks frames read from the CLI.
+	TeeInbound = "inbound"
+	// TeeOutbound marks frames written to the CLI.
+	TeeOutbound = "outbound"
+)
+
+// TeeRecord is one line of TeeJSONL output.
+//
+// Each line is a JSON object of the form
+//
+//	{"direction":"inbound","timestamp":"2025-01-02T15:04:05.999999999Z","message":{...}}
+//
+// where message is the stream-json frame exactly as exchanged with the CLI:
+// SDK messages and stream events, and control requests and responses in
+// either direction. Timestamps are RFC 3339 with nanoseconds, in UTC.
+type TeeRecord struct {
+	Direction string          `json:"direction"`
+	Timestamp time.Time       `json:"timestamp"`
+	Message   json.RawMessage `json:"message"`
+}
+
+// frameTee mirrors frames to a writer as TeeRecord lines.
+type frameTee struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time // Timestamps records
+	err error
+}
+
+// record writes a frame. Writes are serialized so lines never interleave;
+// after the first write error the tee stops writing. A nil tee does
+// nothing.
+func (t *frameTee) record(direction string, frame []byte) {
+	if t == nil {
+		return
+	}
+
+	line, err := json.Marshal(TeeRecord{
+		Direction: direction,
+		Timestamp: t.now().UTC(),
+		Message:   json.RawMessage(redactAuthToken(frame)),
+	})
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.err != nil {
+		return
+	}
+	_, t.err = t.w.Write(append(line, '\n'))
+}
+
+// TeeJSONL mirrors every frame exchanged with the CLI to w as JSON Lines,
+// one TeeRecord per line, so a session can be piped into jq, a log
+// aggregator, or loaded with the debugger package.
+//
+// The tee applies to the active session and to sessions the client starts
+// later. Calling TeeJSONL again replaces the writer; a nil w stops
+// mirroring. Writes to w happen on the client's I/O goroutines, so a slow
+// writer slows the session; after the first write error, mirroring to w
+// stops. Records are timestamped with Options.Now, if set.
+func (c *ClaudeSDKClient) TeeJSONL(w io.Writer) {
+	var tee *frameTee
+	if w != nil {
+		tee = &frameTee{w: w, now: c.opts.now}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tee.Store(tee)
+	if q, ok := c.query.(*queryImpl); ok {
+		q.tee.Store(tee)
+	}
+}
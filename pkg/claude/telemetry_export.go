@@ -0,0 +1,369 @@
+package claude
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/parquet"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// ExportFormat is the file format of exported telemetry.
+type ExportFormat string
+
+const (
+	// ExportCSV writes CSV files with a header row. Timestamps are RFC 3339
+	// in UTC.
+	ExportCSV ExportFormat = "csv"
+	// ExportParquet writes Parquet files with required columns; strings
+	// are UTF8 and timestamps are microseconds in UTC.
+	ExportParquet ExportFormat = "parquet"
+)
+
+// Directories of the tables TelemetryExport writes, under its Dir.
+const (
+	sessionReportsTable = "session_reports"
+	toolStatsTable      = "tool_stats"
+	annotationsTable    = "annotations"
+)
+
+// TelemetryExport writes a client's SessionReport and ToolStats to files,
+// for data warehouses to ingest. Each session has one file per table, named
+// by its session ID: Dir/session_reports/<id>.<format>, with one row, and
+// Dir/tool_stats/<id>.<format>, with a row per tool. Sessions annotated
+// with ClaudeSDKClient.Annotate also have Dir/annotations/<id>.<format>,
+// with a row per annotation. The files are
+// rewritten with the session's totals when the client closes and, if set,
+// every Interval; each write replaces the file atomically, so readers never
+// see a partial one. Nothing is written before the session ID is known.
+type TelemetryExport struct {
+	// Dir is the root directory of the tables. It is created if needed.
+	Dir string
+	// Format is the file format. Empty means CSV.
+	Format ExportFormat
+	// Interval is how often the files are rewritten while the client is
+	// open. Zero writes them only on Close.
+	Interval time.Duration
+	// OnError, if set, is called with the errors of writes on Interval.
+	// The error of the write on Close is returned by Close.
+	OnError func(error)
+}
+
+// validate checks the export's directory, format and interval.
+func (e *TelemetryExport) validate() []error {
+	var errs []error
+	if e.Dir == "" {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeMissingField,
+			"TelemetryExport.Dir is required",
+			nil,
+			"TelemetryExport.Dir",
+			e.Dir,
+		))
+	}
+	switch e.Format {
+	case "", ExportCSV, ExportParquet:
+	default:
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidFormat,
+			fmt.Sprintf("unknown telemetry export format %q", e.Format),
+			nil,
+			"TelemetryExport.Format",
+			e.Format,
+		))
+	}
+	if e.Interval < 0 {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"TelemetryExport.Interval must not be negative",
+			nil,
+			"TelemetryExport.Interval",
+			e.Interval,
+		))
+	}
+
+	return errs
+}
+
+// format returns Format or its default.
+func (e *TelemetryExport) format() ExportFormat {
+	if e.Format == "" {
+		return ExportCSV
+	}
+
+	return e.Format
+}
+
+// WriteSessionReports writes reports to w in format, one row each.
+func WriteSessionReports(w io.Writer, format ExportFormat, reports ...SessionReport) error {
+	table := telemetryTable{fields: []parquet.Field{
+		{Name: "session_id", Type: parquet.String},
+		{Name: "model", Type: parquet.String},
+		{Name: "started_at", Type: parquet.Timestamp},
+		{Name: "turns", Type: parquet.Int64},
+		{Name: "error_turns", Type: parquet.Int64},
+		{Name: "duration_ms", Type: parquet.Int64},
+		{Name: "api_duration_ms", Type: parquet.Int64},
+		{Name: "cost_usd", Type: parquet.Double},
+		{Name: "input_tokens", Type: parquet.Int64},
+		{Name: "output_tokens", Type: parquet.Int64},
+		{Name: "cache_read_input_tokens", Type: parquet.Int64},
+		{Name: "cache_creation_input_tokens", Type: parquet.Int64},
+		{Name: "permission_denials", Type: parquet.Int64},
+	}}
+	for _, r := range reports {
+		table.rows = append(table.rows, []any{
+			r.SessionID,
+			r.Model,
+			r.StartedAt,
+			r.Turns,
+			r.ErrorTurns,
+			r.Duration.Milliseconds(),
+			r.APIDuration.Milliseconds(),
+			r.Cost.Float64(),
+			r.InputTokens,
+			r.OutputTokens,
+			r.CacheReadInputTokens,
+			r.CacheCreationInputTokens,
+			r.PermissionDenials,
+		})
+	}
+
+	return table.write(w, format)
+}
+
+// WriteToolStats writes the tool statistics of session sessionID to w in
+// format, one row per tool in name order. Latencies are in milliseconds.
+func WriteToolStats(w io.Writer, format ExportFormat, sessionID string, stats map[string]ToolStats) error {
+	table := telemetryTable{fields: []parquet.Field{
+		{Name: "session_id", Type: parquet.String},
+		{Name: "tool_name", Type: parquet.String},
+		{Name: "count", Type: parquet.Int64},
+		{Name: "failures", Type: parquet.Int64},
+		{Name: "failure_rate", Type: parquet.Double},
+		{Name: "p50_ms", Type: parquet.Double},
+		{Name: "p95_ms", Type: parquet.Double},
+		{Name: "max_ms", Type: parquet.Double},
+		{Name: "in_flight", Type: parquet.Int64},
+	}}
+	for _, name := range slices.Sorted(maps.Keys(stats)) {
+		s := stats[name]
+		table.rows = append(table.rows, []any{
+			sessionID,
+			name,
+			s.Count,
+			s.Failures,
+			s.FailureRate,
+			milliseconds(s.P50),
+			milliseconds(s.P95),
+			milliseconds(s.Max),
+			s.InFlight,
+		})
+	}
+
+	return table.write(w, format)
+}
+
+// milliseconds returns d in fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// telemetryTable is a table of exported telemetry.
+type telemetryTable struct {
+	fields []parquet.Field
+	rows   [][]any
+}
+
+// write encodes the table to w in format.
+func (t telemetryTable) write(w io.Writer, format ExportFormat) error {
+	switch format {
+	case ExportParquet:
+		return parquet.Write(w, t.fields, t.rows)
+	case ExportCSV, "":
+	default:
+		return clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidFormat,
+			fmt.Sprintf("unknown telemetry export format %q", format),
+			nil,
+			"format",
+			format,
+		)
+	}
+
+	out := csv.NewWriter(w)
+	header := make([]string, len(t.fields))
+	for i, field := range t.fields {
+		header[i] = field.Name
+	}
+	_ = out.Write(header)
+	for _, row := range t.rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = csvValue(value)
+		}
+		_ = out.Write(record)
+	}
+	out.Flush()
+
+	return out.Error()
+}
+
+// csvValue formats a table value for CSV.
+func csvValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// telemetryExporter rewrites a client's telemetry files on an interval and
+// on Close.
+type telemetryExporter struct {
+	config      *TelemetryExport
+	report      *SessionReportCollector
+	tools       *ToolStatsCollector
+	annotations *annotationLog
+
+	mu   sync.Mutex // Serializes writes
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newTelemetryExporter starts exporting the collectors' data per config.
+func newTelemetryExporter(
+	config *TelemetryExport,
+	report *SessionReportCollector,
+	tools *ToolStatsCollector,
+	annotations *annotationLog,
+) *telemetryExporter {
+	e := &telemetryExporter{
+		config:      config,
+		report:      report,
+		tools:       tools,
+		annotations: annotations,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if config.Interval <= 0 {
+		close(e.done)
+
+		return e
+	}
+
+	go func() {
+		defer close(e.done)
+
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := e.export(); err != nil && config.OnError != nil {
+					config.OnError(err)
+				}
+			case <-e.stop:
+				return
+			}
+		}
+	}()
+
+	return e
+}
+
+// close stops the interval writes and writes the files a last time.
+func (e *telemetryExporter) close() error {
+	close(e.stop)
+	<-e.done
+
+	return e.export()
+}
+
+// export writes the files of the session, if its ID is known.
+func (e *telemetryExporter) export() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	report := e.report.Report()
+	if report.SessionID == "" {
+		return nil
+	}
+
+	format := e.config.format()
+	name := safeFileName(report.SessionID) + "." + string(format)
+
+	var sessions, tools bytes.Buffer
+	if err := WriteSessionReports(&sessions, format, report); err != nil {
+		return err
+	}
+	if err := WriteToolStats(&tools, format, report.SessionID, e.tools.Stats()); err != nil {
+		return err
+	}
+
+	errs := []error{
+		writeFileAtomic(filepath.Join(e.config.Dir, sessionReportsTable), name, sessions.Bytes()),
+		writeFileAtomic(filepath.Join(e.config.Dir, toolStatsTable), name, tools.Bytes()),
+	}
+	if annotations := e.annotations.list(); len(annotations) > 0 {
+		var table bytes.Buffer
+		if err := WriteAnnotations(&table, format, annotations...); err != nil {
+			return err
+		}
+		errs = append(errs, writeFileAtomic(filepath.Join(e.config.Dir, annotationsTable), name, table.Bytes()))
+	}
+
+	return errors.Join(errs...)
+}
+
+// safeFileName replaces the path separators in name.
+func safeFileName(name string) string {
+	return strings.NewReplacer("/", "_", `\`, "_").Replace(name)
+}
+
+// writeFileAtomic replaces dir/name with data.
+func writeFileAtomic(dir, name string, data []byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+name+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
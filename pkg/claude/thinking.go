@@ -0,0 +1,95 @@
+package claude
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// transcriptThinkingEntry is the part of a transcript line holding
+// thinking blocks.
+type transcriptThinkingEntry struct {
+	Type    string `json:"type"`
+	UUID    string `json:"uuid"`
+	Message struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// transcriptThinkingBlock is the part of a content block checked for a
+// signature.
+type transcriptThinkingBlock struct {
+	Type      string `json:"type"`
+	Signature string `json:"signature"`
+	Data      string `json:"data"`
+}
+
+// checkResumeThinking verifies, for Options.PreserveThinking, that the
+// transcript of the session being resumed still carries the signatures of
+// its thinking blocks. A transcript that can't be found is left for the
+// CLI to report.
+func checkResumeThinking(opts *Options) error {
+	if !opts.PreserveThinking || opts.Resume == "" {
+		return nil
+	}
+
+	cwd := opts.Cwd
+	if cwd == "" {
+		cwd, _ = os.Getwd()
+	}
+	projects := filepath.Join(configDir("", opts.Env), "projects")
+
+	for _, dir := range projectDirs(projects, cwd) {
+		file, err := os.Open(filepath.Join(dir, opts.Resume+".jsonl"))
+		if err != nil {
+			continue
+		}
+		defer func() { _ = file.Close() }()
+
+		return checkTranscriptThinking(file, opts.Resume)
+	}
+
+	return nil
+}
+
+// checkTranscriptThinking reports the first assistant entry of a
+// transcript with an unsigned thinking block or empty redacted thinking.
+func checkTranscriptThinking(file *os.File, sessionID string) error {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxTranscriptLine)
+
+	for line := 1; scanner.Scan(); line++ {
+		var entry transcriptThinkingEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Type != "assistant" {
+			continue
+		}
+
+		var blocks []transcriptThinkingBlock
+		if err := json.Unmarshal(entry.Message.Content, &blocks); err != nil {
+			continue
+		}
+		for _, block := range blocks {
+			unsigned := block.Type == "thinking" && block.Signature == ""
+			redacted := block.Type == "redacted_thinking" && block.Data == ""
+			if unsigned || redacted {
+				return clauderrs.NewValidationError(
+					clauderrs.ErrCodeInvalidFormat,
+					fmt.Sprintf(
+						"session %s has a %s block without its signature (line %d, message %s); "+
+							"the API rejects continuing it with extended thinking",
+						sessionID, block.Type, line, entry.UUID,
+					),
+					nil,
+					"Resume",
+					sessionID,
+				)
+			}
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,232 @@
+package claude
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register GIF for image token estimates
+	_ "image/jpeg" // Register JPEG for image token estimates
+	_ "image/png"  // Register PNG for image token estimates
+	"strings"
+	"unicode"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// defaultContextWindow is the context window of current Claude models.
+	defaultContextWindow = 200_000
+	// extendedContextWindow is the window of models selected with the
+	// "[1m]" suffix.
+	extendedContextWindow = 1_000_000
+	// extendedContextSuffix marks a model alias using the 1M-token window.
+	extendedContextSuffix = "[1m]"
+
+	// maxOutputTokensEnv sets the CLI's output token cap per response.
+	maxOutputTokensEnv = "CLAUDE_CODE_MAX_OUTPUT_TOKENS"
+
+	// imageTokenPixels is the number of pixels per image token.
+	imageTokenPixels = 750
+	// maxImageEdge is the longest edge images are scaled down to before
+	// the model sees them.
+	maxImageEdge = 1568
+	// maxImageTokens is the estimate for images whose size is unknown.
+	maxImageTokens = 1600
+)
+
+// ContextWindow returns the size in tokens of model's context window.
+// Model aliases ending in "[1m]" use the extended 1M-token window.
+func ContextWindow(model string) int {
+	if strings.HasSuffix(model, extendedContextSuffix) {
+		return extendedContextWindow
+	}
+
+	return defaultContextWindow
+}
+
+// outputTokenLimits are the most output tokens each model can produce in
+// one response, keyed by a substring of its ID. The snapshot and version
+// separators keep newer models in a family from matching older entries.
+var outputTokenLimits = []struct {
+	model string
+	limit int
+}{
+	{"claude-opus-4-5", 64_000},
+	{"claude-opus-4-1", 32_000},
+	{"claude-opus-4-2025", 32_000},
+	{"claude-opus-4@", 32_000},
+	{"claude-sonnet-4-5", 64_000},
+	{"claude-sonnet-4-2025", 64_000},
+	{"claude-sonnet-4@", 64_000},
+	{"claude-haiku-4-5", 64_000},
+	{"claude-3-7-sonnet", 64_000},
+	{"claude-3-5-sonnet", 8_192},
+	{"claude-3-5-haiku", 8_192},
+	{"claude-3-opus", 4_096},
+	{"claude-3-haiku", 4_096},
+}
+
+// OutputTokenLimit returns the most output tokens model can produce in one
+// response, or 0 for aliases such as "sonnet" and models this SDK does
+// not know. Bedrock and Vertex AI model IDs are recognized too.
+func OutputTokenLimit(model string) int {
+	for _, entry := range outputTokenLimits {
+		if strings.Contains(model, entry.model) {
+			return entry.limit
+		}
+	}
+
+	return 0
+}
+
+// EstimateTokens approximates how many input tokens text takes up with
+// model. The estimate follows how Claude's tokenizer splits text (words
+// into pieces of about five letters, numbers into groups of three
+// digits, one token per symbol or CJK character) and is usually within
+// 20% for prose and code. Current models share one tokenizer, so model
+// does not yet change the estimate. Use the usage reported in results
+// for exact counts.
+func EstimateTokens(_ string, text string) int {
+	tokens := 0
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		j := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+			// A single space joins the following word's token
+			if j-i > 1 {
+				tokens++
+			}
+		case isWideRune(r):
+			tokens++
+		case unicode.IsLetter(r):
+			for j < len(runes) && unicode.IsLetter(runes[j]) && !isWideRune(runes[j]) {
+				j++
+			}
+			tokens += (j - i + 4) / 5
+		case unicode.IsDigit(r):
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			tokens += (j - i + 2) / 3
+		default:
+			tokens++
+		}
+
+		i = j
+	}
+
+	return tokens
+}
+
+// EstimateBlockTokens approximates how many input tokens content blocks
+// take up with model. Images are estimated from their dimensions as
+// width*height/750 after scaling to fit 1568 pixels.
+func EstimateBlockTokens(model string, blocks []ContentBlock) int {
+	tokens := 0
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case TextContentBlock:
+			tokens += EstimateTokens(model, b.Text)
+		case TextBlock:
+			tokens += EstimateTokens(model, b.Text)
+		case ThinkingBlock:
+			tokens += EstimateTokens(model, b.Thinking)
+		case ImageContentBlock:
+			tokens += estimateImageTokens(b.Source)
+		case ToolUseContentBlock:
+			tokens += EstimateTokens(model, b.Name) + EstimateTokens(model, string(b.Input))
+		case ToolResultContentBlock:
+			if b.Content != nil && b.Content.Text != nil {
+				tokens += EstimateTokens(model, *b.Content.Text)
+			} else if b.Content != nil {
+				tokens += EstimateBlockTokens(model, b.Content.Blocks)
+			}
+		default:
+			if data, err := json.Marshal(block); err == nil {
+				tokens += EstimateTokens(model, string(data))
+			}
+		}
+	}
+
+	return tokens
+}
+
+// estimateImageTokens estimates the tokens of a base64 image from its
+// dimensions, or returns the maximum when they can't be read.
+func estimateImageTokens(source ImageSource) int {
+	data, err := base64.StdEncoding.DecodeString(source.Data)
+	if err != nil {
+		return maxImageTokens
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= 0 || config.Height <= 0 {
+		return maxImageTokens
+	}
+
+	width, height := float64(config.Width), float64(config.Height)
+	if edge := max(width, height); edge > maxImageEdge {
+		width *= maxImageEdge / edge
+		height *= maxImageEdge / edge
+	}
+
+	return max(int(width*height)/imageTokenPixels, 1)
+}
+
+// isWideRune reports whether r is from a script the tokenizer splits
+// into roughly one token per character.
+func isWideRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// PromptLimit caps the estimated size of each prompt sent through
+// ClaudeSDKClient, as a fraction of the model's context window, so a
+// runaway prompt is caught before it is sent rather than failing in the
+// API. Only the prompt itself is counted, not the conversation so far.
+type PromptLimit struct {
+	// Fraction of the context window a prompt may use, e.g. 0.5.
+	Fraction float64
+	// Warn, if set, is called with the estimate and the limit for
+	// oversized prompts, which are then sent anyway. Without Warn they
+	// are rejected with ErrCodeRangeViolation.
+	Warn func(estimate, limit int)
+}
+
+// checkPromptSize applies opts.PromptLimit to a prompt.
+func checkPromptSize(opts *Options, blocks []ContentBlock) error {
+	if opts.PromptLimit == nil || opts.PromptLimit.Fraction <= 0 {
+		return nil
+	}
+
+	limit := int(float64(ContextWindow(opts.Model)) * opts.PromptLimit.Fraction)
+	estimate := EstimateBlockTokens(opts.Model, blocks)
+	if estimate <= limit {
+		return nil
+	}
+
+	if opts.PromptLimit.Warn != nil {
+		opts.PromptLimit.Warn(estimate, limit)
+
+		return nil
+	}
+
+	return clauderrs.NewValidationError(
+		clauderrs.ErrCodeRangeViolation,
+		fmt.Sprintf(
+			"prompt is about %d tokens, over the limit of %d (%g of the context window)",
+			estimate,
+			limit,
+			opts.PromptLimit.Fraction,
+		),
+		nil,
+		"prompt",
+		estimate,
+	)
+}
@@ -0,0 +1,126 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// ToolUseLimits bounds how many times tools may be called in a session,
+// protecting against loops that call an expensive tool such as WebSearch
+// over and over. Calls over a limit are denied with a PreToolUse hook
+// whose reason tells the model the budget is spent; denied calls don't
+// count against the limits.
+type ToolUseLimits struct {
+	// Total bounds the calls of all tools together. Zero means no limit.
+	Total int
+	// PerTool bounds the calls of single tools by name, such as
+	// {"WebSearch": 5}. MCP tools are named "mcp__<server>__<tool>".
+	PerTool map[string]int
+}
+
+// validate reports negative limits.
+func (l *ToolUseLimits) validate() []error {
+	var errs []error
+	if l.Total < 0 {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"MaxToolUses.Total must not be negative",
+			nil,
+			"MaxToolUses.Total",
+			l.Total,
+		))
+	}
+
+	tools := make([]string, 0, len(l.PerTool))
+	for tool := range l.PerTool {
+		tools = append(tools, tool)
+	}
+	slices.Sort(tools)
+	for _, tool := range tools {
+		if l.PerTool[tool] < 0 {
+			errs = append(errs, clauderrs.NewValidationError(
+				clauderrs.ErrCodeRangeViolation,
+				fmt.Sprintf("MaxToolUses.PerTool limit of %s must not be negative", tool),
+				nil,
+				"MaxToolUses.PerTool",
+				l.PerTool[tool],
+			))
+		}
+	}
+
+	return errs
+}
+
+// toolBudget counts the calls allowed under Options.MaxToolUses.
+type toolBudget struct {
+	limits *ToolUseLimits
+
+	mu     sync.Mutex
+	total  int
+	byTool map[string]int
+}
+
+func newToolBudget(limits *ToolUseLimits) *toolBudget {
+	return &toolBudget{limits: limits, byTool: make(map[string]int)}
+}
+
+// take counts a call of tool, or returns why it is over a limit.
+func (b *toolBudget) take(tool string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if limit, ok := b.limits.PerTool[tool]; ok && b.byTool[tool] >= limit {
+		return fmt.Sprintf(
+			"%s may be called at most %d times in this session and its budget is spent; continue without it",
+			tool, limit,
+		), false
+	}
+	if b.limits.Total > 0 && b.total >= b.limits.Total {
+		return fmt.Sprintf(
+			"tools may be called at most %d times in this session and the budget is spent; finish with what you have",
+			b.limits.Total,
+		), false
+	}
+	b.total++
+	b.byTool[tool]++
+
+	return "", true
+}
+
+// toolBudgetHooks returns the PreToolUse hook enforcing
+// Options.MaxToolUses.
+func (q *queryImpl) toolBudgetHooks() map[HookEvent][]HookCallbackMatcher {
+	return map[HookEvent][]HookCallbackMatcher{
+		HookEventPreToolUse: {{Hooks: []HookCallback{q.enforceToolBudget}}},
+	}
+}
+
+// enforceToolBudget denies calls over Options.MaxToolUses.
+func (q *queryImpl) enforceToolBudget(
+	_ context.Context,
+	input HookInput,
+	_ *string,
+) (HookJSONOutput, error) {
+	pre, ok := input.(PreToolUseHookInput)
+	if !ok {
+		return SyncHookOutput{}, nil
+	}
+
+	reason, ok := q.toolBudget.take(pre.ToolName)
+	if ok {
+		return SyncHookOutput{}, nil
+	}
+	decision := string(PermissionDecisionDeny)
+
+	return SyncHookOutput{
+		HookSpecificOutput: PreToolUseHookOutput{
+			HookEventName:            HookEventPreToolUse,
+			PermissionDecision:       &decision,
+			PermissionDecisionReason: &reason,
+		},
+	}, nil
+}
@@ -0,0 +1,161 @@
+package claude
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ToolStart describes a tool call about to run, for Options.OnToolStart.
+type ToolStart struct {
+	ToolName  string
+	ToolUseID string
+	// Input is the tool's input as a JSON object.
+	Input JSONValue
+}
+
+// ToolEnd describes a finished tool call, for Options.OnToolEnd.
+type ToolEnd struct {
+	ToolName  string
+	ToolUseID string
+	Input     JSONValue
+	// Output is the text of the result, as the model sees it. For a
+	// failed or denied call it is the error message.
+	Output string
+	// Response is the tool's raw response, as given to PostToolUse hooks.
+	// It is nil when the call failed or was denied.
+	Response JSONValue
+	// IsError reports whether the call failed or was denied.
+	IsError bool
+	// Duration is the time from OnToolStart to the result.
+	Duration time.Duration
+}
+
+// toolCallbacks tracks the tool calls reported to Options.OnToolStart
+// until their results are reported to Options.OnToolEnd.
+type toolCallbacks struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	pending map[string]*pendingTool // By tool_use ID
+}
+
+// pendingTool is a started tool call awaiting its result.
+type pendingTool struct {
+	name     string
+	input    JSONValue
+	response JSONValue
+	started  time.Time
+}
+
+func newToolCallbacks() *toolCallbacks {
+	return &toolCallbacks{now: time.Now, pending: make(map[string]*pendingTool)}
+}
+
+// toolCallbackHooks returns the hooks feeding Options.OnToolStart and
+// OnToolEnd, or nil if neither is set.
+func (q *queryImpl) toolCallbackHooks() map[HookEvent][]HookCallbackMatcher {
+	if q.opts.OnToolStart == nil && q.opts.OnToolEnd == nil {
+		return nil
+	}
+
+	return map[HookEvent][]HookCallbackMatcher{
+		HookEventPreToolUse:  {{Hooks: []HookCallback{q.observeToolHook}}},
+		HookEventPostToolUse: {{Hooks: []HookCallback{q.observeToolHook}}},
+	}
+}
+
+// observeToolHook records tool calls as they start and the responses of
+// those that succeed. It never affects the call.
+func (q *queryImpl) observeToolHook(
+	_ context.Context,
+	input HookInput,
+	_ *string,
+) (HookJSONOutput, error) {
+	switch in := input.(type) {
+	case PreToolUseHookInput:
+		q.tools.mu.Lock()
+		q.tools.pending[in.ToolUseID] = &pendingTool{
+			name:    in.ToolName,
+			input:   in.ToolInput,
+			started: q.tools.now(),
+		}
+		q.tools.mu.Unlock()
+
+		if q.opts.OnToolStart != nil {
+			q.opts.OnToolStart(ToolStart{
+				ToolName:  in.ToolName,
+				ToolUseID: in.ToolUseID,
+				Input:     in.ToolInput,
+			})
+		}
+	case PostToolUseHookInput:
+		q.tools.mu.Lock()
+		if call, ok := q.tools.pending[in.ToolUseID]; ok {
+			call.response = in.ToolResponse
+		}
+		q.tools.mu.Unlock()
+	}
+
+	return SyncHookOutput{}, nil
+}
+
+// reportToolEnds calls Options.OnToolEnd for the results in msg of the
+// tool calls reported to OnToolStart. The CLI reports a result only after
+// the call's hooks have returned, and for failed and denied calls too, so
+// every call that starts ends here unless the session stops first.
+func (q *queryImpl) reportToolEnds(msg SDKMessage) {
+	user, ok := msg.(*SDKUserMessage)
+	if !ok || (q.opts.OnToolStart == nil && q.opts.OnToolEnd == nil) {
+		return
+	}
+
+	for _, block := range user.Message.Content {
+		result, ok := block.(ToolResultContentBlock)
+		if !ok {
+			continue
+		}
+
+		q.tools.mu.Lock()
+		call, ok := q.tools.pending[result.ToolUseID]
+		delete(q.tools.pending, result.ToolUseID)
+		now := q.tools.now()
+		q.tools.mu.Unlock()
+		if !ok || q.opts.OnToolEnd == nil {
+			continue
+		}
+
+		end := ToolEnd{
+			ToolName:  call.name,
+			ToolUseID: result.ToolUseID,
+			Input:     call.input,
+			Output:    toolResultContentText(result.Content),
+			IsError:   result.IsError,
+			Duration:  now.Sub(call.started),
+		}
+		if !result.IsError {
+			end.Response = call.response
+		}
+		q.opts.OnToolEnd(end)
+	}
+}
+
+// toolResultContentText returns the text of a tool result, joining its
+// text blocks.
+func toolResultContentText(content *ToolResultContent) string {
+	if content == nil {
+		return ""
+	}
+	if content.Text != nil {
+		return *content.Text
+	}
+
+	var parts []string
+	for _, block := range content.Blocks {
+		if text, ok := block.(TextContentBlock); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,147 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// ToolHandler serves a call to a tool named in Options.ToolOverrides. The
+// returned text is the tool's result; an error is reported as a failed
+// call with the error's message.
+type ToolHandler func(ctx context.Context, input JSONValue) (string, error)
+
+// toolOverrides holds the results served by Options.ToolOverrides until
+// the CLI reports the tool results they replace.
+type toolOverrides struct {
+	mu      sync.Mutex
+	results map[string]ToolResultContentBlock // By tool_use ID
+}
+
+func newToolOverrides() *toolOverrides {
+	return &toolOverrides{results: make(map[string]ToolResultContentBlock)}
+}
+
+// toolOverrideHooks returns the PreToolUse hook serving the overridden
+// tools.
+func (q *queryImpl) toolOverrideHooks() map[HookEvent][]HookCallbackMatcher {
+	names := overriddenTools(q.opts)
+	for i, name := range names {
+		names[i] = regexp.QuoteMeta(name)
+	}
+	matcher := "^(" + strings.Join(names, "|") + ")$"
+
+	return map[HookEvent][]HookCallbackMatcher{
+		HookEventPreToolUse: {{Matcher: &matcher, Hooks: []HookCallback{q.serveToolOverride}}},
+	}
+}
+
+// serveToolOverride runs the handler for an overridden tool and denies the
+// call, so the CLI never runs the tool. The handler's result is the deny
+// reason, which is what the model sees.
+func (q *queryImpl) serveToolOverride(
+	ctx context.Context,
+	input HookInput,
+	_ *string,
+) (HookJSONOutput, error) {
+	pre, ok := input.(PreToolUseHookInput)
+	if !ok {
+		return SyncHookOutput{}, nil
+	}
+	handler, ok := q.opts.ToolOverrides[pre.ToolName]
+	if !ok {
+		return SyncHookOutput{}, nil
+	}
+
+	text, err := handler(ctx, pre.ToolInput)
+	isError := err != nil
+	if isError {
+		text = err.Error()
+	}
+
+	q.overrides.mu.Lock()
+	q.overrides.results[pre.ToolUseID] = ToolResultContentBlock{
+		Type:      "tool_result",
+		ToolUseID: pre.ToolUseID,
+		Content:   &ToolResultContent{Text: &text},
+		IsError:   isError,
+	}
+	q.overrides.mu.Unlock()
+
+	decision := string(PermissionDecisionDeny)
+
+	return SyncHookOutput{
+		HookSpecificOutput: PreToolUseHookOutput{
+			HookEventName:            HookEventPreToolUse,
+			PermissionDecision:       &decision,
+			PermissionDecisionReason: &text,
+		},
+	}, nil
+}
+
+// applyToolOverrides replaces the denials the CLI reports for overridden
+// calls in a user message with the results their handlers served.
+func (q *queryImpl) applyToolOverrides(msg SDKMessage) {
+	user, ok := msg.(*SDKUserMessage)
+	if !ok || len(q.opts.ToolOverrides) == 0 {
+		return
+	}
+
+	q.overrides.mu.Lock()
+	defer q.overrides.mu.Unlock()
+
+	for i, block := range user.Message.Content {
+		result, ok := block.(ToolResultContentBlock)
+		if !ok {
+			continue
+		}
+		if served, ok := q.overrides.results[result.ToolUseID]; ok {
+			user.Message.Content[i] = served
+			delete(q.overrides.results, result.ToolUseID)
+		}
+	}
+}
+
+// overriddenTools returns the sorted names of the tools in
+// opts.ToolOverrides.
+func overriddenTools(opts *Options) []string {
+	names := make([]string, 0, len(opts.ToolOverrides))
+	for name := range opts.ToolOverrides {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// validateToolOverrides reports overrides without a handler or for tools
+// that are disallowed.
+func (o *Options) validateToolOverrides() []error {
+	var errs []error
+	for _, name := range overriddenTools(o) {
+		handler := o.ToolOverrides[name]
+		if handler == nil {
+			errs = append(errs, clauderrs.NewValidationError(
+				clauderrs.ErrCodeMissingField,
+				fmt.Sprintf("tool override %q has no handler", name),
+				nil,
+				"ToolOverrides",
+				name,
+			))
+		}
+		if slices.Contains(o.DisallowedTools, name) {
+			errs = append(errs, conflictError(
+				"ToolOverrides",
+				fmt.Sprintf("tool %q is both overridden and disallowed", name),
+				name,
+			))
+		}
+	}
+
+	return errs
+}
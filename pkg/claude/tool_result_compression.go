@@ -0,0 +1,153 @@
+package claude
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
+)
+
+// gzipMimeTypes mark an MCP resource blob as gzip-compressed text.
+var gzipMimeTypes = []string{"application/gzip", "application/x-gzip"}
+
+// toolResultItem is an MCP tool result content item: text, or a resource
+// that may hold a compressed blob.
+type toolResultItem struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Resource *struct {
+		MimeType string `json:"mimeType"`
+		Blob     string `json:"blob"`
+	} `json:"resource"`
+}
+
+// decompressionHooks returns the PostToolUse hook that expands compressed
+// MCP tool output before the model sees it.
+func (q *queryImpl) decompressionHooks() map[HookEvent][]HookCallbackMatcher {
+	matcher := mcpToolMatcher
+
+	return map[HookEvent][]HookCallbackMatcher{
+		HookEventPostToolUse: {{Matcher: &matcher, Hooks: []HookCallback{q.decompressMcpToolOutput}}},
+	}
+}
+
+// decompressMcpToolOutput replaces MCP tool output holding compressed
+// resources with its text, paged or truncated like other output. Output
+// without any is left to the paging or truncation hook.
+func (q *queryImpl) decompressMcpToolOutput(
+	ctx context.Context,
+	input HookInput,
+	toolUseID *string,
+) (HookJSONOutput, error) {
+	post, ok := input.(PostToolUseHookInput)
+	if !ok || post.ToolName == ReadMoreToolName {
+		return SyncHookOutput{}, nil
+	}
+
+	text, compressed, err := decompressToolResponse(post.ToolResponse, q.maxDecompressedBytes())
+	switch {
+	case err != nil:
+		text = fmt.Sprintf("[compressed tool result could not be read: %v]", err)
+	case !compressed && q.pager != nil:
+		return q.pageMcpToolOutput(ctx, input, toolUseID)
+	case !compressed && q.opts.MaxToolResultBytes > 0:
+		return q.limitMcpToolOutput(ctx, input, toolUseID)
+	case !compressed:
+		return SyncHookOutput{}, nil
+	case q.pager != nil && len(text) > q.pager.pageBytes:
+		text = q.pager.store(text)
+	case q.pager == nil:
+		text, _ = q.truncateToolResult(text)
+	}
+
+	return SyncHookOutput{
+		HookSpecificOutput: PostToolUseHookOutput{
+			HookEventName:        HookEventPostToolUse,
+			UpdatedMCPToolOutput: []map[string]string{{"type": "text", "text": text}},
+		},
+	}, nil
+}
+
+// maxDecompressedBytes bounds a decompressed tool result like a message
+// from the CLI: by MaxMessageSize, or the transport default when it is
+// zero. Zero means no bound.
+func (q *queryImpl) maxDecompressedBytes() int {
+	switch {
+	case q.opts.MaxMessageSize > 0:
+		return q.opts.MaxMessageSize
+	case q.opts.MaxMessageSize < 0:
+		return 0
+	default:
+		return transport.DefaultMaxMessageSize
+	}
+}
+
+// decompressToolResponse returns the text of a tool response with its
+// gzip resources expanded, and whether it had any. The other items' text
+// is kept in order; items without text are dropped.
+func decompressToolResponse(response JSONValue, limit int) (string, bool, error) {
+	var items []toolResultItem
+	if err := json.Unmarshal(response, &items); err != nil {
+		var result struct {
+			Content []toolResultItem `json:"content"`
+		}
+		if err := json.Unmarshal(response, &result); err != nil {
+			return "", false, nil
+		}
+		items = result.Content
+	}
+
+	var parts []string
+	compressed := false
+	for _, item := range items {
+		switch {
+		case item.Type == "text":
+			parts = append(parts, item.Text)
+		case item.Type == "resource" && item.Resource != nil && slices.Contains(gzipMimeTypes, item.Resource.MimeType):
+			compressed = true
+			text, err := gunzipBlob(item.Resource.Blob, limit)
+			if err != nil {
+				return "", true, err
+			}
+			parts = append(parts, text)
+		}
+	}
+
+	return strings.Join(parts, "\n"), compressed, nil
+}
+
+// gunzipBlob decodes and decompresses a base64 gzip blob of at most limit
+// bytes once expanded, or any size when limit is zero.
+func gunzipBlob(blob string, limit int) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(blob)
+	if err != nil {
+		return "", err
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = reader.Close() }()
+
+	var src io.Reader = reader
+	if limit > 0 {
+		// One byte past the limit detects oversized results
+		src = io.LimitReader(reader, int64(limit)+1)
+	}
+	text, err := io.ReadAll(src)
+	if err != nil {
+		return "", err
+	}
+	if limit > 0 && len(text) > limit {
+		return "", fmt.Errorf("decompressed result exceeds %d bytes", limit)
+	}
+
+	return string(text), nil
+}
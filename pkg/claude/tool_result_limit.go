@@ -0,0 +1,174 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// mcpToolMatcher matches MCP tools, whose output hooks may replace.
+	mcpToolMatcher = "mcp__.*"
+	// spillFilePattern names files holding full oversized tool results.
+	spillFilePattern = "claude-tool-result-*.txt"
+)
+
+// toolResultLimitHooks returns the PostToolUse hook that truncates
+// oversized MCP tool output before the model sees it.
+func (q *queryImpl) toolResultLimitHooks() map[HookEvent][]HookCallbackMatcher {
+	matcher := mcpToolMatcher
+
+	return map[HookEvent][]HookCallbackMatcher{
+		HookEventPostToolUse: {{Matcher: &matcher, Hooks: []HookCallback{q.limitMcpToolOutput}}},
+	}
+}
+
+// limitMcpToolOutput replaces MCP tool output over MaxToolResultBytes with
+// a truncated copy.
+func (q *queryImpl) limitMcpToolOutput(
+	_ context.Context,
+	input HookInput,
+	_ *string,
+) (HookJSONOutput, error) {
+	post, ok := input.(PostToolUseHookInput)
+	if !ok || len(post.ToolResponse) <= q.opts.MaxToolResultBytes {
+		return SyncHookOutput{}, nil
+	}
+
+	text, truncated := q.truncateToolResult(toolResponseText(post.ToolResponse))
+	if !truncated {
+		return SyncHookOutput{}, nil
+	}
+
+	return SyncHookOutput{
+		HookSpecificOutput: PostToolUseHookOutput{
+			HookEventName:        HookEventPostToolUse,
+			UpdatedMCPToolOutput: []map[string]string{{"type": "text", "text": text}},
+		},
+	}, nil
+}
+
+// limitToolResults truncates oversized tool results in a user message
+// before it is delivered.
+func (q *queryImpl) limitToolResults(msg SDKMessage) {
+	user, ok := msg.(*SDKUserMessage)
+	if !ok || q.opts.MaxToolResultBytes <= 0 {
+		return
+	}
+
+	for i, block := range user.Message.Content {
+		result, ok := block.(ToolResultContentBlock)
+		if !ok || result.Content == nil {
+			continue
+		}
+
+		if result.Content.Text != nil {
+			if text, truncated := q.truncateToolResult(*result.Content.Text); truncated {
+				result.Content = &ToolResultContent{Text: &text}
+				user.Message.Content[i] = result
+			}
+
+			continue
+		}
+
+		var texts []string
+		var others []ContentBlock
+		for _, b := range result.Content.Blocks {
+			if text, ok := b.(TextContentBlock); ok {
+				texts = append(texts, text.Text)
+			} else {
+				others = append(others, b)
+			}
+		}
+		if text, truncated := q.truncateToolResult(strings.Join(texts, "\n")); truncated {
+			blocks := append([]ContentBlock{TextContentBlock{Type: "text", Text: text}}, others...)
+			result.Content = &ToolResultContent{Blocks: blocks}
+			user.Message.Content[i] = result
+		}
+	}
+}
+
+// truncateToolResult cuts text to MaxToolResultBytes on a character
+// boundary and appends a marker saying how much was dropped and, if
+// SpillToolResults is set, where the full text was saved.
+func (q *queryImpl) truncateToolResult(text string) (string, bool) {
+	limit := q.opts.MaxToolResultBytes
+	if limit <= 0 || len(text) <= limit {
+		return text, false
+	}
+
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+
+	marker := fmt.Sprintf("[output truncated: showing %d of %d bytes]", cut, len(text))
+	if q.opts.SpillToolResults {
+		if path, err := spillToolResult(text); err == nil {
+			marker = fmt.Sprintf(
+				"[output truncated: showing %d of %d bytes; full output saved to %s]",
+				cut,
+				len(text),
+				path,
+			)
+		}
+	}
+
+	return text[:cut] + "\n\n" + marker, true
+}
+
+// spillToolResult writes text to a new temporary file and returns its
+// path.
+func spillToolResult(text string) (string, error) {
+	f, err := os.CreateTemp("", spillFilePattern)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := f.WriteString(text); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		return "", err
+	}
+
+	return f.Name(), f.Close()
+}
+
+// toolResponseText returns the text of a tool response: a JSON string, the
+// text of MCP content blocks, or the raw JSON.
+func toolResponseText(response JSONValue) string {
+	var text string
+	if err := json.Unmarshal(response, &text); err == nil {
+		return text
+	}
+
+	type textBlock struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	var blocks []textBlock
+	if err := json.Unmarshal(response, &blocks); err != nil {
+		var result struct {
+			Content []textBlock `json:"content"`
+		}
+		if err := json.Unmarshal(response, &result); err == nil {
+			blocks = result.Content
+		}
+	}
+
+	var parts []string
+	for _, block := range blocks {
+		if block.Type == "text" {
+			parts = append(parts, block.Text)
+		}
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, "\n")
+	}
+
+	return string(response)
+}
@@ -0,0 +1,220 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// PagingServerName is the SDK MCP server serving ReadMoreToolName.
+	PagingServerName = "sdk-pages"
+	// ReadMoreToolName is the tool the model calls for further pages of a
+	// paged tool result.
+	ReadMoreToolName = mcpToolPrefix + PagingServerName + "__" + readMoreTool
+
+	// readMoreTool is the name of the read_more tool on its server.
+	readMoreTool = "read_more"
+	// defaultPageBytes is ToolResultPaging's PageBytes when unset.
+	defaultPageBytes = 16 << 10
+	// defaultMaxStoredBytes is ToolResultPaging's MaxStoredBytes when
+	// unset.
+	defaultMaxStoredBytes = 64 << 20
+)
+
+// ToolResultPaging pages MCP tool results too large for the context. A
+// result over PageBytes is stored by the SDK for the session, and the model
+// gets its first page followed by a note naming a handle. The model reads
+// further pages by calling ReadMoreToolName with the handle and an offset,
+// served by an SDK MCP server the SDK adds to the session and allows.
+//
+// Like MaxToolResultBytes, paging relies on a PostToolUse hook, so only
+// MCP tool output can be paged; it replaces MaxToolResultBytes truncation
+// of that output.
+type ToolResultPaging struct {
+	// PageBytes is the size of each page. Zero means 16 KiB.
+	PageBytes int
+	// MaxStoredBytes bounds the results stored per session; the oldest are
+	// dropped to make room, after which their handles report that they
+	// expired. Zero means 64 MiB.
+	MaxStoredBytes int
+}
+
+// validate checks the paging sizes.
+func (p *ToolResultPaging) validate() []error {
+	var errs []error
+	if p.PageBytes < 0 {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"ToolResultPaging.PageBytes must not be negative",
+			nil,
+			"ToolResultPaging.PageBytes",
+			p.PageBytes,
+		))
+	}
+	if p.MaxStoredBytes < 0 {
+		errs = append(errs, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"ToolResultPaging.MaxStoredBytes must not be negative",
+			nil,
+			"ToolResultPaging.MaxStoredBytes",
+			p.MaxStoredBytes,
+		))
+	}
+
+	return errs
+}
+
+// toolResultPager stores a session's paged results and serves read_more.
+type toolResultPager struct {
+	pageBytes int
+	maxStored int
+	server    McpSdkServerConfig
+
+	mu      sync.Mutex
+	next    int
+	results map[string]string
+	order   []string // Handles, oldest first
+	stored  int
+}
+
+// newToolResultPager returns nil if paging is nil.
+func newToolResultPager(paging *ToolResultPaging) *toolResultPager {
+	if paging == nil {
+		return nil
+	}
+
+	p := &toolResultPager{
+		pageBytes: paging.PageBytes,
+		maxStored: paging.MaxStoredBytes,
+		results:   make(map[string]string),
+	}
+	if p.pageBytes <= 0 {
+		p.pageBytes = defaultPageBytes
+	}
+	if p.maxStored <= 0 {
+		p.maxStored = defaultMaxStoredBytes
+	}
+	p.server, _ = CreateSdkMcpServer(PagingServerName, "1.0.0", []McpTool{Tool(
+		readMoreTool,
+		"Reads the next page of a tool result that was too large to show at once. "+
+			"Pass the handle and offset given at the end of the previous page.",
+		map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"handle": map[string]any{"type": "string", "description": "Handle of the paged result"},
+				"offset": map[string]any{"type": "integer", "minimum": 0, "description": "Byte offset to read from"},
+			},
+			"required": []string{"handle", "offset"},
+		},
+		p.readMore,
+	)}).(McpSdkServerConfig)
+
+	return p
+}
+
+// pagingHooks returns the PostToolUse hook that pages oversized MCP tool
+// output before the model sees it.
+func (q *queryImpl) pagingHooks() map[HookEvent][]HookCallbackMatcher {
+	matcher := mcpToolMatcher
+
+	return map[HookEvent][]HookCallbackMatcher{
+		HookEventPostToolUse: {{Matcher: &matcher, Hooks: []HookCallback{q.pageMcpToolOutput}}},
+	}
+}
+
+// pageMcpToolOutput replaces MCP tool output over a page with its first
+// page.
+func (q *queryImpl) pageMcpToolOutput(
+	_ context.Context,
+	input HookInput,
+	_ *string,
+) (HookJSONOutput, error) {
+	post, ok := input.(PostToolUseHookInput)
+	if !ok || post.ToolName == ReadMoreToolName || len(post.ToolResponse) <= q.pager.pageBytes {
+		return SyncHookOutput{}, nil
+	}
+
+	text := toolResponseText(post.ToolResponse)
+	if len(text) <= q.pager.pageBytes {
+		return SyncHookOutput{}, nil
+	}
+
+	return SyncHookOutput{
+		HookSpecificOutput: PostToolUseHookOutput{
+			HookEventName:        HookEventPostToolUse,
+			UpdatedMCPToolOutput: []map[string]string{{"type": "text", "text": q.pager.store(text)}},
+		},
+	}, nil
+}
+
+// store keeps text and returns its first page.
+func (p *toolResultPager) store(text string) string {
+	p.mu.Lock()
+	p.next++
+	handle := fmt.Sprintf("r%d", p.next)
+	for len(p.order) > 0 && p.stored+len(text) > p.maxStored {
+		p.stored -= len(p.results[p.order[0]])
+		delete(p.results, p.order[0])
+		p.order = p.order[1:]
+	}
+	p.results[handle] = text
+	p.order = append(p.order, handle)
+	p.stored += len(text)
+	p.mu.Unlock()
+
+	return p.page(handle, text, 0)
+}
+
+// page returns the page of text starting at offset, cut on a character
+// boundary, with a note on how to read the next one.
+func (p *toolResultPager) page(handle, text string, offset int) string {
+	end := min(offset+p.pageBytes, len(text))
+	for end > offset && end < len(text) && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	if end == len(text) {
+		return text[offset:] + fmt.Sprintf("\n\n[end of result %s: bytes %d-%d of %d]", handle, offset, end, len(text))
+	}
+
+	return text[offset:end] + fmt.Sprintf(
+		"\n\n[result %s continues: showing bytes %d-%d of %d; call %s with handle %q and offset %d for the next page]",
+		handle, offset, end, len(text), ReadMoreToolName, handle, end,
+	)
+}
+
+// readMore serves the read_more tool.
+func (p *toolResultPager) readMore(_ context.Context, args map[string]any) (*McpToolResult, error) {
+	handle, _ := args["handle"].(string)
+	offset, _ := args["offset"].(float64)
+
+	p.mu.Lock()
+	text, ok := p.results[handle]
+	p.mu.Unlock()
+
+	switch {
+	case !ok:
+		return &McpToolResult{
+			Content: []ContentBlock{TextContentBlock{Type: "text", Text: fmt.Sprintf("result %q is unknown or has expired", handle)}},
+			IsError: true,
+		}, nil
+	case offset < 0 || int(offset) >= len(text):
+		return &McpToolResult{
+			Content: []ContentBlock{TextContentBlock{Type: "text", Text: fmt.Sprintf("offset %v is outside result %s of %d bytes", offset, handle, len(text))}},
+			IsError: true,
+		}, nil
+	}
+
+	// Offsets the SDK hands out are on character boundaries; move others
+	// back to one
+	start := int(offset)
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+
+	return TextResult(p.page(handle, text, start)), nil
+}
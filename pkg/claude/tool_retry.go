@@ -0,0 +1,129 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// defaultToolRetryDelay is ToolRetry's Delay when unset.
+const defaultToolRetryDelay = 200 * time.Millisecond
+
+// ToolRetry configures WithToolRetry.
+type ToolRetry struct {
+	// MaxAttempts bounds the attempts per call. Zero means 3.
+	MaxAttempts int
+	// Delay is the wait before the first retry; it doubles for each later
+	// retry. Zero means 200ms.
+	Delay time.Duration
+	// MaxDelay caps the wait between retries. Zero means no cap.
+	MaxDelay time.Duration
+	// Timeout bounds each attempt. An attempt still running when it
+	// expires fails with an ErrCodeCallbackTimeout error, and its context
+	// is cancelled. Zero means no timeout.
+	Timeout time.Duration
+	// Retryable reports whether a failed attempt may succeed if repeated.
+	// Nil retries every error, including timeouts.
+	Retryable func(error) bool
+}
+
+// retryTool is the McpTool returned by WithToolRetry.
+type retryTool struct {
+	McpTool
+	retry ToolRetry
+}
+
+// WithToolRetry returns tool repeating calls whose handler returns an
+// error or times out, waiting longer between each attempt, before the
+// last error is returned to the model as an error result. Results the
+// handler marks IsError are returned as they are. Use it on the tools
+// passed to CreateSdkMcpServer, with a policy per tool:
+//
+//	claude.CreateSdkMcpServer("billing", "1.0", []claude.McpTool{
+//		claude.WithToolRetry(lookupInvoice, claude.ToolRetry{Timeout: 5 * time.Second}),
+//		refundInvoice, // not idempotent, so never retried
+//	})
+//
+// Only retry tools that are safe to run more than once.
+func WithToolRetry(tool McpTool, retry ToolRetry) McpTool {
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = defaultRetryAttempts
+	}
+	if retry.Delay <= 0 {
+		retry.Delay = defaultToolRetryDelay
+	}
+	if retry.Retryable == nil {
+		retry.Retryable = func(error) bool { return true }
+	}
+
+	return &retryTool{McpTool: tool, retry: retry}
+}
+
+// Execute runs the tool until it succeeds, fails with an error that isn't
+// retryable, or runs out of attempts.
+func (t *retryTool) Execute(ctx context.Context, input map[string]any) (*McpToolResult, error) {
+	delay := t.retry.Delay
+	for attempt := 1; ; attempt++ {
+		result, err := t.attempt(ctx, input)
+		if err == nil || ctx.Err() != nil || !t.retry.Retryable(err) {
+			return result, err
+		}
+		if attempt >= t.retry.MaxAttempts {
+			return nil, fmt.Errorf("tool %s failed after %d attempts: %w", t.Name(), attempt, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return nil, err
+		}
+		delay *= 2
+		if t.retry.MaxDelay > 0 && delay > t.retry.MaxDelay {
+			delay = t.retry.MaxDelay
+		}
+	}
+}
+
+// attempt runs the tool once, within the timeout if there is one. A
+// handler ignoring its context is left running after the timeout.
+func (t *retryTool) attempt(ctx context.Context, input map[string]any) (*McpToolResult, error) {
+	if t.retry.Timeout <= 0 {
+		return executeTool(ctx, t.McpTool, input)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, t.retry.Timeout)
+	defer cancel()
+
+	type outcome struct {
+		result *McpToolResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := executeTool(attemptCtx, t.McpTool, input)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case out := <-done:
+		return out.result, out.err
+	case <-attemptCtx.Done():
+		// The call itself was cancelled
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, clauderrs.NewCallbackError(
+			clauderrs.ErrCodeCallbackTimeout,
+			fmt.Sprintf("tool %s timed out after %s", t.Name(), t.retry.Timeout),
+			attemptCtx.Err(),
+			t.Name(),
+			true,
+		)
+	}
+}
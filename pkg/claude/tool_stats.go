@@ -0,0 +1,288 @@
+package claude
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxToolLatencySamples bounds the latency samples kept per tool. Older
+	// samples are overwritten once the limit is reached.
+	maxToolLatencySamples = 1024
+
+	// Percentiles reported by ToolStats.
+	percentile50 = 50
+	percentile95 = 95
+	percentTotal = 100
+)
+
+// ToolStats summarizes usage of a single tool within a session.
+type ToolStats struct {
+	ToolName string
+	// Count is the number of completed invocations.
+	Count int
+	// Failures is the number of invocations whose result had is_error set.
+	Failures int
+	// FailureRate is Failures divided by Count.
+	FailureRate float64
+	// P50 and P95 are latency percentiles over the retained samples,
+	// which come from calls timed by the collector's hooks.
+	P50 time.Duration
+	P95 time.Duration
+	// Max is the slowest retained sample.
+	Max time.Duration
+	// InFlight is the number of invocations still awaiting a result.
+	InFlight int
+}
+
+// pendingToolUse records a tool call awaiting its tool_result.
+type pendingToolUse struct {
+	name  string
+	start time.Time // Set by the PreToolUse hook
+	end   time.Time // Set by the PostToolUse hook
+}
+
+// toolCall is a completed tool call, as reported to recorders.
+type toolCall struct {
+	name    string
+	latency time.Duration
+	timed   bool
+	failed  bool
+}
+
+// toolSamples is a bounded ring of latency samples for one tool.
+type toolSamples struct {
+	count    int
+	failures int
+	samples  []time.Duration
+	next     int
+}
+
+// ToolStatsCollector measures per-tool latency and failure rates.
+//
+// Calls and failures are counted from the message stream: a call completes
+// with the tool_result matching its tool_use block, and fails if the result
+// has is_error set. Latency is the time between the CLI's PreToolUse and
+// PostToolUse hooks for the call, so only calls seen by the collector's
+// Hooks are timed; failed calls usually skip PostToolUse and are not.
+// ClaudeSDKClient maintains one automatically, registering its hooks with
+// Options.ToolTiming; use this type directly when consuming a Query
+// without the client.
+type ToolStatsCollector struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	pending   map[string]*pendingToolUse
+	tools     map[string]*toolSamples
+	recorders []MetricsRecorder // Added by WithMetrics
+}
+
+// NewToolStatsCollector creates an empty collector.
+func NewToolStatsCollector() *ToolStatsCollector {
+	return &ToolStatsCollector{
+		now:     time.Now,
+		pending: make(map[string]*pendingToolUse),
+		tools:   make(map[string]*toolSamples),
+	}
+}
+
+// WithClock replaces the time source, primarily for tests.
+func (c *ToolStatsCollector) WithClock(now func() time.Time) *ToolStatsCollector {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+
+	return c
+}
+
+// Hooks returns the PreToolUse and PostToolUse hooks that time tool calls.
+// Add them to Options.Hooks to time the calls of a Query.
+func (c *ToolStatsCollector) Hooks() map[HookEvent][]HookCallbackMatcher {
+	return map[HookEvent][]HookCallbackMatcher{
+		HookEventPreToolUse:  {{Hooks: []HookCallback{c.preToolUse}}},
+		HookEventPostToolUse: {{Hooks: []HookCallback{c.postToolUse}}},
+	}
+}
+
+// timedOptions returns opts with the collector's hooks added if
+// opts.ToolTiming is set, and opts otherwise.
+func (c *ToolStatsCollector) timedOptions(opts *Options) *Options {
+	if opts == nil || !opts.ToolTiming {
+		return opts
+	}
+
+	timed := *opts
+	timed.Hooks = maps.Clone(opts.Hooks)
+	if timed.Hooks == nil {
+		timed.Hooks = make(map[HookEvent][]HookCallbackMatcher)
+	}
+	for event, matchers := range c.Hooks() {
+		timed.Hooks[event] = append(slices.Clip(timed.Hooks[event]), matchers...)
+	}
+
+	return &timed
+}
+
+// preToolUse records the start of a tool call.
+func (c *ToolStatsCollector) preToolUse(_ context.Context, input HookInput, _ *string) (HookJSONOutput, error) {
+	if pre, ok := input.(PreToolUseHookInput); ok {
+		c.mu.Lock()
+		c.call(pre.ToolUseID, pre.ToolName).start = c.now()
+		c.mu.Unlock()
+	}
+
+	return SyncHookOutput{}, nil
+}
+
+// postToolUse records the end of a tool call.
+func (c *ToolStatsCollector) postToolUse(_ context.Context, input HookInput, _ *string) (HookJSONOutput, error) {
+	if post, ok := input.(PostToolUseHookInput); ok {
+		c.mu.Lock()
+		c.call(post.ToolUseID, post.ToolName).end = c.now()
+		c.mu.Unlock()
+	}
+
+	return SyncHookOutput{}, nil
+}
+
+// call returns the pending call id, adding it if needed. Callers must hold
+// c.mu.
+func (c *ToolStatsCollector) call(id, name string) *pendingToolUse {
+	pending, ok := c.pending[id]
+	if !ok {
+		pending = &pendingToolUse{name: name}
+		c.pending[id] = pending
+	}
+
+	return pending
+}
+
+// Observe records tool calls and completions found in msg.
+func (c *ToolStatsCollector) Observe(msg SDKMessage) {
+	switch m := msg.(type) {
+	case *SDKAssistantMessage:
+		c.observeBlocks(m.Message.Content)
+	case *SDKUserMessage:
+		c.observeBlocks(m.Message.Content)
+	}
+}
+
+// observeBlocks processes tool_use and tool_result blocks.
+func (c *ToolStatsCollector) observeBlocks(blocks []ContentBlock) {
+	c.mu.Lock()
+	var done []toolCall
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case ToolUseContentBlock:
+			c.call(b.ID, b.Name)
+		case ToolResultContentBlock:
+			pending, ok := c.pending[b.ToolUseID]
+			if !ok {
+				continue
+			}
+			delete(c.pending, b.ToolUseID)
+			call := toolCall{name: pending.name, failed: b.IsError}
+			if !pending.start.IsZero() && !pending.end.IsZero() {
+				call.latency = pending.end.Sub(pending.start)
+				call.timed = true
+			}
+			c.record(call)
+			done = append(done, call)
+		}
+	}
+	recorders := c.recorders
+	c.mu.Unlock()
+
+	for _, call := range done {
+		recordToolCall(recorders, call)
+	}
+}
+
+// record adds a completed call. Callers must hold c.mu.
+func (c *ToolStatsCollector) record(call toolCall) {
+	stats, ok := c.tools[call.name]
+	if !ok {
+		stats = &toolSamples{}
+		c.tools[call.name] = stats
+	}
+
+	stats.count++
+	if call.failed {
+		stats.failures++
+	}
+	if !call.timed {
+		return
+	}
+
+	if len(stats.samples) < maxToolLatencySamples {
+		stats.samples = append(stats.samples, call.latency)
+
+		return
+	}
+
+	stats.samples[stats.next] = call.latency
+	stats.next = (stats.next + 1) % maxToolLatencySamples
+}
+
+// addRecorder records the calls completed from now on in recorder.
+func (c *ToolStatsCollector) addRecorder(recorder MetricsRecorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.recorders = append(slices.Clip(c.recorders), recorder)
+}
+
+// Stats returns a snapshot of statistics keyed by tool name.
+func (c *ToolStatsCollector) Stats() map[string]ToolStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make(map[string]ToolStats, len(c.tools))
+
+	for name, samples := range c.tools {
+		sorted := append([]time.Duration(nil), samples.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		stats := ToolStats{
+			ToolName: name,
+			Count:    samples.count,
+			Failures: samples.failures,
+			P50:      percentile(sorted, percentile50),
+			P95:      percentile(sorted, percentile95),
+		}
+		if samples.count > 0 {
+			stats.FailureRate = float64(samples.failures) / float64(samples.count)
+		}
+		if len(sorted) > 0 {
+			stats.Max = sorted[len(sorted)-1]
+		}
+		result[name] = stats
+	}
+
+	for _, pending := range c.pending {
+		stats := result[pending.name]
+		stats.ToolName = pending.name
+		stats.InFlight++
+		result[pending.name] = stats
+	}
+
+	return result
+}
+
+// percentile returns the nearest-rank percentile of sorted samples.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := (p*len(sorted) + percentTotal - 1) / percentTotal
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
@@ -0,0 +1,133 @@
+package claude
+
+import (
+	"sync"
+	"time"
+)
+
+// maxToolUseRecords bounds the tool uses a client remembers. The oldest
+// records are dropped once the limit is reached.
+const maxToolUseRecords = 4096
+
+// ToolUseRecord correlates a tool_use block with what followed it, keyed
+// by its tool_use_id.
+type ToolUseRecord struct {
+	ID    string
+	Name  string
+	Input JSONValue
+	// ParentToolUseID is the Task tool use of the subagent that made this
+	// call, or "" for calls made by the main agent.
+	ParentToolUseID string
+	// Started is when the SDK received the tool_use block.
+	Started time.Time
+	// Completed is when the SDK received the tool_result, or zero while
+	// the tool is still running.
+	Completed time.Time
+	// Result is the tool_result block, or nil while the tool is running.
+	Result *ToolResultContentBlock
+	// Denied reports that the call was listed in a result's permission
+	// denials.
+	Denied bool
+}
+
+// Done reports whether the tool's result has been received.
+func (r ToolUseRecord) Done() bool {
+	return r.Result != nil
+}
+
+// Duration is the time from tool_use to tool_result, or zero while the
+// tool is running.
+func (r ToolUseRecord) Duration() time.Duration {
+	if r.Completed.IsZero() {
+		return 0
+	}
+
+	return r.Completed.Sub(r.Started)
+}
+
+// toolUseTracker maintains the tool_use_id correlation map of a client.
+type toolUseTracker struct {
+	mu      sync.Mutex
+	records map[string]*ToolUseRecord
+	order   []string // IDs, oldest first, for eviction
+}
+
+// observe records tool uses, results and denials found in msg.
+func (t *toolUseTracker) observe(msg SDKMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	switch m := msg.(type) {
+	case *SDKAssistantMessage:
+		parent := ""
+		if m.ParentToolUseID != nil {
+			parent = *m.ParentToolUseID
+		}
+		for _, block := range m.Message.Content {
+			if use, ok := block.(ToolUseContentBlock); ok {
+				t.add(&ToolUseRecord{
+					ID:              use.ID,
+					Name:            use.Name,
+					Input:           use.Input,
+					ParentToolUseID: parent,
+					Started:         now,
+				})
+			}
+		}
+	case *SDKUserMessage:
+		for _, block := range m.Message.Content {
+			if result, ok := block.(ToolResultContentBlock); ok {
+				if record, ok := t.records[result.ToolUseID]; ok {
+					record.Result = &result
+					record.Completed = now
+				}
+			}
+		}
+	case *SDKResultMessage:
+		for _, denial := range m.PermissionDenials {
+			if record, ok := t.records[denial.ToolUseID]; ok {
+				record.Denied = true
+			}
+		}
+	}
+}
+
+// add stores record, evicting the oldest record when full. Callers must
+// hold t.mu.
+func (t *toolUseTracker) add(record *ToolUseRecord) {
+	if t.records == nil {
+		t.records = make(map[string]*ToolUseRecord)
+	}
+	if _, ok := t.records[record.ID]; !ok {
+		if len(t.order) >= maxToolUseRecords {
+			delete(t.records, t.order[0])
+			t.order = t.order[1:]
+		}
+		t.order = append(t.order, record.ID)
+	}
+	t.records[record.ID] = record
+}
+
+// get returns a copy of the record for id.
+func (t *toolUseTracker) get(id string) (ToolUseRecord, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, ok := t.records[id]
+	if !ok {
+		return ToolUseRecord{}, false
+	}
+
+	return *record, true
+}
+
+// ToolUse returns what the client has seen of the tool use with the given
+// tool_use_id: its name, input, parent, timing and, once received, its
+// result. Hooks, CanUseTool and UI code can use it to enrich events
+// without tracking tool uses themselves. Inside a PostToolUse hook the
+// result is usually not yet recorded, since the CLI sends it after the
+// hook. The client remembers the most recent 4096 tool uses.
+func (c *ClaudeSDKClient) ToolUse(id string) (ToolUseRecord, bool) {
+	return c.toolUses.get(id)
+}
@@ -0,0 +1,264 @@
+package claude
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DiffOp is how an element of a TranscriptDiff differs between the
+// transcripts.
+type DiffOp string
+
+const (
+	// DiffEqual elements are in both transcripts.
+	DiffEqual DiffOp = "equal"
+	// DiffRemoved elements are only in the first transcript.
+	DiffRemoved DiffOp = "removed"
+	// DiffAdded elements are only in the second transcript.
+	DiffAdded DiffOp = "added"
+	// DiffChanged tool calls are to the same tool in both transcripts,
+	// with different input.
+	DiffChanged DiffOp = "changed"
+)
+
+// TextLineDiff is a line of assistant text.
+type TextLineDiff struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// ToolCallDiff is a tool call, matched by tool name across the
+// transcripts.
+type ToolCallDiff struct {
+	Op   DiffOp `json:"op"`
+	Name string `json:"name"`
+	// InputA and InputB are the call's input in each transcript, nil for
+	// the transcript without the call.
+	InputA JSONValue `json:"input_a,omitempty"`
+	InputB JSONValue `json:"input_b,omitempty"`
+}
+
+// TranscriptStats totals the results of a transcript.
+type TranscriptStats struct {
+	Turns        int `json:"turns"`
+	ToolCalls    int `json:"tool_calls"`
+	Cost         USD `json:"cost"`
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// TranscriptDiff is the difference between two transcripts, such as two
+// runs of the same prompt under different system prompts or models.
+type TranscriptDiff struct {
+	// Text diffs the lines of the assistant text.
+	Text []TextLineDiff `json:"text"`
+	// ToolCalls diffs the sequence of tool calls.
+	ToolCalls []ToolCallDiff `json:"tool_calls"`
+	// A and B total each transcript's results.
+	A TranscriptStats `json:"a"`
+	B TranscriptStats `json:"b"`
+}
+
+// DiffTranscripts compares the assistant text, tool calls and costs of the
+// messages of two runs, such as those collected from ReceiveResponse.
+// Text is compared line by line and tool calls by name, then by input with
+// JSON formatting and key order ignored.
+func DiffTranscripts(a, b []SDKMessage) *TranscriptDiff {
+	textA, callsA, statsA := summarizeTranscript(a)
+	textB, callsB, statsB := summarizeTranscript(b)
+	diff := &TranscriptDiff{A: statsA, B: statsB}
+
+	for _, pair := range diffSequences(textA, textB, func(x, y string) bool { return x == y }) {
+		line := TextLineDiff{Op: pair.op}
+		if pair.a >= 0 {
+			line.Text = textA[pair.a]
+		} else {
+			line.Text = textB[pair.b]
+		}
+		diff.Text = append(diff.Text, line)
+	}
+
+	sameTool := func(x, y ToolUseContentBlock) bool { return x.Name == y.Name }
+	for _, pair := range diffSequences(callsA, callsB, sameTool) {
+		var call ToolCallDiff
+		if pair.a >= 0 {
+			call.Name = callsA[pair.a].Name
+			call.InputA = callsA[pair.a].Input
+		}
+		if pair.b >= 0 {
+			call.Name = callsB[pair.b].Name
+			call.InputB = callsB[pair.b].Input
+		}
+		call.Op = pair.op
+		if call.Op == DiffEqual && !equalJSON(call.InputA, call.InputB) {
+			call.Op = DiffChanged
+		}
+		diff.ToolCalls = append(diff.ToolCalls, call)
+	}
+
+	return diff
+}
+
+// Equal reports whether the transcripts have the same text and tool calls.
+// Costs are not compared.
+func (d *TranscriptDiff) Equal() bool {
+	for _, line := range d.Text {
+		if line.Op != DiffEqual {
+			return false
+		}
+	}
+	for _, call := range d.ToolCalls {
+		if call.Op != DiffEqual {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CostDelta returns how much more the second transcript cost.
+func (d *TranscriptDiff) CostDelta() USD {
+	return d.B.Cost - d.A.Cost
+}
+
+// String renders the diff for review, marking lines and tool calls only in
+// the first transcript with "-", only in the second with "+", and changed
+// tool calls with "~".
+func (d *TranscriptDiff) String() string {
+	var out strings.Builder
+	out.WriteString("text:\n")
+	for _, line := range d.Text {
+		fmt.Fprintf(&out, "%s %s\n", diffMarker(line.Op), line.Text)
+	}
+
+	out.WriteString("tool calls:\n")
+	for _, call := range d.ToolCalls {
+		switch call.Op {
+		case DiffRemoved:
+			fmt.Fprintf(&out, "- %s %s\n", call.Name, call.InputA)
+		case DiffChanged:
+			fmt.Fprintf(&out, "~ %s %s -> %s\n", call.Name, call.InputA, call.InputB)
+		default:
+			fmt.Fprintf(&out, "%s %s %s\n", diffMarker(call.Op), call.Name, call.InputB)
+		}
+	}
+
+	delta := d.CostDelta()
+	sign := "+"
+	if delta < 0 {
+		sign = ""
+	}
+	fmt.Fprintf(&out, "cost: $%s -> $%s (%s$%s)\n", d.A.Cost, d.B.Cost, sign, delta)
+	fmt.Fprintf(&out, "turns: %d -> %d\n", d.A.Turns, d.B.Turns)
+	fmt.Fprintf(&out, "tokens: %d/%d -> %d/%d (input/output)\n",
+		d.A.InputTokens, d.A.OutputTokens, d.B.InputTokens, d.B.OutputTokens)
+
+	return out.String()
+}
+
+func diffMarker(op DiffOp) string {
+	switch op {
+	case DiffRemoved:
+		return "-"
+	case DiffAdded:
+		return "+"
+	case DiffChanged:
+		return "~"
+	default:
+		return " "
+	}
+}
+
+// summarizeTranscript returns the lines of assistant text, tool calls and
+// result totals of msgs.
+func summarizeTranscript(msgs []SDKMessage) ([]string, []ToolUseContentBlock, TranscriptStats) {
+	var (
+		lines []string
+		calls []ToolUseContentBlock
+		stats TranscriptStats
+	)
+	for _, msg := range msgs {
+		switch m := msg.(type) {
+		case *SDKAssistantMessage:
+			if text := assistantText(m); text != "" {
+				lines = append(lines, strings.Split(text, "\n")...)
+			}
+			for _, block := range m.Message.Content {
+				if use, ok := block.(ToolUseContentBlock); ok {
+					calls = append(calls, use)
+				}
+			}
+		case *SDKResultMessage:
+			stats.Turns++
+			stats.Cost += m.TotalCost()
+			stats.InputTokens += m.Usage.InputTokens
+			stats.OutputTokens += m.Usage.OutputTokens
+		}
+	}
+	stats.ToolCalls = len(calls)
+
+	return lines, calls, stats
+}
+
+// equalJSON reports whether a and b encode the same value.
+func equalJSON(a, b JSONValue) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	ca, errA := json.Marshal(va)
+	cb, errB := json.Marshal(vb)
+
+	return errA == nil && errB == nil && bytes.Equal(ca, cb)
+}
+
+// diffPair is an element of a sequence diff: indexes into the first and
+// second sequence, -1 for the sequence without the element.
+type diffPair struct {
+	op   DiffOp
+	a, b int
+}
+
+// diffSequences aligns a and b along their longest common subsequence
+// under equal, listing removals before additions at each difference.
+func diffSequences[T any](a, b []T, equal func(T, T) bool) []diffPair {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if equal(a[i], b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var pairs []diffPair
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && equal(a[i], b[j]):
+			pairs = append(pairs, diffPair{op: DiffEqual, a: i, b: j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			pairs = append(pairs, diffPair{op: DiffRemoved, a: i, b: -1})
+			i++
+		default:
+			pairs = append(pairs, diffPair{op: DiffAdded, a: -1, b: j})
+			j++
+		}
+	}
+
+	return pairs
+}
@@ -0,0 +1,185 @@
+package claude
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// TruncationStrategy selects which part of oversized content
+// TruncateBlocks keeps.
+type TruncationStrategy string
+
+const (
+	// TruncateHead keeps the beginning and drops the end.
+	TruncateHead TruncationStrategy = "head"
+	// TruncateTail keeps the end and drops the beginning, as for logs
+	// whose latest lines matter most.
+	TruncateTail TruncationStrategy = "tail"
+	// TruncateMiddleOut keeps the beginning and the end and drops the
+	// middle, as for diffs and stack traces.
+	TruncateMiddleOut TruncationStrategy = "middle-out"
+)
+
+// truncationMarker replaces the content TruncateBlocks drops.
+const truncationMarker = "[... about %d tokens truncated ...]"
+
+// TruncateBlocks shortens content blocks to at most maxTokens estimated
+// tokens, for preparing long inputs such as log files and diffs before
+// sending them. Text blocks are cut, at a line break where one is near;
+// other blocks such as images are kept whole or dropped. The dropped part
+// is replaced by a text block saying about how many tokens were removed.
+// Blocks already within maxTokens are returned unchanged.
+//
+// Sizes are measured with EstimateBlockTokens, so leave some headroom
+// below hard limits.
+func TruncateBlocks(blocks []ContentBlock, maxTokens int, strategy TruncationStrategy) ([]ContentBlock, error) {
+	switch strategy {
+	case TruncateHead, TruncateTail, TruncateMiddleOut:
+	default:
+		return nil, clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidType,
+			fmt.Sprintf("unknown truncation strategy %q", strategy),
+			nil,
+			"strategy",
+			strategy,
+		)
+	}
+	if maxTokens <= 0 {
+		return nil, clauderrs.NewValidationError(
+			clauderrs.ErrCodeRangeViolation,
+			"maxTokens must be positive",
+			nil,
+			"maxTokens",
+			maxTokens,
+		)
+	}
+
+	total := EstimateBlockTokens("", blocks)
+	if total <= maxTokens {
+		return blocks, nil
+	}
+
+	budget := max(maxTokens-EstimateTokens("", fmt.Sprintf(truncationMarker, total)), 0)
+
+	var head, tail []ContentBlock
+	switch strategy {
+	case TruncateHead:
+		head, _ = takeHead(blocks, budget)
+	case TruncateTail:
+		tail = takeTail(blocks, budget)
+	case TruncateMiddleOut:
+		var rest []ContentBlock
+		head, rest = takeHead(blocks, budget/2)
+		tail = takeTail(rest, budget-EstimateBlockTokens("", head))
+	}
+
+	dropped := total - EstimateBlockTokens("", head) - EstimateBlockTokens("", tail)
+	truncated := make([]ContentBlock, 0, len(head)+len(tail)+1)
+	truncated = append(truncated, head...)
+	truncated = append(truncated, TextContentBlock{Type: "text", Text: fmt.Sprintf(truncationMarker, dropped)})
+
+	return append(truncated, tail...), nil
+}
+
+// takeHead returns the leading blocks fitting budget, cutting the last
+// text block if needed, and the blocks left over.
+func takeHead(blocks []ContentBlock, budget int) (kept, rest []ContentBlock) {
+	used := 0
+	for i, block := range blocks {
+		tokens := EstimateBlockTokens("", []ContentBlock{block})
+		if used+tokens <= budget {
+			kept = append(kept, block)
+			used += tokens
+
+			continue
+		}
+
+		rest = blocks[i:]
+		if text, ok := block.(TextContentBlock); ok {
+			prefix := textHead(text.Text, budget-used)
+			if prefix != "" {
+				kept = append(kept, TextContentBlock{Type: text.Type, Text: prefix})
+			}
+			rest = append([]ContentBlock{TextContentBlock{Type: text.Type, Text: text.Text[len(prefix):]}}, blocks[i+1:]...)
+		}
+
+		return kept, rest
+	}
+
+	return kept, nil
+}
+
+// takeTail returns the trailing blocks fitting budget, cutting the first
+// text block if needed.
+func takeTail(blocks []ContentBlock, budget int) []ContentBlock {
+	used := 0
+	start := len(blocks)
+	for start > 0 {
+		block := blocks[start-1]
+		tokens := EstimateBlockTokens("", []ContentBlock{block})
+		if used+tokens > budget {
+			break
+		}
+		used += tokens
+		start--
+	}
+
+	kept := blocks[start:]
+	if start == 0 {
+		return kept
+	}
+	if text, ok := blocks[start-1].(TextContentBlock); ok {
+		if suffix := textTail(text.Text, budget-used); suffix != "" {
+			kept = append([]ContentBlock{TextContentBlock{Type: text.Type, Text: suffix}}, kept...)
+		}
+	}
+
+	return kept
+}
+
+// textHead returns the longest prefix of text within budget tokens,
+// shortened to end at a line break in its second half if there is one.
+func textHead(text string, budget int) string {
+	runes := []rune(text)
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if EstimateTokens("", string(runes[:mid])) <= budget {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	prefix := string(runes[:lo])
+	if i := strings.LastIndexByte(prefix, '\n'); i >= len(prefix)/2 {
+		prefix = prefix[:i+1]
+	}
+
+	return prefix
+}
+
+// textTail returns the longest suffix of text within budget tokens,
+// shortened to start after a line break in its first half if there is
+// one.
+func textTail(text string, budget int) string {
+	runes := []rune(text)
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if EstimateTokens("", string(runes[mid:])) <= budget {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	suffix := string(runes[lo:])
+	if i := strings.IndexByte(suffix, '\n'); i >= 0 && i < len(suffix)/2 {
+		suffix = suffix[i+1:]
+	}
+
+	return suffix
+}
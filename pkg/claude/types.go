@@ -107,6 +107,8 @@ type ModelUsage struct {
 	WebSearchRequests        int     `json:"webSearchRequests"`
 	CostUSD                  float64 `json:"costUSD"`
 	ContextWindow            int     `json:"contextWindow"`
+
+	cost *USD // costUSD as decoded, for Cost
 }
 
 // McpServerConfig represents different MCP server configurations.
@@ -263,6 +265,10 @@ type PermissionAllow struct {
 	ToolUseID          *string              `json:"toolUseID,omitempty"`
 	UpdatedInput       map[string]JSONValue `json:"updatedInput"`
 	UpdatedPermissions []PermissionUpdate   `json:"updatedPermissions,omitempty"`
+	// ApplySuggestions accepts the permission suggestions passed to
+	// CanUseTool in addition to UpdatedPermissions, e.g. "always allow this
+	// tool for this session".
+	ApplySuggestions bool `json:"-"`
 }
 
 func (PermissionAllow) permissionResult() {}
@@ -343,9 +349,31 @@ type OutputFormat = JsonSchemaOutputFormat
 
 // SdkPluginConfig describes the configuration for an SDK plugin.
 // Plugins extend SDK functionality through external modules loaded at runtime.
-// The Type field specifies the plugin type, and Path indicates the location
-// of the plugin module.
+// The Type field specifies where the plugin comes from:
+//
+//   - PluginTypeLocal: a plugin directory at Path.
+//   - PluginTypeGit: a git repository at URL, checked out at Ref (or the
+//     default branch), with the plugin in Subdir.
+//   - PluginTypeRegistry: the plugin called Name in the marketplace
+//     repository at URL, whose .claude-plugin/marketplace.json says where
+//     the plugin's source is.
+//
+// Git and registry plugins are fetched with the git executable into
+// Options.PluginCacheDir before the CLI starts. Setting SHA pins the
+// checkout to that commit: a cached checkout at the commit is reused
+// without network access, and the query fails if the fetched commit does
+// not match.
 type SdkPluginConfig struct {
 	Type string `json:"type"`
-	Path string `json:"path"`
+	Path string `json:"path,omitempty"`
+	// URL is the repository URL for git and registry plugins.
+	URL string `json:"url,omitempty"`
+	// Ref is the branch or tag to check out.
+	Ref string `json:"ref,omitempty"`
+	// SHA is the full commit hash the checkout must be at.
+	SHA string `json:"sha,omitempty"`
+	// Subdir is the plugin's directory within the repository.
+	Subdir string `json:"subdir,omitempty"`
+	// Name is the plugin to install from a registry.
+	Name string `json:"name,omitempty"`
 }
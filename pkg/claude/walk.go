@@ -0,0 +1,114 @@
+package claude
+
+import "errors"
+
+// SkipBlocks, returned by a BlockVisitor method, stops WalkBlocks without
+// an error.
+var SkipBlocks = errors.New("skip remaining blocks")
+
+// BlockVisitor receives the content blocks of a message from WalkBlocks,
+// one typed method per block kind. Embed NopBlockVisitor and override only
+// the methods of interest, so visitors keep compiling when methods for new
+// block kinds are added.
+//
+// A non-nil error stops the walk and is returned by WalkBlocks, except
+// SkipBlocks, which stops it cleanly.
+type BlockVisitor interface {
+	// VisitText receives text blocks. TextBlock values are passed as the
+	// equivalent TextContentBlock.
+	VisitText(block TextContentBlock) error
+	VisitToolUse(block ToolUseContentBlock) error
+	// VisitToolResult receives tool results. Blocks nested in the result's
+	// content are visited right after it.
+	VisitToolResult(block ToolResultContentBlock) error
+	VisitThinking(block ThinkingBlock) error
+	VisitImage(block ImageContentBlock) error
+	// VisitOther receives every other block, such as RedactedThinkingBlock
+	// and ResourceContentBlock.
+	VisitOther(block ContentBlock) error
+}
+
+// NopBlockVisitor implements BlockVisitor by ignoring every block.
+type NopBlockVisitor struct{}
+
+// VisitText ignores block.
+func (NopBlockVisitor) VisitText(TextContentBlock) error { return nil }
+
+// VisitToolUse ignores block.
+func (NopBlockVisitor) VisitToolUse(ToolUseContentBlock) error { return nil }
+
+// VisitToolResult ignores block.
+func (NopBlockVisitor) VisitToolResult(ToolResultContentBlock) error { return nil }
+
+// VisitThinking ignores block.
+func (NopBlockVisitor) VisitThinking(ThinkingBlock) error { return nil }
+
+// VisitImage ignores block.
+func (NopBlockVisitor) VisitImage(ImageContentBlock) error { return nil }
+
+// VisitOther ignores block.
+func (NopBlockVisitor) VisitOther(ContentBlock) error { return nil }
+
+// WalkBlocks passes each content block of msg, in order, to visitor.
+// Assistant and user messages, including replayed ones, have content
+// blocks; other messages have none and are ignored.
+func WalkBlocks(msg SDKMessage, visitor BlockVisitor) error {
+	var content []ContentBlock
+	switch m := msg.(type) {
+	case *SDKAssistantMessage:
+		content = m.Message.Content
+	case SDKAssistantMessage:
+		content = m.Message.Content
+	case *SDKUserMessage:
+		content = m.Message.Content
+	case SDKUserMessage:
+		content = m.Message.Content
+	case *SDKUserMessageReplay:
+		content = m.Message.Content
+	case SDKUserMessageReplay:
+		content = m.Message.Content
+	}
+
+	return WalkContent(content, visitor)
+}
+
+// WalkContent is WalkBlocks for a slice of content blocks.
+func WalkContent(content []ContentBlock, visitor BlockVisitor) error {
+	err := walkContent(content, visitor)
+	if errors.Is(err, SkipBlocks) {
+		return nil
+	}
+
+	return err
+}
+
+// walkContent visits content depth-first.
+func walkContent(content []ContentBlock, visitor BlockVisitor) error {
+	for _, block := range content {
+		var err error
+		switch b := block.(type) {
+		case TextContentBlock:
+			err = visitor.VisitText(b)
+		case TextBlock:
+			err = visitor.VisitText(TextContentBlock(b))
+		case ToolUseContentBlock:
+			err = visitor.VisitToolUse(b)
+		case ToolResultContentBlock:
+			err = visitor.VisitToolResult(b)
+			if err == nil && b.Content != nil {
+				err = walkContent(b.Content.Blocks, visitor)
+			}
+		case ThinkingBlock:
+			err = visitor.VisitThinking(b)
+		case ImageContentBlock:
+			err = visitor.VisitImage(b)
+		default:
+			err = visitor.VisitOther(block)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	webFetchTool  = "WebFetch"
+	webSearchTool = "WebSearch"
+)
+
+// WebPolicy restricts the domains WebFetch and WebSearch may reach.
+//
+// Domains match themselves and their subdomains; a "*." prefix matches
+// subdomains only. Blocked domains take precedence over allowed ones.
+//
+// WebFetch calls are denied unless the URL's host is permitted. WebSearch
+// calls must restrict results with allowed_domains (when AllowedDomains is
+// set) or blocked_domains (when only BlockedDomains is set). Deny messages
+// list the permitted domains so the model can retry within the policy.
+type WebPolicy struct {
+	// AllowedDomains, if set, are the only domains that may be reached.
+	AllowedDomains []string
+	// BlockedDomains may never be reached.
+	BlockedDomains []string
+	// MaxResponseBytes, if positive, makes WebFetch responses larger than
+	// this many bytes (as JSON) get reported back to the model as blocked.
+	// The CLI has already fetched the content by then, so this bounds what
+	// the model acts on rather than what is downloaded.
+	MaxResponseBytes int
+}
+
+// webSearchInput is the WebSearch tool input.
+type webSearchInput struct {
+	Query          string   `json:"query"`
+	AllowedDomains []string `json:"allowed_domains"`
+	BlockedDomains []string `json:"blocked_domains"`
+}
+
+// hooks returns the hook matchers that enforce the policy.
+func (p *WebPolicy) hooks() map[HookEvent][]HookCallbackMatcher {
+	tools := webFetchTool + "|" + webSearchTool
+	hooks := map[HookEvent][]HookCallbackMatcher{
+		HookEventPreToolUse: {{Matcher: &tools, Hooks: []HookCallback{p.preToolUse}}},
+	}
+
+	if p.MaxResponseBytes > 0 {
+		fetch := webFetchTool
+		hooks[HookEventPostToolUse] = []HookCallbackMatcher{
+			{Matcher: &fetch, Hooks: []HookCallback{p.postToolUse}},
+		}
+	}
+
+	return hooks
+}
+
+// preToolUse denies web tool calls outside the policy.
+func (p *WebPolicy) preToolUse(
+	_ context.Context,
+	input HookInput,
+	_ *string,
+) (HookJSONOutput, error) {
+	pre, ok := input.(PreToolUseHookInput)
+	if !ok {
+		return SyncHookOutput{}, nil
+	}
+
+	reason, allowed := p.CheckToolInput(pre.ToolName, pre.ToolInput)
+	if allowed {
+		return SyncHookOutput{}, nil
+	}
+
+	decision := string(PermissionDecisionDeny)
+
+	return SyncHookOutput{
+		HookSpecificOutput: PreToolUseHookOutput{
+			HookEventName:            HookEventPreToolUse,
+			PermissionDecision:       &decision,
+			PermissionDecisionReason: &reason,
+		},
+	}, nil
+}
+
+// postToolUse blocks WebFetch responses over MaxResponseBytes.
+func (p *WebPolicy) postToolUse(
+	_ context.Context,
+	input HookInput,
+	_ *string,
+) (HookJSONOutput, error) {
+	post, ok := input.(PostToolUseHookInput)
+	if !ok || len(post.ToolResponse) <= p.MaxResponseBytes {
+		return SyncHookOutput{}, nil
+	}
+
+	return Block(fmt.Sprintf(
+		"WebFetch response was %d bytes, over the %d byte limit; fetch a smaller page or a more specific URL",
+		len(post.ToolResponse),
+		p.MaxResponseBytes,
+	)), nil
+}
+
+// CheckToolInput checks a WebFetch or WebSearch tool input against the
+// policy and returns a deny reason if it is not allowed. Other tools are
+// allowed.
+func (p *WebPolicy) CheckToolInput(toolName string, input JSONValue) (string, bool) {
+	switch toolName {
+	case webFetchTool:
+		var fetch struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal(input, &fetch); err != nil {
+			return "WebFetch input has no url", false
+		}
+
+		return p.checkURL(fetch.URL)
+	case webSearchTool:
+		var search webSearchInput
+		if err := json.Unmarshal(input, &search); err != nil {
+			return "WebSearch input could not be parsed", false
+		}
+
+		return p.checkSearch(search)
+	default:
+		return "", true
+	}
+}
+
+// checkURL checks a WebFetch URL.
+func (p *WebPolicy) checkURL(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Sprintf("WebFetch URL %q is not a valid http(s) URL", rawURL), false
+	}
+
+	return p.checkDomain(u.Hostname())
+}
+
+// checkSearch checks that a WebSearch is restricted to the policy.
+func (p *WebPolicy) checkSearch(search webSearchInput) (string, bool) {
+	for _, domain := range search.AllowedDomains {
+		if reason, ok := p.checkDomain(domain); !ok {
+			return reason, false
+		}
+	}
+	if len(search.AllowedDomains) > 0 {
+		return "", true
+	}
+
+	if len(p.AllowedDomains) > 0 {
+		return fmt.Sprintf(
+			"WebSearch must set allowed_domains to domains from: %s",
+			strings.Join(p.AllowedDomains, ", "),
+		), false
+	}
+
+	var missing []string
+	for _, blocked := range p.BlockedDomains {
+		if !slices.ContainsFunc(search.BlockedDomains, func(d string) bool {
+			return normalizeDomain(d) == normalizeDomain(blocked)
+		}) {
+			missing = append(missing, blocked)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Sprintf(
+			"WebSearch must set blocked_domains to include: %s",
+			strings.Join(missing, ", "),
+		), false
+	}
+
+	return "", true
+}
+
+// checkDomain checks a host against the blocked and allowed domains.
+func (p *WebPolicy) checkDomain(host string) (string, bool) {
+	host = normalizeDomain(host)
+
+	for _, blocked := range p.BlockedDomains {
+		if domainMatches(blocked, host) {
+			return fmt.Sprintf("domain %s is blocked by policy%s", host, p.permittedSuffix()), false
+		}
+	}
+
+	if len(p.AllowedDomains) == 0 {
+		return "", true
+	}
+	for _, allowed := range p.AllowedDomains {
+		if domainMatches(allowed, host) {
+			return "", true
+		}
+	}
+
+	return fmt.Sprintf("domain %s is not permitted%s", host, p.permittedSuffix()), false
+}
+
+// permittedSuffix lists the allowed domains for deny messages.
+func (p *WebPolicy) permittedSuffix() string {
+	if len(p.AllowedDomains) == 0 {
+		return ""
+	}
+
+	return "; permitted domains: " + strings.Join(p.AllowedDomains, ", ")
+}
+
+// domainMatches reports whether host is pattern or one of its subdomains,
+// or only a subdomain for "*." patterns.
+func domainMatches(pattern, host string) bool {
+	pattern = normalizeDomain(pattern)
+	if parent, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+parent)
+	}
+
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+// normalizeDomain lowercases a domain and drops a trailing dot.
+func normalizeDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
@@ -0,0 +1,75 @@
+package claudegrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// Code is a gRPC status code. The values match google.golang.org/grpc/codes
+// so adapters can convert with codes.Code(c).
+type Code uint32
+
+// gRPC status codes returned by Code.
+const (
+	CodeOK                 Code = 0
+	CodeCanceled           Code = 1
+	CodeUnknown            Code = 2
+	CodeInvalidArgument    Code = 3
+	CodeDeadlineExceeded   Code = 4
+	CodeNotFound           Code = 5
+	CodePermissionDenied   Code = 7
+	CodeResourceExhausted  Code = 8
+	CodeFailedPrecondition Code = 9
+	CodeAborted            Code = 10
+	CodeInternal           Code = 13
+	CodeUnavailable        Code = 14
+	CodeUnauthenticated    Code = 16
+)
+
+// ErrorCode maps an SDK error to the gRPC status code a server should
+// return for it.
+func ErrorCode(err error) Code {
+	switch {
+	case err == nil:
+		return CodeOK
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeDeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	case clauderrs.IsAbortedError(err):
+		return CodeAborted
+	}
+
+	sdkErr, ok := clauderrs.AsSDKError(err)
+	if !ok {
+		return CodeUnknown
+	}
+
+	switch sdkErr.Code() {
+	case clauderrs.ErrCodeSessionNotFound:
+		return CodeNotFound
+	case clauderrs.ErrCodeSessionBusy:
+		return CodeAborted
+	case clauderrs.ErrCodeClientClosed,
+		clauderrs.ErrCodeNoActiveQuery,
+		clauderrs.ErrCodeInvalidState:
+		return CodeFailedPrecondition
+	case clauderrs.ErrCodeAPIUnauthorized, clauderrs.ErrCodeMissingAPIKey:
+		return CodeUnauthenticated
+	case clauderrs.ErrCodeAPIRateLimit, clauderrs.ErrCodeQuotaExceeded:
+		return CodeResourceExhausted
+	}
+
+	switch sdkErr.Category() {
+	case clauderrs.CategoryValidation:
+		return CodeInvalidArgument
+	case clauderrs.CategoryPermission:
+		return CodePermissionDenied
+	case clauderrs.CategoryNetwork, clauderrs.CategoryProcess, clauderrs.CategoryTransport:
+		return CodeUnavailable
+	default:
+		return CodeInternal
+	}
+}
@@ -0,0 +1,99 @@
+package claudegrpc
+
+import (
+	"context"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claudegrpc/agentpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RegisterAgentServer registers s as the AgentService of registrar, such
+// as a *grpc.Server. Errors are returned as gRPC statuses with the code
+// ErrorCode maps them to.
+func RegisterAgentServer(registrar grpc.ServiceRegistrar, s *Server) {
+	agentpb.RegisterAgentServiceServer(registrar, &agentService{server: s})
+}
+
+// agentService adapts Server to the generated AgentServiceServer.
+type agentService struct {
+	agentpb.UnimplementedAgentServiceServer
+
+	server *Server
+}
+
+func (a *agentService) Query(ctx context.Context, req *agentpb.QueryRequest) (*agentpb.QueryResponse, error) {
+	resp, err := a.server.Query(ctx, &QueryRequest{SessionID: req.GetSessionId(), Prompt: req.GetPrompt()})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &agentpb.QueryResponse{
+		SessionId:    resp.SessionID,
+		Result:       resp.Result,
+		IsError:      resp.IsError,
+		Subtype:      resp.Subtype,
+		TotalCostUsd: resp.TotalCostUsd,
+		NumTurns:     resp.NumTurns,
+	}, nil
+}
+
+func (a *agentService) Stream(req *agentpb.QueryRequest, stream grpc.ServerStreamingServer[agentpb.Event]) error {
+	err := a.server.Stream(
+		stream.Context(),
+		&QueryRequest{SessionID: req.GetSessionId(), Prompt: req.GetPrompt()},
+		func(event *Event) error {
+			return stream.Send(&agentpb.Event{SessionId: event.SessionID, Type: event.Type, Json: event.JSON})
+		},
+	)
+
+	return statusError(err)
+}
+
+func (a *agentService) Interrupt(ctx context.Context, req *agentpb.InterruptRequest) (*agentpb.InterruptResponse, error) {
+	if err := a.server.Interrupt(ctx, req.GetSessionId()); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &agentpb.InterruptResponse{}, nil
+}
+
+func (a *agentService) SetModel(ctx context.Context, req *agentpb.SetModelRequest) (*agentpb.SetModelResponse, error) {
+	if err := a.server.SetModel(ctx, req.GetSessionId(), req.GetModel()); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &agentpb.SetModelResponse{}, nil
+}
+
+func (a *agentService) SetPermissionMode(
+	ctx context.Context,
+	req *agentpb.SetPermissionModeRequest,
+) (*agentpb.SetPermissionModeResponse, error) {
+	if err := a.server.SetPermissionMode(ctx, req.GetSessionId(), req.GetMode()); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &agentpb.SetPermissionModeResponse{}, nil
+}
+
+func (a *agentService) CloseSession(_ context.Context, req *agentpb.CloseSessionRequest) (*agentpb.CloseSessionResponse, error) {
+	if err := a.server.CloseSession(req.GetSessionId()); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &agentpb.CloseSessionResponse{}, nil
+}
+
+// statusError converts an SDK error to a gRPC status error.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	return status.Error(codes.Code(ErrorCode(err)), err.Error())
+}
@@ -0,0 +1,288 @@
+// Package claudegrpc exposes ClaudeSDKClient sessions as an agent service so
+// polyglot services can use the Go SDK as a sidecar.
+//
+// The service is defined in agent.proto, with generated stubs in agentpb.
+// Server is the transport-independent implementation, whose message types
+// mirror the proto messages field for field; RegisterAgentServer serves it
+// on a *grpc.Server:
+//
+//	grpcServer := grpc.NewServer()
+//	claudegrpc.RegisterAgentServer(grpcServer, claudegrpc.NewServer(opts))
+//	_ = grpcServer.Serve(listener)
+package claudegrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+	"github.com/google/uuid"
+)
+
+// QueryRequest mirrors the QueryRequest proto message.
+type QueryRequest struct {
+	// SessionID selects an existing session; empty starts a new one.
+	SessionID string
+	Prompt    string
+}
+
+// QueryResponse mirrors the QueryResponse proto message.
+type QueryResponse struct {
+	SessionID    string
+	Result       string
+	IsError      bool
+	Subtype      string
+	TotalCostUsd float64
+	NumTurns     int32
+}
+
+// Event mirrors the Event proto message.
+type Event struct {
+	SessionID string
+	// Type is the message type, e.g. "assistant" or "result".
+	Type string
+	// JSON is the stream-json encoding of the message.
+	JSON []byte
+}
+
+// Server serves agent sessions backed by ClaudeSDKClient.
+//
+// Each session owns one client created from the server's base options and
+// runs one turn at a time; a Query or Stream on a session already running
+// a turn fails with ErrCodeSessionBusy, which ErrorCode maps to
+// CodeAborted. Sessions live until Close or CloseSession is called.
+type Server struct {
+	opts     *claude.Options
+	mu       sync.Mutex
+	sessions map[string]*claude.ClaudeSDKClient
+	busy     map[string]struct{} // Sessions running a turn
+}
+
+// NewServer creates a server whose sessions use a copy of opts.
+func NewServer(opts *claude.Options) *Server {
+	base := claude.Options{}
+	if opts != nil {
+		base = *opts
+	}
+
+	return &Server{
+		opts:     &base,
+		sessions: make(map[string]*claude.ClaudeSDKClient),
+		busy:     make(map[string]struct{}),
+	}
+}
+
+// Query sends a prompt and waits for the result of the turn.
+func (s *Server) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	resp := &QueryResponse{}
+
+	err := s.run(ctx, req, func(sessionID string, msg claude.SDKMessage) error {
+		resp.SessionID = sessionID
+		if result, ok := msg.(*claude.SDKResultMessage); ok {
+			resp.IsError = result.IsError
+			resp.Subtype = result.Subtype
+			resp.TotalCostUsd = result.TotalCostUSD
+			resp.NumTurns = int32(result.NumTurns)
+			if result.Result != nil {
+				resp.Result = *result.Result
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Stream sends a prompt and calls send for every message of the turn. An
+// error from send stops the stream and is returned.
+func (s *Server) Stream(
+	ctx context.Context,
+	req *QueryRequest,
+	send func(*Event) error,
+) error {
+	return s.run(ctx, req, func(sessionID string, msg claude.SDKMessage) error {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return clauderrs.NewProtocolError(
+				clauderrs.ErrCodeMessageParseFailed,
+				"failed to marshal message",
+				err,
+			).
+				WithSessionID(sessionID).
+				WithMessageType(msg.Type())
+		}
+
+		return send(&Event{SessionID: sessionID, Type: msg.Type(), JSON: data})
+	})
+}
+
+// Interrupt stops the current turn of a session.
+func (s *Server) Interrupt(ctx context.Context, sessionID string) error {
+	client, err := s.session(sessionID)
+	if err != nil {
+		return err
+	}
+
+	return client.Interrupt(ctx)
+}
+
+// SetModel changes the model of a session. An empty model resets it to the
+// default.
+func (s *Server) SetModel(ctx context.Context, sessionID, model string) error {
+	client, err := s.session(sessionID)
+	if err != nil {
+		return err
+	}
+
+	var modelPtr *string
+	if model != "" {
+		modelPtr = &model
+	}
+
+	return client.SetModel(ctx, modelPtr)
+}
+
+// SetPermissionMode changes the permission mode of a session.
+func (s *Server) SetPermissionMode(ctx context.Context, sessionID, mode string) error {
+	client, err := s.session(sessionID)
+	if err != nil {
+		return err
+	}
+
+	return client.SetPermissionMode(ctx, claude.PermissionMode(mode))
+}
+
+// CloseSession closes and forgets a session.
+func (s *Server) CloseSession(sessionID string) error {
+	s.mu.Lock()
+	client, ok := s.sessions[sessionID]
+	delete(s.sessions, sessionID)
+	s.mu.Unlock()
+
+	if !ok {
+		return sessionNotFound(sessionID)
+	}
+
+	return client.Close()
+}
+
+// Close closes every session.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	sessions := s.sessions
+	s.sessions = make(map[string]*claude.ClaudeSDKClient)
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, client := range sessions {
+		if err := client.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// run sends the prompt on the requested session and feeds each message of
+// the turn to handle.
+func (s *Server) run(
+	ctx context.Context,
+	req *QueryRequest,
+	handle func(sessionID string, msg claude.SDKMessage) error,
+) error {
+	if req == nil || req.Prompt == "" {
+		return clauderrs.NewValidationError(
+			clauderrs.ErrCodeMissingField,
+			"prompt is required",
+			nil,
+			"prompt",
+			"",
+		)
+	}
+
+	sessionID, client, err := s.sessionFor(req.SessionID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	_, running := s.busy[sessionID]
+	s.busy[sessionID] = struct{}{}
+	s.mu.Unlock()
+	if running {
+		return clauderrs.NewClientError(
+			clauderrs.ErrCodeSessionBusy,
+			"the session is already running a turn",
+			nil,
+		).WithSessionID(sessionID)
+	}
+	defer func() {
+		s.mu.Lock()
+		delete(s.busy, sessionID)
+		s.mu.Unlock()
+	}()
+
+	if err := client.Query(ctx, req.Prompt); err != nil {
+		return err
+	}
+
+	for msg := range client.ReceiveResponse(ctx) {
+		if err := handle(sessionID, msg); err != nil {
+			return err
+		}
+	}
+
+	return client.Err()
+}
+
+// sessionFor returns the existing session or creates a new one when
+// sessionID is empty.
+func (s *Server) sessionFor(sessionID string) (string, *claude.ClaudeSDKClient, error) {
+	if sessionID != "" {
+		client, err := s.session(sessionID)
+
+		return sessionID, client, err
+	}
+
+	opts := *s.opts
+	client, err := claude.NewClient(&opts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sessionID = uuid.New().String()
+	s.mu.Lock()
+	s.sessions[sessionID] = client
+	s.mu.Unlock()
+
+	return sessionID, client, nil
+}
+
+// session returns an existing session.
+func (s *Server) session(sessionID string) (*claude.ClaudeSDKClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, sessionNotFound(sessionID)
+	}
+
+	return client, nil
+}
+
+// sessionNotFound builds the error for an unknown session ID.
+func sessionNotFound(sessionID string) error {
+	return clauderrs.NewClientError(
+		clauderrs.ErrCodeSessionNotFound,
+		fmt.Sprintf("session %q not found", sessionID),
+		nil,
+	).WithSessionID(sessionID)
+}
@@ -0,0 +1,89 @@
+package clauderrs
+
+import "errors"
+
+// AbortReason describes why an operation was aborted.
+type AbortReason string
+
+const (
+	// AbortReasonContext indicates the caller's context was cancelled or
+	// its deadline expired.
+	AbortReasonContext AbortReason = "context"
+	// AbortReasonInterrupt indicates the turn was stopped by Interrupt.
+	AbortReasonInterrupt AbortReason = "interrupt"
+	// AbortReasonBudget indicates the turn hit the MaxBudgetUsd limit.
+	AbortReasonBudget AbortReason = "budget"
+)
+
+// AbortedError represents an operation that was cancelled rather than
+// failed.
+//
+// It lets callers distinguish "user aborted" from real failures and carries
+// whatever partial progress was made. The partial payload is SDK-defined;
+// the claude package stores a *claude.PartialResponse and exposes it via
+// claude.AbortPartial.
+type AbortedError struct {
+	*BaseError
+	reason  AbortReason
+	partial any
+}
+
+// NewAbortedError creates a new abort error with AbortReasonContext.
+func NewAbortedError(message string, cause error) *AbortedError {
+	err := &AbortedError{
+		BaseError: NewBaseError(CategoryClient, ErrCodeAborted, message, cause),
+		reason:    AbortReasonContext,
+	}
+	_ = err.WithMetadata("abort_reason", string(err.reason))
+
+	return err
+}
+
+// Reason returns why the operation was aborted.
+func (e *AbortedError) Reason() AbortReason {
+	return e.reason
+}
+
+// WithReason sets the abort reason.
+func (e *AbortedError) WithReason(reason AbortReason) *AbortedError {
+	e.reason = reason
+	_ = e.WithMetadata("abort_reason", string(reason))
+
+	return e
+}
+
+// Partial returns the partial progress captured when the operation aborted.
+func (e *AbortedError) Partial() any {
+	return e.partial
+}
+
+// WithPartial attaches partial progress to the error.
+func (e *AbortedError) WithPartial(partial any) *AbortedError {
+	e.partial = partial
+
+	return e
+}
+
+// WithSessionID adds session ID metadata to the error.
+func (e *AbortedError) WithSessionID(sessionID string) *AbortedError {
+	_ = e.WithMetadata(MetadataKeySessionID, sessionID)
+
+	return e
+}
+
+// AsAbortedError extracts an AbortedError from the error chain.
+func AsAbortedError(err error) (*AbortedError, bool) {
+	var abortErr *AbortedError
+	if errors.As(err, &abortErr) {
+		return abortErr, true
+	}
+
+	return nil, false
+}
+
+// IsAbortedError checks if the error is an abort error.
+func IsAbortedError(err error) bool {
+	_, ok := AsAbortedError(err)
+
+	return ok
+}
@@ -67,6 +67,11 @@ func (e *BaseError) Unwrap() error {
 	return e.cause
 }
 
+// base returns e, giving Redact access to the errors embedding it.
+func (e *BaseError) base() *BaseError {
+	return e
+}
+
 // Metadata returns the error metadata.
 func (e *BaseError) Metadata() map[string]any {
 	return e.metadata
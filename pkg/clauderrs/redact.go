@@ -0,0 +1,66 @@
+package clauderrs
+
+import "errors"
+
+// Redact applies redact to the text of err and of the errors it wraps: the
+// messages, string metadata and stderr of SDK errors, which are updated in
+// place, and the messages of other errors, which are replaced by wrappers
+// when redact changes them. It returns the error to report in place of err.
+func Redact(err error, redact func(string) string) error {
+	redacted, _ := redactChain(err, redact)
+
+	return redacted
+}
+
+// redactChain redacts err, reporting whether it had to be replaced.
+func redactChain(err error, redact func(string) string) (error, bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	switch e := err.(type) {
+	case *ProcessError:
+		e.stderr = redact(e.stderr)
+	case *ValidationError:
+		if value, ok := e.value.(string); ok {
+			e.value = redact(value)
+		}
+	}
+
+	if b, ok := err.(interface{ base() *BaseError }); ok {
+		base := b.base()
+		base.message = redact(base.message)
+		for key, value := range base.metadata {
+			if text, ok := value.(string); ok {
+				base.metadata[key] = redact(text)
+			}
+		}
+		base.cause, _ = redactChain(base.cause, redact)
+
+		return err, false
+	}
+
+	// Errors wrapping several others are reported by their text alone
+	text := err.Error()
+	cause, replaced := redactChain(errors.Unwrap(err), redact)
+	if redactedText := redact(text); redactedText != text || replaced {
+		return &redactedError{text: redactedText, cause: cause}, true
+	}
+
+	return err, false
+}
+
+// redactedError replaces an error whose text held secrets. It wraps the
+// redacted form of the original's cause.
+type redactedError struct {
+	text  string
+	cause error
+}
+
+func (e *redactedError) Error() string {
+	return e.text
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.cause
+}
@@ -39,6 +39,23 @@ const (
 	ErrCodeInvalidState  ErrorCode = "invalid_state"
 	ErrCodeMissingAPIKey ErrorCode = "missing_api_key"
 	ErrCodeInvalidConfig ErrorCode = "invalid_config"
+	// ErrCodeSessionNotFound indicates no session exists for the given ID.
+	ErrCodeSessionNotFound ErrorCode = "session_not_found"
+	// ErrCodeSessionBusy indicates a session was asked to run a turn
+	// while already running one.
+	ErrCodeSessionBusy ErrorCode = "session_busy"
+	// ErrCodeAborted indicates the operation was cancelled before
+	// completing, see AbortedError.
+	ErrCodeAborted ErrorCode = "aborted"
+	// ErrCodeSessionStalled indicates the watchdog failed a session whose
+	// internal goroutines stopped making progress.
+	ErrCodeSessionStalled ErrorCode = "session_stalled"
+	// ErrCodeResumeConflict indicates the options of a resumed session
+	// conflict with its transcript, see claude.ResumeConflict.
+	ErrCodeResumeConflict ErrorCode = "resume_conflict"
+	// ErrCodeQuotaExceeded indicates a query was refused because its key
+	// used up its cost or token quota, see the quota package.
+	ErrCodeQuotaExceeded ErrorCode = "quota_exceeded"
 )
 
 // API error codes.
@@ -65,6 +82,12 @@ const (
 	ErrCodeMessageParseFailed ErrorCode = "message_parse_failed"
 	ErrCodeUnknownMessageType ErrorCode = "unknown_message_type"
 	ErrCodeProtocolError      ErrorCode = "protocol_error"
+	// ErrCodeProtocolOutOfOrder indicates a message arrived out of the
+	// order the protocol requires, or after one it depends on went missing.
+	ErrCodeProtocolOutOfOrder ErrorCode = "protocol_out_of_order"
+	// ErrCodeIncompatibleCLI indicates the CLI and SDK versions disagree
+	// on the protocol, such as a CLI too old for a configured feature.
+	ErrCodeIncompatibleCLI ErrorCode = "incompatible_cli"
 )
 
 // Transport error codes.
@@ -73,6 +96,9 @@ const (
 	ErrCodeReadFailed    ErrorCode = "read_failed"
 	ErrCodeWriteFailed   ErrorCode = "write_failed"
 	ErrCodeTransportInit ErrorCode = "transport_init"
+	// ErrCodeMessageTooLarge indicates a single message exceeded the
+	// configured maximum message size.
+	ErrCodeMessageTooLarge ErrorCode = "message_too_large"
 )
 
 // Process error codes.
@@ -81,6 +107,9 @@ const (
 	ErrCodeProcessSpawnFailed ErrorCode = "process_spawn_failed"
 	ErrCodeProcessCrashed     ErrorCode = "process_crashed"
 	ErrCodeProcessExited      ErrorCode = "process_exited"
+	// ErrCodeResourceLimitExceeded indicates the process was killed for
+	// exceeding its ProcessLimits, such as running out of memory.
+	ErrCodeResourceLimitExceeded ErrorCode = "resource_limit_exceeded"
 )
 
 // Validation error codes.
@@ -89,6 +118,8 @@ const (
 	ErrCodeInvalidType    ErrorCode = "invalid_type"
 	ErrCodeRangeViolation ErrorCode = "range_violation"
 	ErrCodeInvalidFormat  ErrorCode = "invalid_format"
+	// ErrCodeConflictingOptions indicates two options cannot be combined.
+	ErrCodeConflictingOptions ErrorCode = "conflicting_options"
 )
 
 // Permission error codes.
@@ -96,6 +127,9 @@ const (
 	ErrCodeToolDenied      ErrorCode = "tool_denied"
 	ErrCodeDirectoryDenied ErrorCode = "directory_denied"
 	ErrCodeResourceDenied  ErrorCode = "resource_denied"
+	// ErrCodeEgressDenied indicates a network connection refused by an
+	// egress policy.
+	ErrCodeEgressDenied ErrorCode = "egress_denied"
 )
 
 // Callback error codes.
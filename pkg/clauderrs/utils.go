@@ -215,20 +215,39 @@ func IsCallbackError(err error) bool {
 	return false
 }
 
+// IsRetryable reports whether an operation failed for a reason another
+// attempt may not hit: a crashed or unreachable CLI, or a rate-limited or
+// failing API.
+func IsRetryable(err error) bool {
+	if IsNetworkError(err) || IsTransportError(err) || IsProcessError(err) {
+		return true
+	}
+	if sdkErr, ok := AsSDKError(err); ok && sdkErr.Category() == CategoryAPI {
+		code := sdkErr.Code()
+
+		return code == ErrCodeAPIRateLimit || code == ErrCodeAPIServerError
+	}
+
+	return false
+}
+
 // Deprecated types for backward compatibility.
 
 // AbortError represents an aborted operation.
-// Deprecated: Use NewCallbackError or appropriate error type instead.
+// Deprecated: Use AbortedError for cancelled operations, or NewCallbackError
+// or the appropriate error type for failures.
 type AbortError = CallbackError
 
 // NewAbortError creates a new abort error.
-// Deprecated: Use NewCallbackError instead.
+// Deprecated: Use NewAbortedError for cancelled operations, or
+// NewCallbackError instead.
 func NewAbortError(message string, cause error) *AbortError {
 	return NewCallbackError(ErrCodeCallbackFailed, message, cause, "", false)
 }
 
-// IsAbortError checks if error is an abort error.
-// Deprecated: Use IsCallbackError instead.
+// IsAbortError checks if error is an abort error: an AbortedError, or a
+// callback error as it reported before AbortedError.
+// Deprecated: Use IsAbortedError, or IsCallbackError, instead.
 func IsAbortError(err error) bool {
-	return IsCallbackError(err)
+	return IsAbortedError(err) || IsCallbackError(err)
 }
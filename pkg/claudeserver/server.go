@@ -0,0 +1,392 @@
+// Package claudeserver serves agent sessions over HTTP, for services that
+// put an API in front of Claude instead of each writing their own wrapper.
+//
+// Sessions are those of a claudegrpc.Server, so they behave the same over
+// both transports:
+//
+//	POST   /sessions                 start a session with its first prompt
+//	POST   /sessions/{id}/messages   send the next prompt of a session
+//	POST   /sessions/{id}/interrupt  stop the session's current turn
+//	DELETE /sessions/{id}            close the session
+//
+// Prompts are sent as {"prompt": "..."}. The response is the turn's result
+// as JSON, or, when the request accepts text/event-stream, a server-sent
+// event per message as the turn runs, ending with a "done" or "error"
+// event. Authenticate ties sessions to the callers that started them:
+//
+//	server := claudeserver.New(claudeserver.Config{
+//		Options: &claude.Options{Cwd: "/srv/repo"},
+//		Authenticate: func(r *http.Request) (string, error) {
+//			return verifyToken(r.Header.Get("Authorization"))
+//		},
+//	})
+//	err := server.ListenAndServe(ctx, ":8080")
+package claudeserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claudegrpc"
+)
+
+const (
+	// defaultShutdownTimeout is Config.ShutdownTimeout when unset.
+	defaultShutdownTimeout = 30 * time.Second
+	// maxRequestBytes bounds request bodies.
+	maxRequestBytes = 1 << 20
+)
+
+// Config configures a Server.
+type Config struct {
+	// Options are the base options of every session; each session uses a
+	// copy.
+	Options *claude.Options
+	// Authenticate, if set, identifies the caller of each request. An
+	// error is answered with 401 Unauthorized and its message. Sessions
+	// belong to the caller that started them; other callers get 404 Not
+	// Found for them.
+	Authenticate func(r *http.Request) (string, error)
+	// Middleware wraps the handler, outermost first, for logging, rate
+	// limiting or authorization beyond Authenticate.
+	Middleware []func(http.Handler) http.Handler
+	// ShutdownTimeout bounds how long Shutdown, and ListenAndServe and
+	// Serve once their context is done, wait for running turns before
+	// closing the sessions. Zero means 30 seconds.
+	ShutdownTimeout time.Duration
+}
+
+// Server serves agent sessions over HTTP.
+type Server struct {
+	config   Config
+	sessions *claudegrpc.Server
+	handler  http.Handler
+
+	mu     sync.Mutex
+	owners map[string]string   // Caller by session ID
+	busy   map[string]struct{} // Sessions running a turn
+	http   *http.Server
+}
+
+// New creates a Server.
+func New(config Config) *Server {
+	s := &Server{
+		config:   config,
+		sessions: claudegrpc.NewServer(config.Options),
+		owners:   make(map[string]string),
+		busy:     make(map[string]struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /sessions", s.handleCreate)
+	mux.HandleFunc("POST /sessions/{id}/messages", s.handleMessage)
+	mux.HandleFunc("POST /sessions/{id}/interrupt", s.handleInterrupt)
+	mux.HandleFunc("DELETE /sessions/{id}", s.handleClose)
+
+	var handler http.Handler = s.authenticate(mux)
+	for i := len(config.Middleware) - 1; i >= 0; i-- {
+		handler = config.Middleware[i](handler)
+	}
+	s.handler = handler
+
+	return s
+}
+
+// ServeHTTP serves a request, for mounting the server in another mux.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// ListenAndServe listens on the TCP address addr and serves until ctx is
+// done, then shuts down gracefully.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves connections from listener until ctx is done, then shuts
+// down gracefully; see Shutdown.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	s.mu.Lock()
+	s.http = server
+	s.mu.Unlock()
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+		timeout := s.config.ShutdownTimeout
+		if timeout <= 0 {
+			timeout = defaultShutdownTimeout
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		return s.Shutdown(shutdownCtx)
+	}
+}
+
+// Shutdown stops accepting requests, waits until the running turns end
+// or ctx is done, and closes every session, ending the turns still
+// running.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	server := s.http
+	s.mu.Unlock()
+
+	var err error
+	if server != nil {
+		err = server.Shutdown(ctx)
+	}
+	if closeErr := s.sessions.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// callerKey is the context key of the authenticated caller.
+type callerKey struct{}
+
+// authenticate identifies the caller of each request.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.config.Authenticate == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, err := s.config.Authenticate(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err.Error())
+
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, caller)))
+	})
+}
+
+// promptRequest is the body of the prompt endpoints.
+type promptRequest struct {
+	Prompt string `json:"prompt"`
+}
+
+// turnResponse is the JSON response to a prompt.
+type turnResponse struct {
+	SessionID    string  `json:"session_id"`
+	Result       string  `json:"result"`
+	IsError      bool    `json:"is_error"`
+	Subtype      string  `json:"subtype"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+	NumTurns     int32   `json:"num_turns"`
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	s.runTurn(w, r, "")
+}
+
+func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("id")
+	if !s.owns(r, sessionID) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("session %q not found", sessionID))
+
+		return
+	}
+
+	s.mu.Lock()
+	_, running := s.busy[sessionID]
+	s.busy[sessionID] = struct{}{}
+	s.mu.Unlock()
+	if running {
+		writeError(w, http.StatusConflict, "the session is already running a turn")
+
+		return
+	}
+	defer func() {
+		s.mu.Lock()
+		delete(s.busy, sessionID)
+		s.mu.Unlock()
+	}()
+
+	s.runTurn(w, r, sessionID)
+}
+
+func (s *Server) handleInterrupt(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("id")
+	if !s.owns(r, sessionID) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("session %q not found", sessionID))
+
+		return
+	}
+	if err := s.sessions.Interrupt(r.Context(), sessionID); err != nil {
+		writeSDKError(w, err)
+
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleClose(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("id")
+	if !s.owns(r, sessionID) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("session %q not found", sessionID))
+
+		return
+	}
+	err := s.sessions.CloseSession(sessionID)
+	s.mu.Lock()
+	delete(s.owners, sessionID)
+	s.mu.Unlock()
+	if err != nil {
+		writeSDKError(w, err)
+
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runTurn sends the prompt of r on sessionID, or on a new session when it
+// is empty, and writes the turn as JSON or server-sent events.
+func (s *Server) runTurn(w http.ResponseWriter, r *http.Request, sessionID string) {
+	var body promptRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+
+		return
+	}
+	stream := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	flusher, _ := w.(http.Flusher)
+
+	started := false
+	resp := turnResponse{SessionID: sessionID}
+	req := &claudegrpc.QueryRequest{SessionID: sessionID, Prompt: body.Prompt}
+	err := s.sessions.Stream(r.Context(), req, func(event *claudegrpc.Event) error {
+		if !started {
+			started = true
+			resp.SessionID = event.SessionID
+			s.claim(r, event.SessionID)
+			w.Header().Set("X-Session-Id", event.SessionID)
+			if stream {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Header().Set("Cache-Control", "no-cache")
+			}
+		}
+		if !stream {
+			if event.Type == "result" {
+				var result claude.SDKResultMessage
+				if err := json.Unmarshal(event.JSON, &result); err != nil {
+					return err
+				}
+				resp.IsError, resp.Subtype = result.IsError, result.Subtype
+				resp.TotalCostUSD, resp.NumTurns = result.TotalCostUSD, int32(result.NumTurns)
+				if result.Result != nil {
+					resp.Result = *result.Result
+				}
+			}
+
+			return nil
+		}
+
+		if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", event.JSON); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		return nil
+	})
+
+	if !stream || !started {
+		if err != nil {
+			writeSDKError(w, err)
+
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+
+		return
+	}
+
+	name, data := "done", map[string]string{"session_id": resp.SessionID}
+	if err != nil {
+		name, data = "error", map[string]string{"session_id": resp.SessionID, "error": err.Error()}
+	}
+	encoded, _ := json.Marshal(data)
+	_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, encoded)
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// claim records the caller of r as the owner of a new session.
+func (s *Server) claim(r *http.Request, sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	caller, _ := r.Context().Value(callerKey{}).(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.owners[sessionID]; !ok {
+		s.owners[sessionID] = caller
+	}
+}
+
+// owns reports whether sessionID exists and belongs to the caller of r.
+func (s *Server) owns(r *http.Request, sessionID string) bool {
+	caller, _ := r.Context().Value(callerKey{}).(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owner, ok := s.owners[sessionID]
+
+	return ok && owner == caller
+}
+
+// httpStatus maps gRPC codes to HTTP statuses.
+var httpStatus = map[claudegrpc.Code]int{
+	claudegrpc.CodeCanceled:           499,
+	claudegrpc.CodeInvalidArgument:    http.StatusBadRequest,
+	claudegrpc.CodeDeadlineExceeded:   http.StatusGatewayTimeout,
+	claudegrpc.CodeNotFound:           http.StatusNotFound,
+	claudegrpc.CodePermissionDenied:   http.StatusForbidden,
+	claudegrpc.CodeResourceExhausted:  http.StatusTooManyRequests,
+	claudegrpc.CodeFailedPrecondition: http.StatusConflict,
+	claudegrpc.CodeAborted:            http.StatusConflict,
+	claudegrpc.CodeUnavailable:        http.StatusServiceUnavailable,
+	claudegrpc.CodeUnauthenticated:    http.StatusBadGateway, // The CLI's credentials, not the caller's
+}
+
+// writeSDKError answers with the status matching err.
+func writeSDKError(w http.ResponseWriter, err error) {
+	status, ok := httpStatus[claudegrpc.ErrorCode(err)]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	writeError(w, status, err.Error())
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
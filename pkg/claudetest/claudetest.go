@@ -0,0 +1,375 @@
+// Package claudetest provides fluent assertions on what an agent did in a
+// session, for behavioral regression tests of prompts and permission
+// configurations.
+//
+// Assertions run against a Session: a live client recorded with Record, a
+// transcript loaded with the debugger package, or a list of messages.
+// Each step looks for an event in the session, and Then makes the next
+// step look only after it:
+//
+//	rec := claudetest.Record(client)
+//	// ... run the prompt under test ...
+//	claudetest.Expect(t, rec).
+//		ToolUse("Read").WithInput(map[string]any{"file_path": "go.mod"}).
+//		Then().
+//		TextContains("module").
+//		NoToolUse("Bash")
+//
+// A failed step is reported to t once, and the rest of the chain is
+// skipped.
+package claudetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/debugger"
+)
+
+// T is the part of testing.TB assertions report failures to.
+type T interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Session is a sequence of SDK messages to make assertions about.
+type Session interface {
+	Messages() []claude.SDKMessage
+}
+
+// messageList is a Session over fixed messages.
+type messageList []claude.SDKMessage
+
+func (m messageList) Messages() []claude.SDKMessage { return m }
+
+// Messages returns a Session over msgs.
+func Messages(msgs ...claude.SDKMessage) Session {
+	return messageList(msgs)
+}
+
+// FromTranscript returns a Session over the messages the CLI sent in a
+// recorded transcript.
+func FromTranscript(transcript *debugger.Transcript) Session {
+	var msgs messageList
+	for _, step := range transcript.Steps {
+		if step.Message != nil && step.Direction != claude.TeeOutbound {
+			msgs = append(msgs, step.Message)
+		}
+	}
+
+	return msgs
+}
+
+// Recording is a Session over the messages a live client receives.
+type Recording struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Record starts recording client's session. It mirrors the client's
+// frames with TeeJSONL, replacing any writer set there, so call it before
+// sending the prompt under test.
+func Record(client *claude.ClaudeSDKClient) *Recording {
+	rec := &Recording{}
+	client.TeeJSONL(rec)
+
+	return rec
+}
+
+// Write receives frames from the client.
+func (r *Recording) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.buf.Write(p)
+}
+
+// Messages returns the messages received so far.
+func (r *Recording) Messages() []claude.SDKMessage {
+	r.mu.Lock()
+	data := bytes.Clone(r.buf.Bytes())
+	r.mu.Unlock()
+
+	transcript, err := debugger.Load(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	return FromTranscript(transcript).Messages()
+}
+
+// event is an assistant content block assertions can match.
+type event struct {
+	toolUse *claude.ToolUseContentBlock
+	text    string
+}
+
+// describe names the event in failure messages.
+func (e event) describe() string {
+	if e.toolUse != nil {
+		return fmt.Sprintf("%s tool use %s", e.toolUse.Name, e.toolUse.ID)
+	}
+
+	return fmt.Sprintf("text %q", e.text)
+}
+
+// step is one assertion of a chain with its refinements.
+type step struct {
+	desc    string
+	start   int
+	preds   []func(event) bool
+	toolUse bool
+	matched int
+}
+
+// Expectation is a chain of assertions about a session.
+type Expectation struct {
+	t       T
+	events  []event
+	results map[string]claude.ToolResultContentBlock
+	denied  map[string]bool
+
+	current *step
+	next    int // Where the next step starts searching
+	failed  bool
+}
+
+// Expect starts a chain of assertions about session, reporting failures
+// to t. The session's messages are read once, when Expect is called.
+func Expect(t T, session Session) *Expectation {
+	e := &Expectation{
+		t:       t,
+		results: make(map[string]claude.ToolResultContentBlock),
+		denied:  make(map[string]bool),
+	}
+	collector := &eventCollector{e: e}
+	for _, msg := range session.Messages() {
+		switch m := msg.(type) {
+		case *claude.SDKAssistantMessage:
+			_ = claude.WalkBlocks(m, collector)
+		case *claude.SDKUserMessage:
+			_ = claude.WalkBlocks(m, collector)
+		case *claude.SDKResultMessage:
+			for _, denial := range m.PermissionDenials {
+				e.denied[denial.ToolUseID] = true
+			}
+		}
+	}
+
+	return e
+}
+
+// eventCollector gathers events and tool results from messages.
+type eventCollector struct {
+	claude.NopBlockVisitor
+	e *Expectation
+}
+
+func (c *eventCollector) VisitText(block claude.TextContentBlock) error {
+	c.e.events = append(c.e.events, event{text: block.Text})
+
+	return nil
+}
+
+func (c *eventCollector) VisitToolUse(block claude.ToolUseContentBlock) error {
+	c.e.events = append(c.e.events, event{toolUse: &block})
+
+	return nil
+}
+
+func (c *eventCollector) VisitToolResult(block claude.ToolResultContentBlock) error {
+	c.e.results[block.ToolUseID] = block
+
+	return nil
+}
+
+// ToolUse expects a use of the named tool.
+func (e *Expectation) ToolUse(name string) *Expectation {
+	e.t.Helper()
+
+	return e.begin(fmt.Sprintf("a %s tool use", name), true, func(ev event) bool {
+		return ev.toolUse != nil && ev.toolUse.Name == name
+	})
+}
+
+// TextContains expects assistant text containing substr.
+func (e *Expectation) TextContains(substr string) *Expectation {
+	e.t.Helper()
+
+	return e.begin(fmt.Sprintf("text containing %q", substr), false, func(ev event) bool {
+		return ev.toolUse == nil && strings.Contains(ev.text, substr)
+	})
+}
+
+// NoToolUse expects no use of the named tool from the current position to
+// the end of the session. Then after it keeps the position.
+func (e *Expectation) NoToolUse(name string) *Expectation {
+	e.t.Helper()
+	if e.failed {
+		return e
+	}
+
+	start := e.next
+	e.current = &step{desc: fmt.Sprintf("no %s tool use", name), start: start, matched: start - 1}
+	for _, ev := range e.events[start:] {
+		if ev.toolUse != nil && ev.toolUse.Name == name {
+			e.fail("expected %s%s, found %s", e.current.desc, e.position(start), ev.describe())
+
+			break
+		}
+	}
+
+	return e
+}
+
+// WithInput narrows the preceding ToolUse to calls whose input has the
+// given fields. Values are compared as JSON, so numbers may be given as
+// any Go numeric type.
+func (e *Expectation) WithInput(fields map[string]any) *Expectation {
+	e.t.Helper()
+	want, _ := json.Marshal(fields)
+
+	return e.refine(fmt.Sprintf("with input %s", want), func(ev event) bool {
+		return inputMatches(ev.toolUse.Input, fields)
+	})
+}
+
+// Succeeded narrows the preceding ToolUse to calls whose result was not
+// an error.
+func (e *Expectation) Succeeded() *Expectation {
+	e.t.Helper()
+
+	return e.refine("that succeeded", func(ev event) bool {
+		result, ok := e.results[ev.toolUse.ID]
+
+		return ok && !result.IsError && !e.denied[ev.toolUse.ID]
+	})
+}
+
+// Denied narrows the preceding ToolUse to calls that were denied
+// permission.
+func (e *Expectation) Denied() *Expectation {
+	e.t.Helper()
+
+	return e.refine("that was denied", func(ev event) bool {
+		return e.denied[ev.toolUse.ID]
+	})
+}
+
+// Then makes the next step look only after the event the current step
+// matched.
+func (e *Expectation) Then() *Expectation {
+	if e.failed || e.current == nil {
+		return e
+	}
+	e.next = e.current.matched + 1
+
+	return e
+}
+
+// Failed reports whether an assertion of the chain failed.
+func (e *Expectation) Failed() bool {
+	return e.failed
+}
+
+// begin starts a step and looks for its first match.
+func (e *Expectation) begin(desc string, toolUse bool, pred func(event) bool) *Expectation {
+	e.t.Helper()
+	if e.failed {
+		return e
+	}
+	e.current = &step{desc: desc, start: e.next, toolUse: toolUse, preds: []func(event) bool{pred}}
+	e.search()
+
+	return e
+}
+
+// refine adds a condition to the current ToolUse step and looks for its
+// first match again.
+func (e *Expectation) refine(desc string, pred func(event) bool) *Expectation {
+	e.t.Helper()
+	if e.failed {
+		return e
+	}
+	if e.current == nil || !e.current.toolUse {
+		e.fail("%s must follow ToolUse", desc)
+
+		return e
+	}
+	e.current.desc += " " + desc
+	e.current.preds = append(e.current.preds, pred)
+	e.search()
+
+	return e
+}
+
+// search matches the current step against the events from its start.
+func (e *Expectation) search() {
+	e.t.Helper()
+	s := e.current
+	for i := s.start; i < len(e.events); i++ {
+		if matchesAll(e.events[i], s.preds) {
+			s.matched = i
+
+			return
+		}
+	}
+	e.fail("expected %s%s, found none", s.desc, e.position(s.start))
+}
+
+// position describes where a step started looking.
+func (e *Expectation) position(start int) string {
+	if start == 0 {
+		return ""
+	}
+
+	return " after " + e.events[start-1].describe()
+}
+
+// fail reports a failed assertion and skips the rest of the chain.
+func (e *Expectation) fail(format string, args ...any) {
+	e.t.Helper()
+	e.t.Errorf(format, args...)
+	e.failed = true
+}
+
+// matchesAll reports whether ev satisfies every predicate.
+func matchesAll(ev event, preds []func(event) bool) bool {
+	for _, pred := range preds {
+		if !pred(ev) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// inputMatches reports whether a tool input has the wanted fields, with
+// both sides compared as decoded JSON.
+func inputMatches(input claude.JSONValue, fields map[string]any) bool {
+	var got map[string]any
+	if err := json.Unmarshal(input, &got); err != nil {
+		return false
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return false
+	}
+	var want map[string]any
+	if err := json.Unmarshal(data, &want); err != nil {
+		return false
+	}
+
+	for key, value := range want {
+		if actual, ok := got[key]; !ok || !reflect.DeepEqual(actual, value) {
+			return false
+		}
+	}
+
+	return true
+}
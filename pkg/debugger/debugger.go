@@ -0,0 +1,160 @@
+package debugger
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// VolatileFields are JSON keys ignored by Diff because they differ between
+// otherwise identical runs.
+var VolatileFields = []string{
+	"uuid",
+	"session_id",
+	"request_id",
+	"id",
+	"tool_use_id",
+	"duration_ms",
+	"duration_api_ms",
+	"total_cost_usd",
+	"timestamp",
+}
+
+// Debugger is a cursor over a transcript.
+//
+// The cursor starts before the first step; call Next to move onto it.
+type Debugger struct {
+	transcript *Transcript
+	pos        int
+}
+
+// New creates a debugger positioned before the first step.
+func New(transcript *Transcript) *Debugger {
+	return &Debugger{transcript: transcript, pos: -1}
+}
+
+// Len returns the number of steps.
+func (d *Debugger) Len() int {
+	return len(d.transcript.Steps)
+}
+
+// Position returns the current step index, or -1 before the first step.
+func (d *Debugger) Position() int {
+	return d.pos
+}
+
+// Current returns the current step, or nil before the first step.
+func (d *Debugger) Current() *Step {
+	if d.pos < 0 || d.pos >= d.Len() {
+		return nil
+	}
+
+	return d.transcript.Steps[d.pos]
+}
+
+// Next advances one step. It returns false at the end of the transcript.
+func (d *Debugger) Next() (*Step, bool) {
+	if d.pos+1 >= d.Len() {
+		return nil, false
+	}
+	d.pos++
+
+	return d.Current(), true
+}
+
+// Prev moves back one step. It returns false at the first step.
+func (d *Debugger) Prev() (*Step, bool) {
+	if d.pos <= 0 {
+		return nil, false
+	}
+	d.pos--
+
+	return d.Current(), true
+}
+
+// Seek moves to the step at index. It returns false if index is out of
+// range, leaving the cursor unchanged.
+func (d *Debugger) Seek(index int) (*Step, bool) {
+	if index < 0 || index >= d.Len() {
+		return nil, false
+	}
+	d.pos = index
+
+	return d.Current(), true
+}
+
+// Until advances to the next step matching match, such as the next
+// permission decision or hook call. It returns false, leaving the cursor at
+// the end, if no later step matches.
+func (d *Debugger) Until(match func(*Step) bool) (*Step, bool) {
+	for {
+		step, ok := d.Next()
+		if !ok {
+			return nil, false
+		}
+		if match(step) {
+			return step, true
+		}
+	}
+}
+
+// Difference describes a step where two transcripts diverge.
+type Difference struct {
+	// Index is the step index in both transcripts.
+	Index int
+	// A and B are the differing steps; one is nil when a transcript is
+	// shorter than the other.
+	A *Step
+	B *Step
+}
+
+// Diff compares two transcripts step by step, ignoring VolatileFields, and
+// returns every differing index.
+func Diff(a, b *Transcript) []Difference {
+	var diffs []Difference
+
+	n := max(len(a.Steps), len(b.Steps))
+	for i := range n {
+		var stepA, stepB *Step
+		if i < len(a.Steps) {
+			stepA = a.Steps[i]
+		}
+		if i < len(b.Steps) {
+			stepB = b.Steps[i]
+		}
+
+		if stepA == nil || stepB == nil || !equalFrames(stepA.Raw, stepB.Raw) {
+			diffs = append(diffs, Difference{Index: i, A: stepA, B: stepB})
+		}
+	}
+
+	return diffs
+}
+
+// equalFrames compares two frames with volatile fields removed.
+func equalFrames(a, b json.RawMessage) bool {
+	var valueA, valueB any
+	if json.Unmarshal(a, &valueA) != nil || json.Unmarshal(b, &valueB) != nil {
+		return string(a) == string(b)
+	}
+
+	return reflect.DeepEqual(stripVolatile(valueA), stripVolatile(valueB))
+}
+
+// stripVolatile removes VolatileFields from a decoded JSON value.
+func stripVolatile(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for _, key := range VolatileFields {
+			delete(v, key)
+		}
+		for key, child := range v {
+			v[key] = stripVolatile(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = stripVolatile(child)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,284 @@
+// Package debugger steps through recorded Claude sessions.
+//
+// A transcript is a JSONL file of stream-json frames as exchanged with the
+// CLI: messages, stream events, and control requests and responses in
+// either direction, either bare or wrapped in the claude.TeeRecord lines
+// written by ClaudeSDKClient.TeeJSONL. Each frame is decoded into a Step exposing the typed
+// SDK message, hook input, permission decision, or text delta, and two
+// transcripts can be diffed to find where a prompt or permission change
+// altered a run.
+package debugger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// maxFrameSize bounds a single transcript line.
+	maxFrameSize = 10 * 1024 * 1024
+	// initialBufferSize is the starting scanner buffer size.
+	initialBufferSize = 64 * 1024
+
+	frameControlRequest  = "control_request"
+	frameControlResponse = "control_response"
+
+	subtypeCanUseTool   = "can_use_tool"
+	subtypeHookCallback = "hook_callback"
+)
+
+// Transcript is a decoded recorded session.
+type Transcript struct {
+	Steps []*Step
+}
+
+// Step is one frame of a transcript.
+type Step struct {
+	// Index is the zero-based position in the transcript.
+	Index int
+	// Raw is the frame as recorded.
+	Raw json.RawMessage
+	// Direction and Timestamp are set for frames recorded by TeeJSONL.
+	Direction string
+	Timestamp time.Time
+	// Type and Subtype are the frame's discriminators.
+	Type    string
+	Subtype string
+
+	// Message is the decoded SDK message, for message frames.
+	Message claude.SDKMessage
+	// Hook is set for hook_callback control requests.
+	Hook *HookStep
+	// Permission is set for can_use_tool control requests.
+	Permission *PermissionStep
+	// Delta is the text of a content_block_delta stream event.
+	Delta string
+	// Err records why the frame could not be decoded, if it could not.
+	Err error
+}
+
+// HookStep is a decoded hook callback request.
+type HookStep struct {
+	RequestID  string
+	CallbackID string
+	ToolUseID  *string
+	Input      claude.HookInput
+}
+
+// PermissionStep is a can_use_tool request and the decision sent for it.
+type PermissionStep struct {
+	RequestID string
+	ToolName  string
+	Input     map[string]claude.JSONValue
+	// Decision is "allow" or "deny", or empty when the transcript holds no
+	// response to the request.
+	Decision string
+	// Reason is the deny message, if any.
+	Reason string
+	// Response is the step carrying the response, if recorded.
+	Response *Step
+}
+
+// LoadFile loads a transcript from a JSONL file.
+func LoadFile(path string) (*Transcript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidFormat,
+			fmt.Sprintf("failed to open transcript %s", path),
+			err,
+			"path",
+			path,
+		)
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// Load reads a JSONL transcript. Blank lines are skipped; frames that fail
+// to decode are kept with Step.Err set so the rest of the run can still be
+// inspected.
+func Load(r io.Reader) (*Transcript, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, initialBufferSize), maxFrameSize)
+
+	t := &Transcript{}
+	permissions := make(map[string]*PermissionStep)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		frame, record := unwrapTee(append(json.RawMessage(nil), line...))
+		step := decodeStep(len(t.Steps), frame)
+		step.Direction = record.Direction
+		step.Timestamp = record.Timestamp
+		if step.Permission != nil {
+			permissions[step.Permission.RequestID] = step.Permission
+		}
+		if step.Type == frameControlResponse {
+			resolvePermission(step, permissions)
+		}
+		t.Steps = append(t.Steps, step)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, clauderrs.NewProtocolError(
+			clauderrs.ErrCodeMessageParseFailed,
+			"failed to read transcript",
+			err,
+		)
+	}
+
+	return t, nil
+}
+
+// unwrapTee returns the frame inside a TeeJSONL record, or line itself if
+// it is a bare frame.
+func unwrapTee(line json.RawMessage) (json.RawMessage, claude.TeeRecord) {
+	var record claude.TeeRecord
+	if err := json.Unmarshal(line, &record); err != nil || record.Direction == "" || len(record.Message) == 0 {
+		return line, claude.TeeRecord{}
+	}
+
+	return record.Message, record
+}
+
+// decodeStep decodes a single frame.
+func decodeStep(index int, raw json.RawMessage) *Step {
+	step := &Step{Index: index, Raw: raw}
+
+	var envelope struct {
+		Type      string          `json:"type"`
+		Subtype   string          `json:"subtype"`
+		RequestID string          `json:"request_id"`
+		Request   json.RawMessage `json:"request"`
+		Response  struct {
+			Subtype string `json:"subtype"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		step.Err = err
+
+		return step
+	}
+	step.Type = envelope.Type
+	step.Subtype = envelope.Subtype
+
+	switch envelope.Type {
+	case frameControlRequest:
+		step.Err = decodeControlRequest(step, envelope.RequestID, envelope.Request)
+	case frameControlResponse:
+		step.Subtype = envelope.Response.Subtype
+	default:
+		step.Message, step.Err = claude.DecodeMessage(raw)
+		if event, ok := step.Message.(*claude.SDKStreamEvent); ok {
+			step.Delta = streamDelta(event)
+		}
+	}
+
+	return step
+}
+
+// decodeControlRequest fills hook and permission details.
+func decodeControlRequest(step *Step, requestID string, request json.RawMessage) error {
+	var req struct {
+		Subtype    string          `json:"subtype"`
+		CallbackID string          `json:"callback_id"`
+		Input      json.RawMessage `json:"input"`
+		ToolUseID  *string         `json:"tool_use_id"`
+		ToolName   string          `json:"tool_name"`
+	}
+	if err := json.Unmarshal(request, &req); err != nil {
+		return err
+	}
+	step.Subtype = req.Subtype
+
+	switch req.Subtype {
+	case subtypeHookCallback:
+		input, err := claude.DecodeHookInput(req.Input)
+		step.Hook = &HookStep{
+			RequestID:  requestID,
+			CallbackID: req.CallbackID,
+			ToolUseID:  req.ToolUseID,
+			Input:      input,
+		}
+
+		return err
+	case subtypeCanUseTool:
+		var input map[string]claude.JSONValue
+		if len(req.Input) > 0 {
+			if err := json.Unmarshal(req.Input, &input); err != nil {
+				return err
+			}
+		}
+		step.Permission = &PermissionStep{
+			RequestID: requestID,
+			ToolName:  req.ToolName,
+			Input:     input,
+		}
+	}
+
+	return nil
+}
+
+// resolvePermission attaches a control response to its permission request.
+func resolvePermission(step *Step, permissions map[string]*PermissionStep) {
+	var resp struct {
+		Response struct {
+			RequestID string `json:"request_id"`
+			Response  struct {
+				Allow    *bool  `json:"allow"`
+				Behavior string `json:"behavior"`
+				Reason   string `json:"reason"`
+				Message  string `json:"message"`
+			} `json:"response"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(step.Raw, &resp); err != nil {
+		step.Err = err
+
+		return
+	}
+
+	permission, ok := permissions[resp.Response.RequestID]
+	if !ok {
+		return
+	}
+
+	decision := resp.Response.Response
+	switch {
+	case decision.Behavior != "":
+		permission.Decision = decision.Behavior
+	case decision.Allow != nil && *decision.Allow:
+		permission.Decision = string(claude.PermissionBehaviorAllow)
+	case decision.Allow != nil:
+		permission.Decision = string(claude.PermissionBehaviorDeny)
+	}
+	permission.Reason = decision.Reason
+	if permission.Reason == "" {
+		permission.Reason = decision.Message
+	}
+	permission.Response = step
+}
+
+// streamDelta returns the text carried by a content_block_delta event.
+func streamDelta(event *claude.SDKStreamEvent) string {
+	delta, ok := event.Event.(claude.ContentBlockDeltaEvent)
+	if !ok || delta.Delta.TextDelta == nil {
+		return ""
+	}
+
+	return *delta.Delta.TextDelta
+}
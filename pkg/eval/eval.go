@@ -0,0 +1,374 @@
+// Package eval runs suites of prompts through agents and scores their
+// answers, to gate prompt and agent changes in CI.
+//
+// Each Case is a prompt, optional fixture files for the agent to work on,
+// and a Scorer deciding whether the answer passes, either a function such
+// as Contains or a second model grading against criteria with Judge.
+// Suite.Run runs the cases concurrently within a budget and returns a
+// Report, written as JSON or JUnit XML:
+//
+//	suite := &eval.Suite{
+//		Name:    "triage",
+//		Options: &claude.Options{Model: "claude-haiku-4-5"},
+//		Cases: []eval.Case{{
+//			Name:     "finds-the-bug",
+//			Prompt:   "Which function in main.go panics?",
+//			Fixtures: map[string]string{"main.go": source},
+//			Scorer:   eval.Contains("parseHeader"),
+//		}},
+//		MaxCost: 2_000_000_000, // $2
+//	}
+//	report, err := suite.Run(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	_ = report.WriteJUnit(os.Stdout)
+//	if report.PassRate < 0.9 {
+//		os.Exit(1)
+//	}
+package eval
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// defaultConcurrency bounds the cases run at once.
+	defaultConcurrency = 4
+	// defaultTimeout bounds a case, including its scoring.
+	defaultTimeout = 5 * time.Minute
+)
+
+// Case is a prompt and how to score the answer to it.
+type Case struct {
+	// Name identifies the case in reports. Names must be unique.
+	Name string
+	// Prompt is sent as the case's only turn.
+	Prompt string
+	// Fixtures are files written to a fresh working directory for the
+	// case, by slash-separated path relative to it. Without fixtures the
+	// case runs in the working directory of its options.
+	Fixtures map[string]string
+	// Options replace Suite.Options for the case.
+	Options *claude.Options
+	// Scorer scores the answer. Nil passes every answer that isn't an
+	// error result.
+	Scorer Scorer
+}
+
+// Output is the outcome of a case's turn, given to its scorer.
+type Output struct {
+	Case *Case
+	// Messages are all messages received for the turn.
+	Messages []claude.SDKMessage
+	// Result ends the turn.
+	Result *claude.SDKResultMessage
+	// Text is the result's text, empty for error results.
+	Text string
+	// Dir is the working directory holding the case's fixtures, as the
+	// agent left them. It is removed once scoring returns.
+	Dir string
+}
+
+// Score is a scorer's verdict on an answer.
+type Score struct {
+	Pass bool
+	// Value grades the answer from 0 to 1, for scorers that grade beyond
+	// pass and fail.
+	Value float64
+	// Reason explains the verdict, reported for failures.
+	Reason string
+	// Cost is what scoring cost, such as a judge's turn.
+	Cost claude.USD
+}
+
+// Scorer scores the output of a case. An error fails the case as an
+// error rather than a failure.
+type Scorer func(ctx context.Context, out *Output) (Score, error)
+
+// Suite is a set of cases run together.
+type Suite struct {
+	// Name names the suite in reports.
+	Name string
+	// Options are used for cases without options of their own; each case
+	// gets its own copy.
+	Options *claude.Options
+	Cases   []Case
+	// Concurrency bounds the cases run at once. It defaults to 4.
+	Concurrency int
+	// Timeout bounds each case, including its scoring. It defaults to
+	// five minutes.
+	Timeout time.Duration
+	// MaxCost bounds the suite's spending, including scoring. Cases not
+	// started once it is reached are skipped; cases in flight finish, so
+	// the suite may overspend by up to Concurrency cases. Zero means no
+	// limit. Bound each case with Options.MaxBudgetUsd.
+	MaxCost claude.USD
+}
+
+// Run runs the suite's cases and reports their outcome. It returns a
+// ValidationError for an invalid suite; cases that fail to run are
+// reported as errors. Cancelling ctx skips the cases not yet started.
+func (s *Suite) Run(ctx context.Context) (*Report, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	r := &runner{suite: s}
+	results := make([]CaseResult, len(s.Cases))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for i := range s.Cases {
+		c := &s.Cases[i]
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[i] = CaseResult{Name: c.Name, Skipped: true}
+
+			continue
+		}
+		if ctx.Err() != nil || r.overBudget() {
+			<-slots
+			results[i] = CaseResult{Name: c.Name, Skipped: true}
+
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	return newReport(s.Name, results, time.Since(start)), nil
+}
+
+// validate checks that cases are named uniquely and have prompts.
+func (s *Suite) validate() error {
+	if len(s.Cases) == 0 {
+		return clauderrs.NewValidationError(clauderrs.ErrCodeMissingField, "eval suite has no cases", nil, "Cases", nil)
+	}
+
+	names := make(map[string]bool, len(s.Cases))
+	for i, c := range s.Cases {
+		field := fmt.Sprintf("Cases[%d]", i)
+		switch {
+		case c.Name == "":
+			return clauderrs.NewValidationError(clauderrs.ErrCodeMissingField, "eval case has no name", nil, field+".Name", nil)
+		case names[c.Name]:
+			return clauderrs.NewValidationError(clauderrs.ErrCodeInvalidFormat, fmt.Sprintf("duplicate eval case %q", c.Name), nil, field+".Name", c.Name)
+		case c.Prompt == "":
+			return clauderrs.NewValidationError(clauderrs.ErrCodeMissingField, fmt.Sprintf("eval case %q has no prompt", c.Name), nil, field+".Prompt", nil)
+		}
+		names[c.Name] = true
+	}
+
+	return nil
+}
+
+// runner holds the state of one run.
+type runner struct {
+	suite *Suite
+
+	mu    sync.Mutex
+	spent claude.USD
+}
+
+func (r *runner) overBudget() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.suite.MaxCost > 0 && r.spent >= r.suite.MaxCost
+}
+
+// run runs and scores one case.
+func (r *runner) run(ctx context.Context, c *Case) CaseResult {
+	timeout := r.suite.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := CaseResult{Name: c.Name}
+	defer func() {
+		r.mu.Lock()
+		r.spent += result.Cost
+		r.mu.Unlock()
+	}()
+
+	out, latency, err := r.answer(ctx, c)
+	result.Latency = latency
+	if out != nil {
+		if out.Dir != "" && len(c.Fixtures) > 0 {
+			defer func() { _ = os.RemoveAll(out.Dir) }()
+		}
+		if out.Result != nil {
+			result.Cost = out.Result.TotalCost()
+			result.Turns = out.Result.NumTurns
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+
+		return result
+	}
+
+	scorer := c.Scorer
+	if scorer == nil {
+		scorer = succeeded
+	}
+	score, err := scorer(ctx, out)
+	result.Cost += score.Cost
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to score: %v", err)
+
+		return result
+	}
+	result.Pass = score.Pass
+	result.Score = score.Value
+	result.Reason = score.Reason
+
+	return result
+}
+
+// answer sends the case's prompt to a new client and collects the turn.
+// The output is returned along with errors once the working directory
+// exists, so it can be removed.
+func (r *runner) answer(ctx context.Context, c *Case) (*Output, time.Duration, error) {
+	opts := c.Options
+	if opts == nil {
+		opts = r.suite.Options
+	}
+	var copied claude.Options
+	if opts != nil {
+		copied = *opts
+	}
+
+	out := &Output{Case: c, Dir: copied.Cwd}
+	if len(c.Fixtures) > 0 {
+		dir, err := writeFixtures(c.Fixtures)
+		if err != nil {
+			return nil, 0, err
+		}
+		copied.Cwd = dir
+		out.Dir = dir
+	}
+
+	client, err := claude.NewClient(&copied)
+	if err != nil {
+		return out, 0, err
+	}
+	defer func() { _ = client.Close() }()
+
+	start := time.Now()
+	if err := client.Query(ctx, c.Prompt); err != nil {
+		return out, time.Since(start), err
+	}
+	for msg := range client.ReceiveResponse(ctx) {
+		out.Messages = append(out.Messages, msg)
+		if result, ok := msg.(*claude.SDKResultMessage); ok {
+			out.Result = result
+		}
+	}
+	latency := time.Since(start)
+
+	switch {
+	case client.Err() != nil:
+		return out, latency, client.Err()
+	case ctx.Err() != nil:
+		return out, latency, clauderrs.NewAbortedError("eval case timed out or was cancelled", ctx.Err())
+	case out.Result == nil:
+		return out, latency, clauderrs.NewProcessError(
+			clauderrs.ErrCodeProcessExited,
+			"Claude Code ended the turn without a result",
+			nil,
+			-1,
+			"",
+		)
+	}
+	if out.Result.Result != nil {
+		out.Text = *out.Result.Result
+	}
+
+	return out, latency, nil
+}
+
+// writeFixtures writes fixtures to a new temporary directory.
+func writeFixtures(fixtures map[string]string) (string, error) {
+	dir, err := os.MkdirTemp("", "claude-eval-*")
+	if err != nil {
+		return "", fixtureError(err)
+	}
+
+	paths := make([]string, 0, len(fixtures))
+	for path := range fixtures {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		local := filepath.FromSlash(path)
+		if !filepath.IsLocal(local) {
+			_ = os.RemoveAll(dir)
+
+			return "", clauderrs.NewValidationError(
+				clauderrs.ErrCodeInvalidFormat,
+				fmt.Sprintf("fixture path %q is outside the working directory", path),
+				nil,
+				"Fixtures",
+				path,
+			)
+		}
+		target := filepath.Join(dir, local)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			_ = os.RemoveAll(dir)
+
+			return "", fixtureError(err)
+		}
+		if err := os.WriteFile(target, []byte(fixtures[path]), 0o644); err != nil {
+			_ = os.RemoveAll(dir)
+
+			return "", fixtureError(err)
+		}
+	}
+
+	return dir, nil
+}
+
+func fixtureError(err error) error {
+	return clauderrs.NewClientError(clauderrs.ErrCodeIOError, "failed to write eval fixtures", err)
+}
+
+// succeeded is the default Scorer, passing answers that aren't errors.
+func succeeded(_ context.Context, out *Output) (Score, error) {
+	if out.Result.IsError {
+		reason := out.Result.Subtype
+		if len(out.Result.Errors) > 0 {
+			reason = strings.Join(out.Result.Errors, "; ")
+		}
+
+		return Score{Reason: reason}, nil
+	}
+
+	return Score{Pass: true, Value: 1}, nil
+}
@@ -0,0 +1,244 @@
+package eval
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// CaseResult is the outcome of a case. A case that ran and was scored
+// either passed or failed; Error is set for cases that couldn't run or be
+// scored, and Skipped for cases not run.
+type CaseResult struct {
+	Name    string
+	Pass    bool
+	Skipped bool
+	// Score and Reason are the scorer's.
+	Score  float64
+	Reason string
+	Error  string
+	// Cost includes scoring.
+	Cost    claude.USD
+	Turns   int
+	Latency time.Duration
+}
+
+// Report is the outcome of a suite.
+type Report struct {
+	Suite string
+	// Cases are in the order of Suite.Cases.
+	Cases []CaseResult
+	// Passed, Failed, Errored and Skipped count the cases.
+	Passed  int
+	Failed  int
+	Errored int
+	Skipped int
+	// PassRate is the share of the cases run that passed, counting errors
+	// as failures.
+	PassRate float64
+	// Cost totals the cases'.
+	Cost claude.USD
+	// Elapsed is the wall time of the run.
+	Elapsed time.Duration
+	// P50 and P95 are percentiles of the latencies of the cases run.
+	P50 time.Duration
+	P95 time.Duration
+}
+
+func newReport(suite string, results []CaseResult, elapsed time.Duration) *Report {
+	r := &Report{Suite: suite, Cases: results, Elapsed: elapsed}
+	var latencies []time.Duration
+	for _, result := range results {
+		r.Cost += result.Cost
+		switch {
+		case result.Skipped:
+			r.Skipped++
+
+			continue
+		case result.Error != "":
+			r.Errored++
+		case result.Pass:
+			r.Passed++
+		default:
+			r.Failed++
+		}
+		latencies = append(latencies, result.Latency)
+	}
+
+	if ran := r.Passed + r.Failed + r.Errored; ran > 0 {
+		r.PassRate = float64(r.Passed) / float64(ran)
+	}
+	slices.Sort(latencies)
+	r.P50 = percentile(latencies, 50)
+	r.P95 = percentile(latencies, 95)
+
+	return r
+}
+
+// percentile returns the nearest-rank percentile p of sorted samples.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := max((p*len(sorted)+99)/100, 1)
+
+	return sorted[rank-1]
+}
+
+// jsonCase is a CaseResult as written by WriteJSON.
+type jsonCase struct {
+	Name           string     `json:"name"`
+	Status         string     `json:"status"`
+	Score          float64    `json:"score"`
+	Reason         string     `json:"reason,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	Cost           claude.USD `json:"cost_usd"`
+	Turns          int        `json:"turns"`
+	LatencySeconds float64    `json:"latency_seconds"`
+}
+
+// jsonReport is a Report as written by WriteJSON.
+type jsonReport struct {
+	Suite          string     `json:"suite"`
+	Passed         int        `json:"passed"`
+	Failed         int        `json:"failed"`
+	Errored        int        `json:"errored"`
+	Skipped        int        `json:"skipped"`
+	PassRate       float64    `json:"pass_rate"`
+	Cost           claude.USD `json:"cost_usd"`
+	ElapsedSeconds float64    `json:"elapsed_seconds"`
+	P50Seconds     float64    `json:"p50_latency_seconds"`
+	P95Seconds     float64    `json:"p95_latency_seconds"`
+	Cases          []jsonCase `json:"cases"`
+}
+
+// WriteJSON writes the report as indented JSON, with snake case fields,
+// durations in seconds and each case's status as "passed", "failed",
+// "error" or "skipped".
+func (r *Report) WriteJSON(w io.Writer) error {
+	report := jsonReport{
+		Suite:          r.Suite,
+		Passed:         r.Passed,
+		Failed:         r.Failed,
+		Errored:        r.Errored,
+		Skipped:        r.Skipped,
+		PassRate:       r.PassRate,
+		Cost:           r.Cost,
+		ElapsedSeconds: r.Elapsed.Seconds(),
+		P50Seconds:     r.P50.Seconds(),
+		P95Seconds:     r.P95.Seconds(),
+		Cases:          make([]jsonCase, 0, len(r.Cases)),
+	}
+	for _, c := range r.Cases {
+		report.Cases = append(report.Cases, jsonCase{
+			Name:           c.Name,
+			Status:         c.status(),
+			Score:          c.Score,
+			Reason:         c.Reason,
+			Error:          c.Error,
+			Cost:           c.Cost,
+			Turns:          c.Turns,
+			LatencySeconds: c.Latency.Seconds(),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
+
+func (c CaseResult) status() string {
+	switch {
+	case c.Skipped:
+		return "skipped"
+	case c.Error != "":
+		return "error"
+	case c.Pass:
+		return "passed"
+	default:
+		return "failed"
+	}
+}
+
+// junitSuites is the root of a JUnit XML report.
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// WriteJUnit writes the report as JUnit XML, for CI systems that show
+// test results. Each case is a test case, with its cost and score in its
+// output.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suite := junitSuite{
+		Name:     r.Suite,
+		Tests:    len(r.Cases),
+		Failures: r.Failed,
+		Errors:   r.Errored,
+		Skipped:  r.Skipped,
+		Time:     seconds(r.Elapsed),
+	}
+	for _, c := range r.Cases {
+		tc := junitCase{Name: c.Name, ClassName: r.Suite, Time: seconds(c.Latency)}
+		switch c.status() {
+		case "skipped":
+			tc.Skipped = &junitMessage{Message: "suite budget reached or run cancelled"}
+		case "error":
+			tc.Error = &junitMessage{Message: c.Error}
+		case "failed":
+			tc.Failure = &junitMessage{Message: c.Reason}
+		}
+		if !c.Skipped {
+			tc.SystemOut = fmt.Sprintf("score=%g cost=$%s turns=%d", c.Score, c.Cost, c.Turns)
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+// seconds formats d as JUnit times are, in seconds.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
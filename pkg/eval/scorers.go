@@ -0,0 +1,148 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// judgePrompt asks the judge to grade an answer against criteria.
+const judgePrompt = `You are grading the answer of an AI agent to a task.
+
+Task:
+<task>
+%s
+</task>
+
+Answer:
+<answer>
+%s
+</answer>
+
+Criteria:
+<criteria>
+%s
+</criteria>
+
+Decide whether the answer meets every criterion. Reply with a JSON object with
+"pass" (boolean), "score" (number from 0 to 1) and "reason" (one sentence).`
+
+// judgeSchema is the structured output of the judge.
+var judgeSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"pass":   map[string]any{"type": "boolean"},
+		"score":  map[string]any{"type": "number", "minimum": 0, "maximum": 1},
+		"reason": map[string]any{"type": "string"},
+	},
+	"required": []string{"pass", "score", "reason"},
+}
+
+// Contains passes answers containing every one of substrings.
+func Contains(substrings ...string) Scorer {
+	return func(_ context.Context, out *Output) (Score, error) {
+		for _, substring := range substrings {
+			if !strings.Contains(out.Text, substring) {
+				return Score{Reason: fmt.Sprintf("answer does not contain %q", substring)}, nil
+			}
+		}
+
+		return Score{Pass: true, Value: 1}, nil
+	}
+}
+
+// Matches passes answers matching re.
+func Matches(re *regexp.Regexp) Scorer {
+	return func(_ context.Context, out *Output) (Score, error) {
+		if !re.MatchString(out.Text) {
+			return Score{Reason: fmt.Sprintf("answer does not match %s", re)}, nil
+		}
+
+		return Score{Pass: true, Value: 1}, nil
+	}
+}
+
+// Judge scores answers by asking a second client, created from opts for
+// each answer, whether they meet criteria, such as "Names parseHeader as
+// the function that panics and explains why". Give the judge options
+// without tools, and a model at least as capable as the agent's. Its cost
+// is counted in the case's.
+func Judge(opts *claude.Options, criteria string) Scorer {
+	return func(ctx context.Context, out *Output) (Score, error) {
+		var copied claude.Options
+		if opts != nil {
+			copied = *opts
+		}
+		copied.OutputFormat = &claude.OutputFormat{
+			BaseOutputFormat: claude.BaseOutputFormat{Type: "json_schema"},
+			Schema:           judgeSchema,
+		}
+
+		client, err := claude.NewClient(&copied)
+		if err != nil {
+			return Score{}, err
+		}
+		defer func() { _ = client.Close() }()
+
+		if err := client.Query(ctx, fmt.Sprintf(judgePrompt, out.Case.Prompt, out.Text, criteria)); err != nil {
+			return Score{}, err
+		}
+		var result *claude.SDKResultMessage
+		for msg := range client.ReceiveResponse(ctx) {
+			if m, ok := msg.(*claude.SDKResultMessage); ok {
+				result = m
+			}
+		}
+		if err := client.Err(); err != nil {
+			return Score{}, err
+		}
+		if result == nil {
+			return Score{}, clauderrs.NewProcessError(
+				clauderrs.ErrCodeProcessExited,
+				"judge ended the turn without a result",
+				nil,
+				-1,
+				"",
+			)
+		}
+
+		score, err := parseVerdict(result)
+		score.Cost = result.TotalCost()
+
+		return score, err
+	}
+}
+
+// parseVerdict reads the judge's verdict from the structured output of
+// result or, failing that, from the JSON object in its text.
+func parseVerdict(result *claude.SDKResultMessage) (Score, error) {
+	var verdict struct {
+		Pass   bool    `json:"pass"`
+		Score  float64 `json:"score"`
+		Reason string  `json:"reason"`
+	}
+
+	var data []byte
+	if result.StructuredOutput != nil {
+		data, _ = json.Marshal(result.StructuredOutput)
+	} else if result.Result != nil {
+		text := *result.Result
+		if start, end := strings.Index(text, "{"), strings.LastIndex(text, "}"); start >= 0 && end > start {
+			data = []byte(text[start : end+1])
+		}
+	}
+	if data == nil || json.Unmarshal(data, &verdict) != nil {
+		return Score{}, clauderrs.NewProtocolError(
+			clauderrs.ErrCodeMessageParseFailed,
+			"judge did not return a verdict",
+			nil,
+		)
+	}
+
+	return Score{Pass: verdict.Pass, Value: verdict.Score, Reason: verdict.Reason}, nil
+}
@@ -0,0 +1,209 @@
+// Package githubtool gives agents tools for reviewing GitHub pull requests.
+//
+// Client.Server is an in-process SDK MCP server with tools fetching a pull
+// request's diff, reading its checks and posting a review. ReviewAgent
+// bundles it into options for a review agent that reads the checked-out
+// repository and reports through a single review:
+//
+//	gh := githubtool.NewClient(os.Getenv("GITHUB_TOKEN"))
+//	client, err := claude.NewClient(githubtool.ReviewAgent(gh, &claude.Options{Cwd: checkout}))
+//	if err != nil {
+//		return err
+//	}
+//	err = client.Query(ctx, githubtool.ReviewPrompt("octo/widgets", 42))
+package githubtool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultBaseURL is the GitHub REST API.
+const defaultBaseURL = "https://api.github.com"
+
+// repoPattern matches "owner/name" repositories; validRepo also rejects
+// the names "." and "..".
+var repoPattern = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9_.-]+$`)
+
+// Client calls the GitHub REST API.
+type Client struct {
+	// Token authenticates requests. It needs read access to pull requests
+	// and checks, and write access to pull requests to post reviews.
+	Token string
+	// BaseURL is the API root. Empty uses https://api.github.com; set it
+	// for GitHub Enterprise Server.
+	BaseURL string
+	// HTTPClient sends requests. Nil uses a client with a 30 second
+	// timeout.
+	HTTPClient *http.Client
+}
+
+// NewClient creates a Client for github.com authenticated with token.
+func NewClient(token string) *Client {
+	return &Client{Token: token}
+}
+
+// APIError is a request GitHub refused.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("GitHub API error %d: %s", e.StatusCode, e.Message)
+}
+
+// CheckRun is the outcome of a check on a commit.
+type CheckRun struct {
+	Name string `json:"name"`
+	// Status is "queued", "in_progress" or "completed".
+	Status string `json:"status"`
+	// Conclusion is set once the check completed, such as "success" or
+	// "failure".
+	Conclusion string `json:"conclusion"`
+	DetailsURL string `json:"details_url"`
+}
+
+// ReviewComment is a comment on a line of a pull request's diff.
+type ReviewComment struct {
+	Path string `json:"path"`
+	// Line is the line in the file after the change, or before it when
+	// Side is "LEFT".
+	Line int    `json:"line"`
+	Side string `json:"side,omitempty"`
+	Body string `json:"body"`
+}
+
+// Review is a pull request review.
+type Review struct {
+	Body string `json:"body"`
+	// Event is "COMMENT", "APPROVE" or "REQUEST_CHANGES".
+	Event    string          `json:"event"`
+	Comments []ReviewComment `json:"comments,omitempty"`
+}
+
+// PullRequestDiff returns the diff of pull request number of repo, given
+// as "owner/name".
+func (c *Client) PullRequestDiff(ctx context.Context, repo string, number int) (string, error) {
+	data, err := c.do(ctx, http.MethodGet, pullPath(repo, number), "application/vnd.github.diff", nil)
+
+	return string(data), err
+}
+
+// CheckRuns returns the checks of the head commit of pull request number.
+func (c *Client) CheckRuns(ctx context.Context, repo string, number int) ([]CheckRun, error) {
+	var pull struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}
+	if err := c.getJSON(ctx, pullPath(repo, number), &pull); err != nil {
+		return nil, err
+	}
+
+	var runs struct {
+		CheckRuns []CheckRun `json:"check_runs"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("/repos/%s/commits/%s/check-runs", repo, pull.Head.SHA), &runs); err != nil {
+		return nil, err
+	}
+
+	return runs.CheckRuns, nil
+}
+
+// CreateReview posts review on pull request number and returns its URL.
+func (c *Client) CreateReview(ctx context.Context, repo string, number int, review Review) (string, error) {
+	body, err := json.Marshal(review)
+	if err != nil {
+		return "", err
+	}
+	data, err := c.do(ctx, http.MethodPost, pullPath(repo, number)+"/reviews", "application/vnd.github+json", body)
+	if err != nil {
+		return "", err
+	}
+
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal(data, &created); err != nil {
+		return "", fmt.Errorf("failed to decode GitHub review: %w", err)
+	}
+
+	return created.HTMLURL, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, v any) error {
+	data, err := c.do(ctx, http.MethodGet, path, "application/vnd.github+json", nil)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode GitHub response: %w", err)
+	}
+
+	return nil
+}
+
+// do sends a request and returns the response body, or an APIError if
+// GitHub refused it.
+func (c *Client) do(ctx context.Context, method, path, accept string, body []byte) ([]byte, error) {
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(baseURL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var failure struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &failure) != nil || failure.Message == "" {
+			failure.Message = http.StatusText(resp.StatusCode)
+		}
+
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: failure.Message}
+	}
+
+	return data, nil
+}
+
+// validRepo reports whether repo is an "owner/name" repository.
+func validRepo(repo string) bool {
+	return repoPattern.MatchString(repo) && !strings.HasSuffix(repo, "/.") && !strings.HasSuffix(repo, "/..")
+}
+
+func pullPath(repo string, number int) string {
+	return fmt.Sprintf("/repos/%s/pulls/%d", repo, number)
+}
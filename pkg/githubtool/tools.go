@@ -0,0 +1,231 @@
+package githubtool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// ServerName is the name ReviewAgent registers the server under.
+const ServerName = "github"
+
+// Tools of the server, as named within it.
+const (
+	PullRequestDiffTool = "pr_diff"
+	CheckRunsTool       = "pr_checks"
+	CreateReviewTool    = "pr_review"
+)
+
+// maxDiffBytes bounds the diff pr_diff returns.
+const maxDiffBytes = 200 << 10
+
+// reviewInstructions are appended to a ReviewAgent's system prompt.
+const reviewInstructions = `You review GitHub pull requests. Fetch the diff with the pr_diff tool ` +
+	`and the CI results with pr_checks, and read the surrounding code in the working directory ` +
+	`for context. Focus on correctness, security and maintainability; skip style nits a linter ` +
+	`would catch. Post your findings with a single pr_review call, commenting on specific lines ` +
+	`where you can.`
+
+// reviewReadTools are the built-in tools a ReviewAgent may use.
+var reviewReadTools = []string{"Read", "Grep", "Glob"}
+
+// reviewDeniedTools are the built-in tools a ReviewAgent may not use.
+var reviewDeniedTools = []string{"Bash", "Edit", "MultiEdit", "NotebookEdit", "Write"}
+
+// Server returns an in-process SDK MCP server named name with tools for
+// reviewing pull requests through c. Each tool takes the repository, as
+// "owner/name", and the pull request number.
+func (c *Client) Server(name string) claude.McpServerConfig {
+	schema := func(extra map[string]any, required ...string) map[string]any {
+		properties := map[string]any{
+			"repo":   map[string]any{"type": "string", "description": `Repository, as "owner/name"`},
+			"number": map[string]any{"type": "integer", "description": "Pull request number"},
+		}
+		for key, value := range extra {
+			properties[key] = value
+		}
+
+		return map[string]any{
+			"type":       "object",
+			"properties": properties,
+			"required":   append([]string{"repo", "number"}, required...),
+		}
+	}
+	comment := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{"type": "string", "description": "File path, relative to the repository root"},
+			"line": map[string]any{"type": "integer", "description": "Line in the changed file"},
+			"body": map[string]any{"type": "string"},
+		},
+		"required": []string{"path", "line", "body"},
+	}
+
+	return claude.CreateSdkMcpServer(name, "1.0.0", []claude.McpTool{
+		claude.Tool(PullRequestDiffTool,
+			fmt.Sprintf("Fetches the unified diff of a pull request, up to %d KiB.", maxDiffBytes>>10),
+			schema(nil), c.diffTool),
+		claude.Tool(CheckRunsTool,
+			"Lists the CI checks of a pull request's head commit with their status and conclusion.",
+			schema(nil), c.checksTool),
+		claude.Tool(CreateReviewTool,
+			"Posts a review on a pull request: a summary and optional comments on lines of the diff.",
+			schema(map[string]any{
+				"body": map[string]any{"type": "string", "description": "Summary of the review"},
+				"event": map[string]any{
+					"type": "string",
+					"enum": []string{"COMMENT", "APPROVE", "REQUEST_CHANGES"},
+				},
+				"comments": map[string]any{"type": "array", "items": comment},
+			}, "body"),
+			c.reviewTool),
+	})
+}
+
+// ReviewAgent returns a copy of opts, which may be nil, set up for
+// reviewing pull requests through c: the server registered as ServerName,
+// its tools and the read-only built-in tools allowed, the built-in tools
+// that write or run commands disallowed, and, unless opts sets one, a
+// system prompt with review instructions. Start it with ReviewPrompt.
+func ReviewAgent(c *Client, opts *claude.Options) *claude.Options {
+	var agent claude.Options
+	if opts != nil {
+		agent = *opts
+	}
+
+	servers := make(map[string]claude.McpServerConfig, len(agent.McpServers)+1)
+	for name, server := range agent.McpServers {
+		servers[name] = server
+	}
+	agent.McpServers = servers
+	agent.McpServers[ServerName] = c.Server(ServerName)
+
+	agent.AllowedTools = slices.Clone(agent.AllowedTools)
+	for _, tool := range append(slices.Clone(reviewReadTools), ToolNames(ServerName)...) {
+		if !slices.Contains(agent.AllowedTools, tool) {
+			agent.AllowedTools = append(agent.AllowedTools, tool)
+		}
+	}
+	agent.DisallowedTools = slices.Clone(agent.DisallowedTools)
+	for _, tool := range reviewDeniedTools {
+		if !slices.Contains(agent.DisallowedTools, tool) {
+			agent.DisallowedTools = append(agent.DisallowedTools, tool)
+		}
+	}
+
+	if agent.SystemPrompt == nil {
+		instructions := reviewInstructions
+		agent.SystemPrompt = claude.SystemPromptPreset{Type: "preset", Preset: "claude_code", Append: &instructions}
+	}
+
+	return &agent
+}
+
+// ReviewPrompt returns the prompt asking a ReviewAgent to review pull
+// request number of repo.
+func ReviewPrompt(repo string, number int) string {
+	return fmt.Sprintf("Review pull request #%d of %s.", number, repo)
+}
+
+// ToolNames returns the full names of the tools of a server registered as
+// name, for Options.AllowedTools.
+func ToolNames(name string) []string {
+	names := make([]string, 0, 3)
+	for _, tool := range []string{PullRequestDiffTool, CheckRunsTool, CreateReviewTool} {
+		names = append(names, "mcp__"+name+"__"+tool)
+	}
+
+	return names
+}
+
+func (c *Client) diffTool(ctx context.Context, args map[string]any) (*claude.McpToolResult, error) {
+	repo, number, err := pullArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := c.PullRequestDiff(ctx, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	if diff == "" {
+		return claude.TextResult("the pull request has no changes"), nil
+	}
+	if len(diff) > maxDiffBytes {
+		diff = diff[:maxDiffBytes] + fmt.Sprintf(
+			"\n\n[diff truncated at %d of %d bytes; read the remaining files in the working directory]",
+			maxDiffBytes, len(diff))
+	}
+
+	return claude.TextResult(diff), nil
+}
+
+func (c *Client) checksTool(ctx context.Context, args map[string]any) (*claude.McpToolResult, error) {
+	repo, number, err := pullArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := c.CheckRuns(ctx, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return claude.TextResult("the head commit has no checks"), nil
+	}
+
+	lines := make([]string, 0, len(runs))
+	for _, run := range runs {
+		outcome := run.Status
+		if run.Conclusion != "" {
+			outcome = run.Conclusion
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s %s", run.Name, outcome, run.DetailsURL))
+	}
+
+	return claude.TextResult(strings.Join(lines, "\n")), nil
+}
+
+func (c *Client) reviewTool(ctx context.Context, args map[string]any) (*claude.McpToolResult, error) {
+	repo, number, err := pullArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-trip the arguments to decode the comments
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	var review Review
+	if err := json.Unmarshal(data, &review); err != nil {
+		return nil, fmt.Errorf("invalid review: %w", err)
+	}
+	if review.Event == "" {
+		review.Event = "COMMENT"
+	}
+
+	url, err := c.CreateReview(ctx, repo, number, review)
+	if err != nil {
+		return nil, err
+	}
+
+	return claude.TextResult(fmt.Sprintf("posted review with %d comments: %s", len(review.Comments), url)), nil
+}
+
+// pullArgs returns the repo and number arguments of a tool call.
+func pullArgs(args map[string]any) (string, int, error) {
+	repo, _ := args["repo"].(string)
+	if !validRepo(repo) {
+		return "", 0, fmt.Errorf(`repo must be "owner/name", got %q`, repo)
+	}
+	number, ok := args["number"].(float64)
+	if !ok || number < 1 || number != float64(int(number)) {
+		return "", 0, errors.New("number must be a positive integer")
+	}
+
+	return repo, int(number), nil
+}
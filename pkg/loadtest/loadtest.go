@@ -0,0 +1,342 @@
+// Package loadtest drives scripted conversations through the SDK at a
+// fixed rate and reports throughput, latency, errors, and resource growth,
+// to validate the SDK for long-running services. Conversations run against
+// the real CLI or, for measuring the SDK alone, MockCLI.
+//
+//	report, err := loadtest.Run(ctx, loadtest.Config{
+//		Options:  &claude.Options{Model: "claude-haiku-4-5"},
+//		Script:   []string{"Say hi", "Say bye"},
+//		QPS:      2,
+//		Duration: time.Minute,
+//	})
+package loadtest
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// defaultConcurrency bounds conversations in flight.
+	defaultConcurrency = 64
+	// defaultTurnTimeout bounds a single turn.
+	defaultTurnTimeout = 2 * time.Minute
+	// defaultSettleTimeout bounds the wait for resources to be released
+	// after the run.
+	defaultSettleTimeout = 5 * time.Second
+	// settleInterval is how often resources are sampled while settling.
+	settleInterval = 50 * time.Millisecond
+	// errorCodeUnknown counts errors that carry no SDK error code.
+	errorCodeUnknown = "unknown"
+)
+
+// Config configures a load test.
+type Config struct {
+	// Options are used for every conversation; each gets its own copy.
+	Options *claude.Options
+	// Script is the prompts of a conversation, sent in order, one turn
+	// each.
+	Script []string
+	// QPS is how many conversations are started per second.
+	QPS float64
+	// Duration is how long new conversations are started for. Run then
+	// waits for those in flight to finish.
+	Duration time.Duration
+	// Concurrency bounds the conversations in flight; a conversation due
+	// while the bound is reached is skipped and counted. It defaults to
+	// 64.
+	Concurrency int
+	// TurnTimeout bounds each turn. It defaults to two minutes.
+	TurnTimeout time.Duration
+	// SettleTimeout bounds the wait after the run for goroutines and file
+	// descriptors to be released before leaks are measured. It defaults
+	// to five seconds.
+	SettleTimeout time.Duration
+}
+
+// Resources is a snapshot of the process's resource use.
+type Resources struct {
+	Goroutines int
+	// FDs is the number of open file descriptors, or -1 where it can't
+	// be counted.
+	FDs int
+	// HeapBytes is the live heap after a garbage collection.
+	HeapBytes uint64
+}
+
+// Report is the outcome of a load test.
+type Report struct {
+	// Started, Completed and Failed count conversations; Skipped counts
+	// those not started because Concurrency was reached.
+	Started   int
+	Completed int
+	Failed    int
+	Skipped   int
+	// Turns counts completed turns.
+	Turns int
+	// Elapsed is the wall time of the run.
+	Elapsed time.Duration
+	// Throughput is completed conversations per second.
+	Throughput float64
+	// P50, P95 and P99 are turn latency percentiles.
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+	// Errors counts failures by SDK error code.
+	Errors map[clauderrs.ErrorCode]int
+	// Before and After are resource snapshots taken before the run and
+	// after it settled.
+	Before Resources
+	After  Resources
+}
+
+// GoroutineLeak is how many more goroutines are running than before.
+func (r *Report) GoroutineLeak() int {
+	return r.After.Goroutines - r.Before.Goroutines
+}
+
+// FDLeak is how many more file descriptors are open than before, or 0
+// where they can't be counted.
+func (r *Report) FDLeak() int {
+	if r.Before.FDs < 0 || r.After.FDs < 0 {
+		return 0
+	}
+
+	return r.After.FDs - r.Before.FDs
+}
+
+// HeapGrowth is the change in live heap bytes.
+func (r *Report) HeapGrowth() int64 {
+	return int64(r.After.HeapBytes) - int64(r.Before.HeapBytes)
+}
+
+// Run runs the load test. It returns a ValidationError for an invalid
+// config; failures of individual conversations are counted in the report.
+// Cancelling ctx stops starting conversations and ends those in flight.
+func Run(ctx context.Context, config Config) (*Report, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	r := &runner{config: config, report: &Report{Errors: make(map[clauderrs.ErrorCode]int)}}
+	r.report.Before = Snapshot()
+
+	start := time.Now()
+	r.drive(ctx)
+	r.report.Elapsed = time.Since(start)
+	if seconds := r.report.Elapsed.Seconds(); seconds > 0 {
+		r.report.Throughput = float64(r.report.Completed) / seconds
+	}
+
+	slices.Sort(r.latencies)
+	r.report.P50 = percentile(r.latencies, 50)
+	r.report.P95 = percentile(r.latencies, 95)
+	r.report.P99 = percentile(r.latencies, 99)
+	r.report.After = settle(r.report.Before, config.SettleTimeout)
+
+	return r.report, nil
+}
+
+// validate checks the config and fills in defaults.
+func (c *Config) validate() error {
+	switch {
+	case len(c.Script) == 0:
+		return clauderrs.NewValidationError(clauderrs.ErrCodeMissingField, "load test script is empty", nil, "Script", nil)
+	case c.QPS <= 0:
+		return clauderrs.NewValidationError(clauderrs.ErrCodeRangeViolation, "QPS must be positive", nil, "QPS", c.QPS)
+	case c.Duration <= 0:
+		return clauderrs.NewValidationError(clauderrs.ErrCodeRangeViolation, "Duration must be positive", nil, "Duration", c.Duration)
+	}
+
+	if c.Options == nil {
+		c.Options = &claude.Options{}
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = defaultConcurrency
+	}
+	if c.TurnTimeout <= 0 {
+		c.TurnTimeout = defaultTurnTimeout
+	}
+	if c.SettleTimeout <= 0 {
+		c.SettleTimeout = defaultSettleTimeout
+	}
+
+	return nil
+}
+
+// runner holds the state of one run.
+type runner struct {
+	config Config
+
+	mu        sync.Mutex
+	report    *Report
+	latencies []time.Duration
+}
+
+// drive starts conversations at the configured rate for the configured
+// duration and waits for them to finish.
+func (r *runner) drive(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	interval := time.Duration(float64(time.Second) / r.config.QPS)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	deadline := time.After(r.config.Duration)
+
+	slots := make(chan struct{}, r.config.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case slots <- struct{}{}:
+			r.record(func(report *Report) { report.Started++ })
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				r.converse(ctx)
+			}()
+		default:
+			r.record(func(report *Report) { report.Skipped++ })
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// converse runs one scripted conversation.
+func (r *runner) converse(ctx context.Context) {
+	opts := *r.config.Options
+	client, err := claude.NewClient(&opts)
+	if err != nil {
+		r.fail(err)
+
+		return
+	}
+	defer func() { _ = client.Close() }()
+
+	for _, prompt := range r.config.Script {
+		started := time.Now()
+		if err := r.turn(ctx, client, prompt); err != nil {
+			r.fail(err)
+
+			return
+		}
+		latency := time.Since(started)
+		r.record(func(report *Report) {
+			report.Turns++
+			r.latencies = append(r.latencies, latency)
+		})
+	}
+
+	r.record(func(report *Report) { report.Completed++ })
+}
+
+// turn sends prompt and waits for its result.
+func (r *runner) turn(ctx context.Context, client *claude.ClaudeSDKClient, prompt string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.TurnTimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, prompt); err != nil {
+		return err
+	}
+
+	var result *claude.SDKResultMessage
+	for msg := range client.ReceiveResponse(ctx) {
+		if m, ok := msg.(*claude.SDKResultMessage); ok {
+			result = m
+		}
+	}
+
+	switch {
+	case client.Err() != nil:
+		return client.Err()
+	case result == nil:
+		return clauderrs.NewProcessError(
+			clauderrs.ErrCodeProcessExited,
+			"Claude Code ended the turn without a result",
+			nil,
+			-1,
+			"",
+		)
+	case result.IsError:
+		return clauderrs.NewAPIError(clauderrs.ErrCodeAPIServerError, "turn ended with "+result.Subtype, nil)
+	}
+
+	return nil
+}
+
+// fail counts a failed conversation by error code.
+func (r *runner) fail(err error) {
+	code := clauderrs.ErrorCode(errorCodeUnknown)
+	if sdkErr, ok := clauderrs.AsSDKError(err); ok {
+		code = sdkErr.Code()
+	}
+
+	r.record(func(report *Report) {
+		report.Failed++
+		report.Errors[code]++
+	})
+}
+
+// record updates the report under the runner's lock.
+func (r *runner) record(update func(report *Report)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	update(r.report)
+}
+
+// Snapshot returns the process's current resource use, after a garbage
+// collection.
+func Snapshot() Resources {
+	runtime.GC()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	fds := -1
+	if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+		fds = len(entries)
+	}
+
+	return Resources{Goroutines: runtime.NumGoroutine(), FDs: fds, HeapBytes: mem.HeapAlloc}
+}
+
+// settle samples resources until goroutines and file descriptors are back
+// to before or timeout passes, and returns the last sample.
+func settle(before Resources, timeout time.Duration) Resources {
+	deadline := time.Now().Add(timeout)
+	for {
+		after := Snapshot()
+		if after.Goroutines <= before.Goroutines && after.FDs <= before.FDs || time.Now().After(deadline) {
+			return after
+		}
+		time.Sleep(settleInterval)
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted samples.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := max((p*len(sorted)+99)/100, 1)
+
+	return sorted[rank-1]
+}
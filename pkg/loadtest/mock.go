@@ -0,0 +1,122 @@
+package loadtest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// mockMaxLine bounds a line read by MockCLI.
+const mockMaxLine = 10 << 20
+
+// MockCLI answers the SDK's stream-json protocol without calling the API,
+// so load tests measure the SDK rather than the model. Every prompt gets
+// an assistant message and a success result after Latency, and every
+// control request gets an empty success response.
+//
+// Run it as the CLI process by having a binary call Serve on its standard
+// input and output, as cmd/claude-loadtest does with -mock.
+type MockCLI struct {
+	// Latency delays each answer, simulating model time.
+	Latency time.Duration
+	// Reply is the assistant text. It defaults to "ok".
+	Reply string
+}
+
+// Serve answers requests read from r on w until r is exhausted.
+func (m MockCLI) Serve(r io.Reader, w io.Writer) error {
+	reply := m.Reply
+	if reply == "" {
+		reply = "ok"
+	}
+	sessionID := uuid.NewString()
+	out := bufio.NewWriter(w)
+	started := false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), mockMaxLine)
+	for scanner.Scan() {
+		var request struct {
+			Type      string `json:"type"`
+			RequestID string `json:"request_id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			continue
+		}
+
+		var frames []any
+		switch request.Type {
+		case "control_request":
+			frames = append(frames, map[string]any{
+				"type": "control_response",
+				"response": map[string]any{
+					"subtype":    "success",
+					"request_id": request.RequestID,
+					"response":   map[string]any{},
+				},
+			})
+		case "user":
+			if !started {
+				started = true
+				frames = append(frames, map[string]any{
+					"type": "system", "subtype": "init", "uuid": uuid.NewString(), "session_id": sessionID,
+				})
+			}
+			time.Sleep(m.Latency)
+			frames = append(frames, m.assistant(sessionID, reply), m.result(sessionID, reply))
+		default:
+			continue
+		}
+
+		for _, frame := range frames {
+			data, err := json.Marshal(frame)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(out, "%s\n", data); err != nil {
+				return err
+			}
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// assistant returns an assistant message frame.
+func (m MockCLI) assistant(sessionID, reply string) map[string]any {
+	return map[string]any{
+		"type":       "assistant",
+		"uuid":       uuid.NewString(),
+		"session_id": sessionID,
+		"message": map[string]any{
+			"id":      "msg_" + uuid.NewString(),
+			"type":    "message",
+			"role":    "assistant",
+			"model":   "mock",
+			"content": []any{map[string]any{"type": "text", "text": reply}},
+			"usage":   map[string]any{"input_tokens": 1, "output_tokens": 1},
+		},
+	}
+}
+
+// result returns a success result frame.
+func (m MockCLI) result(sessionID, reply string) map[string]any {
+	return map[string]any{
+		"type":           "result",
+		"subtype":        "success",
+		"uuid":           uuid.NewString(),
+		"session_id":     sessionID,
+		"duration_ms":    m.Latency.Milliseconds(),
+		"num_turns":      1,
+		"total_cost_usd": 0,
+		"usage":          map[string]any{"input_tokens": 1, "output_tokens": 1},
+		"result":         reply,
+	}
+}
@@ -0,0 +1,290 @@
+// Package notify posts session lifecycle events to Slack and webhooks.
+//
+// A Notifier watches the messages of the clients it wraps and turns
+// session starts, results, budget overruns, permission denials and errors
+// into Events, rendered with per-kind templates and delivered to its
+// sinks in the background, for visibility into fleets of autonomous
+// agents:
+//
+//	notifier := &notify.Notifier{
+//		Sinks:  []notify.Sink{notify.SlackWebhook(os.Getenv("SLACK_WEBHOOK_URL"))},
+//		Events: []notify.EventKind{notify.EventBudgetExceeded, notify.EventError},
+//		Labels: map[string]string{"agent": "triage"},
+//	}
+//	client := notifier.Client(sdkClient)
+//	defer notifier.Wait()
+package notify
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// defaultTimeout bounds each delivery when Notifier.Timeout is unset.
+const defaultTimeout = 10 * time.Second
+
+// messageBuffer is the buffer of the streams returned by Notifier.Client.
+const messageBuffer = 16
+
+// EventKind identifies a lifecycle event.
+type EventKind string
+
+const (
+	// EventSessionStarted is sent when the CLI reports its session.
+	EventSessionStarted EventKind = "session_started"
+	// EventSessionFinished is sent for each successful result.
+	EventSessionFinished EventKind = "session_finished"
+	// EventBudgetExceeded is sent for results stopped by MaxBudgetUsd.
+	EventBudgetExceeded EventKind = "budget_exceeded"
+	// EventPermissionDenied is sent for each tool call a result reports
+	// as denied.
+	EventPermissionDenied EventKind = "permission_denied"
+	// EventError is sent for other error results and for failed or
+	// aborted streams.
+	EventError EventKind = "error"
+)
+
+// defaultTemplates render events whose kind has no template in
+// Notifier.Templates.
+var defaultTemplates = map[EventKind]string{
+	EventSessionStarted:   `{{template "prefix" .}}Session {{.SessionID}} started`,
+	EventSessionFinished:  `{{template "prefix" .}}Session {{.SessionID}} finished after {{.NumTurns}} turns ({{printf "$%.4f" .CostUSD}})`,
+	EventBudgetExceeded:   `{{template "prefix" .}}Session {{.SessionID}} stopped at its budget after {{.NumTurns}} turns ({{printf "$%.4f" .CostUSD}})`,
+	EventPermissionDenied: `{{template "prefix" .}}Session {{.SessionID}} was denied {{.Tool}}`,
+	EventError:            `{{template "prefix" .}}Session {{.SessionID}} failed: {{.Error}}`,
+}
+
+// prefixTemplate starts the default templates with the labels.
+const prefixTemplate = `{{define "prefix"}}{{range $key, $value := .Labels}}[{{$key}}={{$value}}] {{end}}{{end}}`
+
+// Event is a lifecycle event of a session.
+type Event struct {
+	Kind      EventKind
+	SessionID string
+	Time      time.Time
+	// CostUSD and NumTurns are set for results.
+	CostUSD  float64
+	NumTurns int
+	// Tool is the denied tool of EventPermissionDenied.
+	Tool string
+	// Error describes the failure of EventError.
+	Error string
+	// Labels are Notifier.Labels, identifying the agent.
+	Labels map[string]string
+}
+
+// Sink delivers notifications.
+type Sink interface {
+	// Send delivers event, rendered as text.
+	Send(ctx context.Context, event Event, text string) error
+}
+
+// Notifier sends the lifecycle events of the clients it wraps to its
+// sinks. Set its fields before calling Client.
+type Notifier struct {
+	// Sinks receive every event sent.
+	Sinks []Sink
+	// Events are the kinds to send. Nil sends every kind.
+	Events []EventKind
+	// Templates render events by kind, as text/template templates over
+	// Event, such as "{{.Tool}} denied in {{.SessionID}}". Kinds without
+	// one use a default. {{template "prefix" .}} renders the labels.
+	Templates map[EventKind]string
+	// Labels are added to every event, such as the agent's name.
+	Labels map[string]string
+	// Timeout bounds each delivery. Zero means 10 seconds.
+	Timeout time.Duration
+	// OnError, if set, receives failed deliveries and templates that
+	// failed to render, which fall back to the default.
+	OnError func(error)
+
+	wg sync.WaitGroup
+}
+
+// Client returns c sending the lifecycle events of its sessions.
+func (n *Notifier) Client(c claude.Client) claude.Client {
+	return &notifyingClient{Client: c, notifier: n}
+}
+
+// Notify sends event to the sinks in the background, unless its kind is
+// filtered out. Wrapped clients call it; call it directly for events of
+// your own.
+func (n *Notifier) Notify(event Event) {
+	if n.Events != nil && !slices.Contains(n.Events, event.Kind) {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.Labels == nil {
+		event.Labels = n.Labels
+	}
+	text := n.render(event)
+
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	for _, sink := range n.Sinks {
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := sink.Send(ctx, event, text); err != nil {
+				n.reportError(fmt.Errorf("failed to send %s notification: %w", event.Kind, err))
+			}
+		}()
+	}
+}
+
+// Wait blocks until the notifications sent so far are delivered or have
+// failed. Call it before exiting.
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// render returns the text of event.
+func (n *Notifier) render(event Event) string {
+	if text, ok := n.Templates[event.Kind]; ok {
+		rendered, err := renderTemplate(text, event)
+		if err == nil {
+			return rendered
+		}
+		n.reportError(fmt.Errorf("failed to render %s notification: %w", event.Kind, err))
+	}
+
+	rendered, err := renderTemplate(defaultTemplates[event.Kind], event)
+	if err != nil {
+		return fmt.Sprintf("%s: session %s", event.Kind, event.SessionID)
+	}
+
+	return rendered
+}
+
+func renderTemplate(text string, event Event) (string, error) {
+	tmpl, err := template.New("notification").Parse(prefixTemplate + text)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, event); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
+
+func (n *Notifier) reportError(err error) {
+	if n.OnError != nil {
+		n.OnError(err)
+	}
+}
+
+// observe sends the events msg carries.
+func (n *Notifier) observe(msg claude.SDKMessage) {
+	switch m := msg.(type) {
+	case *claude.SDKSystemMessage:
+		if m.Subtype == "init" {
+			n.Notify(Event{Kind: EventSessionStarted, SessionID: m.SessionID()})
+		}
+	case *claude.SDKResultMessage:
+		for _, denial := range m.PermissionDenials {
+			n.Notify(Event{Kind: EventPermissionDenied, SessionID: m.SessionID(), Tool: denial.ToolName})
+		}
+
+		event := Event{SessionID: m.SessionID(), CostUSD: m.TotalCostUSD, NumTurns: m.NumTurns}
+		switch {
+		case m.Subtype == claude.ResultSubtypeErrorMaxBudgetUsd:
+			event.Kind = EventBudgetExceeded
+		case m.IsError:
+			event.Kind = EventError
+			event.Error = m.Subtype
+			if len(m.Errors) > 0 {
+				event.Error = strings.Join(m.Errors, "; ")
+			}
+		default:
+			event.Kind = EventSessionFinished
+		}
+		n.Notify(event)
+	}
+}
+
+// notifyingClient is the Client returned by Notifier.Client.
+type notifyingClient struct {
+	claude.Client
+	notifier *Notifier
+
+	mu        sync.Mutex
+	sessionID string // Of the last message seen, for error events
+}
+
+func (c *notifyingClient) ReceiveMessages(ctx context.Context) (<-chan claude.SDKMessage, <-chan error) {
+	msgs, errs := c.Client.ReceiveMessages(ctx)
+
+	out := make(chan error, 1)
+	go func() {
+		defer close(out)
+		for err := range errs {
+			c.notifyError(err)
+			select {
+			case out <- err:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return c.forward(ctx, msgs, nil), out
+}
+
+func (c *notifyingClient) ReceiveResponse(ctx context.Context) <-chan claude.SDKMessage {
+	return c.forward(ctx, c.Client.ReceiveResponse(ctx), func() {
+		if err := c.Client.Err(); err != nil {
+			c.notifyError(err)
+		}
+	})
+}
+
+// forward forwards in, sending the events of each message first and
+// calling closed, if set, once in is closed.
+func (c *notifyingClient) forward(ctx context.Context, in <-chan claude.SDKMessage, closed func()) <-chan claude.SDKMessage {
+	out := make(chan claude.SDKMessage, messageBuffer)
+	go func() {
+		defer close(out)
+		for msg := range in {
+			if sessionID := msg.SessionID(); sessionID != "" {
+				c.mu.Lock()
+				c.sessionID = sessionID
+				c.mu.Unlock()
+			}
+			c.notifier.observe(msg)
+			select {
+			case out <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if closed != nil {
+			closed()
+		}
+	}()
+
+	return out
+}
+
+func (c *notifyingClient) notifyError(err error) {
+	c.mu.Lock()
+	sessionID := c.sessionID
+	c.mu.Unlock()
+
+	c.notifier.Notify(Event{Kind: EventError, SessionID: sessionID, Error: err.Error()})
+}
@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// SlackWebhook returns a Sink posting the text of events to a Slack
+// incoming webhook URL.
+func SlackWebhook(url string) Sink {
+	return &webhookSink{url: url, slack: true}
+}
+
+// Webhook returns a Sink posting events as JSON to url, with headers such
+// as an Authorization header added to each request. The body holds the
+// event's fields in snake case and its rendered text:
+//
+//	{"kind":"error","session_id":"...","time":"...","error":"...","text":"..."}
+func Webhook(url string, headers map[string]string) Sink {
+	return &webhookSink{url: url, headers: headers}
+}
+
+// webhookSink is the Sink returned by SlackWebhook and Webhook.
+type webhookSink struct {
+	url     string
+	headers map[string]string
+	slack   bool
+	client  http.Client
+}
+
+// webhookBody is the body Webhook posts.
+type webhookBody struct {
+	Kind      EventKind         `json:"kind"`
+	SessionID string            `json:"session_id,omitempty"`
+	Time      string            `json:"time"`
+	CostUSD   float64           `json:"cost_usd,omitempty"`
+	NumTurns  int               `json:"num_turns,omitempty"`
+	Tool      string            `json:"tool,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Text      string            `json:"text"`
+}
+
+func (s *webhookSink) Send(ctx context.Context, event Event, text string) error {
+	var body any = map[string]string{"text": text}
+	if !s.slack {
+		body = webhookBody{
+			Kind:      event.Kind,
+			SessionID: event.SessionID,
+			Time:      event.Time.UTC().Format("2006-01-02T15:04:05.000Z"),
+			CostUSD:   event.CostUSD,
+			NumTurns:  event.NumTurns,
+			Tool:      event.Tool,
+			Error:     event.Error,
+			Labels:    event.Labels,
+			Text:      text,
+		}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= http.StatusBadRequest {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+
+	return nil
+}
@@ -0,0 +1,279 @@
+package pathpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+	"unicode/utf8"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// Tools of the filesystem server, as named within it.
+const (
+	ListDirectoryTool = "list_directory"
+	ReadFileTool      = "read_file"
+	WriteFileTool     = "write_file"
+)
+
+// maxReadBytes bounds the files read_file returns.
+const maxReadBytes = 256 << 10
+
+// FS is a filesystem a filesystem server serves. Names are as in io/fs:
+// slash-separated, relative to the filesystem's root, with "." for the
+// root itself.
+type FS interface {
+	fs.ReadDirFS
+	fs.ReadFileFS
+	// WriteFile creates or replaces the file name, creating its parent
+	// directories as needed.
+	WriteFile(name string, data []byte) error
+}
+
+// DirFS returns the FS of the local directory dir.
+func DirFS(dir string) FS {
+	return dirFS{dirFSBase: os.DirFS(dir).(dirFSBase), dir: dir}
+}
+
+// dirFSBase is what os.DirFS implements.
+type dirFSBase interface {
+	fs.ReadDirFS
+	fs.ReadFileFS
+}
+
+type dirFS struct {
+	dirFSBase
+	dir string
+}
+
+func (d dirFS) WriteFile(name string, data []byte) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	native := filepath.Join(d.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(native), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(native, data, 0o644)
+}
+
+// MemFS is an FS held in memory, for sandboxes without a real filesystem
+// and for tests. The zero value is an empty filesystem.
+type MemFS struct {
+	mu    sync.RWMutex
+	files fstest.MapFS
+}
+
+// NewMemFS creates a MemFS holding files, by name.
+func NewMemFS(files map[string]string) *MemFS {
+	m := &MemFS{}
+	for name, content := range files {
+		_ = m.WriteFile(name, []byte(content))
+	}
+
+	return m
+}
+
+// Open opens the file name.
+func (m *MemFS) Open(name string) (fs.File, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.files.Open(name)
+}
+
+// ReadDir returns the entries of the directory name, sorted by name.
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.files.ReadDir(name)
+}
+
+// ReadFile returns the content of the file name.
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.files.ReadFile(name)
+}
+
+// WriteFile creates or replaces the file name.
+func (m *MemFS) WriteFile(name string, data []byte) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.files == nil {
+		m.files = make(fstest.MapFS)
+	}
+	if file, ok := m.files[name]; ok && file.Mode.IsDir() {
+		return &fs.PathError{Op: "write", Path: name, Err: errors.New("is a directory")}
+	}
+	m.files[name] = &fstest.MapFile{Data: slices.Clone(data), Mode: 0o644, ModTime: time.Now()}
+
+	return nil
+}
+
+// FilesystemServer returns an in-process SDK MCP server named name that
+// lists, reads and writes files in fsys, for sandboxes where Claude's
+// built-in file tools can't reach the files, such as in-memory or remote
+// filesystems. The matcher's root is the root of fsys: paths the model
+// passes resolve against it, paths outside it are refused, and so are
+// paths the matcher denies, which list_directory also leaves out.
+//
+// Register the server under name in Options.McpServers; to keep the model
+// on it, disallow the built-in file tools:
+//
+//	matcher := pathpolicy.NewMatcher().WithRoot("/workspace").WithDeny("secrets/**")
+//	opts := &claude.Options{
+//		McpServers: map[string]claude.McpServerConfig{
+//			"files": matcher.FilesystemServer("files", pathpolicy.NewMemFS(files)),
+//		},
+//		DisallowedTools: []string{"Read", "Write", "Edit", "MultiEdit", "Glob", "Grep", "LS"},
+//	}
+func (m *Matcher) FilesystemServer(name string, fsys FS) claude.McpServerConfig {
+	schema := func(kind string, extra map[string]any, required ...string) map[string]any {
+		properties := map[string]any{"path": map[string]any{
+			"type":        "string",
+			"description": fmt.Sprintf("Path of the %s, absolute or relative to %s", kind, m.root),
+		}}
+		for key, value := range extra {
+			properties[key] = value
+		}
+
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+
+		return schema
+	}
+	server := fsServer{matcher: m, fsys: fsys}
+
+	return claude.CreateSdkMcpServer(name, "1.0.0", []claude.McpTool{
+		claude.Tool(ListDirectoryTool,
+			"Lists a directory. Subdirectories end with a slash.",
+			schema("directory", nil), server.list),
+		claude.Tool(ReadFileTool,
+			fmt.Sprintf("Reads a text file, up to %d KiB.", maxReadBytes>>10),
+			schema("file", nil, "path"), server.read),
+		claude.Tool(WriteFileTool,
+			"Creates or replaces a file with the given content, creating its directories as needed.",
+			schema("file", map[string]any{"content": map[string]any{"type": "string", "description": "The new content"}},
+				"path", "content"),
+			server.write),
+	})
+}
+
+// fsServer serves the filesystem tools.
+type fsServer struct {
+	matcher *Matcher
+	fsys    FS
+}
+
+func (s fsServer) list(_ context.Context, args map[string]any) (*claude.McpToolResult, error) {
+	p, _ := args["path"].(string)
+	if p == "" {
+		p = s.matcher.root
+	}
+	name, err := s.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.fsys.ReadDir(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", p, err)
+	}
+
+	var listing []string
+	for _, entry := range entries {
+		entryPath := path.Join(s.matcher.absolute(p), entry.Name())
+		if !s.matcher.Evaluate(entryPath).Allowed {
+			continue
+		}
+		if entry.IsDir() {
+			listing = append(listing, entry.Name()+"/")
+		} else {
+			listing = append(listing, entry.Name())
+		}
+	}
+	if len(listing) == 0 {
+		return claude.TextResult(fmt.Sprintf("%s is empty", p)), nil
+	}
+
+	return claude.TextResult(strings.Join(listing, "\n")), nil
+}
+
+func (s fsServer) read(_ context.Context, args map[string]any) (*claude.McpToolResult, error) {
+	p, _ := args["path"].(string)
+	name, err := s.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.fsys.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", p, err)
+	}
+	if len(data) > maxReadBytes {
+		return nil, fmt.Errorf("%s is %d bytes, more than the %d bytes read_file returns", p, len(data), maxReadBytes)
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("%s is not a text file", p)
+	}
+
+	return claude.TextResult(string(data)), nil
+}
+
+func (s fsServer) write(_ context.Context, args map[string]any) (*claude.McpToolResult, error) {
+	p, _ := args["path"].(string)
+	content, ok := args["content"].(string)
+	if !ok {
+		return nil, errors.New("content must be a string")
+	}
+	name, err := s.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.fsys.WriteFile(name, []byte(content)); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", p, err)
+	}
+
+	return claude.TextResult(fmt.Sprintf("wrote %d bytes to %s", len(content), p)), nil
+}
+
+// resolve checks p against the matcher and returns its name in the
+// filesystem.
+func (s fsServer) resolve(p string) (string, error) {
+	if decision := s.matcher.Evaluate(p); !decision.Allowed {
+		return "", errors.New(decision.Reason)
+	}
+
+	root := filepath.ToSlash(filepath.Clean(s.matcher.root))
+	abs := s.matcher.absolute(p)
+	if abs == root {
+		return ".", nil
+	}
+	name, ok := strings.CutPrefix(abs, strings.TrimSuffix(root, "/")+"/")
+	if !ok || !fs.ValidPath(name) {
+		return "", fmt.Errorf("%s is outside %s", p, s.matcher.root)
+	}
+
+	return name, nil
+}
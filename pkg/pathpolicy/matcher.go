@@ -0,0 +1,460 @@
+// Package pathpolicy decides which files Claude's file tools may touch.
+//
+// A Matcher combines allow and deny glob lists, dotfile protection, and a
+// preset of sensitive paths, and adapts to a CanUseTool callback covering
+// Read, Write, Edit, MultiEdit, NotebookEdit, Glob, Grep, and LS, or to an
+// in-process filesystem MCP server serving files from any FS.
+package pathpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// SensitivePaths are denied by WithSensitivePaths: system configuration,
+// credentials, and repository configuration that may hold secrets.
+var SensitivePaths = []string{
+	"/etc/**",
+	"~/.ssh/**",
+	"~/.aws/**",
+	"~/.gnupg/**",
+	"~/.netrc",
+	"/**/.env",
+	"/**/.env.*",
+	"/**/.git/config",
+}
+
+// fileToolPaths maps file tools to the input field holding their path.
+var fileToolPaths = map[string]string{
+	"Read":         "file_path",
+	"Write":        "file_path",
+	"Edit":         "file_path",
+	"MultiEdit":    "file_path",
+	"NotebookEdit": "notebook_path",
+	"Glob":         "path",
+	"Grep":         "path",
+	"LS":           "path",
+}
+
+// fileToolPatterns maps search tools to the input field holding a glob
+// that is resolved against their path.
+var fileToolPatterns = map[string]string{
+	"Glob": "pattern",
+	"Grep": "glob",
+}
+
+// Matcher checks file paths against allow and deny patterns.
+//
+// Patterns are globs in which * and ? match within one path segment and **
+// matches any number of segments. A leading ~ is the home directory and
+// relative patterns are relative to the root. Paths are cleaned and made
+// absolute against the root, and symlinks are resolved where the path
+// exists; a path is denied if either its written or resolved form is.
+type Matcher struct {
+	root          string
+	allow         []string
+	deny          []string
+	allowDotfiles bool
+}
+
+// Decision is the outcome of checking a path.
+type Decision struct {
+	Allowed bool
+	// Reason explains a denial in terms the model can act on.
+	Reason string
+}
+
+// NewMatcher creates a matcher rooted at the working directory. It allows
+// every path except dotfiles until patterns are added.
+func NewMatcher() *Matcher {
+	root, _ := os.Getwd()
+
+	return &Matcher{root: root}
+}
+
+// WithRoot sets the directory relative paths and patterns resolve against,
+// typically Options.Cwd.
+func (m *Matcher) WithRoot(root string) *Matcher {
+	m.root = root
+
+	return m
+}
+
+// WithAllow adds allow patterns. Once any are set, paths must match one.
+func (m *Matcher) WithAllow(patterns ...string) *Matcher {
+	m.allow = append(m.allow, patterns...)
+
+	return m
+}
+
+// WithDeny adds deny patterns. Deny patterns take precedence over allow
+// patterns.
+func (m *Matcher) WithDeny(patterns ...string) *Matcher {
+	m.deny = append(m.deny, patterns...)
+
+	return m
+}
+
+// WithSensitivePaths denies SensitivePaths.
+func (m *Matcher) WithSensitivePaths() *Matcher {
+	return m.WithDeny(SensitivePaths...)
+}
+
+// WithDotfiles permits paths with a dot-prefixed segment. By default they
+// are denied below the root, and anywhere for paths outside it.
+func (m *Matcher) WithDotfiles() *Matcher {
+	m.allowDotfiles = true
+
+	return m
+}
+
+// Evaluate checks a path.
+func (m *Matcher) Evaluate(p string) Decision {
+	if p == "" {
+		return deny("empty path")
+	}
+
+	written := m.absolute(p)
+	candidates := []string{written}
+	if resolved := resolveSymlinks(written); resolved != written {
+		candidates = append(candidates, resolved)
+	}
+
+	for _, candidate := range candidates {
+		if pattern, ok := m.matchAny(m.deny, candidate); ok {
+			return deny("access to %s is denied by pattern %q", p, pattern)
+		}
+		if !m.allowDotfiles && m.isDotfile(candidate) {
+			return deny("access to hidden path %s is not allowed", p)
+		}
+		if len(m.allow) > 0 {
+			if _, ok := m.matchAny(m.allow, candidate); !ok {
+				return deny("access to %s is not allowed; allowed paths: %s", p, strings.Join(m.allow, ", "))
+			}
+		}
+	}
+
+	return Decision{Allowed: true}
+}
+
+// CanUseTool returns a permission callback that denies file tool calls on
+// paths the matcher rejects and delegates everything else to next,
+// allowing it when next is nil. Glob, Grep, and LS calls without a path
+// are checked against the root, and the static prefix of a Glob pattern or
+// Grep glob, before its first wildcard, is checked against their path.
+// Glob and Grep searches are also denied when they may reach a file below
+// that a deny pattern matches, such as a Grep of the root under a
+// "/**/.env" deny. Dotfile protection applies to the paths they name, not
+// to the hidden files they find.
+func (m *Matcher) CanUseTool(next claude.CanUseToolFunc) claude.CanUseToolFunc {
+	return func(
+		ctx context.Context,
+		toolName string,
+		input map[string]claude.JSONValue,
+		suggestions []claude.PermissionUpdate,
+		toolUseID string,
+		agentID *string,
+		blockedPath *string,
+		decisionReason *string,
+	) (claude.PermissionResult, error) {
+		if field, ok := fileToolPaths[toolName]; ok {
+			p, err := stringInput(toolName, input, field)
+			if err != nil {
+				return denyTool(err.Error()), nil
+			}
+			if p == "" && field == "path" {
+				p = m.root
+			}
+
+			if decision := m.Evaluate(p); !decision.Allowed {
+				return denyTool(decision.Reason), nil
+			}
+
+			if field, ok := fileToolPatterns[toolName]; ok {
+				pattern, err := stringInput(toolName, input, field)
+				if err != nil {
+					return denyTool(err.Error()), nil
+				}
+				if decision := m.evaluatePattern(p, pattern); !decision.Allowed {
+					return denyTool(decision.Reason), nil
+				}
+			}
+		}
+
+		if next == nil {
+			return &claude.PermissionAllow{Behavior: claude.PermissionBehaviorAllow}, nil
+		}
+
+		return next(
+			ctx,
+			toolName,
+			input,
+			suggestions,
+			toolUseID,
+			agentID,
+			blockedPath,
+			decisionReason,
+		)
+	}
+}
+
+// evaluatePattern checks a search of pattern from dir: the static prefix
+// of the pattern, before its first wildcard, and every deny pattern that
+// may match a file the search reaches below that prefix. Patterns that
+// climb with ".." after a wildcard are denied, as their reach can't be
+// known before matching.
+func (m *Matcher) evaluatePattern(dir, pattern string) Decision {
+	if pattern == "" {
+		return m.evaluateSearch(dir, "")
+	}
+
+	prefix, rest := splitGlob(filepath.ToSlash(pattern))
+	for _, segment := range rest {
+		if segment == ".." {
+			return deny("pattern %s may not climb out of a wildcard with ..", pattern)
+		}
+	}
+
+	prefix = expandHome(prefix)
+	if !filepath.IsAbs(prefix) {
+		prefix = filepath.Join(dir, prefix)
+	}
+	if decision := m.Evaluate(prefix); !decision.Allowed {
+		return deny("pattern %s searches outside the allowed paths: %s", pattern, decision.Reason)
+	}
+
+	return m.evaluateSearch(prefix, pattern)
+}
+
+// evaluateSearch denies a search below base when a deny pattern may match
+// a path there that pattern, if any, also selects. Only file names are
+// compared: a pattern of "*.ts" still reaches a "/**/secret.*" deny through
+// "secret.ts", though, as in shell globs, a leading wildcard doesn't match
+// the dot of a hidden file.
+func (m *Matcher) evaluateSearch(base, pattern string) Decision {
+	name := ""
+	if pattern != "" {
+		name = path.Base(filepath.ToSlash(pattern))
+	}
+
+	written := m.absolute(base)
+	for _, candidate := range []string{written, resolveSymlinks(written)} {
+		segments := strings.Split(strings.TrimSuffix(candidate, "/"), "/")
+		for _, denied := range m.deny {
+			deniedSegments := strings.Split(m.absolute(denied), "/")
+			if !reachesBelow(deniedSegments, segments) {
+				continue
+			}
+			if name != "" && !segmentsOverlap(name, deniedSegments[len(deniedSegments)-1]) {
+				continue
+			}
+
+			return deny(
+				"searching %s may reach paths denied by pattern %q; search a narrower path or pattern",
+				base,
+				denied,
+			)
+		}
+	}
+
+	return Decision{Allowed: true}
+}
+
+// reachesBelow reports whether pattern may match a path below the one with
+// segments.
+func reachesBelow(pattern, segments []string) bool {
+	for ; len(segments) > 0; pattern, segments = pattern[1:], segments[1:] {
+		if len(pattern) == 0 {
+			return false
+		}
+		if pattern[0] == "**" {
+			return true
+		}
+		if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+			return false
+		}
+	}
+
+	return len(pattern) > 0
+}
+
+// globMeta are the characters that make a pattern segment a wildcard.
+const globMeta = "*?[]{}"
+
+// segmentsOverlap reports whether some file name may match both name, a
+// search pattern segment, and denied, a deny pattern segment. Two wildcards
+// are taken to overlap unless their literal starts or ends differ.
+func segmentsOverlap(name, denied string) bool {
+	switch {
+	case name == "**" || denied == "**":
+		return true
+	case strings.IndexAny(name, globMeta) == 0 && strings.HasPrefix(denied, "."):
+		return false
+	case !strings.ContainsAny(name, globMeta):
+		ok, _ := path.Match(denied, name)
+
+		return ok
+	case !strings.ContainsAny(denied, globMeta):
+		ok, _ := path.Match(name, denied)
+
+		return ok
+	}
+
+	startA, startB := name[:strings.IndexAny(name, globMeta)], denied[:strings.IndexAny(denied, globMeta)]
+	endA, endB := name[strings.LastIndexAny(name, globMeta)+1:], denied[strings.LastIndexAny(denied, globMeta)+1:]
+
+	return (strings.HasPrefix(startA, startB) || strings.HasPrefix(startB, startA)) &&
+		(strings.HasSuffix(endA, endB) || strings.HasSuffix(endB, endA))
+}
+
+// splitGlob splits a slash-separated pattern into the path before its
+// first wildcard segment and the segments from there on.
+func splitGlob(pattern string) (string, []string) {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if strings.ContainsAny(segment, "*?[{") {
+			prefix := strings.Join(segments[:i], "/")
+			if prefix == "" && strings.HasPrefix(pattern, "/") {
+				prefix = "/"
+			}
+
+			return prefix, segments[i:]
+		}
+	}
+
+	return pattern, nil
+}
+
+// absolute expands ~ and makes p absolute against the root.
+func (m *Matcher) absolute(p string) string {
+	p = expandHome(p)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(m.root, p)
+	}
+
+	return filepath.ToSlash(filepath.Clean(p))
+}
+
+// matchAny returns the first pattern matching p.
+func (m *Matcher) matchAny(patterns []string, p string) (string, bool) {
+	for _, pattern := range patterns {
+		if matchGlob(m.absolute(pattern), p) {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
+
+// isDotfile reports a dot-prefixed segment below the root, or anywhere
+// for paths outside it.
+func (m *Matcher) isDotfile(p string) bool {
+	if m.root != "" {
+		root := filepath.ToSlash(filepath.Clean(m.root))
+		for _, r := range []string{root, resolveSymlinks(root)} {
+			if rel, ok := strings.CutPrefix(p, strings.TrimSuffix(r, "/")+"/"); ok {
+				p = rel
+
+				break
+			}
+		}
+	}
+
+	for _, segment := range strings.Split(p, "/") {
+		if strings.HasPrefix(segment, ".") && segment != "." && segment != ".." {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchGlob matches a cleaned absolute path against a pattern with **
+// segments.
+func matchGlob(pattern, p string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(p, "/"))
+}
+
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+
+	return len(segments) == 0
+}
+
+// resolveSymlinks resolves symlinks in the longest existing prefix of p.
+func resolveSymlinks(p string) string {
+	native := filepath.FromSlash(p)
+	suffix := ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(native); err == nil {
+			return filepath.ToSlash(filepath.Join(resolved, suffix))
+		}
+		parent := filepath.Dir(native)
+		if parent == native {
+			return p
+		}
+		suffix = filepath.Join(filepath.Base(native), suffix)
+		native = parent
+	}
+}
+
+// expandHome replaces a leading ~ with the home directory.
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(p, "~"))
+}
+
+// stringInput reads a string field of a tool's input, which may be absent.
+func stringInput(toolName string, input map[string]claude.JSONValue, field string) (string, error) {
+	var s string
+	if raw, ok := input[field]; ok {
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", fmt.Errorf("%s input %s must be a string", toolName, field)
+		}
+	}
+
+	return s, nil
+}
+
+func denyTool(message string) *claude.PermissionDeny {
+	return &claude.PermissionDeny{
+		Behavior: claude.PermissionBehaviorDeny,
+		Message:  message,
+	}
+}
+
+func deny(format string, args ...any) Decision {
+	return Decision{Reason: fmt.Sprintf(format, args...)}
+}
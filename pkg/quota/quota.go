@@ -0,0 +1,246 @@
+// Package quota limits the cost and tokens spent per user, team or any
+// other key, across every client in a process or, with a shared Store,
+// across processes.
+//
+// A Manager records the usage reported by each result against its key and
+// refuses new queries for keys past their limits with an
+// ErrCodeQuotaExceeded error:
+//
+//	quotas := quota.NewManager(quota.NewMemoryStore(), quota.Limits{
+//		MaxCost: 5_000_000_000, // $5
+//		Window:  24 * time.Hour,
+//	})
+//	client := quotas.Client(sdkClient, userID, nil)
+//	err := client.Query(ctx, prompt)
+//	if sdkErr, ok := clauderrs.AsSDKError(err); ok && sdkErr.Code() == clauderrs.ErrCodeQuotaExceeded {
+//		// Tell the user to come back tomorrow
+//	}
+//
+// Limits are checked before a query is sent, so the turn that crosses a
+// limit completes, and concurrent queries for a key may each pass the
+// check: quotas bound spending to the limit plus the turns in flight.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// messageBuffer is the buffer of the streams returned by Manager.Client.
+const messageBuffer = 16
+
+// Limits bounds the usage of a key.
+type Limits struct {
+	// MaxCost bounds the cost of the key's turns. Zero means no limit.
+	MaxCost claude.USD
+	// MaxTokens bounds the input and output tokens of the key's turns.
+	// Zero means no limit.
+	MaxTokens int64
+	// Window is the period usage is counted over, starting at multiples
+	// of Window since the Unix epoch, such as each UTC day for 24 hours.
+	// Zero counts usage forever.
+	Window time.Duration
+}
+
+// Usage is the cost and tokens used by a key.
+type Usage struct {
+	Cost claude.USD `json:"cost"`
+	// Tokens counts input and output tokens. Cached input tokens are not
+	// counted.
+	Tokens int64 `json:"tokens"`
+}
+
+// Store keeps usage counters. Share one store, such as one backed by
+// Redis, between processes for quotas across them. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	// Add adds usage to the counter bucket and returns its new total.
+	// expires is when the bucket's window ends, after which the store
+	// may drop it; it is zero for buckets that never expire.
+	Add(ctx context.Context, bucket string, usage Usage, expires time.Time) (Usage, error)
+	// Get returns the total of the counter bucket, zero if it is unknown.
+	Get(ctx context.Context, bucket string) (Usage, error)
+}
+
+// Manager tracks usage per key and enforces limits.
+type Manager struct {
+	store    Store
+	defaults Limits
+	now      func() time.Time
+
+	mu     sync.RWMutex
+	limits map[string]Limits // Overrides of defaults by key
+}
+
+// NewManager creates a Manager counting usage in store and applying
+// limits to every key without limits of its own.
+func NewManager(store Store, limits Limits) *Manager {
+	return &Manager{store: store, defaults: limits, now: time.Now, limits: make(map[string]Limits)}
+}
+
+// SetLimits sets the limits of key, replacing the defaults for it.
+func (m *Manager) SetLimits(key string, limits Limits) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.limits[key] = limits
+}
+
+// Limits returns the limits of key.
+func (m *Manager) Limits(key string) Limits {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if limits, ok := m.limits[key]; ok {
+		return limits
+	}
+
+	return m.defaults
+}
+
+// Usage returns the usage of key in the current window.
+func (m *Manager) Usage(ctx context.Context, key string) (Usage, error) {
+	bucket, _ := m.bucket(key, m.Limits(key))
+
+	return m.store.Get(ctx, bucket)
+}
+
+// Check returns an ErrCodeQuotaExceeded error if key has reached one of
+// its limits.
+func (m *Manager) Check(ctx context.Context, key string) error {
+	limits := m.Limits(key)
+	if limits.MaxCost <= 0 && limits.MaxTokens <= 0 {
+		return nil
+	}
+
+	bucket, _ := m.bucket(key, limits)
+	usage, err := m.store.Get(ctx, bucket)
+	if err != nil {
+		return storeError(err)
+	}
+
+	return exceeded(key, limits, usage)
+}
+
+// Record adds the usage of result to key.
+func (m *Manager) Record(ctx context.Context, key string, result *claude.SDKResultMessage) error {
+	usage := Usage{
+		Cost:   result.TotalCost(),
+		Tokens: int64(result.Usage.InputTokens) + int64(result.Usage.OutputTokens),
+	}
+	if usage == (Usage{}) {
+		return nil
+	}
+
+	bucket, expires := m.bucket(key, m.Limits(key))
+	if _, err := m.store.Add(ctx, bucket, usage, expires); err != nil {
+		return storeError(err)
+	}
+
+	return nil
+}
+
+// Client returns c counting its results against key and refusing Query and
+// SendMessage calls once key has reached a limit. onError, if set,
+// receives failures to record usage, which don't affect the stream.
+func (m *Manager) Client(c claude.Client, key string, onError func(error)) claude.Client {
+	return &quotaClient{Client: c, manager: m, key: key, onError: onError}
+}
+
+// bucket returns the counter of key for the current window, and when the
+// window ends.
+func (m *Manager) bucket(key string, limits Limits) (string, time.Time) {
+	if limits.Window <= 0 {
+		return key, time.Time{}
+	}
+
+	start := m.now().Truncate(limits.Window)
+
+	return key + "@" + strconv.FormatInt(start.Unix(), 10), start.Add(limits.Window)
+}
+
+// exceeded returns an ErrCodeQuotaExceeded error if usage reaches limits.
+func exceeded(key string, limits Limits, usage Usage) error {
+	var what string
+	switch {
+	case limits.MaxCost > 0 && usage.Cost >= limits.MaxCost:
+		what = fmt.Sprintf("cost quota of %s USD (used %s)", limits.MaxCost, usage.Cost)
+	case limits.MaxTokens > 0 && usage.Tokens >= limits.MaxTokens:
+		what = fmt.Sprintf("token quota of %d (used %d)", limits.MaxTokens, usage.Tokens)
+	default:
+		return nil
+	}
+
+	return clauderrs.NewClientError(
+		clauderrs.ErrCodeQuotaExceeded,
+		fmt.Sprintf("%q has used its %s", key, what),
+		nil,
+	)
+}
+
+func storeError(err error) error {
+	return clauderrs.NewClientError(clauderrs.ErrCodeIOError, "failed to access quota store", err)
+}
+
+// quotaClient is the Client returned by Manager.Client.
+type quotaClient struct {
+	claude.Client
+	manager *Manager
+	key     string
+	onError func(error)
+}
+
+func (c *quotaClient) Query(ctx context.Context, prompt string) error {
+	if err := c.manager.Check(ctx, c.key); err != nil {
+		return err
+	}
+
+	return c.Client.Query(ctx, prompt)
+}
+
+func (c *quotaClient) SendMessage(ctx context.Context, content []claude.ContentBlock, sessionID string) error {
+	if err := c.manager.Check(ctx, c.key); err != nil {
+		return err
+	}
+
+	return c.Client.SendMessage(ctx, content, sessionID)
+}
+
+func (c *quotaClient) ReceiveMessages(ctx context.Context) (<-chan claude.SDKMessage, <-chan error) {
+	msgs, errs := c.Client.ReceiveMessages(ctx)
+
+	return c.record(ctx, msgs), errs
+}
+
+func (c *quotaClient) ReceiveResponse(ctx context.Context) <-chan claude.SDKMessage {
+	return c.record(ctx, c.Client.ReceiveResponse(ctx))
+}
+
+// record forwards in, recording each result first.
+func (c *quotaClient) record(ctx context.Context, in <-chan claude.SDKMessage) <-chan claude.SDKMessage {
+	out := make(chan claude.SDKMessage, messageBuffer)
+	go func() {
+		defer close(out)
+		for msg := range in {
+			if result, ok := msg.(*claude.SDKResultMessage); ok {
+				// Record even if the caller gave up on the turn
+				if err := c.manager.Record(context.WithoutCancel(ctx), c.key, result); err != nil && c.onError != nil {
+					c.onError(err)
+				}
+			}
+			select {
+			case out <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
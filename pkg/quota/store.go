@@ -0,0 +1,53 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store, counting usage for the clients of
+// one process.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]memoryBucket
+}
+
+type memoryBucket struct {
+	usage   Usage
+	expires time.Time
+}
+
+// NewMemoryStore creates an empty in-process Store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]memoryBucket)}
+}
+
+// Add adds usage to bucket, dropping buckets whose window has ended.
+func (s *MemoryStore) Add(_ context.Context, bucket string, usage Usage, expires time.Time) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for name, b := range s.buckets {
+		if !b.expires.IsZero() && now.After(b.expires) {
+			delete(s.buckets, name)
+		}
+	}
+
+	b := s.buckets[bucket]
+	b.usage.Cost += usage.Cost
+	b.usage.Tokens += usage.Tokens
+	b.expires = expires
+	s.buckets[bucket] = b
+
+	return b.usage, nil
+}
+
+// Get returns the total of bucket.
+func (s *MemoryStore) Get(_ context.Context, bucket string) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.buckets[bucket].usage, nil
+}
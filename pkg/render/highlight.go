@@ -0,0 +1,159 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+)
+
+// language describes how to highlight a language, line by line.
+type language struct {
+	keywords map[string]bool
+	literals map[string]bool
+	comment  string
+	quotes   string
+}
+
+var (
+	goLanguage = &language{
+		keywords: wordSet("break case chan const continue default defer else fallthrough for func go goto " +
+			"if import interface map package range return select struct switch type var"),
+		literals: wordSet("true false nil iota"),
+		comment:  "//",
+		quotes:   "\"'`",
+	}
+	pythonLanguage = &language{
+		keywords: wordSet("and as assert async await break class continue def del elif else except " +
+			"finally for from global if import in is lambda nonlocal not or pass raise return try while with yield"),
+		literals: wordSet("True False None self"),
+		comment:  "#",
+		quotes:   "\"'",
+	}
+	javascriptLanguage = &language{
+		keywords: wordSet("async await break case catch class const continue default delete do else " +
+			"export extends finally for from function if import in instanceof interface let new of " +
+			"return switch throw try type typeof var void while yield"),
+		literals: wordSet("true false null undefined this"),
+		comment:  "//",
+		quotes:   "\"'`",
+	}
+	shellLanguage = &language{
+		keywords: wordSet("case do done elif else esac export fi for function if in local return then until while"),
+		literals: wordSet("true false"),
+		comment:  "#",
+		quotes:   "\"'",
+	}
+	jsonLanguage = &language{
+		literals: wordSet("true false null"),
+		quotes:   "\"",
+	}
+	// plainLanguage highlights strings and numbers of unknown languages.
+	plainLanguage = &language{quotes: "\"'"}
+)
+
+// languages maps code fence info strings to languages.
+var languages = map[string]*language{
+	"go":         goLanguage,
+	"golang":     goLanguage,
+	"py":         pythonLanguage,
+	"python":     pythonLanguage,
+	"js":         javascriptLanguage,
+	"javascript": javascriptLanguage,
+	"jsx":        javascriptLanguage,
+	"ts":         javascriptLanguage,
+	"typescript": javascriptLanguage,
+	"tsx":        javascriptLanguage,
+	"sh":         shellLanguage,
+	"bash":       shellLanguage,
+	"shell":      shellLanguage,
+	"zsh":        shellLanguage,
+	"console":    shellLanguage,
+	"json":       jsonLanguage,
+	"jsonc":      jsonLanguage,
+}
+
+// wordSet splits a space-separated word list into a set.
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+
+	return set
+}
+
+// highlight colors keywords, literals, strings, numbers and line comments
+// in a line of code. Highlighting is per line, so strings and comments
+// spanning lines are only colored on their first line.
+func highlight(line, lang string, paint func(string, ...string) string) string {
+	if paint("x") == "x" {
+		return line
+	}
+
+	l, ok := languages[lang]
+	if !ok {
+		l = plainLanguage
+	}
+
+	var out strings.Builder
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		rest := string(runes[i:])
+
+		switch {
+		case l.comment != "" && strings.HasPrefix(rest, l.comment):
+			out.WriteString(paint(rest, ansiDim, ansiItalic))
+
+			return out.String()
+		case strings.ContainsRune(l.quotes, r):
+			end := closingQuote(runes, i)
+			out.WriteString(paint(string(runes[i:end]), ansiGreen))
+			i = end
+		case unicode.IsDigit(r):
+			end := i
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || unicode.IsLetter(runes[end]) || runes[end] == '.' || runes[end] == '_') {
+				end++
+			}
+			out.WriteString(paint(string(runes[i:end]), ansiYellow))
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			word := string(runes[i:end])
+			switch {
+			case l.keywords[word]:
+				out.WriteString(paint(word, ansiMagenta))
+			case l.literals[word]:
+				out.WriteString(paint(word, ansiYellow))
+			default:
+				out.WriteString(word)
+			}
+			i = end
+		default:
+			out.WriteRune(r)
+			i++
+		}
+	}
+
+	return out.String()
+}
+
+// closingQuote returns the index just past the quote closing the string
+// that opens at start, or the end of the line if it is unterminated.
+func closingQuote(runes []rune, start int) int {
+	quote := runes[start]
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+
+	return len(runes)
+}
@@ -0,0 +1,154 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ANSI styles.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiItalic  = "\x1b[3m"
+	ansiUnder   = "\x1b[4m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+)
+
+// ruleWidth is the width of rendered horizontal rules.
+const ruleWidth = 40
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	listPattern    = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	rulePattern    = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	codePattern    = regexp.MustCompile("`[^`]+`")
+	boldPattern    = regexp.MustCompile(`\*\*[^*]+\*\*`)
+)
+
+// paint wraps s in the given styles when colors are enabled.
+func (r *Renderer) paint(s string, styles ...string) string {
+	if !r.color || s == "" {
+		return s
+	}
+
+	return strings.Join(styles, "") + s + ansiReset
+}
+
+// markdown renders markdown incrementally, a line at a time. Text is
+// buffered until a newline so block structure such as headings and code
+// fences can be recognized from the start of each line.
+type markdown struct {
+	paint func(string, ...string) string
+
+	buf     strings.Builder
+	inFence bool
+	fence   string
+	lang    string
+}
+
+// write appends text and returns the rendering of any completed lines.
+func (m *markdown) write(text string) string {
+	m.buf.WriteString(text)
+
+	pending := m.buf.String()
+	end := strings.LastIndexByte(pending, '\n')
+	if end < 0 {
+		return ""
+	}
+
+	m.buf.Reset()
+	m.buf.WriteString(pending[end+1:])
+
+	var out strings.Builder
+	for _, line := range strings.Split(pending[:end], "\n") {
+		out.WriteString(m.line(line))
+		out.WriteByte('\n')
+	}
+
+	return out.String()
+}
+
+// flush renders the buffered partial line, if any.
+func (m *markdown) flush() string {
+	if m.buf.Len() == 0 {
+		return ""
+	}
+
+	line := m.buf.String()
+	m.buf.Reset()
+
+	return m.line(line) + "\n"
+}
+
+// line renders a single line.
+func (m *markdown) line(line string) string {
+	trimmed := strings.TrimSpace(line)
+
+	if m.inFence {
+		if strings.HasPrefix(trimmed, m.fence) && strings.Trim(trimmed, m.fence[:1]) == "" {
+			m.inFence = false
+
+			return m.paint("╰─", ansiDim)
+		}
+
+		return m.paint("│ ", ansiDim) + highlight(line, m.lang, m.paint)
+	}
+
+	for _, fence := range []string{"```", "~~~"} {
+		if lang, ok := strings.CutPrefix(trimmed, fence); ok {
+			m.inFence = true
+			m.fence = fence
+			m.lang = strings.ToLower(strings.TrimLeft(strings.TrimSpace(lang), fence[:1]))
+
+			return m.paint("╭─ "+m.lang, ansiDim)
+		}
+	}
+
+	if match := headingPattern.FindStringSubmatch(trimmed); match != nil {
+		if len(match[1]) == 1 {
+			return m.paint(match[2], ansiBold, ansiUnder, ansiMagenta)
+		}
+
+		return m.paint(match[2], ansiBold, ansiCyan)
+	}
+
+	if rulePattern.MatchString(line) {
+		return m.paint(strings.Repeat("─", ruleWidth), ansiDim)
+	}
+
+	if quote, ok := strings.CutPrefix(trimmed, ">"); ok {
+		return m.paint("│ ", ansiDim) + m.paint(m.inline(strings.TrimSpace(quote)), ansiItalic)
+	}
+
+	if match := listPattern.FindStringSubmatch(line); match != nil {
+		marker := match[2]
+		if strings.ContainsAny(marker, "-*+") {
+			marker = "•"
+		}
+
+		return match[1] + m.paint(marker, ansiYellow) + " " + m.inline(match[3])
+	}
+
+	return m.inline(line)
+}
+
+// inline styles inline code and bold spans. Without colors the markup is
+// left as written so it stays readable.
+func (m *markdown) inline(line string) string {
+	if m.paint("x") == "x" {
+		return line
+	}
+
+	line = codePattern.ReplaceAllStringFunc(line, func(code string) string {
+		return m.paint(strings.Trim(code, "`"), ansiCyan)
+	})
+
+	return boldPattern.ReplaceAllStringFunc(line, func(bold string) string {
+		return m.paint(strings.Trim(bold, "*"), ansiBold)
+	})
+}
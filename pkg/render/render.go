@@ -0,0 +1,447 @@
+// Package render renders Claude message streams to a terminal.
+//
+// A Renderer consumes SDK messages as they arrive and writes assistant
+// markdown (headings, lists, quotes and highlighted code fences), one-line
+// tool call and tool result summaries, a spinner while Claude is working,
+// and a cost footer when a response completes. Streamed text deltas are
+// rendered a line at a time, so partial output appears as it is generated.
+//
+// Colors and the spinner are enabled when the writer is a terminal and the
+// NO_COLOR environment variable is unset; both can be overridden:
+//
+//	r := render.New(os.Stdout)
+//	for msg := range client.ReceiveResponse(ctx) {
+//		r.Render(msg)
+//	}
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+const (
+	// spinnerInterval is how often Stream advances the spinner.
+	spinnerInterval = 100 * time.Millisecond
+	// summaryWidth bounds tool call and tool result summaries, in runes.
+	summaryWidth = 80
+	// clearLine returns the cursor to column 0 and erases the line.
+	clearLine = "\r\x1b[K"
+)
+
+// spinnerFrames are the frames of the working spinner.
+var spinnerFrames = []rune{'⠋', '⠙', '⠹', '⠸', '⠼', '⠴', '⠦', '⠧', '⠇', '⠏'}
+
+// Renderer writes a rendered message stream to an io.Writer.
+//
+// Renderer is safe for concurrent use, so Tick may be driven from a
+// separate goroutine while messages are rendered.
+type Renderer struct {
+	mu      sync.Mutex
+	w       io.Writer
+	color   bool
+	spinner bool
+	footer  bool
+
+	md *markdown
+	// streamed is set once text deltas have been rendered for the current
+	// assistant message, so the complete message is not printed again.
+	streamed bool
+	// tools maps tool_use IDs to tool names for result summaries.
+	tools map[string]string
+	// working is set while a response is in progress.
+	working bool
+	// spinning is set while a spinner frame is on screen.
+	spinning bool
+	frame    int
+	err      error
+}
+
+// New creates a Renderer writing to w. Colors and the spinner are enabled
+// when w is a terminal and NO_COLOR is unset; the cost footer is enabled.
+func New(w io.Writer) *Renderer {
+	tty := isTerminal(w) && os.Getenv("NO_COLOR") == ""
+	r := &Renderer{
+		w:       w,
+		color:   tty,
+		spinner: tty,
+		footer:  true,
+		tools:   make(map[string]string),
+	}
+	r.md = &markdown{paint: r.paint}
+
+	return r
+}
+
+// WithColor enables or disables ANSI colors.
+func (r *Renderer) WithColor(enabled bool) *Renderer {
+	r.color = enabled
+
+	return r
+}
+
+// WithSpinner enables or disables the working spinner.
+func (r *Renderer) WithSpinner(enabled bool) *Renderer {
+	r.spinner = enabled
+
+	return r
+}
+
+// WithFooter enables or disables the cost footer printed for results.
+func (r *Renderer) WithFooter(enabled bool) *Renderer {
+	r.footer = enabled
+
+	return r
+}
+
+// Render renders a single message. It returns the first error from the
+// underlying writer; once writing has failed, later calls do nothing.
+func (r *Renderer) Render(msg claude.SDKMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch m := msg.(type) {
+	case *claude.SDKSystemMessage:
+		r.renderSystem(m)
+	case *claude.SDKStreamEvent:
+		r.working = true
+		r.renderStreamEvent(m)
+	case *claude.SDKAssistantMessage:
+		r.working = true
+		r.renderAssistant(m)
+	case *claude.SDKUserMessage:
+		r.renderToolResults(m.Message.Content, m.ParentToolUseID != nil)
+	case *claude.SDKResultMessage:
+		r.working = false
+		r.renderResult(m)
+	}
+
+	return r.err
+}
+
+// Tick advances the spinner while a response is in progress. It is a no-op
+// when the spinner is disabled.
+func (r *Renderer) Tick() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.spinner || !r.working || r.err != nil {
+		return
+	}
+
+	frame := string(spinnerFrames[r.frame%len(spinnerFrames)])
+	r.frame++
+	r.spinning = true
+	_, r.err = fmt.Fprint(r.w, clearLine+r.paint(frame+" Working…", ansiDim))
+}
+
+// Flush writes any buffered partial line and clears the spinner.
+func (r *Renderer) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.write(r.md.flush())
+	r.clearSpinner()
+
+	return r.err
+}
+
+// Stream renders messages until msgs is closed, errs delivers an error, or
+// ctx is done, advancing the spinner while waiting. It accepts the channels
+// of ClaudeSDKClient.ReceiveMessages; errs may be nil, as when rendering
+// ReceiveResponse.
+func (r *Renderer) Stream(
+	ctx context.Context,
+	msgs <-chan claude.SDKMessage,
+	errs <-chan error,
+) error {
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+
+	r.mu.Lock()
+	r.working = true
+	r.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			_ = r.Flush()
+
+			return ctx.Err()
+		case <-ticker.C:
+			r.Tick()
+		case msg, ok := <-msgs:
+			if !ok || msg == nil {
+				return r.Flush()
+			}
+			if err := r.Render(msg); err != nil {
+				return err
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+
+				continue
+			}
+			if err != nil {
+				_ = r.Flush()
+
+				return err
+			}
+		}
+	}
+}
+
+// renderSystem renders the session's model on init.
+func (r *Renderer) renderSystem(m *claude.SDKSystemMessage) {
+	if m.Subtype != "init" {
+		return
+	}
+
+	var model string
+	if raw, ok := m.Data["model"]; ok {
+		_ = json.Unmarshal(raw, &model)
+	}
+	if model != "" {
+		r.write(r.paint("● "+model, ansiDim) + "\n")
+	}
+}
+
+// renderStreamEvent renders text deltas of the main conversation.
+// Subagent output is summarized by its tool calls instead.
+func (r *Renderer) renderStreamEvent(m *claude.SDKStreamEvent) {
+	if m.ParentToolUseID != nil {
+		return
+	}
+
+	switch evt := m.Event.(type) {
+	case claude.ContentBlockDeltaEvent:
+		if evt.Delta.TextDelta != nil {
+			r.streamed = true
+			r.write(r.md.write(*evt.Delta.TextDelta))
+		}
+	case claude.ContentBlockStopEvent, claude.MessageStopEvent:
+		r.write(r.md.flush())
+	}
+}
+
+// renderAssistant renders an assistant message's text, unless it was
+// already streamed, and summarizes its tool calls.
+func (r *Renderer) renderAssistant(m *claude.SDKAssistantMessage) {
+	nested := m.ParentToolUseID != nil
+	streamed := r.streamed
+	r.streamed = false
+
+	for _, block := range m.Message.Content {
+		switch b := block.(type) {
+		case claude.TextContentBlock:
+			if !nested && !streamed {
+				r.write(r.md.write(b.Text))
+				r.write(r.md.flush())
+			}
+		case claude.TextBlock:
+			if !nested && !streamed {
+				r.write(r.md.write(b.Text))
+				r.write(r.md.flush())
+			}
+		case claude.ThinkingBlock:
+			if !nested {
+				r.write(r.paint("✻ "+truncate(firstLine(b.Thinking)), ansiDim, ansiItalic) + "\n")
+			}
+		case claude.ToolUseContentBlock:
+			r.tools[b.ID] = b.Name
+			r.write(indent(nested) + r.paint("⏺ ", ansiGreen) +
+				r.paint(b.Name, ansiBold) + r.paint("("+ToolSummary(b.Name, b.Input)+")", ansiDim) + "\n")
+		}
+	}
+}
+
+// renderToolResults summarizes tool results in a user message.
+func (r *Renderer) renderToolResults(blocks []claude.ContentBlock, nested bool) {
+	for _, block := range blocks {
+		result, ok := block.(claude.ToolResultContentBlock)
+		if !ok {
+			continue
+		}
+
+		name := r.tools[result.ToolUseID]
+		delete(r.tools, result.ToolUseID)
+
+		summary := truncate(firstLine(resultText(result.Content)))
+		if summary == "" {
+			summary = "done"
+		}
+		if result.IsError {
+			summary = r.paint("error: "+summary, ansiRed)
+		} else {
+			summary = r.paint(summary, ansiDim)
+		}
+		if name != "" {
+			summary = r.paint(name+" ", ansiDim) + summary
+		}
+
+		r.write(indent(nested) + "  ⎿ " + summary + "\n")
+	}
+}
+
+// renderResult renders the cost footer.
+func (r *Renderer) renderResult(m *claude.SDKResultMessage) {
+	r.write(r.md.flush())
+	if !r.footer {
+		return
+	}
+
+	status := r.paint(m.Subtype, ansiGreen)
+	if m.IsError || m.Subtype != "success" {
+		status = r.paint(m.Subtype, ansiRed)
+	}
+
+	turns := "turns"
+	if m.NumTurns == 1 {
+		turns = "turn"
+	}
+	details := fmt.Sprintf(
+		" · %d %s · %s · $%.4f · %d in / %d out tokens",
+		m.NumTurns,
+		turns,
+		(time.Duration(m.DurationMS) * time.Millisecond).Round(100*time.Millisecond),
+		m.TotalCostUSD,
+		m.Usage.InputTokens+m.Usage.CacheReadInputTokens+m.Usage.CacheCreationInputTokens,
+		m.Usage.OutputTokens,
+	)
+
+	r.write("\n" + r.paint("── ", ansiDim) + status + r.paint(details, ansiDim) + "\n")
+}
+
+// write clears the spinner and writes s, recording the first error.
+func (r *Renderer) write(s string) {
+	if s == "" || r.err != nil {
+		return
+	}
+
+	r.clearSpinner()
+	_, r.err = io.WriteString(r.w, s)
+}
+
+// clearSpinner erases the spinner line if a frame is on screen.
+func (r *Renderer) clearSpinner() {
+	if !r.spinning || r.err != nil {
+		return
+	}
+
+	r.spinning = false
+	_, r.err = io.WriteString(r.w, clearLine)
+}
+
+// ToolSummary returns a one-line summary of a tool call's input: the
+// command, path, pattern, URL or query it acts on.
+func ToolSummary(toolName string, input claude.JSONValue) string {
+	var fields map[string]any
+	if err := json.Unmarshal(input, &fields); err != nil {
+		return ""
+	}
+
+	for _, key := range summaryKeys(toolName) {
+		if value, ok := fields[key].(string); ok && value != "" {
+			return truncate(firstLine(value))
+		}
+	}
+
+	if todos, ok := fields["todos"].([]any); ok {
+		return fmt.Sprintf("%d todos", len(todos))
+	}
+
+	return ""
+}
+
+// summaryKeys returns the input fields that best describe a tool call.
+func summaryKeys(toolName string) []string {
+	switch toolName {
+	case "Bash":
+		return []string{"command"}
+	case "Read", "Write", "Edit", "MultiEdit":
+		return []string{"file_path"}
+	case "NotebookEdit", "NotebookRead":
+		return []string{"notebook_path"}
+	case "Glob", "Grep":
+		return []string{"pattern"}
+	case "WebFetch":
+		return []string{"url"}
+	case "WebSearch":
+		return []string{"query"}
+	case "Agent", "Task":
+		return []string{"description"}
+	default:
+		return []string{"file_path", "path", "command", "url", "query", "pattern", "description"}
+	}
+}
+
+// resultText returns the text of a tool result.
+func resultText(content *claude.ToolResultContent) string {
+	if content == nil {
+		return ""
+	}
+	if content.Text != nil {
+		return *content.Text
+	}
+	for _, block := range content.Blocks {
+		switch b := block.(type) {
+		case claude.TextContentBlock:
+			return b.Text
+		case claude.TextBlock:
+			return b.Text
+		}
+	}
+
+	return ""
+}
+
+// firstLine returns the first non-blank line of s, trimmed.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+
+	return ""
+}
+
+// truncate shortens s to summaryWidth runes.
+func truncate(s string) string {
+	runes := []rune(s)
+	if len(runes) <= summaryWidth {
+		return s
+	}
+
+	return string(runes[:summaryWidth-1]) + "…"
+}
+
+// indent returns the prefix for subagent output.
+func indent(nested bool) string {
+	if nested {
+		return "  "
+	}
+
+	return ""
+}
+
+// isTerminal reports whether w is a character device.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,224 @@
+// Package verify provides a hook preset that checks the agent's file
+// changes by running a command, such as a build or a test suite, after
+// each Edit or Write. When the command fails, its output is fed back to
+// the agent so it can correct the change before moving on.
+//
+//	v, err := verify.New(verify.Config{Command: []string{"go", "test", "./..."}})
+//	if err != nil {
+//		return err
+//	}
+//	opts := claude.NewOptions().
+//		WithHook(claude.HookEventPostToolUse, v.Matcher()).
+//		Build()
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const (
+	// defaultTimeout bounds a verification run.
+	defaultTimeout = 5 * time.Minute
+	// defaultMaxOutput is how many bytes of output are fed back.
+	defaultMaxOutput = 8 << 10
+	// hookTimeoutMargin is added to Timeout for the CLI's hook timeout.
+	hookTimeoutMargin = 30 * time.Second
+	// editToolMatcher matches the tools that change files.
+	editToolMatcher = "^(Edit|MultiEdit|Write|NotebookEdit)$"
+	// waitDelay bounds the wait for output after a run is cancelled.
+	waitDelay = 5 * time.Second
+)
+
+// Config configures a Verifier.
+type Config struct {
+	// Command is the program and arguments to run, e.g.
+	// []string{"go", "test", "./..."}.
+	Command []string
+	// Dir is the directory to run in. It defaults to the session's
+	// working directory.
+	Dir string
+	// Timeout bounds each run; a run that times out counts as failed. It
+	// defaults to five minutes.
+	Timeout time.Duration
+	// MaxOutput is how many bytes of output, from the end, are fed back
+	// to the agent. It defaults to 8 KiB.
+	MaxOutput int
+	// Runner runs the command. It defaults to ExecRunner.
+	Runner Runner
+}
+
+// Result is the outcome of a verification run.
+type Result struct {
+	// ExitCode is the command's exit status; zero means it passed.
+	ExitCode int
+	// Output is the combined stdout and stderr.
+	Output []byte
+}
+
+// Runner runs verification commands. Implement it to run them somewhere
+// isolated, such as a container. Run returns an error only when the
+// command could not be run; a command that fails is reported through
+// Result.ExitCode.
+type Runner interface {
+	Run(ctx context.Context, dir string, command []string) (*Result, error)
+}
+
+// RunnerFunc adapts a function to the Runner interface.
+type RunnerFunc func(ctx context.Context, dir string, command []string) (*Result, error)
+
+// Run calls f.
+func (f RunnerFunc) Run(ctx context.Context, dir string, command []string) (*Result, error) {
+	return f(ctx, dir, command)
+}
+
+// ExecRunner runs commands as local processes with no standard input.
+// The process is killed when the run's context ends.
+type ExecRunner struct {
+	// Env, if set, replaces the environment the command runs with, so
+	// secrets in the SDK's own environment need not reach it.
+	Env []string
+}
+
+// Run runs command in dir.
+func (r ExecRunner) Run(ctx context.Context, dir string, command []string) (*Result, error) {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = dir
+	cmd.Env = r.Env
+	cmd.WaitDelay = waitDelay
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+
+	return &Result{ExitCode: cmd.ProcessState.ExitCode(), Output: output.Bytes()}, nil
+}
+
+// Verifier runs the verification command after file changes. Runs are
+// serialized, so parallel edits don't start overlapping builds.
+type Verifier struct {
+	config Config
+
+	mu sync.Mutex
+}
+
+// New creates a Verifier. It returns a ValidationError if no command is
+// configured.
+func New(config Config) (*Verifier, error) {
+	if len(config.Command) == 0 || config.Command[0] == "" {
+		return nil, clauderrs.NewValidationError(
+			clauderrs.ErrCodeMissingField,
+			"verify command is required",
+			nil,
+			"Command",
+			config.Command,
+		)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.MaxOutput <= 0 {
+		config.MaxOutput = defaultMaxOutput
+	}
+	if config.Runner == nil {
+		config.Runner = ExecRunner{}
+	}
+
+	return &Verifier{config: config}, nil
+}
+
+// Matcher returns a PostToolUse hook matcher running v after each Edit,
+// MultiEdit, Write or NotebookEdit. Its timeout leaves the CLI room to
+// wait for a full run.
+func (v *Verifier) Matcher() claude.HookCallbackMatcher {
+	matcher := editToolMatcher
+	timeout := int((v.config.Timeout + hookTimeoutMargin).Milliseconds())
+
+	return claude.HookCallbackMatcher{
+		Matcher: &matcher,
+		Hooks:   []claude.HookCallback{v.Hook},
+		Timeout: &timeout,
+	}
+}
+
+// Hook is the PostToolUse hook callback. It runs the command and, if it
+// fails, blocks with the failure output as the reason, which the CLI
+// passes to the agent. Inputs other than PostToolUse are ignored.
+func (v *Verifier) Hook(
+	ctx context.Context,
+	input claude.HookInput,
+	_ *string,
+) (claude.HookJSONOutput, error) {
+	post, ok := input.(claude.PostToolUseHookInput)
+	if !ok {
+		return claude.SyncHookOutput{}, nil
+	}
+
+	dir := v.config.Dir
+	if dir == "" {
+		dir = post.Cwd()
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	runCtx, cancel := context.WithTimeout(ctx, v.config.Timeout)
+	defer cancel()
+
+	result, err := v.config.Runner.Run(runCtx, dir, v.config.Command)
+	if err != nil {
+		return nil, clauderrs.NewCallbackError(
+			clauderrs.ErrCodeHookFailed,
+			fmt.Sprintf("failed to run verify command %q", strings.Join(v.config.Command, " ")),
+			err,
+			"verify",
+			false,
+		)
+	}
+
+	status := fmt.Sprintf("failed with exit code %d", result.ExitCode)
+	switch {
+	case runCtx.Err() != nil && ctx.Err() == nil:
+		status = fmt.Sprintf("timed out after %s", v.config.Timeout)
+	case result.ExitCode == 0:
+		return claude.SyncHookOutput{}, nil
+	}
+
+	return claude.Block(fmt.Sprintf(
+		"Verification command `%s` %s after the %s call. Fix the problem before continuing.\n\n%s",
+		strings.Join(v.config.Command, " "),
+		status,
+		post.ToolName,
+		v.tail(result.Output),
+	)), nil
+}
+
+// tail returns the last MaxOutput bytes of output.
+func (v *Verifier) tail(output []byte) string {
+	text := strings.TrimSpace(string(output))
+	if len(text) <= v.config.MaxOutput {
+		return text
+	}
+
+	cut := len(text) - v.config.MaxOutput
+	if i := strings.IndexByte(text[cut:], '\n'); i >= 0 {
+		cut += i + 1
+	}
+
+	return fmt.Sprintf("[output truncated: showing the last %d of %d bytes]\n%s", len(text)-cut, len(text), text[cut:])
+}
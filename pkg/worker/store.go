@@ -0,0 +1,109 @@
+package worker
+
+import (
+	"context"
+	"sync"
+)
+
+// Store queues tasks and persists job state. Implementations must be safe
+// for concurrent use by the pool's workers.
+type Store interface {
+	// Enqueue adds a task to the queue.
+	Enqueue(ctx context.Context, task AgentTask) error
+	// Dequeue removes and returns the next task, blocking until one is
+	// available or ctx is done.
+	Dequeue(ctx context.Context) (AgentTask, error)
+	// Save records the current state of a job.
+	Save(ctx context.Context, job *Job) error
+	// Load returns the job for a task ID, or nil if there is none.
+	Load(ctx context.Context, id string) (*Job, error)
+}
+
+// MemoryStore is an in-process Store. Queued tasks and jobs are lost when
+// the process exits; use a durable Store for at-least-once delivery.
+type MemoryStore struct {
+	mu    sync.Mutex
+	queue []AgentTask
+	jobs  map[string]*Job
+	ready chan struct{}
+}
+
+// NewMemoryStore creates an empty in-process Store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs:  make(map[string]*Job),
+		ready: make(chan struct{}, 1),
+	}
+}
+
+// Enqueue adds a task to the queue.
+func (s *MemoryStore) Enqueue(_ context.Context, task AgentTask) error {
+	s.mu.Lock()
+	s.queue = append(s.queue, task)
+	s.mu.Unlock()
+	s.signal()
+
+	return nil
+}
+
+// Dequeue removes and returns the oldest queued task, blocking until one
+// is available or ctx is done.
+func (s *MemoryStore) Dequeue(ctx context.Context) (AgentTask, error) {
+	for {
+		s.mu.Lock()
+		if len(s.queue) > 0 {
+			task := s.queue[0]
+			s.queue = s.queue[1:]
+			more := len(s.queue) > 0
+			s.mu.Unlock()
+
+			// Pass the wakeup on to another waiting worker
+			if more {
+				s.signal()
+			}
+
+			return task, nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.ready:
+		case <-ctx.Done():
+			return AgentTask{}, ctx.Err()
+		}
+	}
+}
+
+// Save records a copy of the job.
+func (s *MemoryStore) Save(_ context.Context, job *Job) error {
+	saved := *job
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[job.Task.ID] = &saved
+
+	return nil
+}
+
+// Load returns a copy of the job for a task ID, or nil if there is none.
+func (s *MemoryStore) Load(_ context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	loaded := *job
+
+	return &loaded, nil
+}
+
+// signal wakes one waiting Dequeue without blocking.
+func (s *MemoryStore) signal() {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,368 @@
+// Package worker runs agent tasks in the background.
+//
+// A Pool pulls AgentTask jobs from a Store, runs each in a fresh
+// ClaudeSDKClient session, retries transient failures within the task's
+// budget, and saves the job's status, result and transcript back to the
+// Store. Submit tasks from request handlers and poll Store.Load, or watch
+// OnProgress, to report on them:
+//
+//	store := worker.NewMemoryStore()
+//	pool := &worker.Pool{Concurrency: 4, Store: store}
+//	go pool.Run(ctx)
+//
+//	id, err := pool.Submit(ctx, worker.AgentTask{Prompt: "Summarize the changelog"})
+package worker
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultMaxAttempts is how many times a task is tried when neither
+	// the task nor the pool sets a limit.
+	defaultMaxAttempts = 3
+	// defaultRetryDelay is the wait before the first retry.
+	defaultRetryDelay = time.Second
+)
+
+// Status is the lifecycle state of a job.
+type Status string
+
+// Job statuses.
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// AgentTask is a unit of work for the pool.
+type AgentTask struct {
+	// ID identifies the task; Submit assigns one if empty.
+	ID     string
+	Prompt string
+	// Options configures the session. Each attempt runs with a copy;
+	// nil uses default options.
+	Options *claude.Options
+	// Tags are free-form labels carried through to the job.
+	Tags []string
+	// MaxAttempts bounds how many times the task is tried. Zero uses
+	// Pool.MaxAttempts.
+	MaxAttempts int
+	// MaxBudgetUsd caps the total cost of all attempts. Each attempt runs
+	// with Options.MaxBudgetUsd lowered to what remains. Zero means no cap.
+	MaxBudgetUsd float64
+}
+
+// Job is the persisted state of a task.
+type Job struct {
+	Task     AgentTask
+	Status   Status
+	Attempts int
+	// Cost is the total cost of all attempts, summed exactly.
+	Cost claude.USD
+	// Result is the result message of the last attempt, if it sent one.
+	Result *claude.SDKResultMessage
+	// Transcript holds the last attempt's frames as JSON Lines, in the
+	// format of ClaudeSDKClient.TeeJSONL; load it with debugger.Load.
+	Transcript []byte
+	// Err describes why the last attempt failed.
+	Err       string
+	UpdatedAt time.Time
+}
+
+// Progress reports a job status change or a message received while a task
+// runs.
+type Progress struct {
+	TaskID  string
+	Attempt int
+	Status  Status
+	// Message is the received message, or nil for status changes.
+	Message claude.SDKMessage
+	// Err is the attempt's error when it failed.
+	Err error
+}
+
+// ClientFactory creates the client for one attempt.
+type ClientFactory func(opts *claude.Options) (*claude.ClaudeSDKClient, error)
+
+// Pool runs tasks from a Store with a fixed number of workers.
+type Pool struct {
+	// Concurrency is the number of tasks run at once. Zero means one.
+	Concurrency int
+	// Store queues tasks and persists jobs. Required.
+	Store Store
+	// ClientFactory creates clients. Nil uses claude.NewClient.
+	ClientFactory ClientFactory
+	// MaxAttempts is the default per-task attempt limit. Zero means 3.
+	MaxAttempts int
+	// RetryDelay is the wait before the first retry; it doubles for each
+	// later retry. Zero means one second.
+	RetryDelay time.Duration
+	// OnProgress, if set, is called from worker goroutines for status
+	// changes and every received message.
+	OnProgress func(Progress)
+}
+
+// Submit saves a pending job for the task and queues it. It returns the
+// task ID.
+func (p *Pool) Submit(ctx context.Context, task AgentTask) (string, error) {
+	if err := p.validate(); err != nil {
+		return "", err
+	}
+	if task.ID == "" {
+		task.ID = uuid.New().String()
+	}
+
+	job := &Job{Task: task, Status: StatusPending, UpdatedAt: time.Now()}
+	if err := p.Store.Save(ctx, job); err != nil {
+		return "", err
+	}
+	if err := p.Store.Enqueue(ctx, task); err != nil {
+		return "", err
+	}
+
+	return task.ID, nil
+}
+
+// Run processes queued tasks until ctx is cancelled, then waits for the
+// workers to stop and returns ctx.Err(). Tasks interrupted by the
+// cancellation are queued again so another run picks them up.
+func (p *Pool) Run(ctx context.Context) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for range max(p.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				task, err := p.Store.Dequeue(ctx)
+				if err != nil {
+					return
+				}
+				p.execute(ctx, task)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// validate checks the pool's required fields.
+func (p *Pool) validate() error {
+	if p.Store == nil {
+		return clauderrs.NewValidationError(
+			clauderrs.ErrCodeMissingField,
+			"worker pool requires a Store",
+			nil,
+			"Store",
+			nil,
+		)
+	}
+
+	return nil
+}
+
+// execute runs a task to completion, retrying transient failures.
+func (p *Pool) execute(ctx context.Context, task AgentTask) {
+	// Store writes must land even when ctx is cancelled mid-task
+	saveCtx := context.WithoutCancel(ctx)
+
+	job := &Job{Task: task}
+	if saved, err := p.Store.Load(saveCtx, task.ID); err == nil && saved != nil {
+		job = saved
+		job.Task = task
+	}
+	attempts := task.MaxAttempts
+	if attempts <= 0 {
+		attempts = p.MaxAttempts
+	}
+	if attempts <= 0 {
+		attempts = defaultMaxAttempts
+	}
+	delay := p.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+
+	// Attempts count across runs, so a requeued task keeps its history
+	for {
+		job.Attempts++
+		p.update(saveCtx, job, StatusRunning, nil)
+
+		result, transcript, cost, err := p.attempt(ctx, task, job.Cost, job.Attempts)
+		job.Cost += cost
+		job.Result = result
+		job.Transcript = transcript
+
+		if ctx.Err() != nil {
+			// Shutting down: hand the task back to the queue
+			p.update(saveCtx, job, StatusPending, nil)
+			_ = p.Store.Enqueue(saveCtx, task)
+
+			return
+		}
+		if err == nil {
+			job.Err = ""
+			p.update(saveCtx, job, StatusSucceeded, nil)
+
+			return
+		}
+
+		job.Err = err.Error()
+		budgetLeft := task.MaxBudgetUsd <= 0 || job.Cost < claude.USDFromFloat(task.MaxBudgetUsd)
+		// Task errors reported in a result are not retried
+		if job.Attempts >= attempts || !budgetLeft || !clauderrs.IsRetryable(err) {
+			p.update(saveCtx, job, StatusFailed, err)
+
+			return
+		}
+		p.update(saveCtx, job, StatusPending, err)
+
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			_ = p.Store.Enqueue(saveCtx, task)
+
+			return
+		}
+	}
+}
+
+// attempt runs one session for the task and returns its result,
+// transcript, cost and error. A result reporting an error is returned
+// along with an error describing it.
+func (p *Pool) attempt(
+	ctx context.Context,
+	task AgentTask,
+	spent claude.USD,
+	attempt int,
+) (*claude.SDKResultMessage, []byte, claude.USD, error) {
+	opts := claude.Options{}
+	if task.Options != nil {
+		opts = *task.Options
+	}
+	if task.MaxBudgetUsd > 0 {
+		remaining := (claude.USDFromFloat(task.MaxBudgetUsd) - spent).Float64()
+		if opts.MaxBudgetUsd <= 0 || remaining < opts.MaxBudgetUsd {
+			opts.MaxBudgetUsd = remaining
+		}
+	}
+
+	factory := p.ClientFactory
+	if factory == nil {
+		factory = claude.NewClient
+	}
+	client, err := factory(&opts)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	transcript := &syncBuffer{}
+	client.TeeJSONL(transcript)
+
+	result, err := p.converse(ctx, client, task, attempt)
+	_ = client.Close()
+
+	var cost claude.USD
+	if result != nil {
+		cost = result.TotalCost()
+	} else if partial, ok := claude.AbortPartial(err); ok && partial.Result != nil {
+		cost = partial.Result.TotalCost()
+	}
+
+	return result, transcript.Bytes(), cost, err
+}
+
+// converse sends the task's prompt and reads the response.
+func (p *Pool) converse(
+	ctx context.Context,
+	client *claude.ClaudeSDKClient,
+	task AgentTask,
+	attempt int,
+) (*claude.SDKResultMessage, error) {
+	if err := client.Query(ctx, task.Prompt); err != nil {
+		return nil, err
+	}
+
+	var result *claude.SDKResultMessage
+	for msg := range client.ReceiveResponse(ctx) {
+		if r, ok := msg.(*claude.SDKResultMessage); ok {
+			result = r
+		}
+		p.progress(Progress{TaskID: task.ID, Attempt: attempt, Status: StatusRunning, Message: msg})
+	}
+	if err := client.Err(); err != nil {
+		return result, err
+	}
+
+	switch {
+	case result == nil:
+		return nil, clauderrs.NewProcessError(
+			clauderrs.ErrCodeProcessExited,
+			"session ended without a result",
+			nil,
+			-1,
+			"",
+		)
+	case result.IsError:
+		return result, clauderrs.NewClientError(
+			clauderrs.ErrCodeInvalidState,
+			"task failed: "+result.Subtype,
+			nil,
+		).WithSessionID(result.SessionID())
+	}
+
+	return result, nil
+}
+
+// update saves the job with a new status and reports the change.
+func (p *Pool) update(ctx context.Context, job *Job, status Status, err error) {
+	job.Status = status
+	job.UpdatedAt = time.Now()
+	_ = p.Store.Save(ctx, job)
+
+	p.progress(Progress{TaskID: job.Task.ID, Attempt: job.Attempts, Status: status, Err: err})
+}
+
+// progress reports to OnProgress, if set.
+func (p *Pool) progress(progress Progress) {
+	if p.OnProgress != nil {
+		p.OnProgress(progress)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for the client's I/O goroutines to
+// write while the worker reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// Bytes returns a copy of the buffered data.
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return bytes.Clone(b.buf.Bytes())
+}
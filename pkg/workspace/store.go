@@ -0,0 +1,116 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrVersionMismatch is returned by ObjectStore writes whose expected
+// version no longer matches the object, because it changed or was
+// created or deleted since it was read.
+var ErrVersionMismatch = errors.New("object version mismatch")
+
+// Object is an object in an ObjectStore.
+type Object struct {
+	// Key is the object's slash-separated path below the store's prefix.
+	Key string
+	// Version identifies the object's current content, such as an S3 ETag
+	// or a GCS generation.
+	Version string
+}
+
+// ObjectStore is a prefix of a bucket in object storage such as S3 or GCS.
+// Adapt the provider's client to it, mapping versions to its conditional
+// requests: If-Match and If-None-Match for S3, ifGenerationMatch for GCS.
+// Implementations must be safe for concurrent use.
+type ObjectStore interface {
+	// List returns the objects below the prefix. Keys ending in a slash
+	// are taken as directory markers and skipped.
+	List(ctx context.Context) ([]Object, error)
+	// Get returns the content and version of the object key.
+	Get(ctx context.Context, key string) ([]byte, string, error)
+	// Put writes the object key if its version is ifVersion, or if it
+	// doesn't exist when ifVersion is empty, and returns its new version.
+	// Otherwise it fails with ErrVersionMismatch.
+	Put(ctx context.Context, key string, data []byte, ifVersion string) (string, error)
+	// Delete removes the object key if its version is ifVersion, failing
+	// with ErrVersionMismatch otherwise.
+	Delete(ctx context.Context, key string, ifVersion string) error
+}
+
+// MemoryStore is an ObjectStore held in memory, for tests and local runs.
+// Versions count the writes to the store.
+type MemoryStore struct {
+	mu      sync.Mutex
+	objects map[string]memoryObject
+	writes  int
+}
+
+type memoryObject struct {
+	data    []byte
+	version string
+}
+
+// NewMemoryStore creates an empty in-memory ObjectStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: make(map[string]memoryObject)}
+}
+
+// List returns the objects, sorted by key.
+func (s *MemoryStore) List(_ context.Context) ([]Object, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objects := make([]Object, 0, len(s.objects))
+	for key, object := range s.objects {
+		objects = append(objects, Object{Key: key, Version: object.version})
+	}
+	slices.SortFunc(objects, func(a, b Object) int { return strings.Compare(a.Key, b.Key) })
+
+	return objects, nil
+}
+
+// Get returns the content and version of the object key.
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	object, ok := s.objects[key]
+	if !ok {
+		return nil, "", errors.New("object " + key + " not found")
+	}
+
+	return slices.Clone(object.data), object.version, nil
+}
+
+// Put writes the object key if its version is ifVersion.
+func (s *MemoryStore) Put(_ context.Context, key string, data []byte, ifVersion string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.objects[key].version != ifVersion {
+		return "", ErrVersionMismatch
+	}
+	s.writes++
+	version := strconv.Itoa(s.writes)
+	s.objects[key] = memoryObject{data: slices.Clone(data), version: version}
+
+	return version, nil
+}
+
+// Delete removes the object key if its version is ifVersion.
+func (s *MemoryStore) Delete(_ context.Context, key string, ifVersion string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.objects[key].version != ifVersion {
+		return ErrVersionMismatch
+	}
+	delete(s.objects, key)
+
+	return nil
+}
@@ -0,0 +1,299 @@
+// Package workspace runs agents on projects kept in object storage.
+//
+// Materialize downloads the objects below a bucket prefix, through an
+// ObjectStore adapting an S3 or GCS client, into a local directory the
+// agent works in. Sync uploads the agent's changes back, using the
+// versions seen when the workspace was materialized to detect objects
+// changed remotely in the meantime, which are reported as conflicts
+// instead of being overwritten:
+//
+//	ws, err := workspace.Materialize(ctx, store, "")
+//	if err != nil {
+//		return err
+//	}
+//	defer ws.Close()
+//
+//	client, err := claude.NewClient(&claude.Options{Cwd: ws.Dir()})
+//	if err != nil {
+//		return err
+//	}
+//	synced := ws.Client(client, func(report *workspace.SyncReport, err error) {
+//		// Log the report, or alert on conflicts
+//	})
+package workspace
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// messageBuffer is the buffer of the streams returned by Workspace.Client.
+const messageBuffer = 16
+
+// Workspace is a local copy of an ObjectStore's objects.
+type Workspace struct {
+	store ObjectStore
+	dir   string
+	temp  bool // dir was created by Materialize and is removed by Close
+
+	mu       sync.Mutex
+	baseline map[string]syncedObject // By key, as last downloaded or uploaded
+}
+
+// syncedObject is the state of an object when the workspace last synced
+// it.
+type syncedObject struct {
+	version string
+	sum     [sha256.Size]byte
+}
+
+// Conflict is a change Sync didn't upload because the object changed
+// remotely since the workspace last synced it.
+type Conflict struct {
+	Key string
+	// Deleted is set when the change is a local deletion.
+	Deleted bool
+}
+
+// SyncReport describes what a Sync did.
+type SyncReport struct {
+	// Uploaded are the keys of the objects created or updated.
+	Uploaded []string
+	// Deleted are the keys of the objects removed.
+	Deleted []string
+	// Conflicts are the changes left local. Their files are kept, so they
+	// can be reconciled by hand or by another turn.
+	Conflicts []Conflict
+}
+
+// Materialize downloads the objects of store into dir, creating it if
+// needed. An empty dir uses a new temporary directory, removed by Close.
+func Materialize(ctx context.Context, store ObjectStore, dir string) (*Workspace, error) {
+	w := &Workspace{store: store, dir: dir, baseline: make(map[string]syncedObject)}
+	if dir == "" {
+		temp, err := os.MkdirTemp("", "claude-workspace-*")
+		if err != nil {
+			return nil, clauderrs.NewClientError(clauderrs.ErrCodeIOError, "failed to create workspace directory", err)
+		}
+		w.dir, w.temp = temp, true
+	} else if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, clauderrs.NewValidationError(
+			clauderrs.ErrCodeInvalidFormat,
+			fmt.Sprintf("failed to create workspace directory %s", dir),
+			err,
+			"dir",
+			dir,
+		)
+	}
+
+	if err := w.download(ctx); err != nil {
+		_ = w.Close()
+
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Dir returns the directory holding the workspace, to use as Options.Cwd.
+func (w *Workspace) Dir() string {
+	return w.dir
+}
+
+// Sync uploads the files created or changed since the workspace last
+// synced and deletes the objects whose files were removed. Changes to
+// objects that changed remotely in the meantime are reported as
+// conflicts; remote changes to files left untouched locally are not
+// downloaded. An error means the store or the directory failed, and the
+// report covers what was synced before.
+func (w *Workspace) Sync(ctx context.Context) (*SyncReport, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	report := &SyncReport{}
+	local := make(map[string]bool)
+	err := filepath.WalkDir(w.dir, func(native string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(w.dir, native)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		local[key] = true
+
+		data, err := os.ReadFile(native)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		base, synced := w.baseline[key]
+		if synced && base.sum == sum {
+			return nil
+		}
+
+		version, err := w.store.Put(ctx, key, data, base.version)
+		switch {
+		case errors.Is(err, ErrVersionMismatch):
+			report.Conflicts = append(report.Conflicts, Conflict{Key: key})
+		case err != nil:
+			return storeError("upload", key, err)
+		default:
+			w.baseline[key] = syncedObject{version: version, sum: sum}
+			report.Uploaded = append(report.Uploaded, key)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return report, wrapLocalError(err)
+	}
+
+	var removed []string
+	for key := range w.baseline {
+		if !local[key] {
+			removed = append(removed, key)
+		}
+	}
+	slices.Sort(removed)
+	for _, key := range removed {
+		err := w.store.Delete(ctx, key, w.baseline[key].version)
+		switch {
+		case errors.Is(err, ErrVersionMismatch):
+			report.Conflicts = append(report.Conflicts, Conflict{Key: key, Deleted: true})
+		case err != nil:
+			return report, storeError("delete", key, err)
+		default:
+			delete(w.baseline, key)
+			report.Deleted = append(report.Deleted, key)
+		}
+	}
+
+	return report, nil
+}
+
+// Client returns c syncing the workspace whenever a result arrives, before
+// the result is delivered, so a caller seeing it knows the turn's changes
+// were uploaded or reported. onSync, if set, receives each sync's outcome.
+func (w *Workspace) Client(c claude.Client, onSync func(*SyncReport, error)) claude.Client {
+	return &syncingClient{Client: c, workspace: w, onSync: onSync}
+}
+
+// Close removes the workspace directory if Materialize created it. It
+// doesn't sync.
+func (w *Workspace) Close() error {
+	if !w.temp {
+		return nil
+	}
+
+	return os.RemoveAll(w.dir)
+}
+
+// download writes the store's objects into the directory.
+func (w *Workspace) download(ctx context.Context) error {
+	objects, err := w.store.List(ctx)
+	if err != nil {
+		return clauderrs.NewClientError(clauderrs.ErrCodeIOError, "failed to list workspace objects", err)
+	}
+
+	for _, object := range objects {
+		if object.Key == "" || object.Key[len(object.Key)-1] == '/' {
+			continue
+		}
+		if !fs.ValidPath(object.Key) {
+			return clauderrs.NewValidationError(
+				clauderrs.ErrCodeInvalidFormat,
+				fmt.Sprintf("object key %q can't be stored in a workspace", object.Key),
+				nil,
+				"key",
+				object.Key,
+			)
+		}
+
+		data, version, err := w.store.Get(ctx, object.Key)
+		if err != nil {
+			return storeError("download", object.Key, err)
+		}
+		native := filepath.Join(w.dir, filepath.FromSlash(object.Key))
+		if err := os.MkdirAll(filepath.Dir(native), 0o755); err != nil {
+			return wrapLocalError(err)
+		}
+		if err := os.WriteFile(native, data, 0o644); err != nil {
+			return wrapLocalError(err)
+		}
+		w.baseline[object.Key] = syncedObject{version: version, sum: sha256.Sum256(data)}
+	}
+
+	return nil
+}
+
+// storeError reports a failed store operation on key.
+func storeError(op, key string, err error) error {
+	return clauderrs.NewClientError(
+		clauderrs.ErrCodeIOError,
+		fmt.Sprintf("failed to %s workspace object %s", op, key),
+		err,
+	)
+}
+
+// wrapLocalError reports a failure of the workspace directory, unless err
+// already is a store error.
+func wrapLocalError(err error) error {
+	var sdkErr clauderrs.SDKError
+	if errors.As(err, &sdkErr) {
+		return err
+	}
+
+	return clauderrs.NewClientError(clauderrs.ErrCodeIOError, "failed to access workspace directory", err)
+}
+
+// syncingClient is the Client returned by Workspace.Client.
+type syncingClient struct {
+	claude.Client
+	workspace *Workspace
+	onSync    func(*SyncReport, error)
+}
+
+func (c *syncingClient) ReceiveMessages(ctx context.Context) (<-chan claude.SDKMessage, <-chan error) {
+	msgs, errs := c.Client.ReceiveMessages(ctx)
+
+	return c.observe(ctx, msgs), errs
+}
+
+func (c *syncingClient) ReceiveResponse(ctx context.Context) <-chan claude.SDKMessage {
+	return c.observe(ctx, c.Client.ReceiveResponse(ctx))
+}
+
+// observe forwards in, syncing before each result.
+func (c *syncingClient) observe(ctx context.Context, in <-chan claude.SDKMessage) <-chan claude.SDKMessage {
+	out := make(chan claude.SDKMessage, messageBuffer)
+	go func() {
+		defer close(out)
+		for msg := range in {
+			if _, ok := msg.(*claude.SDKResultMessage); ok {
+				report, err := c.workspace.Sync(ctx)
+				if c.onSync != nil {
+					c.onSync(report, err)
+				}
+			}
+			select {
+			case out <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
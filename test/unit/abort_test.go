@@ -0,0 +1,198 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// TestAbortedError verifies AbortedError keeps its cause and reason.
+func TestAbortedError(t *testing.T) {
+	err := clauderrs.NewAbortedError("cancelled", context.Canceled).
+		WithReason(clauderrs.AbortReasonInterrupt)
+
+	if !clauderrs.IsAbortedError(err) {
+		t.Error("expected IsAbortedError to be true")
+	}
+	if clauderrs.IsCallbackError(err) {
+		t.Error("abort errors should not be callback errors")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Error("expected abort error to unwrap to context.Canceled")
+	}
+	if err.Reason() != clauderrs.AbortReasonInterrupt {
+		t.Errorf("expected interrupt reason, got %q", err.Reason())
+	}
+	if err.Code() != clauderrs.ErrCodeAborted {
+		t.Errorf("expected code %q, got %q", clauderrs.ErrCodeAborted, err.Code())
+	}
+}
+
+// TestDeprecatedAbortError verifies the deprecated AbortError API keeps
+// working alongside AbortedError.
+func TestDeprecatedAbortError(t *testing.T) {
+	legacy := clauderrs.NewAbortError("callback failed", nil)
+	if !clauderrs.IsCallbackError(legacy) || !clauderrs.IsAbortError(legacy) {
+		t.Errorf("expected the deprecated abort error to be a callback error, got %v", legacy)
+	}
+	if clauderrs.IsAbortedError(legacy) {
+		t.Error("expected the deprecated abort error not to be an AbortedError")
+	}
+	if !clauderrs.IsAbortError(clauderrs.NewAbortedError("cancelled", context.Canceled)) {
+		t.Error("expected IsAbortError to report an AbortedError")
+	}
+}
+
+// TestClientErrBudgetAbort verifies a max-budget result is reported as an
+// abort with the partial turn attached.
+func TestClientErrBudgetAbort(t *testing.T) {
+	budgetResult := strings.Replace(
+		fakeResultLine,
+		`"subtype":"success"`,
+		`"subtype":"error_max_budget_usd"`,
+		1,
+	)
+
+	client, _ := runFakeSession(t, nil,
+		fakeInitLine,
+		fakeTextLine("partial answer"),
+		budgetResult,
+	)
+
+	err := client.Err()
+	abortErr, ok := clauderrs.AsAbortedError(err)
+	if !ok {
+		t.Fatalf("expected AbortedError, got %v", err)
+	}
+	if abortErr.Reason() != clauderrs.AbortReasonBudget {
+		t.Errorf("expected budget reason, got %q", abortErr.Reason())
+	}
+
+	partial, ok := claudeagent.AbortPartial(err)
+	if !ok {
+		t.Fatal("expected partial response")
+	}
+	if partial.LastAssistantMessage == nil {
+		t.Fatal("expected last assistant message")
+	}
+	if partial.Usage.OutputTokens != 1 {
+		t.Errorf("expected 1 output token, got %d", partial.Usage.OutputTokens)
+	}
+	if partial.Result == nil {
+		t.Error("expected result message in partial response")
+	}
+}
+
+// TestClientErrNilOnSuccess verifies normal completion leaves Err nil.
+func TestClientErrNilOnSuccess(t *testing.T) {
+	client, _ := runFakeSession(t, nil, fakeInitLine, fakeResultLine)
+
+	if err := client.Err(); err != nil {
+		t.Errorf("expected nil error, got %v", err)
+	}
+}
+
+// TestClientErrContextAbort verifies context cancellation is reported as an
+// abort that still matches the context error.
+func TestClientErrContextAbort(t *testing.T) {
+	opts := &claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t,
+			fakeInitLine,
+			fakeTextLine("still thinking"),
+		),
+	}
+
+	client, err := claudeagent.NewClient(opts)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	if err := client.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	for range client.ReceiveResponse(ctx) {
+	}
+
+	err = client.Err()
+	if !clauderrs.IsAbortedError(err) {
+		t.Fatalf("expected AbortedError, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded cause, got %v", err)
+	}
+
+	partial, ok := claudeagent.AbortPartial(err)
+	if !ok || partial.LastAssistantMessage == nil {
+		t.Error("expected partial response with last assistant message")
+	}
+}
+
+// TestCollectResponseKeepsPartialTurn verifies a cancelled turn returns the
+// messages received before the cancellation.
+func TestCollectResponseKeepsPartialTurn(t *testing.T) {
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t,
+			fakeInitLine,
+			fakeTextLine("still thinking"),
+		),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	if err := client.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	response := client.CollectResponse(ctx)
+	if !response.Interrupted || !clauderrs.IsAbortedError(response.Err) {
+		t.Errorf("expected an interrupted response, got %+v", response)
+	}
+	if response.Result != nil {
+		t.Errorf("expected no result, got %+v", response.Result)
+	}
+	if len(response.Messages) != 2 {
+		t.Fatalf("expected the 2 messages received before the cancellation, got %d", len(response.Messages))
+	}
+	if _, ok := response.Messages[1].(*claudeagent.SDKAssistantMessage); !ok {
+		t.Errorf("expected the partial assistant message, got %T", response.Messages[1])
+	}
+}
+
+// TestCollectResponseCompleteTurn verifies a completed turn carries its
+// result.
+func TestCollectResponseCompleteTurn(t *testing.T) {
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeTextLine("done"), fakeResultLine),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	response := client.CollectResponse(ctx)
+	if response.Interrupted || response.Err != nil || response.Result == nil || len(response.Messages) != 3 {
+		t.Errorf("expected the complete turn, got %+v", response)
+	}
+}
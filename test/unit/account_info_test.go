@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// newAccountFakeCLI writes a fake CLI that emits an init message reporting
+// apiKeySource "user" and a result, then answers initialize requests with
+// initResponse and accountInfo requests with a fixed account.
+func newAccountFakeCLI(t *testing.T, initResponse string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	initLine := strings.Replace(fakeInitLine, `"session_id"`, `"apiKeySource":"user","session_id"`, 1)
+	if err := os.WriteFile(filepath.Join(dir, "stdout.jsonl"), []byte(initLine+"\n"+fakeResultLine+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write fake CLI output: %v", err)
+	}
+
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+cat stdout.jsonl
+while IFS= read -r line; do
+  printf '%s\n' "$line" >>stdin.jsonl
+  id=$(printf '%s\n' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+  case "$line" in
+  *'"subtype":"initialize"'*)
+    printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":%s}}\n' "$id" '` + initResponse + `';;
+  *'"subtype":"accountInfo"'*)
+    printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{"data":{"email":"ops@example.com","tokenSource":"oauth"}}}}\n' "$id";;
+  esac
+done
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatalf("failed to write fake CLI script: %v", err)
+	}
+
+	return script
+}
+
+func TestClientAccountInfoFromInitialize(t *testing.T) {
+	script := newAccountFakeCLI(t, `{"account":{"email":"dev@example.com","organization":"Acme","subscriptionType":"team","tokenSource":"claude.ai"}}`)
+	client, _ := collectFakeSession(t, &claudeagent.Options{PathToClaudeCodeExecutable: script})
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	info, err := client.AccountInfo(ctx)
+	if err != nil {
+		t.Fatalf("AccountInfo failed: %v", err)
+	}
+	if info.Email == nil || *info.Email != "dev@example.com" {
+		t.Errorf("expected email dev@example.com, got %v", info.Email)
+	}
+	if info.Organization == nil || *info.Organization != "Acme" {
+		t.Errorf("expected organization Acme, got %v", info.Organization)
+	}
+	if info.SubscriptionType == nil || *info.SubscriptionType != "team" {
+		t.Errorf("expected subscription team, got %v", info.SubscriptionType)
+	}
+	if info.ApiKeySource == nil || *info.ApiKeySource != "user" {
+		t.Errorf("expected API key source from init message, got %v", info.ApiKeySource)
+	}
+
+	// A second call reuses the initialize response
+	if _, err := client.AccountInfo(ctx); err != nil {
+		t.Fatalf("second AccountInfo failed: %v", err)
+	}
+	lines := fakeCLIStdin(t, script, `"initialize"`, 1)
+	if n := strings.Count(strings.Join(lines, "\n"), `"subtype":"initialize"`); n != 1 {
+		t.Errorf("expected one initialize request, got %d", n)
+	}
+}
+
+func TestClientAccountInfoFallsBackToControlRequest(t *testing.T) {
+	script := newAccountFakeCLI(t, `{}`)
+	client, _ := collectFakeSession(t, &claudeagent.Options{PathToClaudeCodeExecutable: script})
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	info, err := client.AccountInfo(ctx)
+	if err != nil {
+		t.Fatalf("AccountInfo failed: %v", err)
+	}
+	if info.Email == nil || *info.Email != "ops@example.com" {
+		t.Errorf("expected email ops@example.com, got %v", info.Email)
+	}
+	if info.TokenSource == nil || *info.TokenSource != "oauth" {
+		t.Errorf("expected token source oauth, got %v", info.TokenSource)
+	}
+	if info.ApiKeySource == nil || *info.ApiKeySource != "user" {
+		t.Errorf("expected API key source from init message, got %v", info.ApiKeySource)
+	}
+}
+
+func TestClientAccountInfoWithoutQuery(t *testing.T) {
+	client, err := claudeagent.NewClient(&claudeagent.Options{})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	_, err = client.AccountInfo(context.Background())
+	if !clauderrs.IsClientError(err) {
+		t.Fatalf("expected ClientError, got %v", err)
+	}
+}
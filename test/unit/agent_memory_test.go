@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// runMemorySession runs a fake session whose CLI calls the memory tools
+// with calls, given as tool name and JSON arguments, and returns the
+// responses by request ID.
+func runMemorySession(t *testing.T, store claudeagent.SessionStore, calls ...[2]string) map[string]string {
+	t.Helper()
+
+	lines := []string{fakeInitLine}
+	for i, call := range calls {
+		id := fmt.Sprintf("mem_%d", i+1)
+		lines = append(lines, fakeMcpMessageLine(id, claudeagent.MemoryServerName,
+			`{"jsonrpc":"2.0","id":"`+id+`","method":"tools/call","params":{"name":"`+call[0]+`","arguments":`+call[1]+`}}`))
+	}
+	script := newFakeCLI(t, lines...)
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		AgentMemory:                true,
+		SessionStore:               store,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "remember"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	responses := make(map[string]string)
+	for _, line := range fakeCLIStdin(t, script, "mcp_response", len(calls)) {
+		var frame struct {
+			Response struct {
+				RequestID string          `json:"request_id"`
+				Response  json.RawMessage `json:"response"`
+			} `json:"response"`
+		}
+		if json.Unmarshal([]byte(line), &frame) == nil && frame.Response.RequestID != "" {
+			responses[frame.Response.RequestID] = string(frame.Response.Response)
+		}
+	}
+
+	return responses
+}
+
+func TestAgentMemorySharedAcrossSessions(t *testing.T) {
+	dir := t.TempDir()
+	first, err := claudeagent.NewFileSessionStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileSessionStore failed: %v", err)
+	}
+	runMemorySession(t, first,
+		[2]string{"memory_set", `{"key":"deploy_target","value":"staging-eu"}`},
+		[2]string{"note_append", `{"text":"user prefers terse answers"}`},
+	)
+
+	// A new store on the same directory stands in for a later process
+	second, err := claudeagent.NewFileSessionStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileSessionStore failed: %v", err)
+	}
+	responses := runMemorySession(t, second,
+		[2]string{"memory_get", `{"key":"deploy_target"}`},
+		[2]string{"notes_read", `{}`},
+		[2]string{"memory_get", `{"key":"missing"}`},
+		[2]string{"memory_list", `{}`},
+	)
+
+	for id, want := range map[string]string{
+		"mem_1": `"text":"staging-eu"`,
+		"mem_2": `user prefers terse answers`,
+		"mem_3": `nothing is stored under \"missing\"`,
+		"mem_4": `"text":"deploy_target"`,
+	} {
+		if !strings.Contains(responses[id], want) {
+			t.Errorf("expected response %s to contain %q, got %s", id, want, responses[id])
+		}
+	}
+	if !strings.Contains(responses["mem_3"], `"isError":true`) {
+		t.Errorf("expected a missing key to be an error result, got %s", responses["mem_3"])
+	}
+
+	notes, err := claudeagent.ReadAgentNotes(context.Background(), second)
+	if err != nil || len(notes) != 1 || notes[0].SessionID == "" {
+		t.Errorf("expected one note tagged with its session, got %+v (%v)", notes, err)
+	}
+}
+
+func TestAgentMemoryRequiresSessionStore(t *testing.T) {
+	_, err := claudeagent.NewOptions().WithAgentMemory().Build()
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeConflictingOptions {
+		t.Errorf("expected ErrCodeConflictingOptions, got %v", err)
+	}
+
+	_, err = claudeagent.NewOptions().WithAgentMemory().WithSessionStore(claudeagent.NewMemorySessionStore()).Build()
+	if err != nil {
+		t.Errorf("expected agent memory with a session store to build, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// fakeSubagentToolUseLine returns an assistant message from the subagent
+// spawned by parentID invoking a tool.
+func fakeSubagentToolUseLine(parentID, id, name, input string) string {
+	return `{"type":"assistant","uuid":"00000000-0000-0000-0000-000000000005","session_id":"fake-session","parent_tool_use_id":"` +
+		parentID + `","message":{"id":"msg_3","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"tool_use","id":"` +
+		id + `","name":"` + name + `","input":` + input + `}],"usage":{"input_tokens":1,"output_tokens":1}}}`
+}
+
+// TestAgentPermissionsNestedSubagents verifies subagent tool restrictions
+// and permission modes are enforced for nested subagents, and that the
+// callback sees the innermost agent.
+func TestAgentPermissionsNestedSubagents(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		agentIDs = make(map[string]string)
+	)
+
+	opts := &claudeagent.Options{
+		Agents: map[string]claudeagent.AgentDefinition{
+			"reviewer": {
+				Description: "Reviews code",
+				Prompt:      "Review the change.",
+				Tools:       []string{"Agent", "Bash", "Grep", "Read"},
+			},
+			"tester": {
+				Description:    "Plans tests",
+				Prompt:         "Plan the tests.",
+				PermissionMode: claudeagent.PermissionModePlan,
+			},
+		},
+		CanUseTool: func(
+			_ context.Context,
+			toolName string,
+			_ map[string]claudeagent.JSONValue,
+			_ []claudeagent.PermissionUpdate,
+			_ string,
+			agentID *string,
+			_ *string,
+			_ *string,
+		) (claudeagent.PermissionResult, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if agentID != nil {
+				agentIDs[toolName] = *agentID
+			}
+
+			return &claudeagent.PermissionAllow{}, nil
+		},
+	}
+
+	_, _ = runFakeSession(t, opts,
+		fakeInitLine,
+		fakeToolUseLine("toolu_a1", "Agent", `{"description":"review","prompt":"p","subagent_type":"reviewer"}`),
+		fakeSubagentToolUseLine("toolu_a1", "toolu_a2", "Agent", `{"description":"test","prompt":"p","subagent_type":"tester"}`),
+		fakeSubagentToolUseLine("toolu_a2", "toolu_cli_1", "Bash", `{"command":"go test ./..."}`),
+		fakeCanUseToolLine("cli_1", "Bash", "go test ./..."),
+		fakeSubagentToolUseLine("toolu_a2", "toolu_cli_2", "Read", `{"file_path":"main.go"}`),
+		fakeCanUseToolLine("cli_2", "Read", "main.go"),
+		fakeSubagentToolUseLine("toolu_a1", "toolu_cli_3", "Write", `{"file_path":"main.go","content":""}`),
+		fakeCanUseToolLine("cli_3", "Write", "main.go"),
+		fakeResultLine,
+	)
+
+	lines := fakeCLIStdin(t, opts.PathToClaudeCodeExecutable, "control_response", 3)
+	responses := make(map[string]string)
+	for _, line := range lines {
+		for _, id := range []string{"cli_1", "cli_2", "cli_3"} {
+			if strings.Contains(line, `"`+id+`"`) {
+				responses[id] = line
+			}
+		}
+	}
+
+	if !strings.Contains(responses["cli_1"], `"allow":false`) ||
+		!strings.Contains(responses["cli_1"], "agent 'tester' runs in plan mode") {
+		t.Errorf("expected Bash in plan-mode tester to be denied, got %s", responses["cli_1"])
+	}
+	if !strings.Contains(responses["cli_2"], `"allow":true`) {
+		t.Errorf("expected Read in tester to be allowed, got %s", responses["cli_2"])
+	}
+	if !strings.Contains(responses["cli_3"], `"allow":false`) ||
+		!strings.Contains(responses["cli_3"], "not available to agent 'reviewer'") {
+		t.Errorf("expected Write in reviewer to be denied, got %s", responses["cli_3"])
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if agentIDs["Read"] != "tester" {
+		t.Errorf("expected callback agentID 'tester', got %q", agentIDs["Read"])
+	}
+	if _, ok := agentIDs["Bash"]; ok {
+		t.Error("expected denied tool not to reach the callback")
+	}
+}
+
+// TestAgentPermissionsModeCannotWiden verifies an agent's permission mode
+// cannot be broader than the session's.
+func TestAgentPermissionsModeCannotWiden(t *testing.T) {
+	opts := &claudeagent.Options{
+		PermissionMode: claudeagent.PermissionModePlan,
+		Agents: map[string]claudeagent.AgentDefinition{
+			"writer": {
+				Description:    "Writes code",
+				Prompt:         "Write the change.",
+				PermissionMode: claudeagent.PermissionModeBypassPermissions,
+			},
+		},
+		CanUseTool: func(
+			context.Context,
+			string,
+			map[string]claudeagent.JSONValue,
+			[]claudeagent.PermissionUpdate,
+			string,
+			*string,
+			*string,
+			*string,
+		) (claudeagent.PermissionResult, error) {
+			return &claudeagent.PermissionAllow{}, nil
+		},
+	}
+
+	_, _ = runFakeSession(t, opts,
+		fakeInitLine,
+		fakeToolUseLine("toolu_a1", "Task", `{"description":"write","prompt":"p","subagent_type":"writer"}`),
+		fakeSubagentToolUseLine("toolu_a1", "toolu_cli_1", "Bash", `{"command":"rm -rf build"}`),
+		fakeCanUseToolLine("cli_1", "Bash", "rm -rf build"),
+		fakeResultLine,
+	)
+
+	lines := fakeCLIStdin(t, opts.PathToClaudeCodeExecutable, "control_response", 1)
+	var response string
+	for _, line := range lines {
+		if strings.Contains(line, `"cli_1"`) {
+			response = line
+		}
+	}
+	if !strings.Contains(response, `"allow":false`) || !strings.Contains(response, "plan mode") {
+		t.Errorf("expected session plan mode to bind the agent, got %s", response)
+	}
+}
@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestAnnotateStoresInSessionStoreAndExport(t *testing.T) {
+	configDir := t.TempDir()
+	cwd := t.TempDir()
+	exportDir := t.TempDir()
+	transcript := `{"type":"user","sessionId":"fake-session","timestamp":"2026-01-01T10:00:00Z","cwd":"` + cwd + `"}`
+	path := writeTranscript(t, configDir, cwd, "fake-session", transcript)
+	store, err := claudeagent.NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSessionStore failed: %v", err)
+	}
+
+	opts := &claudeagent.Options{
+		Cwd:             cwd,
+		Env:             map[string]string{"CLAUDE_CONFIG_DIR": configDir},
+		SessionStore:    store,
+		TelemetryExport: &claudeagent.TelemetryExport{Dir: exportDir},
+	}
+	client, _ := runFakeSession(t, opts, fakeInitLine, fakeTextLine("hi"), fakeResultLine)
+
+	ctx := context.Background()
+	if err := client.Annotate(ctx, "msg-1", "rating", 4); err != nil {
+		t.Fatalf("Annotate failed: %v", err)
+	}
+	if err := client.Annotate(ctx, "msg-1", "labels", []string{"helpful", "concise"}); err != nil {
+		t.Fatalf("Annotate failed: %v", err)
+	}
+
+	annotations, err := claudeagent.ReadAnnotations(ctx, store, "fake-session")
+	if err != nil {
+		t.Fatalf("ReadAnnotations failed: %v", err)
+	}
+	if len(annotations) != 2 {
+		t.Fatalf("expected 2 annotations in the store, got %+v", annotations)
+	}
+	first := annotations[0]
+	if first.SessionID != "fake-session" || first.MessageID != "msg-1" || first.Key != "rating" ||
+		string(first.Value) != "4" || first.CreatedAt.IsZero() {
+		t.Errorf("unexpected stored annotation %+v", first)
+	}
+	if got := string(annotations[1].Value); got != `["helpful","concise"]` {
+		t.Errorf("expected the labels stored as JSON, got %s", got)
+	}
+	if got := client.Annotations(); len(got) != 2 || got[0].SessionID != "fake-session" {
+		t.Errorf("expected the client to list its annotations, got %+v", got)
+	}
+
+	// The CLI's transcript holds only the CLI's entries
+	if data, err := os.ReadFile(path); err != nil || strings.TrimSpace(string(data)) != transcript {
+		t.Errorf("expected the transcript untouched, got %s (%v)", data, err)
+	}
+
+	_ = client.Close()
+	rows := readCSV(t, filepath.Join(exportDir, "annotations", "fake-session.csv"))
+	if len(rows) != 2 || rows[0]["message_uuid"] != "msg-1" || rows[0]["key"] != "rating" || rows[0]["value"] != "4" {
+		t.Errorf("expected the annotations exported, got %v", rows)
+	}
+}
+
+func TestAnnotateErrors(t *testing.T) {
+	client, err := claudeagent.NewClient(nil)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := client.Annotate(ctx, "msg-1", "rating", 4); !clauderrs.IsClientError(err) {
+		t.Errorf("expected ClientError before the session starts, got %v", err)
+	}
+	assertValidationField(t, client.Annotate(ctx, "", "rating", 4), "messageID")
+	assertValidationField(t, client.Annotate(ctx, "msg-1", "", 4), "key")
+	assertValidationField(t, client.Annotate(ctx, "msg-1", "rating", func() {}), "value")
+}
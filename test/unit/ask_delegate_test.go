@@ -0,0 +1,194 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// askResponses runs a session answering can_use_tool requests with opts
+// and returns the control responses sent to the CLI by request ID.
+func askResponses(t *testing.T, opts *claudeagent.Options, requestIDs ...string) map[string]string {
+	t.Helper()
+
+	lines := []string{fakeInitLine}
+	for _, id := range requestIDs {
+		lines = append(lines, fakeCanUseToolLine(id, "Bash", "git push"))
+	}
+	opts.PathToClaudeCodeExecutable = newFakeCLI(t, append(lines, fakeResultLine)...)
+
+	client, err := claudeagent.NewClient(opts)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	responses := make(map[string]string)
+	for _, line := range fakeCLIStdin(t, opts.PathToClaudeCodeExecutable, "control_response", len(requestIDs)) {
+		for _, id := range requestIDs {
+			if strings.Contains(line, `"request_id":"`+id+`"`) {
+				responses[id] = line
+			}
+		}
+	}
+
+	return responses
+}
+
+func TestWebhookAskDelegate(t *testing.T) {
+	var got struct {
+		SessionID   string                           `json:"session_id"`
+		ToolName    string                           `json:"tool_name"`
+		ToolUseID   string                           `json:"tool_use_id"`
+		Input       map[string]claudeagent.JSONValue `json:"input"`
+		Suggestions []json.RawMessage                `json:"suggestions"`
+		Deadline    time.Time                        `json:"deadline"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode approval request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"behavior":"deny","message":"release freeze"}`))
+	}))
+	defer server.Close()
+
+	opts, err := claudeagent.NewOptions().
+		WithAskDelegate(&claudeagent.WebhookAskDelegate{
+			URL:    server.URL,
+			Header: http.Header{"Authorization": {"Bearer secret"}},
+		}, time.Minute, claudeagent.PermissionBehaviorAllow).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	responses := askResponses(t, opts, "cli_1")
+	if !strings.Contains(responses["cli_1"], `"allow":false`) ||
+		!strings.Contains(responses["cli_1"], "release freeze") {
+		t.Errorf("expected webhook denial, got %s", responses["cli_1"])
+	}
+	if got.ToolName != "Bash" || got.ToolUseID != "toolu_cli_1" || string(got.Input["command"]) != `"git push"` {
+		t.Errorf("unexpected approval request %+v", got)
+	}
+	if got.SessionID == "" || len(got.Suggestions) != 1 || got.Deadline.IsZero() {
+		t.Errorf("expected session, suggestions and deadline in request, got %+v", got)
+	}
+}
+
+func TestAskPolicyTimeoutAppliesDefault(t *testing.T) {
+	var mu sync.Mutex
+	var stderr []string
+	opts := &claudeagent.Options{
+		AskPolicy: &claudeagent.AskPolicy{
+			Delegate: claudeagent.AskDelegateFunc(
+				func(ctx context.Context, _ *claudeagent.AskRequest) (claudeagent.PermissionResult, error) {
+					<-ctx.Done()
+
+					return nil, ctx.Err()
+				},
+			),
+			Timeout: 50 * time.Millisecond,
+		},
+		Stderr: func(line string) {
+			mu.Lock()
+			defer mu.Unlock()
+			stderr = append(stderr, line)
+		},
+	}
+
+	responses := askResponses(t, opts, "cli_1")
+	if !strings.Contains(responses["cli_1"], `"allow":false`) ||
+		!strings.Contains(responses["cli_1"], "no decision within 50ms") {
+		t.Errorf("expected timeout to deny, got %s", responses["cli_1"])
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(stderr) == 0 || !strings.Contains(stderr[0], "applying default policy") {
+		t.Errorf("expected timeout to be reported, got %v", stderr)
+	}
+}
+
+func TestCanUseToolEscalatesAsk(t *testing.T) {
+	var mu sync.Mutex
+	var reasons []string
+	opts := &claudeagent.Options{
+		CanUseTool: func(
+			_ context.Context,
+			_ string,
+			input map[string]claudeagent.JSONValue,
+			_ []claudeagent.PermissionUpdate,
+			toolUseID string,
+			_ *string,
+			_ *string,
+			_ *string,
+		) (claudeagent.PermissionResult, error) {
+			if toolUseID == "toolu_cli_1" {
+				return &claudeagent.PermissionAllow{Behavior: claudeagent.PermissionBehaviorAllow}, nil
+			}
+
+			return &claudeagent.PermissionAsk{Message: "pushes need approval"}, nil
+		},
+		AskPolicy: &claudeagent.AskPolicy{
+			Delegate: claudeagent.AskDelegateFunc(
+				func(_ context.Context, req *claudeagent.AskRequest) (claudeagent.PermissionResult, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					reasons = append(reasons, req.Reason)
+
+					return &claudeagent.PermissionAllow{
+						Behavior:     claudeagent.PermissionBehaviorAllow,
+						UpdatedInput: map[string]claudeagent.JSONValue{"command": json.RawMessage(`"git push --dry-run"`)},
+					}, nil
+				},
+			),
+		},
+	}
+
+	responses := askResponses(t, opts, "cli_1", "cli_2")
+	if !strings.Contains(responses["cli_1"], `"allow":true`) {
+		t.Errorf("expected CanUseTool to allow directly, got %s", responses["cli_1"])
+	}
+	if !strings.Contains(responses["cli_2"], `"command":"git push --dry-run"`) {
+		t.Errorf("expected delegate's updated input, got %s", responses["cli_2"])
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reasons) != 1 || reasons[0] != "pushes need approval" {
+		t.Errorf("expected one escalation with the ask message, got %v", reasons)
+	}
+}
+
+func TestAskPolicyValidation(t *testing.T) {
+	err := (&claudeagent.Options{
+		AskPolicy:                &claudeagent.AskPolicy{Default: claudeagent.PermissionBehaviorAsk},
+		PermissionPromptToolName: "mcp__approve",
+	}).Validate()
+	for _, field := range []string{"requires a Delegate", "AskPolicy.Default", "PermissionPromptToolName"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected %q to be reported, got %v", field, err)
+		}
+	}
+	if !clauderrs.IsValidationError(err) {
+		t.Errorf("expected validation error, got %T", err)
+	}
+}
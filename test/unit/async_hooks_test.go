@@ -0,0 +1,101 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestCompleteAsyncHook(t *testing.T) {
+	script := newHookFakeCLI(t,
+		fakeInitLine,
+		fakePreToolUseLine("cli_1", "hook_0", "Bash", `{"command":"rm -rf build"}`),
+		fakePreToolUseLine("cli_2", "hook_0", "Write", `{"file_path":"/tmp/x","content":"x"}`),
+	)
+
+	ids := make(chan string, 2)
+	quick := 50
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		Hooks: map[claudeagent.HookEvent][]claudeagent.HookCallbackMatcher{
+			claudeagent.HookEventPreToolUse: {{
+				Hooks: []claudeagent.HookCallback{
+					func(ctx context.Context, input claudeagent.HookInput, _ *string) (claudeagent.HookJSONOutput, error) {
+						output := claudeagent.AsyncHookOutput{Async: true}
+						if input.(claudeagent.PreToolUseHookInput).ToolName == "Write" {
+							// Nobody completes this one; it must time out.
+							output.AsyncTimeout = &quick
+						} else {
+							ids <- claudeagent.AsyncHookID(ctx)
+						}
+
+						return output, nil
+					},
+				},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "clean up"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	var id string
+	select {
+	case id = <-ids:
+	case <-ctx.Done():
+		t.Fatal("hook was not called")
+	}
+	if id == "" {
+		t.Fatal("expected an async hook ID in the hook's context")
+	}
+
+	err = client.CompleteAsyncHook(ctx, id, claudeagent.AsyncHookOutput{Async: true})
+	if !clauderrs.IsValidationError(err) {
+		t.Errorf("expected ValidationError for an async completion, got %v", err)
+	}
+	if err := client.CompleteAsyncHook(ctx, id, claudeagent.Block("reviewer rejected it")); err != nil {
+		t.Fatalf("CompleteAsyncHook failed: %v", err)
+	}
+	err = client.CompleteAsyncHook(ctx, id, claudeagent.Block("again"))
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeInvalidState {
+		t.Errorf("expected ErrCodeInvalidState for a second completion, got %v", err)
+	}
+
+	responses := make(map[string]string)
+	for _, line := range fakeCLIStdin(t, script, `"request_id":"cli_`, 2) {
+		for _, id := range []string{"cli_1", "cli_2"} {
+			if strings.Contains(line, `"`+id+`"`) {
+				responses[id] = line
+			}
+		}
+	}
+	if !strings.Contains(responses["cli_1"], `"decision":"block"`) || !strings.Contains(responses["cli_1"], "reviewer rejected it") {
+		t.Errorf("expected the completed output to reach the CLI, got %s", responses["cli_1"])
+	}
+	if !strings.Contains(responses["cli_2"], `"subtype":"error"`) || !strings.Contains(responses["cli_2"], "not completed") {
+		t.Errorf("expected an error response for the timed out hook, got %s", responses["cli_2"])
+	}
+}
+
+func TestCompleteAsyncHookWithoutQuery(t *testing.T) {
+	client, err := claudeagent.NewClient(nil)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	err = client.CompleteAsyncHook(context.Background(), "missing", claudeagent.Block("no"))
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeNoActiveQuery {
+		t.Errorf("expected ErrCodeNoActiveQuery, got %v", err)
+	}
+}
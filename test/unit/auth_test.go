@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// fakeAuthStatusLine is an auth_status message asking to log in.
+const fakeAuthStatusLine = `{"type":"auth_status","uuid":"00000000-0000-0000-0000-000000000004","session_id":"fake-session",` +
+	`"isAuthenticating":true,"output":["Visit https://example.com/login and enter the code"]}`
+
+// newAuthFakeCLI writes a fake CLI that enters an auth flow after the
+// prompt, and answers once it receives an authenticate request.
+func newAuthFakeCLI(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	write := func(name string, lines ...string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("login.jsonl", fakeInitLine, fakeAuthStatusLine)
+	write("answer.jsonl", fakeTextLine("logged in"), fakeResultLine)
+
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+cat login.jsonl
+while IFS= read -r line; do
+  printf '%s\n' "$line" >>stdin.jsonl
+  case "$line" in *'"authenticate"'*)
+    id=$(printf '%s\n' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+    printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{}}}\n' "$id"
+    break;;
+  esac
+done
+cat answer.jsonl
+cat >>stdin.jsonl
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script
+}
+
+// lockedBuffer is a bytes.Buffer safe for concurrent writes.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestAuthenticateCompletesLogin(t *testing.T) {
+	script := newAuthFakeCLI(t)
+	client, err := claudeagent.NewClient(&claudeagent.Options{PathToClaudeCodeExecutable: script})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	tee := &lockedBuffer{}
+	client.TeeJSONL(tee)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+	if err := client.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	response := client.ReceiveResponse(ctx)
+
+	var status *claudeagent.SDKAuthStatusMessage
+	select {
+	case status = <-client.AuthEvents():
+	case <-ctx.Done():
+		t.Fatal("expected an auth status message")
+	}
+	if !status.IsAuthenticating || len(status.Output) != 1 || !strings.Contains(status.Output[0], "example.com/login") {
+		t.Errorf("unexpected auth status %+v", status)
+	}
+
+	if err := client.Authenticate(ctx, "code-123"); err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+
+	var msgs []claudeagent.SDKMessage
+	for msg := range response {
+		if _, ok := msg.(*claudeagent.SDKAuthStatusMessage); ok {
+			t.Error("expected auth status messages only on AuthEvents")
+		}
+		msgs = append(msgs, msg)
+	}
+	if _, ok := msgs[len(msgs)-1].(*claudeagent.SDKResultMessage); !ok {
+		t.Errorf("expected the session to finish after logging in, got %d messages", len(msgs))
+	}
+
+	if stdin := readFakeFile(t, filepath.Dir(script), "stdin.jsonl"); !strings.Contains(stdin, `"token":"code-123"`) {
+		t.Errorf("expected the token to be sent, got %s", stdin)
+	}
+	if out := tee.String(); strings.Contains(out, "code-123") || !strings.Contains(out, `"token":"[redacted]"`) {
+		t.Errorf("expected the token redacted from the tee, got %s", out)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-client.AuthEvents(); ok {
+		t.Error("expected AuthEvents to be closed by Close")
+	}
+}
+
+func TestAuthenticateRequiresToken(t *testing.T) {
+	client, _ := runFakeSession(t, nil, fakeInitLine, fakeResultLine)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+	assertValidationField(t, client.Authenticate(ctx, ""), "request.token")
+}
+
+func TestDecodeAuthStatusMessage(t *testing.T) {
+	msg, err := claudeagent.DecodeMessage([]byte(fakeAuthStatusLine))
+	if err != nil {
+		t.Fatalf("DecodeMessage failed: %v", err)
+	}
+	if status, ok := msg.(*claudeagent.SDKAuthStatusMessage); !ok || !status.IsAuthenticating {
+		t.Errorf("expected an auth status message, got %#v", msg)
+	}
+}
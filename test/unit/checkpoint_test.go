@@ -0,0 +1,127 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// newCrashingFakeCLI writes a fake CLI that reads the prompt, prints
+// crashLines and exits 1 on its first run, and answers with a result on
+// later runs. It records the arguments and input of each run.
+func newCrashingFakeCLI(t *testing.T, crashLines ...string) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	write := func(name string, lines []string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("crash.jsonl", crashLines)
+	write("ok.jsonl", []string{fakeInitLine, fakeTextLine("finished"), fakeResultLine})
+
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+echo run >>runs.txt
+printf '%s\n' "$@" >>args.txt
+read -r line
+printf '%s\n' "$line" >>stdin.jsonl
+if [ "$(wc -l <runs.txt)" -le 1 ]; then
+  cat crash.jsonl
+  exit 1
+fi
+cat ok.jsonl
+cat >/dev/null
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script, dir
+}
+
+func TestCheckpointResumesAfterCrash(t *testing.T) {
+	script, dir := newCrashingFakeCLI(t,
+		fakeInitLine,
+		fakeTextLine("Reading the file"),
+		fakeToolUseLine("toolu_1", "Read", `{"file_path":"go.mod"}`),
+	)
+	checkpointer, err := claudeagent.NewFileCheckpointer(filepath.Join(t.TempDir(), "checkpoints"))
+	if err != nil {
+		t.Fatalf("NewFileCheckpointer failed: %v", err)
+	}
+	opts := &claudeagent.Options{PathToClaudeCodeExecutable: script, Checkpointer: checkpointer}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+	if _, err := claudeagent.QueryResult(ctx, "summarize go.mod", opts); err == nil {
+		t.Fatal("expected the crashed run to fail")
+	}
+
+	key, err := claudeagent.CacheKey("summarize go.mod", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkpoint, err := checkpointer.Load(ctx, key)
+	if err != nil || checkpoint == nil {
+		t.Fatalf("expected a checkpoint after the crash, got %v, %v", checkpoint, err)
+	}
+	if checkpoint.SessionID != "fake-session" || checkpoint.Turns != 2 || checkpoint.Text != "Reading the file" ||
+		len(checkpoint.ToolUses) != 1 || checkpoint.ToolUses[0].Name != "Read" || checkpoint.Usage.OutputTokens != 2 {
+		t.Errorf("unexpected checkpoint %+v", checkpoint)
+	}
+
+	result, err := claudeagent.QueryResult(ctx, "summarize go.mod", opts)
+	if err != nil || result == nil {
+		t.Fatalf("expected the rerun to succeed, got %v", err)
+	}
+
+	if args := readFakeFile(t, dir, "args.txt"); !strings.Contains(args, "--resume\nfake-session\n") {
+		t.Errorf("expected the rerun to resume the session, got args %s", args)
+	}
+	stdin := readFakeFile(t, dir, "stdin.jsonl")
+	if !strings.Contains(stdin, "previous run was interrupted") {
+		t.Errorf("expected a continuation prompt on the rerun, got %s", stdin)
+	}
+	if checkpoint, err := checkpointer.Load(ctx, key); checkpoint != nil || err != nil {
+		t.Errorf("expected the checkpoint to be removed after the result, got %+v, %v", checkpoint, err)
+	}
+}
+
+func TestCheckpointStartsOverWhenResumeFails(t *testing.T) {
+	// The first run crashes without output, standing in for a session
+	// that can no longer be resumed
+	script, dir := newCrashingFakeCLI(t)
+	checkpointer, err := claudeagent.NewFileCheckpointer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &claudeagent.Options{PathToClaudeCodeExecutable: script, Checkpointer: checkpointer}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+	key, err := claudeagent.CacheKey("summarize go.mod", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkpointer.Save(ctx, key, &claudeagent.Checkpoint{SessionID: "gone", Turns: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := claudeagent.QueryResult(ctx, "summarize go.mod", opts); err != nil {
+		t.Fatalf("expected a fresh run after the failed resume, got %v", err)
+	}
+	stdin := readFakeFile(t, dir, "stdin.jsonl")
+	if !strings.Contains(stdin, "summarize go.mod") {
+		t.Errorf("expected the original prompt to be sent again, got %s", stdin)
+	}
+	if checkpoint, _ := checkpointer.Load(ctx, key); checkpoint != nil {
+		t.Errorf("expected the checkpoint to be removed, got %+v", checkpoint)
+	}
+}
@@ -0,0 +1,227 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claudegrpc"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claudegrpc/agentpb"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestAgentServerQuery verifies Query starts a session and returns the
+// turn result.
+func TestAgentServerQuery(t *testing.T) {
+	server := claudegrpc.NewServer(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeTextLine("hi"), fakeResultLine),
+	})
+	t.Cleanup(func() { _ = server.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	resp, err := server.Query(ctx, &claudegrpc.QueryRequest{Prompt: "hello"})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if resp.SessionID == "" {
+		t.Error("expected a session ID")
+	}
+	if resp.Result != "done" || resp.NumTurns != 1 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	if err := server.CloseSession(resp.SessionID); err != nil {
+		t.Errorf("CloseSession failed: %v", err)
+	}
+}
+
+// TestAgentServerStream verifies every message is forwarded as an event.
+func TestAgentServerStream(t *testing.T) {
+	server := claudegrpc.NewServer(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeTextLine("hi"), fakeResultLine),
+	})
+	t.Cleanup(func() { _ = server.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	var events []*claudegrpc.Event
+	err := server.Stream(ctx, &claudegrpc.QueryRequest{Prompt: "hello"}, func(e *claudegrpc.Event) error {
+		events = append(events, e)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	want := []string{"system", "assistant", "result"}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(events))
+	}
+	for i, event := range events {
+		if event.Type != want[i] {
+			t.Errorf("event %d: expected type %q, got %q", i, want[i], event.Type)
+		}
+		if !json.Valid(event.JSON) {
+			t.Errorf("event %d: invalid JSON %s", i, event.JSON)
+		}
+	}
+}
+
+// TestAgentServerUnknownSession verifies unknown sessions map to NotFound.
+func TestAgentServerUnknownSession(t *testing.T) {
+	server := claudegrpc.NewServer(nil)
+
+	err := server.Interrupt(context.Background(), "missing")
+	if code := claudegrpc.ErrorCode(err); code != claudegrpc.CodeNotFound {
+		t.Errorf("expected NotFound, got %d (%v)", code, err)
+	}
+
+	_, err = server.Query(context.Background(), &claudegrpc.QueryRequest{})
+	if code := claudegrpc.ErrorCode(err); code != claudegrpc.CodeInvalidArgument {
+		t.Errorf("expected InvalidArgument for empty prompt, got %d (%v)", code, err)
+	}
+}
+
+// TestAgentServerRejectsConcurrentTurns verifies a session runs one turn
+// at a time.
+func TestAgentServerRejectsConcurrentTurns(t *testing.T) {
+	server := claudegrpc.NewServer(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeTextLine("hi"), fakeResultLine),
+	})
+	t.Cleanup(func() { _ = server.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	var sessionID string
+	var busyErr error
+	err := server.Stream(ctx, &claudegrpc.QueryRequest{Prompt: "hello"}, func(e *claudegrpc.Event) error {
+		if sessionID == "" {
+			sessionID = e.SessionID
+			_, busyErr = server.Query(ctx, &claudegrpc.QueryRequest{SessionID: e.SessionID, Prompt: "again"})
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if code := claudegrpc.ErrorCode(busyErr); code != claudegrpc.CodeAborted {
+		t.Errorf("expected Aborted for a turn on a busy session, got %d (%v)", code, busyErr)
+	}
+
+	if err := server.Stream(ctx, &claudegrpc.QueryRequest{SessionID: sessionID, Prompt: "next"}, func(*claudegrpc.Event) error {
+		return nil
+	}); claudegrpc.ErrorCode(err) == claudegrpc.CodeAborted {
+		t.Errorf("expected the session to be free once its turn ended, got %v", err)
+	}
+}
+
+// TestRegisterAgentServer verifies the service is served over gRPC.
+func TestRegisterAgentServer(t *testing.T) {
+	server := claudegrpc.NewServer(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeTextLine("hi"), fakeResultLine),
+	})
+	t.Cleanup(func() { _ = server.Close() })
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	claudegrpc.RegisterAgentServer(grpcServer, server)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	client := agentpb.NewAgentServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	resp, err := client.Query(ctx, &agentpb.QueryRequest{Prompt: "hello"})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if resp.GetSessionId() == "" || resp.GetResult() != "done" {
+		t.Errorf("unexpected response %v", resp)
+	}
+
+	stream, err := client.Stream(ctx, &agentpb.QueryRequest{Prompt: "hello"})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	var types []string
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		types = append(types, event.GetType())
+	}
+	if len(types) != 3 || types[2] != "result" {
+		t.Errorf("expected the turn's messages, got %v", types)
+	}
+
+	if _, err := client.CloseSession(ctx, &agentpb.CloseSessionRequest{SessionId: resp.GetSessionId()}); err != nil {
+		t.Errorf("CloseSession failed: %v", err)
+	}
+	_, err = client.Interrupt(ctx, &agentpb.InterruptRequest{SessionId: resp.GetSessionId()})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for a closed session, got %v", err)
+	}
+	_, err = client.Query(ctx, &agentpb.QueryRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an empty prompt, got %v", err)
+	}
+}
+
+// TestErrorCodeMapping verifies SDK errors map to gRPC codes.
+func TestErrorCodeMapping(t *testing.T) {
+	tests := []struct {
+		err  error
+		want claudegrpc.Code
+	}{
+		{nil, claudegrpc.CodeOK},
+		{context.Canceled, claudegrpc.CodeCanceled},
+		{clauderrs.NewAbortedError("stop", nil), claudegrpc.CodeAborted},
+		{
+			clauderrs.NewPermissionError(clauderrs.ErrCodeToolDenied, "no", nil, "Bash", "use"),
+			claudegrpc.CodePermissionDenied,
+		},
+		{
+			clauderrs.NewClientError(clauderrs.ErrCodeClientClosed, "closed", nil),
+			claudegrpc.CodeFailedPrecondition,
+		},
+		{
+			clauderrs.NewClientError(clauderrs.ErrCodeSessionBusy, "busy", nil),
+			claudegrpc.CodeAborted,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := claudegrpc.ErrorCode(tt.err); got != tt.want {
+			t.Errorf("ErrorCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
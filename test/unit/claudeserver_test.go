@@ -0,0 +1,175 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claudeserver"
+)
+
+func TestClaudeServerSessions(t *testing.T) {
+	script, _ := newTwoTurnFakeCLI(t,
+		[]string{fakeInitLine, fakeTextLine("first answer"), fakeResultLine},
+		[]string{fakeTextLine("second answer"), fakeResultLine},
+	)
+	server := claudeserver.New(claudeserver.Config{
+		Options: &claudeagent.Options{PathToClaudeCodeExecutable: script},
+		Authenticate: func(r *http.Request) (string, error) {
+			user, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				return "", errors.New("missing token")
+			}
+
+			return user, nil
+		},
+	})
+	api := httptest.NewServer(server)
+	t.Cleanup(func() {
+		api.Close()
+		_ = server.Shutdown(context.Background())
+	})
+
+	request := func(method, path, user, accept, body string) (*http.Response, string) {
+		t.Helper()
+		req, err := http.NewRequest(method, api.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user != "" {
+			req.Header.Set("Authorization", "Bearer "+user)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		data, _ := io.ReadAll(resp.Body)
+
+		return resp, string(data)
+	}
+
+	if resp, _ := request(http.MethodPost, "/sessions", "", "", `{"prompt":"hi"}`); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", resp.StatusCode)
+	}
+
+	resp, body := request(http.MethodPost, "/sessions", "alice", "", `{"prompt":"hi"}`)
+	var turn struct {
+		SessionID string `json:"session_id"`
+		Result    string `json:"result"`
+		NumTurns  int    `json:"num_turns"`
+	}
+	if err := json.Unmarshal([]byte(body), &turn); err != nil || resp.StatusCode != http.StatusOK ||
+		turn.SessionID == "" || turn.Result != "done" || turn.NumTurns != 1 {
+		t.Fatalf("unexpected first turn %d %s", resp.StatusCode, body)
+	}
+	messages := "/sessions/" + turn.SessionID + "/messages"
+
+	if resp, _ := request(http.MethodPost, messages, "mallory", "", `{"prompt":"again"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected another caller's session to be hidden, got %d", resp.StatusCode)
+	}
+
+	resp, body = request(http.MethodPost, messages, "alice", "text/event-stream", `{"prompt":"again"}`)
+	if resp.Header.Get("Content-Type") != "text/event-stream" || resp.Header.Get("X-Session-Id") != turn.SessionID {
+		t.Errorf("unexpected stream headers %v", resp.Header)
+	}
+	if !strings.Contains(body, "event: message\ndata: {") || !strings.Contains(body, "second answer") ||
+		!strings.HasSuffix(body, "event: done\ndata: {\"session_id\":\""+turn.SessionID+"\"}\n\n") {
+		t.Errorf("unexpected stream %q", body)
+	}
+
+	if resp, _ := request(http.MethodDelete, "/sessions/"+turn.SessionID, "alice", "", ""); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected the session to be closed, got %d", resp.StatusCode)
+	}
+	if resp, _ := request(http.MethodPost, messages, "alice", "", `{"prompt":"again"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a closed session to be gone, got %d", resp.StatusCode)
+	}
+	if resp, _ := request(http.MethodPost, "/sessions", "alice", "", `{}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a missing prompt to be rejected, got %d", resp.StatusCode)
+	}
+}
+
+func TestClaudeServerShutsDownWithContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := claudeserver.New(claudeserver.Config{ShutdownTimeout: time.Second})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(ctx, listener) }()
+
+	cancel()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(fakeCLITimeout):
+		t.Fatal("Serve didn't return after its context was cancelled")
+	}
+}
+
+// TestClaudeServerClosesExitedSession verifies closing a session whose CLI
+// already exited is a clean close, not a server error.
+func TestClaudeServerClosesExitedSession(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "stdout.jsonl")
+	lines := []string{fakeInitLine, fakeTextLine("answer"), fakeResultLine}
+	if err := os.WriteFile(output, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "claude")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nIFS= read -r line\ncat '"+output+"'\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	server := claudeserver.New(claudeserver.Config{
+		Options: &claudeagent.Options{PathToClaudeCodeExecutable: script},
+	})
+	api := httptest.NewServer(server)
+	t.Cleanup(func() {
+		api.Close()
+		_ = server.Shutdown(context.Background())
+	})
+
+	resp, err := http.Post(api.URL+"/sessions", "application/json", strings.NewReader(`{"prompt":"hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	sessionID := resp.Header.Get("X-Session-Id")
+	if resp.StatusCode != http.StatusOK || sessionID == "" {
+		t.Fatalf("unexpected turn %d %s", resp.StatusCode, body)
+	}
+
+	// Lets the CLI exit and the SDK reap it
+	time.Sleep(200 * time.Millisecond)
+
+	req, err := http.NewRequest(http.MethodDelete, api.URL+"/sessions/"+sessionID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected the exited session to be closed, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,149 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/claudetest"
+)
+
+// recordingT collects the failures claudetest reports.
+type recordingT struct {
+	errors []string
+}
+
+func (*recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// scenarioLines is a session that reads go.mod, is denied a Bash call,
+// and answers.
+var scenarioLines = []string{
+	fakeInitLine,
+	fakeToolUseLine("toolu_read", "Read", `{"file_path":"go.mod","limit":10}`),
+	fakeToolResultLine("toolu_read", "module example", false),
+	fakeToolUseLine("toolu_bash", "Bash", `{"command":"rm -rf /"}`),
+	fakeToolResultLine("toolu_bash", "Permission denied", true),
+	fakeTextLine("The module is example"),
+	`{"type":"result","subtype":"success","uuid":"00000000-0000-0000-0000-000000000009","session_id":"fake-session","duration_ms":10,"num_turns":2,"total_cost_usd":0.01,"usage":{"input_tokens":10,"output_tokens":5},"result":"done",` +
+		`"permission_denials":[{"tool_name":"Bash","tool_use_id":"toolu_bash","tool_input":{"command":"rm -rf /"}}]}`,
+}
+
+// scenarioSession decodes scenarioLines.
+func scenarioSession(t *testing.T) claudetest.Session {
+	t.Helper()
+
+	var msgs []claudeagent.SDKMessage
+	for _, line := range scenarioLines {
+		msg, err := claudeagent.DecodeMessage([]byte(line))
+		if err != nil {
+			t.Fatalf("failed to decode %s: %v", line, err)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return claudetest.Messages(msgs...)
+}
+
+func TestExpectPasses(t *testing.T) {
+	rt := &recordingT{}
+	claudetest.Expect(rt, scenarioSession(t)).
+		ToolUse("Read").WithInput(map[string]any{"file_path": "go.mod", "limit": 10}).Succeeded().
+		Then().
+		ToolUse("Bash").Denied().
+		Then().
+		TextContains("module is example").
+		NoToolUse("Write")
+
+	if len(rt.errors) != 0 {
+		t.Errorf("expected the scenario to pass, got %v", rt.errors)
+	}
+}
+
+func TestExpectFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		chain  func(*claudetest.Expectation)
+		expect string
+	}{
+		{
+			name: "order",
+			chain: func(e *claudetest.Expectation) {
+				e.TextContains("module").Then().ToolUse("Read")
+			},
+			expect: `expected a Read tool use after text "The module is example", found none`,
+		},
+		{
+			name: "input",
+			chain: func(e *claudetest.Expectation) {
+				e.ToolUse("Read").WithInput(map[string]any{"file_path": "main.go"}).Then().ToolUse("Missing")
+			},
+			expect: `expected a Read tool use with input {"file_path":"main.go"}, found none`,
+		},
+		{
+			name: "denied call did not succeed",
+			chain: func(e *claudetest.Expectation) {
+				e.ToolUse("Bash").Succeeded()
+			},
+			expect: "expected a Bash tool use that succeeded, found none",
+		},
+		{
+			name: "forbidden tool",
+			chain: func(e *claudetest.Expectation) {
+				e.ToolUse("Read").Then().NoToolUse("Bash")
+			},
+			expect: "expected no Bash tool use after Read tool use toolu_read, found Bash tool use toolu_bash",
+		},
+		{
+			name: "refinement without tool use",
+			chain: func(e *claudetest.Expectation) {
+				e.TextContains("module").Denied()
+			},
+			expect: "that was denied must follow ToolUse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &recordingT{}
+			e := claudetest.Expect(rt, scenarioSession(t))
+			tt.chain(e)
+
+			if !e.Failed() || len(rt.errors) != 1 || rt.errors[0] != tt.expect {
+				t.Errorf("expected one failure %q, got %q", tt.expect, rt.errors)
+			}
+		})
+	}
+}
+
+func TestExpectRecordedClient(t *testing.T) {
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, scenarioLines...),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	rec := claudetest.Record(client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+	if err := client.Query(ctx, "What is the module name?"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for range client.ReceiveResponse(ctx) {
+	}
+
+	rt := &recordingT{}
+	claudetest.Expect(rt, rec).
+		ToolUse("Read").Succeeded().
+		Then().
+		TextContains("example")
+	if len(rt.errors) != 0 || len(rec.Messages()) != len(scenarioLines) {
+		t.Errorf("expected the recorded session to pass, got %v with %d messages", rt.errors, len(rec.Messages()))
+	}
+}
@@ -93,6 +93,22 @@ func (m *MockQuery) SetModel(_ context.Context, model *string) error {
 	return nil
 }
 
+func (m *MockQuery) Model() string {
+	if len(m.setModelCalls) == 0 || m.setModelCalls[len(m.setModelCalls)-1] == nil {
+		return ""
+	}
+
+	return *m.setModelCalls[len(m.setModelCalls)-1]
+}
+
+func (m *MockQuery) PermissionMode() claudeagent.PermissionMode {
+	if len(m.setPermissionModeCalls) == 0 {
+		return claudeagent.PermissionModeDefault
+	}
+
+	return m.setPermissionModeCalls[len(m.setPermissionModeCalls)-1]
+}
+
 func (*MockQuery) SupportedCommands(
 	_ context.Context,
 ) ([]claudeagent.SlashCommand, error) {
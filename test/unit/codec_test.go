@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// indentJSON pretty-prints a JSON fixture.
+func indentJSON(t *testing.T, line string) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(line), "", "  "); err != nil {
+		t.Fatalf("failed to indent %s: %v", line, err)
+	}
+
+	return out.String()
+}
+
+func TestPrettyJSONCodecRoundTrip(t *testing.T) {
+	var wire bytes.Buffer
+	enc := transport.PrettyJSON.NewEncoder(&wire)
+	for _, msg := range []string{`{"type":"user","n":1}`, `{"type":"user","n":2}`} {
+		if err := enc.Encode([]byte(msg)); err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+	}
+	if !strings.Contains(wire.String(), "{\n  \"type\": \"user\",") {
+		t.Errorf("expected indented frames, got %q", wire.String())
+	}
+
+	dec := transport.PrettyJSON.NewDecoder(&wire)
+	for _, want := range []string{`{"type":"user","n":1}`, `{"type":"user","n":2}`} {
+		got, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		if string(got) != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	}
+	if _, err := dec.Decode(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestStdioTransportCodecEnforcesMaxMessageSize(t *testing.T) {
+	frame := indentJSON(t, `{"text":"`+strings.Repeat("x", 2048)+`"}`)
+	tr := newTestTransport(frame + "\n" + `{"ok":true}`).
+		WithCodec(transport.PrettyJSON).
+		WithMaxMessageSize(1024)
+
+	if _, err := tr.Read(context.Background()); !errors.Is(err, transport.ErrMessageTooLarge) {
+		t.Fatalf("expected ErrMessageTooLarge, got %v", err)
+	}
+
+	data, err := tr.Read(context.Background())
+	if err != nil {
+		t.Fatalf("Read after oversized message failed: %v", err)
+	}
+	if string(data) != `{"ok":true}` {
+		t.Errorf("expected next frame, got %q", data)
+	}
+}
+
+func TestOptionsCodecFramesSession(t *testing.T) {
+	opts := &claudeagent.Options{Codec: claudeagent.PrettyJSONCodec}
+	_, msgs := runFakeSession(t, opts,
+		indentJSON(t, fakeInitLine), indentJSON(t, fakeTextLine("hi")), indentJSON(t, fakeResultLine))
+
+	if len(msgs) == 0 {
+		t.Fatal("expected messages")
+	}
+	if _, ok := msgs[len(msgs)-1].(*claudeagent.SDKResultMessage); !ok {
+		t.Errorf("expected the session to end with a result, got %d messages", len(msgs))
+	}
+
+	stdin := strings.Join(fakeCLIStdin(t, opts.PathToClaudeCodeExecutable, `"type": "user"`, 1), "\n")
+	if !strings.Contains(stdin, "\n  \"type\": \"user\"") {
+		t.Errorf("expected the prompt written as indented JSON, got %s", stdin)
+	}
+}
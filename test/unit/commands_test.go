@@ -0,0 +1,106 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+func TestParseArgumentHint(t *testing.T) {
+	tests := map[string][]claudeagent.CommandArgument{
+		"": nil,
+		"<file> [mode:fast|slow] [notes...]": {
+			{Name: "file", Required: true},
+			{Name: "mode", Enum: []string{"fast", "slow"}},
+			{Name: "notes", Variadic: true},
+		},
+		"<low | high>": {{Name: "low | high", Required: true, Enum: []string{"low", "high"}}},
+		"add [tagId]":  {{Name: "add", Required: true}, {Name: "tagId"}},
+		"[unclosed":    {{Name: "unclosed"}},
+	}
+	for hint, want := range tests {
+		if got := claudeagent.ParseArgumentHint(hint); !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseArgumentHint(%q) = %+v, want %+v", hint, got, want)
+		}
+	}
+}
+
+func TestCompleteCommand(t *testing.T) {
+	commands := []claudeagent.SlashCommand{
+		{Name: "review", Description: "Review a PR", Arguments: claudeagent.ParseArgumentHint("<depth:fast|full> [files...]")},
+		{Name: "release", Description: "Cut a release"},
+		{Name: "compact", Description: "Compact the conversation"},
+	}
+
+	texts := func(completions []claudeagent.CommandCompletion) []string {
+		var out []string
+		for _, c := range completions {
+			out = append(out, c.Text)
+		}
+
+		return out
+	}
+
+	tests := map[string][]string{
+		"/re":           {"/release", "/review "},
+		"/":             {"/compact", "/release", "/review "},
+		"/review ":      {"/review fast ", "/review full "},
+		"/review fu":    {"/review full "},
+		"/review full ": nil, // Free-form files
+		"/missing a":    nil,
+		"review":        nil,
+	}
+	for input, want := range tests {
+		if got := texts(claudeagent.CompleteCommand(commands, input)); !reflect.DeepEqual(got, want) {
+			t.Errorf("CompleteCommand(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestClientCompleteCommand(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stdout.jsonl"), []byte(fakeInitLine+"\n"+fakeResultLine+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+cat stdout.jsonl
+while IFS= read -r line; do
+  printf '%s\n' "$line" >>stdin.jsonl
+  id=$(printf '%s\n' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+  case "$line" in
+  *'"subtype":"supportedCommands"'*)
+    printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{"commands":[{"name":"deploy","description":"Deploy","argumentHint":"<env:staging|production>"}]}}}\n' "$id";;
+  esac
+done
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	client, _ := collectFakeSession(t, &claudeagent.Options{PathToClaudeCodeExecutable: script})
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	for input, want := range map[string]string{"/dep": "/deploy ", "/deploy pro": "/deploy production "} {
+		completions, err := client.CompleteCommand(ctx, input)
+		if err != nil {
+			t.Fatalf("CompleteCommand failed: %v", err)
+		}
+		if len(completions) != 1 || completions[0].Text != want {
+			t.Errorf("CompleteCommand(%q) = %+v, want %q", input, completions, want)
+		}
+	}
+
+	// The commands are fetched once
+	lines := fakeCLIStdin(t, script, `"supportedCommands"`, 1)
+	if n := strings.Count(strings.Join(lines, "\n"), `"subtype":"supportedCommands"`); n != 1 {
+		t.Errorf("expected one supportedCommands request, got %d", n)
+	}
+}
@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// newOldCLIFake writes a fake CLI that records its input and refuses the
+// initialize request with reason, like a CLI too old for the SDK.
+func newOldCLIFake(t *testing.T, reason string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+while IFS= read -r line; do
+  printf '%s\n' "$line" >>stdin.jsonl
+  id=$(printf '%s\n' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+  case "$line" in
+  *'"subtype":"initialize"'*)
+    printf '{"type":"control_response","response":{"subtype":"error","request_id":"%s","error":"%s"}}\n' "$id" '` + reason + `';;
+  esac
+done
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatalf("failed to write fake CLI script: %v", err)
+	}
+
+	return script
+}
+
+func TestProtocolVersionSentWithInitialize(t *testing.T) {
+	script := newHookFakeCLI(t, fakeInitLine, fakeResultLine)
+	collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		Hooks: map[claudeagent.HookEvent][]claudeagent.HookCallbackMatcher{
+			claudeagent.HookEventStop: {{Hooks: []claudeagent.HookCallback{
+				func(context.Context, claudeagent.HookInput, *string) (claudeagent.HookJSONOutput, error) {
+					return claudeagent.SyncHookOutput{}, nil
+				},
+			}}},
+		},
+	})
+
+	lines := fakeCLIStdin(t, script, `"initialize"`, 1)
+	if !strings.Contains(lines[0], fmt.Sprintf(`"protocolVersion":%d`, claudeagent.ProtocolVersion)) {
+		t.Errorf("expected the protocol version in the initialize request, got %s", lines[0])
+	}
+}
+
+func TestOldCLIRejectingHookEventExplained(t *testing.T) {
+	script := newOldCLIFake(t, "Invalid hook event: SubagentStart")
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		Hooks: map[claudeagent.HookEvent][]claudeagent.HookCallbackMatcher{
+			claudeagent.HookEventSubagentStart: {{Hooks: []claudeagent.HookCallback{
+				func(context.Context, claudeagent.HookInput, *string) (claudeagent.HookJSONOutput, error) {
+					return claudeagent.SyncHookOutput{}, nil
+				},
+			}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+	err = client.Query(ctx, "hello")
+	sdkErr, ok := clauderrs.AsSDKError(err)
+	if !ok || sdkErr.Code() != clauderrs.ErrCodeIncompatibleCLI {
+		t.Fatalf("expected ErrCodeIncompatibleCLI, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "CLI 1.x lacks SubagentStart hooks") {
+		t.Errorf("expected targeted advice, got %q", err)
+	}
+}
+
+func TestUnknownContentBlockExplained(t *testing.T) {
+	hologram := strings.Replace(fakeTextLine("hi"), `{"type":"text","text":"hi"}`, `{"type":"hologram"}`, 1)
+	q, err := claudeagent.QueryFunc("hello", &claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, hologram, fakeResultLine),
+	})
+	if err != nil {
+		t.Fatalf("QueryFunc failed: %v", err)
+	}
+	t.Cleanup(func() { _ = q.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+	for err == nil {
+		_, err = q.Next(ctx)
+	}
+
+	sdkErr, ok := clauderrs.AsSDKError(err)
+	if !ok || sdkErr.Code() != clauderrs.ErrCodeIncompatibleCLI {
+		t.Fatalf("expected ErrCodeIncompatibleCLI, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `"hologram"`) || !strings.Contains(err.Error(), "upgrade the SDK") {
+		t.Errorf("expected targeted advice, got %q", err)
+	}
+}
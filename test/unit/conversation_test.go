@@ -0,0 +1,180 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// newTwoTurnFakeCLI writes a fake CLI answering two prompts, recording
+// each as prompt-1 and prompt-2. It returns the script path and its
+// directory.
+func newTwoTurnFakeCLI(t *testing.T, first, second []string) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, lines := range map[string][]string{"turn-1.jsonl": first, "turn-2.jsonl": second} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatalf("failed to write fake CLI output: %v", err)
+		}
+	}
+
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+for turn in 1 2; do
+  read -r line
+  printf '%s\n' "$line" >prompt-$turn
+  cat turn-$turn.jsonl
+done
+cat >/dev/null
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatalf("failed to write fake CLI script: %v", err)
+	}
+
+	return script, dir
+}
+
+func TestConversationRecordsTurns(t *testing.T) {
+	script, dir := newTwoTurnFakeCLI(t,
+		[]string{fakeInitLine, fakeTextLine("first answer"), fakeResultLine},
+		[]string{fakeTextLine("second answer"), fakeResultLine},
+	)
+	client, err := claudeagent.NewClient(&claudeagent.Options{PathToClaudeCodeExecutable: script})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	conv := claudeagent.NewConversation(client)
+	if conv.LastAssistantText() != "" || conv.Turns() != 0 {
+		t.Fatal("expected an empty conversation")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	turn, err := conv.Send(ctx, "first question")
+	if err != nil {
+		t.Fatalf("first Send failed: %v", err)
+	}
+	if turn.Text() != "first answer" || turn.Result == nil || len(turn.Messages) != 3 {
+		t.Errorf("unexpected first turn: %+v", turn)
+	}
+
+	conv.AppendSystemNote("the ticket was reassigned")
+	if _, err := conv.Send(ctx, "second question"); err != nil {
+		t.Fatalf("second Send failed: %v", err)
+	}
+
+	history := conv.History()
+	if conv.Turns() != 2 || len(history) != 2 {
+		t.Fatalf("expected two turns, got %d", len(history))
+	}
+	if history[1].Index != 1 || history[1].Prompt != "second question" || len(history[1].Notes) != 1 {
+		t.Errorf("unexpected second turn: %+v", history[1])
+	}
+	if got := conv.LastAssistantText(); got != "second answer" {
+		t.Errorf("expected the latest answer, got %q", got)
+	}
+
+	prompt := readFakeFile(t, dir, "prompt-2")
+	if !strings.Contains(prompt, `system-note\u003e\nthe ticket was reassigned`) {
+		t.Errorf("expected the note ahead of the prompt, got %s", prompt)
+	}
+	if !strings.Contains(prompt, "second question") {
+		t.Errorf("expected the prompt, got %s", prompt)
+	}
+	if strings.Contains(readFakeFile(t, dir, "prompt-1"), "system-note") {
+		t.Error("expected no note with the first prompt")
+	}
+}
+
+func TestConversationReportsMissingResult(t *testing.T) {
+	// The fake CLI exits after a partial answer, without a result.
+	dir := t.TempDir()
+	script := filepath.Join(dir, "claude")
+	body := "#!/bin/sh\nread -r line\nprintf '%s\\n' '" + fakeInitLine + "' '" + fakeTextLine("partial") + "'\n"
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	client, err := claudeagent.NewClient(&claudeagent.Options{PathToClaudeCodeExecutable: script})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	conv := claudeagent.NewConversation(client)
+	turn, err := conv.Send(ctx, "question")
+	if err == nil || turn.Err != err || turn.Result != nil {
+		t.Errorf("expected a failed turn, got %+v, %v", turn, err)
+	}
+	if conv.LastAssistantText() != "partial" {
+		t.Errorf("expected the partial answer to be recorded, got %q", conv.LastAssistantText())
+	}
+}
+
+func TestConversationSpillsOldTurns(t *testing.T) {
+	script, _ := newTwoTurnFakeCLI(t,
+		[]string{fakeInitLine, fakeToolUseLine("toolu_1", "Bash", `{"command":"ls"}`), fakeTextLine("first answer"), fakeResultLine},
+		[]string{fakeTextLine("second answer"), fakeResultLine},
+	)
+	client, err := claudeagent.NewClient(&claudeagent.Options{PathToClaudeCodeExecutable: script})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	spillDir := t.TempDir()
+	conv := claudeagent.NewConversation(client)
+	if err := conv.LimitMemory(claudeagent.ConversationMemory{MaxTurns: 1, Dir: spillDir}); err != nil {
+		t.Fatalf("LimitMemory failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+	conv.AppendSystemNote("be brief")
+	if _, err := conv.Send(ctx, "first question"); err != nil {
+		t.Fatalf("first Send failed: %v", err)
+	}
+	if _, err := conv.Send(ctx, "second question"); err != nil {
+		t.Fatalf("second Send failed: %v", err)
+	}
+
+	first, err := conv.Turn(0)
+	if err != nil {
+		t.Fatalf("Turn failed: %v", err)
+	}
+	if first.Index != 0 || first.Prompt != "first question" || len(first.Notes) != 1 || len(first.Messages) != 4 {
+		t.Errorf("unexpected spilled turn: %+v", first)
+	}
+	if first.Text() != "first answer" || len(first.ToolUses()) != 1 || first.Result == nil || first.Result.NumTurns != 1 {
+		t.Errorf("expected the spilled turn's messages back, got %+v", first)
+	}
+
+	page, err := conv.TurnsPage(1, 10)
+	if err != nil || len(page) != 1 || page[0].Index != 1 {
+		t.Errorf("expected the second turn alone, got %+v, %v", page, err)
+	}
+	if conv.Turns() != 2 || len(conv.History()) != 2 {
+		t.Errorf("expected two turns, got %d", conv.Turns())
+	}
+	if _, err := conv.Turn(2); err == nil {
+		t.Error("expected an out-of-range turn to fail")
+	}
+
+	if err := conv.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(spillDir); len(entries) != 0 {
+		t.Errorf("expected the spill file to be removed, got %v", entries)
+	}
+}
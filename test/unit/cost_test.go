@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestParseUSD(t *testing.T) {
+	tests := []struct {
+		input string
+		want  claudeagent.USD
+		str   string
+	}{
+		{"0.0123", 12_300_000, "0.0123"},
+		{"1e-7", 100, "0.0000001"},
+		{"2", 2_000_000_000, "2"},
+		{"-0.5", -500_000_000, "-0.5"},
+		{"0.0000000005", 1, "0.000000001"},
+		{"-0.0000000005", -1, "-0.000000001"},
+		{"0.0000000004", 0, "0"},
+	}
+	for _, tt := range tests {
+		got, err := claudeagent.ParseUSD(tt.input)
+		if err != nil {
+			t.Errorf("ParseUSD(%q) failed: %v", tt.input, err)
+
+			continue
+		}
+		if got != tt.want || got.String() != tt.str {
+			t.Errorf("ParseUSD(%q) = %d (%s), want %d (%s)", tt.input, got, got, tt.want, tt.str)
+		}
+	}
+
+	for _, input := range []string{"", "abc", "1/3", "0x1p-2", "1e30"} {
+		if _, err := claudeagent.ParseUSD(input); !clauderrs.IsValidationError(err) {
+			t.Errorf("ParseUSD(%q): expected ValidationError, got %v", input, err)
+		}
+	}
+}
+
+func TestUSDSumsExactly(t *testing.T) {
+	var result claudeagent.SDKResultMessage
+	if err := json.Unmarshal([]byte(`{"type":"result","subtype":"success","total_cost_usd":0.0001}`), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	var total claudeagent.USD
+	for range 10_000 {
+		total += result.TotalCost()
+	}
+	if total.String() != "1" {
+		t.Errorf("expected exactly $1, got %s", total)
+	}
+
+	usage := claudeagent.ModelUsage{CostUSD: 0.1 + 0.2}
+	if usage.Cost().String() != "0.3" {
+		t.Errorf("expected 0.3, got %s", usage.Cost())
+	}
+}
+
+func TestResultCostDecodesExactly(t *testing.T) {
+	// More digits than a float64 holds
+	var result claudeagent.SDKResultMessage
+	err := json.Unmarshal([]byte(`{"type":"result","subtype":"success","total_cost_usd":12345678.123456789,`+
+		`"modelUsage":{"claude":{"costUSD":12345678.123456789}}}`), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := result.TotalCost().String(); got != "12345678.123456789" {
+		t.Errorf("expected the cost as written, got %s", got)
+	}
+	if got := result.ModelUsage["claude"].Cost().String(); got != "12345678.123456789" {
+		t.Errorf("expected the model cost as written, got %s", got)
+	}
+	if result.TotalCostUSD == 0 {
+		t.Error("expected TotalCostUSD to be decoded too")
+	}
+}
+
+func TestUSDJSON(t *testing.T) {
+	var v struct {
+		Cost   claudeagent.USD `json:"cost"`
+		Quoted claudeagent.USD `json:"quoted"`
+	}
+	if err := json.Unmarshal([]byte(`{"cost":0.015,"quoted":"1.25"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Cost.String() != "0.015" || v.Quoted.String() != "1.25" || v.Cost.Float64() != 0.015 {
+		t.Errorf("unexpected amounts %s, %s", v.Cost, v.Quoted)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil || string(data) != `{"cost":0.015,"quoted":1.25}` {
+		t.Errorf("unexpected encoding %s, %v", data, err)
+	}
+	if err := json.Unmarshal([]byte(`{"cost":"lots"}`), &v); err == nil {
+		t.Error("expected an error for a malformed amount")
+	}
+}
@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// envLines returns the lines of env.txt written by newEnvFakeCLI.
+func envLines(t *testing.T, dir string) map[string]bool {
+	t.Helper()
+
+	lines := make(map[string]bool)
+	for _, line := range strings.Split(readFakeFile(t, dir, "env.txt"), "\n") {
+		lines[line] = true
+	}
+
+	return lines
+}
+
+func TestCredentialsProviderSetsCLIEnvironment(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "sk-static")
+
+	var calls atomic.Int32
+	opts, err := claudeagent.NewOptions().
+		WithCredentialsProvider(claudeagent.CredentialsProviderFunc(
+			func(context.Context) (*claudeagent.Credentials, error) {
+				n := calls.Add(1)
+
+				return &claudeagent.Credentials{OAuthToken: "token-" + strconv.Itoa(int(n))}, nil
+			},
+		)).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	for _, want := range []string{"CLAUDE_CODE_OAUTH_TOKEN=token-1", "CLAUDE_CODE_OAUTH_TOKEN=token-2"} {
+		script, dir := newEnvFakeCLI(t)
+		opts.PathToClaudeCodeExecutable = script
+		if _, err := claudeagent.QueryResult(ctx, "hello", opts); err != nil {
+			t.Fatalf("QueryResult failed: %v", err)
+		}
+
+		env := envLines(t, dir)
+		if !env[want] {
+			t.Errorf("expected %s in CLI environment", want)
+		}
+		if env["ANTHROPIC_API_KEY=sk-static"] {
+			t.Error("expected the inherited API key to be cleared")
+		}
+	}
+}
+
+func TestCredentialsProviderErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		creds *claudeagent.Credentials
+		err   error
+		want  string
+	}{
+		{name: "provider error", err: errors.New("vault sealed"), want: "vault sealed"},
+		{name: "empty", creds: &claudeagent.Credentials{}, want: "no API key or OAuth token"},
+		{
+			name:  "expired",
+			creds: &claudeagent.Credentials{APIKey: "sk-old", ExpiresAt: time.Now().Add(-time.Minute)},
+			want:  "expired",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := claudeagent.NewClient(&claudeagent.Options{
+				PathToClaudeCodeExecutable: "/nonexistent/claude",
+				CredentialsProvider: claudeagent.CredentialsProviderFunc(
+					func(context.Context) (*claudeagent.Credentials, error) { return tt.creds, tt.err },
+				),
+			})
+			if err != nil {
+				t.Fatalf("NewClient failed: %v", err)
+			}
+
+			err = client.Query(context.Background(), "hello")
+			if !clauderrs.IsClientError(err) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected client error containing %q, got %v", tt.want, err)
+			}
+			if strings.Contains(err.Error(), "sk-old") {
+				t.Errorf("expected the secret to stay out of the error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRefreshingCredentials(t *testing.T) {
+	var calls atomic.Int32
+	expiry := time.Now().Add(time.Hour)
+	refreshing := &claudeagent.RefreshingCredentials{
+		Provider: claudeagent.CredentialsProviderFunc(func(context.Context) (*claudeagent.Credentials, error) {
+			calls.Add(1)
+
+			return &claudeagent.Credentials{APIKey: "sk-live", ExpiresAt: expiry}, nil
+		}),
+		RefreshBefore: 30 * time.Minute,
+	}
+
+	for range 3 {
+		if _, err := refreshing.Credentials(context.Background()); err != nil {
+			t.Fatalf("Credentials failed: %v", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected credentials to be cached, got %d calls", calls.Load())
+	}
+
+	refreshing.RefreshBefore = 2 * time.Hour
+	if _, err := refreshing.Credentials(context.Background()); err != nil {
+		t.Fatalf("Credentials failed: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected credentials near expiry to be refreshed, got %d calls", calls.Load())
+	}
+}
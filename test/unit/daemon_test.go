@@ -0,0 +1,397 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// daemonRequest is the request line a client sends a claude daemon.
+type daemonRequest struct {
+	Args []string `json:"args"`
+	Env  []string `json:"env"`
+	Cwd  string   `json:"cwd"`
+}
+
+// newFakeDaemon listens on a Unix socket, answering each connection with
+// reply and then lines, and sends the requests it receives on the returned
+// channel.
+func newFakeDaemon(t *testing.T, reply string, lines ...string) (string, <-chan daemonRequest) {
+	t.Helper()
+
+	// Socket paths are limited to about 100 bytes, too short for TempDir
+	dir, err := os.MkdirTemp("", "daemon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "claude.sock")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	requests := make(chan daemonRequest, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+
+				reader := bufio.NewReader(conn)
+				line, err := reader.ReadBytes('\n')
+				if err != nil {
+					return
+				}
+				var request daemonRequest
+				_ = json.Unmarshal(line, &request)
+				requests <- request
+
+				_, _ = conn.Write([]byte(reply + "\n" + strings.Join(lines, "\n") + "\n"))
+				// Hold the connection until the client closes it
+				for {
+					if _, err := reader.ReadBytes('\n'); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return socket, requests
+}
+
+func TestDaemonRunsSession(t *testing.T) {
+	socket, requests := newFakeDaemon(t, `{"warm":true}`, fakeInitLine, fakeTextLine("from the daemon"), fakeResultLine)
+
+	_, messages := collectFakeSession(t, &claudeagent.Options{
+		Daemon: socket,
+		Cwd:    t.TempDir(),
+		Env:    map[string]string{"DAEMON_TEST": "1"},
+	})
+
+	var text string
+	for _, msg := range messages {
+		if assistant, ok := msg.(*claudeagent.SDKAssistantMessage); ok {
+			if block, ok := assistant.Message.Content[0].(claudeagent.TextContentBlock); ok {
+				text = block.Text
+			}
+		}
+	}
+	if text != "from the daemon" {
+		t.Errorf("expected the daemon's response, got %q", text)
+	}
+	if _, ok := messages[len(messages)-1].(*claudeagent.SDKResultMessage); !ok {
+		t.Errorf("expected the session to end with its result, got %T", messages[len(messages)-1])
+	}
+
+	request := <-requests
+	if !strings.Contains(strings.Join(request.Args, " "), "--output-format=stream-json") {
+		t.Errorf("expected the CLI's arguments in the request, got %v", request.Args)
+	}
+	if request.Cwd == "" || !strings.Contains(strings.Join(request.Env, " "), "DAEMON_TEST=1") {
+		t.Errorf("expected the working directory and environment in the request, got %+v", request)
+	}
+}
+
+func TestDaemonRefusesSession(t *testing.T) {
+	socket, _ := newFakeDaemon(t, `{"error":"claude not found"}`)
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{Daemon: socket})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	err = client.Query(ctx, "hello")
+	if err == nil || !strings.Contains(err.Error(), "claude not found") {
+		t.Errorf("expected the daemon's error, got %v", err)
+	}
+}
+
+func TestDaemonConflictsWithLocalProcessOptions(t *testing.T) {
+	_, err := claudeagent.NewOptions().
+		WithDaemon("/run/claude.sock").
+		WithProcessLimits(claudeagent.ProcessLimits{MaxRSS: 1 << 30}).
+		Build()
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeConflictingOptions {
+		t.Errorf("expected ErrCodeConflictingOptions, got %v", err)
+	}
+}
+
+// startDaemon builds and runs claude-daemon serving the fake CLI script,
+// returning its socket.
+func startDaemon(t *testing.T, script string) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "daemon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	binary := filepath.Join(dir, "claude-daemon")
+	build := exec.Command("go", "build", "-o", binary, "github.com/connerohnesorge/claude-agent-sdk-go/cmd/claude-daemon")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build claude-daemon: %v\n%s", err, out)
+	}
+
+	socket := filepath.Join(dir, "claude.sock")
+	cmd := exec.Command(binary, "-listen", socket, "-claude", script, "-warm", "0")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Signal(os.Interrupt)
+		_ = cmd.Wait()
+	})
+
+	deadline := time.Now().Add(fakeCLITimeout)
+	for {
+		if _, err := os.Stat(socket); err == nil {
+			return socket
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("claude-daemon did not create its socket")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDaemonCommandRefusesUnsafeRequests(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix sockets only")
+	}
+	socket := startDaemon(t, newFakeCLI(t, fakeInitLine, fakeResultLine))
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("expected the socket to have mode 0600, got %v", mode)
+	}
+
+	request := func(line string) string {
+		t.Helper()
+
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = conn.Close() }()
+		if _, err := conn.Write([]byte(line + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		reply, _ := bufio.NewReader(conn).ReadString('\n')
+
+		return reply
+	}
+
+	for _, line := range []string{
+		`{"args":["--print"],"env":["LD_PRELOAD=/tmp/x.so"]}`,
+		`{"args":["--print"],"env":["PATH=/tmp"]}`,
+		`{"args":["--plugin-dir","/tmp/plugin"]}`,
+		`{"args":["--settings","/tmp/settings.json"]}`,
+		`{"args":["--mcp-config","{\"mcpServers\":{\"x\":{\"command\":\"sh\"}}}"]}`,
+		`{"args":["--permission-mode","bypassPermissions"]}`,
+		`{"args":["-c","id"]}`,
+	} {
+		if reply := request(line); !strings.Contains(reply, "request refused") {
+			t.Errorf("%s: expected the request refused, got %q", line, reply)
+		}
+	}
+
+	allowed := `{"args":["--print","--output-format=stream-json","--input-format=stream-json","--model","sonnet",` +
+		`"--mcp-config","{\"mcpServers\":{\"tools\":{\"type\":\"sdk\",\"name\":\"tools\"}}}"],"env":["ANTHROPIC_MODEL=x"]}`
+	if reply := request(allowed); strings.Contains(reply, "error") || reply == "" {
+		t.Errorf("expected the request served, got %q", reply)
+	}
+}
+
+// newEchoFakeCLI writes a fake CLI answering each message with its text.
+func newEchoFakeCLI(t *testing.T) string {
+	t.Helper()
+
+	prefix, suffix, _ := strings.Cut(fakeTextLine("TEXT"), "TEXT")
+	script := filepath.Join(t.TempDir(), "claude")
+	body := "#!/bin/sh\n" +
+		"printf '%s\\n' '" + fakeInitLine + "'\n" +
+		"while IFS= read -r line; do\n" +
+		"  text=$(printf '%s' \"$line\" | sed -n 's/.*\"text\":\"\\([^\"]*\\)\".*/\\1/p')\n" +
+		"  printf '%s%s%s\\n' '" + prefix + "' \"$text\" '" + suffix + "'\n" +
+		"  printf '%s\\n' '" + fakeResultLine + "'\n" +
+		"done\n"
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatalf("failed to write fake CLI: %v", err)
+	}
+
+	return script
+}
+
+func TestMultiplexerRoutesSessions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix sockets only")
+	}
+	socket := startDaemon(t, newEchoFakeCLI(t))
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	mux, err := claudeagent.NewMultiplexer(ctx, socket)
+	if err != nil {
+		t.Fatalf("NewMultiplexer failed: %v", err)
+	}
+	t.Cleanup(func() { _ = mux.Close() })
+
+	clients := make([]*claudeagent.ClaudeSDKClient, 3)
+	for i := range clients {
+		client, err := claudeagent.NewClient(&claudeagent.Options{Multiplexer: mux})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+		clients[i] = client
+	}
+
+	for round := range 2 {
+		for i, client := range clients {
+			if err := client.Query(ctx, fmt.Sprintf("session %d round %d", i, round)); err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+		}
+		if got := mux.Sessions(); got != len(clients) {
+			t.Errorf("expected %d sessions on the connection, got %d", len(clients), got)
+		}
+		for i, client := range clients {
+			var text string
+			for msg := range client.ReceiveResponse(ctx) {
+				if assistant, ok := msg.(*claudeagent.SDKAssistantMessage); ok {
+					text = assistant.Message.Content[0].(claudeagent.TextContentBlock).Text
+				}
+			}
+			if want := fmt.Sprintf("session %d round %d", i, round); text != want {
+				t.Errorf("expected %q, got %q", want, text)
+			}
+		}
+	}
+
+	if err := clients[0].Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := mux.Sessions(); got != len(clients)-1 {
+		t.Errorf("expected a closed client to leave the connection, got %d sessions", got)
+	}
+	if err := clients[1].Query(ctx, "still here"); err != nil {
+		t.Fatalf("Query after another client closed failed: %v", err)
+	}
+	for range clients[1].ReceiveResponse(ctx) {
+	}
+}
+
+func TestMultiplexerRefusesSession(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix sockets only")
+	}
+	socket := startDaemon(t, newEchoFakeCLI(t))
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	mux, err := claudeagent.NewMultiplexer(ctx, socket)
+	if err != nil {
+		t.Fatalf("NewMultiplexer failed: %v", err)
+	}
+	t.Cleanup(func() { _ = mux.Close() })
+
+	refused, err := claudeagent.NewClient(&claudeagent.Options{
+		Multiplexer: mux,
+		Env:         map[string]string{"LD_PRELOAD": "/tmp/x.so"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = refused.Close() })
+	if err := refused.Query(ctx, "hello"); err == nil || !strings.Contains(err.Error(), "request refused") {
+		t.Errorf("expected the daemon to refuse the session, got %v", err)
+	}
+
+	_, messages := collectFakeSession(t, &claudeagent.Options{Multiplexer: mux})
+	if _, ok := messages[len(messages)-1].(*claudeagent.SDKResultMessage); !ok {
+		t.Errorf("expected the connection to keep serving sessions, got %T", messages[len(messages)-1])
+	}
+}
+
+func TestMultiplexerClosesSessions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix sockets only")
+	}
+	socket := startDaemon(t, newEchoFakeCLI(t))
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	mux, err := claudeagent.NewMultiplexer(ctx, socket)
+	if err != nil {
+		t.Fatalf("NewMultiplexer failed: %v", err)
+	}
+	client, err := claudeagent.NewClient(&claudeagent.Options{Multiplexer: mux})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	if err := client.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for range client.ReceiveResponse(ctx) {
+	}
+
+	if err := mux.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if mux.Sessions() != 0 {
+		t.Errorf("expected no sessions after Close, got %d", mux.Sessions())
+	}
+	if err := client.Query(ctx, "again"); err == nil {
+		t.Error("expected a session of a closed Multiplexer to fail")
+	}
+
+	other, err := claudeagent.NewClient(&claudeagent.Options{Multiplexer: mux})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = other.Close() })
+	if err := other.Query(ctx, "hello"); err == nil {
+		t.Error("expected a new session on a closed Multiplexer to fail")
+	}
+}
+
+func TestMultiplexerConflictsWithDaemon(t *testing.T) {
+	_, err := claudeagent.NewOptions().
+		WithDaemon("/run/claude.sock").
+		WithMultiplexer(&claudeagent.Multiplexer{}).
+		Build()
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeConflictingOptions {
+		t.Errorf("expected ErrCodeConflictingOptions, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/debugger"
+)
+
+// debuggerTranscript builds a recorded run whose result text is result.
+func debuggerTranscript(uuid, result string) string {
+	return strings.Join([]string{
+		fakeInitLine,
+		`{"type":"control_request","request_id":"cli_1","request":{"subtype":"hook_callback","callback_id":"hook_0","input":{"hook_event_name":"PreToolUse","session_id":"s","transcript_path":"/tmp/t","cwd":"/tmp","tool_name":"Bash","tool_input":{"command":"ls"}}}}`,
+		fakeCanUseToolLine("cli_2", "Bash", "rm -rf /"),
+		`{"type":"control_response","uuid":"` + uuid + `","response":{"subtype":"success","request_id":"cli_2","response":{"allow":false,"reason":"too dangerous"}}}`,
+		`{"type":"stream_event","uuid":"` + uuid + `","session_id":"s","event":{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}}`,
+		strings.Replace(fakeResultLine, `"result":"done"`, `"result":"`+result+`"`, 1),
+	}, "\n")
+}
+
+// TestDebuggerSteps verifies frames decode into inspectable steps.
+func TestDebuggerSteps(t *testing.T) {
+	transcript, err := debugger.Load(strings.NewReader(
+		debuggerTranscript("00000000-0000-0000-0000-0000000000aa", "done"),
+	))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	d := debugger.New(transcript)
+	if d.Len() != 6 || d.Current() != nil {
+		t.Fatalf("unexpected initial state: len=%d current=%v", d.Len(), d.Current())
+	}
+
+	step, ok := d.Until(func(s *debugger.Step) bool { return s.Hook != nil })
+	if !ok {
+		t.Fatal("expected hook step")
+	}
+	if _, ok := step.Hook.Input.(claudeagent.PreToolUseHookInput); !ok {
+		t.Errorf("expected PreToolUse input, got %T", step.Hook.Input)
+	}
+
+	step, ok = d.Next()
+	if !ok || step.Permission == nil {
+		t.Fatal("expected permission step")
+	}
+	if step.Permission.Decision != "deny" || step.Permission.Reason != "too dangerous" {
+		t.Errorf("unexpected decision %+v", step.Permission)
+	}
+
+	step, ok = d.Until(func(s *debugger.Step) bool { return s.Delta != "" })
+	if !ok || step.Delta != "Hel" {
+		t.Errorf("expected text delta, got %+v", step)
+	}
+
+	if step, ok := d.Prev(); !ok || step.Type != "control_response" {
+		t.Errorf("expected to step back to control response, got %+v", step)
+	}
+
+	step, ok = d.Seek(5)
+	if !ok {
+		t.Fatal("Seek failed")
+	}
+	if _, ok := step.Message.(*claudeagent.SDKResultMessage); !ok {
+		t.Errorf("expected result message, got %T", step.Message)
+	}
+	if _, ok := d.Next(); ok {
+		t.Error("expected end of transcript")
+	}
+}
+
+// TestDebuggerDiff verifies volatile fields are ignored and real changes
+// are reported.
+func TestDebuggerDiff(t *testing.T) {
+	load := func(uuid, result string) *debugger.Transcript {
+		transcript, err := debugger.Load(strings.NewReader(debuggerTranscript(uuid, result)))
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+
+		return transcript
+	}
+
+	base := load("00000000-0000-0000-0000-0000000000aa", "done")
+	same := load("00000000-0000-0000-0000-0000000000bb", "done")
+	changed := load("00000000-0000-0000-0000-0000000000aa", "different")
+
+	if diffs := debugger.Diff(base, same); len(diffs) != 0 {
+		t.Errorf("expected no differences, got %d", len(diffs))
+	}
+
+	diffs := debugger.Diff(base, changed)
+	if len(diffs) != 1 || diffs[0].Index != 5 {
+		t.Errorf("expected a difference at the result step, got %+v", diffs)
+	}
+}
@@ -0,0 +1,157 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// recordedMetrics is a MetricsRecorder keeping totals by name and labels.
+type recordedMetrics struct {
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func (m *recordedMetrics) add(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := name
+	for _, label := range []string{"op", "code", "subtype", "kind", "tool", "status"} {
+		if v, ok := labels[label]; ok {
+			key += " " + label + "=" + v
+		}
+	}
+	m.values[key] += value
+}
+
+func (m *recordedMetrics) Count(name string, delta float64, labels map[string]string) {
+	m.add(name, delta, labels)
+}
+
+func (m *recordedMetrics) Observe(name string, value float64, labels map[string]string) {
+	m.add(name, value, labels)
+}
+
+func TestDecoratorsLogAndRecordTurns(t *testing.T) {
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeTextLine("hi"), fakeResultLine),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	var logs bytes.Buffer
+	metrics := &recordedMetrics{values: make(map[string]float64)}
+	decorated := claudeagent.WithMetrics(
+		claudeagent.WithLogging(client, slog.New(slog.NewTextHandler(&logs, nil))),
+		metrics,
+	)
+	t.Cleanup(func() { _ = decorated.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := decorated.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var received int
+	for range decorated.ReceiveResponse(ctx) {
+		received++
+	}
+	if received != 3 {
+		t.Errorf("expected the decorators to pass on 3 messages, got %d", received)
+	}
+
+	for key, want := range map[string]float64{
+		claudeagent.MetricRequests + " op=query":     1,
+		claudeagent.MetricTurns + " subtype=success": 1,
+		claudeagent.MetricTurnDuration:               0.01,
+		claudeagent.MetricTurnCost:                   0.01,
+		claudeagent.MetricTokens + " kind=input":     10,
+		claudeagent.MetricTokens + " kind=output":    5,
+	} {
+		if got := metrics.values[key]; got != want {
+			t.Errorf("expected %s to be %v, got %v", key, want, got)
+		}
+	}
+	if !strings.Contains(logs.String(), `msg="claude turn completed"`) || !strings.Contains(logs.String(), "cost_usd=0.01") {
+		t.Errorf("expected the turn to be logged, got %s", logs.String())
+	}
+}
+
+func TestWithMetricsRecordsToolCalls(t *testing.T) {
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		ToolTiming:                 true,
+		PathToClaudeCodeExecutable: newTimedToolFakeCLI(t, "0.1"),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	metrics := &recordedMetrics{values: make(map[string]float64)}
+	decorated := claudeagent.WithMetrics(claudeagent.WithRetry(client, claudeagent.RetryPolicy{}), metrics)
+	t.Cleanup(func() { _ = decorated.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := decorated.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for range decorated.ReceiveResponse(ctx) {
+	}
+
+	if got := metrics.values[claudeagent.MetricToolCalls+" tool=Bash status=success"]; got != 1 {
+		t.Errorf("expected one Bash call recorded, got %v", got)
+	}
+	if got := metrics.values[claudeagent.MetricToolDuration+" tool=Bash"]; got < 0.1 {
+		t.Errorf("expected the Bash call's duration from its hooks, got %v", got)
+	}
+}
+
+// flakyClient is a Client whose Query fails with errs, in order, before
+// succeeding.
+type flakyClient struct {
+	claudeagent.Client
+	errs  []error
+	calls int
+}
+
+func (c *flakyClient) Query(context.Context, string) error {
+	c.calls++
+	if len(c.errs) == 0 {
+		return nil
+	}
+	err := c.errs[0]
+	c.errs = c.errs[1:]
+
+	return err
+}
+
+func TestWithRetryRepeatsTransientFailures(t *testing.T) {
+	transient := clauderrs.NewTransportError(clauderrs.ErrCodeWriteFailed, "CLI exited", nil)
+	policy := claudeagent.RetryPolicy{MaxAttempts: 3, Delay: time.Millisecond}
+
+	flaky := &flakyClient{errs: []error{transient, transient}}
+	if err := claudeagent.WithRetry(flaky, policy).Query(context.Background(), "hello"); err != nil || flaky.calls != 3 {
+		t.Errorf("expected success on the third attempt, got %v after %d calls", err, flaky.calls)
+	}
+
+	flaky = &flakyClient{errs: []error{transient, transient, transient}}
+	if err := claudeagent.WithRetry(flaky, policy).Query(context.Background(), "hello"); !errors.Is(err, transient) || flaky.calls != 3 {
+		t.Errorf("expected to give up after 3 attempts, got %v after %d calls", err, flaky.calls)
+	}
+
+	invalid := clauderrs.NewValidationError(clauderrs.ErrCodeMissingField, "no prompt", nil, "prompt", "")
+	flaky = &flakyClient{errs: []error{invalid}}
+	if err := claudeagent.WithRetry(flaky, policy).Query(context.Background(), ""); !errors.Is(err, invalid) || flaky.calls != 1 {
+		t.Errorf("expected no retry of a validation error, got %v after %d calls", err, flaky.calls)
+	}
+}
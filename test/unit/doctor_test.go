@@ -0,0 +1,82 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// doctorChecks indexes a report's checks by name.
+func doctorChecks(report claudeagent.DoctorReport) map[string]claudeagent.DoctorCheck {
+	checks := make(map[string]claudeagent.DoctorCheck, len(report.Checks))
+	for _, check := range report.Checks {
+		checks[check.Name] = check
+	}
+
+	return checks
+}
+
+func TestDoctor(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("x-api-key") != "good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer api.Close()
+
+	cli := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\necho '2.1.3 (Claude Code)'\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	opts := &claudeagent.Options{
+		PathToClaudeCodeExecutable: cli,
+		Cwd:                        t.TempDir(),
+		Env:                        map[string]string{"ANTHROPIC_BASE_URL": api.URL, "ANTHROPIC_API_KEY": "good-key"},
+		McpServers: map[string]claudeagent.McpServerConfig{
+			"shell":   claudeagent.McpStdioServerConfig{Command: "sh"},
+			"missing": claudeagent.McpStdioServerConfig{Command: "no-such-mcp-server"},
+			"remote":  claudeagent.McpHTTPServerConfig{Type: "http", URL: "localhost:8080"},
+		},
+	}
+
+	ctx := context.Background()
+	checks := doctorChecks(claudeagent.Doctor(ctx, opts))
+	want := map[string]claudeagent.DoctorStatus{
+		"cli":         claudeagent.DoctorPass,
+		"credentials": claudeagent.DoctorPass,
+		"workspace":   claudeagent.DoctorPass,
+		"mcp:shell":   claudeagent.DoctorPass,
+		"mcp:missing": claudeagent.DoctorFail,
+		"mcp:remote":  claudeagent.DoctorFail,
+	}
+	for name, status := range want {
+		if checks[name].Status != status {
+			t.Errorf("%s: expected %s, got %+v", name, status, checks[name])
+		}
+	}
+	if !strings.HasPrefix(checks["cli"].Detail, "2.1.3 at ") {
+		t.Errorf("expected the CLI version, got %q", checks["cli"].Detail)
+	}
+	if _, ok := checks["node"]; !ok {
+		t.Error("expected a node check")
+	}
+
+	// A bad key, missing CLI, and unwritable workspace fail.
+	opts.Env["ANTHROPIC_API_KEY"] = "bad-key"
+	opts.PathToClaudeCodeExecutable = filepath.Join(t.TempDir(), "missing")
+	opts.Cwd = filepath.Join(t.TempDir(), "gone")
+	opts.McpServers = nil
+	report := claudeagent.Doctor(ctx, opts)
+	if report.OK() || len(report.Failed()) != 3 {
+		t.Errorf("expected cli, credentials and workspace to fail, got %+v", report.Failed())
+	}
+}
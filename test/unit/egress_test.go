@@ -0,0 +1,164 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// runEgressSession runs a fake session under policy and returns the
+// proxy URL the CLI was given.
+func runEgressSession(t *testing.T, policy claudeagent.EgressPolicy) *url.URL {
+	t.Helper()
+
+	dir := t.TempDir()
+	wrapper := filepath.Join(dir, "claude")
+	body := "#!/bin/sh\nenv >'" + filepath.Join(dir, "env") + "'\nexec '" +
+		newFakeCLI(t, fakeInitLine, fakeResultLine) + "'\n"
+	if err := os.WriteFile(wrapper, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: wrapper,
+		EgressPolicy:               &policy,
+	})
+
+	env := readFakeFile(t, dir, "env")
+	if !strings.Contains(env, "NO_PROXY=localhost") {
+		t.Errorf("expected NO_PROXY for local connections, got %s", env)
+	}
+	for _, line := range strings.Split(env, "\n") {
+		if value, ok := strings.CutPrefix(line, "HTTPS_PROXY="); ok {
+			proxyURL, err := url.Parse(value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return proxyURL
+		}
+	}
+	t.Fatalf("expected HTTPS_PROXY in the CLI environment, got %s", env)
+
+	return nil
+}
+
+func TestEgressPolicyFiltersConnections(t *testing.T) {
+	target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "reached")
+	}))
+	defer target.Close()
+
+	var mu sync.Mutex
+	var denied []error
+	proxyURL := runEgressSession(t, claudeagent.EgressPolicy{
+		AllowedHosts: []string{"127.0.0.1"},
+		OnDenied: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			denied = append(denied, err)
+		},
+	})
+
+	client := target.Client()
+	client.Transport.(*http.Transport).Proxy = http.ProxyURL(proxyURL)
+
+	// An allowed host is tunneled through the proxy.
+	resp, err := client.Get(target.URL)
+	if err != nil {
+		t.Fatalf("expected allowed request to succeed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+
+	// Other hosts are refused, over plain HTTP and CONNECT alike.
+	port := strings.TrimPrefix(target.URL, "https://127.0.0.1")
+	if resp, err := client.Get("http://localhost" + port); err != nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for a plain request to a denied host, got %v, %v", resp, err)
+	} else {
+		_ = resp.Body.Close()
+	}
+	if _, err := client.Get("https://localhost" + port); err == nil {
+		t.Error("expected tunnel to a denied host to fail")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(denied) != 2 {
+		t.Fatalf("expected two denials, got %v", denied)
+	}
+	var permErr *clauderrs.PermissionError
+	if !errors.As(denied[0], &permErr) || permErr.Code() != clauderrs.ErrCodeEgressDenied || permErr.Resource() != "localhost" {
+		t.Errorf("expected egress denied error for localhost, got %v", denied[0])
+	}
+}
+
+func TestEgressPolicyValidation(t *testing.T) {
+	_, err := claudeagent.NewOptions().
+		WithEgressPolicy(claudeagent.EgressPolicy{
+			AllowedHosts:  []string{""},
+			UpstreamProxy: "socks5://proxy:1080",
+		}).
+		Build()
+	if !clauderrs.IsValidationError(err) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	for _, field := range []string{"UpstreamProxy", "AllowedHosts"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected an error for %s, got %v", field, err)
+		}
+	}
+}
+
+func TestEgressPolicyUpstreamProxy(t *testing.T) {
+	var seen []string
+	var mu sync.Mutex
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Method+" "+r.Host+r.URL.Path)
+		mu.Unlock()
+		if r.Method == http.MethodConnect {
+			http.Error(w, "no tunnels", http.StatusForbidden)
+
+			return
+		}
+		fmt.Fprint(w, "via upstream")
+	}))
+	defer upstream.Close()
+
+	proxyURL := runEgressSession(t, claudeagent.EgressPolicy{
+		AllowedHosts:  []string{"example.com"},
+		UpstreamProxy: upstream.URL,
+	})
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	resp, err := client.Get("http://example.com/page")
+	if err != nil {
+		t.Fatalf("expected request through the upstream proxy: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+	if _, err := client.Get("https://example.com/"); err == nil {
+		t.Error("expected the upstream proxy's refusal to fail the tunnel")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 2 || seen[0] != "GET example.com/page" || seen[1] != "CONNECT example.com:443" {
+		t.Errorf("unexpected upstream requests: %q", seen)
+	}
+}
@@ -0,0 +1,124 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/eval"
+)
+
+func TestEvalSuiteScoresCases(t *testing.T) {
+	agent := &claudeagent.Options{PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeResultLine)}
+	verdict := strings.Replace(fakeResultLine, `"result":"done"`,
+		`"result":"graded","structured_output":{"pass":false,"score":0.25,"reason":"misses the cause"}`, 1)
+	judge := &claudeagent.Options{PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, verdict)}
+
+	var fixture string
+	suite := &eval.Suite{
+		Name:    "smoke",
+		Options: agent,
+		Cases: []eval.Case{
+			{Name: "contains", Prompt: "Say done", Scorer: eval.Contains("done")},
+			{Name: "missing", Prompt: "Say hello", Scorer: eval.Contains("hello")},
+			{Name: "judged", Prompt: "Find the bug", Scorer: eval.Judge(judge, "Names the bug")},
+			{
+				Name:     "fixtures",
+				Prompt:   "Read the notes",
+				Fixtures: map[string]string{"docs/notes.txt": "hi"},
+				Scorer: func(_ context.Context, out *eval.Output) (eval.Score, error) {
+					data, err := os.ReadFile(filepath.Join(out.Dir, "docs", "notes.txt"))
+					fixture = string(data)
+
+					return eval.Score{Pass: err == nil, Value: 1}, err
+				},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	report, err := suite.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if report.Passed != 2 || report.Failed != 2 || report.Errored != 0 || report.PassRate != 0.5 {
+		t.Errorf("report = %d passed, %d failed, %d errored, pass rate %g; want 2, 2, 0, 0.5",
+			report.Passed, report.Failed, report.Errored, report.PassRate)
+	}
+	if judged := report.Cases[2]; judged.Pass || judged.Score != 0.25 || judged.Reason != "misses the cause" || judged.Cost != 20_000_000 {
+		t.Errorf("judged case = %+v, want the judge's verdict and both turns' cost", judged)
+	}
+	if fixture != "hi" {
+		t.Errorf("fixture = %q, want %q", fixture, "hi")
+	}
+	if report.Cost != 50_000_000 {
+		t.Errorf("report cost = %s, want 0.05", report.Cost)
+	}
+
+	var jsonOut bytes.Buffer
+	if err := report.WriteJSON(&jsonOut); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var decoded struct {
+		PassRate float64 `json:"pass_rate"`
+		Cases    []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		} `json:"cases"`
+	}
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+		t.Fatalf("JSON report is invalid: %v", err)
+	}
+	if decoded.PassRate != 0.5 || len(decoded.Cases) != 4 || decoded.Cases[1].Status != "failed" {
+		t.Errorf("JSON report = %s", jsonOut.String())
+	}
+
+	var junit bytes.Buffer
+	if err := report.WriteJUnit(&junit); err != nil {
+		t.Fatalf("WriteJUnit failed: %v", err)
+	}
+	for _, want := range []string{`<testsuite name="smoke" tests="4" failures="2" errors="0" skipped="0"`, `<failure message="answer does not contain &#34;hello&#34;">`} {
+		if !strings.Contains(junit.String(), want) {
+			t.Errorf("JUnit report is missing %s:\n%s", want, junit.String())
+		}
+	}
+}
+
+func TestEvalSuiteStopsAtBudget(t *testing.T) {
+	suite := &eval.Suite{
+		Options:     &claudeagent.Options{PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeResultLine)},
+		Concurrency: 1,
+		MaxCost:     10_000_000, // One case
+		Cases: []eval.Case{
+			{Name: "first", Prompt: "one"},
+			{Name: "second", Prompt: "two"},
+			{Name: "third", Prompt: "three"},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	report, err := suite.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Passed != 1 || report.Skipped != 2 || !report.Cases[2].Skipped {
+		t.Errorf("report = %d passed, %d skipped; want 1 and 2", report.Passed, report.Skipped)
+	}
+
+	suite.Cases = append(suite.Cases, eval.Case{Name: "first", Prompt: "again"})
+	_, err = suite.Run(ctx)
+	if !clauderrs.IsValidationError(err) {
+		t.Errorf("Run with duplicate names returned %v, want a validation error", err)
+	}
+}
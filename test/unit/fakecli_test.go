@@ -0,0 +1,201 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// fakeCLITimeout bounds how long client tests wait for the fake CLI.
+const fakeCLITimeout = 5 * time.Second
+
+// newFakeCLI writes a shell script that emits the given stream-json lines on
+// stdout and then keeps stdout open until stdin closes, like the Claude
+// CLI. Everything the SDK writes to stdin is recorded; see fakeCLIStdin.
+// It returns the script path for Options.PathToClaudeCodeExecutable.
+func newFakeCLI(t *testing.T, lines ...string) string {
+	t.Helper()
+
+	return newStagedFakeCLI(t, lines)
+}
+
+// newStagedFakeCLI is like newFakeCLI but emits each stage after the SDK
+// has written one more control_response, so later stages can depend on how
+// earlier control requests were answered.
+func newStagedFakeCLI(t *testing.T, stages ...[]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	stdin := filepath.Join(dir, "stdin.jsonl")
+
+	var body strings.Builder
+	body.WriteString("#!/bin/sh\n")
+	for i, lines := range stages {
+		output := filepath.Join(dir, fmt.Sprintf("stdout-%d.jsonl", i))
+		if err := os.WriteFile(output, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatalf("failed to write fake CLI output: %v", err)
+		}
+		if i > 0 {
+			body.WriteString("while IFS= read -r line; do\n")
+			body.WriteString("  printf '%s\\n' \"$line\" >>'" + stdin + "'\n")
+			body.WriteString("  case \"$line\" in *control_response*) break;; esac\n")
+			body.WriteString("done\n")
+		}
+		body.WriteString("cat '" + output + "'\n")
+	}
+	body.WriteString("cat >>'" + stdin + "'\n")
+
+	script := filepath.Join(dir, "claude")
+	if err := os.WriteFile(script, []byte(body.String()), 0o700); err != nil {
+		t.Fatalf("failed to write fake CLI script: %v", err)
+	}
+
+	return script
+}
+
+// newHookFakeCLI writes a fake CLI that acknowledges the initialize request,
+// then emits lines (typically hook_callback requests) and records stdin.
+func newHookFakeCLI(t *testing.T, lines ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stdout.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write fake CLI output: %v", err)
+	}
+
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+IFS= read -r line
+printf '%s\n' "$line" >>stdin.jsonl
+id=$(printf '%s\n' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{}}}\n' "$id"
+cat stdout.jsonl
+cat >>stdin.jsonl
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatalf("failed to write fake CLI script: %v", err)
+	}
+
+	return script
+}
+
+// fakePreToolUseLine returns a hook_callback request for a PreToolUse hook.
+func fakePreToolUseLine(requestID, callbackID, toolName, toolInput string) string {
+	return `{"type":"control_request","request_id":"` + requestID + `","request":{"subtype":"hook_callback","callback_id":"` +
+		callbackID + `","tool_use_id":"toolu_1","input":{"hook_event_name":"PreToolUse","session_id":"fake-session","transcript_path":"/tmp/t.jsonl","cwd":"/tmp","tool_name":"` +
+		toolName + `","tool_input":` + toolInput + `,"tool_use_id":"toolu_1"}}}`
+}
+
+// fakeCLIStdin waits until the fake CLI at script has recorded at least n
+// stdin lines containing substr and returns all recorded lines.
+func fakeCLIStdin(t *testing.T, script, substr string, n int) []string {
+	t.Helper()
+
+	path := filepath.Join(filepath.Dir(script), "stdin.jsonl")
+	deadline := time.Now().Add(fakeCLITimeout)
+	for {
+		data, _ := os.ReadFile(path)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if strings.Count(string(data), substr) >= n || time.Now().After(deadline) {
+			return lines
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// runFakeSession starts a client against a fake CLI emitting lines, sends a
+// prompt, and collects messages until the result message.
+func runFakeSession(
+	t *testing.T,
+	opts *claudeagent.Options,
+	lines ...string,
+) (*claudeagent.ClaudeSDKClient, []claudeagent.SDKMessage) {
+	t.Helper()
+
+	if opts == nil {
+		opts = &claudeagent.Options{}
+	}
+	opts.PathToClaudeCodeExecutable = newFakeCLI(t, lines...)
+
+	return collectFakeSession(t, opts)
+}
+
+// runHookFakeSession is like runFakeSession for options that register SDK
+// hooks, which need the initialize request acknowledged.
+func runHookFakeSession(
+	t *testing.T,
+	opts *claudeagent.Options,
+	lines ...string,
+) (*claudeagent.ClaudeSDKClient, []claudeagent.SDKMessage) {
+	t.Helper()
+
+	opts.PathToClaudeCodeExecutable = newHookFakeCLI(t, lines...)
+
+	return collectFakeSession(t, opts)
+}
+
+// collectFakeSession starts a client with opts, sends a prompt, and
+// collects messages until the result message.
+func collectFakeSession(
+	t *testing.T,
+	opts *claudeagent.Options,
+) (*claudeagent.ClaudeSDKClient, []claudeagent.SDKMessage) {
+	t.Helper()
+
+	client, err := claudeagent.NewClient(opts)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	var messages []claudeagent.SDKMessage
+	for msg := range client.ReceiveResponse(ctx) {
+		messages = append(messages, msg)
+	}
+
+	return client, messages
+}
+
+// Stream-json fixtures shared by client tests.
+const (
+	fakeInitLine = `{"type":"system","subtype":"init","uuid":"00000000-0000-0000-0000-000000000001","session_id":"fake-session"}`
+
+	fakeResultLine = `{"type":"result","subtype":"success","uuid":"00000000-0000-0000-0000-000000000009","session_id":"fake-session","duration_ms":10,"num_turns":1,"total_cost_usd":0.01,"usage":{"input_tokens":10,"output_tokens":5},"result":"done"}`
+)
+
+// fakeToolUseLine returns an assistant message invoking a tool.
+func fakeToolUseLine(id, name, input string) string {
+	return `{"type":"assistant","uuid":"00000000-0000-0000-0000-000000000002","session_id":"fake-session","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"tool_use","id":"` +
+		id + `","name":"` + name + `","input":` + input + `}],"usage":{"input_tokens":1,"output_tokens":1}}}`
+}
+
+// fakeToolResultLine returns a user message carrying a tool result.
+func fakeToolResultLine(id, content string, isError bool) string {
+	errField := ""
+	if isError {
+		errField = `,"is_error":true`
+	}
+
+	return `{"type":"user","uuid":"00000000-0000-0000-0000-000000000003","session_id":"fake-session","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"` +
+		id + `","content":"` + content + `"` + errField + `}]}}`
+}
+
+// fakeTextLine returns an assistant message with a single text block.
+func fakeTextLine(text string) string {
+	return `{"type":"assistant","uuid":"00000000-0000-0000-0000-000000000004","session_id":"fake-session","message":{"id":"msg_2","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"` +
+		text + `"}],"usage":{"input_tokens":1,"output_tokens":1}}}`
+}
@@ -0,0 +1,163 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestChaosTransportFaults(t *testing.T) {
+	stdout := `{"n":1}` + "\n" + `{"n":2}` + "\n"
+
+	dropped := transport.NewChaosTransport(newTestTransport(stdout), transport.FaultConfig{DropRate: 1}, nil)
+	if _, err := dropped.Read(context.Background()); !errors.Is(err, io.EOF) {
+		t.Errorf("expected every message to be dropped, got %v", err)
+	}
+
+	malformed := transport.NewChaosTransport(newTestTransport(stdout), transport.FaultConfig{MalformedRate: 1}, nil)
+	if data, err := malformed.Read(context.Background()); err != nil || string(data) != `{"n"` {
+		t.Errorf("expected a truncated message, got %q (%v)", data, err)
+	}
+
+	delayed := transport.NewChaosTransport(newTestTransport(stdout), transport.FaultConfig{
+		DelayRate: 1,
+		MaxDelay:  time.Hour,
+	}, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := delayed.Read(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the delay to outlast the context, got %v", err)
+	}
+
+	exits := 0
+	crashed := transport.NewChaosTransport(newTestTransport(stdout), transport.FaultConfig{ExitRate: 1}, func() error {
+		exits++
+
+		return nil
+	})
+	for range 2 {
+		if _, err := crashed.Read(context.Background()); !errors.Is(err, transport.ErrInjectedExit) {
+			t.Errorf("expected ErrInjectedExit, got %v", err)
+		}
+	}
+	if exits != 1 {
+		t.Errorf("expected the process to be killed once, got %d", exits)
+	}
+}
+
+func TestChaosTransportSeedIsReproducible(t *testing.T) {
+	var lines []string
+	for i := range 50 {
+		lines = append(lines, `{"n":`+strings.Repeat("1", i+1)+`}`)
+	}
+	stdout := strings.Join(lines, "\n") + "\n"
+
+	read := func() []string {
+		tr := transport.NewChaosTransport(newTestTransport(stdout), transport.FaultConfig{DropRate: 0.5, Seed: 42}, nil)
+		var got []string
+		for {
+			data, err := tr.Read(context.Background())
+			if err != nil {
+				return got
+			}
+			got = append(got, string(data))
+		}
+	}
+
+	first, second := read(), read()
+	if len(first) == 0 || len(first) == len(lines) || strings.Join(first, ",") != strings.Join(second, ",") {
+		t.Errorf("expected the same partial sequence for a fixed seed, got %d and %d messages", len(first), len(second))
+	}
+}
+
+// newPromptedFakeCLI is like newFakeCLI but emits lines only once the
+// prompt has been written, so faults killing the CLI on its first message
+// can't fail the prompt's write.
+func newPromptedFakeCLI(t *testing.T, lines ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "stdout.jsonl")
+	if err := os.WriteFile(output, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write fake CLI output: %v", err)
+	}
+	stdin := filepath.Join(dir, "stdin.jsonl")
+	body := "#!/bin/sh\n" +
+		"IFS= read -r line\n" +
+		"printf '%s\\n' \"$line\" >>'" + stdin + "'\n" +
+		"cat '" + output + "'\n" +
+		"cat >>'" + stdin + "'\n"
+
+	script := filepath.Join(dir, "claude")
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatalf("failed to write fake CLI script: %v", err)
+	}
+
+	return script
+}
+
+func TestFaultInjectionSurfacesTypedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		faults claudeagent.FaultInjection
+		check  func(error) bool
+	}{
+		{
+			name:   "process exit",
+			faults: claudeagent.FaultInjection{ExitRate: 1},
+			check:  clauderrs.IsProcessError,
+		},
+		{
+			name:   "malformed message",
+			faults: claudeagent.FaultInjection{MalformedRate: 1},
+			check:  clauderrs.IsProtocolError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := claudeagent.NewOptions().WithFaultInjection(tt.faults).Build()
+			if err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+			opts.PathToClaudeCodeExecutable = newPromptedFakeCLI(t, fakeInitLine, fakeResultLine)
+
+			client, err := claudeagent.NewClient(opts)
+			if err != nil {
+				t.Fatalf("NewClient failed: %v", err)
+			}
+			t.Cleanup(func() { _ = client.Close() })
+
+			ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+			defer cancel()
+			if err := client.Query(ctx, "hello"); err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+
+			msgs, errs := client.ReceiveMessages(ctx)
+			for range msgs {
+			}
+			if err := <-errs; !tt.check(err) {
+				t.Errorf("expected a typed error, got %T: %v", err, err)
+			}
+		})
+	}
+}
+
+func TestFaultInjectionValidation(t *testing.T) {
+	_, err := claudeagent.NewOptions().
+		WithFaultInjection(claudeagent.FaultInjection{DropRate: 1.5}).
+		Build()
+	if !clauderrs.IsValidationError(err) || !strings.Contains(err.Error(), "DropRate") {
+		t.Errorf("expected rate over 1 to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestParseFileChanges(t *testing.T) {
+	tests := []struct {
+		tool  string
+		input map[string]claudeagent.JSONValue
+		want  []claudeagent.FileChange
+	}{
+		{
+			tool: "Edit",
+			input: map[string]claudeagent.JSONValue{
+				"file_path":  []byte(`"/src/main.go"`),
+				"old_string": []byte(`"foo"`),
+				"new_string": []byte(`"bar"`),
+			},
+			want: []claudeagent.FileChange{{Path: "/src/main.go", Old: "foo", New: "bar"}},
+		},
+		{
+			tool: "MultiEdit",
+			input: map[string]claudeagent.JSONValue{
+				"file_path": []byte(`"/src/main.go"`),
+				"edits":     []byte(`[{"old_string":"a","new_string":"b"},{"old_string":"c","new_string":"d","replace_all":true}]`),
+			},
+			want: []claudeagent.FileChange{
+				{Path: "/src/main.go", Old: "a", New: "b"},
+				{Path: "/src/main.go", Old: "c", New: "d", ReplaceAll: true},
+			},
+		},
+		{
+			tool: "Write",
+			input: map[string]claudeagent.JSONValue{
+				"file_path": []byte(`"/src/new.go"`),
+				"content":   []byte(`"package main\n"`),
+			},
+			want: []claudeagent.FileChange{{Path: "/src/new.go", New: "package main\n", WholeFile: true}},
+		},
+		{
+			tool:  "Bash",
+			input: map[string]claudeagent.JSONValue{"command": []byte(`"ls"`)},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := claudeagent.ParseFileChanges(tt.tool, tt.input)
+		if err != nil {
+			t.Errorf("%s: ParseFileChanges failed: %v", tt.tool, err)
+
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: expected %d changes, got %+v", tt.tool, len(tt.want), got)
+
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: change %d = %+v, want %+v", tt.tool, i, got[i], tt.want[i])
+			}
+		}
+	}
+
+	_, err := claudeagent.ParseFileChanges("Edit", map[string]claudeagent.JSONValue{"old_string": []byte(`"x"`)})
+	if !clauderrs.IsValidationError(err) {
+		t.Errorf("expected ValidationError for missing file_path, got %v", err)
+	}
+}
+
+func TestResolveFileChangesAndDiff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	var lines []string
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		lines = append(lines, "line "+name)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := claudeagent.ResolveFileChanges([]claudeagent.FileChange{
+		{Path: path, Old: "line b\n", New: "line B\n"},
+		{Path: path, Old: "line k\n", New: ""},
+	})
+	if err != nil {
+		t.Fatalf("ResolveFileChanges failed: %v", err)
+	}
+	if len(resolved) != 1 || !resolved[0].WholeFile {
+		t.Fatalf("expected one whole-file change, got %+v", resolved)
+	}
+
+	want := "--- " + path + "\n+++ " + path + "\n" +
+		"@@ -1,5 +1,5 @@\n line a\n-line b\n+line B\n line c\n line d\n line e\n" +
+		"@@ -8,5 +8,4 @@\n line h\n line i\n line j\n-line k\n line l\n"
+	if got := resolved[0].UnifiedDiff(); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFileChangeDiffOfNewFile(t *testing.T) {
+	change := claudeagent.FileChange{Path: "new.txt", New: "hello\nworld", WholeFile: true}
+
+	want := "--- new.txt\n+++ new.txt\n@@ -0,0 +1,2 @@\n+hello\n+world\n\\ No newline at end of file\n"
+	if got := change.UnifiedDiff(); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+	if got := (claudeagent.FileChange{Path: "same.txt", Old: "x", New: "x"}).UnifiedDiff(); got != "" {
+		t.Errorf("expected no diff for an unchanged file, got %q", got)
+	}
+}
+
+func TestResolveFileChangesRejectsAmbiguousEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dup.txt")
+	if err := os.WriteFile(path, []byte("x\nx\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := claudeagent.ResolveFileChanges([]claudeagent.FileChange{{Path: path, Old: "x", New: "y"}})
+	if !clauderrs.IsValidationError(err) {
+		t.Errorf("expected ValidationError for an ambiguous edit, got %v", err)
+	}
+	_, err = claudeagent.ResolveFileChanges([]claudeagent.FileChange{{Path: path, Old: "z", New: "y"}})
+	if !clauderrs.IsValidationError(err) {
+		t.Errorf("expected ValidationError for a missing edit target, got %v", err)
+	}
+
+	resolved, err := claudeagent.ResolveFileChanges([]claudeagent.FileChange{{Path: path, Old: "x", New: "y", ReplaceAll: true}})
+	if err != nil || resolved[0].New != "y\ny\n" {
+		t.Errorf("expected every occurrence replaced, got %+v, %v", resolved, err)
+	}
+}
@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// newArgsFakeCLI writes a fake CLI that records its arguments to args.txt
+// in the returned directory and emits lines.
+func newArgsFakeCLI(t *testing.T, lines ...string) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stdout.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+printf '%s\n' "$@" >>args.txt
+cat stdout.jsonl
+cat >/dev/null
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script, dir
+}
+
+func TestForkResumesCopyOfSession(t *testing.T) {
+	opts := &claudeagent.Options{Env: map[string]string{"ORIGINAL": "1"}}
+	client, _ := runFakeSession(t, opts, fakeInitLine, fakeTextLine("original"), fakeResultLine)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	script, dir := newArgsFakeCLI(t, fakeInitLine, fakeTextLine("forked"), fakeResultLine)
+	fork, err := client.Fork(ctx, func(o *claudeagent.Options) {
+		o.Model = "claude-opus-4-1"
+		o.Env["FORKED"] = "1"
+		o.PathToClaudeCodeExecutable = script
+	})
+	if err != nil {
+		t.Fatalf("Fork failed: %v", err)
+	}
+	t.Cleanup(func() { _ = fork.Close() })
+
+	if err := fork.Query(ctx, "what if"); err != nil {
+		t.Fatalf("Query on fork failed: %v", err)
+	}
+	var text string
+	for msg := range fork.ReceiveResponse(ctx) {
+		if assistant, ok := msg.(*claudeagent.SDKAssistantMessage); ok {
+			if block, ok := assistant.Message.Content[0].(claudeagent.TextContentBlock); ok {
+				text = block.Text
+			}
+		}
+	}
+	if text != "forked" {
+		t.Errorf("expected the fork's response, got %q", text)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "args.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := "\n" + string(data)
+	for _, want := range []string{"\n--resume\nfake-session\n", "\n--fork-session\n", "\n--model\nclaude-opus-4-1\n"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("expected fork arguments to contain %q, got %q", want, args)
+		}
+	}
+
+	if opts.Model != "" || opts.Resume != "" || opts.ForkSession || opts.Env["FORKED"] != "" {
+		t.Errorf("expected the original options to be unchanged, got %+v", opts)
+	}
+}
+
+func TestForkBeforeSessionStarts(t *testing.T) {
+	client, err := claudeagent.NewClient(nil)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	_, err = client.Fork(context.Background())
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeNoActiveQuery {
+		t.Errorf("expected ErrCodeNoActiveQuery, got %v", err)
+	}
+}
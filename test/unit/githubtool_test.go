@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/githubtool"
+)
+
+// callServerTool runs the tool name of an SDK MCP server.
+func callServerTool(t *testing.T, server claudeagent.McpServerConfig, name string, args map[string]any) (string, error) {
+	t.Helper()
+
+	for _, tool := range server.(claudeagent.McpSdkServerConfig).Instance.Tools() {
+		if tool.Name() != name {
+			continue
+		}
+		result, err := tool.Execute(context.Background(), args)
+		if err != nil {
+			return "", err
+		}
+
+		return result.Content[0].(claudeagent.TextContentBlock).Text, nil
+	}
+	t.Fatalf("tool %s not found", name)
+
+	return "", nil
+}
+
+func TestGitHubToolsCallTheAPI(t *testing.T) {
+	var posted map[string]any
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"message":"Bad credentials"}`)
+
+			return
+		}
+		switch {
+		case r.URL.Path == "/repos/octo/widgets/pulls/7" && r.Header.Get("Accept") == "application/vnd.github.diff":
+			_, _ = io.WriteString(w, "diff --git a/main.go b/main.go\n+fmt.Println()\n")
+		case r.URL.Path == "/repos/octo/widgets/pulls/7":
+			_, _ = io.WriteString(w, `{"head":{"sha":"abc123"}}`)
+		case r.URL.Path == "/repos/octo/widgets/commits/abc123/check-runs":
+			_, _ = io.WriteString(w, `{"check_runs":[{"name":"test","status":"completed","conclusion":"failure","details_url":"https://ci/1"},{"name":"lint","status":"in_progress"}]}`)
+		case r.URL.Path == "/repos/octo/widgets/pulls/7/reviews" && r.Method == http.MethodPost:
+			_ = json.NewDecoder(r.Body).Decode(&posted)
+			_, _ = io.WriteString(w, `{"html_url":"https://github.com/octo/widgets/pull/7#review-1"}`)
+		case r.URL.Path == "/repos/octo/widgets/pulls/8":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"message":"Not Found"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	t.Cleanup(api.Close)
+
+	gh := &githubtool.Client{Token: "secret", BaseURL: api.URL}
+	server := gh.Server("github")
+
+	if text, err := callServerTool(t, server, githubtool.PullRequestDiffTool, map[string]any{"repo": "octo/widgets", "number": 7.0}); err != nil ||
+		!strings.Contains(text, "+fmt.Println()") {
+		t.Errorf("expected the diff, got %q (%v)", text, err)
+	}
+	if text, err := callServerTool(t, server, githubtool.CheckRunsTool, map[string]any{"repo": "octo/widgets", "number": 7.0}); err != nil ||
+		text != "test: failure https://ci/1\nlint: in_progress " {
+		t.Errorf("expected the checks, got %q (%v)", text, err)
+	}
+
+	text, err := callServerTool(t, server, githubtool.CreateReviewTool, map[string]any{
+		"repo": "octo/widgets", "number": 7.0, "body": "One issue",
+		"comments": []any{map[string]any{"path": "main.go", "line": 1.0, "body": "Debug print left in"}},
+	})
+	if err != nil || !strings.Contains(text, "review-1") {
+		t.Errorf("expected the review URL, got %q (%v)", text, err)
+	}
+	if posted["event"] != "COMMENT" || len(posted["comments"].([]any)) != 1 {
+		t.Errorf("unexpected review posted: %v", posted)
+	}
+
+	_, err = callServerTool(t, server, githubtool.PullRequestDiffTool, map[string]any{"repo": "octo/widgets", "number": 8.0})
+	if err == nil || !strings.Contains(err.Error(), "404: Not Found") {
+		t.Errorf("expected an API error, got %v", err)
+	}
+	if _, err := callServerTool(t, server, githubtool.PullRequestDiffTool, map[string]any{"repo": "../x", "number": 7.0}); err == nil {
+		t.Error("expected an invalid repo to be refused")
+	}
+}
+
+func TestGitHubReviewAgentOptions(t *testing.T) {
+	base := &claudeagent.Options{Cwd: "/src", AllowedTools: []string{"WebFetch"}}
+	opts := githubtool.ReviewAgent(githubtool.NewClient("secret"), base)
+
+	if opts.Cwd != "/src" || base.McpServers != nil || len(base.AllowedTools) != 1 {
+		t.Errorf("expected a copy of the base options, got %+v (base %+v)", opts, base)
+	}
+	if _, ok := opts.McpServers[githubtool.ServerName]; !ok {
+		t.Error("expected the GitHub server to be registered")
+	}
+	for _, tool := range append([]string{"WebFetch", "Read"}, githubtool.ToolNames(githubtool.ServerName)...) {
+		if !slices.Contains(opts.AllowedTools, tool) {
+			t.Errorf("expected %s to be allowed, got %v", tool, opts.AllowedTools)
+		}
+	}
+	if !slices.Contains(opts.DisallowedTools, "Bash") || !slices.Contains(opts.DisallowedTools, "Write") {
+		t.Errorf("expected write tools to be disallowed, got %v", opts.DisallowedTools)
+	}
+	if opts.SystemPrompt == nil {
+		t.Error("expected review instructions")
+	}
+}
@@ -0,0 +1,596 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/google/uuid"
+)
+
+// updateGolden rewrites the golden files from the current serialization:
+//
+//	go test ./test/unit -run TestGolden -update
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+// goldenDir holds one fixture per wire type, named after the type in the
+// TypeScript SDK's schema.
+const goldenDir = "testdata/golden"
+
+// goldenDecoder decodes a fixture back into the type it was marshaled from.
+type goldenDecoder func(data []byte) (any, error)
+
+// goldenCase is one wire type's fixture.
+type goldenCase struct {
+	name   string
+	value  any
+	decode goldenDecoder
+}
+
+var (
+	goldenUUID      = uuid.MustParse("6f1c2e8a-3b4d-4e5f-8a9b-0c1d2e3f4a5b")
+	goldenSessionID = "golden-session"
+	goldenBase      = claudeagent.BaseMessage{UUIDField: goldenUUID, SessionIDField: goldenSessionID}
+	goldenHookBase  = claudeagent.BaseHookInput{
+		SessionIDField:      goldenSessionID,
+		TranscriptPathField: "/home/user/.claude/projects/golden/transcript.jsonl",
+		CwdField:            "/home/user/project",
+	}
+)
+
+func goldenPtr[T any](v T) *T { return &v }
+
+// goldenJSON returns s, a compact JSON document, as a JSONValue.
+func goldenJSON(s string) claudeagent.JSONValue { return claudeagent.JSONValue(s) }
+
+// decodeMessageGolden decodes an SDK message fixture.
+func decodeMessageGolden(data []byte) (any, error) {
+	return claudeagent.DecodeMessage(data)
+}
+
+// decodeHookInputGolden decodes a hook input fixture.
+func decodeHookInputGolden(data []byte) (any, error) {
+	return claudeagent.DecodeHookInput(data)
+}
+
+// decodeIntoGolden decodes a fixture into a new T.
+func decodeIntoGolden[T any](data []byte) (any, error) {
+	var v T
+	err := json.Unmarshal(data, &v)
+
+	return v, err
+}
+
+func goldenCases() []goldenCase {
+	return []goldenCase{
+		// SDK messages.
+		{
+			name: "SDKUserMessage",
+			value: claudeagent.SDKUserMessage{
+				BaseMessage: goldenBase,
+				TypeField:   "user",
+				Message: claudeagent.APIUserMessage{
+					Role: "user",
+					Content: []claudeagent.ContentBlock{
+						claudeagent.TextContentBlock{Type: "text", Text: "List the files."},
+						claudeagent.ImageContentBlock{
+							Type:   "image",
+							Source: claudeagent.ImageSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="},
+						},
+						claudeagent.ToolResultContentBlock{
+							Type:      "tool_result",
+							ToolUseID: "toolu_01",
+							Content:   &claudeagent.ToolResultContent{Text: goldenPtr("main.go\ngo.mod")},
+						},
+						claudeagent.ToolResultContentBlock{
+							Type:      "tool_result",
+							ToolUseID: "toolu_02",
+							Content: &claudeagent.ToolResultContent{Blocks: []claudeagent.ContentBlock{
+								claudeagent.TextContentBlock{Type: "text", Text: "permission denied"},
+							}},
+							IsError: true,
+						},
+					},
+				},
+				ParentToolUseID: goldenPtr("toolu_00"),
+			},
+			decode: decodeMessageGolden,
+		},
+		{
+			name: "SDKUserMessageReplay",
+			value: claudeagent.SDKUserMessageReplay{
+				BaseMessage: goldenBase,
+				TypeField:   "user",
+				Message: claudeagent.APIUserMessage{
+					Role:    "user",
+					Content: []claudeagent.ContentBlock{claudeagent.TextContentBlock{Type: "text", Text: "Hello"}},
+				},
+				IsReplay: true,
+			},
+			decode: decodeIntoGolden[claudeagent.SDKUserMessageReplay],
+		},
+		{
+			name: "SDKAssistantMessage",
+			value: claudeagent.SDKAssistantMessage{
+				BaseMessage: goldenBase,
+				Message: claudeagent.APIAssistantMessage{
+					ID:   "msg_01",
+					Type: "message",
+					Role: "assistant",
+					Content: []claudeagent.ContentBlock{
+						claudeagent.ThinkingBlock{Type: "thinking", Thinking: "The user wants a listing.", Signature: "sig=="},
+						claudeagent.RedactedThinkingBlock{Type: "redacted_thinking", Data: "opaque=="},
+						claudeagent.TextContentBlock{Type: "text", Text: "Listing the files."},
+						claudeagent.ToolUseContentBlock{
+							Type:  "tool_use",
+							ID:    "toolu_01",
+							Name:  "Bash",
+							Input: goldenJSON(`{"command":"ls"}`),
+						},
+					},
+					Model:      "claude-sonnet-4-5",
+					StopReason: goldenPtr("tool_use"),
+					Usage: claudeagent.Usage{
+						InputTokens:              120,
+						OutputTokens:             48,
+						CacheReadInputTokens:     1024,
+						CacheCreationInputTokens: 256,
+					},
+				},
+			},
+			decode: decodeMessageGolden,
+		},
+		{
+			name: "SDKResultMessage",
+			value: claudeagent.SDKResultMessage{
+				BaseMessage:   goldenBase,
+				Subtype:       claudeagent.ResultSubtypeSuccess,
+				DurationMS:    1500,
+				DurationAPIMS: 1200,
+				NumTurns:      2,
+				TotalCostUSD:  0.0125,
+				Usage:         claudeagent.Usage{InputTokens: 200, OutputTokens: 80},
+				ModelUsage: map[string]claudeagent.ModelUsage{
+					"claude-sonnet-4-5": {
+						InputTokens:   200,
+						OutputTokens:  80,
+						CostUSD:       0.0125,
+						ContextWindow: 200000,
+					},
+				},
+				PermissionDenials: []claudeagent.SDKPermissionDenial{{
+					ToolName:  "Bash",
+					ToolUseID: "toolu_03",
+					ToolInput: map[string]claudeagent.JSONValue{"command": goldenJSON(`"rm -rf /"`)},
+				}},
+				Result: goldenPtr("Done."),
+			},
+			decode: decodeMessageGolden,
+		},
+		{
+			name: "SDKResultMessageError",
+			value: claudeagent.SDKResultMessage{
+				BaseMessage:       goldenBase,
+				Subtype:           claudeagent.ResultSubtypeErrorMaxTurns,
+				IsError:           true,
+				NumTurns:          10,
+				ModelUsage:        map[string]claudeagent.ModelUsage{},
+				PermissionDenials: []claudeagent.SDKPermissionDenial{},
+				Errors:            []string{"maximum number of turns reached"},
+			},
+			decode: decodeMessageGolden,
+		},
+		{
+			name: "SDKToolProgressMessage",
+			value: claudeagent.SDKToolProgressMessage{
+				BaseMessage:        goldenBase,
+				TypeField:          "tool_progress",
+				ToolUseID:          "toolu_01",
+				ToolName:           "Bash",
+				ElapsedTimeSeconds: 2.5,
+			},
+			decode: decodeIntoGolden[claudeagent.SDKToolProgressMessage],
+		},
+		{
+			name: "SDKAuthStatusMessage",
+			value: claudeagent.SDKAuthStatusMessage{
+				BaseMessage:      goldenBase,
+				TypeField:        "auth_status",
+				IsAuthenticating: true,
+				Output:           []string{"Open https://example.com/device to continue"},
+			},
+			decode: decodeMessageGolden,
+		},
+		{
+			name: "SDKStatusMessage",
+			value: claudeagent.SDKStatusMessage{
+				BaseMessage:  goldenBase,
+				TypeField:    "system",
+				SubtypeField: "status",
+				Status:       claudeagent.SDKStatusCompacting,
+			},
+			decode: decodeIntoGolden[claudeagent.SDKStatusMessage],
+		},
+		{
+			name: "SDKHookResponseMessage",
+			value: claudeagent.SDKHookResponseMessage{
+				BaseMessage:  goldenBase,
+				TypeField:    "system",
+				SubtypeField: "hook_response",
+				HookName:     "lint",
+				HookEvent:    string(claudeagent.HookEventPostToolUse),
+				Stdout:       "ok\n",
+				ExitCode:     goldenPtr(0),
+			},
+			decode: decodeIntoGolden[claudeagent.SDKHookResponseMessage],
+		},
+
+		// Control protocol.
+		{
+			name: "SDKControlInterruptRequest",
+			value: claudeagent.SDKControlRequest{
+				BaseMessage: goldenBase,
+				RequestID:   "req_1",
+				Request:     claudeagent.SDKControlInterruptRequest{},
+			},
+			decode: decodeIntoGolden[claudeagent.SDKControlRequest],
+		},
+		{
+			name: "SDKControlInitializeRequest",
+			value: claudeagent.SDKControlRequest{
+				BaseMessage: goldenBase,
+				RequestID:   "req_2",
+				Request: claudeagent.SDKControlInitializeRequest{
+					Hooks: map[string]claudeagent.JSONValue{
+						"PreToolUse": goldenJSON(`[{"matcher":"Bash","hookCallbackIds":["hook_0"]}]`),
+					},
+				},
+			},
+			decode: decodeIntoGolden[claudeagent.SDKControlRequest],
+		},
+		{
+			name: "SDKControlSetPermissionModeRequest",
+			value: claudeagent.SDKControlRequest{
+				BaseMessage: goldenBase,
+				RequestID:   "req_3",
+				Request:     claudeagent.SDKControlSetPermissionModeRequest{Mode: "acceptEdits"},
+			},
+			decode: decodeIntoGolden[claudeagent.SDKControlRequest],
+		},
+		{
+			name: "SDKControlMcpMessageRequest",
+			value: claudeagent.SDKControlRequest{
+				BaseMessage: goldenBase,
+				RequestID:   "req_4",
+				Request: claudeagent.SDKControlMcpMessageRequest{
+					ServerName: "calculator",
+					Message:    goldenJSON(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`),
+				},
+			},
+			decode: decodeIntoGolden[claudeagent.SDKControlRequest],
+		},
+		{
+			name: "SDKControlAuthenticateRequest",
+			value: claudeagent.SDKControlRequest{
+				BaseMessage: goldenBase,
+				RequestID:   "req_5",
+				Request:     claudeagent.SDKControlAuthenticateRequest{Token: "device-code"},
+			},
+			decode: decodeIntoGolden[claudeagent.SDKControlRequest],
+		},
+		{
+			name: "SDKControlPermissionRequest",
+			value: claudeagent.SDKControlPermissionRequest{
+				RequestIDField: "req_6",
+				SubtypeField:   claudeagent.ControlRequestSubtypeCanUseTool,
+				ToolName:       "Write",
+				Input:          map[string]claudeagent.JSONValue{"file_path": goldenJSON(`"/tmp/out.txt"`), "content": goldenJSON(`"hi"`)},
+				BlockedPath:    goldenPtr("/tmp/out.txt"),
+				ToolUseID:      "toolu_04",
+			},
+			decode: decodeIntoGolden[claudeagent.SDKControlPermissionRequest],
+		},
+		{
+			name: "SDKHookCallbackRequest",
+			value: claudeagent.SDKHookCallbackRequest{
+				RequestIDField: "req_7",
+				SubtypeField:   claudeagent.ControlRequestSubtypeHookCallback,
+				CallbackID:     "hook_0",
+				Input:          goldenJSON(`{"hook_event_name":"PreToolUse","tool_name":"Bash"}`),
+				ToolUseID:      goldenPtr("toolu_01"),
+			},
+			decode: decodeIntoGolden[claudeagent.SDKHookCallbackRequest],
+		},
+		{
+			name: "ControlSuccessResponse",
+			value: claudeagent.SDKControlResponse{
+				BaseMessage: goldenBase,
+				Response: claudeagent.ControlSuccessResponse{
+					SubtypeField:   claudeagent.ControlResponseSubtypeSuccess,
+					RequestIDField: "req_6",
+					Response:       map[string]claudeagent.JSONValue{"behavior": goldenJSON(`"allow"`), "updatedInput": goldenJSON(`{}`)},
+				},
+			},
+			decode: decodeIntoGolden[claudeagent.SDKControlResponse],
+		},
+		{
+			name: "ControlErrorResponse",
+			value: claudeagent.SDKControlResponse{
+				BaseMessage: goldenBase,
+				Response: claudeagent.ControlErrorResponse{
+					SubtypeField:   claudeagent.ControlResponseSubtypeError,
+					RequestIDField: "req_7",
+					Error:          "hook callback failed",
+				},
+			},
+			decode: decodeIntoGolden[claudeagent.SDKControlResponse],
+		},
+
+		// Hook inputs.
+		{
+			name: "PreToolUseHookInput",
+			value: &claudeagent.PreToolUseHookInput{
+				BaseHookInput: goldenHookBase,
+				HookEventName: claudeagent.HookEventPreToolUse,
+				ToolName:      "Bash",
+				ToolInput:     goldenJSON(`{"command":"ls"}`),
+				ToolUseID:     "toolu_01",
+			},
+			decode: decodeHookInputGolden,
+		},
+		{
+			name: "PostToolUseHookInput",
+			value: &claudeagent.PostToolUseHookInput{
+				BaseHookInput: goldenHookBase,
+				HookEventName: claudeagent.HookEventPostToolUse,
+				ToolName:      "Bash",
+				ToolInput:     goldenJSON(`{"command":"ls"}`),
+				ToolResponse:  goldenJSON(`{"stdout":"main.go\n","stderr":""}`),
+				ToolUseID:     "toolu_01",
+			},
+			decode: decodeHookInputGolden,
+		},
+		{
+			name: "NotificationHookInput",
+			value: &claudeagent.NotificationHookInput{
+				BaseHookInput:    goldenHookBase,
+				HookEventName:    claudeagent.HookEventNotification,
+				Message:          "Claude needs your permission to use Bash",
+				Title:            goldenPtr("Permission needed"),
+				NotificationType: "permission_prompt",
+			},
+			decode: decodeHookInputGolden,
+		},
+		{
+			name: "UserPromptSubmitHookInput",
+			value: &claudeagent.UserPromptSubmitHookInput{
+				BaseHookInput: goldenHookBase,
+				HookEventName: claudeagent.HookEventUserPromptSubmit,
+				Prompt:        "List the files.",
+			},
+			decode: decodeHookInputGolden,
+		},
+		{
+			name: "SessionStartHookInput",
+			value: &claudeagent.SessionStartHookInput{
+				BaseHookInput: goldenHookBase,
+				HookEventName: claudeagent.HookEventSessionStart,
+				Source:        claudeagent.SessionStartSourceStartup,
+			},
+			decode: decodeHookInputGolden,
+		},
+		{
+			name: "StopHookInput",
+			value: &claudeagent.StopHookInput{
+				BaseHookInput: goldenHookBase,
+				HookEventName: claudeagent.HookEventStop,
+			},
+			decode: decodeHookInputGolden,
+		},
+		{
+			name: "SubagentStopHookInput",
+			value: &claudeagent.SubagentStopHookInput{
+				BaseHookInput:       goldenHookBase,
+				HookEventName:       claudeagent.HookEventSubagentStop,
+				StopHookActive:      true,
+				AgentID:             "agent_1",
+				AgentTranscriptPath: "/home/user/.claude/projects/golden/agent_1.jsonl",
+			},
+			decode: decodeHookInputGolden,
+		},
+		{
+			name: "PreCompactHookInput",
+			value: &claudeagent.PreCompactHookInput{
+				BaseHookInput: goldenHookBase,
+				HookEventName: claudeagent.HookEventPreCompact,
+				Trigger:       claudeagent.CompactTriggerManual,
+			},
+			decode: decodeHookInputGolden,
+		},
+		{
+			name: "SessionEndHookInput",
+			value: &claudeagent.SessionEndHookInput{
+				BaseHookInput: goldenHookBase,
+				HookEventName: claudeagent.HookEventSessionEnd,
+				Reason:        claudeagent.ExitReasonComplete,
+			},
+			decode: decodeHookInputGolden,
+		},
+		{
+			name: "PermissionRequestHookInput",
+			value: &claudeagent.PermissionRequestHookInput{
+				BaseHookInput: goldenHookBase,
+				HookEventName: claudeagent.HookEventPermissionRequest,
+				ToolName:      "Bash",
+				ToolInput:     goldenJSON(`{"command":"make"}`),
+			},
+			decode: decodeHookInputGolden,
+		},
+		{
+			name: "SubagentStartHookInput",
+			value: &claudeagent.SubagentStartHookInput{
+				BaseHookInput: goldenHookBase,
+				HookEventName: claudeagent.HookEventSubagentStart,
+				AgentID:       "agent_1",
+				AgentType:     "general-purpose",
+			},
+			decode: decodeHookInputGolden,
+		},
+
+		// Hook outputs. They are only ever sent, so they are not decoded.
+		{
+			name: "SyncHookJSONOutput",
+			value: claudeagent.SyncHookOutput{
+				Continue:      goldenPtr(true),
+				Decision:      goldenPtr(claudeagent.HookDecisionApprove),
+				SystemMessage: goldenPtr("checked by policy"),
+				Reason:        goldenPtr("allowed command"),
+			},
+		},
+		{
+			name:  "AsyncHookJSONOutput",
+			value: claudeagent.AsyncHookOutput{Async: true, AsyncTimeout: goldenPtr(30000)},
+		},
+		{
+			name: "PreToolUseHookSpecificOutput",
+			value: claudeagent.SyncHookOutput{HookSpecificOutput: claudeagent.PreToolUseHookOutput{
+				HookEventName:            claudeagent.HookEventPreToolUse,
+				PermissionDecision:       goldenPtr(string(claudeagent.PermissionDecisionDeny)),
+				PermissionDecisionReason: goldenPtr("writes outside the project"),
+				UpdatedInput:             &map[string]any{"command": "ls"},
+			}},
+		},
+		{
+			name: "PostToolUseHookSpecificOutput",
+			value: claudeagent.SyncHookOutput{HookSpecificOutput: claudeagent.PostToolUseHookOutput{
+				HookEventName:        claudeagent.HookEventPostToolUse,
+				AdditionalContext:    goldenPtr("the listing is truncated"),
+				UpdatedMCPToolOutput: map[string]any{"content": []any{}},
+			}},
+		},
+		{
+			name: "UserPromptSubmitHookSpecificOutput",
+			value: claudeagent.SyncHookOutput{HookSpecificOutput: claudeagent.UserPromptSubmitHookOutput{
+				HookEventName:     claudeagent.HookEventUserPromptSubmit,
+				AdditionalContext: goldenPtr("the user is on call"),
+			}},
+		},
+		{
+			name: "SessionStartHookSpecificOutput",
+			value: claudeagent.SyncHookOutput{HookSpecificOutput: claudeagent.SessionStartHookOutput{
+				HookEventName:     claudeagent.HookEventSessionStart,
+				AdditionalContext: goldenPtr("branch: main"),
+			}},
+		},
+		{
+			name: "SubagentStartHookSpecificOutput",
+			value: claudeagent.SyncHookOutput{HookSpecificOutput: claudeagent.SubagentStartHookOutput{
+				HookEventName:     claudeagent.HookEventSubagentStart,
+				AdditionalContext: goldenPtr("stay read-only"),
+			}},
+		},
+		{
+			name: "PermissionRequestHookSpecificOutputAllow",
+			value: claudeagent.SyncHookOutput{HookSpecificOutput: claudeagent.PermissionRequestHookOutput{
+				HookEventName: claudeagent.HookEventPermissionRequest,
+				Decision: claudeagent.PermissionRequestAllow{
+					Behavior:     "allow",
+					UpdatedInput: &map[string]any{"command": "make test"},
+				},
+			}},
+		},
+		{
+			name: "PermissionRequestHookSpecificOutputDeny",
+			value: claudeagent.SyncHookOutput{HookSpecificOutput: claudeagent.PermissionRequestHookOutput{
+				HookEventName: claudeagent.HookEventPermissionRequest,
+				Decision: claudeagent.PermissionRequestDeny{
+					Behavior:  "deny",
+					Message:   goldenPtr("not on the allowlist"),
+					Interrupt: goldenPtr(true),
+				},
+			}},
+		},
+	}
+}
+
+// marshalGolden serializes v as a golden file: indented JSON with a
+// trailing newline.
+func marshalGolden(t *testing.T, v any) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		t.Fatalf("indent: %v", err)
+	}
+	out.WriteByte('\n')
+
+	return out.Bytes()
+}
+
+// TestGoldenSerialization checks that every wire type serializes exactly as
+// its committed fixture, and that fixtures of types the SDK receives decode
+// to the same value. A failure means the wire format changed; if that is
+// intended, rerun with -update and review the fixture diff.
+func TestGoldenSerialization(t *testing.T) {
+	for _, tc := range goldenCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(goldenDir, tc.name+".json")
+			got := marshalGolden(t, tc.value)
+
+			if *updateGolden {
+				if err := os.MkdirAll(goldenDir, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("serialization of %s drifted from %s\ngot:\n%s\nwant:\n%s", tc.name, path, got, want)
+			}
+
+			if tc.decode == nil {
+				return
+			}
+			decoded, err := tc.decode(want)
+			if err != nil {
+				t.Fatalf("decode golden file: %v", err)
+			}
+			if again := marshalGolden(t, decoded); !bytes.Equal(again, want) {
+				t.Errorf("%s does not round-trip\ngot:\n%s\nwant:\n%s", path, again, want)
+			}
+		})
+	}
+}
+
+// TestGoldenFilesCovered fails on fixtures no case produces, so renamed or
+// removed types do not leave stale fixtures behind.
+func TestGoldenFilesCovered(t *testing.T) {
+	names := make(map[string]bool)
+	for _, tc := range goldenCases() {
+		names[tc.name+".json"] = true
+	}
+
+	entries, err := os.ReadDir(goldenDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if !names[entry.Name()] {
+			t.Errorf("golden file %s has no test case", entry.Name())
+		}
+	}
+}
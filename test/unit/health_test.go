@@ -0,0 +1,102 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestHealthReportsSessionGoroutines(t *testing.T) {
+	client, err := claudeagent.NewClient(nil)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if client.Health().Active {
+		t.Error("expected no active session before Query")
+	}
+
+	client, _ = runFakeSession(t, nil, fakeInitLine, fakeTextLine("hi"), fakeResultLine)
+	health := client.Health()
+	if !health.Active {
+		t.Fatal("expected an active session")
+	}
+	if health.Writer.State != claudeagent.GoroutineWaiting || health.Writer.LastActivity.IsZero() {
+		t.Errorf("expected an idle writer that sent the prompt, got %+v", health.Writer)
+	}
+	if health.Dispatcher.State != claudeagent.GoroutineWaiting || health.MessageBacklog != 0 {
+		t.Errorf("expected nothing queued, got %s", health)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if client.Health().Active {
+		t.Error("expected no active session after Close")
+	}
+}
+
+func TestWatchdogFailsStalledSession(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	var stalled claudeagent.Health
+	var stacks []byte
+	opts := &claudeagent.Options{
+		PathToClaudeCodeExecutable: newHookFakeCLI(t,
+			fakeInitLine,
+			fakePreToolUseLine("cli_1", "hook_0", "Bash", `{"command":"ls"}`),
+		),
+		Hooks: map[claudeagent.HookEvent][]claudeagent.HookCallbackMatcher{
+			claudeagent.HookEventPreToolUse: {{
+				Hooks: []claudeagent.HookCallback{
+					func(context.Context, claudeagent.HookInput, *string) (claudeagent.HookJSONOutput, error) {
+						<-release
+
+						return claudeagent.SyncHookOutput{}, nil
+					},
+				},
+			}},
+		},
+		Watchdog: &claudeagent.Watchdog{
+			StallTimeout: 100 * time.Millisecond,
+			OnStall: func(health claudeagent.Health, dump []byte) {
+				stalled, stacks = health, dump
+			},
+		},
+	}
+	client, err := claudeagent.NewClient(opts)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+	if err := client.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	msgs, errs := client.ReceiveMessages(ctx)
+	for range msgs {
+	}
+	err = <-errs
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeSessionStalled {
+		t.Fatalf("expected ErrCodeSessionStalled, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "dispatcher") {
+		t.Errorf("expected the stalled goroutine named, got %v", err)
+	}
+	if stalled.Dispatcher.State != claudeagent.GoroutineWorking || stalled.Dispatcher.InFlight != 1 {
+		t.Errorf("expected OnStall to see the stuck hook, got %s", stalled)
+	}
+	if !strings.Contains(string(stacks), "goroutine ") {
+		t.Error("expected a goroutine dump")
+	}
+	if client.Health().Active {
+		t.Error("expected the stalled session to be closed")
+	}
+}
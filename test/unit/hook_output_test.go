@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestHookOutputConstructors(t *testing.T) {
+	tests := []struct {
+		name   string
+		output claudeagent.SyncHookOutput
+		want   string
+	}{
+		{"block", claudeagent.Block("tests fail"), `{"decision":"block","reason":"tests fail"}`},
+		{"approve", claudeagent.Approve(), `{"decision":"approve"}`},
+		{"continue", claudeagent.Continue("lint passed"), `{"continue":true,"systemMessage":"lint passed"}`},
+		{"continue quietly", claudeagent.Continue(""), `{"continue":true}`},
+		{"stop", claudeagent.Stop("budget spent"), `{"continue":false,"stopReason":"budget spent"}`},
+	}
+
+	for _, tt := range tests {
+		if err := tt.output.Validate(); err != nil {
+			t.Errorf("%s: expected valid output, got %v", tt.name, err)
+		}
+		data, err := json.Marshal(tt.output)
+		if err != nil || string(data) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, data, tt.want)
+		}
+	}
+}
+
+func TestHookOutputValidateRejectsIncoherentCombinations(t *testing.T) {
+	unknown := claudeagent.HookDecision("maybe")
+	proceed := true
+	deny := string(claudeagent.PermissionDecisionDeny)
+	allow := string(claudeagent.PermissionDecisionAllow)
+	reason := "because"
+
+	approveAndStop := claudeagent.Approve()
+	approveAndStop.Continue = new(bool)
+	approveAndDeny := claudeagent.Approve()
+	approveAndDeny.HookSpecificOutput = claudeagent.PreToolUseHookOutput{
+		HookEventName:      claudeagent.HookEventPreToolUse,
+		PermissionDecision: &deny,
+	}
+	blockAndAllow := claudeagent.Block("no")
+	blockAndAllow.HookSpecificOutput = &claudeagent.PreToolUseHookOutput{
+		HookEventName:      claudeagent.HookEventPreToolUse,
+		PermissionDecision: &allow,
+	}
+
+	tests := map[string]claudeagent.SyncHookOutput{
+		"unknown decision":        {Decision: &unknown},
+		"block without reason":    claudeagent.Block(""),
+		"reason without decision": {Reason: &reason},
+		"stop reason continuing":  {Continue: &proceed, StopReason: &reason},
+		"approve and stop":        approveAndStop,
+		"approve and deny":        approveAndDeny,
+		"block and allow":         blockAndAllow,
+	}
+
+	for name, output := range tests {
+		if err := output.Validate(); !clauderrs.IsValidationError(err) {
+			t.Errorf("%s: expected ValidationError, got %v", name, err)
+		}
+	}
+}
+
+func TestInvalidHookOutputFailsCallback(t *testing.T) {
+	script := newHookFakeCLI(t,
+		fakeInitLine,
+		fakePreToolUseLine("cli_1", "hook_0", "Bash", `{"command":"ls"}`),
+	)
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		Hooks: map[claudeagent.HookEvent][]claudeagent.HookCallbackMatcher{
+			claudeagent.HookEventPreToolUse: {{
+				Hooks: []claudeagent.HookCallback{
+					func(context.Context, claudeagent.HookInput, *string) (claudeagent.HookJSONOutput, error) {
+						return claudeagent.Block(""), nil
+					},
+				},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "list files"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	var response string
+	for _, line := range fakeCLIStdin(t, script, `"cli_1"`, 1) {
+		if strings.Contains(line, `"cli_1"`) {
+			response = line
+		}
+	}
+	if !strings.Contains(response, `"subtype":"error"`) || strings.Contains(response, `"decision"`) {
+		t.Errorf("expected an error response instead of the invalid output, got %s", response)
+	}
+}
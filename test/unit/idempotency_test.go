@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// receiveResult collects a response and returns its result message.
+func receiveResult(ctx context.Context, t *testing.T, client *claudeagent.ClaudeSDKClient) *claudeagent.SDKResultMessage {
+	t.Helper()
+
+	var result *claudeagent.SDKResultMessage
+	for msg := range client.ReceiveResponse(ctx) {
+		if r, ok := msg.(*claudeagent.SDKResultMessage); ok {
+			result = r
+		}
+	}
+	if result == nil {
+		t.Fatal("expected a result message")
+	}
+
+	return result
+}
+
+func TestIdempotencyKeyReplaysCachedResult(t *testing.T) {
+	script := newFakeCLI(t, fakeInitLine, fakeResultLine)
+	store := claudeagent.NewMemorySessionStore()
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		SessionStore:               store,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	keyed := claudeagent.QueryOptions{IdempotencyKey: "job-1"}
+	if err := client.QueryWithOptions(ctx, "run job 1", keyed); err != nil {
+		t.Fatalf("QueryWithOptions failed: %v", err)
+	}
+	first := receiveResult(ctx, t, client)
+
+	if err := client.QueryWithOptions(ctx, "run job 1", keyed); err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	if retry := receiveResult(ctx, t, client); retry.SessionID() != first.SessionID() || *retry.Result != *first.Result {
+		t.Errorf("expected retry to replay the cached result, got %+v", retry)
+	}
+
+	// Another client sharing the store replays it too, without a CLI
+	other, err := claudeagent.NewClient(&claudeagent.Options{SessionStore: store})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if err := other.QueryWithOptions(ctx, "run job 1", keyed); err != nil {
+		t.Fatalf("QueryWithOptions failed: %v", err)
+	}
+	if replayed := receiveResult(ctx, t, other); replayed.TotalCostUSD != first.TotalCostUSD {
+		t.Errorf("expected the shared store to replay the result, got %+v", replayed)
+	}
+
+	// A new key runs a new turn
+	if err := client.QueryWithOptions(ctx, "run job 2", claudeagent.QueryOptions{IdempotencyKey: "job-2"}); err != nil {
+		t.Fatalf("QueryWithOptions failed: %v", err)
+	}
+	lines := fakeCLIStdin(t, script, "run job 2", 1)
+	if got := strings.Count(strings.Join(lines, "\n"), "run job 1"); got != 1 {
+		t.Errorf("expected job 1 to be sent once, sent %d times", got)
+	}
+}
+
+func TestIdempotencyKeyDoesNotResendInFlightTurn(t *testing.T) {
+	script := newFakeCLI(t, fakeInitLine, fakeResultLine)
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{PathToClaudeCodeExecutable: script})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	keyed := claudeagent.QueryOptions{IdempotencyKey: "job-1"}
+	for range 2 {
+		if err := client.QueryWithOptions(ctx, "run job 1", keyed); err != nil {
+			t.Fatalf("QueryWithOptions failed: %v", err)
+		}
+	}
+	if result := receiveResult(ctx, t, client); result.Result == nil || *result.Result != "done" {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	if err := client.Query(ctx, "marker"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	lines := fakeCLIStdin(t, script, "marker", 1)
+	if got := strings.Count(strings.Join(lines, "\n"), "run job 1"); got != 1 {
+		t.Errorf("expected in-flight request to be sent once, sent %d times", got)
+	}
+}
+
+func TestIdempotencyKeysPairWithTheirResults(t *testing.T) {
+	script, _ := newTwoTurnFakeCLI(t,
+		[]string{fakeInitLine, strings.Replace(fakeResultLine, `"result":"done"`, `"result":"one"`, 1)},
+		[]string{strings.Replace(fakeResultLine, `"result":"done"`, `"result":"two"`, 1)},
+	)
+	store := claudeagent.NewMemorySessionStore()
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		SessionStore:               store,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	// Both turns are sent before either result is read
+	for _, key := range []string{"job-1", "job-2"} {
+		if err := client.QueryWithOptions(ctx, "run "+key, claudeagent.QueryOptions{IdempotencyKey: key}); err != nil {
+			t.Fatalf("QueryWithOptions failed: %v", err)
+		}
+	}
+	for _, want := range []string{"one", "two"} {
+		if result := receiveResult(ctx, t, client); *result.Result != want {
+			t.Errorf("expected result %q, got %q", want, *result.Result)
+		}
+	}
+
+	for key, want := range map[string]string{"job-1": "one", "job-2": "two"} {
+		other, err := claudeagent.NewClient(&claudeagent.Options{SessionStore: store})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		if err := other.QueryWithOptions(ctx, "run "+key, claudeagent.QueryOptions{IdempotencyKey: key}); err != nil {
+			t.Fatalf("QueryWithOptions failed: %v", err)
+		}
+		if replayed := receiveResult(ctx, t, other); *replayed.Result != want {
+			t.Errorf("%s: expected the cached result %q, got %q", key, want, *replayed.Result)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+func TestSequentialUUIDs(t *testing.T) {
+	next := claudeagent.SequentialUUIDs()
+	for _, want := range []string{
+		"00000000-0000-4000-8000-000000000001",
+		"00000000-0000-4000-8000-000000000002",
+	} {
+		if got := next().String(); got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	}
+	if got := claudeagent.SequentialUUIDs()().String(); !strings.HasSuffix(got, "001") {
+		t.Errorf("expected each generator to start over, got %s", got)
+	}
+}
+
+// runDeterministicSession runs a fake session that registers hooks, so
+// the SDK sends an initialize request, with generated IDs and timestamps
+// injected, and returns its tee output.
+func runDeterministicSession(t *testing.T) []byte {
+	t.Helper()
+
+	at := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newHookFakeCLI(t, fakeInitLine, fakeResultLine),
+		MaxToolUses:                &claudeagent.ToolUseLimits{Total: 10},
+		NewUUID:                    claudeagent.SequentialUUIDs(),
+		Now:                        func() time.Time { return at },
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	client.TeeJSONL(&buf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for range client.ReceiveResponse(ctx) {
+	}
+	_ = client.Close()
+
+	return buf.Bytes()
+}
+
+func TestInjectedUUIDsAndClockMakeFramesStable(t *testing.T) {
+	first := runDeterministicSession(t)
+	second := runDeterministicSession(t)
+
+	if !bytes.Equal(first, second) {
+		t.Errorf("expected identical tee output across runs, got\n%s\nand\n%s", first, second)
+	}
+	for _, want := range []string{
+		`"timestamp":"2026-01-02T15:04:05Z"`,
+		`"request_id":"req_1_00000000"`,
+		`"session_id":"00000000-0000-4000-8000-000000000001"`,
+	} {
+		if !bytes.Contains(first, []byte(want)) {
+			t.Errorf("expected %s in the tee output, got\n%s", want, first)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestJailValidation(t *testing.T) {
+	_, err := claudeagent.NewOptions().
+		WithJail(claudeagent.ProcessJail{ReadOnlyPaths: []string{"usr"}, WritablePaths: []string{"/srv/data"}}).
+		Build()
+	if !clauderrs.IsValidationError(err) || !strings.Contains(err.Error(), "Jail.ReadOnlyPaths") {
+		t.Fatalf("expected a ValidationError for the relative path, got %v", err)
+	}
+
+	for _, user := range []*claudeagent.ProcessUser{nil, {UID: 0, GID: 0}} {
+		opts := &claudeagent.Options{Jail: &claudeagent.ProcessJail{}, RunAs: user}
+		if err := opts.Validate(); !clauderrs.IsValidationError(err) || !strings.Contains(err.Error(), "RunAs") {
+			t.Errorf("expected a jail running as %+v to be rejected, got %v", user, err)
+		}
+	}
+}
+
+// openForAll makes dir and everything in it usable by any user.
+func openForAll(t *testing.T, dir string) {
+	t.Helper()
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		return os.Chmod(path, 0o777)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJailConfinesCLI(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		t.Skip("the jail needs root on Linux")
+	}
+	if err := exec.Command("unshare", "-m", "true").Run(); err != nil {
+		t.Skipf("mount namespaces unavailable: %v", err)
+	}
+
+	helper := filepath.Join(t.TempDir(), "claude-jail")
+	build := exec.Command("go", "build", "-o", helper, "github.com/connerohnesorge/claude-agent-sdk-go/cmd/claude-jail")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build claude-jail: %v\n%s", err, out)
+	}
+
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	openForAll(t, outside)
+
+	// Wrap the fake CLI to record who it runs as and what it can see.
+	fake := newFakeCLI(t, fakeInitLine, fakeResultLine)
+	dir := t.TempDir()
+	wrapper := filepath.Join(dir, "claude")
+	body := "#!/bin/sh\nid -u >uid\n" +
+		"test -e '" + filepath.Join(outside, "secret") + "' && echo visible >outside\n" +
+		"test -e '/proc/1/root" + filepath.Join(outside, "secret") + "' && echo visible >proc-root\n" +
+		"ls -d /proc/[0-9]* | wc -l >pids\n" +
+		"exec '" + fake + "'\n"
+	if err := os.WriteFile(wrapper, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	openForAll(t, dir)
+	openForAll(t, filepath.Dir(fake))
+
+	_, msgs := collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: wrapper,
+		Cwd:                        dir,
+		RunAs:                      &claudeagent.ProcessUser{UID: 65534, GID: 65534},
+		Jail: &claudeagent.ProcessJail{
+			Helper:        helper,
+			WritablePaths: []string{filepath.Dir(fake)},
+		},
+		Stderr: func(line string) { t.Log(line) },
+	})
+
+	if len(msgs) == 0 {
+		t.Fatal("expected the jailed CLI to answer")
+	}
+	if _, ok := msgs[len(msgs)-1].(*claudeagent.SDKResultMessage); !ok {
+		t.Errorf("expected a result from the jailed CLI, got %d messages", len(msgs))
+	}
+	if got := strings.TrimSpace(readFakeFile(t, dir, "uid")); got != "65534" {
+		t.Errorf("expected the CLI to run as 65534, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "outside")); err == nil {
+		t.Error("expected paths outside the jail to be hidden")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "proc-root")); err == nil {
+		t.Error("expected /proc/1/root not to reach the host")
+	}
+	if got := strings.TrimSpace(readFakeFile(t, dir, "pids")); got == "" || len(got) > 1 {
+		t.Errorf("expected /proc to show only the jail's processes, got %q", got)
+	}
+}
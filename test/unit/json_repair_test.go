@@ -0,0 +1,144 @@
+package unit
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// TestRepairJSON covers truncated and sloppy JSON inputs.
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     string
+		repaired bool
+	}{
+		{
+			name:     "valid input unchanged",
+			input:    `{"a":1,"b":[1,2]}`,
+			want:     `{"a":1,"b":[1,2]}`,
+			repaired: false,
+		},
+		{
+			name:     "unclosed object",
+			input:    `{"a":1,"b":2`,
+			want:     `{"a":1,"b":2}`,
+			repaired: true,
+		},
+		{
+			name:     "truncated string value",
+			input:    `{"title":"Hello wor`,
+			want:     `{"title":"Hello wor"}`,
+			repaired: true,
+		},
+		{
+			name:     "trailing comma",
+			input:    `{"items":[1,2,3,],}`,
+			want:     `{"items":[1,2,3]}`,
+			repaired: true,
+		},
+		{
+			name:     "dangling key",
+			input:    `{"a":1,"b"`,
+			want:     `{"a":1}`,
+			repaired: true,
+		},
+		{
+			name:     "dangling colon",
+			input:    `{"a":{"x":true},"b":`,
+			want:     `{"a":{"x":true}}`,
+			repaired: true,
+		},
+		{
+			name:     "partial literal",
+			input:    `[1,2,tru`,
+			want:     `[1,2]`,
+			repaired: true,
+		},
+		{
+			name:     "nested arrays and objects",
+			input:    `{"steps":[{"id":1,"done":false},{"id":2`,
+			want:     `{"steps":[{"id":1,"done":false},{"id":2}]}`,
+			repaired: true,
+		},
+		{
+			name:     "code fence",
+			input:    "```json\n{\"a\":1}\n```",
+			want:     `{"a":1}`,
+			repaired: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, repaired, err := claudeagent.RepairJSON([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("RepairJSON failed: %v", err)
+			}
+
+			if repaired != tt.repaired {
+				t.Errorf("repaired = %v, want %v", repaired, tt.repaired)
+			}
+
+			assertJSONEqual(t, string(got), tt.want)
+		})
+	}
+}
+
+// TestRepairJSONUnrepairable verifies garbage input returns an error.
+func TestRepairJSONUnrepairable(t *testing.T) {
+	if _, _, err := claudeagent.RepairJSON([]byte(`{"`)); err == nil {
+		t.Error("expected error for unrepairable input")
+	}
+}
+
+// TestStructuredOutputLenient verifies the result accessor prefers the parsed
+// field and falls back to repairing the result text.
+func TestStructuredOutputLenient(t *testing.T) {
+	parsed := claudeagent.SDKResultMessage{
+		StructuredOutput: map[string]any{"answer": "42"},
+	}
+
+	data, repaired, err := parsed.StructuredOutputLenient()
+	if err != nil || repaired {
+		t.Fatalf("unexpected result: repaired=%v err=%v", repaired, err)
+	}
+	assertJSONEqual(t, string(data), `{"answer":"42"}`)
+
+	truncated := claudeagent.SDKResultMessage{
+		Result: strPtr(`{"answer":"4`),
+	}
+
+	data, repaired, err = truncated.StructuredOutputLenient()
+	if err != nil {
+		t.Fatalf("StructuredOutputLenient failed: %v", err)
+	}
+	if !repaired {
+		t.Error("expected repaired flag to be set")
+	}
+	assertJSONEqual(t, string(data), `{"answer":"4"}`)
+
+	if _, _, err := (claudeagent.SDKResultMessage{}).StructuredOutputLenient(); err == nil {
+		t.Error("expected error when no output is present")
+	}
+}
+
+// assertJSONEqual compares two JSON documents semantically.
+func assertJSONEqual(t *testing.T, got, want string) {
+	t.Helper()
+
+	var gotValue, wantValue any
+	if err := json.Unmarshal([]byte(got), &gotValue); err != nil {
+		t.Fatalf("invalid JSON %q: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("invalid expected JSON %q: %v", want, err)
+	}
+
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
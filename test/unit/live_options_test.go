@@ -0,0 +1,81 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+func TestGetModelAndPermissionModeFollowInit(t *testing.T) {
+	opts := &claudeagent.Options{
+		Model:          "claude-sonnet-4-5",
+		PermissionMode: claudeagent.PermissionModeAcceptEdits,
+		PathToClaudeCodeExecutable: newFakeCLI(t,
+			strings.Replace(fakeInitLine, `"subtype":"init"`,
+				`"subtype":"init","model":"claude-opus-4-1","permissionMode":"plan"`, 1),
+			fakeResultLine,
+		),
+	}
+
+	client, err := claudeagent.NewClient(opts)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	if got := client.GetModel(); got != "claude-sonnet-4-5" {
+		t.Errorf("expected the configured model before the session starts, got %q", got)
+	}
+	if got := client.GetPermissionMode(); got != claudeagent.PermissionModeAcceptEdits {
+		t.Errorf("expected the configured mode before the session starts, got %q", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for range client.ReceiveResponse(ctx) {
+	}
+
+	if got := client.GetModel(); got != "claude-opus-4-1" {
+		t.Errorf("expected the model the CLI reported, got %q", got)
+	}
+	if got := client.GetPermissionMode(); got != claudeagent.PermissionModePlan {
+		t.Errorf("expected the mode the CLI reported, got %q", got)
+	}
+}
+
+func TestGetPermissionModeFollowsAcceptedSetMode(t *testing.T) {
+	opts := &claudeagent.Options{
+		CanUseTool: func(
+			_ context.Context,
+			_ string,
+			_ map[string]claudeagent.JSONValue,
+			_ []claudeagent.PermissionUpdate,
+			_ string,
+			_ *string,
+			_ *string,
+			_ *string,
+		) (claudeagent.PermissionResult, error) {
+			return &claudeagent.PermissionAllow{UpdatedPermissions: []claudeagent.PermissionUpdate{
+				claudeagent.SetModeUpdate{
+					Mode:        claudeagent.PermissionModeAcceptEdits,
+					Destination: claudeagent.PermissionDestinationSession,
+				},
+			}}, nil
+		},
+	}
+	client, _ := runFakeSession(t, opts, fakeInitLine, fakeCanUseToolLine("cli_1", "Edit", "main.go"), fakeResultLine)
+	fakeCLIStdin(t, opts.PathToClaudeCodeExecutable, "control_response", 1)
+
+	if got := client.GetPermissionMode(); got != claudeagent.PermissionModeAcceptEdits {
+		t.Errorf("expected the accepted mode, got %q", got)
+	}
+	if got := client.GetModel(); got != "" {
+		t.Errorf("expected no model before the CLI reports one, got %q", got)
+	}
+}
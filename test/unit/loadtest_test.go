@@ -0,0 +1,91 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/loadtest"
+)
+
+func TestLoadTestRun(t *testing.T) {
+	// The fake CLI answers every line it reads with a complete turn.
+	script := filepath.Join(t.TempDir(), "claude")
+	body := "#!/bin/sh\nwhile IFS= read -r line; do\n  printf '%s\\n' '" + fakeTextLine("ok") + "' '" + fakeResultLine + "'\ndone\n"
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := loadtest.Run(context.Background(), loadtest.Config{
+		Options:  &claudeagent.Options{PathToClaudeCodeExecutable: script},
+		Script:   []string{"hi", "bye"},
+		QPS:      20,
+		Duration: 300 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if report.Started < 2 || report.Completed != report.Started || report.Failed != 0 {
+		t.Errorf("expected every conversation to complete, got %+v", report)
+	}
+	if report.Turns != 2*report.Completed || report.P50 <= 0 || report.P99 < report.P50 {
+		t.Errorf("unexpected turn stats: %+v", report)
+	}
+	if report.GoroutineLeak() > 0 || report.FDLeak() > 0 {
+		t.Errorf("expected no leaks, got %d goroutines and %d fds", report.GoroutineLeak(), report.FDLeak())
+	}
+}
+
+func TestLoadTestCountsFailures(t *testing.T) {
+	report, err := loadtest.Run(context.Background(), loadtest.Config{
+		Options:  &claudeagent.Options{PathToClaudeCodeExecutable: filepath.Join(t.TempDir(), "missing")},
+		Script:   []string{"hi"},
+		QPS:      10,
+		Duration: 150 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Failed == 0 || report.Failed != report.Started || report.Errors[clauderrs.ErrCodeProcessSpawnFailed] != report.Failed {
+		t.Errorf("expected spawn failures, got %+v", report)
+	}
+
+	if _, err := loadtest.Run(context.Background(), loadtest.Config{QPS: 1, Duration: time.Second}); !clauderrs.IsValidationError(err) {
+		t.Errorf("expected ValidationError for an empty script, got %v", err)
+	}
+}
+
+func TestMockCLIServe(t *testing.T) {
+	input := strings.Join([]string{
+		`{"type":"control_request","request_id":"req_1","request":{"subtype":"initialize"}}`,
+		`{"type":"user","message":{"role":"user","content":"hi"}}`,
+	}, "\n") + "\n"
+
+	var output bytes.Buffer
+	if err := (loadtest.MockCLI{Reply: "pong"}).Serve(strings.NewReader(input), &output); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[0], `"request_id":"req_1"`) {
+		t.Fatalf("expected a control response and a turn, got %q", lines)
+	}
+	var types []string
+	for _, line := range lines[1:] {
+		msg, err := claudeagent.DecodeMessage([]byte(line))
+		if err != nil {
+			t.Fatalf("mock frame does not decode: %v: %s", err, line)
+		}
+		types = append(types, msg.Type())
+	}
+	if strings.Join(types, ",") != "system,assistant,result" {
+		t.Errorf("unexpected frames: %v", types)
+	}
+}
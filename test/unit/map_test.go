@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// newConcurrencyFakeCLI writes a fake CLI that records in counts.txt how
+// many runs, itself included, are in progress when it starts.
+func newConcurrencyFakeCLI(t *testing.T) (script, dir string) {
+	t.Helper()
+
+	dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stdout.jsonl"), []byte(fakeInitLine+"\n"+fakeResultLine+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "running"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	script = filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+touch running/$$
+ls running | wc -l >>counts.txt
+sleep 0.1
+rm running/$$
+cat stdout.jsonl
+cat >/dev/null
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script, dir
+}
+
+func TestMapBoundsConcurrency(t *testing.T) {
+	script, dir := newConcurrencyFakeCLI(t)
+	items := []string{"a.go", "b.go", "c.go", "d.go", "e.go"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	results := claudeagent.Map(ctx, items, func(file string) claudeagent.Prompt {
+		return claudeagent.Prompt{Text: "audit " + file}
+	}, &claudeagent.MapOptions{
+		Options:     &claudeagent.Options{PathToClaudeCodeExecutable: script},
+		Concurrency: 2,
+	})
+
+	if len(results) != len(items) {
+		t.Fatalf("expected %d results, got %d", len(items), len(results))
+	}
+	for i, result := range results {
+		if result.Item != items[i] {
+			t.Errorf("result %d is for %q, want %q", i, result.Item, items[i])
+		}
+		if result.Err != nil || result.Result == nil || *result.Result.Result != "done" {
+			t.Errorf("item %s: expected result done, got %+v", result.Item, result)
+		}
+	}
+
+	counts := strings.Fields(readFakeFile(t, dir, "counts.txt"))
+	if len(counts) != len(items) {
+		t.Fatalf("expected %d runs, got %v", len(items), counts)
+	}
+	for _, count := range counts {
+		if n, _ := strconv.Atoi(count); n > 2 {
+			t.Errorf("expected at most 2 concurrent sessions, saw %d", n)
+		}
+	}
+}
+
+func TestMapReportsPerItemErrors(t *testing.T) {
+	script, _ := newConcurrencyFakeCLI(t)
+	missing := &claudeagent.Options{PathToClaudeCodeExecutable: filepath.Join(t.TempDir(), "missing")}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	results := claudeagent.Map(ctx, []int{1, 2, 3}, func(n int) claudeagent.Prompt {
+		prompt := claudeagent.Prompt{Text: "item " + strconv.Itoa(n)}
+		if n == 2 {
+			prompt.Options = missing
+		}
+
+		return prompt
+	}, &claudeagent.MapOptions{Options: &claudeagent.Options{PathToClaudeCodeExecutable: script}})
+
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Errorf("expected items 1 and 3 to succeed, got %v and %v", results[0].Err, results[2].Err)
+	}
+	if results[1].Err == nil || results[1].Result != nil {
+		t.Errorf("expected item 2 to fail, got %+v", results[1])
+	}
+}
+
+func TestMapSpacesStartsAndStopsOnCancel(t *testing.T) {
+	script, _ := newConcurrencyFakeCLI(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	results := claudeagent.Map(ctx, []int{1, 2, 3}, func(int) claudeagent.Prompt {
+		return claudeagent.Prompt{Text: "hi"}
+	}, &claudeagent.MapOptions{
+		Options:     &claudeagent.Options{PathToClaudeCodeExecutable: script},
+		MinInterval: time.Second,
+	})
+
+	if results[0].Err != nil {
+		t.Errorf("expected the first item to run, got %v", results[0].Err)
+	}
+	for _, result := range results[1:] {
+		if !clauderrs.IsAbortedError(result.Err) {
+			t.Errorf("expected AbortedError for an item not started, got %v", result.Err)
+		}
+	}
+}
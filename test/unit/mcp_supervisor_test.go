@@ -0,0 +1,164 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// newFlakyMcpServer writes a stdio MCP server that exits on its first
+// tools/call without answering it. It records launches and requests in its
+// directory.
+func newFlakyMcpServer(t *testing.T) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "server")
+	body := `#!/bin/sh
+cd '` + dir + `'
+echo launch >>launches
+while IFS= read -r line; do
+  printf '%s\n' "$line" >>requests
+  id=$(printf '%s\n' "$line" | sed -n 's/.*"id":\("[^"]*"\|[0-9]*\).*/\1/p')
+  case "$line" in
+    *'"method":"initialize"'*) printf '{"jsonrpc":"2.0","id":%s,"result":{"protocolVersion":"2024-11-05","capabilities":{"tools":{}},"serverInfo":{"name":"flaky","version":"1.0"}}}\n' "$id" ;;
+    *'"method":"tools/list"'*) printf '{"jsonrpc":"2.0","id":%s,"result":{"tools":[{"name":"echo","inputSchema":{"type":"object"}}]}}\n' "$id" ;;
+    *'"method":"tools/call"'*) [ -e crashed ] || { touch crashed; exit 1; }; printf '{"jsonrpc":"2.0","id":%s,"result":{"content":[{"type":"text","text":"pong"}]}}\n' "$id" ;;
+  esac
+done
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatalf("failed to write fake MCP server: %v", err)
+	}
+
+	return script, dir
+}
+
+func TestMcpSupervisionRestartsServer(t *testing.T) {
+	server, serverDir := newFlakyMcpServer(t)
+	call := `{"jsonrpc":"2.0","id":%s,"method":"tools/call","params":{"name":"echo","arguments":{}}}`
+	script := newStagedFakeCLI(t,
+		[]string{fakeInitLine, fakeMcpMessageLine("cli_1", "flaky",
+			`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"claude-code","version":"1"}}}`)},
+		[]string{fakeMcpMessageLine("cli_2", "flaky", `{"jsonrpc":"2.0","method":"notifications/initialized"}`)},
+		[]string{fakeMcpMessageLine("cli_3", "flaky", strings.Replace(call, "%s", "2", 1))},
+		[]string{fakeMcpMessageLine("cli_4", "flaky", strings.Replace(call, "%s", "3", 1))},
+		[]string{fakeResultLine},
+	)
+
+	var mu sync.Mutex
+	var statuses []string
+	_, _ = collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		McpServers: map[string]claudeagent.McpServerConfig{
+			"flaky": claudeagent.McpStdioServerConfig{Command: server},
+		},
+		McpSupervision: &claudeagent.McpSupervision{
+			InitialBackoff: 10 * time.Millisecond,
+			OnStatus: func(status claudeagent.McpServerStatus) {
+				mu.Lock()
+				defer mu.Unlock()
+				statuses = append(statuses, status.Status)
+			},
+		},
+	})
+
+	responses := make(map[string]string)
+	for _, line := range fakeCLIStdin(t, script, `"request_id":"cli_4"`, 1) {
+		for _, id := range []string{"cli_3", "cli_4"} {
+			if strings.Contains(line, `"`+id+`"`) {
+				responses[id] = line
+			}
+		}
+	}
+	// The call in flight when the server crashed may have had effects, so
+	// it fails rather than being sent again
+	if !strings.Contains(responses["cli_3"], "exited before responding") {
+		t.Errorf("expected the interrupted call to fail, got %s", responses["cli_3"])
+	}
+	if !strings.Contains(responses["cli_4"], "pong") {
+		t.Errorf("expected the next call to reach the restarted server, got %s", responses["cli_4"])
+	}
+
+	if launches := readFakeFile(t, serverDir, "launches"); strings.Count(launches, "launch") != 2 {
+		t.Errorf("expected one restart, got launches %q", launches)
+	}
+	requests := readFakeFile(t, serverDir, "requests")
+	if strings.Count(requests, `"clientInfo":{"name":"claude-code"`) != 2 || !strings.Contains(requests, `"id":"supervisor-`) {
+		t.Errorf("expected the initialize handshake to be replayed, got %s", requests)
+	}
+	if !strings.Contains(requests, `"method":"tools/list"`) {
+		t.Errorf("expected the tools to be listed again after the restart, got %s", requests)
+	}
+
+	// The last transition may be reported just after cli_4 is answered
+	deadline := time.Now().Add(fakeCLITimeout)
+	for {
+		mu.Lock()
+		got := strings.Join(statuses, ",")
+		mu.Unlock()
+		if got == "connected,pending,connected" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected status transitions %s", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMcpSupervisionGivesUp(t *testing.T) {
+	var mu sync.Mutex
+	var statuses []string
+	done := make(chan struct{})
+	script := newStagedFakeCLI(t,
+		[]string{fakeInitLine, fakeMcpMessageLine("cli_1", "missing", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)},
+		[]string{fakeResultLine},
+	)
+
+	_, _ = collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		McpServers: map[string]claudeagent.McpServerConfig{
+			"missing": claudeagent.McpStdioServerConfig{Command: filepath.Join(t.TempDir(), "nope")},
+		},
+		McpSupervision: &claudeagent.McpSupervision{
+			MaxRestarts:    2,
+			InitialBackoff: time.Millisecond,
+			OnStatus: func(status claudeagent.McpServerStatus) {
+				mu.Lock()
+				defer mu.Unlock()
+				statuses = append(statuses, status.Status)
+				if status.Status == "failed" {
+					close(done)
+				}
+			},
+		},
+	})
+
+	select {
+	case <-done:
+	case <-time.After(fakeCLITimeout):
+		t.Fatal("expected the server to be reported as failed")
+	}
+	mu.Lock()
+	if got := strings.Join(statuses, ","); got != "pending,pending,failed" {
+		t.Errorf("unexpected status transitions %s", got)
+	}
+	mu.Unlock()
+
+	response := strings.Join(fakeCLIStdin(t, script, `"request_id":"cli_1"`, 1), "\n")
+	if !strings.Contains(response, "was not restarted") {
+		t.Errorf("expected a JSON-RPC error for the failed server, got %s", response)
+	}
+
+	_, err := claudeagent.NewOptions().WithMcpSupervision(claudeagent.McpSupervision{MaxRestarts: -1}).Build()
+	if !clauderrs.IsValidationError(err) {
+		t.Errorf("expected ValidationError for negative MaxRestarts, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/notify"
+)
+
+func TestNotifierPostsLifecycleEvents(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		slack []string
+		hooks []map[string]any
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/slack":
+			slack = append(slack, body["text"].(string))
+		case "/hook":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+			hooks = append(hooks, body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	var errs []error
+	notifier := &notify.Notifier{
+		Sinks: []notify.Sink{
+			notify.SlackWebhook(server.URL + "/slack"),
+			notify.Webhook(server.URL+"/hook", map[string]string{"Authorization": "Bearer token"}),
+			notify.SlackWebhook(server.URL + "/missing"),
+		},
+		Events:    []notify.EventKind{notify.EventSessionFinished, notify.EventPermissionDenied},
+		Templates: map[notify.EventKind]string{notify.EventPermissionDenied: "{{.Tool}} denied in {{.SessionID}}"},
+		Labels:    map[string]string{"agent": "triage"},
+		OnError:   func(err error) { mu.Lock(); errs = append(errs, err); mu.Unlock() },
+	}
+
+	denied := strings.Replace(fakeResultLine, `"result":"done"`,
+		`"result":"done","permission_denials":[{"tool_name":"Bash","tool_use_id":"toolu_1","tool_input":{}}]`, 1)
+	sdkClient, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, denied),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	client := notifier.Client(sdkClient)
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for range client.ReceiveResponse(ctx) {
+	}
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The start is filtered out
+	want := []string{"Bash denied in fake-session", "[agent=triage] Session fake-session finished after 1 turns ($0.0100)"}
+	if len(slack) != 2 || !strings.Contains(strings.Join(slack, "\n"), want[0]) || !strings.Contains(strings.Join(slack, "\n"), want[1]) {
+		t.Errorf("expected Slack messages %q, got %q", want, slack)
+	}
+	if len(hooks) != 2 {
+		t.Fatalf("expected 2 webhook posts, got %v", hooks)
+	}
+	for _, hook := range hooks {
+		if hook["kind"] == "permission_denied" && (hook["tool"] != "Bash" || hook["session_id"] != "fake-session") {
+			t.Errorf("unexpected webhook body %v", hook)
+		}
+	}
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), "404") {
+		t.Errorf("expected the failing sink to be reported twice, got %v", errs)
+	}
+}
@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// TestOptionsBuilderBuild verifies the builder populates Options.
+func TestOptionsBuilderBuild(t *testing.T) {
+	opts, err := claudeagent.NewOptions().
+		WithModel("claude-sonnet-4-5").
+		WithTools("Read", "Grep").
+		WithPermissionMode(claudeagent.PermissionModePlan).
+		WithEnv("FOO", "bar").
+		WithMaxTurns(3).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if opts.Model != "claude-sonnet-4-5" {
+		t.Errorf("unexpected model %q", opts.Model)
+	}
+	if len(opts.AllowedTools) != 2 {
+		t.Errorf("expected 2 allowed tools, got %v", opts.AllowedTools)
+	}
+	if opts.PermissionMode != claudeagent.PermissionModePlan {
+		t.Errorf("unexpected permission mode %q", opts.PermissionMode)
+	}
+	if opts.Env["FOO"] != "bar" || opts.MaxTurns != 3 {
+		t.Errorf("unexpected env or max turns: %v %d", opts.Env, opts.MaxTurns)
+	}
+}
+
+// TestOptionsBuilderValidation verifies cross-field conflicts are rejected.
+func TestOptionsBuilderValidation(t *testing.T) {
+	canUseTool := func(
+		context.Context,
+		string,
+		map[string]claudeagent.JSONValue,
+		[]claudeagent.PermissionUpdate,
+		string,
+		*string,
+		*string,
+		*string,
+	) (claudeagent.PermissionResult, error) {
+		return nil, nil
+	}
+
+	tests := []struct {
+		name    string
+		builder *claudeagent.OptionsBuilder
+		field   string
+		code    clauderrs.ErrorCode
+	}{
+		{
+			name: "bypass with CanUseTool",
+			builder: claudeagent.NewOptions().
+				WithPermissionMode(claudeagent.PermissionModeBypassPermissions).
+				WithAllowDangerouslySkipPermissions().
+				WithCanUseTool(canUseTool),
+			field: "CanUseTool",
+			code:  clauderrs.ErrCodeConflictingOptions,
+		},
+		{
+			name: "bypass without opt-in",
+			builder: claudeagent.NewOptions().
+				WithPermissionMode(claudeagent.PermissionModeBypassPermissions),
+			field: "AllowDangerouslySkipPermissions",
+			code:  clauderrs.ErrCodeMissingField,
+		},
+		{
+			name: "plan with acceptEdits",
+			builder: claudeagent.NewOptions().
+				WithPermissionMode(claudeagent.PermissionModePlan).
+				WithPermissionMode(claudeagent.PermissionModeAcceptEdits),
+			field: "PermissionMode",
+			code:  clauderrs.ErrCodeConflictingOptions,
+		},
+		{
+			name: "continue with resume",
+			builder: claudeagent.NewOptions().
+				WithContinue().
+				WithResume("session-1"),
+			field: "Resume",
+			code:  clauderrs.ErrCodeConflictingOptions,
+		},
+		{
+			name:    "fork without resume",
+			builder: claudeagent.NewOptions().WithForkSession(),
+			field:   "Resume",
+			code:    clauderrs.ErrCodeMissingField,
+		},
+		{
+			name: "tool allowed and disallowed",
+			builder: claudeagent.NewOptions().
+				WithTools("Bash").
+				WithDisallowedTools("Bash"),
+			field: "AllowedTools",
+			code:  clauderrs.ErrCodeConflictingOptions,
+		},
+		{
+			name:    "negative budget",
+			builder: claudeagent.NewOptions().WithMaxBudgetUsd(-1),
+			field:   "MaxBudgetUsd",
+			code:    clauderrs.ErrCodeRangeViolation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := tt.builder.Build()
+			if err == nil {
+				t.Fatalf("expected error, got options %+v", opts)
+			}
+
+			var validationErr *clauderrs.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected ValidationError, got %T: %v", err, err)
+			}
+			if validationErr.Field() != tt.field {
+				t.Errorf("expected field %q, got %q", tt.field, validationErr.Field())
+			}
+			if validationErr.Code() != tt.code {
+				t.Errorf("expected code %q, got %q", tt.code, validationErr.Code())
+			}
+		})
+	}
+}
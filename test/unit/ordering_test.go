@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// orderingLines is a partial stream whose second content block starts
+// before the first one.
+var orderingLines = []string{
+	fakeInitLine,
+	fakeStreamEventLine(`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude"}}`),
+	fakeStreamEventLine(`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`),
+	fakeStreamEventLine(`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`),
+	fakeStreamEventLine(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`),
+	fakeStreamEventLine(`{"type":"content_block_stop","index":0}`),
+	fakeStreamEventLine(`{"type":"content_block_stop","index":1}`),
+	fakeStreamEventLine(`{"type":"message_stop"}`),
+	fakeResultLine,
+}
+
+func TestMessageOrderingFailsOnSkippedIndex(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	_, err := claudeagent.QueryResult(ctx, "hello", &claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, orderingLines...),
+		IncludePartialMessages:     true,
+		MessageOrdering:            &claudeagent.MessageOrdering{},
+	})
+
+	violation, ok := claudeagent.OrderViolationOf(err)
+	if !ok {
+		t.Fatalf("expected ErrCodeProtocolOutOfOrder, got %v", err)
+	}
+	if violation.Kind != claudeagent.OrderViolationMissing || violation.Expected != "0" || violation.Got != "1" {
+		t.Errorf("expected a missing content block 0, got %+v", violation)
+	}
+}
+
+func TestMessageOrderingRecovery(t *testing.T) {
+	var violations []claudeagent.OrderViolation
+	_, messages := runFakeSession(t, &claudeagent.Options{
+		IncludePartialMessages: true,
+		MessageOrdering: &claudeagent.MessageOrdering{
+			OnViolation: func(v claudeagent.OrderViolation) claudeagent.OrderingAction {
+				violations = append(violations, v)
+
+				return claudeagent.OrderingDrop
+			},
+		},
+	}, orderingLines...)
+
+	// Block 1 skipped block 0, which then starts out of order
+	want := []claudeagent.OrderViolationKind{claudeagent.OrderViolationMissing, claudeagent.OrderViolationOutOfOrder}
+	if len(violations) != len(want) {
+		t.Fatalf("expected %d violations, got %+v", len(want), violations)
+	}
+	for i, kind := range want {
+		if violations[i].Kind != kind {
+			t.Errorf("expected violation %d to be %s, got %+v", i, kind, violations[i])
+		}
+	}
+
+	var events int
+	for _, msg := range messages {
+		if _, ok := msg.(*claudeagent.SDKStreamEvent); ok {
+			events++
+		}
+	}
+	if events != 5 {
+		t.Errorf("expected the out-of-order events to be dropped, got %d events", events)
+	}
+	if _, ok := messages[len(messages)-1].(*claudeagent.SDKResultMessage); !ok {
+		t.Errorf("expected the session to carry on to its result, got %T", messages[len(messages)-1])
+	}
+}
+
+func TestMessageOrderingToolResultBeforeToolUse(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	_, err := claudeagent.QueryResult(ctx, "hello", &claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t,
+			fakeInitLine, fakeToolResultLine("toolu_1", "ok", false), fakeToolUseLine("toolu_1", "Read", `{}`), fakeResultLine),
+		MessageOrdering: &claudeagent.MessageOrdering{},
+	})
+
+	violation, ok := claudeagent.OrderViolationOf(err)
+	if !ok || violation.MessageType != "tool_result" || violation.Got != "toolu_1" {
+		t.Errorf("expected a tool_result without its tool_use, got %v", err)
+	}
+}
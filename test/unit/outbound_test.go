@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// assertValidationField checks that err is a ValidationError for field.
+func assertValidationField(t *testing.T, err error, field string) {
+	t.Helper()
+
+	var validationErr *clauderrs.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for %s, got %v", field, err)
+	}
+	if validationErr.Field() != field {
+		t.Errorf("expected field %q, got %q (%v)", field, validationErr.Field(), err)
+	}
+}
+
+func TestOutboundValidationRejectsBeforeWriting(t *testing.T) {
+	opts := &claudeagent.Options{MaxOutboundMessageSize: 2048}
+	client, _ := runFakeSession(t, opts, fakeInitLine, fakeResultLine)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	err := client.SendMessage(ctx, []claudeagent.ContentBlock{
+		claudeagent.TextContentBlock{Type: "text", Text: "look"},
+		claudeagent.ImageContentBlock{
+			Type:   "image",
+			Source: claudeagent.ImageSource{Type: "base64", MediaType: "image/png"},
+		},
+	}, "")
+	assertValidationField(t, err, "message.content[1].source.data")
+
+	assertValidationField(t, client.SendMessage(ctx, nil, ""), "message.content")
+	assertValidationField(t, client.Query(ctx, ""), "message.content[0].text")
+	assertValidationField(t, client.SetPermissionMode(ctx, "yolo"), "request.mode")
+
+	err = client.Query(ctx, "oversized "+strings.Repeat("x", 4096))
+	assertValidationField(t, err, "message")
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeMessageTooLarge {
+		t.Errorf("expected ErrCodeMessageTooLarge, got %v", err)
+	}
+
+	stdin := strings.Join(fakeCLIStdin(t, opts.PathToClaudeCodeExecutable, `"type":"user"`, 1), "\n")
+	if strings.Contains(stdin, "yolo") || strings.Contains(stdin, "oversized") || strings.Count(stdin, `"type":"user"`) != 1 {
+		t.Errorf("expected only the first prompt to be written, got %s", stdin)
+	}
+}
+
+func TestOutboundValidationInitialPrompt(t *testing.T) {
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeResultLine),
+		MaxOutboundMessageSize:     1024,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	assertValidationField(t, client.Query(ctx, strings.Repeat("x", 2048)), "message")
+}
+
+func TestOutboundSizeLimitDisabled(t *testing.T) {
+	opts := &claudeagent.Options{MaxOutboundMessageSize: -1}
+	client, _ := runFakeSession(t, opts, fakeInitLine, fakeResultLine)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, strings.Repeat("x", 11<<20)); err != nil {
+		t.Fatalf("expected an unlimited message to be sent, got %v", err)
+	}
+}
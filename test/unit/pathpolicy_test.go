@@ -0,0 +1,301 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/pathpolicy"
+)
+
+func TestPathMatcherAllowDeny(t *testing.T) {
+	root := t.TempDir()
+	matcher := pathpolicy.NewMatcher().
+		WithRoot(root).
+		WithAllow("src/**", "README.md").
+		WithDeny("src/generated/**")
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"src/main.go", true},
+		{"src/pkg/util/util.go", true},
+		{filepath.Join(root, "README.md"), true},
+		{"docs/guide.md", false},
+		{"src/generated/api.go", false},
+		{"src/../../outside.txt", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		decision := matcher.Evaluate(tt.path)
+		if decision.Allowed != tt.allowed {
+			t.Errorf("%q: expected allowed=%v, got %+v", tt.path, tt.allowed, decision)
+		}
+	}
+}
+
+func TestPathMatcherDotfiles(t *testing.T) {
+	root := t.TempDir()
+
+	matcher := pathpolicy.NewMatcher().WithRoot(root)
+	if decision := matcher.Evaluate(".github/workflows/ci.yml"); decision.Allowed {
+		t.Error("expected dotfiles to be denied by default")
+	}
+	if decision := matcher.Evaluate("main.go"); !decision.Allowed {
+		t.Errorf("expected main.go to be allowed, got %+v", decision)
+	}
+
+	matcher = pathpolicy.NewMatcher().WithRoot(root).WithDotfiles()
+	if decision := matcher.Evaluate(".github/workflows/ci.yml"); !decision.Allowed {
+		t.Errorf("expected dotfiles to be allowed, got %+v", decision)
+	}
+}
+
+func TestPathMatcherSensitivePaths(t *testing.T) {
+	root := t.TempDir()
+	matcher := pathpolicy.NewMatcher().WithRoot(root).WithDotfiles().WithSensitivePaths()
+
+	for _, path := range []string{
+		"/etc/passwd",
+		"~/.ssh/id_ed25519",
+		".env",
+		"services/api/.env.production",
+		".git/config",
+	} {
+		decision := matcher.Evaluate(path)
+		if decision.Allowed {
+			t.Errorf("%q: expected sensitive path to be denied", path)
+		}
+	}
+
+	if decision := matcher.Evaluate(".git/HEAD"); !decision.Allowed {
+		t.Errorf("expected .git/HEAD to be allowed, got %+v", decision)
+	}
+}
+
+func TestPathMatcherResolvesSymlinks(t *testing.T) {
+	root := t.TempDir()
+	secret := filepath.Join(root, "secret")
+	if err := os.Mkdir(secret, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(root, "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	matcher := pathpolicy.NewMatcher().WithRoot(root).WithDeny("secret/**")
+	if decision := matcher.Evaluate("link/key.pem"); decision.Allowed {
+		t.Error("expected path through symlink to a denied directory to be denied")
+	}
+}
+
+func TestPathMatcherCanUseTool(t *testing.T) {
+	root := t.TempDir()
+	canUseTool := pathpolicy.NewMatcher().
+		WithRoot(root).
+		WithAllow("src/**").
+		CanUseTool(nil)
+
+	check := func(toolName string, input map[string]any) claudeagent.PermissionResult {
+		t.Helper()
+
+		raw := make(map[string]claudeagent.JSONValue, len(input))
+		for k, v := range input {
+			data, _ := json.Marshal(v)
+			raw[k] = data
+		}
+
+		result, err := canUseTool(context.Background(), toolName, raw, nil, "toolu_1", nil, nil, nil)
+		if err != nil {
+			t.Fatalf("CanUseTool failed: %v", err)
+		}
+
+		return result
+	}
+
+	if _, ok := check("Read", map[string]any{"file_path": "src/a.go"}).(*claudeagent.PermissionAllow); !ok {
+		t.Error("expected Read in src to be allowed")
+	}
+
+	denied, ok := check("Write", map[string]any{"file_path": "/tmp/x"}).(*claudeagent.PermissionDeny)
+	if !ok {
+		t.Fatal("expected Write outside src to be denied")
+	}
+	if !strings.Contains(denied.Message, "allowed paths: src/**") {
+		t.Errorf("expected deny message to list allowed paths, got %q", denied.Message)
+	}
+
+	if _, ok := check("Grep", map[string]any{"pattern": "TODO"}).(*claudeagent.PermissionDeny); !ok {
+		t.Error("expected Grep without a path to be checked against the root")
+	}
+	if _, ok := check("Bash", map[string]any{"command": "ls"}).(*claudeagent.PermissionAllow); !ok {
+		t.Error("expected non-file tools to be delegated")
+	}
+}
+
+func TestPathMatcherCanUseToolPatterns(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "repo", "app")
+	canUseTool := pathpolicy.NewMatcher().
+		WithRoot(root).
+		WithSensitivePaths().
+		CanUseTool(nil)
+
+	check := func(toolName string, input map[string]any) claudeagent.PermissionResult {
+		t.Helper()
+
+		raw := make(map[string]claudeagent.JSONValue, len(input))
+		for k, v := range input {
+			data, _ := json.Marshal(v)
+			raw[k] = data
+		}
+
+		result, err := canUseTool(context.Background(), toolName, raw, nil, "toolu_1", nil, nil, nil)
+		if err != nil {
+			t.Fatalf("CanUseTool failed: %v", err)
+		}
+
+		return result
+	}
+
+	for _, input := range []map[string]any{
+		{"pattern": "/etc/**/*"},
+		{"pattern": "../../../../../../../../etc/*"},
+		{"pattern": "etc/*", "path": "/"},
+		{"pattern": "~/.ssh/*"},
+		{"pattern": "*/../../secret"},
+	} {
+		if _, ok := check("Glob", input).(*claudeagent.PermissionDeny); !ok {
+			t.Errorf("Glob %v: expected the pattern to be denied", input)
+		}
+	}
+	if _, ok := check("Grep", map[string]any{"pattern": "root", "glob": "/etc/*"}).(*claudeagent.PermissionDeny); !ok {
+		t.Error("expected a Grep glob under /etc to be denied")
+	}
+
+	for _, input := range []map[string]any{
+		{"pattern": "**/*.go"},
+		{"pattern": "src/*.go"},
+	} {
+		if _, ok := check("Glob", input).(*claudeagent.PermissionAllow); !ok {
+			t.Errorf("Glob %v: expected the pattern to be allowed", input)
+		}
+	}
+	if _, ok := check("Grep", map[string]any{"pattern": "TODO", "glob": "*.{ts,tsx}"}).(*claudeagent.PermissionAllow); !ok {
+		t.Error("expected a relative Grep glob to be allowed")
+	}
+}
+
+func TestPathMatcherCanUseToolSearchReach(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "repo")
+	check := func(matcher *pathpolicy.Matcher, toolName string, input map[string]any) bool {
+		t.Helper()
+
+		raw := make(map[string]claudeagent.JSONValue, len(input))
+		for k, v := range input {
+			data, _ := json.Marshal(v)
+			raw[k] = data
+		}
+		result, err := matcher.CanUseTool(nil)(context.Background(), toolName, raw, nil, "toolu_1", nil, nil, nil)
+		if err != nil {
+			t.Fatalf("CanUseTool failed: %v", err)
+		}
+		_, allowed := result.(*claudeagent.PermissionAllow)
+
+		return allowed
+	}
+
+	env := pathpolicy.NewMatcher().WithRoot(root).WithDeny("/**/.env")
+	secrets := pathpolicy.NewMatcher().WithRoot(root).WithDeny("secrets/**")
+	sensitive := pathpolicy.NewMatcher().WithRoot(root).WithSensitivePaths()
+
+	for _, tt := range []struct {
+		name     string
+		matcher  *pathpolicy.Matcher
+		toolName string
+		input    map[string]any
+		allowed  bool
+	}{
+		{"grep under a .env deny", env, "Grep", map[string]any{"pattern": "TOKEN"}, false},
+		{"grep for .env files", env, "Grep", map[string]any{"pattern": "TOKEN", "glob": ".env"}, false},
+		{"glob for .env files", env, "Glob", map[string]any{"pattern": "**/.env"}, false},
+		{"grep of go files", env, "Grep", map[string]any{"pattern": "TOKEN", "glob": "*.go"}, true},
+		{"grep above a denied subtree", secrets, "Grep", map[string]any{"pattern": "key"}, false},
+		{"grep glob above a denied subtree", secrets, "Grep", map[string]any{"pattern": "key", "glob": "*.pem"}, false},
+		{"glob into a denied subtree", secrets, "Glob", map[string]any{"pattern": "**/*.pem"}, false},
+		{"grep beside a denied subtree", secrets, "Grep", map[string]any{"pattern": "key", "path": "src"}, true},
+		{"grep of the filesystem root", sensitive, "Grep", map[string]any{"pattern": "root", "path": "/"}, false},
+		{"glob from the filesystem root", sensitive, "Glob", map[string]any{"pattern": "**/*.conf", "path": "/"}, false},
+		{"grep of the repository's go files", sensitive, "Grep", map[string]any{"pattern": "TODO", "glob": "*.go"}, true},
+	} {
+		if got := check(tt.matcher, tt.toolName, tt.input); got != tt.allowed {
+			t.Errorf("%s: expected allowed=%v, got %v", tt.name, tt.allowed, got)
+		}
+	}
+}
+
+func TestFilesystemServerEnforcesPolicy(t *testing.T) {
+	fsys := pathpolicy.NewMemFS(map[string]string{
+		"README.md":       "hello",
+		"src/main.go":     "package main",
+		"secrets/key.pem": "secret",
+		".env":            "TOKEN=1",
+	})
+	matcher := pathpolicy.NewMatcher().WithRoot("/workspace").WithDeny("secrets/**")
+	server := matcher.FilesystemServer("files", fsys).(claudeagent.McpSdkServerConfig)
+
+	call := func(tool string, args map[string]any) (string, error) {
+		t.Helper()
+		for _, candidate := range server.Instance.Tools() {
+			if candidate.Name() != tool {
+				continue
+			}
+			result, err := candidate.Execute(context.Background(), args)
+			if err != nil {
+				return "", err
+			}
+
+			return result.Content[0].(claudeagent.TextContentBlock).Text, nil
+		}
+		t.Fatalf("tool %s not found", tool)
+
+		return "", nil
+	}
+
+	if text, err := call(pathpolicy.ListDirectoryTool, map[string]any{}); err != nil || text != "README.md\nsrc/" {
+		t.Errorf("expected the denied and hidden entries to be left out, got %q (%v)", text, err)
+	}
+	if text, err := call(pathpolicy.ReadFileTool, map[string]any{"path": "src/main.go"}); err != nil || text != "package main" {
+		t.Errorf("expected src/main.go to be read, got %q (%v)", text, err)
+	}
+	for _, p := range []string{"secrets/key.pem", ".env", "/etc/passwd", "../outside.txt"} {
+		if _, err := call(pathpolicy.ReadFileTool, map[string]any{"path": p}); err == nil {
+			t.Errorf("expected reading %s to be refused", p)
+		}
+	}
+
+	if _, err := call(pathpolicy.WriteFileTool, map[string]any{"path": "/workspace/docs/guide.md", "content": "# Guide"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if data, err := fsys.ReadFile("docs/guide.md"); err != nil || string(data) != "# Guide" {
+		t.Errorf("expected docs/guide.md to be written, got %q (%v)", data, err)
+	}
+	if _, err := call(pathpolicy.WriteFileTool, map[string]any{"path": "secrets/new.pem", "content": "x"}); err == nil {
+		t.Error("expected writing under secrets to be refused")
+	}
+}
+
+func TestDirFSWritesFiles(t *testing.T) {
+	root := t.TempDir()
+	if err := pathpolicy.DirFS(root).WriteFile("a/b.txt", []byte("x")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "a", "b.txt")); err != nil || string(data) != "x" {
+		t.Errorf("expected a/b.txt to be written, got %q (%v)", data, err)
+	}
+}
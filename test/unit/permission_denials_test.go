@@ -0,0 +1,174 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestSuggestedRuleScopesToInput(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		denial  claudeagent.SDKPermissionDenial
+		content string
+	}{
+		{
+			name: "command",
+			denial: claudeagent.SDKPermissionDenial{ToolName: "Bash", ToolInput: map[string]claudeagent.JSONValue{
+				"command":     json.RawMessage(`"rm -rf build"`),
+				"description": json.RawMessage(`"clean"`),
+			}},
+			content: "rm -rf build",
+		},
+		{
+			name: "file",
+			denial: claudeagent.SDKPermissionDenial{ToolName: "Write", ToolInput: map[string]claudeagent.JSONValue{
+				"file_path": json.RawMessage(`"main.go"`),
+				"content":   json.RawMessage(`"package main"`),
+			}},
+			content: "main.go",
+		},
+		{
+			name: "whole tool",
+			denial: claudeagent.SDKPermissionDenial{ToolName: "Task", ToolInput: map[string]claudeagent.JSONValue{
+				"prompt": json.RawMessage(`"review"`),
+			}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.denial.SuggestedRule()
+			if rule.ToolName != tt.denial.ToolName {
+				t.Errorf("expected a rule for %s, got %s", tt.denial.ToolName, rule.ToolName)
+			}
+			var content string
+			if rule.RuleContent != nil {
+				content = *rule.RuleContent
+			}
+			if content != tt.content {
+				t.Errorf("expected rule content %q, got %q", tt.content, content)
+			}
+		})
+	}
+}
+
+// newRetryFakeCLI writes a fake CLI that emits first, then denied once it
+// answers a permission prompt, then retried once it receives a retry prompt
+// from AllowAndRetry, recording stdin.
+func newRetryFakeCLI(t *testing.T, first, denied, retried []string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, lines := range map[string][]string{"first.jsonl": first, "denied.jsonl": denied, "retried.jsonl": retried} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+cat first.jsonl
+while IFS= read -r line; do
+  printf '%s\n' "$line" >>stdin.jsonl
+  case "$line" in
+    *control_response*) cat denied.jsonl;;
+    *'is now allowed'*) cat retried.jsonl; break;;
+  esac
+done
+cat >>stdin.jsonl
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script
+}
+
+func TestAllowAndRetryAllowsDeniedCall(t *testing.T) {
+	var calls atomic.Int32
+	opts := &claudeagent.Options{
+		CanUseTool: func(
+			_ context.Context,
+			_ string,
+			_ map[string]claudeagent.JSONValue,
+			_ []claudeagent.PermissionUpdate,
+			_ string,
+			_ *string,
+			_ *string,
+			_ *string,
+		) (claudeagent.PermissionResult, error) {
+			calls.Add(1)
+
+			return &claudeagent.PermissionDeny{Message: "not now"}, nil
+		},
+	}
+	opts.PathToClaudeCodeExecutable = newRetryFakeCLI(t,
+		[]string{fakeInitLine, fakeCanUseToolLine("cli_1", "Bash", "rm -rf build")},
+		[]string{strings.Replace(fakeResultLine, `"result":"done"`, `"result":"denied","permission_denials":[`+
+			`{"tool_name":"Bash","tool_use_id":"toolu_cli_1","tool_input":{"command":"rm -rf build"}}]`, 1)},
+		[]string{fakeCanUseToolLine("cli_2", "Bash", "rm -rf build"), fakeResultLine},
+	)
+
+	client, err := claudeagent.NewClient(opts)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "clean the build"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var denials []claudeagent.SDKPermissionDenial
+	for msg := range client.ReceiveResponse(ctx) {
+		if result, ok := msg.(*claudeagent.SDKResultMessage); ok {
+			denials = result.PermissionDenials
+		}
+	}
+	if len(denials) != 1 {
+		t.Fatalf("expected 1 permission denial, got %d", len(denials))
+	}
+
+	if err := client.AllowAndRetry(ctx, denials[0]); err != nil {
+		t.Fatalf("AllowAndRetry failed: %v", err)
+	}
+	for range client.ReceiveResponse(ctx) {
+	}
+
+	var retry, response string
+	for _, line := range fakeCLIStdin(t, opts.PathToClaudeCodeExecutable, `"cli_2"`, 1) {
+		switch {
+		case strings.Contains(line, "is now allowed"):
+			retry = line
+		case strings.Contains(line, `"cli_2"`):
+			response = line
+		}
+	}
+	if !strings.Contains(retry, "toolu_cli_1") {
+		t.Errorf("expected the retry prompt to name the denied tool use, got %q", retry)
+	}
+	if !strings.Contains(response, `"allow":true`) {
+		t.Errorf("expected the retried call to be allowed, got %q", response)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected CanUseTool to run only for the denied call, ran %d times", got)
+	}
+}
+
+func TestAllowAndRetryNeedsPermissionPrompts(t *testing.T) {
+	client, _ := runFakeSession(t, nil, fakeInitLine, fakeResultLine)
+
+	err := client.AllowAndRetry(context.Background(), claudeagent.SDKPermissionDenial{ToolName: "Bash"})
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeInvalidState {
+		t.Errorf("expected ErrCodeInvalidState, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+func TestParsePlanNumberedSteps(t *testing.T) {
+	plan := claudeagent.ParsePlan(`## Plan
+
+1. Read the config
+   - check defaults
+   - note overrides
+2) Update the loader
+
+   Keep backwards compatibility.
+3. Run the tests
+
+Let me know if this looks good.`)
+
+	if len(plan.Steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d: %+v", len(plan.Steps), plan.Steps)
+	}
+
+	want := []claudeagent.PlanStep{
+		{Index: 1, Title: "Read the config", Details: "- check defaults\n- note overrides"},
+		{Index: 2, Title: "Update the loader", Details: "Keep backwards compatibility."},
+		{Index: 3, Title: "Run the tests"},
+	}
+	for i, step := range plan.Steps {
+		if step != want[i] {
+			t.Errorf("step %d: expected %+v, got %+v", i, want[i], step)
+		}
+	}
+}
+
+func TestParsePlanBulletsAndProse(t *testing.T) {
+	plan := claudeagent.ParsePlan("- first\n- second\n")
+	if len(plan.Steps) != 2 || plan.Steps[1].Title != "second" {
+		t.Errorf("expected two bullet steps, got %+v", plan.Steps)
+	}
+
+	plan = claudeagent.ParsePlan("Just edit the file.")
+	if len(plan.Steps) != 0 || plan.Raw != "Just edit the file." {
+		t.Errorf("expected no steps and raw text, got %+v", plan)
+	}
+}
+
+func TestClientLastPlan(t *testing.T) {
+	input, err := json.Marshal(map[string]string{"plan": "1. Write test.txt\n2. Verify it"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, _ := runFakeSession(t, &claudeagent.Options{
+		PermissionMode: claudeagent.PermissionModePlan,
+	},
+		fakeInitLine,
+		fakeTextLine("Here is my plan."),
+		fakeToolUseLine("toolu_plan", "ExitPlanMode", string(input)),
+		fakeResultLine,
+	)
+
+	plan := client.LastPlan()
+	if plan == nil {
+		t.Fatal("expected a plan")
+	}
+	if plan.ToolUseID != "toolu_plan" {
+		t.Errorf("expected tool use ID toolu_plan, got %q", plan.ToolUseID)
+	}
+	if len(plan.Steps) != 2 || plan.Steps[0].Title != "Write test.txt" {
+		t.Errorf("unexpected steps: %+v", plan.Steps)
+	}
+}
+
+func TestClientLastPlanNone(t *testing.T) {
+	client, _ := runFakeSession(t, nil, fakeInitLine, fakeTextLine("hi"), fakeResultLine)
+
+	if plan := client.LastPlan(); plan != nil {
+		t.Errorf("expected no plan, got %+v", plan)
+	}
+}
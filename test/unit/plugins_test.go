@@ -0,0 +1,199 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// newPluginRepo creates a git repository holding files and returns its
+// path and HEAD commit.
+func newPluginRepo(t *testing.T, files map[string]string) (string, string) {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--quiet")
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "plugin")
+
+	return repo, git("rev-parse", "HEAD")
+}
+
+// newPluginDirCLI returns a fake CLI that records its --plugin-dir
+// arguments and the plugin.json found in each to plugins.txt.
+func newPluginDirCLI(t *testing.T) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stdout.jsonl"), []byte(fakeInitLine+"\n"+fakeResultLine+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+while [ $# -gt 0 ]; do
+  if [ "$1" = --plugin-dir ]; then
+    cat "$2/.claude-plugin/plugin.json" >>plugins.txt
+    echo >>plugins.txt
+  fi
+  shift
+done
+cat stdout.jsonl
+cat >/dev/null
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script, dir
+}
+
+func TestGitPluginFetchedAndPinned(t *testing.T) {
+	repo, sha := newPluginRepo(t, map[string]string{
+		"plugins/lint/.claude-plugin/plugin.json": `{"name":"lint"}`,
+	})
+	script, dir := newPluginDirCLI(t)
+	cache := t.TempDir()
+
+	opts, err := claudeagent.NewOptions().
+		WithPlugins(cache, claudeagent.SdkPluginConfig{
+			Type:   claudeagent.PluginTypeGit,
+			URL:    repo,
+			SHA:    sha,
+			Subdir: "plugins/lint",
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	opts.PathToClaudeCodeExecutable = script
+	_, _ = collectFakeSession(t, opts)
+
+	if got := strings.TrimSpace(readFakeFile(t, dir, "plugins.txt")); got != `{"name":"lint"}` {
+		t.Errorf("expected fetched plugin dir to be passed to the CLI, got %q", got)
+	}
+
+	// A pinned checkout is reused from the cache without the repository
+	if err := os.RemoveAll(repo); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = collectFakeSession(t, opts)
+	if got := strings.Count(readFakeFile(t, dir, "plugins.txt"), `{"name":"lint"}`); got != 2 {
+		t.Errorf("expected cached plugin to be reused, got %d loads", got)
+	}
+}
+
+func TestGitPluginShaMismatchFailsQuery(t *testing.T) {
+	repo, _ := newPluginRepo(t, map[string]string{".claude-plugin/plugin.json": `{"name":"lint"}`})
+	script, _ := newPluginDirCLI(t)
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		PluginCacheDir:             t.TempDir(),
+		Plugins: []claudeagent.SdkPluginConfig{{
+			Type: claudeagent.PluginTypeGit,
+			URL:  repo,
+			SHA:  strings.Repeat("a", 40),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "hello"); err == nil {
+		t.Fatal("expected an unknown pinned commit to fail the query")
+	}
+}
+
+func TestRegistryPluginResolvesMarketplaceSource(t *testing.T) {
+	remote, remoteSHA := newPluginRepo(t, map[string]string{".claude-plugin/plugin.json": `{"name":"remote"}`})
+	marketplace, _ := newPluginRepo(t, map[string]string{
+		".claude-plugin/marketplace.json": `{"name":"team","plugins":[` +
+			`{"name":"local","source":"./plugins/local"},` +
+			`{"name":"remote","source":{"source":"url","url":"` + remote + `","sha":"` + remoteSHA + `"}}]}`,
+		"plugins/local/.claude-plugin/plugin.json": `{"name":"local"}`,
+	})
+	script, dir := newPluginDirCLI(t)
+
+	_, _ = collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		PluginCacheDir:             t.TempDir(),
+		Plugins: []claudeagent.SdkPluginConfig{
+			{Type: claudeagent.PluginTypeRegistry, URL: marketplace, Name: "local"},
+			{Type: claudeagent.PluginTypeRegistry, URL: marketplace, Name: "remote"},
+		},
+	})
+
+	if got := strings.Fields(readFakeFile(t, dir, "plugins.txt")); strings.Join(got, " ") !=
+		`{"name":"local"} {"name":"remote"}` {
+		t.Errorf("expected both marketplace plugins to load, got %v", got)
+	}
+}
+
+func TestPluginConfigValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin claudeagent.SdkPluginConfig
+	}{
+		{"short sha", claudeagent.SdkPluginConfig{Type: claudeagent.PluginTypeGit, URL: "x", SHA: "abc123"}},
+		{"git without url", claudeagent.SdkPluginConfig{Type: claudeagent.PluginTypeGit}},
+		{"registry without name", claudeagent.SdkPluginConfig{Type: claudeagent.PluginTypeRegistry, URL: "x"}},
+		{"unknown type", claudeagent.SdkPluginConfig{Type: "npm"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := claudeagent.NewClient(&claudeagent.Options{
+				PathToClaudeCodeExecutable: "/nonexistent/claude",
+				PluginCacheDir:             t.TempDir(),
+				Plugins:                    []claudeagent.SdkPluginConfig{tt.plugin},
+			})
+			if err != nil {
+				t.Fatalf("NewClient failed: %v", err)
+			}
+
+			err = client.Query(context.Background(), "hello")
+			if !clauderrs.IsValidationError(err) {
+				t.Errorf("expected validation error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+func TestPrewarmHandsOutInitializedSessions(t *testing.T) {
+	script := newHookFakeCLI(t, fakeInitLine, fakeTextLine("warm"), fakeResultLine)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	pool, err := claudeagent.Prewarm(ctx, 2, &claudeagent.Options{PathToClaudeCodeExecutable: script})
+	if err != nil {
+		t.Fatalf("Prewarm failed: %v", err)
+	}
+	t.Cleanup(func() { _ = pool.Close() })
+
+	// Both processes completed the handshake before any client asked
+	fakeCLIStdin(t, script, `"subtype":"initialize"`, 2)
+
+	for range 3 {
+		client, err := pool.NewClient(ctx)
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+
+		if err := client.Query(ctx, "hello"); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		var text string
+		for msg := range client.ReceiveResponse(ctx) {
+			if assistant, ok := msg.(*claudeagent.SDKAssistantMessage); ok {
+				if block, ok := assistant.Message.Content[0].(claudeagent.TextContentBlock); ok {
+					text = block.Text
+				}
+			}
+		}
+		if text != "warm" {
+			t.Errorf("expected the session's response, got %q", text)
+		}
+	}
+
+	// The third client gets a replacement if it started in time
+	stats := pool.Stats()
+	if stats.Warm.Count < 2 || stats.Warm.Count+stats.Cold.Count != 3 {
+		t.Errorf("expected at least 2 of 3 warm starts, got %+v", stats)
+	}
+	if stats.Warm.FirstTokenP50 <= 0 || stats.Warm.FirstTokenP50 < stats.Warm.ReadyP50 {
+		t.Errorf("expected first token timings to include the ready time, got %+v", stats.Warm)
+	}
+}
+
+func TestPrewarmReportsStartFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	_, err := claudeagent.Prewarm(ctx, 2, &claudeagent.Options{PathToClaudeCodeExecutable: "/nonexistent/claude"})
+	if err == nil {
+		t.Error("expected Prewarm to fail when the CLI can't start")
+	}
+}
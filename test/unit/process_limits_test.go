@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestProcessLimitsValidation(t *testing.T) {
+	_, err := claudeagent.NewOptions().
+		WithProcessLimits(claudeagent.ProcessLimits{MaxRSS: -1, CPUShares: -1, Niceness: 20}).
+		Build()
+	if !clauderrs.IsValidationError(err) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	for _, field := range []string{"MaxRSS", "CPUShares", "Niceness"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected an error for %s, got %v", field, err)
+		}
+	}
+
+	valid := &claudeagent.Options{ProcessLimits: &claudeagent.ProcessLimits{MaxRSS: 1 << 30, CPUShares: 512, Niceness: 10}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected valid limits, got %v", err)
+	}
+}
+
+func TestProcessLimitsNiceness(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("niceness is read from /proc")
+	}
+
+	// Wrap the fake CLI to record the nice value it runs with.
+	dir := t.TempDir()
+	niceFile := filepath.Join(dir, "nice")
+	wrapper := filepath.Join(dir, "claude")
+	body := "#!/bin/sh\nawk '{print $19}' /proc/$$/stat >'" + niceFile + "'\nexec '" +
+		newFakeCLI(t, fakeInitLine, fakeResultLine) + "'\n"
+	if err := os.WriteFile(wrapper, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: wrapper,
+		ProcessLimits:              &claudeagent.ProcessLimits{Niceness: 7},
+	})
+
+	if got := strings.TrimSpace(readFakeFile(t, dir, "nice")); got != "7" {
+		t.Errorf("expected the CLI to run with niceness 7, got %q", got)
+	}
+}
+
+func TestProcessLimitsUnavailableFailsSpawn(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("job objects don't use a cgroup parent")
+	}
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeResultLine),
+		ProcessLimits: &claudeagent.ProcessLimits{
+			MaxRSS:       256 << 20,
+			CgroupParent: t.TempDir(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	err = client.Query(context.Background(), "hello")
+	sdkErr, ok := clauderrs.AsSDKError(err)
+	if !ok || sdkErr.Code() != clauderrs.ErrCodeProcessSpawnFailed {
+		t.Fatalf("expected spawn failure, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "resource limits unavailable") {
+		t.Errorf("expected the unavailable limits to be named, got %v", err)
+	}
+}
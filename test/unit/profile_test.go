@@ -0,0 +1,58 @@
+package unit
+
+import (
+	"bytes"
+	"runtime/pprof"
+	"sync"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+func TestProfileTransportTracesFrames(t *testing.T) {
+	var mu sync.Mutex
+	var traces []claudeagent.MessageTrace
+	opts := &claudeagent.Options{
+		ProfileTransport: &claudeagent.TransportProfile{
+			OnTrace: func(trace claudeagent.MessageTrace) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				traces = append(traces, trace)
+			},
+			PprofLabels: true,
+		},
+	}
+	client, _ := runFakeSession(t, opts, fakeInitLine, fakeTextLine("hi"), fakeResultLine)
+
+	var profile bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&profile, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(profile.Bytes(), []byte(`"`+claudeagent.PprofLabelGoroutine+`":"reader"`)) {
+		t.Error("expected the reader goroutine to carry pprof labels")
+	}
+	_ = client.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	var types []string
+	for _, trace := range traces {
+		types = append(types, trace.Direction+":"+trace.Type)
+		if trace.Bytes == 0 || trace.Timestamp.IsZero() {
+			t.Errorf("expected size and timestamp, got %+v", trace)
+		}
+	}
+	want := []string{"outbound:user", "inbound:system", "inbound:assistant", "inbound:result"}
+	if len(types) != len(want) {
+		t.Fatalf("expected traces %v, got %v", want, types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("expected trace %d to be %s, got %s", i, want[i], types[i])
+		}
+	}
+	if result := traces[len(traces)-1]; result.Bytes != len(fakeResultLine)+1 {
+		t.Errorf("expected the result frame's size, got %d", result.Bytes)
+	}
+}
@@ -0,0 +1,131 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// newEnvFakeCLI writes a fake CLI that records its environment in env.txt.
+func newEnvFakeCLI(t *testing.T) (script, dir string) {
+	t.Helper()
+
+	dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stdout.jsonl"), []byte(fakeInitLine+"\n"+fakeResultLine+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	script = filepath.Join(dir, "claude")
+	body := "#!/bin/sh\ncd '" + dir + "'\nenv >env.txt\ncat stdout.jsonl\ncat >/dev/null\n"
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script, dir
+}
+
+// clearProviderEnv unsets provider variables inherited by the test.
+func clearProviderEnv(t *testing.T) {
+	t.Helper()
+
+	for _, name := range []string{
+		"CLAUDE_CODE_USE_BEDROCK", "CLAUDE_CODE_USE_VERTEX", "AWS_REGION",
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "CLOUD_ML_REGION",
+		"ANTHROPIC_VERTEX_PROJECT_ID", "GOOGLE_APPLICATION_CREDENTIALS",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestProviderSetsCLIEnvironment(t *testing.T) {
+	clearProviderEnv(t)
+	t.Setenv("CLAUDE_CODE_USE_BEDROCK", "1")
+	t.Setenv("ANTHROPIC_VERTEX_PROJECT_ID", "acme-ml")
+
+	tests := []struct {
+		name string
+		opts claudeagent.Options
+		want []string
+	}{
+		{
+			name: "bedrock",
+			opts: claudeagent.Options{Provider: claudeagent.ProviderBedrock, ProviderRegion: "us-west-2"},
+			want: []string{"CLAUDE_CODE_USE_BEDROCK=1", "CLAUDE_CODE_USE_VERTEX=0", "AWS_REGION=us-west-2"},
+		},
+		{
+			name: "vertex",
+			opts: claudeagent.Options{Provider: claudeagent.ProviderVertex, ProviderRegion: "us-east5"},
+			want: []string{"CLAUDE_CODE_USE_VERTEX=1", "CLAUDE_CODE_USE_BEDROCK=0", "CLOUD_ML_REGION=us-east5", "ANTHROPIC_VERTEX_PROJECT_ID=acme-ml"},
+		},
+		{
+			name: "anthropic overrides inherited bedrock",
+			opts: claudeagent.Options{Provider: claudeagent.ProviderAnthropic},
+			want: []string{"CLAUDE_CODE_USE_BEDROCK=0", "CLAUDE_CODE_USE_VERTEX=0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, dir := newEnvFakeCLI(t)
+			opts := tt.opts
+			opts.PathToClaudeCodeExecutable = script
+			_, _ = collectFakeSession(t, &opts)
+
+			env := strings.Split(readFakeFile(t, dir, "env.txt"), "\n")
+			for _, want := range tt.want {
+				found := false
+				for _, line := range env {
+					found = found || line == want
+				}
+				if !found {
+					t.Errorf("expected %s in CLI environment", want)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderReportsMissingEnvironment(t *testing.T) {
+	clearProviderEnv(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAEXAMPLE")
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{Provider: claudeagent.ProviderBedrock})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	err = client.Query(context.Background(), "hello")
+	if !clauderrs.IsValidationError(err) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	for _, want := range []string{"AWS_REGION", "AWS_SECRET_ACCESS_KEY"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %s to be reported, got %v", want, err)
+		}
+	}
+
+	client, err = claudeagent.NewClient(&claudeagent.Options{
+		Provider: claudeagent.ProviderVertex,
+		Env:      map[string]string{"CLOUD_ML_REGION": "us-east5"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	err = client.Query(context.Background(), "hello")
+	if !clauderrs.IsValidationError(err) || !strings.Contains(err.Error(), "ANTHROPIC_VERTEX_PROJECT_ID") ||
+		strings.Contains(err.Error(), "CLOUD_ML_REGION") {
+		t.Errorf("expected only the missing project to be reported, got %v", err)
+	}
+}
+
+func TestProviderValidation(t *testing.T) {
+	err := (&claudeagent.Options{Provider: "azure", VertexProjectID: "acme-ml"}).Validate()
+	if !clauderrs.IsValidationError(err) ||
+		!strings.Contains(err.Error(), `unknown provider "azure"`) ||
+		!strings.Contains(err.Error(), "VertexProjectID requires the vertex provider") {
+		t.Errorf("expected provider errors, got %v", err)
+	}
+}
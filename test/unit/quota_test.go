@@ -0,0 +1,63 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/quota"
+)
+
+func TestQuotaRefusesQueriesPastLimit(t *testing.T) {
+	store := quota.NewMemoryStore()
+	quotas := quota.NewManager(store, quota.Limits{MaxCost: 10_000_000, Window: time.Hour}) // $0.01
+
+	sdkClient, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeResultLine),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	client := quotas.Client(sdkClient, "alice", func(err error) { t.Errorf("failed to record usage: %v", err) })
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for range client.ReceiveResponse(ctx) {
+	}
+
+	usage, err := quotas.Usage(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Usage failed: %v", err)
+	}
+	if usage.Cost != 10_000_000 || usage.Tokens != 15 {
+		t.Errorf("usage = %+v, want $0.01 and 15 tokens", usage)
+	}
+
+	err = client.Query(ctx, "again")
+	sdkErr, ok := clauderrs.AsSDKError(err)
+	if !ok || sdkErr.Code() != clauderrs.ErrCodeQuotaExceeded {
+		t.Fatalf("Query past the quota returned %v, want %s", err, clauderrs.ErrCodeQuotaExceeded)
+	}
+
+	// Other managers sharing the store see the usage
+	shared := quota.NewManager(store, quota.Limits{MaxCost: 10_000_000, Window: time.Hour})
+	if err := shared.Check(ctx, "alice"); err == nil {
+		t.Error("Check on a shared store passed for a key past its quota")
+	}
+	if err := shared.Check(ctx, "bob"); err != nil {
+		t.Errorf("Check failed for a key without usage: %v", err)
+	}
+
+	// Limits of a key override the defaults
+	quotas.SetLimits("alice", quota.Limits{MaxTokens: 100, Window: time.Hour})
+	if err := quotas.Check(ctx, "alice"); err != nil {
+		t.Errorf("Check failed under the key's own limits: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// testAPIKey is a fake Anthropic key passed in the CLI's environment.
+const testAPIKey = "sk-ant-REDACTED"
+
+// newStderrFakeCLI writes a fake CLI that writes stderr to its standard
+// error, then behaves like newFakeCLI with lines.
+func newStderrFakeCLI(t *testing.T, stderr []string, lines ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range map[string][]string{"stderr.txt": stderr, "stdout.jsonl": lines} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(content, "\n")+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+cat stderr.txt >&2
+cat stdout.jsonl
+cat >/dev/null
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script
+}
+
+func TestRedactionScrubsStderrLines(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	collectFakeSession(t, &claudeagent.Options{
+		Env:       map[string]string{"ANTHROPIC_API_KEY": testAPIKey, "MY_SERVICE_TOKEN": "tok-1234567890"},
+		Redaction: &claudeagent.Redaction{Patterns: []string{`acct-\d+`}},
+		Stderr: func(line string) {
+			mu.Lock()
+			defer mu.Unlock()
+			lines = append(lines, line)
+		},
+		PathToClaudeCodeExecutable: newStderrFakeCLI(t,
+			[]string{
+				"request failed with key " + testAPIKey,
+				"Authorization: Bearer abc.def.ghi",
+				"service token tok-1234567890 rejected for acct-42",
+			},
+			fakeInitLine, fakeResultLine,
+		),
+	})
+
+	deadline := time.Now().Add(fakeCLITimeout)
+	for {
+		mu.Lock()
+		got := strings.Join(lines, "\n")
+		n := len(lines)
+		mu.Unlock()
+		if n >= 3 {
+			for _, secret := range []string{testAPIKey, "abc.def.ghi", "tok-1234567890", "acct-42"} {
+				if strings.Contains(got, secret) {
+					t.Errorf("expected %q to be redacted from stderr, got %q", secret, got)
+				}
+			}
+			if !strings.Contains(got, "Authorization: Bearer [redacted]") {
+				t.Errorf("expected the header name to be kept, got %q", got)
+			}
+
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 3 stderr lines, got %q", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedactionCanBeDisabled(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	collectFakeSession(t, &claudeagent.Options{
+		Env:       map[string]string{"ANTHROPIC_API_KEY": testAPIKey},
+		Redaction: &claudeagent.Redaction{Disabled: true},
+		Stderr: func(line string) {
+			mu.Lock()
+			defer mu.Unlock()
+			lines = append(lines, line)
+		},
+		PathToClaudeCodeExecutable: newStderrFakeCLI(t,
+			[]string{"key " + testAPIKey},
+			fakeInitLine, fakeResultLine,
+		),
+	})
+
+	deadline := time.Now().Add(fakeCLITimeout)
+	for {
+		mu.Lock()
+		got := strings.Join(lines, "\n")
+		mu.Unlock()
+		if got != "" {
+			if !strings.Contains(got, testAPIKey) {
+				t.Errorf("expected the key to be kept with redaction disabled, got %q", got)
+			}
+
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected a stderr line")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedactScrubsErrorChain(t *testing.T) {
+	redact := func(s string) string { return strings.ReplaceAll(s, "hunter22", "[redacted]") }
+
+	procErr := clauderrs.NewProcessError(
+		clauderrs.ErrCodeProcessCrashed,
+		"Claude Code exited",
+		fmt.Errorf("exec failed with password hunter22"),
+		1,
+		"fatal: password hunter22 rejected",
+	)
+	err := clauderrs.Redact(fmt.Errorf("session: %w", procErr), redact)
+
+	if strings.Contains(err.Error(), "hunter22") {
+		t.Errorf("expected the error text to be redacted, got %q", err)
+	}
+	var got *clauderrs.ProcessError
+	if !errors.As(err, &got) {
+		t.Fatalf("expected the ProcessError to stay in the chain, got %v", err)
+	}
+	if strings.Contains(got.Stderr(), "hunter22") || got.Stderr() != "fatal: password [redacted] rejected" {
+		t.Errorf("expected stderr to be redacted, got %q", got.Stderr())
+	}
+	if stderr, _ := got.Metadata()["stderr"].(string); strings.Contains(stderr, "hunter22") {
+		t.Errorf("expected stderr metadata to be redacted, got %q", stderr)
+	}
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeProcessCrashed {
+		t.Errorf("expected the error code to be kept, got %v", err)
+	}
+}
+
+func TestRedactionValidatesPatterns(t *testing.T) {
+	_, err := claudeagent.NewOptions().WithRedactionPatterns("(unclosed").Build()
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeInvalidFormat {
+		t.Errorf("expected ErrCodeInvalidFormat, got %v", err)
+	}
+}
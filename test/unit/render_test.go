@@ -0,0 +1,128 @@
+package unit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/render"
+)
+
+// textDelta returns a stream event carrying a text delta.
+func textDelta(text string) *claudeagent.SDKStreamEvent {
+	return &claudeagent.SDKStreamEvent{
+		Event: claudeagent.ContentBlockDeltaEvent{
+			Delta: claudeagent.ContentDelta{TextDelta: &text},
+		},
+	}
+}
+
+func TestRendererStreamsMarkdownByLine(t *testing.T) {
+	var buf bytes.Buffer
+	r := render.New(&buf)
+
+	for _, delta := range []string{"# Plan\n- first ", "step\n```go\nfunc main", "() {}\n```\n", "done"} {
+		if err := r.Render(textDelta(delta)); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+	}
+	if strings.Contains(buf.String(), "done") {
+		t.Error("expected partial line to stay buffered")
+	}
+
+	_ = r.Render(&claudeagent.SDKStreamEvent{Event: claudeagent.MessageStopEvent{}})
+	_ = r.Render(&claudeagent.SDKAssistantMessage{Message: claudeagent.APIAssistantMessage{
+		Content: []claudeagent.ContentBlock{claudeagent.TextContentBlock{Text: "# Plan"}},
+	}})
+
+	want := "Plan\n• first step\n╭─ go\n│ func main() {}\n╰─\ndone\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\n%q\nwant:\n%q", got, want)
+	}
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Error("expected no colors for a non-terminal writer")
+	}
+}
+
+func TestRendererHighlightsCode(t *testing.T) {
+	var buf bytes.Buffer
+	r := render.New(&buf).WithColor(true)
+
+	_ = r.Render(&claudeagent.SDKAssistantMessage{Message: claudeagent.APIAssistantMessage{
+		Content: []claudeagent.ContentBlock{claudeagent.TextContentBlock{
+			Text: "```go\nreturn \"x\" // done\n```",
+		}},
+	}})
+
+	out := buf.String()
+	for _, want := range []string{"\x1b[35mreturn\x1b[0m", "\x1b[32m\"x\"\x1b[0m", "\x1b[2m\x1b[3m// done\x1b[0m"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+}
+
+func TestRendererToolSummariesAndFooter(t *testing.T) {
+	var buf bytes.Buffer
+	r := render.New(&buf)
+
+	output := "ok  \tpkg\t0.1s\nmore"
+	result := "done"
+	messages := []claudeagent.SDKMessage{
+		&claudeagent.SDKAssistantMessage{Message: claudeagent.APIAssistantMessage{
+			Content: []claudeagent.ContentBlock{claudeagent.ToolUseContentBlock{
+				ID: "toolu_1", Name: "Bash", Input: claudeagent.JSONValue(`{"command":"go test ./..."}`),
+			}},
+		}},
+		&claudeagent.SDKUserMessage{Message: claudeagent.APIUserMessage{
+			Content: []claudeagent.ContentBlock{claudeagent.ToolResultContentBlock{
+				ToolUseID: "toolu_1", Content: &claudeagent.ToolResultContent{Text: &output},
+			}},
+		}},
+		&claudeagent.SDKResultMessage{
+			Subtype:      "success",
+			DurationMS:   1234,
+			NumTurns:     2,
+			TotalCostUSD: 0.0125,
+			Usage:        claudeagent.Usage{InputTokens: 100, OutputTokens: 20},
+			Result:       &result,
+		},
+	}
+	for _, msg := range messages {
+		if err := r.Render(msg); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"⏺ Bash(go test ./...)\n",
+		"  ⎿ Bash ok  \tpkg\t0.1s\n",
+		"── success · 2 turns · 1.2s · $0.0125 · 100 in / 20 out tokens\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+}
+
+func TestToolSummary(t *testing.T) {
+	tests := []struct {
+		tool  string
+		input string
+		want  string
+	}{
+		{"Read", `{"file_path":"/src/main.go","limit":10}`, "/src/main.go"},
+		{"Grep", `{"pattern":"TODO","path":"pkg"}`, "TODO"},
+		{"TodoWrite", `{"todos":[{},{}]}`, "2 todos"},
+		{"Bash", `{"command":"echo a\necho b"}`, "echo a"},
+		{"mcp__db__query", `{"sql":"select 1"}`, ""},
+	}
+
+	for _, tt := range tests {
+		if got := render.ToolSummary(tt.tool, claudeagent.JSONValue(tt.input)); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.tool, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// decodeResult decodes a result message line.
+func decodeResult(t *testing.T, line string) *claudeagent.SDKResultMessage {
+	t.Helper()
+
+	msg, err := claudeagent.DecodeMessage([]byte(line))
+	if err != nil {
+		t.Fatalf("DecodeMessage failed: %v", err)
+	}
+	result, ok := msg.(*claudeagent.SDKResultMessage)
+	if !ok {
+		t.Fatalf("expected a result message, got %T", msg)
+	}
+
+	return result
+}
+
+func TestResultStructuredOutput(t *testing.T) {
+	result := decodeResult(t, `{"type":"result","subtype":"success","uuid":"00000000-0000-0000-0000-000000000009","session_id":"s","num_turns":1,"structured_output":{"title":"Fix login","priority":2}}`)
+
+	data, ok := result.StructuredOutputJSON()
+	if !ok || string(data) != `{"priority":2,"title":"Fix login"}` {
+		t.Errorf("unexpected structured output %s (%v)", data, ok)
+	}
+
+	var ticket struct {
+		Title    string `json:"title"`
+		Priority int    `json:"priority"`
+	}
+	if err := result.DecodeStructured(&ticket); err != nil {
+		t.Fatalf("DecodeStructured failed: %v", err)
+	}
+	if ticket.Title != "Fix login" || ticket.Priority != 2 {
+		t.Errorf("unexpected decoded output %+v", ticket)
+	}
+
+	var wrong []string
+	if err := result.DecodeStructured(&wrong); !clauderrs.IsProtocolError(err) {
+		t.Errorf("expected ProtocolError for a mismatched type, got %v", err)
+	}
+
+	plain := decodeResult(t, fakeResultLine)
+	if _, ok := plain.StructuredOutputJSON(); ok {
+		t.Error("expected no structured output without OutputFormat")
+	}
+	if err := plain.DecodeStructured(&ticket); !clauderrs.IsProtocolError(err) {
+		t.Errorf("expected ProtocolError without structured output, got %v", err)
+	}
+}
+
+func TestResultErrorDetails(t *testing.T) {
+	if details := decodeResult(t, fakeResultLine).ErrorDetails(); details != nil {
+		t.Errorf("expected no details for a success, got %v", details)
+	}
+
+	maxTurns := decodeResult(t, `{"type":"result","subtype":"error_max_turns","is_error":true,"num_turns":7}`)
+	if details, ok := maxTurns.ErrorDetails().(claudeagent.MaxTurnsExceeded); !ok || details.Turns != 7 {
+		t.Errorf("expected MaxTurnsExceeded after 7 turns, got %#v", maxTurns.ErrorDetails())
+	}
+
+	budget := decodeResult(t, `{"type":"result","subtype":"error_max_budget_usd","is_error":true,"total_cost_usd":1.25}`)
+	if details, ok := budget.ErrorDetails().(claudeagent.BudgetExceeded); !ok || details.CostUSD != 1.25 {
+		t.Errorf("expected BudgetExceeded at $1.25, got %#v", budget.ErrorDetails())
+	}
+
+	execution := decodeResult(t, `{"type":"result","subtype":"error_during_execution","is_error":true,"errors":["tool crashed","api overloaded"]}`)
+	details := execution.ErrorDetails()
+	if _, ok := details.(claudeagent.ExecutionFailed); !ok || !strings.Contains(details.Error(), "tool crashed; api overloaded") {
+		t.Errorf("expected ExecutionFailed with the CLI's errors, got %#v", details)
+	}
+
+	apiError := decodeResult(t, `{"type":"result","subtype":"success","is_error":true,"result":"Invalid API key"}`)
+	if details, ok := apiError.ErrorDetails().(claudeagent.ExecutionFailed); !ok || len(details.Errors) != 1 || details.Errors[0] != "Invalid API key" {
+		t.Errorf("expected the error-flagged success as ExecutionFailed, got %#v", apiError.ErrorDetails())
+	}
+
+	retries := decodeResult(t, `{"type":"result","subtype":"error_max_structured_output_retries","is_error":true}`)
+	if _, ok := retries.ErrorDetails().(claudeagent.StructuredOutputRetriesExceeded); !ok {
+		t.Errorf("expected StructuredOutputRetriesExceeded, got %#v", retries.ErrorDetails())
+	}
+
+	future := decodeResult(t, `{"type":"result","subtype":"error_new_thing","is_error":true}`)
+	var unknown claudeagent.UnknownResultError
+	if !errors.As(future.ErrorDetails(), &unknown) || unknown.Subtype != "error_new_thing" {
+		t.Errorf("expected UnknownResultError, got %#v", future.ErrorDetails())
+	}
+}
+
+func TestOutputFormatPassedToCLI(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stdout.jsonl"), []byte(fakeInitLine+"\n"+fakeResultLine+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "claude")
+	body := "#!/bin/sh\ncd '" + dir + "'\nprintf '%s\\n' \"$@\" >args.txt\ncat stdout.jsonl\ncat >/dev/null\n"
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		OutputFormat: &claudeagent.OutputFormat{
+			BaseOutputFormat: claudeagent.BaseOutputFormat{Type: "json_schema"},
+			Schema:           map[string]any{"type": "object"},
+		},
+	})
+
+	if args := readFakeFile(t, dir, "args.txt"); !strings.Contains(args, "--json-schema\n"+`{"type":"object"}`) {
+		t.Errorf("expected the schema in --json-schema, got %s", args)
+	}
+}
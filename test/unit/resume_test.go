@@ -0,0 +1,164 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// writeResumeTranscript writes a session that used Bash and the calc MCP
+// server and ended awaiting a Bash result.
+func writeResumeTranscript(t *testing.T, configDir, cwd string) {
+	t.Helper()
+
+	writeTranscript(t, configDir, cwd, "s1",
+		`{"type":"user","uuid":"u1","sessionId":"s1","message":{"role":"user","content":"add then list"}}`,
+		`{"type":"assistant","uuid":"a1","sessionId":"s1","message":{"role":"assistant","model":"claude-sonnet-4-5-20250929","content":[{"type":"tool_use","id":"t1","name":"mcp__calc__add","input":{}}]}}`,
+		`{"type":"user","uuid":"u2","sessionId":"s1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"3"}]}}`,
+		`{"type":"assistant","uuid":"a2","sessionId":"s1","message":{"role":"assistant","model":"claude-sonnet-4-5-20250929","content":[{"type":"tool_use","id":"t2","name":"Bash","input":{"command":"ls"}}]}}`,
+	)
+}
+
+func TestCheckResumeFindsConflicts(t *testing.T) {
+	configDir := t.TempDir()
+	cwd := t.TempDir()
+	writeResumeTranscript(t, configDir, cwd)
+	env := map[string]string{"CLAUDE_CONFIG_DIR": configDir}
+
+	conflicts, err := claudeagent.CheckResume(&claudeagent.Options{
+		Cwd:    cwd,
+		Env:    env,
+		Resume: "s1",
+		Model:  "sonnet",
+		McpServers: map[string]claudeagent.McpServerConfig{
+			"calc": claudeagent.McpStdioServerConfig{Command: "calc"},
+		},
+	})
+	if err != nil || len(conflicts) != 0 {
+		t.Errorf("expected no conflicts with compatible options, got %v, %v", conflicts, err)
+	}
+
+	conflicts, err = claudeagent.CheckResume(&claudeagent.Options{
+		Cwd:             cwd,
+		Env:             env,
+		Resume:          "s1",
+		Model:           "claude-opus-4-1",
+		DisallowedTools: []string{"Bash", "Read"},
+		StrictMcpConfig: true,
+	})
+	if err != nil {
+		t.Fatalf("CheckResume failed: %v", err)
+	}
+
+	want := []struct {
+		kind     claudeagent.ResumeConflictKind
+		previous string
+		blocking bool
+	}{
+		{claudeagent.ResumeConflictModel, "claude-sonnet-4-5-20250929", false},
+		{claudeagent.ResumeConflictToolRemoved, "mcp__calc__add", false},
+		{claudeagent.ResumeConflictToolRemoved, "Bash", false},
+		{claudeagent.ResumeConflictPendingToolUse, "Bash", true},
+	}
+	if len(conflicts) != len(want) {
+		t.Fatalf("expected %d conflicts, got %v", len(want), conflicts)
+	}
+	for i, w := range want {
+		c := conflicts[i]
+		if c.Kind != w.kind || c.Previous != w.previous || c.Blocking != w.blocking {
+			t.Errorf("conflict %d = %+v, want %s of %s (blocking %v)", i, c, w.kind, w.previous, w.blocking)
+		}
+	}
+
+	// Resuming before the Bash call leaves nothing pending
+	conflicts, err = claudeagent.CheckResume(&claudeagent.Options{
+		Cwd:             cwd,
+		Env:             env,
+		Resume:          "s1",
+		ResumeSessionAt: "u2",
+		DisallowedTools: []string{"Bash"},
+	})
+	if err != nil || len(conflicts) != 0 {
+		t.Errorf("expected no conflicts when resuming at u2, got %v, %v", conflicts, err)
+	}
+}
+
+func TestResumeConflictFailsQuery(t *testing.T) {
+	configDir := t.TempDir()
+	cwd := t.TempDir()
+	writeResumeTranscript(t, configDir, cwd)
+	env := map[string]string{"CLAUDE_CONFIG_DIR": configDir}
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		Cwd:                        cwd,
+		Env:                        env,
+		Resume:                     "s1",
+		DisallowedTools:            []string{"Bash"},
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeResultLine),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	err = client.Query(context.Background(), "continue")
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeResumeConflict {
+		t.Fatalf("expected ErrCodeResumeConflict, got %v", err)
+	}
+	conflicts := claudeagent.ResumeConflicts(err)
+	if len(conflicts) != 2 || conflicts[1].Kind != claudeagent.ResumeConflictPendingToolUse {
+		t.Errorf("expected the removed and pending Bash conflicts, got %v", conflicts)
+	}
+}
+
+func TestOnResumeConflictDecides(t *testing.T) {
+	configDir := t.TempDir()
+	cwd := t.TempDir()
+	writeResumeTranscript(t, configDir, cwd)
+	env := map[string]string{"CLAUDE_CONFIG_DIR": configDir}
+
+	var seen []claudeagent.ResumeConflict
+	var warnings []string
+	_, messages := runFakeSession(t, &claudeagent.Options{
+		Cwd:             cwd,
+		Env:             env,
+		Resume:          "s1",
+		DisallowedTools: []string{"Bash"},
+		OnResumeConflict: func(conflicts []claudeagent.ResumeConflict) error {
+			seen = conflicts
+
+			return nil
+		},
+		Stderr: func(line string) { warnings = append(warnings, line) },
+	}, fakeInitLine, fakeResultLine)
+	if len(seen) != 2 || len(messages) == 0 {
+		t.Errorf("expected the session to resume after the callback saw 2 conflicts, got %v and %d messages", seen, len(messages))
+	}
+	for _, line := range warnings {
+		if strings.HasPrefix(line, "resume:") {
+			t.Errorf("expected no warnings when OnResumeConflict is set, got %q", line)
+		}
+	}
+
+	errFork := errors.New("fork instead")
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		Cwd:                        cwd,
+		Env:                        env,
+		Resume:                     "s1",
+		Model:                      "claude-opus-4-1",
+		OnResumeConflict:           func([]claudeagent.ResumeConflict) error { return errFork },
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeResultLine),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	if err := client.Query(context.Background(), "continue"); !errors.Is(err, errFork) {
+		t.Errorf("expected the callback's error, got %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// TestReviewClientRejectsWritableModes verifies unsafe options are refused.
+func TestReviewClientRejectsWritableModes(t *testing.T) {
+	_, err := claudeagent.ReviewClient(&claudeagent.Options{
+		PermissionMode: claudeagent.PermissionModeBypassPermissions,
+	})
+	if !clauderrs.IsValidationError(err) {
+		t.Errorf("expected ValidationError, got %v", err)
+	}
+}
+
+// TestReviewClientDeniesWrites verifies write tools are denied in the SDK
+// and permission escalation is refused.
+func TestReviewClientDeniesWrites(t *testing.T) {
+	script := newHookFakeCLI(t,
+		fakeInitLine,
+		fakeCanUseToolLine("cli_write", "Write", "/tmp/out"),
+		fakeCanUseToolLine("cli_read", "Read", "/tmp/in"),
+		fakeResultLine,
+	)
+
+	client, err := claudeagent.ReviewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+	})
+	if err != nil {
+		t.Fatalf("ReviewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "review this"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for range client.ReceiveResponse(ctx) {
+	}
+
+	for _, line := range fakeCLIStdin(t, script, "control_response", 2) {
+		switch {
+		case strings.Contains(line, `"request_id":"cli_write"`):
+			if !strings.Contains(line, `"allow":false`) {
+				t.Errorf("expected Write to be denied: %s", line)
+			}
+		case strings.Contains(line, `"request_id":"cli_read"`):
+			if !strings.Contains(line, `"allow":true`) {
+				t.Errorf("expected Read to be allowed: %s", line)
+			}
+		}
+	}
+
+	err = client.SetPermissionMode(ctx, claudeagent.PermissionModeAcceptEdits)
+	if !clauderrs.IsPermissionError(err) {
+		t.Errorf("expected PermissionError for escalation, got %v", err)
+	}
+
+	err = client.UpdatePermissions(ctx, []claudeagent.PermissionUpdate{
+		claudeagent.AddRulesUpdate{
+			Rules:       []claudeagent.PermissionRuleValue{{ToolName: "Bash"}},
+			Behavior:    claudeagent.PermissionBehaviorAllow,
+			Destination: claudeagent.PermissionDestinationSession,
+		},
+	})
+	if !clauderrs.IsPermissionError(err) {
+		t.Errorf("expected PermissionError for allow rule, got %v", err)
+	}
+}
+
+// TestReviewClientDeniesToolsSettingsAllow verifies tools are denied in a
+// PreToolUse hook, which the CLI runs even for tools its settings allow,
+// and that the CLI is told which tools and mode to use.
+func TestReviewClientDeniesToolsSettingsAllow(t *testing.T) {
+	script := newHookFakeCLI(t,
+		fakeInitLine,
+		fakePreToolUseLine("hook_web", "hook_0", "WebFetch", `{"url":"https://example.com"}`),
+		fakePreToolUseLine("hook_mcp", "hook_0", "mcp__docs__write", `{}`),
+		fakePreToolUseLine("hook_read", "hook_0", "Read", `{"file_path":"/tmp/in"}`),
+		fakeResultLine,
+	)
+	// Record the CLI's arguments
+	wrapper := filepath.Join(filepath.Dir(script), "claude-args")
+	body := "#!/bin/sh\nprintf '%s\\n' \"$@\" >'" + filepath.Join(filepath.Dir(script), "args.txt") + "'\nexec '" + script + "' \"$@\"\n"
+	if err := os.WriteFile(wrapper, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	runReviewSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: wrapper,
+		McpServers: map[string]claudeagent.McpServerConfig{
+			"docs": claudeagent.McpHTTPServerConfig{Type: "http", URL: "https://example.com/mcp"},
+		},
+	})
+
+	for _, line := range fakeCLIStdin(t, script, `"permissionDecision"`, 2) {
+		switch {
+		case strings.Contains(line, `"request_id":"hook_web"`), strings.Contains(line, `"request_id":"hook_mcp"`):
+			if !strings.Contains(line, `"permissionDecision":"deny"`) {
+				t.Errorf("expected the tool to be denied: %s", line)
+			}
+		case strings.Contains(line, `"request_id":"hook_read"`):
+			if strings.Contains(line, `"permissionDecision"`) {
+				t.Errorf("expected Read to be left alone: %s", line)
+			}
+		}
+	}
+
+	args := readFakeFile(t, filepath.Dir(script), "args.txt")
+	for _, want := range []string{"--permission-mode\ndefault", "--disallowed-tools\nWebFetch", "--disallowed-tools\nTask", "--disallowed-tools\nmcp__docs"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("expected %q in the CLI's arguments, got:\n%s", want, args)
+		}
+	}
+}
+
+// TestReviewClientFiltersSuggestions verifies permission suggestions that
+// would widen a review client's permissions are dropped when accepted.
+func TestReviewClientFiltersSuggestions(t *testing.T) {
+	request := `{"type":"control_request","request_id":"cli_read","request":{"subtype":"can_use_tool","tool_name":"Read",` +
+		`"input":{"file_path":"/tmp/in"},"tool_use_id":"toolu_1","permission_suggestions":[` +
+		`{"type":"setMode","mode":"acceptEdits","destination":"session"},` +
+		`{"type":"addRules","rules":[{"toolName":"Bash"}],"behavior":"allow","destination":"session"},` +
+		`{"type":"removeRules","rules":[{"toolName":"Write"}],"behavior":"deny","destination":"session"},` +
+		`{"type":"addDirectories","directories":["/"],"destination":"session"},` +
+		`{"type":"addRules","rules":[{"toolName":"Grep"}],"behavior":"allow","destination":"session"}]}}`
+	script := newHookFakeCLI(t, fakeInitLine, request, fakeResultLine)
+
+	runReviewSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		CanUseTool: func(
+			context.Context,
+			string,
+			map[string]claudeagent.JSONValue,
+			[]claudeagent.PermissionUpdate,
+			string,
+			*string,
+			*string,
+			*string,
+		) (claudeagent.PermissionResult, error) {
+			return &claudeagent.PermissionAllow{
+				Behavior:         claudeagent.PermissionBehaviorAllow,
+				ApplySuggestions: true,
+			}, nil
+		},
+	})
+
+	var response string
+	for _, line := range fakeCLIStdin(t, script, `"request_id":"cli_read"`, 1) {
+		if strings.Contains(line, `"request_id":"cli_read"`) {
+			response = line
+		}
+	}
+	if !strings.Contains(response, `"toolName":"Grep"`) {
+		t.Errorf("expected the Grep rule to be kept: %s", response)
+	}
+	for _, dropped := range []string{"setMode", "Bash", "removeRules", "addDirectories"} {
+		if strings.Contains(response, dropped) {
+			t.Errorf("expected the %s suggestion to be dropped: %s", dropped, response)
+		}
+	}
+}
+
+// runReviewSession runs a review client session with opts until its
+// result.
+func runReviewSession(t *testing.T, opts *claudeagent.Options) {
+	t.Helper()
+
+	client, err := claudeagent.ReviewClient(opts)
+	if err != nil {
+		t.Fatalf("ReviewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "review this"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for range client.ReceiveResponse(ctx) {
+	}
+}
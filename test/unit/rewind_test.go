@@ -0,0 +1,101 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// newRewindFakeCLI writes a fake CLI that records whether file
+// checkpointing is enabled and answers rewind_code requests, failing
+// those for unknown messages and any other control request, as the CLI
+// does for subtypes it doesn't handle.
+func newRewindFakeCLI(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stdout.jsonl"), []byte(fakeInitLine+"\n"+fakeResultLine+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write fake CLI output: %v", err)
+	}
+
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+printf '%s\n' "$CLAUDE_CODE_ENABLE_SDK_FILE_CHECKPOINTING" >env.txt
+cat stdout.jsonl
+while IFS= read -r line; do
+  printf '%s\n' "$line" >>stdin.jsonl
+  id=$(printf '%s\n' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+  case "$line" in
+  *'"user_message_id":"unknown"'*)
+    printf '{"type":"control_response","response":{"subtype":"error","request_id":"%s","error":"no checkpoint for message"}}\n' "$id";;
+  *'"request":{"subtype":"rewind_code"'*)
+    printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{}}}\n' "$id";;
+  *'"type":"control_request"'*)
+    printf '{"type":"control_response","response":{"subtype":"error","request_id":"%s","error":"Unsupported control request subtype"}}\n' "$id";;
+  esac
+done
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatalf("failed to write fake CLI script: %v", err)
+	}
+
+	return script
+}
+
+func TestClientRewindFiles(t *testing.T) {
+	script := newRewindFakeCLI(t)
+	client, _ := collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		EnableFileCheckpointing:    true,
+	})
+
+	if env := readFakeFile(t, filepath.Dir(script), "env.txt"); strings.TrimSpace(env) != "true" {
+		t.Errorf("expected CLAUDE_CODE_ENABLE_SDK_FILE_CHECKPOINTING=true, got %q", env)
+	}
+
+	checkpoints := client.FileCheckpoints()
+	if len(checkpoints) != 1 || checkpoints[0].Prompt != "hello" || checkpoints[0].ID == "" {
+		t.Fatalf("expected a checkpoint for the prompt, got %+v", checkpoints)
+	}
+	// The checkpoint is named by the UUID of the user message sent
+	lines := fakeCLIStdin(t, script, `"type":"user"`, 1)
+	if !strings.Contains(strings.Join(lines, "\n"), `"uuid":"`+checkpoints[0].ID+`"`) {
+		t.Errorf("expected the user message to carry checkpoint %s", checkpoints[0].ID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.RewindFiles(ctx, checkpoints[0].ID); err != nil {
+		t.Fatalf("RewindFiles failed: %v", err)
+	}
+	lines = fakeCLIStdin(t, script, `"rewind_code"`, 1)
+	if !strings.Contains(strings.Join(lines, "\n"), `"user_message_id":"`+checkpoints[0].ID+`"`) {
+		t.Errorf("expected a rewind_code request for %s, got %v", checkpoints[0].ID, lines)
+	}
+
+	if err := client.RewindFiles(ctx, "unknown"); err == nil || !strings.Contains(err.Error(), "no checkpoint") {
+		t.Errorf("expected the CLI's refusal, got %v", err)
+	}
+	if err := client.RewindFiles(ctx, ""); !clauderrs.IsValidationError(err) {
+		t.Errorf("expected a validation error for an empty checkpoint ID, got %v", err)
+	}
+}
+
+func TestClientRewindFilesRequiresCheckpointing(t *testing.T) {
+	client, _ := collectFakeSession(t, &claudeagent.Options{PathToClaudeCodeExecutable: newRewindFakeCLI(t)})
+
+	if checkpoints := client.FileCheckpoints(); len(checkpoints) != 0 {
+		t.Errorf("expected no checkpoints without EnableFileCheckpointing, got %+v", checkpoints)
+	}
+	err := client.RewindFiles(context.Background(), "00000000-0000-0000-0000-000000000001")
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeInvalidConfig {
+		t.Errorf("expected ErrCodeInvalidConfig, got %v", err)
+	}
+}
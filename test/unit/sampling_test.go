@@ -0,0 +1,66 @@
+package unit
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+func TestSamplingValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *claudeagent.OptionsBuilder
+		field   string
+	}{
+		{name: "temperature over 1", builder: claudeagent.NewOptions().WithTemperature(1.5), field: "Temperature"},
+		{name: "negative temperature", builder: claudeagent.NewOptions().WithTemperature(-0.1), field: "Temperature"},
+		{name: "zero top p", builder: claudeagent.NewOptions().WithTopP(0), field: "TopP"},
+		{name: "thinking with low temperature", builder: claudeagent.NewOptions().WithMaxThinkingTokens(1_024).WithTemperature(0), field: "Temperature"},
+		{name: "thinking with low top p", builder: claudeagent.NewOptions().WithMaxThinkingTokens(1_024).WithTopP(0.5), field: "TopP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			assertValidationField(t, err, tt.field)
+		})
+	}
+
+	opts, err := claudeagent.NewOptions().WithTemperature(0).WithTopP(0.9).Build()
+	if err != nil {
+		t.Fatalf("expected sampling without thinking to be accepted, got %v", err)
+	}
+	warnings := opts.SamplingWarnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "doesn't support Temperature, TopP") {
+		t.Errorf("expected the parameters reported unsupported, got %v", warnings)
+	}
+
+	opts.Model = "claude-sonnet-4-5-20250929"
+	opts.Seed = new(int64)
+	if warnings := opts.SamplingWarnings(); len(warnings) != 3 {
+		t.Errorf("expected unsupported, seed and Temperature with TopP warnings, got %v", warnings)
+	}
+
+	if warnings := (&claudeagent.Options{}).SamplingWarnings(); len(warnings) != 0 {
+		t.Errorf("expected no warnings without sampling parameters, got %v", warnings)
+	}
+}
+
+func TestSamplingWarningsReportedToStderr(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		stderr []string
+	)
+	temperature := 0.25
+	runFakeSession(t, &claudeagent.Options{
+		Temperature: &temperature,
+		Stderr:      func(line string) { mu.Lock(); stderr = append(stderr, line); mu.Unlock() },
+	}, fakeInitLine, fakeResultLine)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(stderr) == 0 || stderr[0] != "sampling: the CLI doesn't support Temperature, so the model keeps its default sampling" {
+		t.Errorf("expected the unsupported Temperature reported on Stderr, got %q", stderr)
+	}
+}
@@ -0,0 +1,280 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// fakeMcpMessageLine returns an mcp_message control request carrying a
+// JSON-RPC message for server.
+func fakeMcpMessageLine(requestID, server, message string) string {
+	return `{"type":"control_request","request_id":"` + requestID +
+		`","request":{"subtype":"mcp_message","server_name":"` + server + `","message":` + message + `}}`
+}
+
+// encodePNG returns a small encoded PNG.
+func encodePNG(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestMcpToolResultSerialization(t *testing.T) {
+	pngData := encodePNG(t)
+
+	data, err := json.Marshal(claudeagent.ImageResult(pngData))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"content":[{"data":"` + base64.StdEncoding.EncodeToString(pngData) + `","mimeType":"image/png","type":"image"}]}`
+	if string(data) != want {
+		t.Errorf("expected MCP image content\n%s\ngot\n%s", want, data)
+	}
+
+	data, err = json.Marshal(claudeagent.ResourceResult("file:///report.csv", "text/csv", []byte("a,b\n")))
+	if err != nil || !strings.Contains(string(data), `"resource":{"uri":"file:///report.csv","mimeType":"text/csv","text":"a,b\n"}`) {
+		t.Errorf("expected text resource, got %s (%v)", data, err)
+	}
+	data, err = json.Marshal(claudeagent.ResourceResult("file:///chart.png", "image/png", []byte{1, 2}))
+	if err != nil || !strings.Contains(string(data), `"blob":"AQI="`) {
+		t.Errorf("expected blob resource, got %s (%v)", data, err)
+	}
+
+	_, err = json.Marshal(claudeagent.McpToolResult{Content: []claudeagent.ContentBlock{
+		claudeagent.ToolUseContentBlock{Type: "tool_use", ID: "toolu_1", Name: "Read"},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "unsupported content block") {
+		t.Errorf("expected unsupported block to fail, got %v", err)
+	}
+}
+
+func TestSdkMcpServerHandlesToolCalls(t *testing.T) {
+	pngData := encodePNG(t)
+	chart := claudeagent.Tool("chart", "Render a chart", map[string]any{"type": "object"},
+		func(_ context.Context, args map[string]any) (*claudeagent.McpToolResult, error) {
+			if args["series"] != "latency" {
+				return claudeagent.TextResult("unknown series"), nil
+			}
+
+			return claudeagent.ImageResult(pngData), nil
+		})
+	broken := claudeagent.Tool("broken", "Returns an invalid block", map[string]any{"type": "object"},
+		func(context.Context, map[string]any) (*claudeagent.McpToolResult, error) {
+			return &claudeagent.McpToolResult{Content: []claudeagent.ContentBlock{claudeagent.ThinkingBlock{}}}, nil
+		})
+
+	script := newFakeCLI(t,
+		fakeInitLine,
+		fakeMcpMessageLine("mcp_1", "charts", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`),
+		fakeMcpMessageLine("mcp_2", "charts", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`),
+		fakeMcpMessageLine("mcp_3", "charts", `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"chart","arguments":{"series":"latency"}}}`),
+		fakeMcpMessageLine("mcp_4", "charts", `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"broken","arguments":{}}}`),
+		fakeMcpMessageLine("mcp_5", "missing", `{"jsonrpc":"2.0","id":5,"method":"tools/list"}`),
+	)
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		McpServers: map[string]claudeagent.McpServerConfig{
+			"charts": claudeagent.CreateSdkMcpServer("charts", "1.0.0", []claudeagent.McpTool{chart, broken}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "plot latency"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	responses := make(map[string]string)
+	for _, line := range fakeCLIStdin(t, script, "mcp_response", 5) {
+		var frame struct {
+			Response struct {
+				RequestID string          `json:"request_id"`
+				Response  json.RawMessage `json:"response"`
+			} `json:"response"`
+		}
+		if json.Unmarshal([]byte(line), &frame) == nil && frame.Response.RequestID != "" {
+			responses[frame.Response.RequestID] = string(frame.Response.Response)
+		}
+	}
+
+	checks := map[string]string{
+		"mcp_1": `"serverInfo":{"name":"charts","version":"1.0.0"}`,
+		"mcp_2": `"name":"broken"`,
+		"mcp_3": `"result":{"content":[{"data":"` + base64.StdEncoding.EncodeToString(pngData) + `","mimeType":"image/png","type":"image"}]}`,
+		"mcp_4": `"isError":true`,
+		"mcp_5": `"error":{"code":-32601`,
+	}
+	for id, want := range checks {
+		if !strings.Contains(responses[id], want) {
+			t.Errorf("%s: expected response containing %s, got %s", id, want, responses[id])
+		}
+	}
+}
+
+func TestSdkMcpServersPassedToCLI(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stdout.jsonl"), []byte(fakeInitLine+"\n"+fakeResultLine+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "claude")
+	body := "#!/bin/sh\ncd '" + dir + "'\nprintf '%s\\n' \"$@\" >args.txt\ncat stdout.jsonl\ncat >/dev/null\n"
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		StrictMcpConfig:            true,
+		McpServers: map[string]claudeagent.McpServerConfig{
+			"charts": claudeagent.CreateSdkMcpServer("charts", "1.0.0", nil),
+			"fs":     claudeagent.McpStdioServerConfig{Command: "mcp-fs"},
+		},
+	})
+
+	args := readFakeFile(t, dir, "args.txt")
+	if !strings.Contains(args, "--mcp-config\n"+
+		`{"mcpServers":{"charts":{"type":"sdk","name":"charts"},"fs":{"command":"mcp-fs"}}}`) {
+		t.Errorf("expected MCP servers in --mcp-config, got %s", args)
+	}
+	if !strings.Contains(args, "--strict-mcp-config") {
+		t.Errorf("expected --strict-mcp-config, got %s", args)
+	}
+}
+
+func TestSdkMcpServerSharedAcrossClients(t *testing.T) {
+	var mu sync.Mutex
+	sessions := make(map[string]string)
+	echo := claudeagent.Tool("echo", "Echo the caller", map[string]any{"type": "object"},
+		func(ctx context.Context, args map[string]any) (*claudeagent.McpToolResult, error) {
+			caller, _ := args["caller"].(string)
+			mu.Lock()
+			sessions[claudeagent.McpSessionID(ctx)] += caller
+			mu.Unlock()
+
+			return claudeagent.TextResult("hello " + caller), nil
+		})
+	shared := claudeagent.CreateSdkMcpServer("shared", "1.0.0", []claudeagent.McpTool{echo})
+
+	callers := []string{"a", "b", "c", "d"}
+	responses := make([][]string, len(callers))
+	var wg sync.WaitGroup
+	for i, caller := range callers {
+		script := newFakeCLI(t,
+			fakeInitLine,
+			fakeMcpMessageLine("mcp_1", "shared", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"caller":"`+caller+`"}}}`),
+			fakeMcpMessageLine("mcp_2", "shared", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"caller":"`+caller+`"}}}`),
+		)
+		client, err := claudeagent.NewClient(&claudeagent.Options{
+			PathToClaudeCodeExecutable: script,
+			McpServers:                 map[string]claudeagent.McpServerConfig{"shared": shared},
+		})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+			defer cancel()
+			if err := client.Query(ctx, "hello"); err != nil {
+				t.Errorf("Query failed: %v", err)
+
+				return
+			}
+			responses[i] = fakeCLIStdin(t, script, "mcp_response", 2)
+		}()
+	}
+	wg.Wait()
+
+	for i, caller := range callers {
+		for _, line := range responses[i] {
+			if strings.Contains(line, "mcp_response") && !strings.Contains(line, `"text":"hello `+caller+`"`) {
+				t.Errorf("client %s received another session's result: %s", caller, line)
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sessions) != len(callers) {
+		t.Fatalf("expected calls from %d distinct sessions, got %v", len(callers), sessions)
+	}
+	for id, calls := range sessions {
+		if id == "" || len(calls) != 2 || calls[0] != calls[1] {
+			t.Errorf("expected both calls of a session to share its ID, got %q for %q", calls, id)
+		}
+	}
+}
+
+func TestSdkMcpToolPanicReturnedAsError(t *testing.T) {
+	crashing := claudeagent.Tool("crash", "Panics", map[string]any{"type": "object"},
+		func(context.Context, map[string]any) (*claudeagent.McpToolResult, error) {
+			var invoices map[string]int
+			invoices["INV-1"]++
+
+			return nil, nil
+		})
+
+	script := newFakeCLI(t,
+		fakeInitLine,
+		fakeMcpMessageLine("mcp_1", "billing", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"crash","arguments":{}}}`),
+		fakeTextLine("still here"),
+		fakeResultLine,
+	)
+
+	var mu sync.Mutex
+	var logged []string
+	_, messages := collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		McpServers: map[string]claudeagent.McpServerConfig{
+			"billing": claudeagent.CreateSdkMcpServer("billing", "1.0.0", []claudeagent.McpTool{crashing}),
+		},
+		Stderr: func(line string) {
+			mu.Lock()
+			defer mu.Unlock()
+			logged = append(logged, line)
+		},
+	})
+
+	response := strings.Join(fakeCLIStdin(t, script, "mcp_response", 1), "\n")
+	if !strings.Contains(response, `"isError":true`) || !strings.Contains(response, "tool crash panicked: assignment to entry in nil map") {
+		t.Errorf("expected the panic as an error result, got %s", response)
+	}
+	if strings.Contains(response, "goroutine") {
+		t.Errorf("expected the stack kept from the model, got %s", response)
+	}
+	if _, ok := messages[len(messages)-1].(*claudeagent.SDKResultMessage); !ok {
+		t.Errorf("expected the session to continue to its result, got %d messages", len(messages))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if log := strings.Join(logged, "\n"); !strings.Contains(log, "SDK MCP tool 'crash' panicked") || !strings.Contains(log, "goroutine") {
+		t.Errorf("expected the panic logged with its stack, got %q", log)
+	}
+}
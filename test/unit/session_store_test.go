@@ -0,0 +1,61 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+func TestSessionStores(t *testing.T) {
+	files, err := claudeagent.NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSessionStore failed: %v", err)
+	}
+
+	for name, store := range map[string]claudeagent.SessionStore{
+		"memory": claudeagent.NewMemorySessionStore(),
+		"file":   files,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			// Keys that aren't safe file names are kept apart
+			for _, key := range []string{"a/b", "a/c", "..", "", "b"} {
+				if err := store.Put(ctx, key, json.RawMessage(`"`+key+`"`)); err != nil {
+					t.Fatalf("Put(%q) failed: %v", key, err)
+				}
+			}
+			_ = store.Put(ctx, "a/b", json.RawMessage(`"replaced"`))
+			_ = store.Delete(ctx, "a/c")
+			_ = store.Delete(ctx, "missing")
+
+			if keys, _ := store.Keys(ctx, "a/"); !slices.Equal(keys, []string{"a/b"}) {
+				t.Errorf("expected keys [a/b], got %v", keys)
+			}
+			if keys, _ := store.Keys(ctx, ""); !slices.Equal(keys, []string{"", "..", "a/b", "b"}) {
+				t.Errorf("expected every key, got %v", keys)
+			}
+			if value, ok, _ := store.Get(ctx, "a/b"); !ok || string(value) != `"replaced"` {
+				t.Errorf("expected the replaced value, got %s (%v)", value, ok)
+			}
+			if value, ok, _ := store.Get(ctx, ".."); !ok || string(value) != `".."` {
+				t.Errorf("expected the value of .., got %s (%v)", value, ok)
+			}
+			if _, ok, err := store.Get(ctx, "a/c"); ok || err != nil {
+				t.Errorf("expected a deleted key to be missing, got %v (%v)", ok, err)
+			}
+
+			_ = store.Append(ctx, "log", json.RawMessage("{\n\"n\": 1}"))
+			_ = store.Append(ctx, "log", json.RawMessage(`{"n":2}`))
+			records, err := store.Records(ctx, "log")
+			if err != nil || len(records) != 2 || string(records[1]) != `{"n":2}` {
+				t.Errorf("expected 2 records in order, got %s (%v)", records, err)
+			}
+			if records, err := store.Records(ctx, "other"); err != nil || len(records) != 0 {
+				t.Errorf("expected an unknown log to be empty, got %s (%v)", records, err)
+			}
+		})
+	}
+}
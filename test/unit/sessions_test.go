@@ -0,0 +1,122 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// writeTranscript stores transcript lines for session id of a project in
+// cwd under configDir, as the CLI does, and returns the file path.
+func writeTranscript(t *testing.T, configDir, cwd, id string, lines ...string) string {
+	t.Helper()
+
+	dir := filepath.Join(configDir, "projects", regexp.MustCompile(`[^a-zA-Z0-9]`).ReplaceAllString(cwd, "-"))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, id+".jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestListSessions(t *testing.T) {
+	configDir := t.TempDir()
+	writeTranscript(t, configDir, "/work/api", "s1",
+		`{"type":"user","sessionId":"s1","timestamp":"2026-01-01T10:00:00Z","cwd":"/work/api","gitBranch":"main","message":{"role":"user","content":"hi"}}`,
+		`{"type":"assistant","sessionId":"s1","timestamp":"2026-01-01T10:01:00Z","costUSD":0.25,"message":{"role":"assistant","usage":{"input_tokens":100,"output_tokens":20}}}`,
+		`{"type":"summary","summary":"Fix login bug","leafUuid":"x"}`,
+		`{"type":"custom-title","customTitle":"login fix","sessionId":"s1"}`,
+	)
+	writeTranscript(t, configDir, "/work/web", "s2",
+		`{"type":"user","sessionId":"s2","timestamp":"2026-01-02T09:00:00Z","cwd":"/work/web","message":{"role":"user","content":"hi"}}`,
+	)
+	writeTranscript(t, configDir, "/work/web", "agent-123",
+		`{"type":"user","sessionId":"s2","timestamp":"2026-01-02T09:00:00Z","isSidechain":true}`,
+	)
+
+	ctx := context.Background()
+	sessions, err := claudeagent.ListSessions(ctx, claudeagent.SessionFilter{ConfigDir: configDir})
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "s2" || sessions[1].ID != "s1" {
+		t.Fatalf("expected s2 then s1, got %+v", sessions)
+	}
+
+	s1 := sessions[1]
+	if s1.Label != "login fix" || s1.Summary != "Fix login bug" || s1.GitBranch != "main" || s1.Cwd != "/work/api" {
+		t.Errorf("unexpected session details: %+v", s1)
+	}
+	if s1.Messages != 2 || s1.InputTokens != 100 || s1.OutputTokens != 20 || s1.CostUSD != 0.25 || s1.Cost.String() != "0.25" {
+		t.Errorf("unexpected session usage: %+v", s1)
+	}
+	if !s1.CreatedAt.Equal(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)) ||
+		!s1.UpdatedAt.Equal(time.Date(2026, 1, 1, 10, 1, 0, 0, time.UTC)) {
+		t.Errorf("unexpected timestamps: %v, %v", s1.CreatedAt, s1.UpdatedAt)
+	}
+
+	filtered, err := claudeagent.ListSessions(ctx, claudeagent.SessionFilter{ConfigDir: configDir, Label: "login"})
+	if err != nil || len(filtered) != 1 || filtered[0].ID != "s1" {
+		t.Errorf("expected only the labelled session, got %+v, %v", filtered, err)
+	}
+	filtered, err = claudeagent.ListSessions(ctx, claudeagent.SessionFilter{ConfigDir: configDir, Cwd: "/work/web"})
+	if err != nil || len(filtered) != 1 || filtered[0].ID != "s2" {
+		t.Errorf("expected only the web session, got %+v, %v", filtered, err)
+	}
+	filtered, err = claudeagent.ListSessions(ctx, claudeagent.SessionFilter{ConfigDir: configDir, Limit: 1})
+	if err != nil || len(filtered) != 1 || filtered[0].ID != "s2" {
+		t.Errorf("expected the latest session, got %+v, %v", filtered, err)
+	}
+}
+
+func TestSetSessionLabel(t *testing.T) {
+	configDir := t.TempDir()
+	cwd := t.TempDir()
+	path := writeTranscript(t, configDir, cwd, "fake-session",
+		`{"type":"user","sessionId":"fake-session","timestamp":"2026-01-01T10:00:00Z","cwd":"`+cwd+`"}`,
+	)
+
+	opts := &claudeagent.Options{Cwd: cwd, Env: map[string]string{"CLAUDE_CONFIG_DIR": configDir}}
+	client, _ := runFakeSession(t, opts, fakeInitLine, fakeResultLine)
+
+	ctx := context.Background()
+	if err := client.SetSessionLabel(ctx, "nightly audit"); err != nil {
+		t.Fatalf("SetSessionLabel failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), `"customTitle":"nightly audit"`) {
+		t.Errorf("expected label entry in transcript, got %s", data)
+	}
+
+	sessions, err := claudeagent.ListSessions(ctx, claudeagent.SessionFilter{ConfigDir: configDir, Cwd: cwd})
+	if err != nil || len(sessions) != 1 || sessions[0].Label != "nightly audit" {
+		t.Errorf("expected labelled session, got %+v, %v", sessions, err)
+	}
+}
+
+func TestSetSessionLabelErrors(t *testing.T) {
+	client, err := claudeagent.NewClient(nil)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if err := client.SetSessionLabel(context.Background(), "x"); !clauderrs.IsClientError(err) {
+		t.Errorf("expected ClientError before the session starts, got %v", err)
+	}
+
+	opts := &claudeagent.Options{Cwd: t.TempDir(), Env: map[string]string{"CLAUDE_CONFIG_DIR": t.TempDir()}}
+	client, _ = runFakeSession(t, opts, fakeInitLine, fakeResultLine)
+	err = client.SetSessionLabel(context.Background(), "x")
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeSessionNotFound {
+		t.Errorf("expected session not found, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// writeSkill writes dir/name/SKILL.md with the given frontmatter.
+func writeSkill(t *testing.T, dir, name, frontmatter string) string {
+	t.Helper()
+
+	skillDir := filepath.Join(dir, name)
+	if err := os.MkdirAll(skillDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\n" + frontmatter + "\n---\n\n# " + name + "\n\nInstructions.\n"
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return skillDir
+}
+
+func TestLoadSkills(t *testing.T) {
+	dir := t.TempDir()
+	writeSkill(t, dir, "pdf", "name: pdf\ndescription: \"Extract text from PDFs\"\nallowed-tools: Read, Bash")
+	writeSkill(t, dir, "review", "name: review\ndescription: Review diffs\nallowed-tools:\n  - Read\n  - Grep")
+	if err := os.Mkdir(filepath.Join(dir, "notes"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	skills, err := claudeagent.LoadSkills(dir)
+	if err != nil {
+		t.Fatalf("LoadSkills failed: %v", err)
+	}
+	if len(skills) != 2 {
+		t.Fatalf("expected 2 skills, got %+v", skills)
+	}
+	if skills[0].Name != "pdf" || skills[0].Description != "Extract text from PDFs" ||
+		strings.Join(skills[0].AllowedTools, ",") != "Read,Bash" {
+		t.Errorf("unexpected pdf skill %+v", skills[0])
+	}
+	if strings.Join(skills[1].AllowedTools, ",") != "Read,Grep" {
+		t.Errorf("expected YAML list of allowed tools, got %v", skills[1].AllowedTools)
+	}
+
+	single, err := claudeagent.LoadSkills(filepath.Join(dir, "pdf"))
+	if err != nil || len(single) != 1 || single[0].Name != "pdf" {
+		t.Errorf("expected a single skill directory to load, got %+v (%v)", single, err)
+	}
+}
+
+func TestLoadSkillRejectsInvalidFrontmatter(t *testing.T) {
+	dir := t.TempDir()
+	bad := writeSkill(t, dir, "bad", "name: Bad_Name\ndescription: x")
+	missing := writeSkill(t, dir, "missing", "name: missing")
+
+	for _, path := range []string{bad, missing} {
+		_, err := claudeagent.LoadSkill(path)
+		if err == nil {
+			t.Errorf("%s: expected error", path)
+
+			continue
+		}
+		if !clauderrs.IsValidationError(err) {
+			t.Errorf("%s: expected validation error, got %v", path, err)
+		}
+	}
+}
+
+func TestSkillsPassedAsPluginDir(t *testing.T) {
+	skills := t.TempDir()
+	writeSkill(t, skills, "pdf", "name: pdf\ndescription: PDFs")
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stdout.jsonl"), []byte(fakeInitLine+"\n"+fakeResultLine+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+while [ $# -gt 0 ]; do
+  if [ "$1" = --plugin-dir ]; then
+    ls "$2/skills" >skills.txt
+    cat "$2/.claude-plugin/plugin.json" >plugin.json
+    cat "$2/skills/pdf/SKILL.md" >skill.md
+  fi
+  shift
+done
+cat stdout.jsonl
+cat >/dev/null
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		Skills:                     []claudeagent.SkillConfig{{Path: skills}},
+	})
+
+	if got := strings.TrimSpace(readFakeFile(t, dir, "skills.txt")); got != "pdf" {
+		t.Errorf("expected generated plugin to expose pdf, got %q", got)
+	}
+	if got := readFakeFile(t, dir, "plugin.json"); !strings.Contains(got, `"name":"sdk-skills"`) {
+		t.Errorf("unexpected plugin manifest %q", got)
+	}
+	if got := readFakeFile(t, dir, "skill.md"); !strings.Contains(got, "description: PDFs") {
+		t.Errorf("expected skill link to resolve to SKILL.md, got %q", got)
+	}
+}
+
+func TestSkillsListsConfiguredAndProjectSkills(t *testing.T) {
+	configured := t.TempDir()
+	writeSkill(t, configured, "pdf", "name: pdf\ndescription: PDFs")
+
+	project := t.TempDir()
+	writeSkill(t, filepath.Join(project, ".claude", "skills"), "deploy", "name: deploy\ndescription: Deploys")
+	writeSkill(t, filepath.Join(project, ".claude", "skills"), "pdf", "name: pdf\ndescription: Shadowed")
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		Cwd:            project,
+		SettingSources: []claudeagent.ConfigScope{claudeagent.ConfigScopeProject},
+		Skills:         []claudeagent.SkillConfig{{Path: configured}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	skills, err := client.Skills(context.Background())
+	if err != nil {
+		t.Fatalf("Skills failed: %v", err)
+	}
+	if len(skills) != 2 {
+		t.Fatalf("expected 2 skills, got %+v", skills)
+	}
+	if skills[0].Name != "pdf" || skills[0].Source != "sdk" || skills[0].Description != "PDFs" {
+		t.Errorf("expected configured pdf skill first, got %+v", skills[0])
+	}
+	if skills[1].Name != "deploy" || skills[1].Source != "project" {
+		t.Errorf("expected project deploy skill, got %+v", skills[1])
+	}
+}
+
+func TestSkillInvokedHook(t *testing.T) {
+	script := newHookFakeCLI(t,
+		fakeInitLine,
+		fakePreToolUseLine("cli_1", "hook_0", "Skill", `{"skill":"deploy","args":"prod"}`),
+	)
+
+	var invoked claudeagent.SkillInvokedHookInput
+	matcher := "deploy"
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		Hooks: map[claudeagent.HookEvent][]claudeagent.HookCallbackMatcher{
+			claudeagent.HookEventSkillInvoked: {{
+				Matcher: &matcher,
+				Hooks: []claudeagent.HookCallback{func(
+					_ context.Context,
+					input claudeagent.HookInput,
+					_ *string,
+				) (claudeagent.HookJSONOutput, error) {
+					invoked, _ = input.(claudeagent.SkillInvokedHookInput)
+					decision := string(claudeagent.PermissionDecisionDeny)
+					reason := "deploys need approval"
+
+					return claudeagent.SyncHookOutput{
+						HookSpecificOutput: claudeagent.PreToolUseHookOutput{
+							HookEventName:            claudeagent.HookEventPreToolUse,
+							PermissionDecision:       &decision,
+							PermissionDecisionReason: &reason,
+						},
+					}, nil
+				}},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "ship it"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	lines := fakeCLIStdin(t, script, `"cli_1"`, 1)
+	if !strings.Contains(lines[0], `"matcher":"Skill"`) || strings.Contains(lines[0], "SkillInvoked") {
+		t.Errorf("expected initialize to register a Skill PreToolUse hook only, got %s", lines[0])
+	}
+
+	var response string
+	for _, line := range lines {
+		if strings.Contains(line, `"cli_1"`) {
+			response = line
+		}
+	}
+	if !strings.Contains(response, `"permissionDecision":"deny"`) || !strings.Contains(response, "deploys need approval") {
+		t.Errorf("expected skill hook decision to be returned, got %s", response)
+	}
+	if invoked.Skill != "deploy" || invoked.Args != "prod" || invoked.ToolUseID != "toolu_1" {
+		t.Errorf("unexpected skill hook input %+v", invoked)
+	}
+}
@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// decodedStream returns a closed channel of the decoded lines.
+func decodedStream(t *testing.T, lines ...string) <-chan claudeagent.SDKMessage {
+	t.Helper()
+
+	msgs := make(chan claudeagent.SDKMessage, len(lines))
+	for _, line := range lines {
+		msg, err := claudeagent.DecodeMessage([]byte(line))
+		if err != nil {
+			t.Fatalf("failed to decode %s: %v", line, err)
+		}
+		msgs <- msg
+	}
+	close(msgs)
+
+	return msgs
+}
+
+// drain collects the values of ch on its own goroutine.
+func drain[T any](wg *sync.WaitGroup, ch <-chan T) *[]T {
+	var got []T
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for v := range ch {
+			got = append(got, v)
+		}
+	}()
+
+	return &got
+}
+
+func TestSplitStreamRoutesMessages(t *testing.T) {
+	msgs := decodedStream(t,
+		fakeInitLine,
+		fakeStreamEventLine(`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude"}}`),
+		fakeDeltaLine(`{"type":"text_delta","text":"Reading"}`),
+		fakeTextLine("Reading"),
+		fakeToolUseLine("toolu_1", "Read", `{"file_path":"go.mod"}`),
+		fakeToolResultLine("toolu_1", "module example", false),
+		fakeSubagentToolUseLine("toolu_task", "toolu_2", "Grep", `{"pattern":"x"}`),
+		fakeResultLine,
+	)
+
+	streams := claudeagent.SplitStream(context.Background(), msgs)
+	var wg sync.WaitGroup
+	assistant := drain(&wg, streams.Assistant)
+	toolEvents := drain(&wg, streams.ToolEvents)
+	results := drain(&wg, streams.Results)
+	system := drain(&wg, streams.System)
+	deltas := drain(&wg, streams.Deltas)
+	other := drain(&wg, streams.Other)
+	wg.Wait()
+
+	if len(*assistant) != 3 || len(*results) != 1 || len(*system) != 1 || len(*other) != 1 {
+		t.Errorf("expected 3 assistant, 1 result, 1 system and 1 other message, got %d, %d, %d and %d",
+			len(*assistant), len(*results), len(*system), len(*other))
+	}
+
+	if len(*deltas) != 1 || (*deltas)[0].Delta.TextDelta == nil || *(*deltas)[0].Delta.TextDelta != "Reading" {
+		t.Errorf("expected the text delta, got %+v", *deltas)
+	}
+
+	events := *toolEvents
+	if len(events) != 3 {
+		t.Fatalf("expected 3 tool events, got %d", len(events))
+	}
+	if events[0].Use == nil || events[0].Use.Name != "Read" || events[0].ParentToolUseID != "" {
+		t.Errorf("expected the Read call first, got %+v", events[0])
+	}
+	if events[1].Result == nil || events[1].Result.ToolUseID != "toolu_1" {
+		t.Errorf("expected the Read result second, got %+v", events[1])
+	}
+	if events[2].Use == nil || events[2].Use.Name != "Grep" || events[2].ParentToolUseID != "toolu_task" {
+		t.Errorf("expected the subagent Grep call last, got %+v", events[2])
+	}
+}
+
+func TestSplitStreamStopsOnCancel(t *testing.T) {
+	msgs := make(chan claudeagent.SDKMessage)
+	ctx, cancel := context.WithCancel(context.Background())
+	streams := claudeagent.SplitStream(ctx, msgs)
+	cancel()
+
+	select {
+	case _, ok := <-streams.Results:
+		if ok {
+			t.Error("expected no result")
+		}
+	case <-time.After(fakeCLITimeout):
+		t.Fatal("expected the channels to close after cancel")
+	}
+}
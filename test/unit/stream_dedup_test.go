@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// streamedAssistantLine is the assistant message completing the deltas of
+// streamDedupLines, with a tool call that wasn't streamed.
+const streamedAssistantLine = `{"type":"assistant","uuid":"00000000-0000-0000-0000-000000000002","session_id":"fake-session","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[` +
+	`{"type":"thinking","thinking":"Let me check","signature":"sig"},{"type":"text","text":"Hello there"},` +
+	`{"type":"tool_use","id":"toolu_1","name":"Read","input":{"file_path":"main.go"}}],"usage":{"input_tokens":1,"output_tokens":1}}}`
+
+// streamDedupLines streams a thinking and a text block before the
+// assistant message carrying them.
+var streamDedupLines = []string{
+	fakeInitLine,
+	fakeStreamEventLine(`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude"}}`),
+	fakeStreamEventLine(`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Let me check"}}`),
+	fakeStreamEventLine(`{"type":"content_block_stop","index":0}`),
+	fakeStreamEventLine(`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Hello "}}`),
+	fakeStreamEventLine(`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"there"}}`),
+	fakeStreamEventLine(`{"type":"content_block_stop","index":1}`),
+	streamedAssistantLine,
+	fakeStreamEventLine(`{"type":"message_stop"}`),
+	fakeResultLine,
+}
+
+// assistantBlocks returns the content of the assistant messages among
+// messages.
+func assistantBlocks(messages []claudeagent.SDKMessage) []claudeagent.ContentBlock {
+	var blocks []claudeagent.ContentBlock
+	for _, msg := range messages {
+		if assistant, ok := msg.(*claudeagent.SDKAssistantMessage); ok {
+			blocks = append(blocks, assistant.Message.Content...)
+		}
+	}
+
+	return blocks
+}
+
+func TestSuppressFinalDuplicateDropsStreamedBlocks(t *testing.T) {
+	client, messages := collectFakeSession(t, &claudeagent.Options{
+		IncludePartialMessages:     true,
+		SuppressFinalDuplicate:     true,
+		PathToClaudeCodeExecutable: newFakeCLI(t, streamDedupLines...),
+	})
+
+	blocks := assistantBlocks(messages)
+	if len(blocks) != 1 {
+		t.Fatalf("expected only the tool call to remain, got %+v", blocks)
+	}
+	if _, ok := blocks[0].(claudeagent.ToolUseContentBlock); !ok {
+		t.Errorf("expected the tool call to remain, got %T", blocks[0])
+	}
+	if stats := client.ToolStats(); stats["Read"].InFlight != 1 {
+		t.Errorf("expected the client to track the full message, got %+v", stats)
+	}
+}
+
+func TestSuppressFinalDuplicateKeepsUnstreamedBlocks(t *testing.T) {
+	// Without text deltas delivered, the text is only in the message
+	_, messages := collectFakeSession(t, &claudeagent.Options{
+		StreamEventFilter:          &claudeagent.StreamEventFilter{ThinkingDeltas: true, Lifecycle: true},
+		SuppressFinalDuplicate:     true,
+		PathToClaudeCodeExecutable: newFakeCLI(t, streamDedupLines...),
+	})
+
+	blocks := assistantBlocks(messages)
+	if len(blocks) != 2 {
+		t.Fatalf("expected the text and the tool call to remain, got %+v", blocks)
+	}
+	if text, ok := blocks[0].(claudeagent.TextContentBlock); !ok || text.Text != "Hello there" {
+		t.Errorf("expected the unstreamed text to remain, got %+v", blocks[0])
+	}
+}
+
+func TestWithoutSuppressFinalDuplicateKeepsFullMessage(t *testing.T) {
+	_, messages := collectFakeSession(t, &claudeagent.Options{
+		IncludePartialMessages:     true,
+		PathToClaudeCodeExecutable: newFakeCLI(t, streamDedupLines...),
+	})
+
+	if blocks := assistantBlocks(messages); len(blocks) != 3 {
+		t.Errorf("expected the full message, got %+v", blocks)
+	}
+}
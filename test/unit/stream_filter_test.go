@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// fakeStreamEventLine returns a stream_event frame wrapping event.
+func fakeStreamEventLine(event string) string {
+	return `{"type":"stream_event","uuid":"00000000-0000-0000-0000-000000000005","session_id":"fake-session","event":` + event + `}`
+}
+
+// fakeDeltaLine returns a content_block_delta stream event.
+func fakeDeltaLine(delta string) string {
+	return fakeStreamEventLine(`{"type":"content_block_delta","index":0,"delta":` + delta + `}`)
+}
+
+// streamFilterLines is a partial stream with one event of each kind.
+var streamFilterLines = []string{
+	fakeInitLine,
+	fakeStreamEventLine(`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude"}}`),
+	fakeDeltaLine(`{"type":"thinking_delta","thinking":"Let me check"}`),
+	fakeDeltaLine(`{"type":"signature_delta","signature":"sig"}`),
+	fakeDeltaLine(`{"type":"text_delta","text":"Hello"}`),
+	fakeDeltaLine(`{"type":"input_json_delta","partial_json":"{\"path\":"}`),
+	fakeStreamEventLine(`{"type":"message_stop"}`),
+	fakeResultLine,
+}
+
+// streamDeltas summarizes the stream events among messages.
+func streamDeltas(messages []claudeagent.SDKMessage) []string {
+	var got []string
+	for _, msg := range messages {
+		event, ok := msg.(*claudeagent.SDKStreamEvent)
+		if !ok {
+			continue
+		}
+		delta, ok := event.Event.(claudeagent.ContentBlockDeltaEvent)
+		switch {
+		case !ok:
+			got = append(got, event.Event.EventType())
+		case delta.Delta.TextDelta != nil:
+			got = append(got, "text:"+*delta.Delta.TextDelta)
+		case delta.Delta.ThinkingDelta != nil:
+			got = append(got, "thinking:"+*delta.Delta.ThinkingDelta)
+		case delta.Delta.PartialJSON != nil:
+			got = append(got, "json:"+*delta.Delta.PartialJSON)
+		default:
+			got = append(got, "signature")
+		}
+	}
+
+	return got
+}
+
+func TestStreamEventFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *claudeagent.StreamEventFilter
+		want   string
+	}{
+		{
+			name: "unfiltered",
+			want: `message_start thinking:Let me check signature text:Hello json:{"path": message_stop`,
+		},
+		{
+			name:   "text only",
+			filter: &claudeagent.StreamEventFilter{TextDeltas: true},
+			want:   "text:Hello",
+		},
+		{
+			name:   "thinking only",
+			filter: &claudeagent.StreamEventFilter{ThinkingDeltas: true},
+			want:   "thinking:Let me check signature",
+		},
+		{
+			name:   "tool input and lifecycle",
+			filter: &claudeagent.StreamEventFilter{ToolInputDeltas: true, Lifecycle: true},
+			want:   `message_start json:{"path": message_stop`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, messages := runFakeSession(t,
+				&claudeagent.Options{IncludePartialMessages: true, StreamEventFilter: tt.filter},
+				streamFilterLines...,
+			)
+
+			if got := strings.Join(streamDeltas(messages), " "); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if _, ok := messages[len(messages)-1].(*claudeagent.SDKResultMessage); !ok {
+				t.Errorf("expected the stream to end with the result, got %T", messages[len(messages)-1])
+			}
+		})
+	}
+}
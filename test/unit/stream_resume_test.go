@@ -0,0 +1,178 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// newResumeFakeCLI writes a fake CLI whose first run emits first and exits
+// mid-turn, and whose later runs record their arguments and prompt and
+// emit resumed. It returns the script path and its directory.
+func newResumeFakeCLI(t *testing.T, first, resumed []string) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, lines := range map[string][]string{"first.jsonl": first, "resumed.jsonl": resumed} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatalf("failed to write fake CLI output: %v", err)
+		}
+	}
+
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+printf 'run\n' >>runs
+if [ ! -e started ]; then
+  : >started
+  read -r line
+  cat first.jsonl
+  exit 1
+fi
+printf '%s\n' "$*" >args
+read -r line
+printf '%s\n' "$line" >prompt
+cat resumed.jsonl
+cat >/dev/null
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatalf("failed to write fake CLI script: %v", err)
+	}
+
+	return script, dir
+}
+
+// streamedText joins the text deltas among messages.
+func streamedText(messages []claudeagent.SDKMessage) string {
+	var text strings.Builder
+	for _, msg := range messages {
+		event, ok := msg.(*claudeagent.SDKStreamEvent)
+		if !ok {
+			continue
+		}
+		if delta, ok := event.Event.(claudeagent.ContentBlockDeltaEvent); ok && delta.Delta.TextDelta != nil {
+			text.WriteString(*delta.Delta.TextDelta)
+		}
+	}
+
+	return text.String()
+}
+
+func TestStreamResumeContinuesDroppedTurn(t *testing.T) {
+	// The resumed session answers with a new response, under a new ID
+	script, dir := newResumeFakeCLI(t,
+		[]string{
+			fakeInitLine,
+			fakeStreamEventLine(`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude"}}`),
+			fakeStreamEventLine(`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`),
+			fakeDeltaLine(`{"type":"text_delta","text":"Hello "}`),
+			fakeDeltaLine(`{"type":"text_delta","text":"wor"}`),
+		},
+		[]string{
+			fakeInitLine,
+			fakeStreamEventLine(`{"type":"message_start","message":{"id":"msg_2","type":"message","role":"assistant","content":[],"model":"claude"}}`),
+			fakeStreamEventLine(`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`),
+			fakeDeltaLine(`{"type":"text_delta","text":"Hello wo"}`),
+			fakeDeltaLine(`{"type":"text_delta","text":"rld"}`),
+			fakeStreamEventLine(`{"type":"content_block_stop","index":0}`),
+			fakeTextLine("Hello world"),
+			fakeResultLine,
+		},
+	)
+
+	var attempts []int
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		IncludePartialMessages: true,
+		StreamResume: &claudeagent.StreamResume{
+			OnResume: func(attempt int, _ error) { attempts = append(attempts, attempt) },
+		},
+		PathToClaudeCodeExecutable: script,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	messages := receiveAll(t, client)
+
+	// Nothing is dropped as a replay of the interrupted response
+	if got := streamedText(messages); got != "Hello worHello world" {
+		t.Errorf("expected the interrupted and resumed responses in full, got %q", got)
+	}
+	var inits, starts, results int
+	for _, msg := range messages {
+		switch m := msg.(type) {
+		case *claudeagent.SDKSystemMessage:
+			inits++
+		case *claudeagent.SDKStreamEvent:
+			if _, ok := m.Event.(claudeagent.ContentBlockStartEvent); ok {
+				starts++
+			}
+		case *claudeagent.SDKResultMessage:
+			results++
+		}
+	}
+	if inits != 1 || starts != 2 || results != 1 {
+		t.Errorf("expected one init, two block starts and one result, got %d, %d and %d", inits, starts, results)
+	}
+
+	// The interrupted block and message are stopped before the resumed
+	// message starts
+	var events []string
+	for _, msg := range messages {
+		if event, ok := msg.(*claudeagent.SDKStreamEvent); ok {
+			events = append(events, event.Event.EventType())
+		}
+	}
+	want := []string{
+		"message_start", "content_block_start", "content_block_delta", "content_block_delta",
+		"content_block_stop", "message_stop",
+		"message_start", "content_block_start", "content_block_delta", "content_block_delta", "content_block_stop",
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("expected stream events %v, got %v", want, events)
+	}
+	if len(attempts) != 1 || attempts[0] != 1 {
+		t.Errorf("expected one resume attempt, got %v", attempts)
+	}
+	if args := readFakeFile(t, dir, "args"); !strings.Contains(args, "--resume fake-session") {
+		t.Errorf("expected the session to be resumed, got args %q", args)
+	}
+	if prompt := readFakeFile(t, dir, "prompt"); !strings.Contains(prompt, "Continue exactly where it stopped") {
+		t.Errorf("expected the resume prompt, got %q", prompt)
+	}
+}
+
+func TestStreamResumeGivesUpAfterMaxAttempts(t *testing.T) {
+	dropped := []string{fakeInitLine, fakeTextLine("partial")}
+	script, dir := newResumeFakeCLI(t, dropped, []string{fakeTextLine("partial")})
+	// Every resumed run drops the stream too
+	body, _ := os.ReadFile(script)
+	_ = os.WriteFile(script, []byte(strings.Replace(string(body), "cat >/dev/null\n", "exit 1\n", 1)), 0o700)
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		StreamResume:               &claudeagent.StreamResume{MaxAttempts: 1},
+		PathToClaudeCodeExecutable: script,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+	if err := client.Query(ctx, "long task"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for range client.ReceiveResponse(ctx) {
+	}
+
+	if runs := strings.Count(readFakeFile(t, dir, "runs"), "run"); runs != 2 {
+		t.Errorf("expected the first run and one resume, got %d runs", runs)
+	}
+}
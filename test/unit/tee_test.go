@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/debugger"
+)
+
+// runTeeSession runs a fake session with a tee attached and returns the
+// tee output once the client is closed.
+func runTeeSession(t *testing.T, lines ...string) []byte {
+	t.Helper()
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, lines...),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	client.TeeJSONL(&buf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "hello"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for range client.ReceiveResponse(ctx) {
+	}
+	_ = client.Close()
+
+	return buf.Bytes()
+}
+
+func TestTeeJSONLMirrorsBothDirections(t *testing.T) {
+	out := runTeeSession(t, fakeInitLine, fakeTextLine("hi"), fakeResultLine)
+
+	var records []claudeagent.TeeRecord
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		var record claudeagent.TeeRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid tee line %q: %v", scanner.Text(), err)
+		}
+		if record.Timestamp.IsZero() {
+			t.Errorf("expected timestamp on %q", scanner.Text())
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 4 {
+		t.Fatalf("expected 4 records, got %d:\n%s", len(records), out)
+	}
+	if records[0].Direction != claudeagent.TeeOutbound || !strings.Contains(string(records[0].Message), `"hello"`) {
+		t.Errorf("expected outbound prompt first, got %+v", records[0])
+	}
+	for i, want := range []string{fakeInitLine, fakeTextLine("hi"), fakeResultLine} {
+		record := records[i+1]
+		if record.Direction != claudeagent.TeeInbound || string(record.Message) != want {
+			t.Errorf("record %d: expected inbound %s, got %s %s", i+1, want, record.Direction, record.Message)
+		}
+	}
+	for i := 1; i < len(records); i++ {
+		if records[i].Timestamp.Before(records[i-1].Timestamp) {
+			t.Errorf("record %d timestamp goes backwards", i)
+		}
+	}
+}
+
+func TestTeeJSONLLoadsInDebugger(t *testing.T) {
+	out := runTeeSession(t, fakeInitLine, fakeResultLine)
+
+	transcript, err := debugger.Load(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(transcript.Steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d", len(transcript.Steps))
+	}
+
+	last := transcript.Steps[2]
+	if _, ok := last.Message.(*claudeagent.SDKResultMessage); !ok {
+		t.Errorf("expected result message, got %T (%v)", last.Message, last.Err)
+	}
+	if last.Direction != claudeagent.TeeInbound || last.Timestamp.IsZero() {
+		t.Errorf("expected inbound step with timestamp, got %q %v", last.Direction, last.Timestamp)
+	}
+	if transcript.Steps[0].Direction != claudeagent.TeeOutbound {
+		t.Errorf("expected outbound first step, got %q", transcript.Steps[0].Direction)
+	}
+}
@@ -0,0 +1,141 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// readCSV reads a CSV file into records keyed by column name.
+func readCSV(t *testing.T, path string) []map[string]string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("expected %s to be exported: %v", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	var rows []map[string]string
+	for _, record := range records[1:] {
+		row := make(map[string]string)
+		for i, column := range records[0] {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func TestTelemetryExportWritesCSVOnClose(t *testing.T) {
+	dir := t.TempDir()
+	client, _ := collectFakeSession(t, &claudeagent.Options{
+		TelemetryExport: &claudeagent.TelemetryExport{Dir: dir},
+		PathToClaudeCodeExecutable: newFakeCLI(t,
+			fakeInitLine,
+			fakeToolUseLine("toolu_1", "Bash", `{"command":"ls"}`),
+			fakeToolResultLine("toolu_1", "main.go", false),
+			fakeToolUseLine("toolu_2", "Read", `{"file_path":"main.go"}`),
+			fakeToolResultLine("toolu_2", "no such file", true),
+			fakeResultLine,
+		),
+	})
+	_ = client.Close()
+
+	sessions := readCSV(t, filepath.Join(dir, "session_reports", "fake-session.csv"))
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 session row, got %d", len(sessions))
+	}
+	for column, want := range map[string]string{
+		"session_id":    "fake-session",
+		"model":         "claude-sonnet-4-5",
+		"turns":         "1",
+		"duration_ms":   "10",
+		"cost_usd":      "0.01",
+		"input_tokens":  "10",
+		"output_tokens": "5",
+	} {
+		if got := sessions[0][column]; got != want {
+			t.Errorf("expected session %s %q, got %q", column, want, got)
+		}
+	}
+
+	tools := readCSV(t, filepath.Join(dir, "tool_stats", "fake-session.csv"))
+	if len(tools) != 2 || tools[0]["tool_name"] != "Bash" || tools[1]["tool_name"] != "Read" {
+		t.Fatalf("expected a row for Bash and Read, got %v", tools)
+	}
+	if tools[1]["failures"] != "1" || tools[1]["failure_rate"] != "1" {
+		t.Errorf("expected the failed Read to be counted, got %v", tools[1])
+	}
+}
+
+func TestTelemetryExportWritesOnInterval(t *testing.T) {
+	dir := t.TempDir()
+	collectFakeSession(t, &claudeagent.Options{
+		TelemetryExport: &claudeagent.TelemetryExport{
+			Dir:      dir,
+			Format:   claudeagent.ExportParquet,
+			Interval: 10 * time.Millisecond,
+		},
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeResultLine),
+	})
+
+	path := filepath.Join(dir, "session_reports", "fake-session.parquet")
+	deadline := time.Now().Add(fakeCLITimeout)
+	for {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+				t.Errorf("expected a Parquet file, got %q", data)
+			}
+			if !bytes.Contains(data, []byte("cost_usd")) || !bytes.Contains(data, []byte("fake-session")) {
+				t.Errorf("expected the report's columns and values in the file")
+			}
+
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the report to be exported before Close: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWriteToolStatsCSV(t *testing.T) {
+	var out strings.Builder
+	err := claudeagent.WriteToolStats(&out, claudeagent.ExportCSV, "s1", map[string]claudeagent.ToolStats{
+		"Write": {ToolName: "Write", Count: 1, P50: 1500 * time.Microsecond},
+		"Bash":  {ToolName: "Bash", Count: 2, P95: 2 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("WriteToolStats failed: %v", err)
+	}
+
+	want := "session_id,tool_name,count,failures,failure_rate,p50_ms,p95_ms,max_ms,in_flight\n" +
+		"s1,Bash,2,0,0,0,2000,0,0\n" +
+		"s1,Write,1,0,0,1.5,0,0,0\n"
+	if out.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, out.String())
+	}
+}
+
+func TestTelemetryExportValidation(t *testing.T) {
+	_, err := claudeagent.NewOptions().
+		WithTelemetryExport(claudeagent.TelemetryExport{Dir: t.TempDir(), Format: "xlsx"}).
+		Build()
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || sdkErr.Code() != clauderrs.ErrCodeInvalidFormat {
+		t.Errorf("expected ErrCodeInvalidFormat, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+const thinkingAssistantLine = `{"type":"assistant","session_id":"s","parent_tool_use_id":null,"message":{"id":"m1","type":"message","role":"assistant","model":"claude","content":[` +
+	`{"type":"thinking","thinking":"Let me check","signature":"sig-1"},` +
+	`{"type":"redacted_thinking","data":"ENCRYPTED"},` +
+	`{"type":"text","text":"Done"}]}}`
+
+func TestThinkingBlocksRoundTrip(t *testing.T) {
+	msg, err := claudeagent.DecodeMessage([]byte(thinkingAssistantLine))
+	if err != nil {
+		t.Fatalf("DecodeMessage failed: %v", err)
+	}
+	assistant, ok := msg.(*claudeagent.SDKAssistantMessage)
+	if !ok {
+		t.Fatalf("expected assistant message, got %T", msg)
+	}
+
+	content := assistant.Message.Content
+	thinking, ok := content[0].(claudeagent.ThinkingBlock)
+	if !ok || thinking.Signature != "sig-1" {
+		t.Errorf("expected signed thinking block, got %#v", content[0])
+	}
+	if redacted, ok := content[1].(claudeagent.RedactedThinkingBlock); !ok || redacted.Data != "ENCRYPTED" {
+		t.Errorf("expected redacted thinking block, got %#v", content[1])
+	}
+
+	// Storing and reloading the message keeps the blocks intact.
+	data, err := json.Marshal(assistant)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	reloaded, err := claudeagent.DecodeMessage(data)
+	if err != nil {
+		t.Fatalf("DecodeMessage of stored message failed: %v", err)
+	}
+	if got := reloaded.(*claudeagent.SDKAssistantMessage).Message.Content; !reflect.DeepEqual(got, content) {
+		t.Errorf("content changed on round trip:\n got %#v\nwant %#v", got, content)
+	}
+}
+
+func TestSignatureDeltaIsDelivered(t *testing.T) {
+	_, messages := runFakeSession(t, &claudeagent.Options{IncludePartialMessages: true},
+		fakeInitLine,
+		fakeDeltaLine(`{"type":"signature_delta","signature":"sig-1"}`),
+		fakeResultLine,
+	)
+
+	for _, msg := range messages {
+		if event, ok := msg.(*claudeagent.SDKStreamEvent); ok {
+			delta, ok := event.Event.(claudeagent.ContentBlockDeltaEvent)
+			if ok && delta.Delta.SignatureDelta != nil && *delta.Delta.SignatureDelta == "sig-1" {
+				return
+			}
+		}
+	}
+	t.Errorf("expected a signature delta, got %v", messages)
+}
+
+func TestPreserveThinkingChecksResumedTranscript(t *testing.T) {
+	configDir := t.TempDir()
+	cwd := t.TempDir()
+	writeTranscript(t, configDir, cwd, "signed",
+		`{"type":"user","sessionId":"signed","message":{"role":"user","content":"hi"}}`,
+		`{"type":"assistant","uuid":"a1","sessionId":"signed","message":{"role":"assistant","content":[{"type":"thinking","thinking":"hm","signature":"sig"}]}}`,
+	)
+	writeTranscript(t, configDir, cwd, "stripped",
+		`{"type":"assistant","uuid":"a1","sessionId":"stripped","message":{"role":"assistant","content":[{"type":"thinking","thinking":"hm"}]}}`,
+	)
+	env := map[string]string{"CLAUDE_CONFIG_DIR": configDir}
+
+	runFakeSession(t, &claudeagent.Options{Cwd: cwd, Env: env, Resume: "signed", PreserveThinking: true},
+		fakeInitLine, fakeResultLine)
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		Cwd:                        cwd,
+		Env:                        env,
+		Resume:                     "stripped",
+		PreserveThinking:           true,
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeResultLine),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	err = client.Query(context.Background(), "continue")
+	if sdkErr, ok := clauderrs.AsSDKError(err); !ok || !clauderrs.IsValidationError(err) || sdkErr.Code() != clauderrs.ErrCodeInvalidFormat {
+		t.Errorf("expected a ValidationError for the stripped signature, got %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"Hello world", 2},          // a single space joins the next word
+		{"internationalization", 4}, // long words split into pieces
+		{"1234567", 3},              // digits group in threes
+		{"if (x) {\n\treturn\n}", 9},
+		{"日本語", 3},
+	}
+
+	for _, tt := range tests {
+		if got := claudeagent.EstimateTokens("claude-sonnet-4-5", tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+
+	prose := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 100)
+	if got := claudeagent.EstimateTokens("", prose); got < 900 || got > 1400 {
+		t.Errorf("expected roughly 1000 tokens of prose, got %d", got)
+	}
+}
+
+func TestEstimateBlockTokensSizesImages(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 300, 250))); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := []claudeagent.ContentBlock{
+		claudeagent.TextContentBlock{Type: "text", Text: "the cat sat"},
+		claudeagent.ImageContentBlock{Type: "image", Source: claudeagent.ImageSource{
+			Type:      "base64",
+			MediaType: "image/png",
+			Data:      base64.StdEncoding.EncodeToString(buf.Bytes()),
+		}},
+	}
+	if got := claudeagent.EstimateBlockTokens("", blocks); got != 3+100 {
+		t.Errorf("expected 3 text tokens plus 100 for a 300x250 image, got %d", got)
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	if got := claudeagent.ContextWindow("claude-sonnet-4-5"); got != 200_000 {
+		t.Errorf("expected 200k window, got %d", got)
+	}
+	if got := claudeagent.ContextWindow("sonnet[1m]"); got != 1_000_000 {
+		t.Errorf("expected 1M window for [1m] models, got %d", got)
+	}
+}
+
+func TestPromptLimit(t *testing.T) {
+	prompt := strings.Repeat("word ", 2000)
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: "/nonexistent/claude",
+		PromptLimit:                &claudeagent.PromptLimit{Fraction: 0.001},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	err = client.Query(context.Background(), prompt)
+	if !clauderrs.IsValidationError(err) || !strings.Contains(err.Error(), "limit of 200") {
+		t.Errorf("expected oversized prompt to be rejected, got %v", err)
+	}
+
+	var warned [2]int
+	opts, err := claudeagent.NewOptions().
+		WithPromptLimit(0.001, func(estimate, limit int) { warned = [2]int{estimate, limit} }).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	opts.PathToClaudeCodeExecutable = newFakeCLI(t, fakeInitLine, fakeResultLine)
+	client, err = claudeagent.NewClient(opts)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	if err := client.Query(context.Background(), prompt); err != nil {
+		t.Fatalf("expected prompt to be sent after warning, got %v", err)
+	}
+	if warned[1] != 200 || warned[0] <= 200 {
+		t.Errorf("expected warning with estimate over the 200-token limit, got %v", warned)
+	}
+}
+
+func TestOutputTokenLimit(t *testing.T) {
+	tests := map[string]int{
+		"claude-sonnet-4-5-20250929":                   64_000,
+		"claude-opus-4-1-20250805":                     32_000,
+		"us.anthropic.claude-opus-4-20250514-v1:0":     32_000,
+		"claude-sonnet-4@20250514":                     64_000,
+		"claude-3-5-haiku-20241022":                    8_192,
+		"sonnet":                                       0,
+		"claude-opus-4-9":                              0,
+		"anthropic.claude-haiku-4-5-20251001-v1:0[1m]": 64_000,
+	}
+	for model, want := range tests {
+		if got := claudeagent.OutputTokenLimit(model); got != want {
+			t.Errorf("OutputTokenLimit(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
+
+func TestTokenCapsValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    string
+		output   int
+		thinking int
+		field    string
+	}{
+		{name: "over model limit", model: "claude-opus-4-1-20250805", output: 40_000, field: "MaxOutputTokens"},
+		{name: "thinking not below output", output: 8_000, thinking: 8_000, field: "MaxThinkingTokens"},
+		{name: "thinking over model limit", model: "claude-3-5-haiku-20241022", thinking: 10_000, field: "MaxThinkingTokens"},
+		{name: "negative output", output: -1, field: "MaxOutputTokens"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := claudeagent.NewOptions().
+				WithModel(tt.model).
+				WithMaxOutputTokens(tt.output).
+				WithMaxThinkingTokens(tt.thinking).
+				Build()
+			assertValidationField(t, err, tt.field)
+		})
+	}
+
+	// Unknown models are only checked against each other
+	_, err := claudeagent.NewOptions().
+		WithModel("sonnet").
+		WithMaxOutputTokens(200_000).
+		WithMaxThinkingTokens(100_000).
+		Build()
+	if err != nil {
+		t.Errorf("expected caps for an alias to be accepted, got %v", err)
+	}
+}
+
+func TestTokenCapsPassedToCLI(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stdout.jsonl"), []byte(fakeInitLine+"\n"+fakeResultLine+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "claude")
+	body := "#!/bin/sh\ncd '" + dir + "'\nprintf '%s\\n' \"$@\" >args.txt\n" +
+		"printf '%s\\n' \"$CLAUDE_CODE_MAX_OUTPUT_TOKENS\" >env.txt\ncat stdout.jsonl\ncat >/dev/null\n"
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		MaxOutputTokens:            4_096,
+		MaxThinkingTokens:          2_048,
+	})
+
+	if args := readFakeFile(t, dir, "args.txt"); !strings.Contains(args, "--max-thinking-tokens\n2048\n") {
+		t.Errorf("expected --max-thinking-tokens, got %s", args)
+	}
+	if env := readFakeFile(t, dir, "env.txt"); strings.TrimSpace(env) != "4096" {
+		t.Errorf("expected CLAUDE_CODE_MAX_OUTPUT_TOKENS=4096, got %q", env)
+	}
+}
@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// newBudgetFakeCLI writes a fake CLI that acknowledges the initialize
+// request and calls the PreToolUse hook for each of tools in turn, as
+// requests cli_1, cli_2 and so on, waiting for each answer before the
+// next call.
+func newBudgetFakeCLI(t *testing.T, tools ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	var calls []string
+	for i, tool := range tools {
+		calls = append(calls, fakePreToolUseLine("cli_"+strconv.Itoa(i+1), "hook_0", tool, `{}`))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "calls.jsonl"), []byte(strings.Join(calls, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "result.jsonl"), []byte(fakeResultLine+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+IFS= read -r line
+printf '%s\n' "$line" >>stdin.jsonl
+id=$(printf '%s\n' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{}}}\n' "$id"
+printf '%s\n' '` + fakeInitLine + `'
+while IFS= read -r call <&3; do
+  printf '%s\n' "$call"
+  while IFS= read -r line; do
+    printf '%s\n' "$line" >>stdin.jsonl
+    case "$line" in *control_response*) break;; esac
+  done
+done 3<calls.jsonl
+cat result.jsonl
+cat >>stdin.jsonl
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script
+}
+
+// budgetResponse returns the hook answer to requestID among lines.
+func budgetResponse(t *testing.T, lines []string, requestID string) string {
+	t.Helper()
+
+	for _, line := range lines {
+		if strings.Contains(line, `"request_id":"`+requestID+`"`) {
+			return line
+		}
+	}
+	t.Fatalf("expected an answer to %s", requestID)
+
+	return ""
+}
+
+func TestMaxToolUsesDeniesCallsOverLimits(t *testing.T) {
+	opts, err := claudeagent.NewOptions().
+		WithMaxToolUses(claudeagent.ToolUseLimits{Total: 2, PerTool: map[string]int{"WebSearch": 1}}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	opts.PathToClaudeCodeExecutable = newBudgetFakeCLI(t, "WebSearch", "WebSearch", "Read", "Read")
+
+	collectFakeSession(t, opts)
+
+	lines := fakeCLIStdin(t, opts.PathToClaudeCodeExecutable, `"cli_4"`, 1)
+	if !strings.Contains(lines[0], `"PreToolUse"`) {
+		t.Errorf("expected initialize to register the budget hook, got %s", lines[0])
+	}
+
+	if response := budgetResponse(t, lines, "cli_1"); strings.Contains(response, `"deny"`) {
+		t.Errorf("expected the first WebSearch allowed, got %s", response)
+	}
+	response := budgetResponse(t, lines, "cli_2")
+	if !strings.Contains(response, `"permissionDecision":"deny"`) ||
+		!strings.Contains(response, "WebSearch may be called at most 1 times") {
+		t.Errorf("expected the second WebSearch denied by its limit, got %s", response)
+	}
+	if response := budgetResponse(t, lines, "cli_3"); strings.Contains(response, `"deny"`) {
+		t.Errorf("expected the denied call not to count, got %s", response)
+	}
+	response = budgetResponse(t, lines, "cli_4")
+	if !strings.Contains(response, `"permissionDecision":"deny"`) ||
+		!strings.Contains(response, "tools may be called at most 2 times") {
+		t.Errorf("expected the second Read denied by the total limit, got %s", response)
+	}
+}
+
+func TestMaxToolUsesValidation(t *testing.T) {
+	opts := &claudeagent.Options{
+		MaxToolUses: &claudeagent.ToolUseLimits{PerTool: map[string]int{"WebSearch": -1}},
+	}
+	assertValidationField(t, opts.Validate(), "MaxToolUses.PerTool")
+
+	opts.MaxToolUses = &claudeagent.ToolUseLimits{Total: -1}
+	assertValidationField(t, opts.Validate(), "MaxToolUses.Total")
+}
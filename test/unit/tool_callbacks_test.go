@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// newToolCallbackFakeCLI writes a fake CLI that acknowledges the initialize
+// request and emits each stage once the SDK has answered the control
+// request ending the previous one, as the CLI waits for each hook.
+func newToolCallbackFakeCLI(t *testing.T, stages ...[]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	var body strings.Builder
+	body.WriteString(`#!/bin/sh
+cd '` + dir + `'
+IFS= read -r line
+printf '%s\n' "$line" >>stdin.jsonl
+id=$(printf '%s\n' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{}}}\n' "$id"
+`)
+	for i, lines := range stages {
+		name := fmt.Sprintf("stage-%d.jsonl", i)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 {
+			body.WriteString(`while IFS= read -r line; do
+  printf '%s\n' "$line" >>stdin.jsonl
+  case "$line" in *control_response*) break;; esac
+done
+`)
+		}
+		body.WriteString("cat " + name + "\n")
+	}
+	body.WriteString("cat >>stdin.jsonl\n")
+
+	script := filepath.Join(dir, "claude")
+	if err := os.WriteFile(script, []byte(body.String()), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script
+}
+
+// toolEvents records the calls of OnToolStart and OnToolEnd.
+type toolEvents struct {
+	mu     sync.Mutex
+	starts []claudeagent.ToolStart
+	ends   []claudeagent.ToolEnd
+}
+
+func (e *toolEvents) options(script string) *claudeagent.Options {
+	return &claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		OnToolStart: func(start claudeagent.ToolStart) {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			e.starts = append(e.starts, start)
+		},
+		OnToolEnd: func(end claudeagent.ToolEnd) {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			e.ends = append(e.ends, end)
+		},
+	}
+}
+
+func TestToolCallbacksReportSuccessfulCall(t *testing.T) {
+	var events toolEvents
+	script := newToolCallbackFakeCLI(t,
+		[]string{
+			fakeInitLine,
+			fakeToolUseLine("toolu_1", "Bash", `{"command":"ls"}`),
+			fakePreToolUseLine("cli_1", "hook_0", "Bash", `{"command":"ls"}`),
+		},
+		[]string{fakePostToolUseLine("cli_2", "hook_1", "Bash", `{"stdout":"main.go","stderr":""}`)},
+		[]string{fakeToolResultLine("toolu_1", "main.go", false), fakeResultLine},
+	)
+	collectFakeSession(t, events.options(script))
+
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	if len(events.starts) != 1 || events.starts[0].ToolName != "Bash" || events.starts[0].ToolUseID != "toolu_1" ||
+		string(events.starts[0].Input) != `{"command":"ls"}` {
+		t.Fatalf("expected the Bash call to start, got %+v", events.starts)
+	}
+	if len(events.ends) != 1 {
+		t.Fatalf("expected 1 end, got %d", len(events.ends))
+	}
+	end := events.ends[0]
+	if end.ToolName != "Bash" || end.Output != "main.go" || end.IsError || end.Duration < 0 {
+		t.Errorf("expected a successful Bash end, got %+v", end)
+	}
+	if string(end.Response) != `{"stdout":"main.go","stderr":""}` {
+		t.Errorf("expected the raw tool response, got %s", end.Response)
+	}
+
+	// The observing hooks must not affect the call
+	stdin := fakeCLIStdin(t, script, `"request_id":"cli_2"`, 1)
+	for _, line := range stdin {
+		if strings.Contains(line, `"request_id":"cli_`) && strings.Contains(line, "decision") {
+			t.Errorf("expected empty hook outputs, got %s", line)
+		}
+	}
+}
+
+func TestToolCallbacksReportFailedCall(t *testing.T) {
+	var events toolEvents
+	script := newToolCallbackFakeCLI(t,
+		[]string{
+			fakeInitLine,
+			fakeToolUseLine("toolu_1", "Read", `{"file_path":"missing.go"}`),
+			fakePreToolUseLine("cli_1", "hook_0", "Read", `{"file_path":"missing.go"}`),
+		},
+		[]string{fakeToolResultLine("toolu_1", "no such file", true), fakeResultLine},
+	)
+	collectFakeSession(t, events.options(script))
+
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	if len(events.ends) != 1 {
+		t.Fatalf("expected 1 end, got %d", len(events.ends))
+	}
+	end := events.ends[0]
+	if end.ToolName != "Read" || !end.IsError || end.Output != "no such file" || end.Response != nil {
+		t.Errorf("expected a failed Read end, got %+v", end)
+	}
+}
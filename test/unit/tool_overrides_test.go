@@ -0,0 +1,157 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// newOverrideFakeCLI writes a fake CLI that acknowledges the initialize
+// request, calls the PreToolUse hook for a Read of go.mod, and once the
+// hook is answered reports the call as denied with reason.
+func newOverrideFakeCLI(t *testing.T, reason string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	write := func(name string, lines ...string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("hook.jsonl",
+		fakeInitLine,
+		fakeToolUseLine("toolu_1", "Read", `{"file_path":"go.mod"}`),
+		fakePreToolUseLine("cli_1", "hook_0", "Read", `{"file_path":"go.mod"}`),
+	)
+	write("result.jsonl",
+		fakeToolResultLine("toolu_1", reason, true),
+		fakeTextLine("done"),
+		fakeResultLine,
+	)
+
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+IFS= read -r line
+printf '%s\n' "$line" >>stdin.jsonl
+id=$(printf '%s\n' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{}}}\n' "$id"
+cat hook.jsonl
+while IFS= read -r line; do
+  printf '%s\n' "$line" >>stdin.jsonl
+  case "$line" in *control_response*) break;; esac
+done
+cat result.jsonl
+cat >>stdin.jsonl
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script
+}
+
+// overriddenResult returns the toolu_1 result among msgs.
+func overriddenResult(t *testing.T, msgs []claudeagent.SDKMessage) claudeagent.ToolResultContentBlock {
+	t.Helper()
+
+	for _, msg := range msgs {
+		user, ok := msg.(*claudeagent.SDKUserMessage)
+		if !ok {
+			continue
+		}
+		for _, block := range user.Message.Content {
+			if result, ok := block.(claudeagent.ToolResultContentBlock); ok && result.ToolUseID == "toolu_1" {
+				return result
+			}
+		}
+	}
+	t.Fatal("expected a result for toolu_1")
+
+	return claudeagent.ToolResultContentBlock{}
+}
+
+func TestToolOverrideServesResult(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		input string
+	)
+	opts, err := claudeagent.NewOptions().
+		WithToolOverride("Read", func(_ context.Context, in claudeagent.JSONValue) (string, error) {
+			mu.Lock()
+			input = string(in)
+			mu.Unlock()
+
+			return "module canned", nil
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	opts.PathToClaudeCodeExecutable = newOverrideFakeCLI(t, "module canned")
+
+	_, msgs := collectFakeSession(t, opts)
+
+	mu.Lock()
+	if !strings.Contains(input, `"file_path":"go.mod"`) {
+		t.Errorf("expected the handler to get the tool input, got %s", input)
+	}
+	mu.Unlock()
+
+	lines := fakeCLIStdin(t, opts.PathToClaudeCodeExecutable, `"cli_1"`, 1)
+	if !strings.Contains(lines[0], `"matcher":"^(Read)$"`) {
+		t.Errorf("expected initialize to register the override hook, got %s", lines[0])
+	}
+	var response string
+	for _, line := range lines {
+		if strings.Contains(line, `"cli_1"`) {
+			response = line
+		}
+	}
+	if !strings.Contains(response, `"permissionDecision":"deny"`) ||
+		!strings.Contains(response, `"permissionDecisionReason":"module canned"`) {
+		t.Errorf("expected the call denied with the served result, got %s", response)
+	}
+
+	result := overriddenResult(t, msgs)
+	if result.IsError || result.Content == nil || result.Content.Text == nil || *result.Content.Text != "module canned" {
+		t.Errorf("expected the served result to be delivered, got %+v", result)
+	}
+}
+
+func TestToolOverrideReportsHandlerError(t *testing.T) {
+	opts := &claudeagent.Options{
+		ToolOverrides: map[string]claudeagent.ToolHandler{
+			"Read": func(context.Context, claudeagent.JSONValue) (string, error) {
+				return "", errors.New("no such file")
+			},
+		},
+	}
+	opts.PathToClaudeCodeExecutable = newOverrideFakeCLI(t, "no such file")
+
+	_, msgs := collectFakeSession(t, opts)
+
+	result := overriddenResult(t, msgs)
+	if !result.IsError || result.Content == nil || result.Content.Text == nil || *result.Content.Text != "no such file" {
+		t.Errorf("expected the handler error as a failed result, got %+v", result)
+	}
+}
+
+func TestToolOverrideValidation(t *testing.T) {
+	opts := &claudeagent.Options{
+		ToolOverrides:   map[string]claudeagent.ToolHandler{"Bash": nil},
+		DisallowedTools: []string{"Bash"},
+	}
+
+	err := opts.Validate()
+	assertValidationField(t, err, "ToolOverrides")
+	if !strings.Contains(err.Error(), "has no handler") || !strings.Contains(err.Error(), "overridden and disallowed") {
+		t.Errorf("expected both override errors, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// gzipResource returns an MCP resource item holding text gzip-compressed.
+func gzipResource(t *testing.T, text string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(text)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return `{"type":"resource","resource":{"uri":"db://rows","mimeType":"application/gzip","blob":"` +
+		base64.StdEncoding.EncodeToString(buf.Bytes()) + `"}}`
+}
+
+// decompressedHookResponse runs a PostToolUse hook for an MCP tool
+// returning response and returns the SDK's answer to it.
+func decompressedHookResponse(t *testing.T, opts *claudeagent.Options, response string) string {
+	t.Helper()
+
+	opts.DecompressToolResults = true
+	opts.PathToClaudeCodeExecutable = newHookFakeCLI(t,
+		fakeInitLine,
+		fakePostToolUseLine("cli_1", "hook_0", "mcp__db__query", response),
+	)
+	client, err := claudeagent.NewClient(opts)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+	if err := client.Query(ctx, "query the db"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	for _, line := range fakeCLIStdin(t, opts.PathToClaudeCodeExecutable, `"cli_1"`, 1) {
+		if strings.Contains(line, `"cli_1"`) {
+			return line
+		}
+	}
+
+	return ""
+}
+
+func TestDecompressToolResultsExpandsGzipResources(t *testing.T) {
+	response := decompressedHookResponse(t, &claudeagent.Options{},
+		`[{"type":"text","text":"rows:"},`+gzipResource(t, "row 1\nrow 2")+`]`)
+
+	if !strings.Contains(response, `"updatedMCPToolOutput":[{"text":"rows:\nrow 1\nrow 2","type":"text"}]`) {
+		t.Errorf("expected the decompressed rows, got %s", response)
+	}
+}
+
+func TestDecompressToolResultsTruncatesExpandedText(t *testing.T) {
+	response := decompressedHookResponse(t, &claudeagent.Options{MaxToolResultBytes: 8},
+		`{"content":[`+gzipResource(t, strings.Repeat("row ", 50))+`]}`)
+
+	if !strings.Contains(response, `"text":"row row \n\n[output truncated: showing 8 of 200 bytes]"`) {
+		t.Errorf("expected the decompressed text truncated, got %s", response)
+	}
+}
+
+func TestDecompressToolResultsBoundsExpandedSize(t *testing.T) {
+	response := decompressedHookResponse(t, &claudeagent.Options{MaxMessageSize: 1024},
+		`[`+gzipResource(t, strings.Repeat("x", 4096))+`]`)
+
+	if !strings.Contains(response, `[compressed tool result could not be read: decompressed result exceeds 1024 bytes]`) {
+		t.Errorf("expected the oversized result replaced with an error, got %s", response)
+	}
+}
+
+func TestDecompressToolResultsLeavesPlainOutput(t *testing.T) {
+	response := decompressedHookResponse(t, &claudeagent.Options{}, `[{"type":"text","text":"plain"}]`)
+
+	if strings.Contains(response, "updatedMCPToolOutput") {
+		t.Errorf("expected plain output left unchanged, got %s", response)
+	}
+}
@@ -0,0 +1,122 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// fakePostToolUseLine returns a hook_callback request for a PostToolUse hook.
+func fakePostToolUseLine(requestID, callbackID, toolName, toolResponse string) string {
+	return `{"type":"control_request","request_id":"` + requestID + `","request":{"subtype":"hook_callback","callback_id":"` +
+		callbackID + `","tool_use_id":"toolu_1","input":{"hook_event_name":"PostToolUse","session_id":"fake-session","transcript_path":"/tmp/t.jsonl","cwd":"/tmp","tool_name":"` +
+		toolName + `","tool_input":{},"tool_response":` + toolResponse + `,"tool_use_id":"toolu_1"}}}`
+}
+
+func TestMaxToolResultBytesTruncatesDeliveredResults(t *testing.T) {
+	long := strings.Repeat("0123456789", 10)
+
+	_, messages := runHookFakeSession(t,
+		&claudeagent.Options{MaxToolResultBytes: 16, SpillToolResults: true},
+		fakeInitLine,
+		fakeToolUseLine("toolu_1", "Bash", `{"command":"cat big.log"}`),
+		fakeToolResultLine("toolu_1", long, false),
+		fakeToolResultLine("toolu_2", "short", false),
+		fakeResultLine,
+	)
+
+	var results []string
+	for _, msg := range messages {
+		user, ok := msg.(*claudeagent.SDKUserMessage)
+		if !ok {
+			continue
+		}
+		for _, block := range user.Message.Content {
+			if result, ok := block.(claudeagent.ToolResultContentBlock); ok && result.Content.Text != nil {
+				results = append(results, *result.Content.Text)
+			}
+		}
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 tool results, got %d", len(results))
+	}
+
+	if !strings.HasPrefix(results[0], long[:16]+"\n\n[output truncated: showing 16 of 100 bytes; full output saved to ") {
+		t.Fatalf("unexpected truncated result %q", results[0])
+	}
+	path := strings.TrimSuffix(results[0][strings.LastIndex(results[0], " ")+1:], "]")
+	t.Cleanup(func() { _ = os.Remove(path) })
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != long {
+		t.Errorf("expected spilled file to hold the full result, got %q (%v)", data, err)
+	}
+	if results[1] != "short" {
+		t.Errorf("expected small result to be untouched, got %q", results[1])
+	}
+}
+
+func TestMaxToolResultBytesKeepsRunesWhole(t *testing.T) {
+	_, messages := runHookFakeSession(t,
+		&claudeagent.Options{MaxToolResultBytes: 5},
+		fakeInitLine,
+		fakeToolResultLine("toolu_1", "ééééé", false),
+		fakeResultLine,
+	)
+
+	for _, msg := range messages {
+		if user, ok := msg.(*claudeagent.SDKUserMessage); ok {
+			result := user.Message.Content[0].(claudeagent.ToolResultContentBlock)
+			if want := "éé\n\n[output truncated: showing 4 of 10 bytes]"; *result.Content.Text != want {
+				t.Errorf("expected %q, got %q", want, *result.Content.Text)
+			}
+
+			return
+		}
+	}
+	t.Fatal("expected a tool result message")
+}
+
+func TestMaxToolResultBytesTruncatesMcpOutput(t *testing.T) {
+	script := newHookFakeCLI(t,
+		fakeInitLine,
+		fakePostToolUseLine("cli_1", "hook_0", "mcp__db__query",
+			`[{"type":"text","text":"`+strings.Repeat("row ", 50)+`"}]`),
+	)
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		MaxToolResultBytes:         32,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "query the db"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	lines := fakeCLIStdin(t, script, `"cli_1"`, 1)
+	if !strings.Contains(lines[0], `mcp__.*`) {
+		t.Errorf("expected initialize to register the MCP output hook, got %s", lines[0])
+	}
+
+	var response string
+	for _, line := range lines {
+		if strings.Contains(line, `"cli_1"`) {
+			response = line
+		}
+	}
+	if !regexp.MustCompile(`"updatedMCPToolOutput":\[\{"text":"(row ){8}\\n\\n\[output truncated: showing 32 of 200 bytes\]","type":"text"\}\]`).
+		MatchString(response) {
+		t.Errorf("expected truncated MCP output, got %s", response)
+	}
+}
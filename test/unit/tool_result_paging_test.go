@@ -0,0 +1,122 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// newPagingFakeCLI writes a fake CLI that acknowledges the initialize
+// request and emits first, then emits then once the SDK has answered the
+// control request with ID after. It records stdin like newHookFakeCLI.
+func newPagingFakeCLI(t *testing.T, first []string, after string, then []string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, lines := range map[string][]string{"first.jsonl": first, "then.jsonl": then} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+printf '%s\n' "$@" >>args.txt
+IFS= read -r line
+printf '%s\n' "$line" >>stdin.jsonl
+id=$(printf '%s\n' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{}}}\n' "$id"
+cat first.jsonl
+while IFS= read -r line; do
+  printf '%s\n' "$line" >>stdin.jsonl
+  case "$line" in *'"` + after + `"'*) cat then.jsonl; break;; esac
+done
+cat >>stdin.jsonl
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script
+}
+
+func TestToolResultPagingServesPages(t *testing.T) {
+	readMore := func(id, handle, offset string) string {
+		return fakeMcpMessageLine(id, claudeagent.PagingServerName,
+			`{"jsonrpc":"2.0","id":"`+id+`","method":"tools/call","params":{"name":"read_more","arguments":{"handle":"`+handle+`","offset":`+offset+`}}}`)
+	}
+	script := newPagingFakeCLI(t,
+		[]string{
+			fakeInitLine,
+			fakePostToolUseLine("cli_1", "hook_0", "mcp__db__query",
+				`[{"type":"text","text":"`+strings.Repeat("row ", 50)+`"}]`),
+		},
+		"cli_1",
+		[]string{readMore("cli_2", "r1", "64"), readMore("cli_3", "r1", "192"), readMore("cli_4", "r9", "0")},
+	)
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		ToolResultPaging:           &claudeagent.ToolResultPaging{PageBytes: 64},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "query the db"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	lines := fakeCLIStdin(t, script, `"cli_4"`, 1)
+	responses := make(map[string]string)
+	for _, line := range lines {
+		for _, id := range []string{"cli_1", "cli_2", "cli_3", "cli_4"} {
+			if strings.Contains(line, `"request_id":"`+id+`"`) {
+				responses[id] = line
+			}
+		}
+	}
+
+	for id, want := range map[string]string{
+		"cli_1": strings.Repeat("row ", 16) + `\n\n[result r1 continues: showing bytes 0-64 of 200; call ` +
+			claudeagent.ReadMoreToolName + ` with handle \"r1\" and offset 64 for the next page]`,
+		"cli_2": strings.Repeat("row ", 16) + `\n\n[result r1 continues: showing bytes 64-128 of 200; call ` +
+			claudeagent.ReadMoreToolName + ` with handle \"r1\" and offset 128 for the next page]`,
+		"cli_3": strings.Repeat("row ", 2) + `\n\n[end of result r1: bytes 192-200 of 200]`,
+		"cli_4": `result \"r9\" is unknown or has expired`,
+	} {
+		if !strings.Contains(responses[id], want) {
+			t.Errorf("expected response %s to contain %q, got %s", id, want, responses[id])
+		}
+	}
+	if !strings.Contains(responses["cli_4"], `"isError":true`) {
+		t.Errorf("expected an unknown handle to be an error result, got %s", responses["cli_4"])
+	}
+
+	args, err := os.ReadFile(filepath.Join(filepath.Dir(script), "args.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(args), "--allowed-tools\n"+claudeagent.ReadMoreToolName+"\n") ||
+		!strings.Contains(string(args), `"`+claudeagent.PagingServerName+`":{"type":"sdk"`) {
+		t.Errorf("expected the paging server to be declared and allowed, got %s", args)
+	}
+}
+
+func TestToolResultPagingValidation(t *testing.T) {
+	_, err := claudeagent.NewOptions().
+		WithToolResultPaging(claudeagent.ToolResultPaging{PageBytes: -1}).
+		Build()
+	if err == nil {
+		t.Error("expected a negative page size to be rejected")
+	}
+}
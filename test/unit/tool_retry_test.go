@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+func TestToolRetryRepeatsFailedCalls(t *testing.T) {
+	var calls atomic.Int32
+	tool := claudeagent.WithToolRetry(claudeagent.Tool("lookup", "Looks up an invoice", nil,
+		func(context.Context, map[string]any) (*claudeagent.McpToolResult, error) {
+			if calls.Add(1) < 3 {
+				return nil, errors.New("billing API unavailable")
+			}
+
+			return claudeagent.TextResult("paid"), nil
+		},
+	), claudeagent.ToolRetry{Delay: time.Millisecond})
+
+	result, err := tool.Execute(context.Background(), map[string]any{})
+	if err != nil || calls.Load() != 3 {
+		t.Fatalf("expected success on the third attempt, got %v after %d calls", err, calls.Load())
+	}
+	if text := result.Content[0].(claudeagent.TextContentBlock).Text; text != "paid" {
+		t.Errorf("expected the successful result, got %q", text)
+	}
+}
+
+func TestToolRetryTimesOutAttempts(t *testing.T) {
+	var calls atomic.Int32
+	tool := claudeagent.WithToolRetry(claudeagent.Tool("slow", "Hangs", nil,
+		func(ctx context.Context, _ map[string]any) (*claudeagent.McpToolResult, error) {
+			calls.Add(1)
+			<-ctx.Done()
+
+			return nil, ctx.Err()
+		},
+	), claudeagent.ToolRetry{MaxAttempts: 2, Delay: time.Millisecond, Timeout: 20 * time.Millisecond})
+
+	_, err := tool.Execute(context.Background(), map[string]any{})
+	var callbackErr *clauderrs.CallbackError
+	if !errors.As(err, &callbackErr) || !callbackErr.Timeout() {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if calls.Load() != 2 || !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Errorf("expected two attempts, got %d: %v", calls.Load(), err)
+	}
+}
+
+func TestToolRetrySkipsErrorsNotRetryable(t *testing.T) {
+	var calls atomic.Int32
+	invalid := errors.New("invoice number is malformed")
+	tool := claudeagent.WithToolRetry(claudeagent.Tool("lookup", "Looks up an invoice", nil,
+		func(context.Context, map[string]any) (*claudeagent.McpToolResult, error) {
+			calls.Add(1)
+
+			return nil, invalid
+		},
+	), claudeagent.ToolRetry{
+		Delay:     time.Millisecond,
+		Retryable: func(err error) bool { return !errors.Is(err, invalid) },
+	})
+
+	if _, err := tool.Execute(context.Background(), map[string]any{}); !errors.Is(err, invalid) || calls.Load() != 1 {
+		t.Errorf("expected one attempt failing with the handler's error, got %v after %d calls", err, calls.Load())
+	}
+}
@@ -0,0 +1,192 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// toolUseMessage builds an assistant message with a tool_use block.
+func toolUseMessage(id, name string) *claudeagent.SDKAssistantMessage {
+	return &claudeagent.SDKAssistantMessage{
+		Message: claudeagent.APIAssistantMessage{
+			Content: []claudeagent.ContentBlock{
+				claudeagent.ToolUseContentBlock{Type: "tool_use", ID: id, Name: name},
+			},
+		},
+	}
+}
+
+// toolResultMessage builds a user message with a tool_result block.
+func toolResultMessage(id string, isError bool) *claudeagent.SDKUserMessage {
+	return &claudeagent.SDKUserMessage{
+		Message: claudeagent.APIUserMessage{
+			Content: []claudeagent.ContentBlock{
+				claudeagent.ToolResultContentBlock{
+					Type:      "tool_result",
+					ToolUseID: id,
+					IsError:   isError,
+				},
+			},
+		},
+	}
+}
+
+// TestToolStatsCollectorPercentiles verifies latency percentiles from the
+// collector's hooks and failure rates from the stream, using a controlled
+// clock.
+func TestToolStatsCollectorPercentiles(t *testing.T) {
+	now := time.Unix(0, 0)
+	collector := claudeagent.NewToolStatsCollector().
+		WithClock(func() time.Time { return now })
+	hooks := collector.Hooks()
+	pre := hooks[claudeagent.HookEventPreToolUse][0].Hooks[0]
+	post := hooks[claudeagent.HookEventPostToolUse][0].Hooks[0]
+	ctx := context.Background()
+
+	for i := 1; i <= 20; i++ {
+		id := "tool-" + string(rune('a'+i))
+		collector.Observe(toolUseMessage(id, "WebFetch"))
+		_, _ = pre(ctx, claudeagent.PreToolUseHookInput{ToolName: "WebFetch", ToolUseID: id}, &id)
+		now = now.Add(time.Duration(i) * time.Millisecond)
+		// Failed calls skip PostToolUse and aren't timed
+		if i%5 != 0 {
+			_, _ = post(ctx, claudeagent.PostToolUseHookInput{ToolName: "WebFetch", ToolUseID: id}, &id)
+		}
+		// Reading the result later doesn't change the latency
+		now = now.Add(time.Second)
+		collector.Observe(toolResultMessage(id, i%5 == 0))
+	}
+
+	collector.Observe(toolUseMessage("untimed", "Grep"))
+	collector.Observe(toolResultMessage("untimed", false))
+	collector.Observe(toolUseMessage("pending", "Read"))
+
+	stats := collector.Stats()
+
+	fetch := stats["WebFetch"]
+	if fetch.Count != 20 {
+		t.Errorf("Count = %d, want 20", fetch.Count)
+	}
+	if fetch.Failures != 4 {
+		t.Errorf("Failures = %d, want 4", fetch.Failures)
+	}
+	if fetch.FailureRate != 0.2 {
+		t.Errorf("FailureRate = %v, want 0.2", fetch.FailureRate)
+	}
+	if fetch.P50 != 9*time.Millisecond {
+		t.Errorf("P50 = %v, want 9ms", fetch.P50)
+	}
+	if fetch.P95 != 19*time.Millisecond {
+		t.Errorf("P95 = %v, want 19ms", fetch.P95)
+	}
+	if fetch.Max != 19*time.Millisecond {
+		t.Errorf("Max = %v, want 19ms", fetch.Max)
+	}
+
+	if grep := stats["Grep"]; grep.Count != 1 || grep.Max != 0 {
+		t.Errorf("Grep stats = %+v, want one untimed call", grep)
+	}
+
+	read := stats["Read"]
+	if read.InFlight != 1 || read.Count != 0 {
+		t.Errorf("Read stats = %+v, want one in-flight call", read)
+	}
+}
+
+// TestToolStatsCollectorIgnoresUnknownResults verifies unmatched results are
+// not counted.
+func TestToolStatsCollectorIgnoresUnknownResults(t *testing.T) {
+	collector := claudeagent.NewToolStatsCollector()
+	collector.Observe(toolResultMessage("missing", true))
+
+	if len(collector.Stats()) != 0 {
+		t.Error("expected no stats for unmatched tool result")
+	}
+}
+
+// TestClientToolStats verifies the client tracks tools from its stream.
+func TestClientToolStats(t *testing.T) {
+	client, _ := runFakeSession(t, nil,
+		fakeInitLine,
+		fakeToolUseLine("toolu_1", "Bash", `{"command":"ls"}`),
+		fakeToolResultLine("toolu_1", "file.txt", false),
+		fakeToolUseLine("toolu_2", "Bash", `{"command":"false"}`),
+		fakeToolResultLine("toolu_2", "exit 1", true),
+		fakeResultLine,
+	)
+
+	bash := client.ToolStats()["Bash"]
+	if bash.Count != 2 || bash.Failures != 1 {
+		t.Errorf("Bash stats = %+v, want 2 calls with 1 failure", bash)
+	}
+}
+
+// newTimedToolFakeCLI writes a fake CLI that acknowledges the initialize
+// request and runs one Bash call the way the CLI does: its PreToolUse
+// hooks, the tool for delay, its PostToolUse hooks, then the messages,
+// waiting for the SDK's answer to each hook.
+func newTimedToolFakeCLI(t *testing.T, delay string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	// Callback IDs follow map order, so each hook is sent to both
+	stages := [][]string{
+		{fakeInitLine, fakePreToolUseLine("cli_1", "hook_0", "Bash", `{}`), fakePreToolUseLine("cli_2", "hook_1", "Bash", `{}`)},
+		{fakePostToolUseLine("cli_3", "hook_0", "Bash", `{}`), fakePostToolUseLine("cli_4", "hook_1", "Bash", `{}`)},
+		{
+			fakeToolUseLine("toolu_1", "Bash", `{"command":"sleep"}`),
+			fakeToolResultLine("toolu_1", "done", false),
+			fakeResultLine,
+		},
+	}
+	for i, lines := range stages {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("stdout-%d.jsonl", i)), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatalf("failed to write fake CLI output: %v", err)
+		}
+	}
+
+	wait := `n=0
+while [ $n -lt 2 ] && IFS= read -r line; do
+  printf '%s\n' "$line" >>stdin.jsonl
+  case "$line" in *control_response*) n=$((n+1));; esac
+done
+`
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+IFS= read -r line
+printf '%s\n' "$line" >>stdin.jsonl
+id=$(printf '%s\n' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{}}}\n' "$id"
+cat stdout-0.jsonl
+` + wait + `sleep ` + delay + `
+cat stdout-1.jsonl
+` + wait + `cat stdout-2.jsonl
+cat >>stdin.jsonl
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatalf("failed to write fake CLI script: %v", err)
+	}
+
+	return script
+}
+
+// TestClientToolTiming verifies ToolTiming times calls between their hooks.
+func TestClientToolTiming(t *testing.T) {
+	client, _ := collectFakeSession(t, &claudeagent.Options{
+		ToolTiming:                 true,
+		PathToClaudeCodeExecutable: newTimedToolFakeCLI(t, "0.2"),
+	})
+
+	bash := client.ToolStats()["Bash"]
+	if bash.Count != 1 || bash.P50 < 200*time.Millisecond || bash.P50 > fakeCLITimeout {
+		t.Errorf("Bash stats = %+v, want one call timed at about 200ms", bash)
+	}
+}
@@ -0,0 +1,53 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClientToolUseCorrelation(t *testing.T) {
+	subagentUse := strings.Replace(
+		fakeToolUseLine("toolu_2", "Grep", `{"pattern":"TODO"}`),
+		`"session_id":"fake-session",`,
+		`"session_id":"fake-session","parent_tool_use_id":"toolu_1",`,
+		1,
+	)
+	denied := fakeToolUseLine("toolu_3", "Bash", `{"command":"rm -rf /"}`)
+	result := strings.Replace(
+		fakeResultLine,
+		`"result":"done"`,
+		`"result":"done","permission_denials":[{"tool_name":"Bash","tool_use_id":"toolu_3","tool_input":{"command":"rm -rf /"}}]`,
+		1,
+	)
+
+	client, _ := runFakeSession(t, nil,
+		fakeInitLine,
+		fakeToolUseLine("toolu_1", "Task", `{"prompt":"find todos"}`),
+		subagentUse,
+		fakeToolResultLine("toolu_2", "3 matches", false),
+		denied,
+		result,
+	)
+
+	task, ok := client.ToolUse("toolu_1")
+	if !ok || task.Name != "Task" || string(task.Input) != `{"prompt":"find todos"}` || task.Done() {
+		t.Errorf("expected a running Task tool use, got %+v", task)
+	}
+
+	grep, ok := client.ToolUse("toolu_2")
+	if !ok || grep.ParentToolUseID != "toolu_1" || !grep.Done() || grep.Duration() < 0 {
+		t.Errorf("expected a completed subagent Grep tool use, got %+v", grep)
+	}
+	if grep.Result == nil || grep.Result.Content == nil || *grep.Result.Content.Text != "3 matches" {
+		t.Errorf("expected the tool result to be recorded, got %+v", grep.Result)
+	}
+
+	bash, ok := client.ToolUse("toolu_3")
+	if !ok || !bash.Denied || bash.Done() {
+		t.Errorf("expected a denied Bash tool use, got %+v", bash)
+	}
+
+	if _, ok := client.ToolUse("toolu_missing"); ok {
+		t.Error("expected no record for an unknown tool_use_id")
+	}
+}
@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// decodeTranscript decodes JSON lines into messages.
+func decodeTranscript(t *testing.T, lines ...string) []claudeagent.SDKMessage {
+	t.Helper()
+
+	var msgs []claudeagent.SDKMessage
+	for _, line := range lines {
+		msg, err := claudeagent.DecodeMessage([]byte(line))
+		if err != nil {
+			t.Fatalf("DecodeMessage failed: %v", err)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+func TestDiffTranscripts(t *testing.T) {
+	a := decodeTranscript(t,
+		fakeInitLine,
+		fakeToolUseLine("toolu_1", "Read", `{"file_path":"a.go","limit":10}`),
+		fakeToolUseLine("toolu_2", "Grep", `{"pattern":"x"}`),
+		fakeTextLine(`The bug is in a.go.\nFix the loop.`),
+		fakeResultLine,
+	)
+	b := decodeTranscript(t,
+		fakeInitLine,
+		fakeToolUseLine("toolu_1", "Read", `{"limit": 10, "file_path": "a.go"}`),
+		fakeToolUseLine("toolu_2", "Bash", `{"command":"go test"}`),
+		fakeToolUseLine("toolu_3", "Grep", `{"pattern":"y"}`),
+		fakeTextLine(`The bug is in a.go.\nFix the bounds check.`),
+		strings.Replace(fakeResultLine, `"total_cost_usd":0.01`, `"total_cost_usd":0.025`, 1),
+	)
+
+	diff := claudeagent.DiffTranscripts(a, b)
+
+	wantText := []claudeagent.TextLineDiff{
+		{Op: claudeagent.DiffEqual, Text: "The bug is in a.go."},
+		{Op: claudeagent.DiffRemoved, Text: "Fix the loop."},
+		{Op: claudeagent.DiffAdded, Text: "Fix the bounds check."},
+	}
+	if len(diff.Text) != len(wantText) {
+		t.Fatalf("text diff = %+v, want %+v", diff.Text, wantText)
+	}
+	for i, want := range wantText {
+		if diff.Text[i] != want {
+			t.Errorf("text line %d = %+v, want %+v", i, diff.Text[i], want)
+		}
+	}
+
+	wantCalls := []struct {
+		op   claudeagent.DiffOp
+		name string
+	}{
+		{claudeagent.DiffEqual, "Read"}, // Key order and spacing are ignored
+		{claudeagent.DiffAdded, "Bash"},
+		{claudeagent.DiffChanged, "Grep"},
+	}
+	if len(diff.ToolCalls) != len(wantCalls) {
+		t.Fatalf("tool call diff = %+v, want %+v", diff.ToolCalls, wantCalls)
+	}
+	for i, want := range wantCalls {
+		if got := diff.ToolCalls[i]; got.Op != want.op || got.Name != want.name {
+			t.Errorf("tool call %d = %s %s, want %s %s", i, got.Op, got.Name, want.op, want.name)
+		}
+	}
+
+	if diff.A.Cost != 10_000_000 || diff.B.Cost != 25_000_000 || diff.CostDelta() != 15_000_000 {
+		t.Errorf("costs = %s and %s, delta %s", diff.A.Cost, diff.B.Cost, diff.CostDelta())
+	}
+	if diff.A.ToolCalls != 2 || diff.B.ToolCalls != 3 {
+		t.Errorf("tool call counts = %d and %d, want 2 and 3", diff.A.ToolCalls, diff.B.ToolCalls)
+	}
+	if diff.Equal() {
+		t.Error("Equal reported differing transcripts as equal")
+	}
+	rendered := diff.String()
+	for _, want := range []string{"- Fix the loop.", "+ Fix the bounds check.", "+ Bash", "~ Grep", "cost: $0.01 -> $0.025 (+$0.015)"} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("String() is missing %q:\n%s", want, rendered)
+		}
+	}
+
+	if same := claudeagent.DiffTranscripts(a, a); !same.Equal() || same.CostDelta() != 0 {
+		t.Errorf("a transcript differs from itself:\n%s", same)
+	}
+}
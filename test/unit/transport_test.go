@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/connerohnesorge/claude-agent-sdk-go/internal/transport"
+)
+
+// nopWriteCloser adapts an io.Writer to io.WriteCloser for transport tests.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// newTestTransport builds a StdioTransport that reads the given stdout data.
+func newTestTransport(stdout string) *transport.StdioTransport {
+	return transport.NewStdioTransport(
+		nopWriteCloser{io.Discard},
+		io.NopCloser(strings.NewReader(stdout)),
+		io.NopCloser(strings.NewReader("")),
+	)
+}
+
+// TestStdioTransportReassemblesLargeMessage verifies messages larger than
+// the read buffer are returned intact.
+func TestStdioTransportReassemblesLargeMessage(t *testing.T) {
+	payload := `{"type":"user","text":"` + strings.Repeat("x", 512*1024) + `"}`
+	tr := newTestTransport(payload + "\n")
+
+	data, err := tr.Read(context.Background())
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if got := strings.TrimSpace(string(data)); got != payload {
+		t.Errorf("expected %d bytes, got %d", len(payload), len(got))
+	}
+}
+
+// TestStdioTransportMessageTooLarge verifies oversized messages are rejected
+// and the reader resynchronizes on the next frame.
+func TestStdioTransportMessageTooLarge(t *testing.T) {
+	oversized := strings.Repeat("a", 200*1024)
+	tr := newTestTransport(oversized + "\n" + `{"ok":true}` + "\n").
+		WithMaxMessageSize(1024)
+
+	_, err := tr.Read(context.Background())
+	if !errors.Is(err, transport.ErrMessageTooLarge) {
+		t.Fatalf("expected ErrMessageTooLarge, got %v", err)
+	}
+
+	data, err := tr.Read(context.Background())
+	if err != nil {
+		t.Fatalf("Read after oversized message failed: %v", err)
+	}
+
+	if got := strings.TrimSpace(string(data)); got != `{"ok":true}` {
+		t.Errorf("expected next frame, got %q", got)
+	}
+}
+
+// TestStdioTransportSkipsBlankLines verifies empty frames are ignored.
+func TestStdioTransportSkipsBlankLines(t *testing.T) {
+	tr := newTestTransport("\n  \n{\"a\":1}\n")
+
+	data, err := tr.Read(context.Background())
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if got := strings.TrimSpace(string(data)); got != `{"a":1}` {
+		t.Errorf("expected frame, got %q", got)
+	}
+}
+
+// TestStdioTransportUnterminatedFinalFrame verifies a trailing frame without
+// a newline is delivered before EOF.
+func TestStdioTransportUnterminatedFinalFrame(t *testing.T) {
+	tr := newTestTransport(`{"a":1}`)
+
+	data, err := tr.Read(context.Background())
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if string(data) != `{"a":1}` {
+		t.Errorf("expected frame, got %q", data)
+	}
+
+	if _, err := tr.Read(context.Background()); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+// TestStdioTransportCloseAfterExit verifies Close succeeds when the pipes
+// were already closed, as exec.Cmd.Wait does once the CLI exits.
+func TestStdioTransportCloseAfterExit(t *testing.T) {
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []*os.File{stdinR, stderrW, stderrR} {
+		_ = f.Close()
+	}
+
+	tr := transport.NewStdioTransport(stdinW, io.NopCloser(strings.NewReader("")), stderrR)
+	if err := tr.Close(); err != nil {
+		t.Errorf("Close after the pipes closed = %v, want nil", err)
+	}
+}
+
+// TestProcessCloseAfterExit verifies Close succeeds once the CLI has exited
+// on its own, as it does when its session ends before the client closes.
+func TestProcessCloseAfterExit(t *testing.T) {
+	executable, err := exec.LookPath("true")
+	if err != nil {
+		t.Skip("true not found")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	process, err := transport.NewProcess(ctx, &transport.ProcessConfig{Executable: executable})
+	if err != nil {
+		t.Fatalf("NewProcess failed: %v", err)
+	}
+	if err := process.Wait(ctx); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if err := process.Close(); err != nil {
+		t.Errorf("Close after exit = %v, want nil", err)
+	}
+}
@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+)
+
+// logLines returns n numbered log lines as one text block.
+func logLines(n int) claudeagent.TextContentBlock {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line %d: request handled\n", i)
+	}
+
+	return claudeagent.TextContentBlock{Type: "text", Text: b.String()}
+}
+
+// blockText joins the text of text blocks.
+func blockText(blocks []claudeagent.ContentBlock) string {
+	var texts []string
+	for _, block := range blocks {
+		if text, ok := block.(claudeagent.TextContentBlock); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+
+	return strings.Join(texts, "|")
+}
+
+func TestTruncateBlocksStrategies(t *testing.T) {
+	blocks := []claudeagent.ContentBlock{logLines(1000)}
+	const maxTokens = 500
+
+	tests := []struct {
+		strategy claudeagent.TruncationStrategy
+		keep     []string
+		drop     []string
+	}{
+		{claudeagent.TruncateHead, []string{"line 1:"}, []string{"line 1000:"}},
+		{claudeagent.TruncateTail, []string{"line 1000:"}, []string{"line 1:"}},
+		{claudeagent.TruncateMiddleOut, []string{"line 1:", "line 1000:"}, []string{"line 500:"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			got, err := claudeagent.TruncateBlocks(blocks, maxTokens, tt.strategy)
+			if err != nil {
+				t.Fatalf("TruncateBlocks failed: %v", err)
+			}
+
+			if tokens := claudeagent.EstimateBlockTokens("", got); tokens > maxTokens {
+				t.Errorf("expected at most %d tokens, got %d", maxTokens, tokens)
+			}
+			text := blockText(got)
+			if !strings.Contains(text, "tokens truncated ...]") {
+				t.Errorf("expected truncation marker, got %q", text)
+			}
+			for _, s := range tt.keep {
+				if !strings.Contains(text, s+" request handled\n") {
+					t.Errorf("expected %q to be kept whole", s)
+				}
+			}
+			for _, s := range tt.drop {
+				if strings.Contains(text, s) {
+					t.Errorf("expected %q to be dropped", s)
+				}
+			}
+		})
+	}
+}
+
+func TestTruncateBlocksKeepsImagesWhole(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 300, 250))); err != nil {
+		t.Fatal(err)
+	}
+	// About 100 tokens
+	picture := claudeagent.ImageContentBlock{Type: "image", Source: claudeagent.ImageSource{
+		Type:      "base64",
+		MediaType: "image/png",
+		Data:      base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}}
+	blocks := []claudeagent.ContentBlock{picture, logLines(1000)}
+
+	got, err := claudeagent.TruncateBlocks(blocks, 300, claudeagent.TruncateHead)
+	if err != nil {
+		t.Fatalf("TruncateBlocks failed: %v", err)
+	}
+	if _, ok := got[0].(claudeagent.ImageContentBlock); !ok {
+		t.Errorf("expected the image to be kept, got %T", got[0])
+	}
+	if !strings.HasPrefix(blockText(got), "line 1:") {
+		t.Errorf("expected the text to follow the image, got %q", blockText(got))
+	}
+
+	got, err = claudeagent.TruncateBlocks(blocks, 50, claudeagent.TruncateHead)
+	if err != nil {
+		t.Fatalf("TruncateBlocks failed: %v", err)
+	}
+	for _, block := range got {
+		if _, ok := block.(claudeagent.ImageContentBlock); ok {
+			t.Error("expected the image to be dropped when it doesn't fit")
+		}
+	}
+}
+
+func TestTruncateBlocksWithinLimit(t *testing.T) {
+	blocks := []claudeagent.ContentBlock{logLines(3)}
+
+	got, err := claudeagent.TruncateBlocks(blocks, 1000, claudeagent.TruncateMiddleOut)
+	if err != nil {
+		t.Fatalf("TruncateBlocks failed: %v", err)
+	}
+	if blockText(got) != blockText(blocks) {
+		t.Errorf("expected blocks unchanged, got %q", blockText(got))
+	}
+}
+
+func TestTruncateBlocksRejectsInvalidArguments(t *testing.T) {
+	blocks := []claudeagent.ContentBlock{logLines(3)}
+
+	if _, err := claudeagent.TruncateBlocks(blocks, 100, "sideways"); !clauderrs.IsValidationError(err) {
+		t.Errorf("expected ValidationError for unknown strategy, got %v", err)
+	}
+	if _, err := claudeagent.TruncateBlocks(blocks, 0, claudeagent.TruncateHead); !clauderrs.IsValidationError(err) {
+		t.Errorf("expected ValidationError for zero maxTokens, got %v", err)
+	}
+}
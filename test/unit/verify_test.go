@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/clauderrs"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/verify"
+)
+
+// postEditInput returns a PostToolUse hook input for an Edit in dir.
+func postEditInput(dir string) claudeagent.PostToolUseHookInput {
+	return claudeagent.PostToolUseHookInput{
+		BaseHookInput: claudeagent.BaseHookInput{SessionIDField: "fake-session", CwdField: dir},
+		HookEventName: claudeagent.HookEventPostToolUse,
+		ToolName:      "Edit",
+		ToolInput:     []byte(`{"file_path":"main.go","old_string":"a","new_string":"b"}`),
+		ToolUseID:     "toolu_1",
+	}
+}
+
+// runVerifyHook runs the hook of a Verifier configured with config.
+func runVerifyHook(t *testing.T, config verify.Config) claudeagent.SyncHookOutput {
+	t.Helper()
+
+	v, err := verify.New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	output, err := v.Hook(context.Background(), postEditInput(t.TempDir()), nil)
+	if err != nil {
+		t.Fatalf("Hook failed: %v", err)
+	}
+
+	return output.(claudeagent.SyncHookOutput)
+}
+
+func TestVerifyFeedsBackFailures(t *testing.T) {
+	output := runVerifyHook(t, verify.Config{
+		Command: []string{"sh", "-c", "echo ok; echo 'main.go:3:2: undefined: x' >&2; exit 2"},
+	})
+
+	if output.Decision == nil || *output.Decision != claudeagent.HookDecisionBlock {
+		t.Fatalf("expected block decision, got %+v", output)
+	}
+	for _, want := range []string{"exit code 2", "after the Edit call", "main.go:3:2: undefined: x"} {
+		if !strings.Contains(*output.Reason, want) {
+			t.Errorf("expected reason to contain %q, got %q", want, *output.Reason)
+		}
+	}
+}
+
+func TestVerifyPassesQuietly(t *testing.T) {
+	output := runVerifyHook(t, verify.Config{Command: []string{"sh", "-c", "pwd"}})
+
+	if output.Decision != nil || output.Reason != nil {
+		t.Errorf("expected no decision for a passing run, got %+v", output)
+	}
+}
+
+func TestVerifyRunsInSessionDirectoryAndTruncates(t *testing.T) {
+	var gotDir string
+	output := runVerifyHook(t, verify.Config{
+		Command:   []string{"go", "build"},
+		MaxOutput: 25,
+		Runner: verify.RunnerFunc(func(_ context.Context, dir string, _ []string) (*verify.Result, error) {
+			gotDir = dir
+
+			return &verify.Result{ExitCode: 1, Output: []byte("first line\nsecond line\nlast line\n")}, nil
+		}),
+	})
+
+	if gotDir == "" {
+		t.Error("expected the command to run in the session's working directory")
+	}
+	if !strings.HasSuffix(*output.Reason, "second line\nlast line") || strings.Contains(*output.Reason, "first line") {
+		t.Errorf("expected only the end of the output, got %q", *output.Reason)
+	}
+}
+
+func TestVerifyReportsTimeouts(t *testing.T) {
+	output := runVerifyHook(t, verify.Config{
+		Command: []string{"go", "test"},
+		Timeout: 20 * time.Millisecond,
+		Runner: verify.RunnerFunc(func(ctx context.Context, _ string, _ []string) (*verify.Result, error) {
+			<-ctx.Done()
+
+			return &verify.Result{ExitCode: -1}, nil
+		}),
+	})
+
+	if output.Reason == nil || !strings.Contains(*output.Reason, "timed out after 20ms") {
+		t.Errorf("expected timeout reason, got %+v", output)
+	}
+}
+
+func TestVerifyConfig(t *testing.T) {
+	if _, err := verify.New(verify.Config{}); !clauderrs.IsValidationError(err) {
+		t.Errorf("expected ValidationError without a command, got %v", err)
+	}
+
+	v, err := verify.New(verify.Config{Command: []string{"make"}, Timeout: time.Minute})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	matcher := v.Matcher()
+	re := regexp.MustCompile(*matcher.Matcher)
+	for tool, want := range map[string]bool{"Edit": true, "Write": true, "MultiEdit": true, "Read": false, "Bash": false} {
+		if re.MatchString(tool) != want {
+			t.Errorf("matcher on %s = %v, want %v", tool, !want, want)
+		}
+	}
+	if matcher.Timeout == nil || *matcher.Timeout <= int(time.Minute.Milliseconds()) {
+		t.Errorf("expected hook timeout beyond the run timeout, got %v", matcher.Timeout)
+	}
+}
+
+func TestHookMatcherTimeoutSentInSeconds(t *testing.T) {
+	timeout := 90_500
+	script := newHookFakeCLI(t, fakeInitLine, fakeResultLine)
+	collectFakeSession(t, &claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		Hooks: map[claudeagent.HookEvent][]claudeagent.HookCallbackMatcher{
+			claudeagent.HookEventPostToolUse: {{
+				Hooks: []claudeagent.HookCallback{
+					func(context.Context, claudeagent.HookInput, *string) (claudeagent.HookJSONOutput, error) {
+						return claudeagent.SyncHookOutput{}, nil
+					},
+				},
+				Timeout: &timeout,
+			}},
+		},
+	})
+
+	lines := fakeCLIStdin(t, script, `"initialize"`, 1)
+	if !strings.Contains(lines[0], `"timeout":91`) {
+		t.Errorf("expected the timeout rounded up to 91 seconds, got %s", lines[0])
+	}
+}
@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+// recordingVisitor records the kind of each visited block.
+type recordingVisitor struct {
+	claudeagent.NopBlockVisitor
+	visited []string
+	stopAt  string
+}
+
+func (v *recordingVisitor) record(kind string) error {
+	v.visited = append(v.visited, kind)
+	if kind == v.stopAt {
+		return claudeagent.SkipBlocks
+	}
+
+	return nil
+}
+
+func (v *recordingVisitor) VisitText(block claudeagent.TextContentBlock) error {
+	return v.record("text:" + block.Text)
+}
+
+func (v *recordingVisitor) VisitToolUse(block claudeagent.ToolUseContentBlock) error {
+	return v.record("tool_use:" + block.Name)
+}
+
+func (v *recordingVisitor) VisitToolResult(block claudeagent.ToolResultContentBlock) error {
+	return v.record("tool_result:" + block.ToolUseID)
+}
+
+func (v *recordingVisitor) VisitThinking(block claudeagent.ThinkingBlock) error {
+	return v.record("thinking:" + block.Thinking)
+}
+
+func (v *recordingVisitor) VisitImage(block claudeagent.ImageContentBlock) error {
+	return v.record("image:" + block.Source.MediaType)
+}
+
+func (v *recordingVisitor) VisitOther(block claudeagent.ContentBlock) error {
+	if _, ok := block.(claudeagent.RedactedThinkingBlock); ok {
+		return v.record("redacted")
+	}
+
+	return v.record("other")
+}
+
+func TestWalkBlocksVisitsInOrder(t *testing.T) {
+	assistant, err := claudeagent.DecodeMessage([]byte(`{"type":"assistant","uuid":"00000000-0000-0000-0000-000000000002","session_id":"s","message":{"id":"msg_1","type":"message","role":"assistant","model":"m","content":[` +
+		`{"type":"thinking","thinking":"hmm","signature":"sig"},{"type":"redacted_thinking","data":"abc"},` +
+		`{"type":"text","text":"let me look"},{"type":"tool_use","id":"toolu_1","name":"Read","input":{}}],"usage":{"input_tokens":1,"output_tokens":1}}}`))
+	if err != nil {
+		t.Fatalf("DecodeMessage failed: %v", err)
+	}
+	user, err := claudeagent.DecodeMessage([]byte(`{"type":"user","uuid":"00000000-0000-0000-0000-000000000003","session_id":"s","message":{"role":"user","content":[` +
+		`{"type":"tool_result","tool_use_id":"toolu_1","content":[{"type":"text","text":"file body"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AA=="}}]},` +
+		`{"type":"text","text":"thanks"}]}}`))
+	if err != nil {
+		t.Fatalf("DecodeMessage failed: %v", err)
+	}
+
+	visitor := &recordingVisitor{}
+	for _, msg := range []claudeagent.SDKMessage{assistant, user, &claudeagent.SDKResultMessage{}} {
+		if err := claudeagent.WalkBlocks(msg, visitor); err != nil {
+			t.Fatalf("WalkBlocks failed: %v", err)
+		}
+	}
+
+	want := "thinking:hmm,redacted,text:let me look,tool_use:Read," +
+		"tool_result:toolu_1,text:file body,image:image/png,text:thanks"
+	if got := strings.Join(visitor.visited, ","); got != want {
+		t.Errorf("unexpected visit order:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestWalkContentStops(t *testing.T) {
+	content := []claudeagent.ContentBlock{
+		claudeagent.TextBlock{Type: "text", Text: "one"},
+		claudeagent.TextContentBlock{Type: "text", Text: "two"},
+		claudeagent.TextContentBlock{Type: "text", Text: "three"},
+	}
+
+	visitor := &recordingVisitor{stopAt: "text:two"}
+	if err := claudeagent.WalkContent(content, visitor); err != nil {
+		t.Fatalf("SkipBlocks should stop without an error, got %v", err)
+	}
+	if got := strings.Join(visitor.visited, ","); got != "text:one,text:two" {
+		t.Errorf("expected the walk to stop at the second block, got %s", got)
+	}
+
+	boom := errors.New("boom")
+	err := claudeagent.WalkContent(content, &failingVisitor{err: boom})
+	if !errors.Is(err, boom) {
+		t.Errorf("expected the visitor's error, got %v", err)
+	}
+}
+
+// failingVisitor fails on the first text block.
+type failingVisitor struct {
+	claudeagent.NopBlockVisitor
+	err error
+}
+
+func (v *failingVisitor) VisitText(claudeagent.TextContentBlock) error {
+	return v.err
+}
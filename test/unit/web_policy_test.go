@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+)
+
+func TestWebPolicyCheckToolInput(t *testing.T) {
+	policy := &claudeagent.WebPolicy{
+		AllowedDomains: []string{"go.dev", "*.github.com"},
+		BlockedDomains: []string{"gist.github.com"},
+	}
+
+	tests := []struct {
+		tool    string
+		input   string
+		allowed bool
+	}{
+		{"WebFetch", `{"url":"https://go.dev/doc"}`, true},
+		{"WebFetch", `{"url":"https://pkg.go.dev/net/url"}`, true},
+		{"WebFetch", `{"url":"https://api.github.com/repos"}`, true},
+		{"WebFetch", `{"url":"https://github.com/"}`, false},
+		{"WebFetch", `{"url":"https://gist.github.com/x"}`, false},
+		{"WebFetch", `{"url":"https://evil.example/?q=go.dev"}`, false},
+		{"WebFetch", `{"url":"file:///etc/passwd"}`, false},
+		{"WebSearch", `{"query":"generics","allowed_domains":["go.dev"]}`, true},
+		{"WebSearch", `{"query":"generics"}`, false},
+		{"WebSearch", `{"query":"generics","allowed_domains":["example.com"]}`, false},
+		{"Read", `{"file_path":"/tmp/x"}`, true},
+	}
+
+	for _, tt := range tests {
+		reason, allowed := policy.CheckToolInput(tt.tool, claudeagent.JSONValue(tt.input))
+		if allowed != tt.allowed {
+			t.Errorf("%s %s: expected allowed=%v, got %v (%s)", tt.tool, tt.input, tt.allowed, allowed, reason)
+		}
+		if !allowed && !strings.Contains(tt.input, "file:") && !strings.Contains(reason, "go.dev") {
+			t.Errorf("%s %s: expected reason to list permitted domains, got %q", tt.tool, tt.input, reason)
+		}
+	}
+}
+
+func TestWebPolicyBlockedOnlySearch(t *testing.T) {
+	policy := &claudeagent.WebPolicy{BlockedDomains: []string{"example.com"}}
+
+	if reason, ok := policy.CheckToolInput("WebSearch", claudeagent.JSONValue(`{"query":"x"}`)); ok ||
+		!strings.Contains(reason, "blocked_domains") {
+		t.Errorf("expected search without blocked_domains to be denied, got %v %q", ok, reason)
+	}
+	if _, ok := policy.CheckToolInput("WebSearch", claudeagent.JSONValue(`{"query":"x","blocked_domains":["Example.com"]}`)); !ok {
+		t.Error("expected search excluding blocked domains to be allowed")
+	}
+	if _, ok := policy.CheckToolInput("WebFetch", claudeagent.JSONValue(`{"url":"https://www.example.com"}`)); ok {
+		t.Error("expected blocked subdomain to be denied")
+	}
+}
+
+func TestWebPolicyRegistersPreToolUseHook(t *testing.T) {
+	script := newHookFakeCLI(t,
+		fakeInitLine,
+		fakePreToolUseLine("cli_1", "hook_0", "WebFetch", `{"url":"https://evil.example/","prompt":"p"}`),
+	)
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: script,
+		WebPolicy:                  &claudeagent.WebPolicy{AllowedDomains: []string{"go.dev"}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	if err := client.Query(ctx, "fetch something"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	lines := fakeCLIStdin(t, script, `"cli_1"`, 1)
+	if !strings.Contains(lines[0], `"subtype":"initialize"`) || !strings.Contains(lines[0], `WebFetch|WebSearch`) {
+		t.Errorf("expected initialize request registering the web policy hook, got %s", lines[0])
+	}
+
+	var response string
+	for _, line := range lines {
+		if strings.Contains(line, `"cli_1"`) {
+			response = line
+		}
+	}
+	if !strings.Contains(response, `"permissionDecision":"deny"`) {
+		t.Errorf("expected deny decision, got %s", response)
+	}
+	if !strings.Contains(response, "permitted domains: go.dev") {
+		t.Errorf("expected deny reason to list permitted domains, got %s", response)
+	}
+}
@@ -0,0 +1,158 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/worker"
+)
+
+// newFlakyFakeCLI returns a fake CLI that exits without a result on its
+// first failures runs and completes the turn afterwards.
+func newFlakyFakeCLI(t *testing.T, failures int, resultLine string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ok.jsonl"), []byte(fakeInitLine+"\n"+resultLine+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "claude")
+	body := `#!/bin/sh
+cd '` + dir + `'
+echo run >>runs.txt
+if [ "$(wc -l <runs.txt)" -le ` + strconv.Itoa(failures) + ` ]; then
+  echo '` + fakeInitLine + `'
+  exit 1
+fi
+cat ok.jsonl
+cat >/dev/null
+`
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return script
+}
+
+// runPool runs pool until the task reaches a final status and returns its
+// job.
+func runPool(t *testing.T, pool *worker.Pool, task worker.AgentTask) *worker.Job {
+	t.Helper()
+
+	done := make(chan struct{})
+	var once sync.Once
+	pool.OnProgress = func(p worker.Progress) {
+		if p.Status == worker.StatusSucceeded || p.Status == worker.StatusFailed {
+			once.Do(func() { close(done) })
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	id, err := pool.Submit(ctx, task)
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	runCtx, stop := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		_ = pool.Run(runCtx)
+		close(stopped)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for task")
+	}
+	stop()
+	<-stopped
+
+	job, err := pool.Store.Load(ctx, id)
+	if err != nil || job == nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	return job
+}
+
+func TestWorkerPoolRunsTask(t *testing.T) {
+	script := newFakeCLI(t, fakeInitLine, fakeResultLine)
+
+	job := runPool(t, &worker.Pool{Store: worker.NewMemoryStore()}, worker.AgentTask{
+		Prompt:  "summarize",
+		Options: &claudeagent.Options{PathToClaudeCodeExecutable: script},
+		Tags:    []string{"nightly"},
+	})
+
+	if job.Status != worker.StatusSucceeded || job.Attempts != 1 {
+		t.Fatalf("expected one successful attempt, got %s after %d (%s)", job.Status, job.Attempts, job.Err)
+	}
+	if job.Result == nil || *job.Result.Result != "done" || job.Cost != 10_000_000 {
+		t.Errorf("unexpected result %+v cost %v", job.Result, job.Cost)
+	}
+	if !strings.Contains(string(job.Transcript), `"direction":"outbound"`) ||
+		!strings.Contains(string(job.Transcript), `"summarize"`) {
+		t.Errorf("expected transcript of the session, got %s", job.Transcript)
+	}
+	if len(job.Task.Tags) != 1 || job.Task.Tags[0] != "nightly" {
+		t.Errorf("expected tags to be kept, got %v", job.Task.Tags)
+	}
+}
+
+func TestWorkerPoolRetriesCrashedSession(t *testing.T) {
+	script := newFlakyFakeCLI(t, 1, fakeResultLine)
+
+	var budgets []float64
+	var mu sync.Mutex
+	pool := &worker.Pool{
+		Store:      worker.NewMemoryStore(),
+		RetryDelay: 1,
+		ClientFactory: func(opts *claudeagent.Options) (*claudeagent.ClaudeSDKClient, error) {
+			mu.Lock()
+			budgets = append(budgets, opts.MaxBudgetUsd)
+			mu.Unlock()
+
+			return claudeagent.NewClient(opts)
+		},
+	}
+	job := runPool(t, pool, worker.AgentTask{
+		Prompt:       "summarize",
+		Options:      &claudeagent.Options{PathToClaudeCodeExecutable: script, MaxBudgetUsd: 1},
+		MaxBudgetUsd: 0.5,
+	})
+
+	if job.Status != worker.StatusSucceeded || job.Attempts != 2 {
+		t.Fatalf("expected success on the second attempt, got %s after %d (%s)", job.Status, job.Attempts, job.Err)
+	}
+	if len(budgets) != 2 || budgets[0] != 0.5 {
+		t.Errorf("expected attempts capped at the task budget, got %v", budgets)
+	}
+}
+
+func TestWorkerPoolDoesNotRetryTaskErrors(t *testing.T) {
+	errorResult := strings.Replace(
+		strings.Replace(fakeResultLine, `"subtype":"success"`, `"subtype":"error_max_turns","is_error":true`, 1),
+		`,"result":"done"`, "", 1)
+	script := newFakeCLI(t, fakeInitLine, errorResult)
+
+	job := runPool(t, &worker.Pool{Store: worker.NewMemoryStore(), RetryDelay: 1}, worker.AgentTask{
+		Prompt:  "summarize",
+		Options: &claudeagent.Options{PathToClaudeCodeExecutable: script},
+	})
+
+	if job.Status != worker.StatusFailed || job.Attempts != 1 {
+		t.Fatalf("expected one failed attempt, got %s after %d", job.Status, job.Attempts)
+	}
+	if !strings.Contains(job.Err, "error_max_turns") || job.Result == nil {
+		t.Errorf("expected error result to be recorded, got %q %+v", job.Err, job.Result)
+	}
+}
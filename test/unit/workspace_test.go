@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	claudeagent "github.com/connerohnesorge/claude-agent-sdk-go/pkg/claude"
+	"github.com/connerohnesorge/claude-agent-sdk-go/pkg/workspace"
+)
+
+func TestWorkspaceSyncDetectsConflicts(t *testing.T) {
+	ctx := context.Background()
+	store := workspace.NewMemoryStore()
+	for key, content := range map[string]string{
+		"README.md":     "v1",
+		"src/main.go":   "package main",
+		"src/old.go":    "package main // old",
+		"docs/guide.md": "guide",
+	} {
+		if _, err := store.Put(ctx, key, []byte(content), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ws, err := workspace.Materialize(ctx, store, "")
+	if err != nil {
+		t.Fatalf("Materialize failed: %v", err)
+	}
+	dir := ws.Dir()
+	if data, err := os.ReadFile(filepath.Join(dir, "src", "main.go")); err != nil || string(data) != "package main" {
+		t.Fatalf("expected src/main.go to be materialized, got %q (%v)", data, err)
+	}
+
+	// The agent edits two files, adds one and removes one, while someone
+	// else changes the guide remotely
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("README.md", "v2")
+	write("docs/guide.md", "agent's guide")
+	write("src/new.go", "package main // new")
+	if err := os.Remove(filepath.Join(dir, "src", "old.go")); err != nil {
+		t.Fatal(err)
+	}
+	_, version, _ := store.Get(ctx, "docs/guide.md")
+	if _, err := store.Put(ctx, "docs/guide.md", []byte("teammate's guide"), version); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ws.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if !slices.Equal(report.Uploaded, []string{"README.md", "src/new.go"}) ||
+		!slices.Equal(report.Deleted, []string{"src/old.go"}) ||
+		len(report.Conflicts) != 1 || report.Conflicts[0].Key != "docs/guide.md" {
+		t.Errorf("unexpected sync report %+v", report)
+	}
+	if data, _, _ := store.Get(ctx, "docs/guide.md"); string(data) != "teammate's guide" {
+		t.Errorf("expected the remote change to be kept, got %q", data)
+	}
+	if data, _, _ := store.Get(ctx, "README.md"); string(data) != "v2" {
+		t.Errorf("expected README.md to be uploaded, got %q", data)
+	}
+
+	// Synced changes aren't uploaded again
+	report, err = ws.Sync(ctx)
+	if err != nil || len(report.Uploaded) != 0 || len(report.Deleted) != 0 {
+		t.Errorf("expected nothing new to sync, got %+v (%v)", report, err)
+	}
+
+	if err := ws.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary workspace to be removed, got %v", err)
+	}
+}
+
+func TestWorkspaceClientSyncsBeforeResult(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), fakeCLITimeout)
+	defer cancel()
+
+	store := workspace.NewMemoryStore()
+	ws, err := workspace.Materialize(ctx, store, t.TempDir())
+	if err != nil {
+		t.Fatalf("Materialize failed: %v", err)
+	}
+
+	client, err := claudeagent.NewClient(&claudeagent.Options{
+		PathToClaudeCodeExecutable: newFakeCLI(t, fakeInitLine, fakeResultLine),
+		Cwd:                        ws.Dir(),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	var reports []*workspace.SyncReport
+	synced := ws.Client(client, func(report *workspace.SyncReport, err error) {
+		if err != nil {
+			t.Errorf("sync failed: %v", err)
+		}
+		reports = append(reports, report)
+	})
+	t.Cleanup(func() { _ = synced.Close() })
+
+	if err := os.WriteFile(filepath.Join(ws.Dir(), "out.txt"), []byte("done"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := synced.Query(ctx, "write out.txt"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for msg := range synced.ReceiveResponse(ctx) {
+		if _, ok := msg.(*claudeagent.SDKResultMessage); ok && len(reports) != 1 {
+			t.Error("expected the workspace to be synced before the result")
+		}
+	}
+
+	if len(reports) != 1 || !slices.Equal(reports[0].Uploaded, []string{"out.txt"}) {
+		t.Errorf("expected out.txt to be uploaded, got %+v", reports)
+	}
+}